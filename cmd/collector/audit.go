@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// AuditEvent records one command executed by the collector
+type AuditEvent struct {
+	Label    string `json:"label"`
+	Command  string `json:"command"`
+	User     string `json:"user"`
+	Sudo     bool   `json:"sudo"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// AuditLog appends an AuditEvent per line (NDJSON) to a file
+type AuditLog struct {
+	mutex sync.Mutex
+	file  *os.File
+	user  string
+}
+
+// gAuditLog is nil unless the -audit option is set
+var gAuditLog *AuditLog
+
+func newAuditLog(path string) (auditLog *AuditLog, err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		err = fmt.Errorf("failed to open audit log: %v", err)
+		return
+	}
+	auditLog = &AuditLog{file: file}
+	if u, err := user.Current(); err == nil {
+		auditLog.user = u.Username
+	}
+	return
+}
+
+// record appends an event for the command to the audit log
+func (a *AuditLog) record(label string, command string, sudo bool, start time.Time, end time.Time, exitCode int) {
+	if a == nil {
+		return
+	}
+	event := AuditEvent{
+		Label:    label,
+		Command:  command,
+		User:     a.user,
+		Sudo:     sudo,
+		Start:    start.UTC().Format(time.RFC3339Nano),
+		End:      end.UTC().Format(time.RFC3339Nano),
+		ExitCode: exitCode,
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, err = a.file.Write(append(b, '\n')); err != nil {
+		log.Printf("Failed to write audit log: %v", err)
+	}
+}
+
+func (a *AuditLog) close() {
+	if a != nil {
+		a.file.Close()
+	}
+}
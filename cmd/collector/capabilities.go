@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A super-user command can be annotated with the Linux capabilities it needs. With the
+// -capabilities option, the collector runs those commands with only the annotated
+// capabilities instead of full root privileges:
+//   - when running as root, the capabilities bounding set is reduced with setpriv
+//   - when running as a regular user with the capabilities in its permitted set, e.g.,
+//     granted to the collector with setcap, they are passed to the command as ambient
+//     capabilities and sudo isn't used
+//   - otherwise, setpriv is run with sudo to reduce the bounding set, sudo runs setpriv
+//     directly so the sudoers rule can be limited to it
+//
+// Commands without annotations run with sudo.
+
+// how a command was run, reported in the collector's output
+const (
+	privilegesUser         = "user"              // not elevated
+	privilegesRoot         = "root"              // the collector is running as root
+	privilegesSudo         = "sudo"              // elevated with sudo
+	privilegesCapabilities = "capabilities"      // only the annotated capabilities
+	privilegesSudoCaps     = "sudo+capabilities" // only the annotated capabilities, with sudo
+)
+
+// gUseCapabilities is set by the -capabilities option
+var gUseCapabilities bool
+
+// capabilityNumbers are the capabilities that can be used in command annotations, see
+// capabilities(7)
+var capabilityNumbers = map[string]uint{
+	"cap_dac_override":    1,
+	"cap_dac_read_search": 2,
+	"cap_net_admin":       12,
+	"cap_net_raw":         13,
+	"cap_sys_rawio":       17,
+	"cap_sys_ptrace":      19,
+	"cap_sys_admin":       21,
+	"cap_syslog":          34,
+	"cap_perfmon":         38,
+	"cap_bpf":             39,
+}
+
+// parseCapabilities parses the comma-separated list of capabilities in a command's
+// annotation
+func parseCapabilities(annotation string) (capabilities []string, err error) {
+	for _, field := range strings.Split(annotation, ",") {
+		capability := strings.ToLower(strings.TrimSpace(field))
+		if capability == "" {
+			continue
+		}
+		if _, ok := capabilityNumbers[capability]; !ok {
+			err = fmt.Errorf("unsupported capability: %s", capability)
+			return
+		}
+		capabilities = append(capabilities, capability)
+	}
+	return
+}
+
+// parsePermittedCapabilities returns the permitted capabilities mask (CapPrm) from the
+// contents of /proc/<pid>/status
+func parsePermittedCapabilities(status []byte) (mask uint64, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "CapPrm:"); found {
+			return strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		}
+	}
+	err = fmt.Errorf("CapPrm not found")
+	return
+}
+
+// hasCapabilities is true if all of the capabilities are in the mask
+func hasCapabilities(mask uint64, capabilities []string) bool {
+	for _, capability := range capabilities {
+		if mask&(1<<capabilityNumbers[capability]) == 0 {
+			return false
+		}
+	}
+	return true
+}
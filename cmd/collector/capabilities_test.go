@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"testing"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	capabilities, err := parseCapabilities("cap_sys_rawio, CAP_DAC_OVERRIDE,")
+	if err != nil || len(capabilities) != 2 || capabilities[1] != "cap_dac_override" {
+		t.Errorf("unexpected result: %v, %v", capabilities, err)
+	}
+	if _, err := parseCapabilities("cap_sys_rawio,cap_everything"); err == nil {
+		t.Error("unsupported capability accepted")
+	}
+	if capabilities, err := parseCapabilities(""); err != nil || len(capabilities) != 0 {
+		t.Errorf("unexpected result: %v, %v", capabilities, err)
+	}
+}
+
+func TestPermittedCapabilities(t *testing.T) {
+	status := []byte("Name:\tcollector\nCapInh:\t0000000000000000\nCapPrm:\t0000000400020000\nCapEff:\t0000000000000000\n")
+	mask, err := parsePermittedCapabilities(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasCapabilities(mask, []string{"cap_sys_rawio", "cap_syslog"}) {
+		t.Errorf("capabilities not found in %x", mask)
+	}
+	if hasCapabilities(mask, []string{"cap_sys_rawio", "cap_dac_override"}) {
+		t.Errorf("unexpected capabilities in %x", mask)
+	}
+	if _, err := parsePermittedCapabilities([]byte("Name:\tcollector\n")); err == nil {
+		t.Error("missing CapPrm accepted")
+	}
+}
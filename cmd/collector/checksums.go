@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The collector reports the version and SHA-256 hash of itself and of each bundled tool
+// so that results can be tied to the exact tools that produced them, for audit and
+// reproduction. The bundled tools and their versions are listed in the versions file,
+// written when the tools are built, one tool per line: the path relative to the bin
+// directory and the version.
+
+// toolChecksumsLabel labels the result that lists the tools, their versions, and hashes
+const toolChecksumsLabel = "tool checksums"
+
+// toolVersionsFile lists the bundled tools and their versions, in the bin directory
+const toolVersionsFile = "versions.txt"
+
+// readToolVersions reads the versions file, the tools are returned in file order
+func readToolVersions(path string) (tools []string, versions map[string]string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	versions = make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if _, ok := versions[fields[0]]; !ok {
+			tools = append(tools, fields[0])
+		}
+		versions[fields[0]] = strings.Join(fields[1:], " ")
+	}
+	err = scanner.Err()
+	return
+}
+
+// getToolChecksumLines returns a line per tool: the name, version, and SHA-256 hash. The
+// collector is first. Tools that aren't found are reported as missing.
+func getToolChecksumLines(collectorPath string, binPath string) (lines []string) {
+	hash, err := util.FileSHA256(collectorPath)
+	if err != nil {
+		log.Printf("failed to hash collector: %v", err)
+		hash = "unknown"
+	}
+	lines = append(lines, fmt.Sprintf("collector %s %s", gVersion, hash))
+	tools, versions, err := readToolVersions(filepath.Join(binPath, toolVersionsFile))
+	if err != nil {
+		log.Printf("tool versions not available: %v", err)
+		return
+	}
+	for _, tool := range tools {
+		hash, err := util.FileSHA256(filepath.Join(binPath, tool))
+		if err != nil {
+			hash = "missing"
+		}
+		version := versions[tool]
+		if version == "" {
+			version = "unknown"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", tool, version, hash))
+	}
+	return
+}
+
+// getToolChecksumsResult returns the result that lists the collector and bundled tools
+func getToolChecksumsResult(binPath string) (result ResultType) {
+	collectorPath, err := os.Executable()
+	if err != nil {
+		collectorPath = os.Args[0]
+	}
+	result = ResultType{
+		"label":      toolChecksumsLabel,
+		"command":    "",
+		"superuser":  "false",
+		"privileges": privilegesUser,
+		"stdout":     strings.Join(getToolChecksumLines(collectorPath, binPath), "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	}
+	return
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetToolChecksumLines(t *testing.T) {
+	binPath := t.TempDir()
+	writeFile := func(name string, content string) {
+		path := filepath.Join(binPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("collector", "")
+	writeFile("fio", "abc")
+	writeFile("async-profiler/profiler.sh", "abc")
+	writeFile(toolVersionsFile, "# bundled tools\nfio fio-3.36\nasync-profiler/profiler.sh 2.9\nturbostat\nmlc v3.11\n")
+	lines := getToolChecksumLines(filepath.Join(binPath, "collector"), binPath)
+	expected := []string{
+		"collector dev e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"fio fio-3.36 ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"async-profiler/profiler.sh 2.9 ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"turbostat unknown missing",
+		"mlc v3.11 missing",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %d: %v", len(expected), len(lines), lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line %d: expected '%s', got '%s'", i, expected[i], lines[i])
+		}
+	}
+	// without the versions file, only the collector is reported
+	if err := os.Remove(filepath.Join(binPath, toolVersionsFile)); err != nil {
+		t.Fatal(err)
+	}
+	if lines := getToolChecksumLines(filepath.Join(binPath, "collector"), binPath); len(lines) != 1 {
+		t.Errorf("expected only the collector, got: %v", lines)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/intel/svr-info/internal/target"
 )
@@ -31,7 +33,35 @@ func getUserPath() string {
 	return strings.Join(verifiedPaths, ":")
 }
 
-func runCommand(label string, command string, superuser bool, superuserPassword string, binPath string, timeout int) (stdout string, stderr string, exitCode int, err error) {
+// getPrivileges returns how the command will run: as the user, as root, with sudo, or
+// with only the capabilities it needs
+func getPrivileges(superuser bool, capabilities []string) string {
+	if !superuser {
+		return privilegesUser
+	}
+	if gUseCapabilities && len(capabilities) > 0 {
+		if os.Geteuid() != 0 {
+			if status, err := os.ReadFile("/proc/self/status"); err == nil {
+				if mask, err := parsePermittedCapabilities(status); err == nil && hasCapabilities(mask, capabilities) {
+					return privilegesCapabilities
+				}
+			}
+		}
+		if _, err := exec.LookPath("setpriv"); err == nil {
+			if os.Geteuid() == 0 {
+				return privilegesCapabilities
+			}
+			return privilegesSudoCaps
+		}
+		log.Printf("setpriv not found, running command with full privileges: %s", strings.Join(capabilities, ","))
+	}
+	if os.Geteuid() == 0 {
+		return privilegesRoot
+	}
+	return privilegesSudo
+}
+
+func runCommand(label string, command string, privileges string, capabilities []string, superuserPassword string, binPath string, timeout int) (stdout string, stderr string, exitCode int, err error) {
 	// explicitly set PATH by pre-pending to command
 	cmdWithPath := command
 	if binPath != "" {
@@ -39,12 +69,52 @@ func runCommand(label string, command string, superuser bool, superuserPassword
 		newPath := fmt.Sprintf("%s%c%s", binPath, os.PathListSeparator, path)
 		cmdWithPath = fmt.Sprintf("PATH=\"%s\"\n%s", newPath, command)
 	}
-	if superuser {
+	if gGentle {
+		cmdWithPath = gentlePrefix + cmdWithPath
+	}
+	switch privileges {
+	case privilegesCapabilities, privilegesSudoCaps:
+		return runCapabilitiesCommand(cmdWithPath, capabilities, privileges, superuserPassword, timeout)
+	case privilegesRoot, privilegesSudo:
 		return runSuperUserCommand(cmdWithPath, superuserPassword, timeout)
 	}
 	return runRegularUserCommand(cmdWithPath, timeout)
 }
 
+// runCapabilitiesCommand runs the command with only the capabilities. If the collector
+// has the capabilities, they are raised into the command's ambient set. Otherwise, the
+// bounding set is reduced to the capabilities with setpriv, run with sudo if the
+// collector isn't running as root. A sudoers rule that allows only setpriv is still
+// root-equivalent, only the capabilities granted with setcap avoid sudo.
+func runCapabilitiesCommand(command string, capabilities []string, privileges string, sudoPassword string, timeout int) (stdout string, stderr string, exitCode int, err error) {
+	log.Printf("runCapabilitiesCommand Start (%s): %s", strings.Join(capabilities, ","), command)
+	defer log.Printf("runCapabilitiesCommand Finish: %s", command)
+	if os.Geteuid() != 0 && privileges == privilegesCapabilities {
+		cmd := exec.Command("bash", "-c", command)
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+		for _, capability := range capabilities {
+			cmd.SysProcAttr.AmbientCaps = append(cmd.SysProcAttr.AmbientCaps, uintptr(capabilityNumbers[capability]))
+		}
+		return target.RunLocalCommandWithTimeout(cmd, timeout)
+	}
+	// setpriv names capabilities without the cap_ prefix
+	caps := "-all"
+	for _, capability := range capabilities {
+		caps += ",+" + strings.TrimPrefix(capability, "cap_")
+	}
+	setpriv := []string{"setpriv", "--bounding-set", caps, "--inh-caps", "-all", "bash", "-c", command}
+	if os.Geteuid() == 0 {
+		return target.RunLocalCommandWithTimeout(exec.Command(setpriv[0], setpriv[1:]...), timeout)
+	}
+	// the environment isn't preserved, i.e., no -E, which needs the SETENV tag in the
+	// sudoers rule, the command sets its PATH
+	if sudoPassword != "" {
+		cmd := exec.Command("sudo", append([]string{"-kS", "-p", ""}, setpriv...)...)
+		return target.RunLocalCommandWithInputWithTimeout(cmd, sudoPassword+"\n", timeout)
+	}
+	return target.RunLocalCommandWithTimeout(exec.Command("sudo", append([]string{"-n"}, setpriv...)...), timeout)
+}
+
 func runRegularUserCommand(command string, timeout int) (stdout string, stderr string, exitCode int, err error) {
 	log.Printf("runRegularUserCommand Start: %s", command)
 	defer log.Printf("runRegularUserCommand Finish: %s", command)
@@ -81,7 +151,10 @@ func installMods(mods string, sudoPassword string) (installedMods []string) {
 		modList := strings.Split(mods, ",")
 		for _, mod := range modList {
 			log.Printf("Installing kernel module: %s", mod)
-			_, _, _, err := runSuperUserCommand(fmt.Sprintf("modprobe --first-time %s > /dev/null 2>&1", mod), sudoPassword, 10)
+			command := fmt.Sprintf("modprobe --first-time %s > /dev/null 2>&1", mod)
+			start := time.Now()
+			_, _, exitCode, err := runSuperUserCommand(command, sudoPassword, 10)
+			gAuditLog.record("install kernel module", command, os.Geteuid() != 0, start, time.Now(), exitCode)
 			if err != nil {
 				log.Printf("Kernel module %s already installed or problem installing: %v", mod, err)
 				continue
@@ -96,7 +169,11 @@ func installMods(mods string, sudoPassword string) (installedMods []string) {
 func uninstallMods(modList []string, sudoPassword string) (err error) {
 	for _, mod := range modList {
 		log.Printf("Uninstalling kernel module %s", mod)
-		_, _, _, err = runSuperUserCommand(fmt.Sprintf("modprobe -r %s", mod), sudoPassword, 10)
+		command := fmt.Sprintf("modprobe -r %s", mod)
+		start := time.Now()
+		var exitCode int
+		_, _, exitCode, err = runSuperUserCommand(command, sudoPassword, 10)
+		gAuditLog.record("uninstall kernel module", command, os.Geteuid() != 0, start, time.Now(), exitCode)
 		if err != nil {
 			log.Printf("Error uninstalling kernel module %s: %v", mod, err)
 			continue
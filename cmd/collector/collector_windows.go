@@ -12,8 +12,15 @@ import (
 	"github.com/intel/svr-info/internal/target"
 )
 
-func runCommand(label string, command string, superuser bool, sudoPassword string, binPath string, timeout int) (stdout string, stderr string, exitCode int, err error) {
+func getPrivileges(superuser bool, capabilities []string) string {
 	if superuser {
+		return privilegesSudo
+	}
+	return privilegesUser
+}
+
+func runCommand(label string, command string, privileges string, capabilities []string, sudoPassword string, binPath string, timeout int) (stdout string, stderr string, exitCode int, err error) {
+	if privileges != privilegesUser {
 		return runSuperUserCommand(command, sudoPassword, timeout)
 	}
 	return runRegularUserCommand(command, timeout)
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/cpu"
+)
+
+// PlatformFacts are the properties of the target that command conditions are
+// evaluated against
+type PlatformFacts struct {
+	CPUVendor         string
+	Microarchitecture string
+	Architecture      string
+	NVMe              bool
+	Virtualized       bool
+}
+
+// getPlatformFacts gathers the platform properties from the local system
+func getPlatformFacts() (facts PlatformFacts) {
+	var family, model, stepping string
+	cpuinfo, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		log.Printf("failed to read /proc/cpuinfo: %v", err)
+	} else {
+		facts.CPUVendor, family, model, stepping, facts.Virtualized = parseCPUInfo(cpuinfo)
+	}
+	if family != "" && model != "" {
+		facts.Microarchitecture = getMicroarchitecture(family, model, stepping)
+	}
+	facts.Architecture = runtime.GOARCH
+	if out, err := exec.Command("uname", "-m").Output(); err == nil {
+		facts.Architecture = strings.TrimSpace(string(out))
+	}
+	if entries, err := os.ReadDir("/sys/class/nvme"); err == nil && len(entries) > 0 {
+		facts.NVMe = true
+	}
+	log.Printf("Platform: vendor=%s, microarchitecture=%s, architecture=%s, nvme=%t, virtualized=%t",
+		facts.CPUVendor, facts.Microarchitecture, facts.Architecture, facts.NVMe, facts.Virtualized)
+	return
+}
+
+// parseCPUInfo extracts the fields of interest from the first processor in /proc/cpuinfo
+func parseCPUInfo(cpuinfo []byte) (vendor, family, model, stepping string, hypervisor bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(cpuinfo))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" { // end of first processor
+			break
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "vendor_id":
+			vendor = value
+		case "cpu family":
+			family = value
+		case "model":
+			model = value
+		case "stepping":
+			stepping = value
+		case "flags":
+			for _, flag := range strings.Fields(value) {
+				if flag == "hypervisor" {
+					hypervisor = true
+				}
+			}
+		}
+	}
+	return
+}
+
+// getMicroarchitecture returns the CPU's microarchitecture, e.g., SPR. Variants that
+// can't be identified from family and model alone, e.g., GNR_X2, are reported by their
+// base name.
+func getMicroarchitecture(family, model, stepping string) (uarch string) {
+	c, err := cpu.NewCPU()
+	if err != nil {
+		return
+	}
+	uarch, err = c.GetMicroArchitecture(family, model, stepping, "", "", "")
+	if err != nil && family == "6" && model == "173" {
+		uarch = "GNR"
+	}
+	return
+}
+
+// conditionsMet returns true if the command's conditions are satisfied by the platform. When
+// a condition isn't met, the reason is returned.
+func conditionsMet(conditions *commandfile.Conditions, facts PlatformFacts) (met bool, reason string) {
+	if conditions == nil {
+		met = true
+		return
+	}
+	if conditions.CPUVendor != "" && conditions.CPUVendor != facts.CPUVendor {
+		reason = fmt.Sprintf("cpu_vendor is %s, requires %s", facts.CPUVendor, conditions.CPUVendor)
+		return
+	}
+	if conditions.Microarchitecture != "" {
+		re, err := regexp.Compile(conditions.Microarchitecture)
+		if err != nil {
+			reason = fmt.Sprintf("invalid microarchitecture expression: %v", err)
+			return
+		}
+		if facts.Microarchitecture == "" || !re.MatchString(facts.Microarchitecture) {
+			reason = fmt.Sprintf("microarchitecture is %s, requires %s", facts.Microarchitecture, conditions.Microarchitecture)
+			return
+		}
+	}
+	if conditions.Architecture != "" && conditions.Architecture != facts.Architecture {
+		reason = fmt.Sprintf("architecture is %s, requires %s", facts.Architecture, conditions.Architecture)
+		return
+	}
+	if conditions.NVMe != nil && *conditions.NVMe != facts.NVMe {
+		reason = fmt.Sprintf("nvme is %t, requires %t", facts.NVMe, *conditions.NVMe)
+		return
+	}
+	if conditions.Virtualized != nil && *conditions.Virtualized != facts.Virtualized {
+		reason = fmt.Sprintf("virtualized is %t, requires %t", facts.Virtualized, *conditions.Virtualized)
+		return
+	}
+	met = true
+	return
+}
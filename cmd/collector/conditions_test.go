@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseCPUInfo(t *testing.T) {
+	cpuinfo := []byte("processor\t: 0\nvendor_id\t: GenuineIntel\ncpu family\t: 6\nmodel\t\t: 143\nmodel name\t: Intel(R) Xeon(R) Platinum 8480+\nstepping\t: 8\nflags\t\t: fpu vme hypervisor avx512f\n\nprocessor\t: 1\nvendor_id\t: AuthenticAMD\n")
+	vendor, family, model, stepping, hypervisor := parseCPUInfo(cpuinfo)
+	if vendor != "GenuineIntel" || family != "6" || model != "143" || stepping != "8" || !hypervisor {
+		t.Errorf("unexpected result: %s %s %s %s %t", vendor, family, model, stepping, hypervisor)
+	}
+	if uarch := getMicroarchitecture(family, model, stepping); uarch != "SPR" {
+		t.Errorf("unexpected microarchitecture: %s", uarch)
+	}
+}
+
+func TestConditionsMet(t *testing.T) {
+	facts := PlatformFacts{
+		CPUVendor:         "GenuineIntel",
+		Microarchitecture: "EMR_XCC",
+		Architecture:      "x86_64",
+		NVMe:              false,
+		Virtualized:       true,
+	}
+	tests := []struct {
+		yaml string
+		met  bool
+	}{
+		{"", true},
+		{"cpu_vendor: GenuineIntel", true},
+		{"cpu_vendor: AuthenticAMD", false},
+		{"microarchitecture: ^(SPR|EMR|GNR)", true},
+		{"microarchitecture: ^(ICX|SKX)", false},
+		{"architecture: aarch64", false},
+		{"nvme: true", false},
+		{"nvme: false", true},
+		{"virtualized: false", false},
+		{"cpu_vendor: GenuineIntel\nvirtualized: true", true},
+	}
+	for _, test := range tests {
+		var conditions *commandfile.Conditions
+		if test.yaml != "" {
+			conditions = &commandfile.Conditions{}
+			if err := yaml.Unmarshal([]byte(test.yaml), conditions); err != nil {
+				t.Fatal(err)
+			}
+		}
+		met, reason := conditionsMet(conditions, facts)
+		if met != test.met {
+			t.Errorf("%q: expected %t, got %t (%s)", test.yaml, test.met, met, reason)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+// In a container, e.g., an LXC system container, commands that read the host's hardware
+// through devices like /dev/cpu/*/msr or /dev/mem either fail or report the container's
+// restricted view as if it were the host's. The commands are annotated with the devices
+// they read. In a container, they are run only if the devices are accessible, e.g., in a
+// privileged container, so host information is inherited when it's available. The
+// container type and the skipped commands are reported in the containerLabel result.
+
+// containerLabel labels the result that identifies the container and lists the commands
+// skipped because the host's hardware isn't accessible
+const containerLabel = "container"
+
+var reCgroupContainer = regexp.MustCompile(`/(lxc|lxc\.payload|docker|kubepods|libpod|machine\.slice)[/.]`)
+
+// detectContainer returns the type of container the collector is running in, e.g., lxc,
+// or an empty string if it isn't running in a container. Paths are relative to root.
+func detectContainer(root string) (containerType string) {
+	// set by systemd and by most container managers
+	if content, err := os.ReadFile(filepath.Join(root, "/run/systemd/container")); err == nil {
+		if containerType = strings.TrimSpace(string(content)); containerType != "" {
+			return
+		}
+	}
+	// the container variable in init's environment, readable by root only
+	if content, err := os.ReadFile(filepath.Join(root, "/proc/1/environ")); err == nil {
+		for _, variable := range strings.Split(string(content), "\x00") {
+			if value, found := strings.CutPrefix(variable, "container="); found && value != "" {
+				return value
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "/.dockerenv")); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat(filepath.Join(root, "/run/.containerenv")); err == nil {
+		return "podman"
+	}
+	if _, err := os.Stat(filepath.Join(root, "/dev/lxd/sock")); err == nil {
+		return "lxc"
+	}
+	if content, err := os.ReadFile(filepath.Join(root, "/proc/1/cgroup")); err == nil {
+		if match := reCgroupContainer.FindStringSubmatch(string(content)); match != nil {
+			switch match[1] {
+			case "lxc", "lxc.payload":
+				return "lxc"
+			case "libpod":
+				return "podman"
+			case "machine.slice":
+				return "systemd-nspawn"
+			default:
+				return match[1]
+			}
+		}
+	}
+	return
+}
+
+// deviceAccessible is true if a path matching the pattern exists
+func deviceAccessible(pattern string) bool {
+	matches, err := filepath.Glob(pattern)
+	return err == nil && len(matches) > 0
+}
+
+// filterContainerCommands removes the commands that read host devices that aren't
+// accessible. The reasons the commands were removed are returned by command label.
+func filterContainerCommands(commands []commandfile.Command, accessible func(string) bool) (filtered []commandfile.Command, skipped map[string]string) {
+	skipped = make(map[string]string)
+	for _, cmd := range commands {
+		if !cmd.Run || strings.TrimSpace(cmd.Hardware) == "" {
+			filtered = append(filtered, cmd)
+			continue
+		}
+		var missing []string
+		for _, device := range strings.Split(cmd.Hardware, ",") {
+			device = strings.TrimSpace(device)
+			if device == "" {
+				continue
+			}
+			found := false
+			for _, alternative := range strings.Split(device, "|") {
+				if accessible(strings.TrimSpace(alternative)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				missing = append(missing, device)
+			}
+		}
+		if len(missing) > 0 {
+			skipped[cmd.Label] = "host hardware not accessible: " + strings.Join(missing, ",")
+			log.Printf("Skipping command in container: %s, %s", cmd.Label, skipped[cmd.Label])
+			continue
+		}
+		filtered = append(filtered, cmd)
+	}
+	return
+}
+
+// getContainerResult returns the result that identifies the container, on the first
+// line, followed by the skipped commands
+func getContainerResult(containerType string, skipped map[string]string) (result ResultType) {
+	var labels []string
+	for label := range skipped {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	lines := []string{containerType}
+	for _, label := range labels {
+		lines = append(lines, fmt.Sprintf("%s: %s", label, skipped[label]))
+	}
+	result = ResultType{
+		"label":      containerLabel,
+		"command":    "",
+		"superuser":  "false",
+		"privileges": privilegesUser,
+		"stdout":     strings.Join(lines, "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	}
+	return
+}
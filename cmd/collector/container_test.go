@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+func TestDetectContainer(t *testing.T) {
+	writeFile := func(root string, name string, content string) {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root := t.TempDir()
+	if containerType := detectContainer(root); containerType != "" {
+		t.Errorf("detected container on host: %s", containerType)
+	}
+	writeFile(root, "/proc/1/cgroup", "0::/lxc.payload.web1/init.scope\n")
+	if containerType := detectContainer(root); containerType != "lxc" {
+		t.Errorf("expected lxc from cgroup, got: %s", containerType)
+	}
+	writeFile(root, "/proc/1/environ", "PATH=/usr/bin\x00container=lxc-libvirt\x00")
+	if containerType := detectContainer(root); containerType != "lxc-libvirt" {
+		t.Errorf("expected lxc-libvirt from environ, got: %s", containerType)
+	}
+	writeFile(root, "/run/systemd/container", "lxc\n")
+	if containerType := detectContainer(root); containerType != "lxc" {
+		t.Errorf("expected lxc from systemd, got: %s", containerType)
+	}
+}
+
+func TestFilterContainerCommands(t *testing.T) {
+	commands := []commandfile.Command{
+		{Label: "date", Command: "date -u", Run: true},
+		{Label: "rdmsr", Command: "msrread 0x1a4", Hardware: "/dev/cpu/*/msr", Run: true},
+		{Label: "dmidecode", Command: "dmidecode", Hardware: "/sys/firmware/dmi/tables/DMI|/dev/mem", Run: true},
+		{Label: "ipmitool", Command: "ipmitool sel elist", Hardware: "/dev/ipmi*|/dev/ipmi/*", Run: true},
+		{Label: "cpuid", Command: "cpuid", Hardware: "/dev/cpu/*/cpuid", Run: false},
+	}
+	accessible := func(pattern string) bool { return pattern == "/dev/mem" || pattern == "/dev/cpu/*/msr" }
+	filtered, skipped := filterContainerCommands(commands, accessible)
+	if len(filtered) != 4 || filtered[0].Label != "date" || filtered[1].Label != "rdmsr" || filtered[2].Label != "dmidecode" || filtered[3].Label != "cpuid" {
+		t.Fatalf("unexpected commands: %v", filtered)
+	}
+	if skipped["ipmitool"] != "host hardware not accessible: /dev/ipmi*|/dev/ipmi/*" || len(skipped) != 1 {
+		t.Errorf("unexpected skipped commands: %v", skipped)
+	}
+	result := getContainerResult("lxc", skipped)
+	if result["label"] != containerLabel || result["stdout"] != "lxc\nipmitool: host hardware not accessible: /dev/ipmi*|/dev/ipmi/*" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
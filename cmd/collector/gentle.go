@@ -0,0 +1,24 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"time"
+)
+
+// In gentle mode, for hosts serving latency-critical traffic, the collector runs the
+// commands one at a time, at the lowest CPU priority and in the idle I/O scheduling class,
+// and waits gentlePacing between them. The orchestrator doesn't enable the commands that
+// are annotated as intensive, e.g., the benchmarks.
+
+// gGentle is set by the -gentle option
+var gGentle bool
+
+// gentlePacing is the delay between commands in gentle mode
+const gentlePacing = 1 * time.Second
+
+// gentlePrefix lowers the priority of the command's shell, and so of the processes it
+// starts, in gentle mode
+const gentlePrefix = "renice -n 19 -p $$ >/dev/null 2>&1; ionice -c 3 -p $$ >/dev/null 2>&1\n"
@@ -13,8 +13,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/rawdata"
 	"github.com/intel/svr-info/internal/util"
 	"gopkg.in/yaml.v2"
 )
@@ -43,6 +45,8 @@ func showUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  [SUDO_PASSWORD=*********] collector < file[.yaml]")
 	fmt.Println("  [SUDO_PASSWORD=*********] collector [OPTION...] file[.yaml]")
+	fmt.Println("  cat file[.yaml] | [SUDO_PASSWORD=*********] collector -ndjson")
+	fmt.Println("  [SUDO_PASSWORD=*********] collector -gob file[.yaml] > host.raw.gob")
 	fmt.Println("Options:")
 	flag.PrintDefaults()
 	fmt.Println(
@@ -59,9 +63,24 @@ func showUsage() {
       command - will be executed by bash:
   Optional command attributes:
       superuser: bool indicates need for elevated privilege (default: false)
+      capabilities: comma separated list of the Linux capabilities a superuser command needs,
+          e.g., cap_sys_rawio,cap_dac_override, used instead of root with the -capabilities option
+      side_effects: comma separated list of the command's side effects, e.g., disk, msr, settings,
+          commands with side effects are skipped with the -read_only option
+      hardware: comma separated list of the host devices the command reads, alternatives separated by |,
+          e.g., /dev/cpu/*/msr, when running in a container the command is skipped if a device isn't accessible
       run: bool indicates if command will be run (default: false)
       modprobe: comma separated list of kernel modules required to run command
-      parallel: bool indicates if command can be run in parallel with other commands (default: false)`)
+      parallel: bool indicates if command can be run in parallel with other commands (default: false)
+      timeout: the maximum number of seconds to wait for the command, overrides command_timeout
+      intensive: bool indicates the command loads the CPU, disks, or network, e.g., a benchmark,
+          it isn't enabled by svr-info for hosts collected with the -gentle option (default: false)
+      conditions: command will be run only if all of the specified conditions are met by the platform
+          cpu_vendor: string, e.g., GenuineIntel, AuthenticAMD
+          microarchitecture: regular expression, e.g., ^(SPR|EMR|GNR)
+          architecture: string, e.g., x86_64, aarch64
+          nvme: bool indicates if NVMe devices are present
+          virtualized: bool indicates if running in a virtual machine`)
 	fmt.Println(
 		`YAML Example:
     arguments:
@@ -75,7 +94,13 @@ func showUsage() {
     - cpuid -1:
         command: cpuid -1 | grep family
         modprobe: cpuid
-        parallel: true`)
+        parallel: true
+    - nvme list:
+        command: nvme list
+        superuser: true
+        capabilities: cap_sys_admin,cap_dac_override
+        conditions:
+            nvme: true`)
 }
 
 func printResult(out io.Writer, result ResultType, firstCommand bool) error {
@@ -91,6 +116,19 @@ func printResult(out io.Writer, result ResultType, firstCommand bool) error {
 	return nil
 }
 
+// printResultNDJSON prints the result on a single line, i.e., newline delimited JSON, so
+// that consumers can process each result as the command completes
+func printResultNDJSON(out io.Writer, result ResultType, firstCommand bool) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s\n", string(b))
+	return nil
+}
+
+type resultPrinter func(out io.Writer, result ResultType, firstCommand bool) error
+
 func runConfigCommand(cmd commandfile.Command, args commandfile.Arguments, sudo string, ch chan ResultType) {
 	result := make(ResultType)
 	result["label"] = cmd.Label
@@ -100,7 +138,21 @@ func runConfigCommand(cmd commandfile.Command, args commandfile.Arguments, sudo
 	} else {
 		result["superuser"] = "false"
 	}
-	stdout, stderr, exitCode, err := runCommand(cmd.Label, cmd.Command, cmd.Superuser, sudo, args.Binpath, args.Timeout)
+	capabilities, err := parseCapabilities(cmd.Capabilities)
+	if err != nil {
+		log.Printf("Error: %s: %v", cmd.Label, err)
+		capabilities = nil
+	}
+	privileges := getPrivileges(cmd.Superuser, capabilities)
+	result["privileges"] = privileges
+	if privileges == privilegesCapabilities || privileges == privilegesSudoCaps {
+		result["capabilities"] = strings.Join(capabilities, ",")
+	}
+	timeout := max(args.Timeout, cmd.Timeout)
+	start := time.Now()
+	stdout, stderr, exitCode, err := runCommand(cmd.Label, cmd.Command, privileges, capabilities, sudo, args.Binpath, timeout)
+	gAuditLog.record(cmd.Label, cmd.Command, privileges == privilegesSudo || privileges == privilegesSudoCaps, start, time.Now(), exitCode)
+	gProgressLog.done(cmd.Label, time.Since(start))
 	if err != nil {
 		log.Printf("Error: %v Stderr: %s, Exit Code: %d", err, stderr, exitCode)
 	}
@@ -110,7 +162,39 @@ func runConfigCommand(cmd commandfile.Command, args commandfile.Arguments, sudo
 	ch <- result
 }
 
-func runConfigCommands(config *RunConfiguration, out io.Writer) error {
+// filterCommands removes the commands whose conditions aren't met by the platform
+func filterCommands(commands []commandfile.Command) (filtered []commandfile.Command) {
+	var facts PlatformFacts
+	var haveFacts bool
+	for _, cmd := range commands {
+		if cmd.Conditions != nil && cmd.Run {
+			// only gather platform facts when needed
+			if !haveFacts {
+				facts = getPlatformFacts()
+				haveFacts = true
+			}
+			if met, reason := conditionsMet(cmd.Conditions, facts); !met {
+				log.Printf("Skipping command, condition not met: %s, %s", cmd.Label, reason)
+				continue
+			}
+		}
+		filtered = append(filtered, cmd)
+	}
+	return
+}
+
+func runConfigCommands(config *RunConfiguration, out io.Writer, print resultPrinter) error {
+	config.cmdFile.Commands = filterCommands(config.cmdFile.Commands)
+	var readOnlySkipped map[string]string
+	if gReadOnly {
+		config.cmdFile.Commands, readOnlySkipped = filterReadOnlyCommands(config.cmdFile.Commands, moduleLoaded)
+	}
+	var containerSkipped map[string]string
+	containerType := detectContainer("/")
+	if containerType != "" {
+		log.Printf("Running in container: %s", containerType)
+		config.cmdFile.Commands, containerSkipped = filterContainerCommands(config.cmdFile.Commands, deviceAccessible)
+	}
 	// build a unique list of loadable kernel modules that must be installed
 	install := make(map[string]int)
 	for _, cmd := range config.cmdFile.Commands {
@@ -141,13 +225,22 @@ func runConfigCommands(config *RunConfiguration, out io.Writer) error {
 			}
 		}
 	}
+	if gGentle {
+		// one at a time, the parallel commands after the serial commands
+		serialCommands = append(serialCommands, parallelCommands...)
+		parallelCommands = nil
+	}
+	gProgressLog.commands(serialCommands, parallelCommands)
 	// run serial commands one at a time
 	// we run these first because they, typically, are more time sensitive...especially for profiling
 	ch := make(chan ResultType)
 	for idx, cmd := range serialCommands {
+		if gGentle && idx > 0 {
+			time.Sleep(gentlePacing)
+		}
 		go runConfigCommand(cmd, config.cmdFile.Args, config.sudo, ch)
 		result := <-ch
-		err := printResult(out, result, idx == 0)
+		err := print(out, result, idx == 0)
 		if err != nil {
 			log.Printf("Error: %v", err)
 			return err
@@ -159,21 +252,51 @@ func runConfigCommands(config *RunConfiguration, out io.Writer) error {
 	}
 	for idx := range parallelCommands {
 		result := <-ch
-		err := printResult(out, result, (idx+len(serialCommands)) == 0)
+		err := print(out, result, (idx+len(serialCommands)) == 0)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return err
+		}
+	}
+	if gReadOnly {
+		err := print(out, getReadOnlyResult(readOnlySkipped), len(serialCommands)+len(parallelCommands) == 0)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return err
+		}
+	}
+	if containerType != "" {
+		err := print(out, getContainerResult(containerType, containerSkipped), len(serialCommands)+len(parallelCommands) == 0 && !gReadOnly)
 		if err != nil {
 			log.Printf("Error: %v", err)
 			return err
 		}
 	}
+	err := print(out, getToolChecksumsResult(config.cmdFile.Args.Binpath), len(serialCommands)+len(parallelCommands) == 0 && !gReadOnly && containerType == "")
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return err
+	}
 	return nil
 }
 
 func mainReturnWithCode() int {
 	var showHelp bool
 	var showVersion bool
+	var ndjson bool
+	var gobOutput bool
+	var auditPath string
+	var progressPath string
 	flag.Usage = func() { showUsage() } // override default usage output
 	flag.BoolVar(&showHelp, "h", false, "Print this usage message.")
 	flag.BoolVar(&showVersion, "v", false, "Print program version.")
+	flag.BoolVar(&ndjson, "ndjson", false, "Stream one JSON object per line as each command completes. Logs to stderr and does not write files to the working directory.")
+	flag.BoolVar(&gobOutput, "gob", false, "Write the results in the compact, gzip compressed gob format instead of JSON, for large collections.")
+	flag.BoolVar(&gUseCapabilities, "capabilities", false, "Run super-user commands that are annotated with capabilities with only those capabilities, using setpriv when running as root or the collector's permitted capabilities (see setcap) otherwise, instead of full root privileges.")
+	flag.BoolVar(&gReadOnly, "read_only", false, "Don't run commands that are annotated with side effects, e.g., writing to disk or MSRs, and don't load kernel modules. Commands that require a kernel module that isn't loaded are skipped.")
+	flag.BoolVar(&gGentle, "gentle", false, "Run the commands one at a time, at the lowest CPU and I/O priority, with a pause between them, for hosts serving latency-critical traffic.")
+	flag.StringVar(&auditPath, "audit", "", "Append a JSON object per line (NDJSON) to `FILE` for each command executed: label, command, user, sudo, start and end time, and exit code.")
+	flag.StringVar(&progressPath, "progress", "", "Append a line to `FILE` for each command that will run and for each command that completes, with its duration, for monitoring the collection.")
 	flag.Parse()
 	if showHelp {
 		showUsage()
@@ -185,14 +308,16 @@ func mainReturnWithCode() int {
 	}
 
 	// configure logging
-	logFilename := filepath.Base(os.Args[0]) + ".log"
-	logFile, err := os.OpenFile(logFilename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Printf("Error: %v", err)
-		return 1
+	if !ndjson {
+		logFilename := filepath.Base(os.Args[0]) + ".log"
+		logFile, err := os.OpenFile(logFilename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer logFile.Close()
+		log.SetOutput(logFile)
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 
 	log.Printf("Starting up %s, version %s, PID %d, PPID %d, arguments: %s",
@@ -204,17 +329,42 @@ func mainReturnWithCode() int {
 	)
 
 	// write pid to file
-	pidFilename := filepath.Base(os.Args[0]) + ".pid"
-	pidFile, err := os.OpenFile(pidFilename, os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Printf("Error: %v", err)
-		return 1
+	if !ndjson {
+		pidFilename := filepath.Base(os.Args[0]) + ".pid"
+		pidFile, err := os.OpenFile(pidFilename, os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		pidFile.WriteString(fmt.Sprintf("%d", os.Getpid()))
+		pidFile.Close()
+	}
+
+	// open the audit log, if requested
+	if auditPath != "" {
+		auditLog, err := newAuditLog(auditPath)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer auditLog.close()
+		gAuditLog = auditLog
+	}
+
+	// open the progress log, if requested
+	if progressPath != "" {
+		progressLog, err := newProgressLog(progressPath)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		defer progressLog.close()
+		gProgressLog = progressLog
 	}
-	pidFile.WriteString(fmt.Sprintf("%d", os.Getpid()))
-	pidFile.Close()
 
 	// read input
 	var data []byte
+	var err error
 	if flag.NArg() == 0 {
 		log.Print("Reading data from stdin")
 		data, err = io.ReadAll(os.Stdin)
@@ -248,11 +398,46 @@ func mainReturnWithCode() int {
 	}
 	runConfig.sudo = os.Getenv("SUDO_PASSWORD")
 
+	if ndjson {
+		// run commands - prints one line of json for each command, labeled with the name argument
+		err = runConfigCommands(runConfig, os.Stdout, func(out io.Writer, result ResultType, firstCommand bool) error {
+			result["name"] = runConfig.cmdFile.Args.Name
+			return printResultNDJSON(out, result, firstCommand)
+		})
+		if err != nil {
+			return 1
+		}
+		log.Print("All done.")
+		return 0
+	}
+
+	if gobOutput {
+		// run commands - encodes each result as the command completes
+		var gobWriter *rawdata.GobWriter
+		gobWriter, err = rawdata.NewGobWriter(os.Stdout, runConfig.cmdFile.Args.Name)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		err = runConfigCommands(runConfig, os.Stdout, func(out io.Writer, result ResultType, firstCommand bool) error {
+			return gobWriter.Write(rawdata.Result(result))
+		})
+		if err != nil {
+			return 1
+		}
+		if err = gobWriter.Close(); err != nil {
+			log.Printf("Error: %v", err)
+			return 1
+		}
+		log.Print("All done.")
+		return 0
+	}
+
 	// start json
 	fmt.Printf("{\n\"%s\": [\n", runConfig.cmdFile.Args.Name)
 
 	// run commands - prints json formatted output for each command
-	err = runConfigCommands(runConfig, os.Stdout)
+	err = runConfigCommands(runConfig, os.Stdout, printResult)
 	if err != nil {
 		return 1
 	}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+func TestRunConfigCommandTimeout(t *testing.T) {
+	// the larger of the command's and the arguments' timeouts applies
+	cmd := commandfile.Command{Label: "sleep", Command: "sleep 30", Run: true, Timeout: 2}
+	ch := make(chan ResultType)
+	start := time.Now()
+	go runConfigCommand(cmd, commandfile.Arguments{Binpath: ".", Timeout: 1}, "", ch)
+	result := <-ch
+	if elapsed := time.Since(start); elapsed < 2*time.Second || elapsed > 10*time.Second {
+		t.Errorf("command wasn't stopped at its timeout: %v", elapsed)
+	}
+	if result["label"] != "sleep" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func TestRunConfigCommandGentle(t *testing.T) {
+	defer func() { gGentle = false }()
+	gGentle = true
+	ch := make(chan ResultType)
+	go runConfigCommand(commandfile.Command{Label: "nice", Command: "nice", Run: true}, commandfile.Arguments{Binpath: ".", Timeout: 10}, "", ch)
+	if result := <-ch; strings.TrimSpace(result["stdout"]) != "19" {
+		t.Errorf("unexpected niceness: %v", result)
+	}
+}
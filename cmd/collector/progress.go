@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+// ProgressLog appends a line to a file for each command that will run, and another when
+// it completes, so that the orchestrator can show the progress of a long collection:
+//
+//	run<TAB>label<TAB>serial|parallel
+//	done<TAB>label<TAB>seconds
+type ProgressLog struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// gProgressLog is nil unless the -progress option is set
+var gProgressLog *ProgressLog
+
+func newProgressLog(path string) (progressLog *ProgressLog, err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to open progress log: %v", err)
+		return
+	}
+	progressLog = &ProgressLog{file: file}
+	return
+}
+
+func (p *ProgressLog) write(fields ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, err := p.file.WriteString(strings.Join(fields, "\t") + "\n"); err != nil {
+		log.Printf("Failed to write progress log: %v", err)
+	}
+}
+
+// commands records the commands that will run, the serial commands one at a time and
+// then the parallel commands all at once
+func (p *ProgressLog) commands(serial []commandfile.Command, parallel []commandfile.Command) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range serial {
+		p.write("run", cmd.Label, "serial")
+	}
+	for _, cmd := range parallel {
+		p.write("run", cmd.Label, "parallel")
+	}
+}
+
+// done records that the command completed
+func (p *ProgressLog) done(label string, duration time.Duration) {
+	if p == nil {
+		return
+	}
+	p.write("done", label, fmt.Sprintf("%.3f", duration.Seconds()))
+}
+
+func (p *ProgressLog) close() {
+	if p != nil {
+		p.file.Close()
+	}
+}
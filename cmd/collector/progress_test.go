@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+func TestProgressLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collector.progress")
+	progressLog, err := newProgressLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progressLog.commands([]commandfile.Command{{Label: "lscpu"}}, []commandfile.Command{{Label: "fio"}, {Label: "mlc"}})
+	progressLog.done("lscpu", 1500*time.Millisecond)
+	progressLog.close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "run\tlscpu\tserial\nrun\tfio\tparallel\nrun\tmlc\tparallel\ndone\tlscpu\t1.500\n"
+	if string(content) != expected {
+		t.Errorf("unexpected progress log: %q", content)
+	}
+	// disabled
+	var disabled *ProgressLog
+	disabled.commands(nil, nil)
+	disabled.done("lscpu", time.Second)
+	disabled.close()
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+// In read-only mode, the collector doesn't run the commands that are annotated with side
+// effects, e.g., writing to disk outside of the working directory, writing MSRs, or
+// changing kernel settings, and it doesn't load kernel modules. Commands that require a
+// kernel module that isn't already loaded are skipped. The skipped commands are listed in
+// the readOnlyLabel result so that the report can state the guarantee.
+
+// readOnlyLabel labels the result that lists the commands skipped in read-only mode
+const readOnlyLabel = "read-only mode"
+
+// gReadOnly is set by the -read_only option
+var gReadOnly bool
+
+// moduleLoaded is true if the kernel module is loaded or built into the kernel
+func moduleLoaded(module string) bool {
+	_, err := os.Stat(filepath.Join("/sys/module", strings.ReplaceAll(module, "-", "_")))
+	return err == nil
+}
+
+// filterReadOnlyCommands removes the commands that have side effects or require kernel
+// modules that aren't loaded. The reasons the commands were removed are returned by
+// command label.
+func filterReadOnlyCommands(commands []commandfile.Command, loaded func(string) bool) (filtered []commandfile.Command, skipped map[string]string) {
+	skipped = make(map[string]string)
+	for _, cmd := range commands {
+		if !cmd.Run {
+			filtered = append(filtered, cmd)
+			continue
+		}
+		if strings.TrimSpace(cmd.SideEffects) != "" {
+			skipped[cmd.Label] = "side effects: " + cmd.SideEffects
+			log.Printf("Skipping command in read-only mode: %s, %s", cmd.Label, skipped[cmd.Label])
+			continue
+		}
+		var unloaded []string
+		for _, module := range strings.Split(cmd.Modprobe, ",") {
+			module = strings.TrimSpace(module)
+			if module != "" && !loaded(module) {
+				unloaded = append(unloaded, module)
+			}
+		}
+		if len(unloaded) > 0 {
+			skipped[cmd.Label] = "requires kernel module: " + strings.Join(unloaded, ",")
+			log.Printf("Skipping command in read-only mode: %s, %s", cmd.Label, skipped[cmd.Label])
+			continue
+		}
+		cmd.Modprobe = "" // already loaded
+		filtered = append(filtered, cmd)
+	}
+	return
+}
+
+// getReadOnlyResult returns the result that lists the commands skipped in read-only mode
+func getReadOnlyResult(skipped map[string]string) (result ResultType) {
+	var labels []string
+	for label := range skipped {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	var lines []string
+	for _, label := range labels {
+		lines = append(lines, fmt.Sprintf("%s: %s", label, skipped[label]))
+	}
+	result = ResultType{
+		"label":      readOnlyLabel,
+		"command":    "",
+		"superuser":  "false",
+		"privileges": privilegesUser,
+		"stdout":     strings.Join(lines, "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	}
+	return
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"testing"
+
+	"github.com/intel/svr-info/internal/commandfile"
+)
+
+func TestFilterReadOnlyCommands(t *testing.T) {
+	commands := []commandfile.Command{
+		{Label: "date", Command: "date -u", Run: true},
+		{Label: "fio", Command: "fio", SideEffects: "disk", Run: true},
+		{Label: "rdmsr", Command: "msrread 0x1a4", Modprobe: "msr", Run: true},
+		{Label: "ipmitool", Command: "ipmitool sel elist", Modprobe: "ipmi_devintf, ipmi_si", Run: true},
+		{Label: "mlc", Command: "mlc", SideEffects: "msr,settings", Run: false},
+	}
+	loaded := func(module string) bool { return module == "msr" || module == "ipmi_devintf" }
+	filtered, skipped := filterReadOnlyCommands(commands, loaded)
+	if len(filtered) != 3 || filtered[0].Label != "date" || filtered[1].Label != "rdmsr" || filtered[2].Label != "mlc" {
+		t.Fatalf("unexpected commands: %v", filtered)
+	}
+	if filtered[1].Modprobe != "" {
+		t.Errorf("module will be loaded: %s", filtered[1].Modprobe)
+	}
+	if skipped["fio"] != "side effects: disk" || skipped["ipmitool"] != "requires kernel module: ipmi_si" || len(skipped) != 2 {
+		t.Errorf("unexpected skipped commands: %v", skipped)
+	}
+	result := getReadOnlyResult(skipped)
+	if result["label"] != readOnlyLabel || result["stdout"] != "fio: side effects: disk\nipmitool: requires kernel module: ipmi_si" {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+// The audit log records every command executed on every target, one JSON object per
+// line (NDJSON), for ingestion by a SIEM. Commands run by the orchestrator, e.g., ssh and
+// scp, are recorded as they complete. Commands run by the collector on the target are
+// recorded by the collector in collectorAuditFile and appended when the collection ends.
+
+// collectorAuditFile is the name of the collector's audit log on the target
+const collectorAuditFile = "collector.audit"
+
+// AuditEvent is one line in the audit log
+type AuditEvent struct {
+	Time     string `json:"time"`
+	Tool     string `json:"tool"`
+	Version  string `json:"version"`
+	Operator string `json:"operator"`
+	Target   string `json:"target"`
+	Source   string `json:"source"` // orchestrator or collector
+	Label    string `json:"label,omitempty"`
+	Command  string `json:"command"`
+	User     string `json:"user"`
+	Sudo     bool   `json:"sudo"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	ExitCode int    `json:"exit_code"`
+}
+
+type AuditLog struct {
+	mutex    sync.Mutex
+	file     *os.File
+	operator string
+}
+
+func newAuditLog(path string) (auditLog *AuditLog, err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		err = fmt.Errorf("failed to open audit log: %v", err)
+		return
+	}
+	auditLog = &AuditLog{file: file}
+	if u, err := user.Current(); err == nil {
+		auditLog.operator = u.Username
+	}
+	return
+}
+
+func (a *AuditLog) close() {
+	a.file.Close()
+}
+
+func (a *AuditLog) write(event AuditEvent) {
+	event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	event.Tool = filepath.Base(os.Args[0])
+	event.Version = gVersion
+	event.Operator = a.operator
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if _, err = a.file.Write(append(b, '\n')); err != nil {
+		log.Printf("failed to write audit log: %v", err)
+	}
+}
+
+// observe is the target.CommandObserver that records the commands the orchestrator runs
+// on the targets
+func (a *AuditLog) observe(t target.Target, command string, start time.Time, end time.Time, exitCode int) {
+	a.write(AuditEvent{
+		Target:   t.GetName(),
+		Source:   "orchestrator",
+		Command:  command,
+		User:     t.GetUser(),
+		Sudo:     strings.Contains(command, "sudo "),
+		Start:    start.UTC().Format(time.RFC3339Nano),
+		End:      end.UTC().Format(time.RFC3339Nano),
+		ExitCode: exitCode,
+	})
+}
+
+// appendCollectorEvents records the events from the collector's audit log
+func (a *AuditLog) appendCollectorEvents(t target.Target, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			log.Printf("skipping malformed collector audit event from %s: %v", t.GetName(), err)
+			continue
+		}
+		event.Target = t.GetName()
+		event.Source = "collector"
+		a.write(event)
+	}
+}
+
+// pullAuditLog retrieves the collector's audit log from the target's working directory
+// and appends its events to the audit log
+func (c *Collection) pullAuditLog(workingDirectory string) {
+	if c.auditLog == nil {
+		return
+	}
+	localPath := filepath.Join(c.tempDir, c.target.GetName()+"_"+collectorAuditFile)
+	err := c.target.PullFile(filepath.Join(workingDirectory, collectorAuditFile), localPath)
+	if err != nil {
+		log.Printf("failed to retrieve collector audit log from %s: %v", c.target.GetName(), err)
+		return
+	}
+	defer os.Remove(localPath)
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		log.Printf("failed to read collector audit log from %s: %v", c.target.GetName(), err)
+		return
+	}
+	c.auditLog.appendCollectorEvents(c.target, data)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	auditLog, err := newAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localTarget := target.NewLocalTarget("host", "")
+	localTarget.SetCommandObserver(auditLog.observe)
+	_, _, _, _ = localTarget.RunCommand(exec.Command("true"))
+	auditLog.appendCollectorEvents(localTarget, []byte(
+		`{"label":"uname","command":"uname -a","user":"root","sudo":true,"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:00:01Z","exit_code":1}`+"\n"+
+			"not json\n"))
+	auditLog.close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 events, got %d: %s", len(lines), data)
+	}
+	var events []AuditEvent
+	for _, line := range lines {
+		var event AuditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if events[0].Source != "orchestrator" || events[0].Command != "true" || events[0].Target != "host" {
+		t.Errorf("unexpected orchestrator event: %+v", events[0])
+	}
+	if events[1].Source != "collector" || events[1].Target != "host" || !events[1].Sudo || events[1].ExitCode != 1 || events[1].Label != "uname" {
+		t.Errorf("unexpected collector event: %+v", events[1])
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The version and SHA-256 hash of each svr-info component used to collect a target's
+// data, i.e., svr-info itself, the reporter, the collector, and the collector's
+// dependencies archive, are saved with the data so that results can be tied to exact
+// tool versions. The collector adds the hashes of the tools it ran on the target.
+
+// componentsLabel labels the svr-info components in the collected data
+const componentsLabel = "svr-info components"
+
+// getComponentLines returns a line per component: the name, version, and SHA-256 hash
+func getComponentLines(paths []string) (lines []string) {
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+	for _, path := range append([]string{self}, paths...) {
+		hash, err := util.FileSHA256(path)
+		if err != nil {
+			log.Printf("failed to hash %s: %v", path, err)
+			hash = "unknown"
+		}
+		name := filepath.Base(path)
+		if path == self {
+			name = "svr-info"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", name, gVersion, hash))
+	}
+	return
+}
+
+// addComponents adds the components, one per line, to the collected data in the
+// raw.json or raw.gob file as if they were the output of a command
+func addComponents(rawFilePath string, paths []string) (err error) {
+	err = appendRawResult(rawFilePath, map[string]string{
+		"label":      componentsLabel,
+		"command":    "",
+		"superuser":  "false",
+		"stdout":     strings.Join(getComponentLines(paths), "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	})
+	return
+}
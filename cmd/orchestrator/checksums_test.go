@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddComponents(t *testing.T) {
+	dir := t.TempDir()
+	rawFilePath := filepath.Join(dir, "host.raw.json")
+	err := os.WriteFile(rawFilePath, []byte(`{"host":[{"label":"hostname","command":"hostname","stdout":"host\n","stderr":"","exitstatus":"0","superuser":"false"}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reporterPath := filepath.Join(dir, "reporter")
+	if err = os.WriteFile(reporterPath, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = addComponents(rawFilePath, []string{reporterPath, filepath.Join(dir, "collector")}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(rawFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string][]map[string]string
+	if err = json.Unmarshal(content, &data); err != nil {
+		t.Fatal(err)
+	}
+	results := data["host"]
+	if len(results) != 2 || results[1]["label"] != componentsLabel {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	lines := strings.Split(results[1]["stdout"], "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "svr-info "+gVersion+" ") {
+		t.Fatalf("unexpected components: %v", lines)
+	}
+	if lines[1] != "reporter "+gVersion+" ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("unexpected reporter: %s", lines[1])
+	}
+	if lines[2] != "collector "+gVersion+" unknown" {
+		t.Errorf("unexpected collector: %s", lines[2])
+	}
+}
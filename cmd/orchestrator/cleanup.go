@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// staleTempDirAge is the age after which the temporary directory of a run that didn't
+// remove it, e.g., it was killed or run with -debug, is removed by a later run
+const staleTempDirAge = 24 * time.Hour
+
+// tempDirOwnerFile, in the temporary directory, holds the PID of the process that
+// created it so that the directories of running processes aren't removed
+const tempDirOwnerFile = "owner.pid"
+
+// writeTempDirOwner records the current process as the owner of the temporary directory
+func writeTempDirOwner(tempDir string) error {
+	return os.WriteFile(filepath.Join(tempDir, tempDirOwnerFile), []byte(fmt.Sprint(os.Getpid())), 0644)
+}
+
+// processRunning is true if a process with the PID exists
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// removeStaleTempDirs removes the temporary directories, i.e., prefix*, in dir that are
+// older than staleTempDirAge and whose owner isn't running. The removed directories are
+// returned.
+func removeStaleTempDirs(dir string, prefix string, now time.Time) (removed []string) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < staleTempDirAge {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if content, err := os.ReadFile(filepath.Join(path, tempDirOwnerFile)); err == nil {
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(content))); err == nil && processRunning(pid) {
+				continue
+			}
+		}
+		if err := os.RemoveAll(path); err == nil {
+			removed = append(removed, path)
+		}
+	}
+	return
+}
+
+// getOutputDirPattern matches the names of the output directories created when -output
+// isn't specified, e.g., svr-info_2024-01-31_13-45-00
+func getOutputDirPattern() *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(filepath.Base(os.Args[0])) + `_\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}$`)
+}
+
+// daemonRunDirLayout is the time layout of the names of the daemon's run directories, in
+// local time
+const daemonRunDirLayout = "2006-01-02_15-04"
+
+// daemonRunDirPattern matches the names of the daemon's run directories, e.g., 2024-01-31_02-00
+var daemonRunDirPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}_\d{2}-\d{2}$`)
+
+// pruneDatedDirs removes all but the newest keep directories in parent whose names match
+// pattern. The names include the date and time, so they sort in the order they were
+// created. The removed directories are returned.
+func pruneDatedDirs(parent string, pattern *regexp.Regexp, keep int) (removed []string, err error) {
+	if keep <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && pattern.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) <= keep {
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		path := filepath.Join(parent, name)
+		if err = os.RemoveAll(path); err != nil {
+			return
+		}
+		removed = append(removed, path)
+	}
+	return
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveStaleTempDirs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * staleTempDirAge)
+	for _, name := range []string{"svr-info.tmp.old", "svr-info.tmp.running", "svr-info.tmp.new", "other.old"} {
+		path := filepath.Join(dir, name)
+		if err := os.Mkdir(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if name == "svr-info.tmp.running" {
+			if err := writeTempDirOwner(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if name != "svr-info.tmp.new" {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	removed := removeStaleTempDirs(dir, "svr-info.tmp.", time.Now())
+	if len(removed) != 1 || filepath.Base(removed[0]) != "svr-info.tmp.old" {
+		t.Errorf("unexpected removed directories: %v", removed)
+	}
+	// a directory whose owner isn't running is removed
+	path := filepath.Join(dir, "svr-info.tmp.running")
+	if err := os.WriteFile(filepath.Join(path, tempDirOwnerFile), []byte(fmt.Sprint(1<<22+1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(path, old, old)
+	removed = removeStaleTempDirs(dir, "svr-info.tmp.", time.Now())
+	if len(removed) != 1 || filepath.Base(removed[0]) != "svr-info.tmp.running" {
+		t.Errorf("unexpected removed directories: %v", removed)
+	}
+}
+
+func TestPruneDatedDirs(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"2024-01-31_02-00", "2024-02-07_02-00", "2024-02-14_02-00", "logs"}
+	for _, name := range names {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	removed, err := pruneDatedDirs(dir, daemonRunDirPattern, 0)
+	if err != nil || len(removed) != 0 {
+		t.Errorf("expected nothing removed when keeping all, got %v, %v", removed, err)
+	}
+	removed, err = pruneDatedDirs(dir, daemonRunDirPattern, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != "2024-01-31_02-00" {
+		t.Errorf("unexpected removed directories: %v", removed)
+	}
+	for _, name := range names[1:] {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be kept", name)
+		}
+	}
+	if !getOutputDirPattern().MatchString(filepath.Base(os.Args[0]) + "_2024-01-31_13-45-00") {
+		t.Error("expected output directory name to match")
+	}
+}
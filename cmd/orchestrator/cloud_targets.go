@@ -0,0 +1,343 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+// With -targets_from, the targets are the running instances in a cloud provider's
+// inventory, listed with the provider's CLI, aws, gcloud, or az, which must be installed
+// and logged in. The instances can be selected by their tags (labels in GCP) with
+// -targets_filter, and by region with -targets_region. The instances are reached at
+// their private IP address, or public IP address with -targets_address public, with the
+// -user, -key, and -port command line arguments. Like -targets_cmd, the instances are
+// listed once, when svr-info starts, and a -daemon collects from the same instances on
+// each run. The targets are named by the instances' names, which are also the names of
+// their files in the output directory, so names with other characters than letters,
+// digits, '.', '_', and '-' are replaced by the instance ID.
+
+// cloud providers
+const (
+	cloudAWS   = "aws"
+	cloudGCP   = "gcp"
+	cloudAzure = "azure"
+)
+
+var cloudProviders = []string{cloudAWS, cloudGCP, cloudAzure}
+
+// instance addresses
+const (
+	cloudAddressPrivate = "private"
+	cloudAddressPublic  = "public"
+)
+
+var cloudAddresses = []string{cloudAddressPrivate, cloudAddressPublic}
+
+// reCloudTargetName matches the instance names that can be used as target names
+var reCloudTargetName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// cloudCLIs are the CLIs that list each provider's instances
+var cloudCLIs = map[string]string{
+	cloudAWS:   "aws",
+	cloudGCP:   "gcloud",
+	cloudAzure: "az",
+}
+
+// runCloudCLI runs the cloud provider's CLI and returns its output
+var runCloudCLI = func(name string, args ...string) (output []byte, err error) {
+	if _, err = exec.LookPath(name); err != nil {
+		err = fmt.Errorf("%s not found, the provider's CLI is required", name)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), targetsCmdTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err = cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s timed out after %s", name, targetsCmdTimeout)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return
+}
+
+// cloudInstance is a running instance in a cloud provider's inventory
+type cloudInstance struct {
+	id        string
+	name      string
+	region    string // or zone
+	privateIP string
+	publicIP  string
+	tags      map[string]string
+}
+
+var reCloudFilter = regexp.MustCompile(`^[^=,]+=[^=,]*$`)
+
+// parseCloudFilter parses the -targets_filter tags, e.g., "env=prod,role=db"
+func parseCloudFilter(filter string) (tags map[string]string, err error) {
+	tags = make(map[string]string)
+	if filter == "" {
+		return
+	}
+	for _, tag := range strings.Split(filter, ",") {
+		tag = strings.TrimSpace(tag)
+		if !reCloudFilter.MatchString(tag) {
+			err = fmt.Errorf("invalid tag filter: %s, expected KEY=VALUE", tag)
+			return
+		}
+		key, value, _ := strings.Cut(tag, "=")
+		tags[key] = value
+	}
+	return
+}
+
+// getCloudCLIArgs returns the arguments of the provider's CLI that list the running
+// instances with the tags, in the region
+func getCloudCLIArgs(provider string, tags map[string]string, region string) (args []string) {
+	var keys []string
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	switch provider {
+	case cloudAWS:
+		args = []string{"ec2", "describe-instances", "--output", "json", "--filters", "Name=instance-state-name,Values=running"}
+		for _, key := range keys {
+			args = append(args, fmt.Sprintf("Name=tag:%s,Values=%s", key, tags[key]))
+		}
+		if region != "" {
+			args = append(args, "--region", region)
+		}
+	case cloudGCP:
+		filter := []string{"status=RUNNING"}
+		for _, key := range keys {
+			filter = append(filter, fmt.Sprintf("labels.%s=%s", key, tags[key]))
+		}
+		if region != "" {
+			filter = append(filter, fmt.Sprintf("zone ~ /%s", region))
+		}
+		args = []string{"compute", "instances", "list", "--format", "json", "--filter", strings.Join(filter, " AND ")}
+	case cloudAzure:
+		// az filters neither by tag nor by location, the instances are filtered after
+		// they're listed
+		args = []string{"vm", "list", "--show-details", "--output", "json"}
+	}
+	return
+}
+
+// parseAWSInstances parses the output of aws ec2 describe-instances
+func parseAWSInstances(output []byte) (instances []cloudInstance, err error) {
+	var result struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceID       string `json:"InstanceId"`
+				PrivateIPAddress string `json:"PrivateIpAddress"`
+				PublicIPAddress  string `json:"PublicIpAddress"`
+				Placement        struct {
+					AvailabilityZone string
+				}
+				State struct {
+					Name string
+				}
+				Tags []struct {
+					Key   string
+					Value string
+				}
+			}
+		}
+	}
+	if err = json.Unmarshal(output, &result); err != nil {
+		return
+	}
+	for _, reservation := range result.Reservations {
+		for _, i := range reservation.Instances {
+			if i.State.Name != "" && i.State.Name != "running" {
+				continue
+			}
+			instance := cloudInstance{
+				id:        i.InstanceID,
+				region:    i.Placement.AvailabilityZone,
+				privateIP: i.PrivateIPAddress,
+				publicIP:  i.PublicIPAddress,
+				tags:      make(map[string]string),
+			}
+			for _, tag := range i.Tags {
+				instance.tags[tag.Key] = tag.Value
+			}
+			instance.name = instance.tags["Name"]
+			instances = append(instances, instance)
+		}
+	}
+	return
+}
+
+// parseGCPInstances parses the output of gcloud compute instances list
+func parseGCPInstances(output []byte) (instances []cloudInstance, err error) {
+	var result []struct {
+		ID                string `json:"id"`
+		Name              string `json:"name"`
+		Zone              string `json:"zone"`
+		Status            string `json:"status"`
+		Labels            map[string]string
+		NetworkInterfaces []struct {
+			NetworkIP     string `json:"networkIP"`
+			AccessConfigs []struct {
+				NatIP string `json:"natIP"`
+			} `json:"accessConfigs"`
+		} `json:"networkInterfaces"`
+	}
+	if err = json.Unmarshal(output, &result); err != nil {
+		return
+	}
+	for _, i := range result {
+		if i.Status != "" && i.Status != "RUNNING" {
+			continue
+		}
+		instance := cloudInstance{
+			id:     i.ID,
+			name:   i.Name,
+			region: i.Zone[strings.LastIndex(i.Zone, "/")+1:],
+			tags:   i.Labels,
+		}
+		if len(i.NetworkInterfaces) > 0 {
+			instance.privateIP = i.NetworkInterfaces[0].NetworkIP
+			if len(i.NetworkInterfaces[0].AccessConfigs) > 0 {
+				instance.publicIP = i.NetworkInterfaces[0].AccessConfigs[0].NatIP
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return
+}
+
+// parseAzureInstances parses the output of az vm list --show-details
+func parseAzureInstances(output []byte) (instances []cloudInstance, err error) {
+	var result []struct {
+		VMID       string `json:"vmId"`
+		Name       string `json:"name"`
+		Location   string `json:"location"`
+		PowerState string `json:"powerState"`
+		PrivateIPs string `json:"privateIps"`
+		PublicIPs  string `json:"publicIps"`
+		Tags       map[string]string
+	}
+	if err = json.Unmarshal(output, &result); err != nil {
+		return
+	}
+	for _, i := range result {
+		if i.PowerState != "" && i.PowerState != "VM running" {
+			continue
+		}
+		// the addresses of all of the VM's NICs, comma separated
+		privateIP, _, _ := strings.Cut(i.PrivateIPs, ",")
+		publicIP, _, _ := strings.Cut(i.PublicIPs, ",")
+		instances = append(instances, cloudInstance{
+			id:        i.VMID,
+			name:      i.Name,
+			region:    i.Location,
+			privateIP: privateIP,
+			publicIP:  publicIP,
+			tags:      i.Tags,
+		})
+	}
+	return
+}
+
+// matchesCloudFilter returns true if the instance has the tags and is in the region
+func (i cloudInstance) matchesCloudFilter(tags map[string]string, region string) bool {
+	for key, value := range tags {
+		if v, ok := i.tags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return region == "" || strings.HasPrefix(i.region, region)
+}
+
+// getTargetsFromCloud lists the running instances in the cloud provider's inventory
+// that match -targets_filter and -targets_region and returns them as targets
+func getTargetsFromCloud(args *CmdLineArgs) (targets []targetFromFile, err error) {
+	tags, err := parseCloudFilter(args.targetsFilter)
+	if err != nil {
+		err = fmt.Errorf("-targets_filter %s : %v", args.targetsFilter, err)
+		return
+	}
+	output, err := runCloudCLI(cloudCLIs[args.targetsFrom], getCloudCLIArgs(args.targetsFrom, tags, args.targetsRegion)...)
+	if err != nil {
+		err = fmt.Errorf("-targets_from %s : %v", args.targetsFrom, err)
+		return
+	}
+	var instances []cloudInstance
+	switch args.targetsFrom {
+	case cloudAWS:
+		instances, err = parseAWSInstances(output)
+	case cloudGCP:
+		instances, err = parseGCPInstances(output)
+	case cloudAzure:
+		instances, err = parseAzureInstances(output)
+	}
+	if err != nil {
+		err = fmt.Errorf("-targets_from %s : failed to parse the instances: %v", args.targetsFrom, err)
+		return
+	}
+	labels := make(map[string]int)
+	var ids []string
+	for _, instance := range instances {
+		if !instance.matchesCloudFilter(tags, args.targetsRegion) {
+			continue
+		}
+		host := instance.privateIP
+		if args.targetsAddress == cloudAddressPublic {
+			host = instance.publicIP
+		}
+		if args.targetsFrom == cloudAWS && args.transport == target.TransportSSM {
+			host = instance.id // reached through Systems Manager by instance ID
+		}
+		label := instance.name
+		if !reCloudTargetName.MatchString(label) || label == "." || label == ".." {
+			label = instance.id
+		}
+		if host == "" {
+			log.Printf("-targets_from %s : skipping %s, it has no %s IP address", args.targetsFrom, label, args.targetsAddress)
+			fmt.Fprintf(messages, "WARNING: skipping %s, it has no %s IP address\n", label, args.targetsAddress)
+			continue
+		}
+		labels[label]++
+		ids = append(ids, instance.id)
+		targets = append(targets, targetFromFile{
+			label: label,
+			ip:    host,
+			port:  fmt.Sprintf("%d", args.port),
+			user:  args.user,
+			key:   args.key,
+			index: len(targets) + 1,
+			file:  "<" + args.targetsFrom + " instances>",
+		})
+	}
+	// instance names needn't be unique, the target names must be
+	for i := range targets {
+		if labels[targets[i].label] > 1 {
+			targets[i].label = fmt.Sprintf("%s_%s", targets[i].label, ids[i])
+		}
+	}
+	if len(targets) == 0 {
+		err = fmt.Errorf("-targets_from %s : no running instances match the filter", args.targetsFrom)
+	}
+	return
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+const awsInstances = `{"Reservations": [{"Instances": [
+  {"InstanceId": "i-0a1", "PrivateIpAddress": "10.0.0.1", "PublicIpAddress": "54.1.1.1", "Placement": {"AvailabilityZone": "us-east-1a"}, "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "db"}, {"Key": "env", "Value": "prod"}]},
+  {"InstanceId": "i-0a2", "PrivateIpAddress": "10.0.0.2", "Placement": {"AvailabilityZone": "us-east-1b"}, "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "db"}, {"Key": "env", "Value": "prod"}]},
+  {"InstanceId": "i-0a3", "PrivateIpAddress": "10.0.0.3", "Placement": {"AvailabilityZone": "us-east-1a"}, "State": {"Name": "running"}, "Tags": [{"Key": "env", "Value": "dev"}]},
+  {"InstanceId": "i-0a4", "PrivateIpAddress": "10.0.0.4", "Placement": {"AvailabilityZone": "us-east-1a"}, "State": {"Name": "running"}, "Tags": [{"Key": "Name", "Value": "../web $(reboot)"}, {"Key": "env", "Value": "prod"}]}
+]}]}`
+
+const gcpInstances = `[
+  {"id": "101", "name": "web-1", "zone": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a", "status": "RUNNING", "labels": {"env": "prod"}, "networkInterfaces": [{"networkIP": "10.1.0.1", "accessConfigs": [{"natIP": "34.1.1.1"}]}]},
+  {"id": "102", "name": "web-2", "zone": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-b", "status": "TERMINATED", "labels": {"env": "prod"}, "networkInterfaces": [{"networkIP": "10.1.0.2"}]}
+]`
+
+const azureInstances = `[
+  {"vmId": "a1", "name": "app-1", "location": "eastus", "powerState": "VM running", "privateIps": "10.2.0.1,10.2.1.1", "publicIps": "", "tags": {"env": "prod"}},
+  {"vmId": "a2", "name": "app-2", "location": "westus", "powerState": "VM running", "privateIps": "10.2.0.2", "publicIps": "", "tags": {"env": "prod"}},
+  {"vmId": "a3", "name": "app-3", "location": "eastus", "powerState": "VM deallocated", "privateIps": "", "publicIps": "", "tags": {"env": "prod"}}
+]`
+
+func TestParseCloudFilter(t *testing.T) {
+	tags, err := parseCloudFilter("env=prod, role=db")
+	if err != nil || len(tags) != 2 || tags["env"] != "prod" || tags["role"] != "db" {
+		t.Errorf("unexpected tags: %v, %v", tags, err)
+	}
+	if _, err = parseCloudFilter("env"); err == nil {
+		t.Error("expected error for filter without value")
+	}
+}
+
+func TestGetCloudCLIArgs(t *testing.T) {
+	tags := map[string]string{"role": "db", "env": "prod"}
+	expected := "ec2 describe-instances --output json --filters Name=instance-state-name,Values=running Name=tag:env,Values=prod Name=tag:role,Values=db --region us-east-1"
+	if got := strings.Join(getCloudCLIArgs(cloudAWS, tags, "us-east-1"), " "); got != expected {
+		t.Errorf("expected '%s', got '%s'", expected, got)
+	}
+	expected = "compute instances list --format json --filter status=RUNNING AND labels.env=prod AND labels.role=db"
+	if got := strings.Join(getCloudCLIArgs(cloudGCP, tags, ""), " "); got != expected {
+		t.Errorf("expected '%s', got '%s'", expected, got)
+	}
+}
+
+func TestGetTargetsFromCloud(t *testing.T) {
+	defer func(f func(string, ...string) ([]byte, error)) { runCloudCLI = f }(runCloudCLI)
+	outputs := map[string]string{"aws": awsInstances, "gcloud": gcpInstances, "az": azureInstances}
+	runCloudCLI = func(name string, args ...string) ([]byte, error) {
+		return []byte(outputs[name]), nil
+	}
+	names := func(targets []targetFromFile) (s []string) {
+		for _, t := range targets {
+			s = append(s, t.label+"@"+t.ip)
+		}
+		return
+	}
+	args := &CmdLineArgs{targetsFrom: cloudAWS, targetsFilter: "env=prod", port: 22, user: "ec2-user", targetsAddress: cloudAddressPrivate}
+	targets, err := getTargetsFromCloud(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names(targets), ","); got != "db_i-0a1@10.0.0.1,db_i-0a2@10.0.0.2,i-0a4@10.0.0.4" {
+		t.Errorf("unexpected targets: %s", got)
+	}
+	if targets[0].user != "ec2-user" || targets[0].port != "22" {
+		t.Errorf("unexpected target: %v", targets[0])
+	}
+	// public addresses, the instance without one is skipped
+	args.targetsAddress = cloudAddressPublic
+	if targets, err = getTargetsFromCloud(args); err != nil || strings.Join(names(targets), ",") != "db@54.1.1.1" {
+		t.Errorf("unexpected targets: %v, %v", names(targets), err)
+	}
+	// Systems Manager
+	args.targetsAddress, args.transport = cloudAddressPrivate, target.TransportSSM
+	if targets, err = getTargetsFromCloud(args); err != nil || targets[0].ip != "i-0a1" {
+		t.Errorf("unexpected targets: %v, %v", names(targets), err)
+	}
+	args = &CmdLineArgs{targetsFrom: cloudGCP, port: 22, user: "user"}
+	if targets, err = getTargetsFromCloud(args); err != nil || strings.Join(names(targets), ",") != "web-1@10.1.0.1" {
+		t.Errorf("unexpected targets: %v, %v", names(targets), err)
+	}
+	args = &CmdLineArgs{targetsFrom: cloudAzure, targetsFilter: "env=prod", targetsRegion: "eastus", port: 22, user: "azureuser"}
+	if targets, err = getTargetsFromCloud(args); err != nil || strings.Join(names(targets), ",") != "app-1@10.2.0.1" {
+		t.Errorf("unexpected targets: %v, %v", names(targets), err)
+	}
+	args.targetsFilter = "env=staging"
+	if _, err = getTargetsFromCloud(args); err == nil || !strings.Contains(err.Error(), "no running instances") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	runCloudCLI = func(name string, args ...string) ([]byte, error) {
+		return nil, errors.New("az not found, the provider's CLI is required")
+	}
+	if _, err = getTargetsFromCloud(args); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -12,21 +12,45 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/progress"
+	"github.com/intel/svr-info/internal/rawdata"
 	"github.com/intel/svr-info/internal/target"
 	"github.com/intel/svr-info/internal/util"
 	"gopkg.in/yaml.v2"
 )
 
+// errCollectionDeadline is the cause of a collection that was stopped at the -timeout
+// deadline, its reports are created from the data collected before the deadline
+var errCollectionDeadline = errors.New("collection deadline exceeded")
+
+// timeoutExitCode is the exit status of timeout when the command timed out
+const timeoutExitCode = 124
+
+// collectorStopGracePeriod is the number of seconds the collector has to stop after the
+// deadline before it's killed
+const collectorStopGracePeriod = 10
+
 type Collection struct {
 	target         target.Target
 	cmdLineArgs    *CmdLineArgs
 	outputDir      string
 	tempDir        string
 	outputFilePath string
+	sessionDir     string // on the target, for detached collections
+	auditLog       *AuditLog
+	statusUpdate   progress.MultiSpinnerUpdateFunc // nil if the status isn't shown
+	timings        *progress.Timings               // of the commands in previous collections
+	progressFile   string                          // on the target, while the collector's progress is monitored
+	notCollected   []string                        // the commands that weren't collected, when the collector failed
+	commandCount   int                             // the commands that were to be collected, when the collector failed
+	err            error                           // the cause of the collection's failure, nil if it didn't fail
+	deadline       bool                            // the collector was stopped at the -timeout deadline
 	stdout         string
 	stderr         string
 	ok             bool
@@ -61,6 +85,108 @@ func stringInList(s string, l []string) bool {
 	return false
 }
 
+// optionalCommands are the benchmark, profile, and analysis commands, which only run when
+// they're selected
+var optionalCommands = []string{"Memory MLC Bandwidth", "Memory MLC Loaded Latency Test", "stress-ng cpu methods", "Measure Turbo Frequencies", "CPU Turbo Test", "CPU Idle", "fio", "profile", "analyze", "megadata profiling", "topdown", "NIC Throughput Test"}
+
+// commandCategories maps the reports collector's configuration commands to their
+// categories. The commands in the empty category identify the host in every report, so
+// they're always collected. Commands that aren't listed, e.g., megadata's, aren't filtered.
+var commandCategories = map[string]string{
+	"date -u":                       "",
+	"date":                          "",
+	"lscpu":                         "",
+	"/proc/cpuinfo":                 "",
+	"uname -a":                      "",
+	"/etc/*-release":                "",
+	"dmidecode":                     "",
+	"lspci -vmm":                    "",
+	"quantities":                    "",
+	"cloud instance":                "system",
+	"bios settings":                 "system",
+	"redfish bios settings":         "system",
+	"lshw":                          "system",
+	"bootloader":                    "system",
+	"kernel patches":                "software",
+	"ps -eo":                        "software",
+	"/proc/cmdline":                 "software",
+	"gcc version":                   "software",
+	"binutils version":              "software",
+	"glibc version":                 "software",
+	"python version":                "software",
+	"python3 version":               "software",
+	"java version":                  "software",
+	"openssl version":               "software",
+	"container runtime":             "software",
+	"cpuid -1":                      "cpu",
+	"base frequency":                "cpu",
+	"maximum frequency":             "cpu",
+	"cpu isolation":                 "cpu",
+	"resctrl":                       "cpu",
+	"iommu":                         "cpu",
+	"rdmsr 0x1a4":                   "cpu",
+	"rdmsr 0x1ad":                   "cpu",
+	"rdmsr 0x1ae":                   "cpu",
+	"rdmsr 0x4f":                    "cpu",
+	"rdmsr 0xc90":                   "cpu",
+	"uncore cha count":              "cpu",
+	"uncore client cha count":       "cpu",
+	"uncore cha count spr":          "cpu",
+	"msrbusy":                       "cpu",
+	"lspci bits":                    "cpu",
+	"lspci devices":                 "cpu",
+	"iaa devices":                   "cpu",
+	"dsa devices":                   "cpu",
+	"max_cstate":                    "power",
+	"cpuidle states":                "power",
+	"cpu_freq_driver":               "power",
+	"cpu_freq_governor":             "power",
+	"cpufreq policies":              "power",
+	"cpufreq boost":                 "power",
+	"rdmsr 0x1b0":                   "power",
+	"rdmsr 0x770":                   "power",
+	"rdmsr 0x610":                   "power",
+	"rdmsr 0x6d":                    "power",
+	"intel-speed-select":            "power",
+	"uncore max frequency":          "power",
+	"uncore min frequency":          "power",
+	"active idle utilization point": "power",
+	"active idle mesh frequency":    "power",
+	"/proc/meminfo":                 "memory",
+	"transparent huge pages":        "memory",
+	"automatic numa balancing":      "memory",
+	"memory tiers":                  "memory",
+	"nic info":                      "network",
+	"dpu":                           "network",
+	"irqbalance":                    "network",
+	"lsblk -r -o":                   "storage",
+	"df -h":                         "storage",
+	"nvme":                          "storage",
+	"zoned block devices":           "storage",
+	"hdparm":                        "storage",
+	"findmnt":                       "storage",
+	"spectre-meltdown-checker":      "security",
+	"ipmitool sel time get":         "status",
+	"ipmitool sel elist":            "status",
+	"ipmitool chassis status":       "status",
+	"ipmitool sdr list full":        "status",
+	"dmesg":                         "status",
+}
+
+// collectsCommand returns true if the command's category is selected with -collect, or
+// -collect isn't set
+func collectsCommand(label string, collect string) bool {
+	categories := strings.Split(collect, ",")
+	if collect == "" || stringInList("all", categories) {
+		return true
+	}
+	category, ok := commandCategories[label]
+	if !ok || category == "" {
+		return true
+	}
+	return stringInList(category, categories)
+}
+
 func customizeCommandYAML(cmdTemplate []byte, cmdLineArgs *CmdLineArgs, targetBinDir string, targetHostName string) (customized []byte, err error) {
 	var cf commandfile.CommandFile
 	err = yaml.Unmarshal(cmdTemplate, &cf)
@@ -76,10 +202,9 @@ func customizeCommandYAML(cmdTemplate []byte, cmdLineArgs *CmdLineArgs, targetBi
 		if cmd.Label == "lspci -vmm" {
 			cmd.Command = fmt.Sprintf("lspci -i %s -vmm", filepath.Join(targetBinDir, "pci.ids.gz"))
 		}
-		optionalCommands := []string{"Memory MLC Bandwidth", "Memory MLC Loaded Latency Test", "stress-ng cpu methods", "Measure Turbo Frequencies", "CPU Turbo Test", "CPU Idle", "fio", "profile", "analyze"}
 		if !stringInList(cmd.Label, optionalCommands) {
 			if !cmdLineArgs.noConfig {
-				cmd.Run = true
+				cmd.Run = collectsCommand(cmd.Label, cmdLineArgs.collect)
 			}
 		} else {
 			// benchmark
@@ -110,29 +235,82 @@ func customizeCommandYAML(cmdTemplate []byte, cmdLineArgs *CmdLineArgs, targetBi
 					}
 					cmd.Command = buf.String()
 				}
+			} else if cmd.Label == "NIC Throughput Test" {
+				// saturates the NICs, so it isn't included in -benchmark all
+				cmd.Run = strings.Contains(cmdLineArgs.benchmark, "network")
+				if cmd.Run {
+					tmpl := template.Must(template.New("nicCommand").Parse(cmd.Command))
+					buf := new(bytes.Buffer)
+					err = tmpl.Execute(buf, struct {
+						Peer string
+					}{
+						Peer: cmdLineArgs.nicPeer,
+					})
+					if err != nil {
+						return
+					}
+					cmd.Command = buf.String()
+				}
 			} else if cmd.Label == "profile" {
 				cmd.Run = cmdLineArgs.profile != ""
 				if cmd.Run {
 					tmpl := template.Must(template.New("profileCommand").Parse(cmd.Command))
 					buf := new(bytes.Buffer)
 					err = tmpl.Execute(buf, struct {
-						Duration       int
-						Interval       int
-						ProfileCPU     bool
-						ProfileStorage bool
-						ProfileMemory  bool
-						ProfileNetwork bool
-						ProfilePMU     bool
-						ProfilePower   bool
+						Duration        int
+						Interval        int
+						ProfileCPU      bool
+						ProfileStorage  bool
+						ProfileMemory   bool
+						ProfileNetwork  bool
+						ProfilePMU      bool
+						ProfilePower    bool
+						ProfileEBPF     bool
+						ProfileCState   bool
+						ProfileWorkload bool
+					}{
+						Duration:        cmdLineArgs.profileDuration,
+						Interval:        cmdLineArgs.profileInterval,
+						ProfileCPU:      strings.Contains(cmdLineArgs.profile, "cpu") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileStorage:  strings.Contains(cmdLineArgs.profile, "storage") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileMemory:   strings.Contains(cmdLineArgs.profile, "memory") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileNetwork:  strings.Contains(cmdLineArgs.profile, "network") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfilePMU:      strings.Contains(cmdLineArgs.profile, "pmu") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfilePower:    strings.Contains(cmdLineArgs.profile, "power") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileEBPF:     strings.Contains(cmdLineArgs.profile, "ebpf") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileCState:   strings.Contains(cmdLineArgs.profile, "cstate") || strings.Contains(cmdLineArgs.profile, "all"),
+						ProfileWorkload: strings.Contains(cmdLineArgs.profile, "workload") || strings.Contains(cmdLineArgs.profile, "all"),
+					})
+					if err != nil {
+						return
+					}
+					cmd.Command = buf.String()
+				}
+			} else if cmd.Label == "topdown" {
+				cmd.Run = cmdLineArgs.topdown
+			} else if cmd.Label == "megadata profiling" {
+				cmd.Run = cmdLineArgs.megaProfilers != ""
+				if cmd.Run {
+					tmpl := template.Must(template.New("megadataProfilingCommand").Parse(cmd.Command))
+					buf := new(bytes.Buffer)
+					err = tmpl.Execute(buf, struct {
+						Delay     int
+						Duration  int
+						Interval  int
+						Mpstat    bool
+						Iostat    bool
+						Sar       bool
+						Turbostat bool
+						Perf      bool
 					}{
-						Duration:       cmdLineArgs.profileDuration,
-						Interval:       cmdLineArgs.profileInterval,
-						ProfileCPU:     strings.Contains(cmdLineArgs.profile, "cpu") || strings.Contains(cmdLineArgs.profile, "all"),
-						ProfileStorage: strings.Contains(cmdLineArgs.profile, "storage") || strings.Contains(cmdLineArgs.profile, "all"),
-						ProfileMemory:  strings.Contains(cmdLineArgs.profile, "memory") || strings.Contains(cmdLineArgs.profile, "all"),
-						ProfileNetwork: strings.Contains(cmdLineArgs.profile, "network") || strings.Contains(cmdLineArgs.profile, "all"),
-						ProfilePMU:     strings.Contains(cmdLineArgs.profile, "pmu") || strings.Contains(cmdLineArgs.profile, "all"),
-						ProfilePower:   strings.Contains(cmdLineArgs.profile, "power") || strings.Contains(cmdLineArgs.profile, "all"),
+						Delay:     cmdLineArgs.megaDelay,
+						Duration:  cmdLineArgs.megaDuration,
+						Interval:  cmdLineArgs.megaInterval,
+						Mpstat:    strings.Contains(cmdLineArgs.megaProfilers, "mpstat") || strings.Contains(cmdLineArgs.megaProfilers, "all"),
+						Iostat:    strings.Contains(cmdLineArgs.megaProfilers, "iostat") || strings.Contains(cmdLineArgs.megaProfilers, "all"),
+						Sar:       strings.Contains(cmdLineArgs.megaProfilers, "sar") || strings.Contains(cmdLineArgs.megaProfilers, "all"),
+						Turbostat: strings.Contains(cmdLineArgs.megaProfilers, "turbostat") || strings.Contains(cmdLineArgs.megaProfilers, "all"),
+						Perf:      strings.Contains(cmdLineArgs.megaProfilers, "perf") || strings.Contains(cmdLineArgs.megaProfilers, "all"),
 					})
 					if err != nil {
 						return
@@ -162,11 +340,63 @@ func customizeCommandYAML(cmdTemplate []byte, cmdLineArgs *CmdLineArgs, targetBi
 				}
 			}
 		}
+		// intensive commands would disturb the host's latency-critical traffic
+		if cmdLineArgs.gentle && cmd.Intensive {
+			cmd.Run = false
+		}
+		err = applyTemplateVars(cmd, cmdLineArgs.vars)
+		if err != nil {
+			return
+		}
 	}
 	customized, err = yaml.Marshal(cf)
 	return
 }
 
+var reVarReference = regexp.MustCompile(`{{[^}]*\.Var\b`)
+var reVarName = regexp.MustCompile(`\.Var\.(\w+)`)
+
+// reVarValue matches the variable values that can be substituted in commands, which run
+// in a root shell, e.g., interface names, addresses, and user names
+var reVarValue = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+// applyTemplateVars replaces references to user-supplied variables, e.g., {{.Var.nic}},
+// in the command. Commands that reference a variable that wasn't provided are not run.
+func applyTemplateVars(cmd *commandfile.Command, vars templateVars) (err error) {
+	if !cmd.Run || !reVarReference.MatchString(cmd.Command) {
+		return
+	}
+	for _, match := range reVarName.FindAllStringSubmatch(cmd.Command, -1) {
+		if value, ok := vars[match[1]]; ok && !reVarValue.MatchString(value) {
+			err = fmt.Errorf("-var %s=%s : invalid value for command '%s', the value may contain only letters, digits, '_', '.', ':', and '-'", match[1], value, cmd.Label)
+			return
+		}
+	}
+	tmpl, err := template.New(cmd.Label).Option("missingkey=error").Parse(cmd.Command)
+	if err != nil {
+		err = fmt.Errorf("failed to parse command '%s': %v", cmd.Label, err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	err = tmpl.Execute(buf, struct {
+		Var templateVars
+	}{
+		Var: vars,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "map has no entry for key") {
+			log.Printf("not running command '%s', variable not provided: %v", cmd.Label, err)
+			cmd.Run = false
+			err = nil
+			return
+		}
+		err = fmt.Errorf("failed to apply variables to command '%s': %v", cmd.Label, err)
+		return
+	}
+	cmd.Command = buf.String()
+	return
+}
+
 func (c *Collection) customizeCommandFile(cmdTemplate []byte, targetFilePath string, targetBinDir string) (err error) {
 	return customizeCmdFile(cmdTemplate, targetFilePath, targetBinDir, c.target.GetName(), c.cmdLineArgs)
 }
@@ -241,8 +471,35 @@ func hasPreReqs(t target.Target, preReqs []string) bool {
 }
 
 func (c *Collection) getCollectorOutputFile(workingDirectory string) (outputFilePath string, err error) {
-	outputFilePath = filepath.Join(c.outputDir, c.target.GetName()+".raw.json")
+	outputFilePath = filepath.Join(c.outputDir, c.target.GetName()+getRawFileExtension(c.cmdLineArgs.rawFormat))
 	err = c.target.PullFile(filepath.Join(workingDirectory, "collector.stdout"), outputFilePath)
+	if err != nil {
+		return
+	}
+	err = c.addCollectionResults(outputFilePath)
+	return
+}
+
+// addCollectionResults adds the schema version, the tags, and the versions of the
+// svr-info components to the collected data
+func (c *Collection) addCollectionResults(outputFilePath string) (err error) {
+	err = appendRawResult(outputFilePath, rawdata.GetSchemaVersionResult())
+	if err != nil {
+		return
+	}
+	err = addTags(outputFilePath, c.cmdLineArgs.tags)
+	if err != nil {
+		return
+	}
+	collectorFile, err := c.getCollectorFile()
+	if err != nil {
+		return
+	}
+	depsFile, err := c.getDepsFile()
+	if err != nil {
+		return
+	}
+	err = addComponents(outputFilePath, []string{filepath.Join(c.tempDir, "reporter"), collectorFile, depsFile})
 	return
 }
 
@@ -290,56 +547,110 @@ func (c *Collection) getExtrasFiles() (extras []string, err error) {
 	return
 }
 
+// getCollectorEnv returns the passwords that are provided to the collector through
+// environment variables
+func (c *Collection) getCollectorEnv() (env []string) {
+	if c.target.GetSudo() != "" {
+		env = append(env, "SUDO_PASSWORD="+c.target.GetSudo())
+	}
+	// used by the redfish bios settings command
+	if password := os.Getenv("REDFISH_PASSWORD"); password != "" {
+		env = append(env, "REDFISH_PASSWORD="+password)
+	}
+	return
+}
+
+// getStdinEnv returns the shell commands that read the values of the KEY=value
+// environment variables from stdin, one per line, and export them, and the input they
+// read, so that the values, e.g., passwords, aren't on the remote command line where ps
+// shows them
+func getStdinEnv(env []string) (command string, input string) {
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		command += fmt.Sprintf("IFS= read -r %[1]s && export %[1]s && ", key)
+		input += value + "\n"
+	}
+	return
+}
+
 func (c *Collection) runCollector(collectorFilePath string, yamlFilePath string, workingDirectory string) (stdout string, stderr string, err error) {
+	if replayTarget, ok := c.target.(*target.ReplayTarget); ok {
+		err = replayCollector(replayTarget, yamlFilePath, filepath.Join(workingDirectory, "collector.stdout"), c.cmdLineArgs.rawFormat)
+		return
+	}
 	var cmd *exec.Cmd
-	bashCmd := fmt.Sprintf("%s %s > collector.stdout", collectorFilePath, yamlFilePath)
+	bashCmd := fmt.Sprintf("%s%s %s > collector.stdout", collectorFilePath, c.getCollectorFlags(filepath.Dir(collectorFilePath)), yamlFilePath)
+	// at the -timeout deadline, timeout stops the collector and the commands it's running,
+	// the command's timeout stops the collection if timeout isn't available on the target
+	var timeout int
+	if c.cmdLineArgs.timeout > 0 {
+		timeout = c.cmdLineArgs.timeout + collectorStopGracePeriod*2
+		if hasPreReqs(c.target, []string{"timeout"}) {
+			bashCmd = fmt.Sprintf("timeout -k %d %d %s", collectorStopGracePeriod, c.cmdLineArgs.timeout, bashCmd)
+		} else {
+			log.Printf("timeout not found on %s, the collector may not stop at the deadline", c.target.GetName())
+			timeout = c.cmdLineArgs.timeout
+		}
+	}
+	env := c.getCollectorEnv()
 	tType := fmt.Sprintf("%T", c.target)
 	if tType == "*target.LocalTarget" {
 		cmd = exec.Command("bash", "-c", bashCmd)
-		if c.target.GetSudo() != "" {
-			cmd.Env = append(os.Environ(), "SUDO_PASSWORD="+c.target.GetSudo())
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
 		}
 		cmd.Dir = workingDirectory
 	} else { // RemoteTarget
-		if c.target.GetSudo() != "" {
-			cmd = exec.Command(fmt.Sprintf("cd %s && SUDO_PASSWORD=%s %s", workingDirectory, c.target.GetSudo(), bashCmd))
+		if len(env) > 0 {
+			readEnv, input := getStdinEnv(env)
+			cmd = exec.Command(fmt.Sprintf("cd %s && %s%s", workingDirectory, readEnv, bashCmd))
+			cmd.Stdin = strings.NewReader(input)
 		} else {
 			cmd = exec.Command(fmt.Sprintf("cd %s && %s", workingDirectory, bashCmd))
 		}
 	}
-	stdout, stderr, _, err = c.target.RunCommand(cmd)
+	start := time.Now()
+	stdout, stderr, exitCode, err := c.target.RunCommandWithTimeout(cmd, timeout)
+	if err != nil && timeout > 0 && (exitCode == timeoutExitCode || time.Since(start) >= time.Duration(c.cmdLineArgs.timeout)*time.Second) {
+		c.deadline = true
+		err = fmt.Errorf("%w, -timeout %d seconds", errCollectionDeadline, c.cmdLineArgs.timeout)
+	}
 	return
 }
 
-func (c *Collection) Collect() (err error) {
-	log.Printf("collection starting for target: %s", c.target.GetName())
-	if !c.target.CanConnect() {
-		err = fmt.Errorf("failed to connect to target: %s", c.target.GetName())
-		log.Print(err)
-		return
+// getCollectorFlags returns the collector's options, if any, with a leading space. The
+// collector's audit log, if enabled, is written in dir.
+func (c *Collection) getCollectorFlags(dir string) (flags string) {
+	if c.auditLog != nil {
+		flags += " -audit " + filepath.Join(dir, collectorAuditFile)
 	}
-	if !hasPreReqs(c.target, []string{"tar"}) {
-		err = fmt.Errorf("tar not found on target: %s", c.target.GetName())
-		log.Print(err)
-		return
+	if c.progressFile != "" {
+		flags += " -progress " + c.progressFile
 	}
-
-	if (strings.Contains(c.cmdLineArgs.analyze, "system") || strings.Contains(c.cmdLineArgs.analyze, "all")) &&
-		!hasPreReqs(c.target, []string{"perl"}) {
-		log.Printf("perl not found on target: %s. Analyze system requires perl to process data.", c.target.GetName())
+	if c.cmdLineArgs.capabilities {
+		flags += " -capabilities"
 	}
-
-	tempDir, err := c.target.CreateTempDirectory(c.cmdLineArgs.targetTemp)
-	if err != nil {
-		log.Printf("failed to create temporary directory for %s", c.target.GetName())
-		return
+	if c.cmdLineArgs.gentle {
+		flags += " -gentle"
 	}
-	defer c.cleanupTarget(tempDir)
+	if c.cmdLineArgs.readOnly {
+		flags += " -read_only"
+	}
+	if c.cmdLineArgs.rawFormat == rawFormatGob {
+		flags += " -gob"
+	}
+	return
+}
+
+// prepareTarget pushes the collector, its dependencies, the reports command file, and any
+// extra files to the directory on the target. The local path to the command file is
+// returned.
+func (c *Collection) prepareTarget(tempDir string) (commandFilePath string, err error) {
 	cmdTemplate, err := resources.ReadFile("resources/collector_reports.yaml.tmpl")
 	if err != nil {
 		return
 	}
-	commandFilePath := c.getCommandFilePath("_reports")
+	commandFilePath = c.getCommandFilePath("_reports")
 	err = c.customizeCommandFile(cmdTemplate, commandFilePath, tempDir)
 	if err != nil {
 		log.Print("failed to customize command file path")
@@ -405,94 +716,171 @@ func (c *Collection) Collect() (err error) {
 	} else {
 		log.Printf("Optional directory of extra collection files (%s) not found.", extrasDir)
 	}
+	return
+}
+
+// pullMegadata archives the megadata directory on the target, retrieves it, and extracts
+// it in the output directory
+func (c *Collection) pullMegadata(tempDir string, megaDir string) (err error) {
+	megadataTarball := filepath.Join(tempDir, c.target.GetName()+"_megadata.tgz")
+	cmd := exec.Command("tar", "-C", tempDir, "-czf", megadataTarball, megaDir)
+	_, _, _, err = c.target.RunCommand(cmd)
+	if err != nil {
+		log.Printf("failed to create megadata tarball")
+		return
+	}
+	err = c.target.PullFile(megadataTarball, c.outputDir)
+	if err != nil {
+		log.Printf("failed to retrieve megadata tarball")
+		return
+	}
+	err = c.target.PullFile(filepath.Join(tempDir, megaDir, "collector.log"), filepath.Join(c.outputDir, c.target.GetName()+"_megadata_collector.log"))
+	if err != nil {
+		log.Printf("failed to retrieve megadata collector.log")
+		return
+	}
+	cmd = exec.Command("tar", "-C", c.outputDir, "-xf", filepath.Join(c.outputDir, c.target.GetName()+"_megadata.tgz"))
+	_, _, _, err = target.RunLocalCommand(cmd)
+	if err != nil {
+		log.Printf("failed to extract megadata tarball")
+		return
+	}
+	cmd = exec.Command("rm", filepath.Join(c.outputDir, c.target.GetName()+"_megadata.tgz"))
+	_, _, _, err = target.RunLocalCommand(cmd)
+	if err != nil {
+		log.Printf("failed to remove megadata tarball")
+		return
+	}
+	return
+}
+
+func (c *Collection) Collect() (err error) {
+	log.Printf("collection starting for target: %s", c.target.GetName())
+	if !c.target.CanConnect() {
+		err = fmt.Errorf("failed to connect to target: %s", c.target.GetName())
+		log.Print(err)
+		return
+	}
+	if isWindowsTarget(c.target) {
+		err = c.collectWindows()
+		return
+	}
+	if !hasPreReqs(c.target, []string{"tar"}) {
+		err = notRetryable(fmt.Errorf("tar not found on target: %s", c.target.GetName()))
+		log.Print(err)
+		return
+	}
+	if err = c.checkRequiredPrivileges(); err != nil {
+		log.Print(err)
+		return
+	}
+
+	if (strings.Contains(c.cmdLineArgs.analyze, "system") || strings.Contains(c.cmdLineArgs.analyze, "all")) &&
+		!hasPreReqs(c.target, []string{"perl"}) {
+		log.Printf("perl not found on target: %s. Analyze system requires perl to process data.", c.target.GetName())
+	}
+
+	tempDir, err := c.target.CreateTempDirectory(c.cmdLineArgs.targetTemp)
+	if err != nil {
+		log.Printf("failed to create temporary directory for %s", c.target.GetName())
+		return
+	}
+	defer c.cleanupTarget(tempDir)
+	commandFilePath, err := c.prepareTarget(tempDir)
+	if err != nil {
+		return
+	}
+	var stopMonitor func()
+	if c.monitorsProgress() {
+		c.progressFile = filepath.Join(tempDir, collectorProgressFile)
+		stopMonitor = c.monitorCollector(c.progressFile)
+	}
 	c.stdout, c.stderr, err = c.runCollector(
 		filepath.Join(tempDir, "collector"),
 		filepath.Join(tempDir, filepath.Base(commandFilePath)),
 		tempDir,
 	)
-	if err != nil {
-		log.Printf("failed to run collector on %s, stderr: [%s]. "+
-			"Override the temporary directory used by svr-info with the "+
-			"--targettemp option if the target's temporary directory does "+
-			"not support binary execution.",
-			c.target.GetName(), c.stderr)
-		return
+	if stopMonitor != nil {
+		stopMonitor()
+		c.progressFile = ""
 	}
-	c.outputFilePath, err = c.getCollectorOutputFile(tempDir)
 	if err != nil {
-		log.Printf("failed to retrieve collector output file for %s", c.target.GetName())
-		return
-	}
-	if c.cmdLineArgs.megadata {
-		var cmdTemplate []byte
-		cmdTemplate, err = resources.ReadFile("resources/collector_megadata.yaml.tmpl")
-		if err != nil {
-			return
-		}
-		commandFilePath := c.getCommandFilePath("_megadata")
-		err = c.customizeCommandFile(cmdTemplate, commandFilePath, tempDir)
-		if err != nil {
-			log.Print("failed to customize command file path")
-			return
-		}
-		err = c.target.PushFile(commandFilePath, tempDir)
-		if err != nil {
-			log.Printf("failed to push megadata command file to temporary directory for %s", c.target.GetName())
-			return
-		}
-		megaDir := c.target.GetName() + "_" + "megadata"
-		var megaPath string
-		megaPath, err = c.target.CreateDirectory(tempDir, megaDir)
-		if err != nil {
-			log.Printf("failed to create megadata directory on %s", c.target.GetName())
-			return
-		}
-		// run collector in the megadata directory so output from commands will land in that directory
-		_, _, err = c.runCollector(
-			filepath.Join(tempDir, "collector"),
-			filepath.Join(tempDir, filepath.Base(commandFilePath)),
-			megaPath,
-		)
-		if err != nil {
-			log.Printf("failed to run megadata collector on %s, stderr: [%s]",
+		if c.deadline {
+			log.Printf("stopped the collector on %s: %v", c.target.GetName(), err)
+		} else {
+			log.Printf("failed to run collector on %s, stderr: [%s]. "+
+				"Override the temporary directory used by svr-info with the "+
+				"--targettemp option if the target's temporary directory does "+
+				"not support binary execution.",
 				c.target.GetName(), c.stderr)
-			return
 		}
-		megadataTarball := filepath.Join(tempDir, c.target.GetName()+"_megadata.tgz")
-		cmd := exec.Command("tar", "-C", tempDir, "-czf", megadataTarball, megaDir)
-		_, _, _, err = c.target.RunCommand(cmd)
-		if err != nil {
-			log.Printf("failed to create megadata tarball")
+		// keep the results collected before the collector failed or the deadline, if there
+		// are enough and a complete collection isn't required
+		if c.cmdLineArgs.requireComplete {
 			return
 		}
-		err = c.target.PullFile(megadataTarball, c.outputDir)
+		c.outputFilePath, err = c.getPartialOutputFile(tempDir, commandFilePath, err)
 		if err != nil {
-			log.Printf("failed to retrieve megadata tarball")
+			log.Printf("failed to keep partial collection for %s: %v", c.target.GetName(), err)
 			return
 		}
-		err = c.target.PullFile(filepath.Join(tempDir, megaDir, "collector.log"), filepath.Join(c.outputDir, c.target.GetName()+"_megadata_collector.log"))
-		if err != nil {
-			log.Printf("failed to retrieve megadata collector.log")
-			return
-		}
-		cmd = exec.Command("tar", "-C", c.outputDir, "-xf", filepath.Join(c.outputDir, c.target.GetName()+"_megadata.tgz"))
-		_, _, _, err = target.RunLocalCommand(cmd)
+	} else {
+		c.outputFilePath, err = c.getCollectorOutputFile(tempDir)
 		if err != nil {
-			log.Printf("failed to extract megadata tarball")
+			log.Printf("failed to retrieve collector output file for %s", c.target.GetName())
 			return
 		}
-		cmd = exec.Command("rm", filepath.Join(c.outputDir, c.target.GetName()+"_megadata.tgz"))
-		_, _, _, err = target.RunLocalCommand(cmd)
-		if err != nil {
-			log.Printf("failed to remove megadata tarball")
-			return
+	}
+	// the reports are created from the collected data even if the steps that follow fail
+	c.ok = true
+	if c.cmdLineArgs.megadata && !c.deadline {
+		if err := c.collectMegadata(tempDir); err != nil {
+			log.Printf("failed to collect megadata from %s: %v", c.target.GetName(), err)
 		}
 	}
-	err = c.target.PullFile(filepath.Join(tempDir, "collector.log"), filepath.Join(c.outputDir, c.target.GetName()+"_collector.log"))
+	c.pullAuditLog(tempDir)
+	if err := c.target.PullFile(filepath.Join(tempDir, "collector.log"), filepath.Join(c.outputDir, c.target.GetName()+"_collector.log")); err != nil {
+		log.Printf("failed to retrieve collector.log from %s: %v", c.target.GetName(), err)
+	}
+	return
+}
+
+// collectMegadata runs the collector with the megadata command file in a directory on
+// the target and retrieves the directory's content
+func (c *Collection) collectMegadata(tempDir string) (err error) {
+	cmdTemplate, err := resources.ReadFile("resources/collector_megadata.yaml.tmpl")
 	if err != nil {
-		log.Printf("failed to retrieve collector.log")
 		return
 	}
-	c.ok = true
+	commandFilePath := c.getCommandFilePath("_megadata")
+	err = c.customizeCommandFile(cmdTemplate, commandFilePath, tempDir)
+	if err != nil {
+		log.Print("failed to customize command file path")
+		return
+	}
+	err = c.target.PushFile(commandFilePath, tempDir)
+	if err != nil {
+		log.Printf("failed to push megadata command file to temporary directory for %s", c.target.GetName())
+		return
+	}
+	megaDir := c.target.GetName() + "_" + "megadata"
+	var megaPath string
+	megaPath, err = c.target.CreateDirectory(tempDir, megaDir)
+	if err != nil {
+		log.Printf("failed to create megadata directory on %s", c.target.GetName())
+		return
+	}
+	// run collector in the megadata directory so output from commands will land in that directory
+	_, _, err = c.runCollector(
+		filepath.Join(tempDir, "collector"),
+		filepath.Join(tempDir, filepath.Base(commandFilePath)),
+		megaPath,
+	)
+	if err != nil {
+		log.Printf("failed to run megadata collector on %s, stderr: [%s]",
+			c.target.GetName(), c.stderr)
+		return
+	}
+	err = c.pullMegadata(tempDir, megaDir)
 	return
 }
@@ -10,9 +10,12 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/intel/svr-info/internal/core"
+	"github.com/intel/svr-info/internal/target"
 	"github.com/intel/svr-info/internal/util"
 )
 
@@ -20,50 +23,189 @@ type CmdLineArgs struct {
 	help             bool
 	version          bool
 	format           string
+	highlight        string
+	microcode        string
+	instanceTypes    string
+	healthWeights    string
+	remediation      bool
+	collect          string
 	benchmark        string
 	storageDir       string
+	nicPeer          string
+	reference        string
+	refThreshold     float64
 	profile          string
 	profileDuration  int
 	profileInterval  int
 	analyze          string
 	analyzeDuration  int
 	analyzeFrequency int
+	topdown          bool
 	all              bool
 	ipAddress        string
 	port             int
 	user             string
 	key              string
+	interactiveAuth  bool
+	transport        string
+	proxy            string
+	jumpHost         string
+	auth             string
+	fips             bool
+	targetOS         string
 	targets          string
+	targetsCmd       string
+	targetsFrom      string
+	targetsFilter    string
+	targetsRegion    string
+	targetsAddress   string
+	targetsSelect    string
 	megadata         bool
+	megaProfilers    string
+	megaDuration     int
+	megaInterval     int
+	megaDelay        int
+	detach           bool
+	triggers         triggerConditions
+	triggerTimeout   int
+	daemon           bool
+	schedule         string
+	blackout         string
+	rolling          int
+	maxParallel      int
+	retries          int
+	powerOn          bool
+	powerOnTimeout   int
+	restorePower     bool
+	metricsAddress   string
 	output           string
 	targetTemp       string
 	temp             string
 	printConfig      bool
 	noConfig         bool
 	cmdTimeout       int
+	timeout          int
+	gentle           bool
 	reporter         string
 	collector        string
 	debug            bool
+	auditLog         string
+	progress         string
+	progressFile     string
+	requireRoot      bool
+	requireComplete  bool
+	capabilities     bool
+	readOnly         bool
+	tags             string
+	rawFormat        string
+	keepLast         int
+	replay           string
+	vars             templateVars
+	keepRawData      bool   // not a flag, the snapshot command and daemon runs keep the *.raw.json files
+	group            string // not a flag, the target's group in the targets file
+	bmcHost          string // not a flag, the target's BMC in the targets file, for -power_on
+	bmcUser          string // not a flag, per target in the targets file
+	bmcPassword      string // not a flag, per target in the targets file
+	wolMAC           string // not a flag, the target's MAC address in the targets file, for -power_on
 }
 
-var benchmarkTypes = []string{"cpu", "frequency", "memory", "storage", "turbo", "all"}
-var profileTypes = []string{"cpu", "network", "storage", "memory", "pmu", "power", "all"}
+// templateVars holds the user-supplied -var key=value pairs that are available to
+// collector commands as {{.Var.key}}
+type templateVars map[string]string
+
+var reVarKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (v *templateVars) String() string {
+	var pairs []string
+	for key, value := range *v {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v *templateVars) Set(pair string) error {
+	key, value, found := strings.Cut(pair, "=")
+	if !found || !reVarKey.MatchString(key) {
+		return fmt.Errorf("must be in key=value format, where key is alphanumeric, e.g., nic=eth0")
+	}
+	if strings.ContainsAny(value, "\n\r") {
+		return fmt.Errorf("value must not contain newlines")
+	}
+	if *v == nil {
+		*v = make(templateVars)
+	}
+	(*v)[key] = value
+	return nil
+}
+
+var benchmarkTypes = []string{"cpu", "frequency", "memory", "storage", "turbo", "network", "all"}
+var profileTypes = []string{"cpu", "network", "storage", "memory", "pmu", "power", "ebpf", "cstate", "workload", "all"}
 var analyzeTypes = []string{"system", "java", "all"}
+var megadataProfilerTypes = []string{"mpstat", "iostat", "sar", "turbostat", "perf", "all"}
+var collectCategories = []string{"system", "software", "cpu", "power", "memory", "network", "storage", "security", "status", "benchmarks", "all"}
+
+// the operating systems of remote targets
+const (
+	targetOSLinux   = "linux"
+	targetOSWindows = "windows"
+)
+
+var targetOSTypes = []string{targetOSLinux, targetOSWindows}
 
 func showUsage() {
-	fmt.Fprintf(os.Stderr, "usage: %s [-h] [-v]\n", filepath.Base(os.Args[0]))
-	fmt.Fprintf(os.Stderr, "                [-format SELECT]\n")
-	fmt.Fprintf(os.Stderr, "                [-benchmark SELECT] [-storage_dir DIR]\n")
+	fmt.Fprintf(os.Stderr, "usage: %s COMMAND [-h] [arguments]\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "       %s [collect] [-h] [-v]\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(os.Stderr, "                [-format SELECT] [-highlight RULES] [-microcode TABLE] [-instance_types TABLE]\n")
+	fmt.Fprintf(os.Stderr, "                [-health_weights WEIGHTS] [-remediation]\n")
+	fmt.Fprintf(os.Stderr, "                [-benchmark SELECT] [-storage_dir DIR] [-nic_peer ADDRESS] [-reference RESULTS]\n")
+	fmt.Fprintf(os.Stderr, "                [-reference_threshold PERCENT]\n")
 	fmt.Fprintf(os.Stderr, "                [-profile SELECT] [-profile_duration SECONDS] [-profile_interval N]\n")
-	fmt.Fprintf(os.Stderr, "                [-analyze SELECT] [-analyze_duration SECONDS] [-analyze_frequency N]\n")
-	fmt.Fprintf(os.Stderr, "                [-megadata]\n")
-	fmt.Fprintf(os.Stderr, "                [-ip IP] [-port PORT] [-user USER] [-key KEY] [-targets TARGETS]\n")
-	fmt.Fprintf(os.Stderr, "                [-output OUTPUT] [-temp TEMP] [-targettemp TEMP] [-printconfig] [-noconfig] [-cmd_timeout]\n")
+	fmt.Fprintf(os.Stderr, "                [-analyze SELECT] [-analyze_duration SECONDS] [-analyze_frequency N] [-topdown]\n")
+	fmt.Fprintf(os.Stderr, "                [-megadata] [-megadata_profilers SELECT] [-megadata_duration SECONDS]\n"+
+		"                [-megadata_interval SECONDS] [-megadata_delay SECONDS] [-detach]\n"+
+		"                [-trigger CONDITION] [-trigger_timeout SECONDS]\n"+
+		"                [-daemon] [-schedule CRON] [-blackout CRON] [-metrics_address ADDRESS]\n")
+	fmt.Fprintf(os.Stderr, "                [-ip IP] [-port PORT] [-user USER] [-key KEY] [-targets TARGETS] [-targets_cmd CMD] [-interactive_auth]\n")
+	fmt.Fprintf(os.Stderr, "                [-targets_from aws|gcp|azure] [-targets_filter TAGS] [-targets_region REGION] [-targets_address SELECT]\n")
+	fmt.Fprintf(os.Stderr, "                [-targets_select NAMES]\n")
+	fmt.Fprintf(os.Stderr, "                [-transport SELECT] [-proxy URL] [-jump_host HOST] [-auth SELECT] [-fips] [-os SELECT]\n")
+	fmt.Fprintf(os.Stderr, "                [-require_root] [-require_complete] [-rolling N] [-max_parallel N] [-retries N]\n")
+	fmt.Fprintf(os.Stderr, "                [-power_on] [-power_on_timeout SECONDS] [-restore_power]\n")
+	fmt.Fprintf(os.Stderr, "                [-output OUTPUT] [-temp TEMP] [-targettemp TEMP] [-printconfig] [-noconfig] [-collect SELECT]\n")
+	fmt.Fprintf(os.Stderr, "                [-cmd_timeout] [-timeout SECONDS] [-gentle] [-var KEY=VALUE]\n")
 	fmt.Fprintf(os.Stderr, "                [-reporter \"args\"] [-collector \"args\"] [-debug]\n")
+	fmt.Fprintf(os.Stderr, "                [-audit_log FILE] [-capabilities] [-read_only] [-tags TAGS]\n")
+	fmt.Fprintf(os.Stderr, "                [-progress SELECT] [-progress_file FILE]\n")
+	fmt.Fprintf(os.Stderr, "                [-raw_format SELECT] [-keep_last N] [-replay CAPTURES]\n")
 
 	longHelp := `
 Intel System Health Inspector. Creates configuration, benchmark, profile, analysis, and insights reports for one or more systems.
 
+commands:
+  collect               collect data and create reports, the default when the first
+                        argument is a flag, its arguments are described below
+  report                create reports from previously collected data (*.raw.json)
+  diff                  create reports that compare two or more systems side by side
+  query                 print selected values, e.g., 'Operating System.Kernel' 'CPU.Microcode',
+                        for every host in previously collected data, as CSV or JSON
+  check                 verify that targets are reachable and that elevated privileges
+                        are available, without collecting data
+  serve                 serve the reports in an output directory over HTTP
+  snapshot pre|post     collect quick configuration snapshots before and after a
+                        maintenance activity, 'snapshot post -compare' creates a report
+                        of only the configuration changes
+  package               archive an output directory for sharing
+  import ARCHIVE...     add the data in archives of previous runs to the daemon's output
+                        directory, for its report history and reports, diffs, and queries
+  fetch                 retrieve the data from detached collections that have finished and
+                        create reports, takes the same target arguments as collect
+  init                  answer a few questions to configure a run, write a targets file
+                        if collecting from remote systems, then optionally start the run
+  completion SHELL      print the completion script for bash, zsh, or fish, e.g.,
+                        source <(./%[1]s completion bash)
+  Run '%[1]s COMMAND -h' for the arguments of each command.
+
 general arguments:
   -h                    show this help message and exit
   -v                    show version number and exit
@@ -71,15 +213,52 @@ general arguments:
 report arguments:
   -format SELECT        comma separated list of desired output format(s): %[2]s,
                         e.g., -format json (default: html,xlsx,json)
+  -highlight RULES      path to YAML file containing rules for highlighting values in
+                        the HTML and xlsx reports (default: Nil)
+  -microcode TABLE      path to YAML file containing current microcode revisions by CPU
+                        family, model, and stepping. Overrides the table bundled with the
+                        reporter. (default: Nil)
+  -instance_types TABLE path to YAML file containing the specifications of cloud instance
+                        types, e.g., vCPUs, memory, and local NVMe drives. Overrides the
+                        table bundled with the reporter. (default: Nil)
+  -health_weights WEIGHTS
+                        path to YAML file containing the weights of the health grade,
+                        i.e., the points deducted for insights, error counters, and
+                        benchmark regressions. Overrides the weights bundled with the
+                        reporter. (default: Nil)
+  -remediation          write a shell script per target containing the commands that
+                        implement the insights' recommendations, e.g., sysctl, frequency
+                        governor, and IRQ affinity changes. The scripts are for review
+                        and are never run by svr-info. (default: False)
 
 benchmark arguments:
   -benchmark SELECT     comma separated list of benchmarks: %[3]s,
                         e.g., -benchmark cpu,turbo (default: None)
+                        network measures each connected NIC's throughput against its rated speed
+                        and isn't included in all because it saturates the NICs.
   -storage_dir DIR      Path to directory on target (default: -temp DIR)
+  -reference RESULTS    path to JSON file containing reference benchmark results, e.g., vendor-claimed
+                        or lab-measured, for acceptance testing. The benchmark Summary table lists
+                        each metric's reference value and delta, and the metrics that are worse
+                        than the reference by more than -reference_threshold. (default: Nil)
+  -reference_threshold PERCENT
+                        percent by which a benchmark metric can be worse than the -reference
+                        result before it's listed as below the reference (default: 5)
+  -nic_peer ADDRESS     IPv4 address or hostname of a peer running 'iperf3 -s'. The network
+                        benchmark sends to the peer from each NIC with iperf3. Without a peer,
+                        each NIC transmits frames addressed to itself with pktgen, except
+                        the NICs that carry the default route or an SSH connection.
+                        (default: None)
 
 profile arguments:
   -profile SELECT       comma separated list of profile options: %[4]s,
                         e.g., -profile cpu,memory (default: None)
+                        ebpf runs bpftrace collectors for run queue latency, block IO latency,
+                        off-CPU time, and TCP retransmits on kernels with BTF (CO-RE) support.
+                        cstate collects C-state residency, P-state (frequency) distribution,
+                        and energy performance preference (EPP).
+                        workload characterizes what the system was doing, e.g., CPU, memory, or
+                        IO bound, from thread counts, syscall rates, and the top syscalls.
   -profile_duration N   time, in seconds, to collect profiling data (default: 60)
   -profile_interval N   the amount of time in seconds between each sample (default: 2)
 
@@ -88,12 +267,59 @@ analyze arguments:
                         e.g., -analyze system,java (default: None)
   -analyze_duration N   time, in seconds, to collect analysis data (default: 60)
   -analyze_frequency N  the number of samples taken per second (default: 11)
+  -topdown              measure the workload's top-down microarchitecture analysis (TMA) level 1
+                        and 2 breakdown per socket for 10 seconds with perf: frontend bound, bad
+                        speculation, backend bound, and retiring, reported in the CPU Efficiency
+                        section of the configuration report. Intel CPUs only. (default: False)
 
 additional data collection arguments:
   -megadata             collect additional data in megadata directory (default: False)
+  -megadata_profilers SELECT
+                        comma separated list of profilers to run during megadata collection:
+                        %[6]s, e.g., -megadata_profilers mpstat,perf (default: None)
+  -megadata_duration N  time, in seconds, to run the megadata profilers (default: 60)
+  -megadata_interval N  the amount of time in seconds between each megadata profiler sample (default: 2)
+  -megadata_delay N     time, in seconds, to wait before starting the megadata profilers, e.g.,
+                        to skip workload warmup (default: 0)
+                        The megadata options can be overridden per target in the targets file.
+  -detach               start the collection in the background on the targets and exit. The
+                        data is buffered on the targets, in the -targettemp directory, until
+                        retrieved with the fetch command, so hours-long megadata collections
+                        survive disconnects. Requires -megadata. (default: False)
+  -trigger CONDITION    wait on the target until the condition fires, then start the detached
+                        collection, to capture the system state at the moment of an incident.
+                        Conditions: load=N (1 minute load average exceeds N), temp=N (a
+                        thermal sensor exceeds N degrees C), dmesg=REGEX (a new kernel message
+                        matches the extended regular expression, e.g., dmesg='Machine Check').
+                        Can be repeated, the first condition that fires starts the collection.
+                        Requires -detach. (default: None)
+  -trigger_timeout N    time, in seconds, to wait for a trigger condition (default: 86400)
+
+daemon arguments:
+  -daemon               run until interrupted, collecting from each target on its schedule into
+                        a timestamped subdirectory of the output directory. Targets that are
+                        due at the same time are collected together. (default: False)
+  -schedule CRON        when to collect, a cron expression: minute hour day-of-month month
+                        day-of-week, e.g., '0 2 * * 6' for 2 AM every Saturday, or @daily.
+                        Can be set per target in the targets file. Requires -daemon.
+                        (default: Nil)
+  -blackout CRON        semicolon separated list of cron expressions matching the minutes during
+                        which collections must not start, e.g., '* 8-17 * * 1-5' for business
+                        hours. Scheduled runs that fall in a blackout window are skipped. Can be
+                        set per target in the targets file. Requires -daemon. (default: Nil)
+  -metrics_address ADDRESS
+                        serve the daemon's health at /healthz and Prometheus metrics at /metrics,
+                        e.g., runs started, succeeded, and failed, run durations, and queue depth,
+                        on the address, e.g., localhost:9100 or :9100 for all interfaces. The
+                        values in the runs' JSON reports are served at /grafana for Grafana's
+                        JSON datasource plugin, on other than a loopback address only if the
+                        SVR_INFO_API_TOKEN environment variable is set, requests must then
+                        include the token. Requires -daemon. (default: Nil)
 
 remote target arguments:
-  -ip IP                ip address or hostname (default: Nil)
+  -ip IP                ip address or hostname. May contain ranges in brackets, e.g.,
+                        node[01-48].dc1.example.com, to collect from each host with the
+                        same settings. (default: Nil)
   -port PORT            ssh port (default: 22)
   -user USER            user on remote target (default: Nil)
   -key KEY              local path to ssh private key file (default: Nil)
@@ -101,22 +327,188 @@ remote target arguments:
                         Line format: 
                            '<label:>ip_address:ssh_port:user_name:private_key_path:ssh_password:sudo_password'
                               - Provide private_key_path or ssh_password.
+                        A file named *.yaml or *.yml is in the YAML format, where each
+                        target's settings are named and can include a jump host, tags, and
+                        the benchmarks, profiles, and analyses to run, see
+                        targets.example.yaml. A target's ip address or hostname may
+                        contain ranges in brackets, as with -ip.
                         If provided, overrides single target arguments. (default: Nil)
+  -targets_cmd CMD      a command, run with sh, that writes the targets to stdout in JSON, e.g.,
+                        a query of an inventory system. The targets have the settings of a
+                        YAML targets file, as a list or as an object with a "targets" list,
+                        e.g., [{"ip": "192.168.1.1", "user": "elaine"}]. The command is run
+                        once, when svr-info starts, restart a -daemon to pick up new targets.
+                        Mutually exclusive with -ip and -targets. (default: Nil)
+  -targets_from PROVIDER
+                        collect from the running instances in a cloud provider's inventory:
+                        aws, gcp, or azure. The instances are listed with the provider's CLI,
+                        aws, gcloud, or az, which must be installed and logged in, when
+                        svr-info starts. They're reached with the -user, -key, and -port
+                        arguments. Mutually exclusive with -ip, -targets, and -targets_cmd.
+                        (default: Nil)
+  -targets_filter TAGS  comma separated list of KEY=VALUE tags (labels in gcp) that the
+                        -targets_from instances must have, e.g., env=prod,role=db. (default: Nil)
+  -targets_region REGION
+                        collect only from the -targets_from instances in the region, e.g.,
+                        us-east-1, us-central1, or eastus. (default: all regions the CLI lists)
+  -targets_address SELECT
+                        the -targets_from instances' address to connect to: private or public.
+                        With -transport ssm, aws instances are reached by their instance ID.
+                        (default: private)
+  -targets_select NAMES comma separated list of the names of the targets to collect from, of
+                        those in -targets, -targets_cmd, or -targets_from. A target's name is
+                        its label, or its ip address or hostname if it has no label.
+                        (default: all targets)
+  -interactive_auth     prompt for keyboard-interactive authentication, e.g., password and
+                        one-time passcode, when connecting to remote targets. Requires a
+                        terminal. (default: False)
+  -transport SELECT     how to reach remote targets: %[7]s. With ssm, the SSH connection is
+                        tunneled through an AWS Systems Manager session, so the target doesn't
+                        need an open inbound SSH port, and -ip is the EC2 instance ID. The
+                        target must still run an SSH server, e.g., listening on localhost, that
+                        accepts the -user's key or password. Requires the AWS CLI and Session
+                        Manager plugin. Azure Run Command isn't supported. Can be set per
+                        target in the targets file. (default: ssh)
+  -proxy URL            connect to remote targets through a SOCKS5 or HTTP CONNECT proxy,
+                        e.g., socks5://proxy.example.com:1080 or http://proxy.example.com:3128.
+                        Requires OpenBSD netcat (nc). Can be set per target in the targets
+                        file. (default: Nil)
+  -jump_host HOST       connect to remote targets through an SSH jump host, e.g., a bastion,
+                        given as [USER@]HOST[:PORT]. The jump host is authenticated with the
+                        -key or the SSH agent. Not supported with -proxy or the ssm
+                        transport. Can be set per target in the targets file. (default: Nil)
+  -auth SELECT          how to authenticate to remote targets: %[8]s. With gssapi, the
+                        user's Kerberos ticket is used, e.g., where password and key
+                        authentication are disabled by policy. Get a ticket with kinit first.
+                        Targets must be identified by their fully qualified host names. Can be
+                        set per target in the targets file. (default: default, i.e., key or
+                        password)
+  -fips                 use only FIPS 140 approved algorithms to reach remote targets, for
+                        deployments that require FIPS validated cryptography. ssh is restricted
+                        to approved ciphers, MACs, key exchange, and host and user key types,
+                        and refuses targets that don't support them. The local system must be
+                        in FIPS mode so that ssh uses its validated module. With -transport ssm,
+                        the AWS CLI uses the FIPS endpoints. Requires the FIPS build of svr-info,
+                        i.e., built with BoringCrypto. (default: False)
+  -os SELECT            the operating system of remote targets: %[12]s. Windows targets are reached
+                        with the OpenSSH server that ships with Windows, and their data is collected
+                        with PowerShell, e.g., the CPU, memory, and platform inventory. Can't be used
+                        with -benchmark, -profile, -analyze, -megadata, -detach, or -gentle. Can be
+                        set per target in the targets file. (default: linux)
+  -require_root         fail a target's collection, instead of collecting without elevated
+                        privileges, if the user isn't root on the target and can't use sudo.
+                        Can be set per target in the targets file. (default: False)
+  -require_complete     fail a target's collection, instead of creating reports with missing
+                        data, if data items that require elevated privileges would be skipped,
+                        or the collection is interrupted. The data items that would be skipped
+                        on each target are listed before collection starts. Commands skipped
+                        with -read_only aren't counted. Can be set per target in the targets
+                        file. (default: False)
+  -rolling N            start at most N target collections per hour, evenly spaced, instead of
+                        collecting from all targets at once, to limit the aggregate network and
+                        CPU impact on a production fleet, e.g., -rolling 20 collects from 100
+                        targets over 5 hours. The reports are created when all collections
+                        have finished. (default: 0, all at once)
+  -max_parallel N       collect from at most N targets at once. The other targets wait for a
+                        collection to finish, to protect the local system and network when the
+                        targets file lists hundreds of hosts. (default: 0, no limit)
+  -retries N            retry a target's collection that fails, e.g., because the SSH connection
+                        was dropped, up to N times, waiting 10 seconds before the first retry
+                        and twice as long before each retry that follows, up to 5 minutes.
+                        (default: 0)
+  -power_on             power on the targets that can't be reached, e.g., a pool of spares, and
+                        wait for SSH before collecting. A target is powered on with ipmitool
+                        through its BMC, if bmc_host, bmc_user, and bmc_password are set for it
+                        in the targets file, or woken with a Wake-on-LAN magic packet, if
+                        wol_mac is set. Targets that don't come up are skipped. (default: False)
+  -power_on_timeout SECONDS
+                        how long to wait for SSH on the targets that were powered on.
+                        (default: 600)
+  -restore_power        power off the targets that were powered on by -power_on after
+                        collecting, through the BMC, or by shutting down the target if it was
+                        woken with Wake-on-LAN. (default: False)
 
 advanced arguments:
   -output DIR           path to output directory. Directory must exist. (default: $PWD/orchestrator_timestamp)
+  -keep_last N          keep only the newest N timestamped output directories, i.e., those created
+                        in the working directory when -output isn't specified and the daemon's
+                        run directories, removing older ones. Temporary directories left by runs
+                        that were interrupted or run with -debug are removed after 24 hours
+                        regardless. (default: 0, keep all)
   -temp DIR             path to temporary directory on localhost. Directory must exist. (default: system default)
   -targettemp DIR       path to temporary directory on target. Directory must exist. (default: system default)
   -printconfig          print the collector configuration file and exit (default: False)
   -noconfig             do not collect system configuration data. (default: False)
+  -collect SELECT       comma separated list of the categories of configuration data to collect:
+                        %[11]s,
+                        e.g., -collect cpu,memory,security. Skipping the other categories' commands
+                        shortens the collection. The host's identity, e.g., its OS, CPU, and
+                        platform, is always collected. benchmarks runs the -benchmark selection,
+                        all if not set. all collects every category but runs only the -benchmark
+                        selection, as if -collect isn't set. (default: all)
   -cmd_timeout          the maximum number of seconds to wait for each data collection command (default: 300)
+  -timeout SECONDS      the maximum number of seconds to collect data from each target. The collection
+                        is stopped at the deadline, and the target's reports are created from the data
+                        collected so far, with the other commands marked not collected. (default: 0, no limit)
+  -gentle               collect from hosts serving latency-critical traffic: the commands run one at a
+                        time, at the lowest CPU and I/O priority (nice and ionice), with a pause between
+                        them, and the intensive commands, e.g., the vulnerability checker, aren't run.
+                        Can't be used with -benchmark, -analyze, -profile, -topdown, or -megadata.
+                        (default: False)
+  -var KEY=VALUE        set a variable that collector commands can reference as {{.Var.KEY}}, e.g.,
+                        -var nic=eth0 enables the NIC deep dive in megadata collection. Commands
+                        that reference a variable that isn't set are skipped. The values they
+                        reference may contain only letters, digits, '_', '.', ':', and '-'.
+                        Can be repeated. (default: None)
   -reporter             run the the reporter sub-component with args
                         e.g., -reporter "-input /home/rex -output /home/rex -format html" (default: Nil)
   -collector            run the the collector sub-component with args
                         e.g., -collector "collect.yaml" (default: Nil)
   -debug                additional logging and retain temporary files (default: False)
+  -audit_log FILE       append a JSON object per line (NDJSON) to FILE for each command executed
+                        on each target, including the commands run by the collector, with the
+                        target, user, sudo, start and end times, and exit code. Suitable for
+                        SIEM ingestion. (default: Nil)
+  -progress SELECT      how the targets' statuses are shown: %[10]s. With json, a JSON object
+                        is written to stdout per line for each change of a target's status,
+                        with the target, phase, timestamp, and error, if any, instead of
+                        the terminal spinners, e.g., for automation. The reports are listed
+                        in the last object. Other messages are printed to stderr.
+                        (default: spinner)
+  -progress_file FILE   with -progress json, append the JSON objects to FILE instead of
+                        writing them to stdout. (default: Nil)
+  -capabilities         run the collector commands that are annotated with the Linux capabilities
+                        they need with only those capabilities instead of full root privileges.
+                        The capabilities are limited with setpriv, run with sudo when the
+                        collector isn't running as root. A sudo rule that allows only setpriv
+                        still grants full root, e.g., sudo setpriv bash. Only granting the
+                        collector the capabilities with setcap avoids sudo. Commands that
+                        aren't annotated use sudo.
+                        The Privileges table reports how each elevated command ran.
+                        (default: False)
+  -read_only            don't run the collector commands that are known to have side effects, i.e.,
+                        writing to disk outside of svr-info's temporary directory, writing MSRs,
+                        changing kernel settings, or loading kernel modules. Commands that require
+                        a kernel module that isn't loaded are skipped. Benchmarks that have side
+                        effects are skipped. The reports state the guarantee. (default: False)
+  -tags TAGS            comma separated list of key=value tags saved with each target's data,
+                        e.g., env=prod,rack=12, for selecting hosts later with the report
+                        command's -filter option. Can be set per target in the targets file.
+                        (default: Nil)
+  -raw_format SELECT    format of the collected data (raw) files: %[9]s. The gob format is a
+                        compact, compressed binary format that is streamed from the collector and
+                        parsed by the reporter faster than JSON, for large collections, e.g.,
+                        with profiling. Reports are unaffected. (default: json)
+  -replay CAPTURES      instead of collecting from targets, replay previously collected data, a
+                        raw.json or raw.gob file or a directory of them, e.g., a corpus of
+                        collections from real-world hosts. Each captured host is a target whose
+                        collector output is the captured results of the commands that would
+                        run. Nothing is run, so parsers and reports can be developed and
+                        regression tested without the hosts. (default: Nil)
 
 Examples:
+$ ./%[1]s init
+    New users: answer a few questions to configure and start a run.
 $ ./%[1]s
     Collect configuration data on local machine.
 $ ./%[1]s -benchmark all
@@ -127,11 +519,27 @@ $ ./%[1]s -format all
     Collect configuration data on local machine. Generate all report formats.
 $ ./%[1]s -ip 198.51.100.255 -port 22 -user user83767 -key ~/.ssh/id_rsa
     Collect configuration data on one remote target.
+$ ./%[1]s -megadata -megadata_profilers all -megadata_duration 28800 -megadata_interval 30 -detach -targets ./targets
+    Start an 8 hour megadata collection on remote machines and disconnect.
+$ ./%[1]s fetch -targets ./targets
+    Later, retrieve the data from the detached collection and create reports.
+$ ./%[1]s -megadata -megadata_profilers all -detach -trigger load=64 -trigger dmesg='mce:' -targets ./targets
+    Collect data on remote machines when load spikes or a machine check is logged.
+$ ./%[1]s -daemon -schedule '0 2 * * 6' -blackout '* * * 12 *' -targets ./targets
+    Collect from remote machines every Saturday at 2 AM, except in December.
+$ ./%[1]s check -targets ./targets
+    Verify that the remote machines defined in targets file are reachable.
+$ ./%[1]s diff host1.raw.json host2.raw.json
+    Create reports that compare two previously collected machines.
 `
-	fmt.Fprintf(os.Stderr, longHelp, filepath.Base(os.Args[0]), strings.Join(core.ReportTypes, ","), strings.Join(benchmarkTypes, ","), strings.Join(profileTypes, ","), strings.Join(analyzeTypes, ","))
+	fmt.Fprintf(os.Stderr, longHelp, filepath.Base(os.Args[0]), strings.Join(core.ReportTypes, ","), strings.Join(benchmarkTypes, ","), strings.Join(profileTypes, ","), strings.Join(analyzeTypes, ","), strings.Join(megadataProfilerTypes, ","), strings.Join(target.Transports, ","), strings.Join(target.AuthMethods, ","), strings.Join(rawFormats, ","), strings.Join(progressModes, ","), strings.Join(collectCategories, ","), strings.Join(targetOSTypes, ","))
 }
 
 func showVersion() {
+	if gFIPSBuild {
+		fmt.Println(gVersion + " (FIPS)")
+		return
+	}
 	fmt.Println(gVersion)
 }
 
@@ -140,8 +548,9 @@ func newCmdLineArgs() *CmdLineArgs {
 	return &cmdLineArgs
 }
 
-func (cmdLineArgs *CmdLineArgs) parse(name string, arguments []string) (err error) {
-	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+// newFlagSet defines the command line flags, bound to the fields of cmdLineArgs
+func (cmdLineArgs *CmdLineArgs) newFlagSet(name string) (flagSet *flag.FlagSet) {
+	flagSet = flag.NewFlagSet(name, flag.ContinueOnError)
 	flagSet.Usage = func() { showUsage() } // override default usage output
 	flagSet.BoolVar(&cmdLineArgs.help, "h", false, "")
 	flagSet.BoolVar(&cmdLineArgs.version, "v", false, "")
@@ -150,26 +559,85 @@ func (cmdLineArgs *CmdLineArgs) parse(name string, arguments []string) (err erro
 	flagSet.StringVar(&cmdLineArgs.targetTemp, "targettemp", "", "")
 	flagSet.BoolVar(&cmdLineArgs.printConfig, "printconfig", false, "")
 	flagSet.BoolVar(&cmdLineArgs.noConfig, "noconfig", false, "")
+	flagSet.StringVar(&cmdLineArgs.collect, "collect", "", "")
 	flagSet.IntVar(&cmdLineArgs.cmdTimeout, "cmd_timeout", 300, "")
+	flagSet.IntVar(&cmdLineArgs.timeout, "timeout", 0, "")
+	flagSet.BoolVar(&cmdLineArgs.gentle, "gentle", false, "")
 	flagSet.StringVar(&cmdLineArgs.format, "format", "html,xlsx,json", "")
+	flagSet.StringVar(&cmdLineArgs.highlight, "highlight", "", "")
+	flagSet.StringVar(&cmdLineArgs.microcode, "microcode", "", "")
+	flagSet.StringVar(&cmdLineArgs.instanceTypes, "instance_types", "", "")
+	flagSet.StringVar(&cmdLineArgs.healthWeights, "health_weights", "", "")
+	flagSet.BoolVar(&cmdLineArgs.remediation, "remediation", false, "")
 	flagSet.StringVar(&cmdLineArgs.benchmark, "benchmark", "", "")
 	flagSet.StringVar(&cmdLineArgs.profile, "profile", "", "")
 	flagSet.StringVar(&cmdLineArgs.analyze, "analyze", "", "")
 	flagSet.StringVar(&cmdLineArgs.storageDir, "storage_dir", "", "")
+	flagSet.StringVar(&cmdLineArgs.nicPeer, "nic_peer", "", "")
+	flagSet.StringVar(&cmdLineArgs.reference, "reference", "", "")
+	flagSet.Float64Var(&cmdLineArgs.refThreshold, "reference_threshold", 5, "")
 	flagSet.BoolVar(&cmdLineArgs.all, "all", false, "")
 	flagSet.StringVar(&cmdLineArgs.ipAddress, "ip", "", "")
 	flagSet.IntVar(&cmdLineArgs.port, "port", 22, "")
 	flagSet.StringVar(&cmdLineArgs.user, "user", "", "")
 	flagSet.StringVar(&cmdLineArgs.key, "key", "", "")
 	flagSet.StringVar(&cmdLineArgs.targets, "targets", "", "")
+	flagSet.StringVar(&cmdLineArgs.targetsCmd, "targets_cmd", "", "")
+	flagSet.StringVar(&cmdLineArgs.targetsFrom, "targets_from", "", "")
+	flagSet.StringVar(&cmdLineArgs.targetsFilter, "targets_filter", "", "")
+	flagSet.StringVar(&cmdLineArgs.targetsRegion, "targets_region", "", "")
+	flagSet.StringVar(&cmdLineArgs.targetsAddress, "targets_address", cloudAddressPrivate, "")
+	flagSet.StringVar(&cmdLineArgs.targetsSelect, "targets_select", "", "")
+	flagSet.BoolVar(&cmdLineArgs.interactiveAuth, "interactive_auth", false, "")
+	flagSet.StringVar(&cmdLineArgs.transport, "transport", target.TransportSSH, "")
+	flagSet.StringVar(&cmdLineArgs.proxy, "proxy", "", "")
+	flagSet.StringVar(&cmdLineArgs.jumpHost, "jump_host", "", "")
+	flagSet.StringVar(&cmdLineArgs.auth, "auth", target.AuthDefault, "")
+	flagSet.BoolVar(&cmdLineArgs.fips, "fips", false, "")
+	flagSet.StringVar(&cmdLineArgs.targetOS, "os", targetOSLinux, "")
+	flagSet.BoolVar(&cmdLineArgs.requireRoot, "require_root", false, "")
+	flagSet.BoolVar(&cmdLineArgs.requireComplete, "require_complete", false, "")
 	flagSet.BoolVar(&cmdLineArgs.debug, "debug", false, "")
+	flagSet.StringVar(&cmdLineArgs.auditLog, "audit_log", "", "")
+	flagSet.StringVar(&cmdLineArgs.progress, "progress", progressSpinner, "")
+	flagSet.StringVar(&cmdLineArgs.progressFile, "progress_file", "", "")
+	flagSet.BoolVar(&cmdLineArgs.capabilities, "capabilities", false, "")
+	flagSet.BoolVar(&cmdLineArgs.readOnly, "read_only", false, "")
+	flagSet.StringVar(&cmdLineArgs.tags, "tags", "", "")
+	flagSet.StringVar(&cmdLineArgs.rawFormat, "raw_format", rawFormatJSON, "")
+	flagSet.IntVar(&cmdLineArgs.keepLast, "keep_last", 0, "")
+	flagSet.StringVar(&cmdLineArgs.replay, "replay", "", "")
 	flagSet.BoolVar(&cmdLineArgs.megadata, "megadata", false, "")
+	flagSet.StringVar(&cmdLineArgs.megaProfilers, "megadata_profilers", "", "")
+	flagSet.IntVar(&cmdLineArgs.megaDuration, "megadata_duration", 60, "")
+	flagSet.IntVar(&cmdLineArgs.megaInterval, "megadata_interval", 2, "")
+	flagSet.IntVar(&cmdLineArgs.megaDelay, "megadata_delay", 0, "")
+	flagSet.BoolVar(&cmdLineArgs.detach, "detach", false, "")
+	flagSet.Var(&cmdLineArgs.triggers, "trigger", "")
+	flagSet.IntVar(&cmdLineArgs.triggerTimeout, "trigger_timeout", 86400, "")
+	flagSet.BoolVar(&cmdLineArgs.daemon, "daemon", false, "")
+	flagSet.StringVar(&cmdLineArgs.schedule, "schedule", "", "")
+	flagSet.StringVar(&cmdLineArgs.blackout, "blackout", "", "")
+	flagSet.IntVar(&cmdLineArgs.rolling, "rolling", 0, "")
+	flagSet.IntVar(&cmdLineArgs.maxParallel, "max_parallel", 0, "")
+	flagSet.IntVar(&cmdLineArgs.retries, "retries", 0, "")
+	flagSet.BoolVar(&cmdLineArgs.powerOn, "power_on", false, "")
+	flagSet.IntVar(&cmdLineArgs.powerOnTimeout, "power_on_timeout", 600, "")
+	flagSet.BoolVar(&cmdLineArgs.restorePower, "restore_power", false, "")
+	flagSet.StringVar(&cmdLineArgs.metricsAddress, "metrics_address", "", "")
 	flagSet.IntVar(&cmdLineArgs.profileDuration, "profile_duration", 60, "")
 	flagSet.IntVar(&cmdLineArgs.analyzeDuration, "analyze_duration", 60, "")
 	flagSet.IntVar(&cmdLineArgs.profileInterval, "profile_interval", 2, "")
 	flagSet.IntVar(&cmdLineArgs.analyzeFrequency, "analyze_frequency", 11, "")
+	flagSet.BoolVar(&cmdLineArgs.topdown, "topdown", false, "")
 	flagSet.StringVar(&cmdLineArgs.reporter, "reporter", "", "")
 	flagSet.StringVar(&cmdLineArgs.collector, "collector", "", "")
+	flagSet.Var(&cmdLineArgs.vars, "var", "")
+	return
+}
+
+func (cmdLineArgs *CmdLineArgs) parse(name string, arguments []string) (err error) {
+	flagSet := cmdLineArgs.newFlagSet(name)
 	err = flagSet.Parse(arguments)
 	if err != nil {
 		return
@@ -202,6 +670,61 @@ func argDirExists(dir string, label string) (err error) {
 	return
 }
 
+// argDirWritable returns an error if files can't be created in the directory
+func argDirWritable(dir string, label string) (err error) {
+	f, err := os.CreateTemp(dir, ".write_test.")
+	if err != nil {
+		err = fmt.Errorf("-%s %s : directory is not writable, choose a directory where you have write permission", label, dir)
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return
+}
+
+// argFileReadable returns the absolute path to the file, or an error if the file doesn't
+// exist or can't be read
+func argFileReadable(file string, label string) (path string, err error) {
+	path, err = util.AbsPath(file)
+	if err != nil {
+		return
+	}
+	exists, err := util.FileExists(path)
+	if err != nil {
+		err = fmt.Errorf("-%s %s : %s", label, path, err.Error())
+		return
+	}
+	if !exists {
+		err = fmt.Errorf("-%s %s : file does not exist", label, path)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("-%s %s : file is not readable, check the file's permissions", label, path)
+		return
+	}
+	f.Close()
+	return
+}
+
+// getInvalidTypes returns the items in the comma separated input that aren't valid types
+func getInvalidTypes(validTypes []string, input string) (invalidTypes []string) {
+	for _, inputType := range strings.Split(input, ",") {
+		if !isValidType(validTypes, inputType) {
+			invalidTypes = append(invalidTypes, inputType)
+		}
+	}
+	return
+}
+
+// argTypesValid returns an error that names the invalid types and lists the valid types
+func argTypesValid(validTypes []string, input string, label string) (err error) {
+	if invalidTypes := getInvalidTypes(validTypes, input); len(invalidTypes) > 0 {
+		err = fmt.Errorf("-%s %s : invalid %s type(s): %s, choose from: %s", label, input, label, strings.Join(invalidTypes, ","), strings.Join(validTypes, ","))
+	}
+	return
+}
+
 func isValidType(validTypes []string, input string) (valid bool) {
 	inputTypes := strings.Split(input, ",")
 	for _, inputType := range inputTypes {
@@ -214,6 +737,37 @@ func isValidType(validTypes []string, input string) (valid bool) {
 	return false
 }
 
+// validateMegadataOptions is shared by the command line and the targets file
+func validateMegadataOptions(profilers string, duration int, interval int, delay int) (err error) {
+	if profilers != "" && !isValidType(megadataProfilerTypes, profilers) {
+		err = fmt.Errorf("-megadata_profilers %s : invalid profiler type: %s", profilers, profilers)
+		return
+	}
+	if duration <= 0 {
+		err = fmt.Errorf("-megadata_duration %d : invalid value", duration)
+		return
+	}
+	if interval <= 0 || interval > duration {
+		err = fmt.Errorf("-megadata_interval %d : invalid value", interval)
+		return
+	}
+	if delay < 0 {
+		err = fmt.Errorf("-megadata_delay %d : invalid value", delay)
+		return
+	}
+	return
+}
+
+// validateGentleOptions is shared by the command line and the targets file, the
+// options that load the host can't be used with -gentle. The megadata collection would
+// run its profilers one at a time.
+func validateGentleOptions(args *CmdLineArgs) (err error) {
+	if args.gentle && (args.benchmark != "" || args.analyze != "" || args.profile != "" || args.topdown || args.megadata) {
+		err = fmt.Errorf("-gentle : can't be used with -benchmark, -analyze, -profile, -topdown, or -megadata")
+	}
+	return
+}
+
 func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 	// -all (deprecated)  TODO: remove the -all option in a future release
 	if cmdLineArgs.all {
@@ -228,25 +782,145 @@ func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 		if err != nil {
 			return
 		}
+		err = argDirWritable(cmdLineArgs.output, "output")
+		if err != nil {
+			return
+		}
+	}
+	// -temp dir
+	if cmdLineArgs.temp != "" {
+		err = argDirExists(cmdLineArgs.temp, "temp")
+		if err != nil {
+			return
+		}
+		err = argDirWritable(cmdLineArgs.temp, "temp")
+		if err != nil {
+			return
+		}
+	}
+	// -tags
+	if _, err = parseTags(cmdLineArgs.tags); err != nil {
+		err = fmt.Errorf("-tags %s : %v", cmdLineArgs.tags, err)
+		return
+	}
+	// -cmd_timeout
+	if cmdLineArgs.cmdTimeout <= 0 {
+		err = fmt.Errorf("-cmd_timeout %d : timeout must be a positive number of seconds", cmdLineArgs.cmdTimeout)
+		return
+	}
+	// -timeout
+	if cmdLineArgs.timeout < 0 {
+		err = fmt.Errorf("-timeout %d : timeout must be a positive number of seconds", cmdLineArgs.timeout)
+		return
 	}
 	// -format
 	if cmdLineArgs.format != "" {
-		if !isValidType(core.ReportTypes, cmdLineArgs.format) {
-			err = fmt.Errorf("-format %s : invalid format type: %s", cmdLineArgs.format, cmdLineArgs.format)
+		err = argTypesValid(core.ReportTypes, cmdLineArgs.format, "format")
+		if err != nil {
+			return
+		}
+	}
+	// -highlight
+	if cmdLineArgs.highlight != "" {
+		var path string
+		path, err = argFileReadable(cmdLineArgs.highlight, "highlight")
+		if err != nil {
+			return
+		}
+		cmdLineArgs.highlight = path // the reporter requires an absolute path
+	}
+	// -microcode
+	if cmdLineArgs.microcode != "" {
+		var path string
+		path, err = argFileReadable(cmdLineArgs.microcode, "microcode")
+		if err != nil {
+			return
+		}
+		cmdLineArgs.microcode = path // the reporter requires an absolute path
+	}
+	// -instance_types
+	if cmdLineArgs.instanceTypes != "" {
+		var path string
+		path, err = argFileReadable(cmdLineArgs.instanceTypes, "instance_types")
+		if err != nil {
+			return
+		}
+		cmdLineArgs.instanceTypes = path // the reporter requires an absolute path
+	}
+	// -health_weights
+	if cmdLineArgs.healthWeights != "" {
+		var path string
+		path, err = argFileReadable(cmdLineArgs.healthWeights, "health_weights")
+		if err != nil {
+			return
+		}
+		cmdLineArgs.healthWeights = path // the reporter requires an absolute path
+	}
+	// -collect
+	if cmdLineArgs.collect != "" {
+		err = argTypesValid(collectCategories, cmdLineArgs.collect, "collect")
+		if err != nil {
+			return
+		}
+		if cmdLineArgs.noConfig {
+			err = fmt.Errorf("-collect : can't be used with -noconfig")
+			return
+		}
+		// only the benchmarks category runs the benchmarks, all is the same as not
+		// setting -collect
+		if isValidType([]string{"benchmarks"}, cmdLineArgs.collect) {
+			if cmdLineArgs.benchmark == "" {
+				cmdLineArgs.benchmark = "all"
+			}
+		} else if cmdLineArgs.benchmark != "" && !isValidType([]string{"all"}, cmdLineArgs.collect) {
+			err = fmt.Errorf("-benchmark : requires benchmarks in -collect %s", cmdLineArgs.collect)
 			return
 		}
 	}
 	// -benchmark
 	if cmdLineArgs.benchmark != "" {
-		if !isValidType(benchmarkTypes, cmdLineArgs.benchmark) {
-			err = fmt.Errorf("-benchmark %s : invalid benchmark type: %s", cmdLineArgs.benchmark, cmdLineArgs.benchmark)
+		err = argTypesValid(benchmarkTypes, cmdLineArgs.benchmark, "benchmark")
+		if err != nil {
+			return
+		}
+	}
+	// -reference
+	if cmdLineArgs.reference != "" {
+		if cmdLineArgs.benchmark == "" {
+			err = fmt.Errorf("-reference : requires -benchmark")
+			return
+		}
+		var path string
+		path, err = argFileReadable(cmdLineArgs.reference, "reference")
+		if err != nil {
+			return
+		}
+		cmdLineArgs.reference = path // the reporter requires an absolute path
+	}
+	// -reference_threshold
+	if cmdLineArgs.refThreshold < 0 {
+		err = fmt.Errorf("-reference_threshold %g : must not be negative", cmdLineArgs.refThreshold)
+		return
+	}
+	// -gentle
+	if err = validateGentleOptions(cmdLineArgs); err != nil {
+		return
+	}
+	// -nic_peer
+	if cmdLineArgs.nicPeer != "" {
+		if !strings.Contains(cmdLineArgs.benchmark, "network") {
+			err = fmt.Errorf("-nic_peer : requires -benchmark network")
+			return
+		}
+		if err = validateNICPeer(cmdLineArgs.nicPeer); err != nil {
+			err = fmt.Errorf("-nic_peer %s : %v", cmdLineArgs.nicPeer, err)
 			return
 		}
 	}
 	// -profile
 	if cmdLineArgs.profile != "" {
-		if !isValidType(profileTypes, cmdLineArgs.profile) {
-			err = fmt.Errorf("-profile %s : invalid profile type: %s", cmdLineArgs.profile, cmdLineArgs.profile)
+		err = argTypesValid(profileTypes, cmdLineArgs.profile, "profile")
+		if err != nil {
 			return
 		}
 		if cmdLineArgs.profileDuration <= 0 {
@@ -266,8 +940,8 @@ func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 	}
 	// -analyze
 	if cmdLineArgs.analyze != "" {
-		if !isValidType(analyzeTypes, cmdLineArgs.analyze) {
-			err = fmt.Errorf("-analyze %s : invalid profile type: %s", cmdLineArgs.analyze, cmdLineArgs.analyze)
+		err = argTypesValid(analyzeTypes, cmdLineArgs.analyze, "analyze")
+		if err != nil {
 			return
 		}
 		if cmdLineArgs.analyzeDuration <= 0 {
@@ -285,20 +959,105 @@ func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 			return
 		}
 	}
+	// -megadata_*
+	if cmdLineArgs.megaProfilers != "" && !cmdLineArgs.megadata {
+		err = fmt.Errorf("-megadata_profilers %s : megadata required when megadata_profilers provided", cmdLineArgs.megaProfilers)
+		return
+	}
+	err = validateMegadataOptions(cmdLineArgs.megaProfilers, cmdLineArgs.megaDuration, cmdLineArgs.megaInterval, cmdLineArgs.megaDelay)
+	if err != nil {
+		return
+	}
+	// -detach
+	if cmdLineArgs.detach && !cmdLineArgs.megadata {
+		err = fmt.Errorf("-detach : megadata required when detach provided")
+		return
+	}
+	// -trigger
+	if len(cmdLineArgs.triggers) > 0 && !cmdLineArgs.detach {
+		err = fmt.Errorf("-trigger %s : detach required when trigger provided", cmdLineArgs.triggers.String())
+		return
+	}
+	if cmdLineArgs.triggerTimeout <= 0 {
+		err = fmt.Errorf("-trigger_timeout %d : timeout must be a positive number of seconds", cmdLineArgs.triggerTimeout)
+		return
+	}
+	// -rolling
+	if cmdLineArgs.rolling < 0 {
+		err = fmt.Errorf("-rolling %d : must be 0, i.e., all at once, or a positive number of collections per hour", cmdLineArgs.rolling)
+		return
+	}
+	// -max_parallel
+	if cmdLineArgs.maxParallel < 0 {
+		err = fmt.Errorf("-max_parallel %d : must be 0, i.e., no limit, or a positive number of collections", cmdLineArgs.maxParallel)
+		return
+	}
+	// -retries
+	if cmdLineArgs.retries < 0 {
+		err = fmt.Errorf("-retries %d : must be zero or more", cmdLineArgs.retries)
+		return
+	}
+	// -power_on
+	if cmdLineArgs.powerOnTimeout <= 0 {
+		err = fmt.Errorf("-power_on_timeout %d : must be a positive number of seconds", cmdLineArgs.powerOnTimeout)
+		return
+	}
+	if cmdLineArgs.restorePower && !cmdLineArgs.powerOn {
+		err = fmt.Errorf("-restore_power : power_on required when restore_power provided")
+		return
+	}
+	if cmdLineArgs.powerOn && (cmdLineArgs.daemon || cmdLineArgs.interactiveAuth || cmdLineArgs.replay != "") {
+		err = fmt.Errorf("-power_on : not supported with -daemon, -interactive_auth, or -replay")
+		return
+	}
+	if cmdLineArgs.restorePower && cmdLineArgs.detach {
+		err = fmt.Errorf("-restore_power : not supported with -detach")
+		return
+	}
+	// -daemon
+	if cmdLineArgs.daemon && (cmdLineArgs.detach || cmdLineArgs.interactiveAuth) {
+		err = fmt.Errorf("-daemon : not supported with -detach or -interactive_auth")
+		return
+	}
+	if (cmdLineArgs.schedule != "" || cmdLineArgs.blackout != "") && !cmdLineArgs.daemon {
+		err = fmt.Errorf("-schedule, -blackout : daemon required when schedule or blackout provided")
+		return
+	}
+	if cmdLineArgs.metricsAddress != "" && !cmdLineArgs.daemon {
+		err = fmt.Errorf("-metrics_address %s : daemon required when metrics address provided", cmdLineArgs.metricsAddress)
+		return
+	}
+	if cmdLineArgs.schedule != "" {
+		if _, err = parseCron(cmdLineArgs.schedule); err != nil {
+			err = fmt.Errorf("-schedule %s : %v", cmdLineArgs.schedule, err)
+			return
+		}
+	}
+	if _, err = parseBlackouts(cmdLineArgs.blackout); err != nil {
+		return
+	}
 	// -ip
 	if cmdLineArgs.ipAddress != "" {
-		// make sure it isn't too long (max FQDN length is 255)
-		if len(cmdLineArgs.ipAddress) > 255 {
-			err = fmt.Errorf("-ip %s : longer than allowed max (255)", cmdLineArgs.ipAddress)
+		// the host may contain ranges, e.g., node[01-48], that expand to several hosts
+		var hosts []string
+		if hosts, err = expandHostRange(cmdLineArgs.ipAddress); err != nil {
+			err = fmt.Errorf("-ip %s : %v", cmdLineArgs.ipAddress, err)
 			return
 		}
+		for _, host := range hosts {
+			// make sure it isn't too long (max FQDN length is 255)
+			if len(host) > 255 {
+				err = fmt.Errorf("-ip %s : longer than allowed max (255)", host)
+				return
+			}
+		}
 	}
 	if cmdLineArgs.ipAddress != "" && cmdLineArgs.user == "" {
 		// if ip is provided, user is required
 		err = fmt.Errorf("-user <blank> : user required when -ip %s provided", cmdLineArgs.ipAddress)
 		return
 	}
-	if cmdLineArgs.ipAddress == "" && cmdLineArgs.user != "" {
+	if cmdLineArgs.ipAddress == "" && cmdLineArgs.user != "" && cmdLineArgs.targetsFrom == "" {
 		// if user is provided, ip is required
 		err = fmt.Errorf("-ip <blank> : ip required when -user %s provided", cmdLineArgs.user)
 		return
@@ -308,47 +1067,199 @@ func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 		err = fmt.Errorf("-port %d : port must be a positive integer", cmdLineArgs.port)
 		return
 	}
-	if cmdLineArgs.port != 22 && (cmdLineArgs.ipAddress == "" || cmdLineArgs.user == "") {
+	if cmdLineArgs.port != 22 && (cmdLineArgs.ipAddress == "" || cmdLineArgs.user == "") && cmdLineArgs.targetsFrom == "" {
 		err = fmt.Errorf("-port %d : user and ip required when port provided", cmdLineArgs.port)
 		return
 	}
 	// -key
 	if cmdLineArgs.key != "" {
 		var path string
-		path, err = util.AbsPath(cmdLineArgs.key)
-		if err != nil {
-			return
-		}
-		var exists bool
-		exists, err = util.FileExists(path)
+		path, err = argFileReadable(cmdLineArgs.key, "key")
 		if err != nil {
-			err = fmt.Errorf("-key %s : %s", path, err.Error())
 			return
 		}
-		if !exists {
-			err = fmt.Errorf("-key %s : file does not exist", path)
-			return
-		}
-		if cmdLineArgs.ipAddress == "" || cmdLineArgs.user == "" {
+		if (cmdLineArgs.ipAddress == "" || cmdLineArgs.user == "") && cmdLineArgs.targetsFrom == "" {
 			err = fmt.Errorf("-key %s : user and ip required when key provided", cmdLineArgs.key)
 			return
 		}
+		warnKeyPermissions(path, "key")
 	}
 	// -targets
 	if cmdLineArgs.targets != "" {
 		var path string
-		path, err = util.AbsPath(cmdLineArgs.targets)
+		path, err = argFileReadable(cmdLineArgs.targets, "targets")
 		if err != nil {
 			return
 		}
-		var exists bool
-		exists, err = util.FileExists(path)
+		if cmdLineArgs.ipAddress != "" {
+			err = fmt.Errorf("-ip %s -targets %s : ip and targets are mutually exclusive, add the target to the targets file instead", cmdLineArgs.ipAddress, cmdLineArgs.targets)
+			return
+		}
+		// report all errors in the targets file now, rather than after some targets
+		// have been contacted
+		var targets []targetFromFile
+		targets, err = newTargetsFile(path).parse()
 		if err != nil {
-			err = fmt.Errorf("-targets %s : %s", path, err.Error())
+			example := "targets.example"
+			if isYAMLTargetsFile(path) {
+				example = "targets.example.yaml"
+			}
+			err = fmt.Errorf("%s\nSee %s for the targets file format", strings.TrimSpace(err.Error()), example)
+			return
+		}
+		if len(targets) == 0 {
+			err = fmt.Errorf("-targets %s : no targets found in file, see targets.example or targets.example.yaml for the format", path)
+			return
+		}
+		for _, t := range targets {
+			if t.key != "" {
+				warnKeyPermissions(t.key, "targets "+path)
+			}
+		}
+	}
+	// -targets_cmd, the command is run when svr-info starts
+	if cmdLineArgs.targetsCmd != "" {
+		if cmdLineArgs.ipAddress != "" || cmdLineArgs.targets != "" {
+			err = fmt.Errorf("-targets_cmd %s : mutually exclusive with -ip and -targets", cmdLineArgs.targetsCmd)
+			return
+		}
+	}
+	// -targets_from, the instances are listed when svr-info starts, like -targets_cmd
+	if cmdLineArgs.targetsFrom != "" {
+		if !util.StringInList(cmdLineArgs.targetsFrom, cloudProviders) {
+			err = fmt.Errorf("-targets_from %s : invalid cloud provider, choose from: %s", cmdLineArgs.targetsFrom, strings.Join(cloudProviders, ","))
+			return
+		}
+		if cmdLineArgs.ipAddress != "" || cmdLineArgs.targets != "" || cmdLineArgs.targetsCmd != "" {
+			err = fmt.Errorf("-targets_from %s : mutually exclusive with -ip, -targets, and -targets_cmd", cmdLineArgs.targetsFrom)
+			return
+		}
+		if cmdLineArgs.user == "" {
+			err = fmt.Errorf("-targets_from %s : -user is required to log in to the instances", cmdLineArgs.targetsFrom)
+			return
+		}
+		// Systems Manager reaches only EC2 instances, there's no Azure Run Command or GCP
+		// equivalent
+		if cmdLineArgs.transport == target.TransportSSM && cmdLineArgs.targetsFrom != cloudAWS {
+			err = fmt.Errorf("-targets_from %s : -transport %s reaches only %s instances", cmdLineArgs.targetsFrom, target.TransportSSM, cloudAWS)
+			return
+		}
+		if _, err = parseCloudFilter(cmdLineArgs.targetsFilter); err != nil {
+			err = fmt.Errorf("-targets_filter %s : %v", cmdLineArgs.targetsFilter, err)
+			return
+		}
+	} else if cmdLineArgs.targetsFilter != "" || cmdLineArgs.targetsRegion != "" {
+		err = fmt.Errorf("-targets_filter and -targets_region require -targets_from")
+		return
+	}
+	if !util.StringInList(cmdLineArgs.targetsAddress, cloudAddresses) {
+		err = fmt.Errorf("-targets_address %s : invalid address, choose from: %s", cmdLineArgs.targetsAddress, strings.Join(cloudAddresses, ","))
+		return
+	}
+	// -targets_select
+	if cmdLineArgs.targetsSelect != "" && cmdLineArgs.targets == "" && cmdLineArgs.targetsCmd == "" && cmdLineArgs.targetsFrom == "" {
+		err = fmt.Errorf("-targets_select %s : requires -targets, -targets_cmd, or -targets_from", cmdLineArgs.targetsSelect)
+		return
+	}
+	// -interactive_auth
+	if cmdLineArgs.interactiveAuth && cmdLineArgs.ipAddress == "" && cmdLineArgs.targets == "" && cmdLineArgs.targetsCmd == "" && cmdLineArgs.targetsFrom == "" {
+		err = fmt.Errorf("-interactive_auth : ip or targets required when interactive_auth provided")
+		return
+	}
+	// -keep_last
+	if cmdLineArgs.keepLast < 0 {
+		err = fmt.Errorf("-keep_last %d : number of output directories to keep must be zero or more", cmdLineArgs.keepLast)
+		return
+	}
+	// -raw_format
+	if !util.StringInList(cmdLineArgs.rawFormat, rawFormats) {
+		err = fmt.Errorf("-raw_format %s : invalid raw data format, choose from: %s", cmdLineArgs.rawFormat, strings.Join(rawFormats, ","))
+		return
+	}
+	// -progress
+	if !util.StringInList(cmdLineArgs.progress, progressModes) {
+		err = fmt.Errorf("-progress %s : invalid progress output, choose from: %s", cmdLineArgs.progress, strings.Join(progressModes, ","))
+		return
+	}
+	if cmdLineArgs.progressFile != "" && cmdLineArgs.progress != progressJSON {
+		err = fmt.Errorf("-progress_file %s : requires -progress %s", cmdLineArgs.progressFile, progressJSON)
+		return
+	}
+	// -replay
+	if cmdLineArgs.replay != "" {
+		if cmdLineArgs.ipAddress != "" || cmdLineArgs.targets != "" || cmdLineArgs.targetsCmd != "" || cmdLineArgs.targetsFrom != "" {
+			err = fmt.Errorf("-replay %s : replay, ip, targets, targets_cmd, and targets_from are mutually exclusive", cmdLineArgs.replay)
 			return
 		}
-		if !exists {
-			err = fmt.Errorf("-targets %s : file does not exist", path)
+		if cmdLineArgs.megadata || cmdLineArgs.detach {
+			err = fmt.Errorf("-replay %s : not supported with -megadata or -detach", cmdLineArgs.replay)
+			return
+		}
+		if cmdLineArgs.replay, err = util.AbsPath(cmdLineArgs.replay); err != nil {
+			return
+		}
+		if _, err = getCaptureFiles(cmdLineArgs.replay); err != nil {
+			err = fmt.Errorf("-replay %s : %v", cmdLineArgs.replay, err)
+			return
+		}
+	}
+	// -transport
+	if !util.StringInList(cmdLineArgs.transport, target.Transports) {
+		err = fmt.Errorf("-transport %s : invalid transport type: %s", cmdLineArgs.transport, cmdLineArgs.transport)
+		return
+	}
+	// -auth
+	if !util.StringInList(cmdLineArgs.auth, target.AuthMethods) {
+		err = fmt.Errorf("-auth %s : invalid authentication method, choose from: %s", cmdLineArgs.auth, strings.Join(target.AuthMethods, ","))
+		return
+	}
+	if cmdLineArgs.auth == target.AuthGSSAPI && cmdLineArgs.interactiveAuth {
+		err = fmt.Errorf("-auth %s : not supported with -interactive_auth", cmdLineArgs.auth)
+		return
+	}
+	// -fips, the orchestrator's own cryptography must also use a validated module
+	if cmdLineArgs.fips && !gFIPSBuild {
+		err = fmt.Errorf("-fips : requires the FIPS build of svr-info, built with GOEXPERIMENT=boringcrypto, see the orchestrator-fips make target")
+		return
+	}
+	// -proxy
+	if cmdLineArgs.proxy != "" {
+		if _, err = target.ParseProxy(cmdLineArgs.proxy); err != nil {
+			err = fmt.Errorf("-proxy %s : %v", cmdLineArgs.proxy, err)
+			return
+		}
+		if cmdLineArgs.ipAddress == "" && cmdLineArgs.targets == "" && cmdLineArgs.targetsCmd == "" && cmdLineArgs.targetsFrom == "" {
+			err = fmt.Errorf("-proxy %s : ip or targets required when proxy provided", cmdLineArgs.proxy)
+			return
+		}
+	}
+	// -jump_host
+	if cmdLineArgs.jumpHost != "" {
+		if err = target.ValidateJumpHost(cmdLineArgs.jumpHost); err != nil {
+			err = fmt.Errorf("-jump_host %s : %v", cmdLineArgs.jumpHost, err)
+			return
+		}
+		if cmdLineArgs.ipAddress == "" && cmdLineArgs.targets == "" && cmdLineArgs.targetsCmd == "" && cmdLineArgs.targetsFrom == "" {
+			err = fmt.Errorf("-jump_host %s : ip or targets required when jump host provided", cmdLineArgs.jumpHost)
+			return
+		}
+		if cmdLineArgs.proxy != "" || cmdLineArgs.transport == target.TransportSSM {
+			err = fmt.Errorf("-jump_host %s : not supported with -proxy or -transport %s", cmdLineArgs.jumpHost, target.TransportSSM)
+			return
+		}
+	}
+	// -os
+	if !util.StringInList(cmdLineArgs.targetOS, targetOSTypes) {
+		err = fmt.Errorf("-os %s : invalid operating system, choose from: %s", cmdLineArgs.targetOS, strings.Join(targetOSTypes, ","))
+		return
+	}
+	if cmdLineArgs.targetOS == targetOSWindows {
+		if cmdLineArgs.ipAddress == "" && cmdLineArgs.targets == "" && cmdLineArgs.targetsCmd == "" && cmdLineArgs.targetsFrom == "" {
+			err = fmt.Errorf("-os %s : ip or targets required, the local host is a Linux host", cmdLineArgs.targetOS)
+			return
+		}
+		if err = validateWindowsOptions(cmdLineArgs); err != nil {
+			err = fmt.Errorf("-os %s : %v", cmdLineArgs.targetOS, err)
 			return
 		}
 	}
@@ -357,5 +1268,45 @@ func (cmdLineArgs *CmdLineArgs) validate() (err error) {
 		err = fmt.Errorf("-collector and -reporter are mutually exclusive options")
 		return
 	}
+	// -printconfig only prints the collector configuration, nothing is collected
+	if cmdLineArgs.printConfig && (cmdLineArgs.collector != "" || cmdLineArgs.reporter != "") {
+		err = fmt.Errorf("-printconfig : mutually exclusive with -collector and -reporter")
+		return
+	}
+	return
+}
+
+// warnKeyPermissions warns when a private key is readable by other users because ssh
+// refuses to use such keys
+func warnKeyPermissions(path string, label string) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if fileInfo.Mode().Perm()&0077 != 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: -%s : key file %s permissions (%#o) allow access by other users, ssh may refuse to use it. Run 'chmod 600 %s' to fix.\n", label, path, fileInfo.Mode().Perm(), path)
+	}
+}
+
+// validateWindowsOptions returns an error if the options that require the collector,
+// which doesn't run on Windows, are set
+func validateWindowsOptions(cmdLineArgs *CmdLineArgs) (err error) {
+	if cmdLineArgs.benchmark != "" || cmdLineArgs.profile != "" || cmdLineArgs.analyze != "" || cmdLineArgs.megadata {
+		err = fmt.Errorf("benchmark, profile, analyze, and megadata aren't supported on Windows targets")
+	} else if cmdLineArgs.detach || cmdLineArgs.gentle {
+		// the collector detaches and lowers its own priority
+		err = fmt.Errorf("detach and gentle aren't supported on Windows targets")
+	}
+	return
+}
+
+var reNICPeer = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.:-]*$`)
+
+// validateNICPeer checks that the peer is an IP address or hostname, it is inserted into
+// the network benchmark's command
+func validateNICPeer(peer string) (err error) {
+	if !reNICPeer.MatchString(peer) {
+		err = fmt.Errorf("not an IP address or hostname")
+	}
 	return
 }
@@ -6,7 +6,16 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/progress"
+	"github.com/intel/svr-info/internal/target"
+	"gopkg.in/yaml.v2"
 )
 
 // helper
@@ -84,6 +93,11 @@ func TestAllExceptTargetsFile(t *testing.T) {
 		"-targettemp", "/tmp", // any dir
 		"-output", "/tmp", // any dir
 		"-megadata",
+		"-megadata_profilers", "all",
+		"-megadata_duration", "30",
+		"-megadata_interval", "1",
+		"-megadata_delay", "10",
+		"-topdown",
 		"-debug",
 		"-cmd_timeout", "150",
 		"-printconfig",
@@ -132,17 +146,111 @@ func TestUserNoIp(t *testing.T) {
 }
 
 func TestTargetsFile(t *testing.T) {
-	if !isValid(([]string{"-targets", "targets.example"})) {
+	targetsPath := filepath.Join(t.TempDir(), "targets")
+	err := os.WriteFile(targetsPath, []byte("192.168.1.1::elaine:targets.example::\n"), 0600) // any file as key
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isValid(([]string{"-targets", targetsPath})) {
+		t.Fail()
+	}
+}
+
+func TestTargetsFileInvalid(t *testing.T) {
+	targetsPath := filepath.Join(t.TempDir(), "targets")
+	err := os.WriteFile(targetsPath, []byte("192.168.1.1:elaine\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isValid(([]string{"-targets", targetsPath})) {
 		t.Fail()
 	}
 }
 
+func TestTargetsFileWithIP(t *testing.T) {
+	targetsPath := filepath.Join(t.TempDir(), "targets")
+	err := os.WriteFile(targetsPath, []byte("192.168.1.1::elaine:::\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isValid(([]string{"-targets", targetsPath, "-ip", "192.168.1.2", "-user", "foo"})) {
+		t.Fail()
+	}
+}
+
+func TestOutputNotWritable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to any directory")
+	}
+	dir := t.TempDir()
+	err := os.Chmod(dir, 0500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0700)
+	if isValid([]string{"-output", dir}) {
+		t.Fail()
+	}
+}
+
+func TestInvalidTypes(t *testing.T) {
+	invalidTypes := getInvalidTypes(benchmarkTypes, "cpu,foo,memory,bar")
+	if len(invalidTypes) != 2 || invalidTypes[0] != "foo" || invalidTypes[1] != "bar" {
+		t.Errorf("unexpected invalid types: %v", invalidTypes)
+	}
+}
+
 func TestKeyFile(t *testing.T) {
 	if !isValid(([]string{"-key", "targets.example", "-ip", "192.168.1.1", "-user", "foo"})) { // any file will do
 		t.Fail()
 	}
 }
 
+func TestIpHostRange(t *testing.T) {
+	if !isValid(([]string{"-ip", "node[01-48].example.com", "-user", "foo"})) {
+		t.Error("expected a host range to be valid")
+	}
+	if isValid(([]string{"-ip", "node[48-01].example.com", "-user", "foo"})) {
+		t.Error("expected a reversed host range to be invalid")
+	}
+}
+
+func TestTargetsCmd(t *testing.T) {
+	if !isValid(([]string{"-targets_cmd", "my-inventory --json"})) {
+		t.Error("expected a targets command to be valid")
+	}
+	if isValid(([]string{"-targets_cmd", "my-inventory --json", "-ip", "192.168.1.1", "-user", "foo"})) {
+		t.Error("expected a targets command with -ip to be invalid")
+	}
+}
+
+func TestTargetsFrom(t *testing.T) {
+	if !isValid(([]string{"-targets_from", "aws", "-user", "ec2-user", "-targets_filter", "env=prod", "-targets_region", "us-east-1"})) {
+		t.Error("expected a cloud provider with a user to be valid")
+	}
+	if isValid(([]string{"-targets_from", "aws"})) {
+		t.Error("expected a cloud provider without a user to be invalid")
+	}
+	if isValid(([]string{"-targets_from", "oracle", "-user", "opc"})) {
+		t.Error("expected an unsupported cloud provider to be invalid")
+	}
+	if isValid(([]string{"-targets_from", "gcp", "-user", "foo", "-targets_cmd", "my-inventory --json"})) {
+		t.Error("expected a cloud provider with a targets command to be invalid")
+	}
+	if isValid(([]string{"-targets_filter", "env=prod"})) {
+		t.Error("expected a filter without a cloud provider to be invalid")
+	}
+	if isValid(([]string{"-targets_from", "azure", "-user", "foo", "-targets_address", "external"})) {
+		t.Error("expected an invalid address to be invalid")
+	}
+	if !isValid(([]string{"-targets_from", "aws", "-user", "ec2-user", "-transport", "ssm"})) {
+		t.Error("expected aws instances with the ssm transport to be valid")
+	}
+	if isValid(([]string{"-targets_from", "azure", "-user", "foo", "-transport", "ssm"})) {
+		t.Error("expected azure instances with the ssm transport to be invalid")
+	}
+}
+
 func TestKeyNoIpUser(t *testing.T) {
 	if isValid(([]string{"-key", "targets.example"})) {
 		t.Fail()
@@ -154,3 +262,359 @@ func TestPortNoIpUser(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestMegadataProfilersNoMegadata(t *testing.T) {
+	if isValid([]string{"-megadata_profilers", "perf"}) {
+		t.Fail()
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	if isValid([]string{"-megadata", "-trigger", "load=16"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-megadata", "-detach", "-trigger", "load=16", "-trigger_timeout", "0"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-megadata", "-detach", "-trigger", "load=16", "-trigger", "dmesg=Machine Check"}) {
+		t.Fail()
+	}
+}
+
+func TestDetach(t *testing.T) {
+	if isValid([]string{"-detach"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-detach", "-megadata", "-megadata_profilers", "all", "-megadata_duration", "28800", "-megadata_interval", "30"}) {
+		t.Fail()
+	}
+}
+
+func TestVars(t *testing.T) {
+	if !isValid([]string{"-var", "nic=eth0", "-var", "device=/dev/nvme1n1"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-var", "nic"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-var", "1nic=eth0"}) {
+		t.Fail()
+	}
+	args := newCmdLineArgs()
+	err := args.parse("tester", []string{"-var", "nic=eth0", "-var", "opts=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.vars["nic"] != "eth0" || args.vars["opts"] != "a=b" {
+		t.Errorf("unexpected vars: %s", args.vars.String())
+	}
+}
+
+func TestApplyTemplateVars(t *testing.T) {
+	vars := templateVars{"nic": "eth0"}
+	cmd := commandfile.Command{Label: "nic", Command: "ethtool -S {{.Var.nic}}", Run: true}
+	if err := applyTemplateVars(&cmd, vars); err != nil || cmd.Command != "ethtool -S eth0" || !cmd.Run {
+		t.Errorf("unexpected result: %v, %s, %t", err, cmd.Command, cmd.Run)
+	}
+	// values that could inject shell commands are rejected
+	cmd = commandfile.Command{Label: "nic", Command: "NIC=\"{{.Var.nic}}\"", Run: true}
+	if err := applyTemplateVars(&cmd, templateVars{"nic": "eth0\"; reboot; \""}); err == nil {
+		t.Errorf("unexpected result: %s", cmd.Command)
+	}
+	// commands referencing a variable that wasn't provided are not run
+	cmd = commandfile.Command{Label: "dev", Command: "fio --filename={{.Var.device}}", Run: true}
+	if err := applyTemplateVars(&cmd, vars); err != nil || cmd.Run {
+		t.Errorf("unexpected result: %v, %t", err, cmd.Run)
+	}
+	// commands that don't reference variables are not modified
+	cmd = commandfile.Command{Label: "awk", Command: "lspci | awk 'NR==1{{print $1}}'", Run: true}
+	if err := applyTemplateVars(&cmd, nil); err != nil || cmd.Command != "lspci | awk 'NR==1{{print $1}}'" {
+		t.Errorf("unexpected result: %v, %s", err, cmd.Command)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	if !isValid([]string{"-collect", "cpu,memory,security"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-collect", "cpu,gpu"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-collect", "cpu", "-noconfig"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-collect", "cpu", "-benchmark", "memory"}) {
+		t.Fail()
+	}
+	args := newCmdLineArgs()
+	if err := args.parse("tester", []string{"-collect", "storage,benchmarks"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := args.validate(); err != nil || args.benchmark != "all" {
+		t.Errorf("unexpected benchmark: %s, %v", args.benchmark, err)
+	}
+	// all is the same as not setting -collect, it doesn't run the benchmarks
+	args = newCmdLineArgs()
+	if err := args.parse("tester", []string{"-collect", "all"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := args.validate(); err != nil || args.benchmark != "" {
+		t.Errorf("unexpected benchmark: %s, %v", args.benchmark, err)
+	}
+	if !isValid([]string{"-collect", "all", "-benchmark", "memory"}) {
+		t.Fail()
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	if !isValid([]string{"-timeout", "600"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-timeout", "-1"}) {
+		t.Fail()
+	}
+}
+
+func TestGentle(t *testing.T) {
+	if !isValid([]string{"-gentle", "-collect", "cpu"}) {
+		t.Fail()
+	}
+	for _, option := range [][]string{{"-benchmark", "cpu"}, {"-profile", "cpu"}, {"-topdown"}, {"-megadata"}} {
+		if isValid(append([]string{"-gentle"}, option...)) {
+			t.Errorf("%v : expected error with -gentle", option)
+		}
+	}
+	cmdTemplate, err := resources.ReadFile("resources/collector_reports.yaml.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// e.g., a target with the gentle option in the targets file, only the benchmarks'
+	// commands that aren't intensive are run
+	args := &CmdLineArgs{gentle: true, benchmark: "all", profile: "all", topdown: true, cmdTimeout: 300}
+	customized, err := customizeCommandYAML(cmdTemplate, args, ".", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(customized, &cf); err != nil {
+		t.Fatal(err)
+	}
+	run := make(map[string]bool)
+	for _, cmd := range cf.Commands {
+		run[cmd.Label] = cmd.Run
+	}
+	if !run["lscpu"] || !run["CPU Idle"] || run["spectre-meltdown-checker"] || run["fio"] || run["stress-ng cpu methods"] || run["profile"] || run["topdown"] {
+		t.Errorf("unexpected commands: %v", run)
+	}
+	c := newCollection(target.NewLocalTarget("host", ""), args, t.TempDir(), t.TempDir())
+	if flags := c.getCollectorFlags("."); flags != " -gentle" {
+		t.Errorf("unexpected collector flags: %s", flags)
+	}
+}
+
+func TestTargetOS(t *testing.T) {
+	if !isValid([]string{"-os", "windows", "-ip", "host", "-user", "user"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-os", "windows"}) { // the local host
+		t.Fail()
+	}
+	if isValid([]string{"-os", "windows", "-ip", "host", "-user", "user", "-benchmark", "cpu"}) {
+		t.Fail()
+	}
+	for _, option := range []string{"-detach", "-gentle"} {
+		if isValid([]string{"-os", "windows", "-ip", "host", "-user", "user", option}) {
+			t.Errorf("%s accepted for Windows targets", option)
+		}
+	}
+	if isValid([]string{"-os", "macos", "-ip", "host", "-user", "user"}) {
+		t.Fail()
+	}
+}
+
+func TestReference(t *testing.T) {
+	reference := filepath.Join(t.TempDir(), "reference.json")
+	if err := os.WriteFile(reference, []byte(`{"metrics": {"CPU Speed": 400000}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !isValid([]string{"-benchmark", "cpu", "-reference", reference}) {
+		t.Fail()
+	}
+	if isValid([]string{"-reference", reference}) {
+		t.Fail()
+	}
+	if isValid([]string{"-benchmark", "cpu", "-reference", reference + ".missing"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-benchmark", "cpu", "-reference", reference, "-reference_threshold", "2.5"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-benchmark", "cpu", "-reference", reference, "-reference_threshold", "-1"}) {
+		t.Fail()
+	}
+}
+
+func TestCustomizeCommandYAMLCollect(t *testing.T) {
+	cmdTemplate, err := resources.ReadFile("resources/collector_reports.yaml.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(cmdTemplate, &cf); err != nil {
+		t.Fatal(err)
+	}
+	// every configuration command has a category
+	for _, cmd := range cf.Commands {
+		if _, ok := commandCategories[cmd.Label]; !ok && !stringInList(cmd.Label, optionalCommands) {
+			t.Errorf("command '%s' has no category", cmd.Label)
+		}
+	}
+	args := newCmdLineArgs()
+	args.collect = "storage"
+	customized, err := customizeCommandYAML(cmdTemplate, args, ".", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = yaml.Unmarshal(customized, &cf); err != nil {
+		t.Fatal(err)
+	}
+	run := make(map[string]bool)
+	for _, cmd := range cf.Commands {
+		run[cmd.Label] = cmd.Run
+	}
+	if !run["nvme"] || !run["lscpu"] || run["/proc/meminfo"] || run["dmesg"] || run["fio"] {
+		t.Errorf("unexpected commands: %v", run)
+	}
+}
+
+func TestDaemon(t *testing.T) {
+	if isValid([]string{"-schedule", "0 2 * * *"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-daemon", "-schedule", "0 2 * *"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-daemon", "-schedule", "0 2 * * *", "-blackout", "* 8-17 * * 1-5;bad"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-daemon", "-schedule", "0 2 * * *", "-blackout", "* 8-17 * * 1-5;* * * 12 *"}) {
+		t.Fail()
+	}
+}
+
+func TestRolling(t *testing.T) {
+	if isValid([]string{"-rolling", "-1"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-rolling", "20"}) || !isValid([]string{"-rolling", "0"}) {
+		t.Fail()
+	}
+	if rollingDelay(3, 0) != 0 || rollingDelay(0, 20) != 0 || rollingDelay(3, 20) != 9*time.Minute || rollingDelay(1, 7200) != 500*time.Millisecond {
+		t.Fail()
+	}
+}
+
+func TestMaxParallel(t *testing.T) {
+	if isValid([]string{"-max_parallel", "-1"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-max_parallel", "10", "-rolling", "20"}) || !isValid([]string{"-max_parallel", "0"}) {
+		t.Fail()
+	}
+	limit := newParallelLimit(2)
+	var mutex sync.Mutex
+	running, most, waited := 0, 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit.run(func() {
+				mutex.Lock()
+				running++
+				most = max(most, running)
+				mutex.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+			}, func() {
+				mutex.Lock()
+				waited++
+				mutex.Unlock()
+			})
+		}()
+	}
+	wg.Wait()
+	if most != 2 || waited == 0 {
+		t.Errorf("unexpected concurrency: %d running at most, %d waited", most, waited)
+	}
+	// no limit
+	ran := false
+	newParallelLimit(0).run(func() { ran = true }, func() { t.Error("waited without a limit") })
+	if !ran {
+		t.Fail()
+	}
+}
+
+func TestNICPeer(t *testing.T) {
+	if isValid([]string{"-nic_peer", "192.168.1.2"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-benchmark", "network", "-nic_peer", "$(reboot)"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-benchmark", "network", "-nic_peer", "peer.example.com"}) {
+		t.Fail()
+	}
+}
+
+func TestMetricsAddress(t *testing.T) {
+	if isValid([]string{"-metrics_address", "localhost:9100"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-daemon", "-schedule", "@daily", "-metrics_address", "localhost:9100"}) {
+		t.Fail()
+	}
+}
+
+func TestAuth(t *testing.T) {
+	if isValid([]string{"-auth", "kerberos"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-ip", "host.example.com", "-user", "user", "-auth", "gssapi", "-interactive_auth"}) {
+		t.Fail()
+	}
+	if !isValid([]string{"-ip", "host.example.com", "-user", "user", "-auth", "gssapi"}) {
+		t.Fail()
+	}
+}
+
+func TestFIPS(t *testing.T) {
+	saved := gFIPSBuild
+	defer func() { gFIPSBuild = saved }()
+	gFIPSBuild = false
+	if isValid([]string{"-ip", "host.example.com", "-user", "user", "-fips"}) {
+		t.Error("-fips accepted without the FIPS build")
+	}
+	gFIPSBuild = true
+	if !isValid([]string{"-ip", "host.example.com", "-user", "user", "-fips"}) {
+		t.Fail()
+	}
+}
+
+func TestGetStatusColor(t *testing.T) {
+	tests := map[string]progress.Color{
+		"?":                             progress.ColorYellow,
+		"waiting, starts at 02:00":      progress.ColorYellow,
+		"collecting data 3/94 commands": progress.ColorNone,
+		"error collecting data":         progress.ColorRed,
+		"finished creating report(s)":   progress.ColorGreen,
+		"detached collection started":   progress.ColorGreen,
+	}
+	for status, expected := range tests {
+		if color := getStatusColor("host", status); color != expected {
+			t.Errorf("%s: expected %d, got %d", status, expected, color)
+		}
+	}
+}
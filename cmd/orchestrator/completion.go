@@ -0,0 +1,377 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/intel/svr-info/internal/core"
+	"github.com/intel/svr-info/internal/target"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// flag value completion kinds
+const (
+	completeNone = iota // value can't be completed, e.g., a number
+	completeList        // comma separated list of choices
+	completeFile
+	completeDir
+)
+
+type flagCompletion struct {
+	name    string
+	isBool  bool
+	kind    int
+	choices []string
+}
+
+// subcommandCompletion is the completion of a subcommand's flags and arguments
+type subcommandCompletion struct {
+	name     string
+	flags    []flagCompletion
+	choices  []string // of the first argument, e.g., the shell of the completion subcommand
+	operands int      // completion kind of the arguments, e.g., completeFile for diff's inputs
+}
+
+// newFlagCompletions returns the completion of each flag in the flag set, in the order
+// defined by the flag set, i.e., sorted by name
+func newFlagCompletions(flagSet *flag.FlagSet, lists map[string][]string, files []string, dirs []string) (completions []flagCompletion) {
+	flagSet.VisitAll(func(f *flag.Flag) {
+		c := flagCompletion{name: f.Name}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			c.isBool = true
+		} else if choices, ok := lists[f.Name]; ok {
+			c.kind = completeList
+			c.choices = choices
+		} else if isValidType(files, f.Name) {
+			c.kind = completeFile
+		} else if isValidType(dirs, f.Name) {
+			c.kind = completeDir
+		}
+		completions = append(completions, c)
+	})
+	return
+}
+
+// getFlagCompletions returns the completion of each collect flag
+func getFlagCompletions() (completions []flagCompletion) {
+	lists := map[string][]string{
+		"format":             core.ReportTypes,
+		"benchmark":          benchmarkTypes,
+		"collect":            collectCategories,
+		"profile":            profileTypes,
+		"analyze":            analyzeTypes,
+		"megadata_profilers": megadataProfilerTypes,
+		"transport":          target.Transports,
+		"auth":               target.AuthMethods,
+		"os":                 targetOSTypes,
+		"raw_format":         rawFormats,
+		"progress":           progressModes,
+		"targets_from":       cloudProviders,
+		"targets_address":    cloudAddresses,
+	}
+	files := []string{"targets", "key", "highlight", "microcode", "instance_types", "health_weights", "reference", "audit_log", "progress_file", "replay"}
+	dirs := []string{"output", "temp", "targettemp", "storage_dir"}
+	return newFlagCompletions(newCmdLineArgs().newFlagSet(""), lists, files, dirs)
+}
+
+// getSubcommandCompletions returns the completion of each subcommand. collect and fetch
+// share the collect flags, the other subcommands' flags are taken from their flag sets.
+func getSubcommandCompletions() (completions []subcommandCompletion) {
+	var fixtureProfiles []string
+	for _, profile := range getFixtureProfiles() {
+		fixtureProfiles = append(fixtureProfiles, profile.name)
+	}
+	// flags whose meaning differs from the flags of the same name in other subcommands
+	overrides := map[string]map[string][]string{
+		"query":    {"format": {"csv", "json"}},
+		"fixtures": {"profile": fixtureProfiles},
+	}
+	files := []string{"input", "baseline", "targets", "key", "highlight", "microcode", "instance_types", "health_weights", "reference", "audit_log"}
+	dirs := []string{"output", "dir"}
+	for _, subcommand := range getSubcommands() {
+		c := subcommandCompletion{name: subcommand.name}
+		switch subcommand.name {
+		case "collect", "fetch":
+			c.flags = getFlagCompletions()
+		case "init":
+		case "completion":
+			c.choices = completionShells
+		default:
+			lists := map[string][]string{
+				"format":          core.ReportTypes,
+				"transport":       target.Transports,
+				"auth":            target.AuthMethods,
+				"os":              targetOSTypes,
+				"targets_from":    cloudProviders,
+				"targets_address": cloudAddresses,
+			}
+			for name, choices := range overrides[subcommand.name] {
+				lists[name] = choices
+			}
+			subcommandFiles := files
+			if subcommand.name == "package" {
+				subcommandFiles = append([]string{"output"}, files...) // the archive
+			}
+			if flagSet := getSubcommandFlagSet(subcommand); flagSet != nil {
+				c.flags = newFlagCompletions(flagSet, lists, subcommandFiles, dirs)
+			}
+		}
+		switch subcommand.name {
+		case "snapshot":
+			c.choices = []string{snapshotPre, snapshotPost}
+		case "diff", "import":
+			c.operands = completeFile
+		case "serve", "package":
+			c.operands = completeDir
+		}
+		completions = append(completions, c)
+	}
+	return
+}
+
+// getCompletionScript returns the completion script for the shell, registered for the
+// program name
+func getCompletionScript(shell string, program string) (script string, err error) {
+	switch shell {
+	case "bash":
+		script = getBashCompletion(program, getSubcommandCompletions())
+	case "zsh":
+		script = getZshCompletion(program, getSubcommandCompletions())
+	case "fish":
+		script = getFishCompletion(program, getSubcommandCompletions())
+	default:
+		err = fmt.Errorf("completion %s : unsupported shell, choose from: %s", shell, strings.Join(completionShells, ", "))
+	}
+	return
+}
+
+// functionName converts the program name to a valid shell function name
+func functionName(program string) string {
+	return "_" + strings.NewReplacer("-", "_", ".", "_").Replace(program)
+}
+
+// writeBashSubcommand writes the function that completes the subcommand's flags and
+// arguments, cur and prev are set by the caller
+func writeBashSubcommand(sb *strings.Builder, fn string, subcommand subcommandCompletion) {
+	sb.WriteString(fmt.Sprintf("%s_%s() {\n", fn, subcommand.name))
+	if len(subcommand.choices) > 0 {
+		sb.WriteString("    if [[ $COMP_CWORD -eq 2 && \"$cur\" != -* ]]; then\n")
+		sb.WriteString(fmt.Sprintf("        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(subcommand.choices, " ")))
+		sb.WriteString("        return\n")
+		sb.WriteString("    fi\n")
+	}
+	var files, dirs, others, flags []string
+	var lists strings.Builder
+	for _, c := range subcommand.flags {
+		flags = append(flags, "-"+c.name)
+		if c.isBool {
+			continue
+		}
+		switch c.kind {
+		case completeList:
+			lists.WriteString(fmt.Sprintf("        -%s)\n", c.name))
+			lists.WriteString(fmt.Sprintf("            %s_list \"%s\"\n", fn, strings.Join(c.choices, " ")))
+			lists.WriteString("            return ;;\n")
+		case completeFile:
+			files = append(files, "-"+c.name)
+		case completeDir:
+			dirs = append(dirs, "-"+c.name)
+		default:
+			others = append(others, "-"+c.name)
+		}
+	}
+	if len(flags) > 0 {
+		sb.WriteString("    case \"$prev\" in\n")
+		sb.WriteString(lists.String())
+		if len(files) > 0 {
+			sb.WriteString(fmt.Sprintf("        %s)\n", strings.Join(files, "|")))
+			sb.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+			sb.WriteString("            return ;;\n")
+		}
+		if len(dirs) > 0 {
+			sb.WriteString(fmt.Sprintf("        %s)\n", strings.Join(dirs, "|")))
+			sb.WriteString("            COMPREPLY=($(compgen -d -- \"$cur\"))\n")
+			sb.WriteString("            return ;;\n")
+		}
+		if len(others) > 0 {
+			sb.WriteString(fmt.Sprintf("        %s)\n", strings.Join(others, "|")))
+			sb.WriteString("            return ;;\n")
+		}
+		sb.WriteString("    esac\n")
+	}
+	switch subcommand.operands {
+	case completeFile:
+		sb.WriteString("    if [[ \"$cur\" != -* ]]; then\n")
+		sb.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		sb.WriteString("        return\n")
+		sb.WriteString("    fi\n")
+	case completeDir:
+		sb.WriteString("    if [[ \"$cur\" != -* ]]; then\n")
+		sb.WriteString("        COMPREPLY=($(compgen -d -- \"$cur\"))\n")
+		sb.WriteString("        return\n")
+		sb.WriteString("    fi\n")
+	}
+	sb.WriteString(fmt.Sprintf("    COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(flags, " ")))
+	sb.WriteString("}\n\n")
+}
+
+func getBashCompletion(program string, subcommands []subcommandCompletion) string {
+	fn := functionName(program)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# bash completion for %s\n", program))
+	sb.WriteString(fmt.Sprintf("#   source <(./%s completion bash)\n\n", program))
+	// complete the item following the last comma in comma separated lists
+	sb.WriteString(fmt.Sprintf("%s_list() {\n", fn))
+	sb.WriteString("    local prefix=\"\"\n")
+	sb.WriteString("    if [[ \"$cur\" == *,* ]]; then\n")
+	sb.WriteString("        prefix=\"${cur%,*},\"\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("    COMPREPLY=($(compgen -P \"$prefix\" -W \"$1\" -- \"${cur##*,}\"))\n")
+	sb.WriteString("}\n\n")
+	var names []string
+	for _, subcommand := range subcommands {
+		names = append(names, subcommand.name)
+		writeBashSubcommand(&sb, fn, subcommand)
+	}
+	sb.WriteString(fmt.Sprintf("%s() {\n", fn))
+	sb.WriteString("    COMPREPLY=()\n")
+	sb.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	sb.WriteString("    local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	sb.WriteString("    if [[ $COMP_CWORD -eq 1 && \"$cur\" != -* ]]; then\n")
+	sb.WriteString(fmt.Sprintf("        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(names, " ")))
+	sb.WriteString("        return\n")
+	sb.WriteString("    fi\n")
+	// the collect flags when the first argument is a flag
+	sb.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+	sb.WriteString(fmt.Sprintf("        %s)\n", strings.Join(names, "|")))
+	sb.WriteString(fmt.Sprintf("            %s_${COMP_WORDS[1]} ;;\n", fn))
+	sb.WriteString("        *)\n")
+	sb.WriteString(fmt.Sprintf("            %s_collect ;;\n", fn))
+	sb.WriteString("    esac\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString(fmt.Sprintf("complete -F %s %s ./%s\n", fn, program, program))
+	return sb.String()
+}
+
+// getZshSpecs returns the _arguments specs of the flags and arguments
+func getZshSpecs(subcommand subcommandCompletion) (specs []string) {
+	for _, c := range subcommand.flags {
+		switch {
+		case c.isBool:
+			specs = append(specs, fmt.Sprintf("'-%s'", c.name))
+		case c.kind == completeList:
+			specs = append(specs, fmt.Sprintf("'-%s:%s:_values -s , %s %s'", c.name, c.name, c.name, strings.Join(c.choices, " ")))
+		case c.kind == completeFile:
+			specs = append(specs, fmt.Sprintf("'-%s:file:_files'", c.name))
+		case c.kind == completeDir:
+			specs = append(specs, fmt.Sprintf("'-%s:directory:_files -/'", c.name))
+		default:
+			specs = append(specs, fmt.Sprintf("'-%s:%s: '", c.name, c.name))
+		}
+	}
+	if len(subcommand.choices) > 0 {
+		specs = append(specs, fmt.Sprintf("'1:argument:(%s)'", strings.Join(subcommand.choices, " ")))
+	}
+	switch subcommand.operands {
+	case completeFile:
+		specs = append(specs, "'*:file:_files'")
+	case completeDir:
+		specs = append(specs, "'*:directory:_files -/'")
+	}
+	return
+}
+
+func getZshCompletion(program string, subcommands []subcommandCompletion) string {
+	fn := functionName(program)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("#compdef %s\n", program))
+	sb.WriteString(fmt.Sprintf("# zsh completion for %s\n", program))
+	sb.WriteString(fmt.Sprintf("#   source <(./%s completion zsh)\n\n", program))
+	sb.WriteString(fmt.Sprintf("%s() {\n", fn))
+	sb.WriteString("    if (( CURRENT > 2 )); then\n")
+	sb.WriteString("        case \"$words[2]\" in\n")
+	var names []string
+	var collectSpecs []string
+	for _, subcommand := range subcommands {
+		names = append(names, subcommand.name)
+		specs := getZshSpecs(subcommand)
+		if subcommand.name == "collect" {
+			collectSpecs = specs
+		}
+		sb.WriteString(fmt.Sprintf("            %s)\n", subcommand.name))
+		if len(specs) > 0 {
+			// complete the subcommand's arguments as if it were the command
+			sb.WriteString("                shift words\n")
+			sb.WriteString("                (( CURRENT-- ))\n")
+			sb.WriteString(fmt.Sprintf("                _arguments %s\n", strings.Join(specs, " \\\n                    ")))
+		}
+		sb.WriteString("                return ;;\n")
+	}
+	sb.WriteString("        esac\n")
+	sb.WriteString("    fi\n")
+	// the collect flags when the first argument is a flag
+	sb.WriteString("    _arguments \\\n")
+	for _, spec := range collectSpecs {
+		sb.WriteString(fmt.Sprintf("        %s \\\n", spec))
+	}
+	sb.WriteString(fmt.Sprintf("        '1::command:(%s)'\n", strings.Join(names, " ")))
+	sb.WriteString("}\n\n")
+	sb.WriteString(fmt.Sprintf("if [[ \"$funcstack[1]\" == \"%s\" ]]; then\n", fn))
+	sb.WriteString(fmt.Sprintf("    %s \"$@\"\n", fn))
+	sb.WriteString("else\n")
+	sb.WriteString(fmt.Sprintf("    compdef %s %s ./%s\n", fn, program, program))
+	sb.WriteString("fi\n")
+	return sb.String()
+}
+
+func getFishCompletion(program string, subcommands []subcommandCompletion) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# fish completion for %s\n", program))
+	sb.WriteString(fmt.Sprintf("#   ./%s completion fish | source\n\n", program))
+	sb.WriteString(fmt.Sprintf("complete -c %s -f\n", program))
+	var names, others []string
+	for _, subcommand := range subcommands {
+		names = append(names, subcommand.name)
+		if subcommand.name != "collect" {
+			others = append(others, subcommand.name)
+		}
+	}
+	sb.WriteString(fmt.Sprintf("complete -c %s -n __fish_use_subcommand -a \"%s\"\n", program, strings.Join(names, " ")))
+	for _, subcommand := range subcommands {
+		// the collect flags are also completed when no subcommand is given
+		condition := fmt.Sprintf("-n \"__fish_seen_subcommand_from %s\"", subcommand.name)
+		if subcommand.name == "collect" {
+			condition = fmt.Sprintf("-n \"not __fish_seen_subcommand_from %s\"", strings.Join(others, " "))
+		}
+		if len(subcommand.choices) > 0 {
+			sb.WriteString(fmt.Sprintf("complete -c %s %s -a \"%s\"\n", program, condition, strings.Join(subcommand.choices, " ")))
+		}
+		switch subcommand.operands {
+		case completeFile:
+			sb.WriteString(fmt.Sprintf("complete -c %s %s -F\n", program, condition))
+		case completeDir:
+			sb.WriteString(fmt.Sprintf("complete -c %s %s -a \"(__fish_complete_directories)\"\n", program, condition))
+		}
+		for _, c := range subcommand.flags {
+			switch {
+			case c.isBool:
+				sb.WriteString(fmt.Sprintf("complete -c %s %s -o %s\n", program, condition, c.name))
+			case c.kind == completeList:
+				sb.WriteString(fmt.Sprintf("complete -c %s %s -o %s -x -a \"(__fish_complete_list , 'string split , %s')\"\n", program, condition, c.name, strings.Join(c.choices, ",")))
+			case c.kind == completeFile:
+				sb.WriteString(fmt.Sprintf("complete -c %s %s -o %s -r -F\n", program, condition, c.name))
+			case c.kind == completeDir:
+				sb.WriteString(fmt.Sprintf("complete -c %s %s -o %s -x -a \"(__fish_complete_directories)\"\n", program, condition, c.name))
+			default:
+				sb.WriteString(fmt.Sprintf("complete -c %s %s -o %s -x\n", program, condition, c.name))
+			}
+		}
+	}
+	return sb.String()
+}
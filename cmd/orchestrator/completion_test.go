@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFlagCompletions(t *testing.T) {
+	completions := getFlagCompletions()
+	found := make(map[string]flagCompletion)
+	for _, c := range completions {
+		found[c.name] = c
+	}
+	if c, ok := found["format"]; !ok || c.kind != completeList || !strings.Contains(strings.Join(c.choices, ","), "html") {
+		t.Errorf("unexpected format completion: %+v", c)
+	}
+	if c, ok := found["targets"]; !ok || c.kind != completeFile {
+		t.Errorf("unexpected targets completion: %+v", c)
+	}
+	if c, ok := found["output"]; !ok || c.kind != completeDir {
+		t.Errorf("unexpected output completion: %+v", c)
+	}
+	if c, ok := found["debug"]; !ok || !c.isBool {
+		t.Errorf("unexpected debug completion: %+v", c)
+	}
+}
+
+func TestSubcommandCompletions(t *testing.T) {
+	found := make(map[string]subcommandCompletion)
+	for _, c := range getSubcommandCompletions() {
+		found[c.name] = c
+	}
+	if len(found) != len(getSubcommands()) {
+		t.Errorf("expected a completion for each subcommand, got %d", len(found))
+	}
+	getFlag := func(subcommand string, name string) (flag flagCompletion) {
+		for _, c := range found[subcommand].flags {
+			if c.name == name {
+				return c
+			}
+		}
+		t.Errorf("%s : flag not found: %s", subcommand, name)
+		return
+	}
+	if c := getFlag("report", "input"); c.kind != completeFile {
+		t.Errorf("unexpected report input completion: %+v", c)
+	}
+	if c := getFlag("query", "format"); strings.Join(c.choices, ",") != "csv,json" {
+		t.Errorf("unexpected query format completion: %+v", c)
+	}
+	if c := getFlag("package", "output"); c.kind != completeFile {
+		t.Errorf("unexpected package output completion: %+v", c)
+	}
+	if c := getFlag("fetch", "benchmark"); c.kind != completeList {
+		t.Errorf("unexpected fetch benchmark completion: %+v", c)
+	}
+	if c := getFlag("snapshot", "dir"); c.kind != completeDir {
+		t.Errorf("unexpected snapshot dir completion: %+v", c)
+	}
+	if strings.Join(found["snapshot"].choices, ",") != "pre,post" || found["diff"].operands != completeFile {
+		t.Errorf("unexpected snapshot or diff arguments: %+v, %+v", found["snapshot"], found["diff"])
+	}
+	if len(found["init"].flags) != 0 {
+		t.Errorf("unexpected init flags: %+v", found["init"])
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range completionShells {
+		script, err := getCompletionScript(shell, "svr-info")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{"benchmark", "turbo", "init", "regression_threshold", "pre post"} {
+			if !strings.Contains(script, expected) {
+				t.Errorf("%s completion script does not contain '%s'", shell, expected)
+			}
+		}
+	}
+	_, err := getCompletionScript("tcsh", "svr-info")
+	if err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+}
@@ -0,0 +1,443 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+	"github.com/intel/svr-info/internal/util"
+)
+
+// In daemon mode, svr-info runs until interrupted and collects from each target on its
+// schedule, a cron expression, e.g., '0 2 * * 6' for 2 AM every Saturday. Blackout
+// windows are cron expressions that match the minutes during which collections must not
+// start, e.g., '* 8-17 * * 1-5' for business hours. The schedule and blackout windows
+// are set with -schedule and -blackout and can be set per target in the targets file,
+// e.g., per site. Targets that are due at the same time are collected together, into a
+// timestamped subdirectory of the output directory. Each run's drift report, the delta
+// report of the changes since each target's previous run, is written there too.
+
+// cronMacros are the supported shorthands for common schedules
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronFieldRanges are the minimum and maximum values of the minute, hour, day of month,
+// month, and day of week fields, 7 is also Sunday
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// cronSchedule is a parsed cron expression
+type cronSchedule struct {
+	expression string
+	fields     [5]uint64 // the matching values of each field, as bits
+	anyDay     [2]bool   // the day of month and day of week fields are *
+}
+
+// parseCron parses a five field cron expression: minute hour day-of-month month
+// day-of-week. Each field is *, a value, a range, or a comma separated list of them,
+// optionally with a /step, e.g., */15 or 1-5.
+func parseCron(expression string) (schedule *cronSchedule, err error) {
+	expression = strings.TrimSpace(expression)
+	fields := strings.Fields(expression)
+	if macro, ok := cronMacros[expression]; ok {
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		err = fmt.Errorf("expected 5 fields: minute hour day-of-month month day-of-week")
+		return
+	}
+	schedule = &cronSchedule{expression: expression}
+	for i, field := range fields {
+		if schedule.fields[i], err = parseCronField(field, cronFieldRanges[i][0], cronFieldRanges[i][1]); err != nil {
+			return nil, err
+		}
+	}
+	// Sunday is 0 or 7
+	if schedule.fields[4]&(1<<7) != 0 {
+		schedule.fields[4] |= 1
+	}
+	schedule.anyDay = [2]bool{fields[2] == "*", fields[4] == "*"}
+	return
+}
+
+func parseCronField(field string, min int, max int) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, found := strings.Cut(part, "/"); found {
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				err = fmt.Errorf("invalid step: %s", part)
+				return
+			}
+			part = rangePart
+		}
+		first, last := min, max
+		if part != "*" {
+			firstPart, lastPart, isRange := strings.Cut(part, "-")
+			if first, err = strconv.Atoi(firstPart); err != nil {
+				err = fmt.Errorf("invalid value: %s", part)
+				return
+			}
+			last = first
+			if isRange {
+				if last, err = strconv.Atoi(lastPart); err != nil {
+					err = fmt.Errorf("invalid value: %s", part)
+					return
+				}
+			} else if step > 1 {
+				last = max // e.g., 5/10 is 5, 15, 25...
+			}
+			if first < min || last > max || first > last {
+				err = fmt.Errorf("out of range %d-%d: %s", min, max, part)
+				return
+			}
+		}
+		for value := first; value <= last; value += step {
+			bits |= 1 << value
+		}
+	}
+	return
+}
+
+// matches is true if the schedule includes the minute of t. As in cron, when both the
+// day of month and day of week are restricted, either may match.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.fields[0]&(1<<t.Minute()) == 0 || s.fields[1]&(1<<t.Hour()) == 0 || s.fields[3]&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := s.fields[2]&(1<<t.Day()) != 0
+	dow := s.fields[4]&(1<<int(t.Weekday())) != 0
+	if s.anyDay[0] || s.anyDay[1] {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// scheduleHorizon limits the search for the next scheduled minute, e.g., for 0 0 30 2 *
+const scheduleHorizon = 4 * 366 * 24 * time.Hour
+
+// next returns the first scheduled minute after the given time, or the zero time if
+// there isn't one within the horizon
+func (s *cronSchedule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for end := after.Add(scheduleHorizon); t.Before(end); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// calendar is when a target may be collected, on its schedule but not during its
+// blackout windows
+type calendar struct {
+	schedule  *cronSchedule
+	blackouts []*cronSchedule
+}
+
+// parseCalendar parses the schedule and the semicolon separated blackout windows
+func parseCalendar(schedule string, blackout string) (c *calendar, err error) {
+	c = &calendar{}
+	if c.schedule, err = parseCron(schedule); err != nil {
+		err = fmt.Errorf("-schedule %s : %v", schedule, err)
+		return
+	}
+	c.blackouts, err = parseBlackouts(blackout)
+	return
+}
+
+// parseBlackouts parses the semicolon separated blackout windows
+func parseBlackouts(blackout string) (blackouts []*cronSchedule, err error) {
+	for _, window := range strings.Split(blackout, ";") {
+		if strings.TrimSpace(window) == "" {
+			continue
+		}
+		var schedule *cronSchedule
+		if schedule, err = parseCron(window); err != nil {
+			err = fmt.Errorf("-blackout %s : %v", window, err)
+			return
+		}
+		blackouts = append(blackouts, schedule)
+	}
+	return
+}
+
+// key identifies calendars that are the same
+func (c *calendar) key() string {
+	expressions := []string{c.schedule.expression}
+	for _, blackout := range c.blackouts {
+		expressions = append(expressions, blackout.expression)
+	}
+	return strings.Join(expressions, ";")
+}
+
+// blackedOut is true if t is in a blackout window
+func (c *calendar) blackedOut(t time.Time) bool {
+	for _, blackout := range c.blackouts {
+		if blackout.matches(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the first scheduled minute after the given time that isn't in a blackout
+// window, or the zero time if there isn't one within the horizon
+func (c *calendar) next(after time.Time) time.Time {
+	end := after.Add(scheduleHorizon)
+	for t := c.schedule.next(after); !t.IsZero() && t.Before(end); t = c.schedule.next(t) {
+		if !c.blackedOut(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// nextDaemonRun returns the time of the next run and the names of the targets that are
+// due then
+func nextDaemonRun(calendars map[string]*calendar, after time.Time) (names []string, at time.Time) {
+	nextByKey := make(map[string]time.Time)
+	for name, c := range calendars {
+		next, ok := nextByKey[c.key()]
+		if !ok {
+			next = c.next(after)
+			nextByKey[c.key()] = next
+		}
+		if next.IsZero() {
+			continue
+		}
+		if at.IsZero() || next.Before(at) {
+			at = next
+			names = nil
+		}
+		if next.Equal(at) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+// getCalendars returns the calendar of each target, from the targets file or the command
+// line
+func (app *App) getCalendars(targets []target.Target) (calendars map[string]*calendar, err error) {
+	calendars = make(map[string]*calendar)
+	for _, t := range targets {
+		args := app.args
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			args = targetArgs
+		}
+		if args.schedule == "" {
+			err = fmt.Errorf("%s : no schedule, set -schedule or schedule= in the targets file", t.GetName())
+			return
+		}
+		if calendars[t.GetName()], err = parseCalendar(args.schedule, args.blackout); err != nil {
+			err = fmt.Errorf("%s : %v", t.GetName(), err)
+			return
+		}
+	}
+	return
+}
+
+// doDaemon collects from the targets on their schedules until interrupted
+func (app *App) doDaemon(targets []target.Target) (err error) {
+	calendars, err := app.getCalendars(targets)
+	if err != nil {
+		return
+	}
+	if app.args.metricsAddress != "" {
+		var listener net.Listener
+		if listener, err = net.Listen("tcp", app.args.metricsAddress); err != nil {
+			return
+		}
+		app.metrics = newDaemonMetrics()
+		// the report history includes the hosts' configurations, it requires the token
+		// unless only local connections are accepted
+		history := newReportHistory(app.outputDir, os.Getenv(apiTokenEnv))
+		if history.token == "" && !isLoopbackAddr(listener.Addr()) {
+			history = nil
+		}
+		go func() {
+			if err := http.Serve(listener, app.metrics.handler(history)); err != nil {
+				log.Printf("Error: %v", err)
+			}
+		}()
+		if history != nil {
+			fmt.Fprintf(messages, "Serving health at http://%[1]s/healthz, metrics at http://%[1]s/metrics, and report history at http://%[1]s/grafana\n", listener.Addr().String())
+		} else {
+			fmt.Fprintf(messages, "Serving health at http://%[1]s/healthz and metrics at http://%[1]s/metrics\n", listener.Addr().String())
+			fmt.Fprintf(messages, "WARNING: %s isn't set, the report history isn't served on %s, set it to serve /grafana to other systems\n", apiTokenEnv, listener.Addr().String())
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// a second interrupt stops a run in progress
+		<-ctx.Done()
+		stop()
+	}()
+	for {
+		names, at := nextDaemonRun(calendars, time.Now())
+		if len(names) == 0 {
+			return fmt.Errorf("no collections are scheduled outside of the blackout windows")
+		}
+		if app.metrics != nil {
+			app.metrics.scheduled(at)
+		}
+		log.Printf("next run at %s: %s", at.Format(time.RFC3339), strings.Join(names, ", "))
+		fmt.Fprintf(messages, "Next run at %s: %s\n", at.Format("2006-01-02 15:04 MST"), strings.Join(names, ", "))
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(messages, "Stopped.")
+			return nil
+		case <-time.After(time.Until(at)):
+		}
+		var due []target.Target
+		for _, t := range targets {
+			if util.StringInList(t.GetName(), names) {
+				due = append(due, t)
+			}
+		}
+		run, err := app.newDaemonRun(at)
+		if err != nil {
+			return err
+		}
+		if app.metrics != nil {
+			app.metrics.runStarted(len(due))
+		}
+		start := time.Now()
+		err = run.collectTargets(due)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if app.metrics != nil {
+			app.metrics.runFinished(start, err)
+		}
+		if err := run.createDriftReport(app.outputDir); err != nil {
+			log.Printf("failed to create the drift report: %v", err)
+			fmt.Fprintf(os.Stderr, "WARNING: failed to create the drift report: %v\n", err)
+		}
+		removed, err := pruneDatedDirs(app.outputDir, daemonRunDirPattern, app.args.keepLast)
+		for _, dir := range removed {
+			log.Printf("removed old run directory: %s", dir)
+		}
+		if err != nil {
+			log.Printf("failed to remove old run directories: %v", err)
+		}
+		if ctx.Err() != nil {
+			fmt.Fprintln(messages, "Stopped.")
+			return nil
+		}
+	}
+}
+
+// newDaemonRun returns the app that collects the run at the given time into its own
+// directory in the daemon's output directory. The run keeps the raw data files, they're
+// the baselines of the next run's drift report and the input of the report and query
+// commands.
+func (app *App) newDaemonRun(at time.Time) (run *App, err error) {
+	args := *app.args
+	args.keepRawData = true
+	r := *app
+	r.args = &args
+	r.outputDir = filepath.Join(app.outputDir, at.Format(daemonRunDirLayout))
+	if err = os.MkdirAll(r.outputDir, 0755); err != nil {
+		return
+	}
+	run = &r
+	return
+}
+
+// getDriftBaselineFiles returns the raw data file of each target collected in the run
+// directory from the target's previous run, the newest of the other run directories in
+// the daemon's output directory that has the target's data
+func getDriftBaselineFiles(daemonDir string, runDir string) (inputs []string, baselines []string, err error) {
+	entries, err := os.ReadDir(daemonDir)
+	if err != nil {
+		return
+	}
+	var previous []string
+	for _, entry := range entries {
+		if entry.IsDir() && daemonRunDirPattern.MatchString(entry.Name()) && entry.Name() < filepath.Base(runDir) {
+			previous = append(previous, filepath.Join(daemonDir, entry.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(previous)))
+	for _, format := range rawFormats {
+		var files []string
+		if files, err = filepath.Glob(filepath.Join(runDir, "*"+getRawFileExtension(format))); err != nil {
+			return
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), getRawFileExtension(format))
+			for _, dir := range previous {
+				baseline := ""
+				for _, f := range rawFormats {
+					path := filepath.Join(dir, name+getRawFileExtension(f))
+					if exists, _ := util.FileExists(path); exists {
+						baseline = path
+					}
+				}
+				if baseline != "" {
+					inputs = append(inputs, file)
+					baselines = append(baselines, baseline)
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+// getDriftReportTypes returns the delta report formats among the -format formats, or json
+func getDriftReportTypes(format string) (reportTypes []string) {
+	for _, reportType := range []string{"html", "txt", "json"} {
+		if format == "all" || util.StringInList(reportType, strings.Split(format, ",")) {
+			reportTypes = append(reportTypes, reportType)
+		}
+	}
+	if len(reportTypes) == 0 {
+		reportTypes = []string{"json"}
+	}
+	return
+}
+
+// createDriftReport writes the delta report of the run, the changes in the configuration
+// and benchmark results of each target since its previous run, to the run directory. The
+// targets collected for the first time aren't included.
+func (app *App) createDriftReport(daemonDir string) (err error) {
+	inputs, baselines, err := getDriftBaselineFiles(daemonDir, app.outputDir)
+	if err != nil || len(inputs) == 0 {
+		return
+	}
+	reporterArgs := []string{"-input", strings.Join(inputs, ","), "-baseline", strings.Join(baselines, ","), "-output", app.outputDir, "-format", strings.Join(getDriftReportTypes(app.args.format), ",")}
+	cmd := exec.Command(filepath.Join(app.tempDir, "reporter"), reporterArgs...)
+	log.Printf("run: %s", strings.Join(cmd.Args, " "))
+	_, stderr, _, err := target.RunLocalCommand(cmd)
+	if err != nil {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr))
+	}
+	return
+}
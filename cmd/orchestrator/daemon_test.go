@@ -0,0 +1,203 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expression := range []string{"* * * * *", "0 2 * * 6", "*/15 8-17 * * 1-5", "0 0 1,15 * 7", "5/10 * * * *", "@daily"} {
+		if _, err := parseCron(expression); err != nil {
+			t.Errorf("%s : %v", expression, err)
+		}
+	}
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often"} {
+		if _, err := parseCron(expression); err == nil {
+			t.Errorf("%s : expected an error", expression)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	start := time.Date(2023, 6, 1, 10, 30, 15, 0, time.UTC) // a Thursday
+	tests := []struct {
+		expression string
+		next       time.Time
+	}{
+		{"* * * * *", time.Date(2023, 6, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * 6", time.Date(2023, 6, 3, 2, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2023, 6, 4, 0, 0, 0, 0, time.UTC)},
+		{"45 10 1 * *", time.Date(2023, 6, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 0 15 * 1", time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)}, // day of month or day of week
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := parseCron(test.expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := schedule.next(start); !next.Equal(test.next) {
+			t.Errorf("%s : expected %s, got %s", test.expression, test.next, next)
+		}
+	}
+	schedule, _ := parseCron("0 0 30 2 *")
+	if next := schedule.next(start); !next.IsZero() {
+		t.Errorf("expected no next run, got %s", next)
+	}
+}
+
+func TestCalendarBlackout(t *testing.T) {
+	start := time.Date(2023, 6, 1, 10, 30, 0, 0, time.UTC) // a Thursday
+	c, err := parseCalendar("0 * * * *", "* 8-17 * * 1-5; * * * * 6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := c.next(start); !next.Equal(time.Date(2023, 6, 1, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run: %s", next)
+	}
+	if next := c.next(time.Date(2023, 6, 2, 23, 30, 0, 0, time.UTC)); !next.Equal(time.Date(2023, 6, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected next run after Friday: %s", next)
+	}
+	if _, err := parseCalendar("0 * * * *", "* 8-17 * *"); err == nil {
+		t.Error("expected an error for an invalid blackout window")
+	}
+	c, _ = parseCalendar("0 2 * * *", "* * * * *")
+	if next := c.next(start); !next.IsZero() {
+		t.Errorf("expected no next run, got %s", next)
+	}
+}
+
+func TestNextDaemonRun(t *testing.T) {
+	start := time.Date(2023, 6, 1, 10, 30, 0, 0, time.UTC)
+	siteA, _ := parseCalendar("0 2 * * *", "")
+	siteB, _ := parseCalendar("0 2 * * *", "")
+	siteC, _ := parseCalendar("0 1 * * *", "* 0-3 2 6 *")
+	calendars := map[string]*calendar{"b1": siteB, "a1": siteA, "a2": siteA, "c1": siteC}
+	names, at := nextDaemonRun(calendars, start)
+	if !at.Equal(time.Date(2023, 6, 2, 2, 0, 0, 0, time.UTC)) || len(names) != 3 || names[0] != "a1" || names[1] != "a2" || names[2] != "b1" {
+		t.Errorf("unexpected run at %s: %v", at, names)
+	}
+	names, at = nextDaemonRun(calendars, at)
+	if !at.Equal(time.Date(2023, 6, 3, 1, 0, 0, 0, time.UTC)) || len(names) != 1 || names[0] != "c1" {
+		t.Errorf("unexpected run at %s: %v", at, names)
+	}
+}
+
+func TestGetDriftBaselineFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"2024-01-01_02-00/web1.raw.json",
+		"2024-01-01_02-00/db1.raw.json",
+		"2024-01-08_02-00/web1.raw.gob",
+		"2024-01-15_02-00/web1.raw.json",
+		"2024-01-15_02-00/db1.raw.json",
+		"2024-01-15_02-00/new1.raw.json",
+		"2024-01-22_02-00/web1.raw.json",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inputs, baselines, err := getDriftBaselineFiles(dir, filepath.Join(dir, "2024-01-15_02-00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i := range inputs {
+		rel, _ := filepath.Rel(dir, baselines[i])
+		got = append(got, filepath.Base(inputs[i])+"<"+rel)
+	}
+	expected := "db1.raw.json<2024-01-01_02-00/db1.raw.json,web1.raw.json<2024-01-08_02-00/web1.raw.gob"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(got, ","))
+	}
+	if inputs, _, _ = getDriftBaselineFiles(dir, filepath.Join(dir, "2024-01-01_02-00")); len(inputs) != 0 {
+		t.Errorf("expected no baseline for the first run, got %v", inputs)
+	}
+}
+
+func TestDaemonRunKeepsRawData(t *testing.T) {
+	captures := t.TempDir()
+	writeCaptures(t, captures)
+	targets, err := getReplayTargets(captures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the reporter records its arguments
+	tempDir := t.TempDir()
+	for _, name := range []string{"collector", "collector_deps_amd64.tgz"} {
+		if err = os.WriteFile(filepath.Join(tempDir, name), []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reporterArgsFile := filepath.Join(tempDir, "reporter.args")
+	if err = os.WriteFile(filepath.Join(tempDir, "reporter"), []byte("#!/bin/sh\necho \"$@\" >> "+reporterArgsFile+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	args := newCmdLineArgs()
+	if err = args.parse("tester", []string{"-replay", captures, "-format", "html"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = args.validate(); err != nil {
+		t.Fatal(err)
+	}
+	daemonDir := t.TempDir()
+	app := newApp(args, daemonDir, tempDir)
+	if app.progress, err = newJSONProgress(filepath.Join(t.TempDir(), "progress.json")); err != nil {
+		t.Fatal(err)
+	}
+	defer app.progress.close()
+	var runs []*App
+	for _, at := range []time.Time{time.Date(2024, 1, 1, 2, 0, 0, 0, time.Local), time.Date(2024, 1, 8, 2, 0, 0, 0, time.Local)} {
+		run, err := app.newDaemonRun(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = run.collectTargets(targets); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"hostA", "hostB"} {
+			if exists, _ := util.FileExists(filepath.Join(run.outputDir, name+getRawFileExtension(args.rawFormat))); !exists {
+				t.Errorf("%s : raw data of %s removed", run.outputDir, name)
+			}
+		}
+		runs = append(runs, run)
+	}
+	if app.args.keepRawData {
+		t.Error("daemon run changed the daemon's arguments")
+	}
+	if err = runs[1].createDriftReport(daemonDir); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(reporterArgsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	expected := "-baseline " + filepath.Join(runs[0].outputDir, "hostA"+getRawFileExtension(args.rawFormat))
+	if len(lines) != 3 || !strings.Contains(lines[2], expected) {
+		t.Errorf("expected drift report with %s, got %v", expected, lines)
+	}
+}
+
+func TestGetDriftReportTypes(t *testing.T) {
+	for format, expected := range map[string]string{"html,xlsx,json": "html,json", "xlsx": "json", "all": "html,txt,json", "txt": "txt"} {
+		if got := strings.Join(getDriftReportTypes(format), ","); got != expected {
+			t.Errorf("%s : expected %s, got %s", format, expected, got)
+		}
+	}
+}
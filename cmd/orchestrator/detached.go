@@ -0,0 +1,367 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+)
+
+// A detached collection runs in the background on the target, buffering its output in a
+// session directory, so that the orchestrator can disconnect while long-duration megadata
+// profilers run. The fetch command retrieves the data later.
+const (
+	detachedSessionPrefix = "svr-info_detached_"
+	detachedDoneFile      = "done"         // created when the collection finishes
+	detachedEndFile       = "expected_end" // estimated completion time, seconds since the epoch
+	detachedTriggerFile   = "trigger"      // the trigger condition that started the collection
+	detachedExpiredFile   = "expired"      // created when no trigger condition fired before the timeout
+	triggerInterval       = 5              // seconds between checks of the trigger conditions
+)
+
+// getDetachedBaseDir returns the directory on the target that holds the session directories
+func (c *Collection) getDetachedBaseDir() string {
+	if c.cmdLineArgs.targetTemp != "" {
+		return c.cmdLineArgs.targetTemp
+	}
+	return "/tmp"
+}
+
+// runShellCommand runs the command line with the shell on the target, with the KEY=value
+// environment variables, which are read from stdin on remote targets
+func (c *Collection) runShellCommand(command string, env []string) (stdout string, stderr string, err error) {
+	var cmd *exec.Cmd
+	tType := fmt.Sprintf("%T", c.target)
+	if tType == "*target.LocalTarget" {
+		cmd = exec.Command("bash", "-c", command)
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+	} else { // RemoteTarget
+		if len(env) > 0 {
+			readEnv, input := getStdinEnv(env)
+			cmd = exec.Command(readEnv + command)
+			cmd.Stdin = strings.NewReader(input)
+		} else {
+			cmd = exec.Command(command)
+		}
+	}
+	stdout, stderr, _, err = c.target.RunCommand(cmd)
+	return
+}
+
+// CollectDetached starts the reports and megadata collections in the background on the
+// target and returns without waiting for them to finish. The session directory on the
+// target is kept until the data is fetched.
+func (c *Collection) CollectDetached() (err error) {
+	log.Printf("detached collection starting for target: %s", c.target.GetName())
+	if !c.target.CanConnect() {
+		err = fmt.Errorf("failed to connect to target: %s", c.target.GetName())
+		log.Print(err)
+		return
+	}
+	preReqs := []string{"tar", "nohup", "setsid"}
+	if c.cmdLineArgs.timeout > 0 {
+		preReqs = append(preReqs, "timeout")
+	}
+	if !hasPreReqs(c.target, preReqs) {
+		err = notRetryable(fmt.Errorf("%s not found on target: %s", strings.Join(preReqs, ", "), c.target.GetName()))
+		log.Print(err)
+		return
+	}
+	if err = c.checkRequiredPrivileges(); err != nil {
+		log.Print(err)
+		return
+	}
+	sessionDir, err := c.target.CreateDirectory(c.getDetachedBaseDir(), detachedSessionPrefix+time.Now().Format("2006-01-02_15-04-05"))
+	if err != nil {
+		log.Printf("failed to create session directory for %s", c.target.GetName())
+		return
+	}
+	reportsCommandFilePath, err := c.prepareTarget(sessionDir)
+	if err != nil {
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	cmdTemplate, err := resources.ReadFile("resources/collector_megadata.yaml.tmpl")
+	if err != nil {
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	// the megadata profilers run for hours, don't let the collector time them out
+	megaArgs := *c.cmdLineArgs
+	runTime := megaArgs.megaDelay + megaArgs.megaDuration
+	if megaArgs.cmdTimeout < runTime+300 {
+		megaArgs.cmdTimeout = runTime + 300
+	}
+	megaCommandFilePath := c.getCommandFilePath("_megadata")
+	err = customizeCmdFile(cmdTemplate, megaCommandFilePath, sessionDir, c.target.GetName(), &megaArgs)
+	if err != nil {
+		log.Print("failed to customize command file path")
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	err = c.target.PushFile(megaCommandFilePath, sessionDir)
+	if err != nil {
+		log.Printf("failed to push megadata command file to session directory for %s", c.target.GetName())
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	megaDir := c.target.GetName() + "_" + "megadata"
+	megaPath, err := c.target.CreateDirectory(sessionDir, megaDir)
+	if err != nil {
+		log.Printf("failed to create megadata directory on %s", c.target.GetName())
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	lines := c.getDetachedScript(sessionDir, filepath.Join(sessionDir, filepath.Base(reportsCommandFilePath)), filepath.Join(sessionDir, filepath.Base(megaCommandFilePath)), megaPath, runTime)
+	scriptFilePath := filepath.Join(c.outputDir, c.target.GetName()+"_detached.sh")
+	err = os.WriteFile(scriptFilePath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	err = c.target.PushFile(scriptFilePath, sessionDir)
+	if err != nil {
+		log.Printf("failed to push detached script to session directory for %s", c.target.GetName())
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	// setsid and nohup keep the collection running after the connection is closed
+	_, stderr, err := c.runShellCommand(fmt.Sprintf("nohup setsid bash %s > /dev/null 2>&1 < /dev/null &", filepath.Join(sessionDir, filepath.Base(scriptFilePath))), c.getCollectorEnv())
+	if err != nil {
+		log.Printf("failed to start detached collector on %s, stderr: [%s]", c.target.GetName(), stderr)
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	log.Printf("detached collection started on %s in %s", c.target.GetName(), sessionDir)
+	c.sessionDir = sessionDir
+	c.ok = true
+	return
+}
+
+// getDetachedScript returns the lines of the script that runs the detached collection in
+// the session directory: the reports collection, then the megadata collection, which
+// runs for runTime seconds
+func (c *Collection) getDetachedScript(sessionDir string, reportsCommandFile string, megaCommandFile string, megaPath string, runTime int) (lines []string) {
+	collector := filepath.Join(sessionDir, "collector")
+	// as in attached collections, -timeout limits each run of the collector
+	if c.cmdLineArgs.timeout > 0 {
+		collector = fmt.Sprintf("timeout -k %d %d %s", collectorStopGracePeriod, c.cmdLineArgs.timeout, collector)
+	}
+	// the expected end, which fetch reports, is estimated before the reports collection
+	// and updated once it's finished, when the megadata collection starts
+	endTime := fmt.Sprintf("echo $(( $(date +%%s) + %d )) > %s", runTime, detachedEndFile)
+	lines = []string{
+		"#!/bin/bash",
+		"# detached collection started by svr-info, see the fetch command",
+		fmt.Sprintf("cd %s || exit 1", sessionDir),
+	}
+	if len(c.cmdLineArgs.triggers) > 0 {
+		lines = append(lines, getTriggerScript(c.cmdLineArgs.triggers, triggerInterval, c.cmdLineArgs.triggerTimeout)...)
+	}
+	lines = append(lines,
+		endTime,
+		fmt.Sprintf("%s%s %s > collector.stdout 2> collector.stderr", collector, c.getCollectorFlags(sessionDir), reportsCommandFile),
+		endTime,
+		fmt.Sprintf("(cd %s && %s%s %s > collector.stdout 2> collector.stderr)", megaPath, collector, c.getCollectorFlags(sessionDir), megaCommandFile),
+		fmt.Sprintf("touch %s", detachedDoneFile),
+	)
+	return
+}
+
+// getLatestSession returns the most recent detached session directory on the target, or
+// an empty string if there isn't one
+func (c *Collection) getLatestSession() (sessionDir string, err error) {
+	stdout, _, err := c.runShellCommand(fmt.Sprintf("ls -d %s 2>/dev/null | sort | tail -n 1", filepath.Join(c.getDetachedBaseDir(), detachedSessionPrefix+"*")), nil)
+	sessionDir = strings.TrimSpace(stdout)
+	return
+}
+
+// getRemainingTime returns the estimated time until the detached collection finishes
+func (c *Collection) getRemainingTime(sessionDir string) (remaining time.Duration, err error) {
+	stdout, _, err := c.runShellCommand(fmt.Sprintf("cat %s", filepath.Join(sessionDir, detachedEndFile)), nil)
+	if err != nil {
+		return
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+	if err != nil {
+		return
+	}
+	remaining = time.Until(time.Unix(end, 0))
+	if remaining < 0 {
+		remaining = 0
+	}
+	return
+}
+
+// Fetch retrieves the data buffered on the target by the latest detached collection.
+// Nothing is retrieved if the collection hasn't finished. Status describes the state of
+// the collection.
+func (c *Collection) Fetch() (status string, err error) {
+	log.Printf("fetch starting for target: %s", c.target.GetName())
+	if !c.target.CanConnect() {
+		err = fmt.Errorf("failed to connect to target: %s", c.target.GetName())
+		log.Print(err)
+		return
+	}
+	sessionDir, err := c.getLatestSession()
+	if err != nil || sessionDir == "" {
+		err = fmt.Errorf("no detached collection found on target: %s", c.target.GetName())
+		log.Print(err)
+		return
+	}
+	if !c.sessionFileExists(sessionDir, detachedDoneFile) {
+		if !c.sessionFileExists(sessionDir, detachedEndFile) {
+			status = "waiting for trigger condition"
+		} else {
+			var remaining time.Duration
+			remaining, err = c.getRemainingTime(sessionDir)
+			if err != nil {
+				log.Printf("failed to get expected end time of detached collection on %s: %v", c.target.GetName(), err)
+				err = nil
+			}
+			status = fmt.Sprintf("still collecting, about %s remaining", remaining.Round(time.Minute))
+		}
+		log.Printf("detached collection on %s is pending in %s: %s", c.target.GetName(), sessionDir, status)
+		return
+	}
+	if c.sessionFileExists(sessionDir, detachedExpiredFile) {
+		status = "no trigger condition fired before the timeout"
+		log.Printf("detached collection on %s expired: %s", c.target.GetName(), status)
+		c.cleanupTarget(sessionDir)
+		return
+	}
+	c.outputFilePath, err = c.getCollectorOutputFile(sessionDir)
+	if err != nil {
+		log.Printf("failed to retrieve collector output file for %s", c.target.GetName())
+		return
+	}
+	err = c.pullMegadata(sessionDir, c.target.GetName()+"_"+"megadata")
+	if err != nil {
+		return
+	}
+	c.pullAuditLog(sessionDir)
+	err = c.target.PullFile(filepath.Join(sessionDir, "collector.log"), filepath.Join(c.outputDir, c.target.GetName()+"_collector.log"))
+	if err != nil {
+		log.Printf("failed to retrieve collector.log")
+		return
+	}
+	status = "finished fetching data"
+	if c.sessionFileExists(sessionDir, detachedTriggerFile) {
+		triggerFilePath := filepath.Join(c.outputDir, c.target.GetName()+"_trigger.txt")
+		err = c.target.PullFile(filepath.Join(sessionDir, detachedTriggerFile), triggerFilePath)
+		if err != nil {
+			log.Printf("failed to retrieve trigger file")
+			return
+		}
+		var trigger []byte
+		if trigger, err = os.ReadFile(triggerFilePath); err != nil {
+			return
+		}
+		firstLine, _, _ := strings.Cut(string(trigger), "\n")
+		status += ", triggered by " + firstLine
+	}
+	c.ok = true
+	c.cleanupTarget(sessionDir)
+	return
+}
+
+// sessionFileExists is true if the file exists in the session directory on the target
+func (c *Collection) sessionFileExists(sessionDir string, name string) bool {
+	_, _, err := c.runShellCommand(fmt.Sprintf("test -f %s", filepath.Join(sessionDir, name)), nil)
+	return err == nil
+}
+
+// go routine
+func fetchCollection(collection *Collection, ch chan *Collection, statusUpdate progress.MultiSpinnerUpdateFunc) {
+	if statusUpdate != nil {
+		statusUpdate(collection.target.GetName(), "fetching data")
+	}
+	status, err := collection.Fetch()
+	if statusUpdate != nil {
+		if err != nil {
+			log.Printf("Error: %v", err)
+			collection.err = err
+			statusUpdate(collection.target.GetName(), "error fetching data")
+		} else {
+			statusUpdate(collection.target.GetName(), status)
+		}
+	}
+	ch <- collection
+}
+
+// doFetch retrieves the data from detached collections that have finished and creates
+// the reports
+func (app *App) doFetch() (err error) {
+	targets, err := app.getTargets()
+	if err != nil {
+		return
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no targets provided")
+	}
+	if app.args.interactiveAuth {
+		targets, err = app.authenticateTargets(targets)
+		if err != nil {
+			return
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("failed to authenticate to any target")
+		}
+	}
+	defer closeTargetConnections(targets)
+	multiSpinner := app.newMultiSpinner(targets)
+	app.startMultiSpinner(multiSpinner)
+	defer multiSpinner.Finish()
+	ch := make(chan *Collection)
+	limit := newParallelLimit(app.args.maxParallel)
+	for _, t := range targets {
+		args := app.args
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			args = targetArgs
+		}
+		collection := newCollection(t, args, app.outputDir, app.tempDir)
+		collection.auditLog = app.auditLog
+		go limit.run(
+			func() { fetchCollection(collection, ch, collectionStatus(collection, multiSpinner)) },
+			waitingForLimit(t.GetName(), app.args.maxParallel, multiSpinner.Status),
+		)
+	}
+	var collections []*Collection
+	for range targets {
+		collections = append(collections, <-ch)
+	}
+	var fetched []*Collection
+	for _, collection := range collections {
+		if collection.ok {
+			fetched = append(fetched, collection)
+		}
+	}
+	if len(fetched) == 0 {
+		multiSpinner.Finish()
+		return fmt.Errorf("no finished detached collections to fetch, try again later")
+	}
+	return app.createReports(fetched, multiSpinner)
+}
+
+// printDetachedSessions tells the user how to retrieve the data from detached collections
+func printDetachedSessions(collections []*Collection) {
+	fmt.Print("Detached collections:\n")
+	for _, collection := range collections {
+		if collection.ok {
+			fmt.Printf("  %s: %s\n", collection.target.GetName(), collection.sessionDir)
+		}
+	}
+	fmt.Printf("Run '%s fetch' with the same target arguments after the collections finish to retrieve the data and create reports.\n", filepath.Base(os.Args[0]))
+}
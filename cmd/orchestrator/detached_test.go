@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestDetachedScript(t *testing.T) {
+	sessionDir := t.TempDir()
+	megaPath := filepath.Join(sessionDir, "host_megadata")
+	if err := os.Mkdir(megaPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// a collector that runs past the -timeout deadline
+	if err := os.WriteFile(filepath.Join(sessionDir, "collector"), []byte("#!/bin/sh\nsleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := newCollection(target.NewLocalTarget("host", ""), &CmdLineArgs{timeout: 1}, t.TempDir(), t.TempDir())
+	lines := c.getDetachedScript(sessionDir, filepath.Join(sessionDir, "reports.yaml"), filepath.Join(sessionDir, "megadata.yaml"), megaPath, 3600)
+	start := time.Now()
+	if out, err := exec.Command("bash", "-c", strings.Join(lines, "\n")).CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v, %s", err, out)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Second {
+		t.Errorf("the collector wasn't stopped at the deadline, the script ran for %s", elapsed)
+	}
+	if _, err := os.Stat(filepath.Join(sessionDir, detachedDoneFile)); err != nil {
+		t.Error(err)
+	}
+	// the expected end is that of the megadata collection, which started after the
+	// reports collection
+	end, err := os.ReadFile(filepath.Join(sessionDir, detachedEndFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(end)), 10, 64)
+	if err != nil || seconds < start.Unix()+3600+1 {
+		t.Errorf("unexpected end: %s, %v", end, err)
+	}
+}
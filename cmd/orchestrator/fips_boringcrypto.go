@@ -0,0 +1,19 @@
+//go:build boringcrypto
+
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+// Built with GOEXPERIMENT=boringcrypto, see the orchestrator-fips make target, Go's
+// cryptography uses the FIPS validated BoringCrypto module and TLS is restricted to
+// FIPS approved settings.
+
+import (
+	_ "crypto/tls/fipsonly"
+)
+
+func init() {
+	gFIPSBuild = true
+}
@@ -0,0 +1,559 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The fixtures command generates the command outputs of hardware that we don't have, e.g.,
+// 8-socket systems, unusual DIMM populations, and exotic NICs, as raw.json files, then
+// replays them through the collection and report pipeline and checks that the reports
+// describe the hardware. Report changes can be validated across these configurations.
+
+type fixtureCPU struct {
+	modelName      string
+	family         int
+	model          int
+	stepping       int
+	uarch          string // expected microarchitecture in the reports
+	sockets        int
+	coresPerSocket int
+	threadsPerCore int
+	l3             string // per socket, e.g., 105 MiB
+	baseMHz        int
+	maxMHz         int
+	microcode      string
+	flags          string
+}
+
+type fixtureDIMM struct {
+	socket       int
+	channel      int
+	slot         int
+	sizeGB       int // zero if the slot is empty
+	dimmType     string
+	speed        int // MT/s
+	configured   int // MT/s
+	manufacturer string
+	part         string
+	rank         int
+}
+
+type fixtureNIC struct {
+	name          string
+	model         string
+	vendor        string
+	vendorID      string
+	deviceID      string
+	bus           string // PCI address, or USB bus info for USB NICs
+	usb           bool
+	driver        string
+	driverVersion string
+	firmware      string
+	speed         string // as reported by ethtool, e.g., 100000Mb/s or Unknown!
+	link          bool
+	numaNode      int
+	mac           string
+}
+
+// fixtureProfile is a synthetic host
+type fixtureProfile struct {
+	name          string
+	description   string
+	systemVendor  string
+	systemProduct string
+	cpu           fixtureCPU
+	dimms         []fixtureDIMM
+	// locator returns the DIMM's Bank Locator and Locator as the system's firmware names them
+	locator func(d fixtureDIMM) (bankLocator string, locator string)
+	nics    []fixtureNIC
+	// populatedChannels is the expected number of memory channels with a DIMM, zero if
+	// the reports can't derive it from the locators
+	populatedChannels int
+}
+
+const fixtureHostPrefix = "fixture-"
+
+var sprFlags = "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush dts acpi mmx fxsr sse sse2 ss ht tm pbe syscall nx pdpe1gb rdtscp lm constant_tsc avx avx2 avx512f avx512dq avx512bw avx512vl avx512_bf16 amx_bf16 amx_tile amx_int8"
+
+// getFixtureProfiles returns the synthetic hosts
+func getFixtureProfiles() []fixtureProfile {
+	nodeChannelDIMM := func(d fixtureDIMM) (string, string) {
+		return fmt.Sprintf("NODE %d CHANNEL %d DIMM %d", d.socket, d.channel, d.slot), fmt.Sprintf("DIMM_%c%d", 'A'+d.channel, d.slot)
+	}
+	return []fixtureProfile{
+		{
+			name:          "2s-spr-balanced",
+			description:   "2-socket Sapphire Rapids, one 64 GB DDR5 DIMM per channel, two dual-port E810 NICs, the baseline",
+			systemVendor:  "Fixture Systems",
+			systemProduct: "FS-2S-SPR",
+			cpu: fixtureCPU{
+				modelName: "Intel(R) Xeon(R) Platinum 8480+", family: 6, model: 143, stepping: 8, uarch: "SPR",
+				sockets: 2, coresPerSocket: 56, threadsPerCore: 2, l3: "105 MiB", baseMHz: 2000, maxMHz: 3800,
+				microcode: "0x2b000590", flags: sprFlags,
+			},
+			dimms:             populateDIMMs(2, 8, 1, []int{64}, "DDR5", 4800, 4800, "Samsung", "M321R8GA0BB0-CQKZJ", 2),
+			locator:           nodeChannelDIMM,
+			populatedChannels: 16,
+			nics: []fixtureNIC{
+				e810("ens785f0", "0000:4b:00.0", 0, "b4:96:91:a0:00:00"),
+				e810("ens785f1", "0000:4b:00.1", 0, "b4:96:91:a0:00:01"),
+				e810("ens801f0", "0000:ca:00.0", 1, "b4:96:91:a1:00:00"),
+				e810("ens801f1", "0000:ca:00.1", 1, "b4:96:91:a1:00:01"),
+			},
+		},
+		{
+			name:          "8s-cpx",
+			description:   "8-socket Cooper Lake, two 32 GB DDR4 DIMMs per channel, 448 logical CPUs",
+			systemVendor:  "Fixture Systems",
+			systemProduct: "FS-8S-CPX",
+			cpu: fixtureCPU{
+				modelName: "Intel(R) Xeon(R) Platinum 8380H CPU @ 2.90GHz", family: 6, model: 85, stepping: 11, uarch: "CPX",
+				sockets: 8, coresPerSocket: 28, threadsPerCore: 2, l3: "38.5 MiB", baseMHz: 2900, maxMHz: 4300,
+				microcode: "0x700001e", flags: "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov ht syscall nx lm avx avx2 avx512f avx512_bf16",
+			},
+			dimms:             populateDIMMs(8, 6, 2, []int{32}, "DDR4", 3200, 2933, "SK Hynix", "HMA84GR7DJR4N-XN", 2),
+			locator:           nodeChannelDIMM,
+			populatedChannels: 48,
+			nics: []fixtureNIC{
+				{name: "eno1", model: "Ethernet Controller X710 for 10GbE SFP+", vendor: "Intel Corporation", vendorID: "8086", deviceID: "1572",
+					bus: "0000:18:00.0", driver: "i40e", driverVersion: "2.20.12", firmware: "9.20 0x8000d8c5 1.3353.0", speed: "10000Mb/s", link: true, numaNode: 0, mac: "3c:fd:fe:00:00:01"},
+				{name: "eno2", model: "Ethernet Controller X710 for 10GbE SFP+", vendor: "Intel Corporation", vendorID: "8086", deviceID: "1572",
+					bus: "0000:18:00.1", driver: "i40e", driverVersion: "2.20.12", firmware: "9.20 0x8000d8c5 1.3353.0", speed: "Unknown!", link: false, numaNode: 0, mac: "3c:fd:fe:00:00:02"},
+			},
+		},
+		{
+			name:          "2s-icx-odd-dimms",
+			description:   "2-socket Ice Lake with mixed DIMM sizes, speeds, and vendors, empty slots, and an unpopulated channel, SuperMicro style locators",
+			systemVendor:  "Supermicro",
+			systemProduct: "SYS-220U-TNR",
+			cpu: fixtureCPU{
+				modelName: "Intel(R) Xeon(R) Gold 6338 CPU @ 2.00GHz", family: 6, model: 106, stepping: 6, uarch: "ICX",
+				sockets: 2, coresPerSocket: 32, threadsPerCore: 2, l3: "48 MiB", baseMHz: 2000, maxMHz: 3200,
+				microcode: "0xd000389", flags: "fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov ht syscall nx lm avx avx2 avx512f",
+			},
+			dimms: oddDIMMs(),
+			locator: func(d fixtureDIMM) (string, string) {
+				return fmt.Sprintf("P%d_Node%d_Channel%d_Dimm%d", d.socket, d.socket, d.channel, d.slot), fmt.Sprintf("P%d-DIMM%c%d", d.socket+1, 'A'+d.channel, d.slot+1)
+			},
+			populatedChannels: 15,
+			nics: []fixtureNIC{
+				{name: "eno1np0", model: "BCM57416 NetXtreme-E Dual-Media 10G RDMA Ethernet Controller", vendor: "Broadcom Inc. and subsidiaries", vendorID: "14e4", deviceID: "16d8",
+					bus: "0000:1a:00.0", driver: "bnxt_en", driverVersion: "6.5.0", firmware: "223.0.161.0/pkg 223.1.169.0", speed: "1000Mb/s", link: true, numaNode: 0, mac: "3c:ec:ef:00:00:01"},
+			},
+		},
+		{
+			name:          "1s-emr-exotic-nics",
+			description:   "1-socket Emerald Rapids with 400 GbE ConnectX-7, 200 GbE Broadcom with the link down, a NIC with an unknown speed, and a USB NIC",
+			systemVendor:  "Fixture Systems",
+			systemProduct: "FS-1S-EMR",
+			cpu: fixtureCPU{
+				modelName: "INTEL(R) XEON(R) PLATINUM 8592+", family: 6, model: 207, stepping: 2, uarch: "EMR",
+				sockets: 1, coresPerSocket: 64, threadsPerCore: 2, l3: "320 MiB", baseMHz: 1900, maxMHz: 3900,
+				microcode: "0x21000200", flags: sprFlags,
+			},
+			dimms:             populateDIMMs(1, 8, 1, []int{96}, "DDR5", 5600, 5600, "Micron Technology", "MTC40F2047S1RC56BB1", 2),
+			locator:           nodeChannelDIMM,
+			populatedChannels: 8,
+			nics: []fixtureNIC{
+				{name: "ens1np0", model: "MT2910 Family [ConnectX-7]", vendor: "Mellanox Technologies", vendorID: "15b3", deviceID: "1021",
+					bus: "0000:17:00.0", driver: "mlx5_core", driverVersion: "24.04-0.6.6", firmware: "28.41.1000 (MT_0000000838)", speed: "400000Mb/s", link: true, numaNode: 0, mac: "a0:88:c2:00:00:01"},
+				{name: "ens2f0np0", model: "BCM57508 NetXtreme-E 10Gb/25Gb/40Gb/50Gb/100Gb/200Gb Ethernet", vendor: "Broadcom Inc. and subsidiaries", vendorID: "14e4", deviceID: "1750",
+					bus: "0000:31:00.0", driver: "bnxt_en", driverVersion: "1.10.2-227.0.130.0", firmware: "227.0.131.0/pkg 227.1.111.0", speed: "Unknown!", link: false, numaNode: 0, mac: "00:0a:f7:00:00:01"},
+				{name: "ens3", model: "Virtio network device", vendor: "Red Hat, Inc.", vendorID: "1af4", deviceID: "1041",
+					bus: "0000:4a:00.0", driver: "virtio_net", driverVersion: "1.0.0", firmware: "", speed: "Unknown!", link: true, numaNode: -1, mac: "52:54:00:00:00:01"},
+				{name: "enx00e04c680001", model: "USB 10/100/1000 LAN", bus: "usb@2:1", usb: true,
+					driver: "r8152", driverVersion: "v1.12.13", firmware: "rtl8153a-4 v2 02/07/20", speed: "1000Mb/s", link: true, numaNode: -1, mac: "00:e0:4c:68:00:01"},
+			},
+		},
+	}
+}
+
+func e810(name string, bus string, numaNode int, mac string) fixtureNIC {
+	return fixtureNIC{name: name, model: "Ethernet Controller E810-C for QSFP", vendor: "Intel Corporation", vendorID: "8086", deviceID: "1592",
+		bus: bus, driver: "ice", driverVersion: "1.11.14", firmware: "4.20 0x8001784e 1.3346.0", speed: "100000Mb/s", link: true, numaNode: numaNode, mac: mac}
+}
+
+// populateDIMMs returns the DIMMs of a uniformly populated system, sizes are used in turn
+func populateDIMMs(sockets int, channels int, slots int, sizes []int, dimmType string, speed int, configured int, manufacturer string, part string, rank int) (dimms []fixtureDIMM) {
+	for socket := 0; socket < sockets; socket++ {
+		for channel := 0; channel < channels; channel++ {
+			for slot := 0; slot < slots; slot++ {
+				dimms = append(dimms, fixtureDIMM{socket, channel, slot, sizes[len(dimms)%len(sizes)], dimmType, speed, configured, manufacturer, part, rank})
+			}
+		}
+	}
+	return
+}
+
+// oddDIMMs returns an unbalanced population, 2 sockets, 8 channels, and 2 slots per
+// channel with a mix of sizes, speeds, and vendors, empty slots, and an empty channel
+func oddDIMMs() (dimms []fixtureDIMM) {
+	dimms = populateDIMMs(2, 8, 2, []int{64, 0}, "DDR4", 3200, 2933, "Samsung", "M393A8G40BB4-CWE", 2)
+	for i := range dimms {
+		switch {
+		case dimms[i].socket == 0 && dimms[i].channel == 1 && dimms[i].slot == 1:
+			dimms[i].sizeGB = 32 // second DIMM in a channel, smaller and from another vendor
+			dimms[i].manufacturer, dimms[i].part, dimms[i].speed, dimms[i].rank = "Micron Technology", "36ASF4G72PZ-2G9E2", 2933, 1
+		case dimms[i].socket == 1 && dimms[i].channel == 3:
+			dimms[i].sizeGB = 0 // unpopulated channel
+		case dimms[i].socket == 1 && dimms[i].channel == 6 && dimms[i].slot == 0:
+			dimms[i].sizeGB = 16
+			dimms[i].manufacturer, dimms[i].part, dimms[i].speed, dimms[i].rank = "Kingston", "KSM32RS8/16MEI", 3200, 1
+		}
+	}
+	return
+}
+
+// getCPULists returns the logical CPUs of each socket, lscpu style, e.g., 0-27,224-251
+func getCPULists(cpu fixtureCPU) (lists []string) {
+	cores := cpu.sockets * cpu.coresPerSocket
+	for socket := 0; socket < cpu.sockets; socket++ {
+		var ranges []string
+		for thread := 0; thread < cpu.threadsPerCore; thread++ {
+			first := thread*cores + socket*cpu.coresPerSocket
+			ranges = append(ranges, fmt.Sprintf("%d-%d", first, first+cpu.coresPerSocket-1))
+		}
+		lists = append(lists, strings.Join(ranges, ","))
+	}
+	return
+}
+
+func (p fixtureProfile) lscpu() string {
+	c := p.cpu
+	cpus := c.sockets * c.coresPerSocket * c.threadsPerCore
+	var b strings.Builder
+	fmt.Fprintf(&b, "Architecture:                       x86_64\n")
+	fmt.Fprintf(&b, "CPU op-mode(s):                     32-bit, 64-bit\n")
+	fmt.Fprintf(&b, "Byte Order:                         Little Endian\n")
+	fmt.Fprintf(&b, "CPU(s):                             %d\n", cpus)
+	fmt.Fprintf(&b, "On-line CPU(s) list:                0-%d\n", cpus-1)
+	fmt.Fprintf(&b, "Vendor ID:                          GenuineIntel\n")
+	fmt.Fprintf(&b, "Model name:                         %s\n", c.modelName)
+	fmt.Fprintf(&b, "CPU family:                         %d\n", c.family)
+	fmt.Fprintf(&b, "Model:                              %d\n", c.model)
+	fmt.Fprintf(&b, "Thread(s) per core:                 %d\n", c.threadsPerCore)
+	fmt.Fprintf(&b, "Core(s) per socket:                 %d\n", c.coresPerSocket)
+	fmt.Fprintf(&b, "Socket(s):                          %d\n", c.sockets)
+	fmt.Fprintf(&b, "Stepping:                           %d\n", c.stepping)
+	fmt.Fprintf(&b, "CPU max MHz:                        %d.0000\n", c.maxMHz)
+	fmt.Fprintf(&b, "CPU min MHz:                        800.0000\n")
+	fmt.Fprintf(&b, "Flags:                              %s\n", c.flags)
+	fmt.Fprintf(&b, "Virtualization:                     VT-x\n")
+	fmt.Fprintf(&b, "L1d cache:                          %d KiB (%d instances)\n", 48*c.sockets*c.coresPerSocket, c.sockets*c.coresPerSocket)
+	fmt.Fprintf(&b, "L1i cache:                          %d KiB (%d instances)\n", 32*c.sockets*c.coresPerSocket, c.sockets*c.coresPerSocket)
+	fmt.Fprintf(&b, "L2 cache:                           %d MiB (%d instances)\n", 2*c.sockets*c.coresPerSocket, c.sockets*c.coresPerSocket)
+	fmt.Fprintf(&b, "L3 cache:                           %s (%d instances)\n", c.l3, c.sockets)
+	fmt.Fprintf(&b, "NUMA node(s):                       %d\n", c.sockets)
+	for node, list := range getCPULists(c) {
+		fmt.Fprintf(&b, "NUMA node%d CPU(s):%s%s\n", node, strings.Repeat(" ", 18-len(fmt.Sprint(node))), list)
+	}
+	return b.String()
+}
+
+func (p fixtureProfile) cpuinfo() string {
+	c := p.cpu
+	cores := c.sockets * c.coresPerSocket
+	var b strings.Builder
+	for cpu := 0; cpu < cores*c.threadsPerCore; cpu++ {
+		core := cpu % cores
+		fmt.Fprintf(&b, "processor\t: %d\n", cpu)
+		fmt.Fprintf(&b, "vendor_id\t: GenuineIntel\n")
+		fmt.Fprintf(&b, "cpu family\t: %d\n", c.family)
+		fmt.Fprintf(&b, "model\t\t: %d\n", c.model)
+		fmt.Fprintf(&b, "model name\t: %s\n", c.modelName)
+		fmt.Fprintf(&b, "stepping\t: %d\n", c.stepping)
+		fmt.Fprintf(&b, "microcode\t: %s\n", c.microcode)
+		fmt.Fprintf(&b, "physical id\t: %d\n", core/c.coresPerSocket)
+		fmt.Fprintf(&b, "siblings\t: %d\n", c.coresPerSocket*c.threadsPerCore)
+		fmt.Fprintf(&b, "core id\t\t: %d\n", core%c.coresPerSocket)
+		fmt.Fprintf(&b, "cpu cores\t: %d\n", c.coresPerSocket)
+		fmt.Fprintf(&b, "flags\t\t: %s\n\n", c.flags)
+	}
+	return b.String()
+}
+
+// installedGB is the total size of the DIMMs
+func (p fixtureProfile) installedGB() (total int) {
+	for _, d := range p.dimms {
+		total += d.sizeGB
+	}
+	return
+}
+
+func (p fixtureProfile) meminfo() string {
+	total := p.installedGB() * 1024 * 1024 * 97 / 100 // kB, less the firmware's reservations
+	var b strings.Builder
+	fmt.Fprintf(&b, "MemTotal:       %d kB\n", total)
+	fmt.Fprintf(&b, "MemFree:        %d kB\n", total*9/10)
+	fmt.Fprintf(&b, "MemAvailable:   %d kB\n", total*92/100)
+	fmt.Fprintf(&b, "Buffers:        %d kB\n", 524288)
+	fmt.Fprintf(&b, "Cached:         %d kB\n", total/50)
+	fmt.Fprintf(&b, "HugePages_Total:       0\n")
+	fmt.Fprintf(&b, "Hugepagesize:       2048 kB\n")
+	return b.String()
+}
+
+func (p fixtureProfile) dmidecode() string {
+	var b strings.Builder
+	handle := 0
+	entry := func(dmiType int, title string, fields ...string) {
+		fmt.Fprintf(&b, "Handle 0x%04X, DMI type %d, 40 bytes\n%s\n", handle, dmiType, title)
+		for _, field := range fields {
+			fmt.Fprintf(&b, "\t%s\n", field)
+		}
+		b.WriteString("\n")
+		handle++
+	}
+	b.WriteString("# dmidecode 3.5\nGetting SMBIOS data from sysfs.\nSMBIOS 3.5.0 present.\n\n")
+	entry(0, "BIOS Information", "Vendor: "+p.systemVendor, "Version: 1.4.2", "Release Date: 03/15/2024")
+	entry(1, "System Information", "Manufacturer: "+p.systemVendor, "Product Name: "+p.systemProduct, "Version: 0123456789",
+		"Serial Number: FX"+strings.ToUpper(strings.ReplaceAll(p.name, "-", "")), "UUID: 00000000-0000-4000-8000-000000000000")
+	entry(2, "Base Board Information", "Manufacturer: "+p.systemVendor, "Product Name: "+p.systemProduct+"-MB", "Version: A01", "Serial Number: MB0001")
+	entry(3, "Chassis Information", "Manufacturer: "+p.systemVendor, "Type: Rack Mount Chassis", "Version: 1", "Serial Number: CH0001")
+	for socket := 0; socket < p.cpu.sockets; socket++ {
+		entry(4, "Processor Information", fmt.Sprintf("Socket Designation: CPU%d", socket), "Type: Central Processor", "Manufacturer: Intel(R) Corporation",
+			"Version: "+p.cpu.modelName, fmt.Sprintf("Max Speed: %d MHz", p.cpu.maxMHz), fmt.Sprintf("Current Speed: %d MHz", p.cpu.baseMHz),
+			fmt.Sprintf("Core Count: %d", p.cpu.coresPerSocket), fmt.Sprintf("Thread Count: %d", p.cpu.coresPerSocket*p.cpu.threadsPerCore))
+	}
+	for i, d := range p.dimms {
+		bankLocator, locator := p.locator(d)
+		if d.sizeGB == 0 {
+			entry(17, "Memory Device", "Size: No Module Installed", "Locator: "+locator, "Bank Locator: "+bankLocator, "Type: Unknown",
+				"Type Detail: Unknown", "Speed: Unknown", "Manufacturer: NO DIMM", "Serial Number: NO DIMM", "Part Number: NO DIMM", "Rank: Unknown",
+				"Configured Memory Speed: Unknown")
+			continue
+		}
+		entry(17, "Memory Device", fmt.Sprintf("Size: %d GB", d.sizeGB), "Form Factor: DIMM", "Locator: "+locator, "Bank Locator: "+bankLocator,
+			"Type: "+d.dimmType, "Type Detail: Synchronous Registered (Buffered)", fmt.Sprintf("Speed: %d MT/s", d.speed), "Manufacturer: "+d.manufacturer,
+			fmt.Sprintf("Serial Number: %08X", 0x10000000+i), "Part Number: "+d.part, fmt.Sprintf("Rank: %d", d.rank),
+			fmt.Sprintf("Configured Memory Speed: %d MT/s", d.configured))
+	}
+	entry(127, "End Of Table")
+	return b.String()
+}
+
+func (p fixtureProfile) lshw() string {
+	var b strings.Builder
+	b.WriteString("Bus info          Device           Class          Description\n")
+	b.WriteString("==============================================================\n")
+	for _, n := range p.nics {
+		if n.usb {
+			fmt.Fprintf(&b, "%-17s %-16s network        %s\n", n.bus, n.name, n.model)
+		} else {
+			fmt.Fprintf(&b, "pci@%-13s %-16s network        %s [%s:%s]\n", n.bus, n.name, n.model, n.vendorID, n.deviceID)
+		}
+	}
+	return b.String()
+}
+
+func (p fixtureProfile) nicInfo() string {
+	var b strings.Builder
+	for i, n := range p.nics {
+		link := "no"
+		if n.link {
+			link = "yes"
+		}
+		fmt.Fprintf(&b, "Settings for %s:\n\tSupported ports: [ FIBRE ]\n\tSpeed: %s\n\tDuplex: Full\n\tPort: Direct Attach Copper\n\tLink detected: %s\n", n.name, n.speed, link)
+		fmt.Fprintf(&b, "driver: %s\nversion: %s\nfirmware-version: %s\nexpansion-rom-version: \nbus-info: %s\n", n.driver, n.driverVersion, n.firmware, strings.TrimPrefix(n.bus, "usb@"))
+		fmt.Fprintf(&b, "MAC ADDRESS %s: %s\n", n.name, n.mac)
+		fmt.Fprintf(&b, "NUMA NODE %s: %d\n", n.name, n.numaNode)
+		fmt.Fprintf(&b, "CPU AFFINITY %s: %d:%d;\n", n.name, 100+i, i)
+	}
+	return b.String()
+}
+
+func (p fixtureProfile) lspci() string {
+	var b strings.Builder
+	b.WriteString("Slot:\t00:00.0\nClass:\tHost bridge\nVendor:\tIntel Corporation\nDevice:\tHost bridge\n\n")
+	for _, n := range p.nics {
+		if n.usb {
+			continue
+		}
+		fmt.Fprintf(&b, "Slot:\t%s\nClass:\tEthernet controller\nVendor:\t%s\nDevice:\t%s\nNUMANode:\t%d\n\n", strings.TrimPrefix(n.bus, "0000:"), n.vendor, n.model, n.numaNode)
+	}
+	return b.String()
+}
+
+// getHostname is the host name of the profile's fixture
+func (p fixtureProfile) getHostname() string {
+	return fixtureHostPrefix + p.name
+}
+
+// getResults returns the synthetic command results
+func (p fixtureProfile) getResults() (results []map[string]string) {
+	outputs := []struct {
+		label     string
+		stdout    string
+		superuser bool
+	}{
+		{"date -u", "Tue Jan 16 09:00:00 UTC 2024\n", false},
+		{"date", "Tue Jan 16 09:00:00 UTC 2024\n", false},
+		{"lscpu", p.lscpu(), false},
+		{"uname -a", fmt.Sprintf("Linux %s 6.5.0-15-generic #15-Ubuntu SMP PREEMPT_DYNAMIC x86_64 x86_64 x86_64 GNU/Linux\n", p.getHostname()), false},
+		{"/proc/cpuinfo", p.cpuinfo(), false},
+		{"/proc/meminfo", p.meminfo(), false},
+		{"kernel patches", "########## tainted ##########\n0\n########## livepatch ##########\n########## kpatch ##########\n", false},
+		{"/proc/cmdline", "BOOT_IMAGE=/vmlinuz-6.5.0-15-generic root=/dev/mapper/root ro quiet\n", false},
+		{"bootloader", "########## boot mode ##########\nUEFI\n########## secure boot ##########\nSecureBoot enabled\n########## shim ##########\nshim-signed 1.56+15.7-0ubuntu1\n########## grub version ##########\ngrub-install (GRUB) 2.12~rc1-10ubuntu4\n########## grubenv ##########\n########## default grub ##########\nGRUB_DEFAULT=0\nGRUB_CMDLINE_LINUX_DEFAULT=\"quiet\"\n########## grub.cfg ##########\nmenuentry 'Ubuntu' --class ubuntu $menuentry_id_option 'gnulinux-simple-root' {\n\tlinux\t/boot/vmlinuz-6.5.0-15-generic root=/dev/mapper/root ro quiet\n}\nsubmenu 'Advanced options for Ubuntu' $menuentry_id_option 'gnulinux-advanced-root' {\n\tmenuentry 'Ubuntu, with Linux 6.5.0-15-generic' --class ubuntu $menuentry_id_option 'gnulinux-6.5.0-15-generic-advanced-root' {\n\t\tlinux\t/boot/vmlinuz-6.5.0-15-generic root=/dev/mapper/root ro quiet\n\t}\n\tmenuentry 'Ubuntu, with Linux 6.5.0-15-generic (recovery mode)' --class ubuntu $menuentry_id_option 'gnulinux-6.5.0-15-generic-recovery-root' {\n\t\tlinux\t/boot/vmlinuz-6.5.0-15-generic root=/dev/mapper/root ro recovery nomodeset dis_ucode_ldr\n\t}\n}\n########## loader entries ##########\n", true},
+		{"/etc/*-release", "PRETTY_NAME=\"Ubuntu 23.10\"\nNAME=\"Ubuntu\"\nVERSION_ID=\"23.10\"\n", false},
+		{"dmidecode", p.dmidecode(), true},
+		{"lshw", p.lshw(), true},
+		{"nic info", p.nicInfo(), true},
+		{"lspci -vmm", p.lspci(), false},
+	}
+	for _, o := range outputs {
+		results = append(results, map[string]string{
+			"label":      o.label,
+			"command":    o.label,
+			"stdout":     o.stdout,
+			"stderr":     "",
+			"exitstatus": "0",
+			"superuser":  fmt.Sprint(o.superuser),
+		})
+	}
+	return
+}
+
+// writeFixture writes the profile's raw.json file in dir, returning its path
+func writeFixture(p fixtureProfile, dir string) (path string, err error) {
+	content, err := json.MarshalIndent(map[string][]map[string]string{p.getHostname(): p.getResults()}, "", "  ")
+	if err != nil {
+		return
+	}
+	path = filepath.Join(dir, p.getHostname()+".raw.json")
+	err = os.WriteFile(path, content, 0644)
+	return
+}
+
+// checkFixtureReport checks that the host's JSON report describes the profile's hardware
+func checkFixtureReport(p fixtureProfile, path string) (check selftestCheck) {
+	check = selftestCheck{name: p.name, result: selftestPass}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return selftestCheck{p.name, selftestFail, err.Error()}
+	}
+	var reports map[string]map[string][]map[string]string
+	if err = json.Unmarshal(content, &reports); err != nil {
+		return selftestCheck{p.name, selftestFail, err.Error()}
+	}
+	var problems []string
+	expect := func(what string, got string, want string) {
+		if got != want {
+			problems = append(problems, fmt.Sprintf("%s is '%s', expected '%s'", what, got, want))
+		}
+	}
+	cpu := reports["Configuration"]["CPU"]
+	if len(cpu) != 1 {
+		problems = append(problems, "no CPU table")
+	} else {
+		expect("sockets", cpu[0]["Sockets"], fmt.Sprint(p.cpu.sockets))
+		expect("cores per socket", cpu[0]["Cores per Socket"], fmt.Sprint(p.cpu.coresPerSocket))
+		expect("microarchitecture", cpu[0]["Microarchitecture"], p.cpu.uarch)
+	}
+	expect("DIMMs", fmt.Sprint(len(reports["Configuration"]["DIMM"])), fmt.Sprint(len(p.dimms)))
+	if p.populatedChannels > 0 {
+		if memory := reports["Configuration"]["Memory"]; len(memory) == 1 {
+			expect("populated memory channels", memory[0]["Populated Memory Channels"], fmt.Sprint(p.populatedChannels))
+		} else {
+			problems = append(problems, "no Memory table")
+		}
+	}
+	nics := reports["Configuration"]["NIC"]
+	expect("NICs", fmt.Sprint(len(nics)), fmt.Sprint(len(p.nics)))
+	for i := 0; i < len(nics) && i < len(p.nics); i++ {
+		expect(p.nics[i].name+" driver", nics[i]["Driver"], p.nics[i].driver)
+		expect(p.nics[i].name+" firmware", nics[i]["Firmware Version"], p.nics[i].firmware)
+	}
+	if len(problems) > 0 {
+		return selftestCheck{p.name, selftestFail, strings.Join(problems, "; ")}
+	}
+	check.detail = fmt.Sprintf("%d sockets, %d DIMM slots, %d GB, %d NICs", p.cpu.sockets, len(p.dimms), p.installedGB(), len(p.nics))
+	return
+}
+
+func runFixtures(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var output, profiles, format string
+	var list bool
+	flagSet.StringVar(&output, "output", "fixtures", "directory for the fixtures, the reports are created in its reports directory")
+	flagSet.StringVar(&profiles, "profile", "all", "comma separated list of the hardware profiles to generate, see -list")
+	flagSet.StringVar(&format, "format", "all", "the report formats to create from the fixtures, json is always created for the checks")
+	flagSet.BoolVar(&list, "list", false, "list the hardware profiles and exit")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(flagSet.Args(), " "))
+		return retError
+	}
+	allProfiles := getFixtureProfiles()
+	if list {
+		for _, p := range allProfiles {
+			fmt.Printf("%-20s %s\n", p.name, p.description)
+		}
+		return retNoError
+	}
+	var selected []fixtureProfile
+	for _, p := range allProfiles {
+		if profiles == "all" || util.StringInList(p.name, strings.Split(profiles, ",")) {
+			selected = append(selected, p)
+		}
+	}
+	if profiles != "all" && len(selected) != len(strings.Split(profiles, ",")) {
+		fmt.Fprintf(os.Stderr, "-profile %s : unknown profile, see -list\n", profiles)
+		return retError
+	}
+	if format != "all" && !util.StringInList("json", strings.Split(format, ",")) {
+		format += ",json"
+	}
+	output, err := util.AbsPath(output)
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(output, "reports"), 0755)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	// remove fixtures of profiles that weren't selected, they would be replayed too
+	if stale, err := filepath.Glob(filepath.Join(output, fixtureHostPrefix+"*.raw.json")); err == nil {
+		for _, path := range stale {
+			os.Remove(path)
+		}
+	}
+	for _, p := range selected {
+		if _, err = writeFixture(p, output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+	}
+	// replay the fixtures through the collection and report pipeline
+	reportsDir := filepath.Join(output, "reports")
+	cmdLineArgs := newCmdLineArgs()
+	if err = cmdLineArgs.parse(os.Args[0], []string{"-replay", output, "-output", reportsDir, "-format", format}); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	exitCode := runAppWithArgs(cmdLineArgs, (*App).doWork)
+	checks := []selftestCheck{{"reports", selftestPass, reportsDir}}
+	if exitCode != retNoError {
+		checks[0] = selftestCheck{"reports", selftestFail, "see " + filepath.Join(reportsDir, getLogfileName())}
+	}
+	for _, p := range selected {
+		checks = append(checks, checkFixtureReport(p, filepath.Join(reportsDir, p.getHostname()+".json")))
+	}
+	fmt.Println()
+	if !printSelftestChecks(checks) {
+		return retError
+	}
+	return retNoError
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFixtureProfiles(t *testing.T) {
+	// the fixtures are replayed, so their labels must be those of the collector's commands
+	template, err := resources.ReadFile("resources/collector_reports.yaml.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(template, &cf); err != nil {
+		t.Fatal(err)
+	}
+	labels := make(map[string]bool)
+	for _, cmd := range cf.Commands {
+		labels[cmd.Label] = true
+	}
+	dir := t.TempDir()
+	names := make(map[string]bool)
+	for _, p := range getFixtureProfiles() {
+		if names[p.name] {
+			t.Errorf("duplicate profile: %s", p.name)
+		}
+		names[p.name] = true
+		path, err := writeFixture(p, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data map[string][]map[string]string
+		if err = json.Unmarshal(content, &data); err != nil {
+			t.Fatal(err)
+		}
+		outputs := make(map[string]string)
+		for _, result := range data[p.getHostname()] {
+			if !labels[result["label"]] {
+				t.Errorf("%s: %s isn't a collector command", p.name, result["label"])
+			}
+			outputs[result["label"]] = result["stdout"]
+		}
+		if !strings.Contains(outputs["lscpu"], fmt.Sprintf("Socket(s):                          %d\n", p.cpu.sockets)) {
+			t.Errorf("%s: sockets not in lscpu", p.name)
+		}
+		if count := strings.Count(outputs["dmidecode"], "DMI type 17,"); count != len(p.dimms) {
+			t.Errorf("%s: %d DIMMs in dmidecode, expected %d", p.name, count, len(p.dimms))
+		}
+		if count := strings.Count(outputs["/proc/cpuinfo"], "processor\t:"); count != p.cpu.sockets*p.cpu.coresPerSocket*p.cpu.threadsPerCore {
+			t.Errorf("%s: %d processors in cpuinfo", p.name, count)
+		}
+		for _, nic := range p.nics {
+			if !strings.Contains(outputs["lshw"], " "+nic.name+" ") || !strings.Contains(outputs["nic info"], "Settings for "+nic.name+":") {
+				t.Errorf("%s: NIC %s missing", p.name, nic.name)
+			}
+		}
+	}
+}
+
+func TestGetCPULists(t *testing.T) {
+	lists := getCPULists(fixtureCPU{sockets: 2, coresPerSocket: 4, threadsPerCore: 2})
+	if strings.Join(lists, " ") != "0-3,8-11 4-7,12-15" {
+		t.Errorf("unexpected CPU lists: %v", lists)
+	}
+}
+
+func TestCheckFixtureReport(t *testing.T) {
+	p := getFixtureProfiles()[0]
+	var nics []map[string]string
+	for _, nic := range p.nics {
+		nics = append(nics, map[string]string{"Name": nic.name, "Driver": nic.driver, "Firmware Version": nic.firmware})
+	}
+	reports := map[string]map[string][]map[string]string{
+		"Configuration": {
+			"CPU":    {{"Sockets": fmt.Sprint(p.cpu.sockets), "Cores per Socket": fmt.Sprint(p.cpu.coresPerSocket), "Microarchitecture": p.cpu.uarch}},
+			"DIMM":   make([]map[string]string, len(p.dimms)),
+			"Memory": {{"Populated Memory Channels": fmt.Sprint(p.populatedChannels)}},
+			"NIC":    nics,
+		},
+	}
+	path := filepath.Join(t.TempDir(), p.getHostname()+".json")
+	write := func() {
+		content, err := json.Marshal(reports)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write()
+	if check := checkFixtureReport(p, path); check.result != selftestPass {
+		t.Errorf("unexpected check: %v", check)
+	}
+	reports["Configuration"]["CPU"][0]["Sockets"] = "1"
+	reports["Configuration"]["NIC"] = nics[1:]
+	write()
+	check := checkFixtureReport(p, path)
+	if check.result != selftestFail || !strings.Contains(check.detail, "sockets is '1'") || !strings.Contains(check.detail, "NICs is") {
+		t.Errorf("unexpected check: %v", check)
+	}
+}
@@ -0,0 +1,487 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In daemon mode, -metrics_address also serves the values in the JSON reports of the
+// daemon's runs at /grafana, in the shape expected by Grafana's JSON datasource
+// plugins, so that dashboards can chart values over time without exporting them to
+// another database. A target is a path to values in the reports, in the style of the
+// query command, i.e., [Report.]Table.Field, e.g., 'CPU.L3 Cache (B)'. The report
+// defaults to Configuration. The optional host payload selects the hosts, a glob
+// pattern. Numeric values are returned as time series, one per host, and any values as
+// a table with the table format. If the SVR_INFO_API_TOKEN environment variable is set,
+// requests must include the token, as in Authorization: Bearer TOKEN, the report history
+// isn't served on other than a loopback address without it.
+
+// grafanaTarget is a target of a /grafana/query request
+type grafanaTarget struct {
+	Target  string `json:"target"`
+	RefID   string `json:"refId"`
+	Hide    bool   `json:"hide"`
+	Type    string `json:"type"` // timeserie or table, from the SimpleJSON plugin
+	Payload struct {
+		Host   string `json:"host"`
+		Format string `json:"format"` // timeserie or table
+	} `json:"payload"`
+}
+
+// grafanaQuery is a /grafana/query request
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []grafanaTarget `json:"targets"`
+}
+
+// grafanaTimeSeries is a time series in a /grafana/query response, each data point is
+// a value and a time in milliseconds since the epoch
+type grafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// grafanaTable is a table in a /grafana/query response
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// grafanaMetric describes a target in a /grafana/metrics response
+type grafanaMetric struct {
+	Label    string           `json:"label"`
+	Value    string           `json:"value"`
+	Payloads []grafanaPayload `json:"payloads,omitempty"`
+}
+
+type grafanaPayload struct {
+	Name    string                 `json:"name"`
+	Label   string                 `json:"label"`
+	Type    string                 `json:"type"`
+	Options []grafanaPayloadOption `json:"options,omitempty"`
+}
+
+type grafanaPayloadOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// reportValues is a JSON report: report name, table name, rows of field values
+type reportValues map[string]map[string][]map[string]interface{}
+
+// historicalReport is the JSON report of a host from one of the daemon's runs
+type historicalReport struct {
+	time   time.Time
+	host   string
+	values reportValues
+}
+
+type cachedReport struct {
+	modTime time.Time
+	values  reportValues
+}
+
+// reportHistory reads the JSON reports in the daemon's run directories, caching them
+// until they change
+type reportHistory struct {
+	dir   string
+	token string // requests must include the token, if set
+	mu    sync.Mutex
+	cache map[string]cachedReport
+}
+
+func newReportHistory(dir string, token string) *reportHistory {
+	return &reportHistory{dir: dir, token: token, cache: make(map[string]cachedReport)}
+}
+
+// isHostReport is true if the file is a host's JSON report, as opposed to the combined
+// report of all hosts or other JSON files in the output directory
+func isHostReport(name string) bool {
+	if !strings.HasSuffix(name, ".json") || name == "all_hosts.json" {
+		return false
+	}
+	for _, suffix := range []string{".raw.json", ".facts.json"} {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// getReports returns the host reports of the runs, in the order they ran
+func (h *reportHistory) getReports() (reports []historicalReport, err error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, entry := range entries {
+		if !entry.IsDir() || !daemonRunDirPattern.MatchString(entry.Name()) {
+			continue
+		}
+		runTime, err := time.ParseInLocation(daemonRunDirLayout, entry.Name(), time.Local)
+		if err != nil {
+			continue
+		}
+		runDir := filepath.Join(h.dir, entry.Name())
+		files, err := os.ReadDir(runDir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() || !isHostReport(file.Name()) {
+				continue
+			}
+			values, err := h.readReport(filepath.Join(runDir, file.Name()))
+			if err != nil {
+				log.Printf("failed to read report %s: %v", filepath.Join(runDir, file.Name()), err)
+				continue
+			}
+			reports = append(reports, historicalReport{
+				time:   runTime,
+				host:   strings.TrimSuffix(file.Name(), ".json"),
+				values: values,
+			})
+		}
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].time.Before(reports[j].time) })
+	return
+}
+
+// readReport returns the values in the JSON report, from the cache if it hasn't changed
+func (h *reportHistory) readReport(path string) (values reportValues, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if cached, ok := h.cache[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.values, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(content, &values); err != nil {
+		return
+	}
+	h.cache[path] = cachedReport{modTime: info.ModTime(), values: values}
+	return
+}
+
+// grafanaPath is a parsed target, each name is a lower case glob pattern
+type grafanaPath struct {
+	report string
+	table  string
+	field  string
+}
+
+// parseGrafanaTarget parses a [Report.]Table.Field target
+func parseGrafanaTarget(target string) (p grafanaPath, err error) {
+	segments := strings.Split(strings.TrimPrefix(strings.TrimSpace(target), "."), ".")
+	if len(segments) == 2 {
+		segments = append([]string{"Configuration"}, segments...)
+	}
+	if len(segments) != 3 {
+		err = fmt.Errorf("%s : expected [Report.]Table.Field", target)
+		return
+	}
+	for i, segment := range segments {
+		segments[i] = strings.ToLower(segment)
+		if _, err = path.Match(segments[i], ""); err != nil || segment == "" {
+			err = fmt.Errorf("%s : invalid name: '%s'", target, segment)
+			return
+		}
+	}
+	p = grafanaPath{report: segments[0], table: segments[1], field: segments[2]}
+	return
+}
+
+// nameMatches is true if the name matches the lower case glob pattern, without regard
+// to case
+func nameMatches(pattern string, name string) bool {
+	matched, _ := path.Match(pattern, strings.ToLower(name))
+	return matched
+}
+
+// grafanaValue is a value selected by a target
+type grafanaValue struct {
+	host  string
+	field string // Table.Field
+	row   int
+	value string
+}
+
+// selectValues returns the report's values that the path selects, ordered by name
+func (p grafanaPath) selectValues(report historicalReport) (values []grafanaValue) {
+	for reportName, tables := range report.values {
+		if !nameMatches(p.report, reportName) {
+			continue
+		}
+		for tableName, rows := range tables {
+			if !nameMatches(p.table, tableName) {
+				continue
+			}
+			for rowIdx, row := range rows {
+				for fieldName, value := range row {
+					if nameMatches(p.field, fieldName) {
+						values = append(values, grafanaValue{host: report.host, field: tableName + "." + fieldName, row: rowIdx, value: fmt.Sprint(value)})
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].field != values[j].field {
+			return values[i].field < values[j].field
+		}
+		return values[i].row < values[j].row
+	})
+	return
+}
+
+// reLeadingNumber matches the number at the start of a value, e.g., 2100 in "2100 MHz"
+var reLeadingNumber = regexp.MustCompile(`^-?\d+(?:\.\d+)?`)
+
+// runGrafanaQuery returns the time series or table of each target
+func runGrafanaQuery(query grafanaQuery, reports []historicalReport) (results []interface{}, err error) {
+	for _, target := range query.Targets {
+		if target.Hide || target.Target == "" {
+			continue
+		}
+		var p grafanaPath
+		if p, err = parseGrafanaTarget(target.Target); err != nil {
+			return
+		}
+		hostPattern := strings.ToLower(target.Payload.Host)
+		if hostPattern == "" {
+			hostPattern = "*"
+		}
+		var selected []historicalReport
+		for _, report := range reports {
+			if !query.Range.From.IsZero() && report.time.Before(query.Range.From) {
+				continue
+			}
+			if !query.Range.To.IsZero() && report.time.After(query.Range.To) {
+				continue
+			}
+			if nameMatches(hostPattern, report.host) {
+				selected = append(selected, report)
+			}
+		}
+		if target.Type == "table" || target.Payload.Format == "table" {
+			table := grafanaTable{
+				Type:    "table",
+				Columns: []grafanaColumn{{"Time", "time"}, {"Host", "string"}, {"Field", "string"}, {"Row", "number"}, {"Value", "string"}},
+				Rows:    [][]interface{}{},
+			}
+			for _, report := range selected {
+				for _, v := range p.selectValues(report) {
+					table.Rows = append(table.Rows, []interface{}{report.time.UnixMilli(), v.host, v.field, v.row + 1, v.value})
+				}
+			}
+			results = append(results, table)
+			continue
+		}
+		// one series per host and field, and row if the table has more than one row
+		var names []string
+		series := make(map[string]*grafanaTimeSeries)
+		for _, report := range selected {
+			for _, v := range p.selectValues(report) {
+				number, err := strconv.ParseFloat(reLeadingNumber.FindString(v.value), 64)
+				if err != nil {
+					continue
+				}
+				name := v.host + " " + v.field
+				if v.row > 0 {
+					name += fmt.Sprintf(" [%d]", v.row+1)
+				}
+				if _, ok := series[name]; !ok {
+					series[name] = &grafanaTimeSeries{Target: name, Datapoints: [][2]float64{}}
+					names = append(names, name)
+				}
+				series[name].Datapoints = append(series[name].Datapoints, [2]float64{number, float64(report.time.UnixMilli())})
+			}
+		}
+		for _, name := range names {
+			results = append(results, series[name])
+		}
+	}
+	return
+}
+
+// getGrafanaMetrics returns the targets, i.e., the [Report.]Table.Field of each value in
+// the reports of the most recent run, that contain the filter, without regard to case
+func getGrafanaMetrics(reports []historicalReport, filter string) (metrics []grafanaMetric) {
+	if len(reports) == 0 {
+		return []grafanaMetric{}
+	}
+	latest := reports[len(reports)-1].time
+	fields := make(map[string]bool)
+	hosts := make(map[string]bool)
+	for _, report := range reports {
+		hosts[report.host] = true
+		if !report.time.Equal(latest) {
+			continue
+		}
+		for reportName, tables := range report.values {
+			for tableName, rows := range tables {
+				for _, row := range rows {
+					for fieldName := range row {
+						name := tableName + "." + fieldName
+						if reportName != "Configuration" {
+							name = reportName + "." + name
+						}
+						fields[name] = true
+					}
+				}
+			}
+		}
+	}
+	hostOptions := []grafanaPayloadOption{{Label: "All", Value: "*"}}
+	var hostNames []string
+	for host := range hosts {
+		hostNames = append(hostNames, host)
+	}
+	sort.Strings(hostNames)
+	for _, host := range hostNames {
+		hostOptions = append(hostOptions, grafanaPayloadOption{Label: host, Value: host})
+	}
+	var names []string
+	for name := range fields {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(filter)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	metrics = []grafanaMetric{}
+	for _, name := range names {
+		metrics = append(metrics, grafanaMetric{
+			Label: name,
+			Value: name,
+			Payloads: []grafanaPayload{
+				{Name: "host", Label: "Host", Type: "select", Options: hostOptions},
+				{Name: "format", Label: "Format", Type: "select", Options: []grafanaPayloadOption{{Label: "Time series", Value: "timeserie"}, {Label: "Table", Value: "table"}}},
+			},
+		})
+	}
+	return
+}
+
+// writeJSON writes the response as JSON
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error: %v", err)
+	}
+}
+
+// register adds the /grafana endpoints to the mux, requests must include the token if
+// it is set
+func (h *reportHistory) register(mux *http.ServeMux) {
+	grafana := http.NewServeMux()
+	mux.HandleFunc("/grafana/", func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" && !hasBearerToken(r, h.token) {
+			http.Error(w, "a valid token is required", http.StatusUnauthorized)
+			return
+		}
+		grafana.ServeHTTP(w, r)
+	})
+	// the datasource's connection test
+	grafana.HandleFunc("/grafana/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/grafana/" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	metricsHandler := func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Metric string `json:"metric"` // JSON datasource
+			Target string `json:"target"` // SimpleJSON datasource
+		}
+		json.NewDecoder(r.Body).Decode(&request) // the body is optional
+		reports, err := h.getReports()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		metrics := getGrafanaMetrics(reports, request.Metric+request.Target)
+		if strings.HasSuffix(r.URL.Path, "/search") {
+			names := []string{}
+			for _, metric := range metrics {
+				names = append(names, metric.Value)
+			}
+			writeJSON(w, names)
+			return
+		}
+		writeJSON(w, metrics)
+	}
+	grafana.HandleFunc("/grafana/metrics", metricsHandler)
+	grafana.HandleFunc("/grafana/search", metricsHandler)
+	grafana.HandleFunc("/grafana/metric-payload-options", func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		reports, err := h.getReports()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		options := []grafanaPayloadOption{}
+		if metrics := getGrafanaMetrics(reports, ""); len(metrics) > 0 {
+			for _, payload := range metrics[0].Payloads {
+				if payload.Name == request.Name {
+					options = payload.Options
+				}
+			}
+		}
+		writeJSON(w, options)
+	})
+	grafana.HandleFunc("/grafana/query", func(w http.ResponseWriter, r *http.Request) {
+		var query grafanaQuery
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		reports, err := h.getReports()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results, err := runGrafanaQuery(query, reports)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if results == nil {
+			results = []interface{}{}
+		}
+		writeJSON(w, results)
+	})
+}
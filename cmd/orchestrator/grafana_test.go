@@ -0,0 +1,104 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrafanaQuery(t *testing.T) {
+	dir := t.TempDir()
+	for run, frequency := range map[string]string{"2024-01-31_02-00": "2100 MHz", "2024-02-07_02-00": "2000 MHz"} {
+		runDir := filepath.Join(dir, run)
+		if err := os.Mkdir(runDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		report := `{"Configuration":{"CPU":[{"Base Frequency":"` + frequency + `","Microarchitecture":"SPR"}]},"Brief":{"CPU":[{"Sockets":"2"}]}}`
+		for _, name := range []string{"host1.json", "all_hosts.json", "host1.facts.json"} {
+			if err := os.WriteFile(filepath.Join(runDir, name), []byte(report), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	m := newDaemonMetrics()
+	server := httptest.NewServer(m.handler(newReportHistory(dir, "")))
+	defer server.Close()
+	post := func(endpoint string, body string, response interface{}) {
+		r, err := server.Client().Post(server.URL+endpoint, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+		if r.StatusCode != 200 {
+			t.Fatalf("%s: unexpected status: %d", endpoint, r.StatusCode)
+		}
+		if err = json.NewDecoder(r.Body).Decode(response); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	post("/grafana/search", `{"target":"cpu"}`, &names)
+	if strings.Join(names, ",") != "Brief.CPU.Sockets,CPU.Base Frequency,CPU.Microarchitecture" {
+		t.Errorf("unexpected metrics: %v", names)
+	}
+	var series []grafanaTimeSeries
+	post("/grafana/query", `{"targets":[{"target":"CPU.Base Frequency","refId":"A","payload":{"host":"host*"}}]}`, &series)
+	if len(series) != 1 || series[0].Target != "host1 CPU.Base Frequency" || len(series[0].Datapoints) != 2 {
+		t.Fatalf("unexpected series: %+v", series)
+	}
+	first := time.Date(2024, 1, 31, 2, 0, 0, 0, time.Local)
+	if series[0].Datapoints[0] != [2]float64{2100, float64(first.UnixMilli())} || series[0].Datapoints[1][0] != 2000 {
+		t.Errorf("unexpected data points: %v", series[0].Datapoints)
+	}
+	// the time range selects the runs
+	post("/grafana/query", `{"range":{"from":"2024-02-01T00:00:00Z","to":"2024-03-01T00:00:00Z"},"targets":[{"target":"CPU.Base Frequency"}]}`, &series)
+	if len(series) != 1 || len(series[0].Datapoints) != 1 || series[0].Datapoints[0][0] != 2000 {
+		t.Errorf("unexpected series in range: %+v", series)
+	}
+	var tables []grafanaTable
+	post("/grafana/query", `{"targets":[{"target":"CPU.Microarchitecture","type":"table"}]}`, &tables)
+	if len(tables) != 1 || len(tables[0].Rows) != 2 || tables[0].Rows[1][1] != "host1" || tables[0].Rows[1][4] != "SPR" {
+		t.Errorf("unexpected table: %+v", tables)
+	}
+	if _, err := parseGrafanaTarget("CPU"); err == nil {
+		t.Error("expected error for target without a field")
+	}
+}
+
+func TestGrafanaToken(t *testing.T) {
+	m := newDaemonMetrics()
+	server := httptest.NewServer(m.handler(newReportHistory(t.TempDir(), "secret")))
+	defer server.Close()
+	for authorization, expected := range map[string]int{"": http.StatusUnauthorized, "Bearer wrong": http.StatusUnauthorized, "Bearer secret": http.StatusOK} {
+		request, _ := http.NewRequest(http.MethodPost, server.URL+"/grafana/search", strings.NewReader(`{}`))
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != expected {
+			t.Errorf("%q: expected %d, got %d", authorization, expected, response.StatusCode)
+		}
+	}
+	// health and metrics don't require the token
+	response, err := server.Client().Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("/healthz: expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A host given on the command line, or in the targets file, can contain ranges in
+// brackets, e.g., node[01-48].dc1.example.com, that are expanded into one target per
+// host, with the same settings. A range is a comma separated list of numbers and
+// number ranges, e.g., [1,3,10-12]. A range's numbers are padded with zeros to the
+// width of its first number, e.g., [01-10] gives 01, 02, ..., 10. Several ranges in a
+// host, e.g., rack[1-2]-node[1-4], give every combination.
+
+// maxHostRangeSize is the maximum number of hosts a host with ranges can expand to, to
+// catch typos, e.g., [1-10000] for [1-100]
+const maxHostRangeSize = 4096
+
+// hasHostRange returns true if the host contains a range
+func hasHostRange(host string) bool {
+	return strings.ContainsAny(host, "[]")
+}
+
+// expandHostRange returns the hosts that the host's ranges expand to, the host itself if
+// it doesn't contain a range
+func expandHostRange(host string) (hosts []string, err error) {
+	begin := strings.Index(host, "[")
+	end := strings.Index(host, "]")
+	if begin == -1 && end == -1 {
+		hosts = []string{host}
+		return
+	}
+	if begin == -1 || end < begin {
+		err = fmt.Errorf("invalid host range: %s", host)
+		return
+	}
+	var values []string
+	if values, err = parseHostRange(host[begin+1 : end]); err != nil {
+		err = fmt.Errorf("invalid host range: %s: %v", host, err)
+		return
+	}
+	var suffixes []string
+	if suffixes, err = expandHostRange(host[end+1:]); err != nil {
+		return
+	}
+	if len(values)*len(suffixes) > maxHostRangeSize {
+		err = fmt.Errorf("host range expands to more than %d hosts: %s", maxHostRangeSize, host)
+		return
+	}
+	for _, value := range values {
+		for _, suffix := range suffixes {
+			hosts = append(hosts, host[:begin]+value+suffix)
+		}
+	}
+	return
+}
+
+// parseHostRange returns the values in a range, i.e., the text between the brackets
+func parseHostRange(hostRange string) (values []string, err error) {
+	if strings.Contains(hostRange, "[") {
+		err = fmt.Errorf("nested range")
+		return
+	}
+	for _, item := range strings.Split(hostRange, ",") {
+		first, last, isRange := strings.Cut(item, "-")
+		if !isRange {
+			last = first
+		}
+		var start, end int
+		if start, err = strconv.Atoi(first); err != nil || start < 0 {
+			err = fmt.Errorf("not a number: %s", first)
+			return
+		}
+		if end, err = strconv.Atoi(last); err != nil || end < 0 {
+			err = fmt.Errorf("not a number: %s", last)
+			return
+		}
+		if end < start {
+			err = fmt.Errorf("range ends before it starts: %s", item)
+			return
+		}
+		// both are non-negative, so end-start doesn't overflow, unlike end-start+1
+		if end-start >= maxHostRangeSize-len(values) {
+			err = fmt.Errorf("more than %d values", maxHostRangeSize)
+			return
+		}
+		width := 0
+		if strings.HasPrefix(first, "0") {
+			width = len(first)
+		}
+		// counted from 0 so that the loop ends when end is the largest int
+		for i := 0; i <= end-start; i++ {
+			values = append(values, fmt.Sprintf("%0*d", width, start+i))
+		}
+	}
+	return
+}
+
+// expandTargetRanges returns the targets with each target whose host contains ranges
+// replaced by one target per host. A target's label, if it's not the host, must expand
+// to the same number of labels, one per host, so that the targets' names are unique.
+func expandTargetRanges(targets []targetFromFile) (expanded []targetFromFile, err error) {
+	for _, t := range targets {
+		if !hasHostRange(t.ip) {
+			expanded = append(expanded, t)
+			continue
+		}
+		var hosts []string
+		if hosts, err = expandHostRange(t.ip); err != nil {
+			err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+			return
+		}
+		labels := make([]string, len(hosts))
+		if t.label == t.ip {
+			copy(labels, hosts)
+		} else if t.label != "" {
+			if labels, err = expandHostRange(t.label); err != nil {
+				err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+				return
+			}
+			if len(labels) != len(hosts) {
+				err = fmt.Errorf("targets file %s: label %s expands to %d labels, host %s expands to %d hosts", t.getLocation(), t.label, len(labels), t.ip, len(hosts))
+				return
+			}
+		}
+		for i, host := range hosts {
+			hostTarget := t
+			hostTarget.ip = host
+			hostTarget.label = labels[i]
+			expanded = append(expanded, hostTarget)
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandHostRange(t *testing.T) {
+	tests := []struct {
+		host  string
+		hosts []string
+	}{
+		{"node1.example.com", []string{"node1.example.com"}},
+		{"node[1-3]", []string{"node1", "node2", "node3"}},
+		{"node[08-10].dc1", []string{"node08.dc1", "node09.dc1", "node10.dc1"}},
+		{"node[1,5,10-11]", []string{"node1", "node5", "node10", "node11"}},
+		{"rack[1-2]-node[1-2]", []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2"}},
+		{"10.0.0.[1-2]", []string{"10.0.0.1", "10.0.0.2"}},
+		{"node[9223372036854775806-9223372036854775807]", []string{"node9223372036854775806", "node9223372036854775807"}},
+	}
+	for _, test := range tests {
+		hosts, err := expandHostRange(test.host)
+		if err != nil {
+			t.Errorf("%s: %v", test.host, err)
+			continue
+		}
+		if strings.Join(hosts, " ") != strings.Join(test.hosts, " ") {
+			t.Errorf("%s: unexpected hosts: %v", test.host, hosts)
+		}
+	}
+	for _, host := range []string{"node[1-3", "node1-3]", "node[]", "node[a-c]", "node[3-1]", "node[[1-2]]", "node[1-5000]", "a[1-100]b[1-100]", "node[0-9223372036854775807]", "node[1-10,0-9223372036854775807]", "node[0-9223372036854775808]"} {
+		if _, err := expandHostRange(host); err == nil {
+			t.Errorf("%s: expected an error", host)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/core"
+	"github.com/intel/svr-info/internal/rawdata"
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The import command adds the collected data in archives of previous runs, e.g., made by
+// the package command, to the daemon's output directory, as if the daemon had collected
+// it. Each host's data goes in the run directory named for the time it was collected, and
+// its reports are created there, so that it's included in the daemon's report history
+// and in the reports, diffs, and queries of the output directory.
+
+// importedFile is a host's collected data extracted from an archive
+type importedFile struct {
+	name      string    // the file's name in the archive, without the directory
+	path      string    // where it was extracted
+	collected time.Time // when the data was collected
+}
+
+// extractRawFiles extracts the collected data (*.raw.json, *.raw.gob) files in the
+// archive to dir. The time the data was collected is from the data's 'date -u' command,
+// or the file's modification time if the command wasn't collected.
+func extractRawFiles(archivePath string, dir string) (files []importedFile, err error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		var header *tar.Header
+		header, err = tr.Next()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		name := filepath.Base(header.Name)
+		if header.Typeflag != tar.TypeReg || !(strings.HasSuffix(name, ".raw.json") || strings.HasSuffix(name, rawdata.GobExtension)) {
+			continue
+		}
+		file := importedFile{
+			name:      name,
+			path:      filepath.Join(dir, fmt.Sprintf("%d_%s", len(files), name)),
+			collected: header.ModTime,
+		}
+		var out *os.File
+		if out, err = os.Create(file.path); err != nil {
+			return
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return
+		}
+		if collected := getRawCollectionTime(file.path); !collected.IsZero() {
+			file.collected = collected
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("no collected data (*.raw.json, *.raw.gob) files in %s", archivePath)
+	}
+	return
+}
+
+// getRawCollectionTime returns the time in the output of the 'date -u' command in the
+// collected data, or the zero time if it wasn't collected
+func getRawCollectionTime(path string) (collected time.Time) {
+	format := rawFormatJSON
+	if strings.HasSuffix(path, rawdata.GobExtension) {
+		format = rawFormatGob
+	}
+	_, results, err := readRawResults(path, format)
+	if err != nil {
+		return
+	}
+	for _, result := range results {
+		if result["label"] == "date -u" {
+			collected, _ = time.Parse(time.UnixDate, strings.TrimSpace(result["stdout"]))
+			break
+		}
+	}
+	return
+}
+
+// importArchive copies the collected data in the archive to the run directories in
+// outputDir, the daemon's output directory. The run directories that data was added to
+// are returned, in order, with the names of the files that were already imported, which
+// are skipped.
+func importArchive(archivePath string, outputDir string) (runDirs []string, skipped []string, err error) {
+	// extracted in outputDir so that the files can be moved to the run directories
+	tempDir, err := os.MkdirTemp(outputDir, ".import.")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tempDir)
+	files, err := extractRawFiles(archivePath, tempDir)
+	if err != nil {
+		return
+	}
+	added := make(map[string]bool)
+	for _, file := range files {
+		runDir := filepath.Join(outputDir, file.collected.Local().Format(daemonRunDirLayout))
+		dest := filepath.Join(runDir, file.name)
+		var exists bool
+		if exists, err = util.FileExists(dest); err != nil {
+			return
+		}
+		if exists {
+			skipped = append(skipped, filepath.Join(filepath.Base(runDir), file.name))
+			continue
+		}
+		if err = os.MkdirAll(runDir, 0755); err != nil {
+			return
+		}
+		if err = os.Rename(file.path, dest); err != nil {
+			return
+		}
+		added[runDir] = true
+	}
+	for runDir := range added {
+		runDirs = append(runDirs, runDir)
+	}
+	sort.Strings(runDirs)
+	return
+}
+
+func runImport(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var output, format string
+	flagSet.StringVar(&output, "output", ".", "the daemon's output directory, the data is added to its run directories")
+	flagSet.StringVar(&format, "format", "json", "comma separated list of report format(s) to create in the run directories: "+strings.Join(core.ReportTypes, ",")+", the report history requires json")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "%s : one or more archives (*.tgz) are required\n", name)
+		return retError
+	}
+	if err := argTypesValid(core.ReportTypes, format, "format"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	outputDir, err := util.AbsPath(output)
+	if err == nil {
+		err = argDirExists(outputDir, "output")
+	}
+	if err == nil {
+		err = argDirWritable(outputDir, "output")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	var runDirs []string
+	for _, archivePath := range flagSet.Args() {
+		added, skipped, err := importArchive(archivePath, outputDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", archivePath, err)
+			return retError
+		}
+		for _, file := range skipped {
+			fmt.Printf("Skipped %s, already imported\n", file)
+		}
+		for _, runDir := range added {
+			if !util.StringInList(runDir, runDirs) {
+				runDirs = append(runDirs, runDir)
+			}
+		}
+	}
+	sort.Strings(runDirs)
+	// the reports of the hosts already in a run directory are created again, so that the
+	// run's combined reports include the imported hosts
+	for _, runDir := range runDirs {
+		fmt.Printf("Imported %s\n", runDir)
+		if exitCode := runReporter([]string{"-input", runDir, "-output", runDir, "-format", format}); exitCode != retNoError {
+			return exitCode
+		}
+	}
+	return retNoError
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/rawdata"
+)
+
+func TestImportArchive(t *testing.T) {
+	runDir := filepath.Join(t.TempDir(), "svr-info_2023-05-01_02-00-00")
+	if err := os.Mkdir(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	collected := time.Date(2023, 5, 1, 2, 0, 0, 0, time.UTC)
+	err := writeRawResults(filepath.Join(runDir, "hostA.raw.json"), rawFormatJSON, "hostA", []map[string]string{
+		{"label": "date -u", "stdout": collected.Format(time.UnixDate) + "\n"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without the date, the file's modification time is used
+	gobPath := filepath.Join(runDir, "hostB"+rawdata.GobExtension)
+	if err = writeRawResults(gobPath, rawFormatGob, "hostB", []map[string]string{{"label": "lscpu"}}); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2023, 6, 1, 3, 30, 0, 0, time.Local)
+	if err = os.Chtimes(gobPath, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(runDir, "hostA.html"), []byte("<html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive := runDir + ".tgz"
+	if err = packageDir(runDir, archive); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	runDirs, skipped, err := importArchive(archive, outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(outputDir, collected.Local().Format(daemonRunDirLayout)),
+		filepath.Join(outputDir, modified.Format(daemonRunDirLayout)),
+	}
+	if len(runDirs) != 2 || runDirs[0] != expected[0] || runDirs[1] != expected[1] || len(skipped) != 0 {
+		t.Fatalf("unexpected import: %v, %v", runDirs, skipped)
+	}
+	for i, name := range []string{"hostA.raw.json", "hostB" + rawdata.GobExtension} {
+		if _, err = os.Stat(filepath.Join(runDirs[i], name)); err != nil {
+			t.Error(err)
+		}
+		if !daemonRunDirPattern.MatchString(filepath.Base(runDirs[i])) {
+			t.Errorf("not a run directory: %s", runDirs[i])
+		}
+	}
+	// the temporary directory is removed
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 2 {
+		t.Errorf("unexpected entries in the output directory: %v", entries)
+	}
+	// imported again
+	if runDirs, skipped, err = importArchive(archive, outputDir); err != nil || len(runDirs) != 0 || len(skipped) != 2 {
+		t.Errorf("unexpected import: %v, %v, %v", runDirs, skipped, err)
+	}
+	// no collected data
+	if err = os.Remove(filepath.Join(runDir, "hostA.raw.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Remove(gobPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = packageDir(runDir, archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = importArchive(archive, outputDir); err == nil {
+		t.Error("expected an error for an archive without collected data")
+	}
+}
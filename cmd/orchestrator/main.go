@@ -16,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,35 +32,63 @@ var resources embed.FS
 
 // globals
 var (
-	gVersion string = "dev" // build overrides this, see makefile
+	gVersion   string = "dev" // build overrides this, see makefile
+	gFIPSBuild bool           // built with the FIPS validated Go crypto module, see fips_boringcrypto.go
 )
 
 type App struct {
-	outputDir string
-	tempDir   string
-	args      *CmdLineArgs
+	outputDir  string
+	tempDir    string
+	args       *CmdLineArgs
+	targetArgs map[string]*CmdLineArgs // per-target overrides from the targets file, by target name
+	auditLog   *AuditLog               // nil unless -audit_log is set
+	metrics    *daemonMetrics          // nil unless -metrics_address is set in daemon mode
+	timings    *progress.Timings       // of the commands in previous collections, for progress estimates
+	progress   *JSONProgress           // nil unless -progress is json
 }
 
 func newApp(args *CmdLineArgs, outputDir string, tempDir string) *App {
 	app := App{
-		outputDir: outputDir,
-		tempDir:   tempDir,
-		args:      args,
+		outputDir:  outputDir,
+		tempDir:    tempDir,
+		args:       args,
+		targetArgs: make(map[string]*CmdLineArgs),
 	}
 	return &app
 }
 
 func (app *App) getTargets() (targets []target.Target, err error) {
-	// if we have a targets file
-	if app.args.targets != "" {
-		targetsFile := newTargetsFile(app.args.targets)
+	kerberos := false // a remote target uses GSSAPI authentication
+	remote := false   // a target is reached over SSH
+	// if replaying captured data
+	if app.args.replay != "" {
+		targets, err = getReplayTargets(app.args.replay)
+	} else if app.args.targets != "" || app.args.targetsCmd != "" || app.args.targetsFrom != "" { // if we have a targets file, command, or cloud provider
 		var targetsFromFile []targetFromFile
-		targetsFromFile, err = targetsFile.parse()
+		if app.args.targetsCmd != "" {
+			targetsFromFile, err = getTargetsFromCommand(app.args.targetsCmd)
+		} else if app.args.targetsFrom != "" {
+			targetsFromFile, err = getTargetsFromCloud(app.args)
+		} else {
+			targetsFromFile, err = newTargetsFile(app.args.targets).parse()
+		}
+		if err == nil && app.args.targetsSelect != "" {
+			targetsFromFile, err = selectTargets(targetsFromFile, app.args.targetsSelect)
+		}
 		if err != nil {
 			return
 		}
 		for _, t := range targetsFromFile {
+			var targetArgs *CmdLineArgs
+			targetArgs, err = t.applyOptions(app.args)
+			if err != nil {
+				return
+			}
 			if t.ip == "localhost" { // special case, "localhost" in targets file
+				if targetArgs.targetOS == targetOSWindows {
+					err = fmt.Errorf("targets file %s: localhost isn't a Windows host", t.getLocation())
+					return
+				}
 				var hostname string
 				if t.label != "" {
 					hostname = t.label
@@ -70,14 +99,48 @@ func (app *App) getTargets() (targets []target.Target, err error) {
 					}
 				}
 				localTarget := target.NewLocalTarget(hostname, t.sudo)
+				localTarget.SetCapabilities(app.args.capabilities)
 				if !localTarget.CanElevatePrivileges() {
 					log.Print("local target in targets file without root privileges.")
-					fmt.Println("WARNING: User does not have root privileges. Not all data will be collected.")
+					fmt.Fprintln(messages, "WARNING: User does not have root privileges. Not all data will be collected.")
 				}
 				targets = append(targets, localTarget)
 			} else {
-				targets = append(targets, target.NewRemoteTarget(t.label, t.ip, t.port, t.user, t.key, t.pwd, filepath.Join(app.tempDir, "sshpass"), t.sudo))
+				var remoteTarget *target.RemoteTarget
+				var windowsTarget *target.WindowsTarget
+				if targetArgs.targetOS == targetOSWindows {
+					windowsTarget = target.NewWindowsTarget(t.label, t.ip, t.port, t.user, t.key, t.pwd, filepath.Join(app.tempDir, "sshpass"))
+					remoteTarget = &windowsTarget.RemoteTarget
+				} else {
+					remoteTarget = target.NewRemoteTarget(t.label, t.ip, t.port, t.user, t.key, t.pwd, filepath.Join(app.tempDir, "sshpass"), t.sudo)
+				}
+				remoteTarget.SetInteractiveAuth(app.args.interactiveAuth)
+				remoteTarget.SetControlDir(app.tempDir)
+				err = remoteTarget.SetTransport(targetArgs.transport)
+				if err == nil {
+					err = remoteTarget.SetProxy(targetArgs.proxy)
+				}
+				if err == nil {
+					err = remoteTarget.SetJumpHost(targetArgs.jumpHost)
+				}
+				if err == nil {
+					err = remoteTarget.SetAuth(targetArgs.auth)
+					kerberos = kerberos || targetArgs.auth == target.AuthGSSAPI
+				}
+				remoteTarget.SetFIPS(app.args.fips)
+				remoteTarget.SetCapabilities(app.args.capabilities)
+				remote = true
+				if err != nil {
+					err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+					return
+				}
+				if windowsTarget != nil {
+					targets = append(targets, windowsTarget)
+				} else {
+					targets = append(targets, remoteTarget)
+				}
 			}
+			app.targetArgs[targets[len(targets)-1].GetName()] = targetArgs
 		}
 	} else {
 		// if collecting on localhost
@@ -88,80 +151,263 @@ func (app *App) getTargets() (targets []target.Target, err error) {
 				return
 			}
 			localTarget := target.NewLocalTarget(hostname, "")
+			localTarget.SetCapabilities(app.args.capabilities)
 			// ask for password if can't elevate privileges without it, but only if getting
 			// input from a terminal, i.e., not from a script (for testing)
 			if !localTarget.CanElevatePrivileges() {
-				fmt.Println("WARNING:  Some data items cannot be collected without elevated privileges.")
+				fmt.Fprintln(messages, "WARNING:  Some data items cannot be collected without elevated privileges.")
 				if !term.IsTerminal(int(os.Stdin.Fd())) {
 					log.Print("NOT prompting for password because STDIN isn't coming from a terminal.")
 				} else {
 					log.Print("Prompting for password.")
-					fmt.Print("To collect all data, enter sudo password followed by Enter. Otherwise, press Enter:")
+					fmt.Fprint(messages, "To collect all data, enter sudo password followed by Enter. Otherwise, press Enter:")
 					var pwd []byte
 					pwd, err = term.ReadPassword(0)
 					if err != nil {
 						return
 					}
-					fmt.Printf("\n") // newline after password
+					fmt.Fprintf(messages, "\n") // newline after password
 					localTarget.SetSudo(string(pwd))
 					if localTarget.GetSudo() != "" && !localTarget.CanElevatePrivileges() {
 						log.Print("Password provided but failed to elevate privileges.")
-						fmt.Println("WARNING: Not able to establish elevated privileges with provided password.")
-						fmt.Println("Continuing with regular user privileges. Some data will not be collected.")
+						fmt.Fprintln(messages, "WARNING: Not able to establish elevated privileges with provided password.")
+						fmt.Fprintln(messages, "Continuing with regular user privileges. Some data will not be collected.")
 						localTarget.SetSudo("")
 					}
 				}
 			}
 			targets = append(targets, localTarget)
 		} else {
-			targets = append(targets, target.NewRemoteTarget(app.args.ipAddress, app.args.ipAddress, fmt.Sprintf("%d", app.args.port), app.args.user, app.args.key, "", "", ""))
+			// the host may contain ranges, e.g., node[01-48], one target per host
+			var hosts []string
+			if hosts, err = expandHostRange(app.args.ipAddress); err != nil {
+				err = fmt.Errorf("-ip %s : %v", app.args.ipAddress, err)
+				return
+			}
+			for _, host := range hosts {
+				var remoteTarget *target.RemoteTarget
+				var windowsTarget *target.WindowsTarget
+				if app.args.targetOS == targetOSWindows {
+					windowsTarget = target.NewWindowsTarget(host, host, fmt.Sprintf("%d", app.args.port), app.args.user, app.args.key, "", "")
+					remoteTarget = &windowsTarget.RemoteTarget
+				} else {
+					remoteTarget = target.NewRemoteTarget(host, host, fmt.Sprintf("%d", app.args.port), app.args.user, app.args.key, "", "", "")
+				}
+				remoteTarget.SetInteractiveAuth(app.args.interactiveAuth)
+				remoteTarget.SetControlDir(app.tempDir)
+				err = remoteTarget.SetTransport(app.args.transport)
+				if err != nil {
+					return
+				}
+				err = remoteTarget.SetProxy(app.args.proxy)
+				if err != nil {
+					return
+				}
+				err = remoteTarget.SetJumpHost(app.args.jumpHost)
+				if err != nil {
+					return
+				}
+				err = remoteTarget.SetAuth(app.args.auth)
+				if err != nil {
+					return
+				}
+				remoteTarget.SetFIPS(app.args.fips)
+				remoteTarget.SetCapabilities(app.args.capabilities)
+				if windowsTarget != nil {
+					targets = append(targets, windowsTarget)
+				} else {
+					targets = append(targets, remoteTarget)
+				}
+			}
+			kerberos = app.args.auth == target.AuthGSSAPI
+			remote = true
+		}
+	}
+	if kerberos {
+		if err = target.CheckKerberosTicket(); err != nil {
+			return
+		}
+	}
+	if app.args.fips && remote {
+		if err = target.CheckFIPSMode(); err != nil {
+			err = fmt.Errorf("-fips : %v", err)
+			return
+		}
+	}
+	if app.auditLog != nil {
+		for _, t := range targets {
+			t.SetCommandObserver(app.auditLog.observe)
 		}
 	}
 	return
 }
 
+// authenticateTargets prompts the user to authenticate to each remote target, one at a
+// time, before collection starts so that prompts aren't interleaved with progress output.
+// Targets that fail authentication are dropped from the returned list.
+func (app *App) authenticateTargets(targets []target.Target) (authenticated []target.Target, err error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		err = fmt.Errorf("-interactive_auth requires input from a terminal")
+		return
+	}
+	for _, t := range targets {
+		remoteTarget, ok := t.(interface{ Authenticate() error }) // remote and Windows targets
+		if !ok {
+			authenticated = append(authenticated, t)
+			continue
+		}
+		fmt.Fprintf(messages, "Authenticating to %s\n", t.GetName())
+		err = remoteTarget.Authenticate()
+		if err != nil {
+			log.Printf("failed to authenticate to %s: %v", t.GetName(), err)
+			fmt.Fprintf(messages, "WARNING: failed to authenticate to %s, skipping target\n", t.GetName())
+			err = nil
+			continue
+		}
+		authenticated = append(authenticated, t)
+	}
+	return
+}
+
+// closeTargetConnections stops the SSH master connections to the targets, which are
+// shared by the commands and file transfers to each target, e.g., opened by
+// authenticateTargets
+func closeTargetConnections(targets []target.Target) {
+	for _, t := range targets {
+		if remoteTarget, ok := t.(interface{ CloseConnection() error }); ok {
+			err := remoteTarget.CloseConnection()
+			if err != nil {
+				log.Printf("failed to close connection to %s: %v", t.GetName(), err)
+			}
+		}
+	}
+}
+
 // go routine
 func doCollection(collection *Collection, ch chan *Collection, statusUpdate progress.MultiSpinnerUpdateFunc) {
 	if statusUpdate != nil {
 		statusUpdate(collection.target.GetName(), "collecting data")
 	}
-	err := collection.Collect()
+	collect := collection.Collect
+	if collection.cmdLineArgs.detach {
+		collect = collection.CollectDetached
+	}
+	err := collection.collectWithRetries(collect, statusUpdate)
 	if err != nil {
 		log.Printf("Error: %v", err)
+		collection.err = err
 		if statusUpdate != nil {
 			statusUpdate(collection.target.GetName(), "error collecting data")
 		}
 	} else {
 		if statusUpdate != nil {
-			statusUpdate(collection.target.GetName(), "finished collecting data")
+			if collection.cmdLineArgs.detach {
+				statusUpdate(collection.target.GetName(), "detached collection started")
+			} else if len(collection.notCollected) > 0 {
+				statusUpdate(collection.target.GetName(), fmt.Sprintf("finished collecting data, %d of %d commands",
+					collection.commandCount-len(collection.notCollected), collection.commandCount))
+			} else {
+				statusUpdate(collection.target.GetName(), "finished collecting data")
+			}
 		}
 	}
 	ch <- collection
 }
 
-func (app *App) getCollections(targets []target.Target, statusUpdate progress.MultiSpinnerUpdateFunc) (collections []*Collection, err error) {
+// rollingDelay returns how long to wait before starting the collection from the target
+// at the index, so that no more than perHour collections start each hour
+func rollingDelay(index int, perHour int) time.Duration {
+	if perHour <= 0 {
+		return 0
+	}
+	return time.Duration(index) * time.Hour / time.Duration(perHour)
+}
+
+// parallelLimit limits the number of target collections that run at once, there's no
+// limit if it's nil
+type parallelLimit chan struct{}
+
+func newParallelLimit(max int) parallelLimit {
+	if max <= 0 {
+		return nil
+	}
+	return make(parallelLimit, max)
+}
+
+// run calls work when fewer than the limit are running, after calling waiting if it has
+// to wait
+func (l parallelLimit) run(work func(), waiting func()) {
+	if l == nil {
+		work()
+		return
+	}
+	select {
+	case l <- struct{}{}:
+	default:
+		waiting()
+		l <- struct{}{}
+	}
+	defer func() { <-l }()
+	work()
+}
+
+// waitingForLimit returns the function that shows that the target is waiting for a
+// collection to finish
+func waitingForLimit(name string, max int, statusUpdate progress.MultiSpinnerUpdateFunc) func() {
+	return func() {
+		if statusUpdate != nil {
+			statusUpdate(name, fmt.Sprintf("waiting, %d collections are running", max))
+		}
+	}
+}
+
+func (app *App) getCollections(targets []target.Target, multiSpinner *progress.MultiSpinner) (collections []*Collection, err error) {
 	// run collections in parallel
 	ch := make(chan *Collection)
-	for _, target := range targets {
-		collection := newCollection(target, app.args, app.outputDir, app.tempDir)
-		go doCollection(collection, ch, statusUpdate)
+	start := time.Now()
+	limit := newParallelLimit(app.args.maxParallel)
+	for i, target := range targets {
+		args := app.args
+		if targetArgs, ok := app.targetArgs[target.GetName()]; ok {
+			args = targetArgs
+		}
+		collection := newCollection(target, args, app.outputDir, app.tempDir)
+		collection.auditLog = app.auditLog
+		statusUpdate := collectionStatus(collection, multiSpinner)
+		collection.statusUpdate = statusUpdate
+		collection.timings = app.timings
+		waiting := waitingForLimit(target.GetName(), app.args.maxParallel, statusUpdate)
+		collect := func() { doCollection(collection, ch, statusUpdate) }
+		delay := rollingDelay(i, app.args.rolling)
+		if delay == 0 {
+			go limit.run(collect, waiting)
+			continue
+		}
+		if statusUpdate != nil {
+			statusUpdate(target.GetName(), fmt.Sprintf("waiting, starts at %s", start.Add(delay).Format("15:04")))
+		}
+		go func() {
+			time.Sleep(delay)
+			limit.run(collect, waiting)
+		}()
 	}
 	// wait for all collections to complete collecting
 	for range targets {
 		collection := <-ch
 		collections = append(collections, collection)
+		if app.metrics != nil {
+			app.metrics.collectionFinished(collection.ok)
+		}
 	}
 	return
 }
 
-func (app *App) getReports(collections []*Collection, statusUpdate progress.MultiSpinnerUpdateFunc) (reportFilePaths []string, err error) {
+func (app *App) getReports(collections []*Collection, multiSpinner *progress.MultiSpinner) (reportFilePaths []string, err error) {
 	var okCollections = make([]*Collection, 0)
 	for _, collection := range collections {
 		if collection.ok {
 			okCollections = append(okCollections, collection)
-			if statusUpdate != nil {
-				statusUpdate(collection.target.GetName(), "creating report(s)")
-			}
+			multiSpinner.Status(collection.target.GetName(), "creating report(s)")
 		}
 	}
 	if len(okCollections) == 0 {
@@ -172,14 +418,31 @@ func (app *App) getReports(collections []*Collection, statusUpdate progress.Mult
 	for _, collection := range okCollections {
 		collectionFilePaths = append(collectionFilePaths, collection.outputFilePath)
 	}
-	cmd := exec.Command(filepath.Join(app.tempDir, "reporter"), "-input", strings.Join(collectionFilePaths, ","), "-output", app.outputDir, "-format", app.args.format)
+	reporterArgs := []string{"-input", strings.Join(collectionFilePaths, ","), "-output", app.outputDir, "-format", app.args.format}
+	if app.args.highlight != "" {
+		reporterArgs = append(reporterArgs, "-highlight", app.args.highlight)
+	}
+	if app.args.microcode != "" {
+		reporterArgs = append(reporterArgs, "-microcode", app.args.microcode)
+	}
+	if app.args.instanceTypes != "" {
+		reporterArgs = append(reporterArgs, "-instance_types", app.args.instanceTypes)
+	}
+	if app.args.healthWeights != "" {
+		reporterArgs = append(reporterArgs, "-health_weights", app.args.healthWeights)
+	}
+	if app.args.reference != "" {
+		reporterArgs = append(reporterArgs, "-reference", app.args.reference, "-reference_threshold", strconv.FormatFloat(app.args.refThreshold, 'f', -1, 64))
+	}
+	if app.args.remediation {
+		reporterArgs = append(reporterArgs, "-remediation")
+	}
+	cmd := exec.Command(filepath.Join(app.tempDir, "reporter"), reporterArgs...)
 	log.Printf("run: %s", strings.Join(cmd.Args, " "))
 	stdout, _, _, err := target.RunLocalCommand(cmd)
 	if err != nil {
 		for _, collection := range collections {
-			if statusUpdate != nil {
-				statusUpdate(collection.target.GetName(), "error creating report(s)")
-			}
+			multiSpinner.StatusWithError(collection.target.GetName(), "error creating report(s)", err)
 		}
 		return
 	}
@@ -187,9 +450,7 @@ func (app *App) getReports(collections []*Collection, statusUpdate progress.Mult
 	reportFilePaths = reportFilePaths[:len(reportFilePaths)-1]
 	for _, collection := range collections {
 		if collection.ok {
-			if statusUpdate != nil {
-				statusUpdate(collection.target.GetName(), "finished creating report(s)")
-			}
+			multiSpinner.Status(collection.target.GetName(), "finished creating report(s)")
 		}
 	}
 	return
@@ -225,9 +486,17 @@ func archiveOutputDir(outputDir string, collections []*Collection, reportFilePat
 		filesToArchive = append(filesToArchive, hostname+"_megadata_collector.log")
 		filesToArchive = append(filesToArchive, hostname+"_megadata", "collector.log")
 		filesToArchive = append(filesToArchive, hostname+"_megadata", "collector.pid")
-		filesToArchive = append(filesToArchive, hostname+".raw.json")
+		filesToArchive = append(filesToArchive, hostname+getRawFileExtension(collection.cmdLineArgs.rawFormat))
+		filesToArchive = append(filesToArchive, hostname+"_trigger.txt")
 	}
+	// the reporter's directories, e.g., a host's raw benchmark output, are archived
+	// with their files
+	var dirsToArchive []string
 	for _, reportFilePath := range reportFilePaths {
+		if info, err := os.Stat(reportFilePath); err == nil && info.IsDir() {
+			dirsToArchive = append(dirsToArchive, filepath.Base(reportFilePath))
+			continue
+		}
 		filesToArchive = append(filesToArchive, filepath.Base(reportFilePath))
 	}
 	filesToArchive = append(filesToArchive, "reporter.log")
@@ -236,8 +505,8 @@ func archiveOutputDir(outputDir string, collections []*Collection, reportFilePat
 			return err
 		}
 		if !d.IsDir() && filepath.Base(path) != filepath.Base(tarFilePath) {
-			// Include files in filesToArchive only
-			if slices.Contains(filesToArchive, filepath.Base(path)) {
+			// Include files in filesToArchive and dirsToArchive only
+			if slices.Contains(filesToArchive, filepath.Base(path)) || slices.Contains(dirsToArchive, filepath.Dir(path)) {
 				info, err := d.Info()
 				if err != nil {
 					return err
@@ -269,7 +538,7 @@ func archiveOutputDir(outputDir string, collections []*Collection, reportFilePat
 	return
 }
 
-func cleanupOutputDir(outputDir string, collections []*Collection, reportFilePaths []string) (err error) {
+func cleanupOutputDir(outputDir string, collections []*Collection, reportFilePaths []string, keepRawData bool) (err error) {
 	var filesToRemove []string
 	for _, collection := range collections {
 		hostname := collection.target.GetName()
@@ -280,7 +549,9 @@ func cleanupOutputDir(outputDir string, collections []*Collection, reportFilePat
 		filesToRemove = append(filesToRemove, filepath.Join(outputDir, hostname+"_megadata_collector.log"))
 		filesToRemove = append(filesToRemove, filepath.Join(outputDir, hostname+"_megadata", "collector.log"))
 		filesToRemove = append(filesToRemove, filepath.Join(outputDir, hostname+"_megadata", "collector.pid"))
-		filesToRemove = append(filesToRemove, filepath.Join(outputDir, hostname+".raw.json"))
+		if !keepRawData {
+			filesToRemove = append(filesToRemove, filepath.Join(outputDir, hostname+getRawFileExtension(collection.cmdLineArgs.rawFormat)))
+		}
 	}
 	filesToRemove = append(filesToRemove, filepath.Join(outputDir, "reporter.log"))
 	for _, file := range filesToRemove {
@@ -311,18 +582,113 @@ func (app *App) doWork() (err error) {
 	if len(targets) == 0 {
 		return fmt.Errorf("no targets provided")
 	}
+	if app.args.powerOn {
+		var poweredOn []target.Target
+		targets, poweredOn = app.powerOnTargets(targets)
+		if app.args.restorePower && len(poweredOn) > 0 {
+			defer app.restorePower(poweredOn)
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("failed to power on any target")
+		}
+	}
+	if app.args.interactiveAuth {
+		targets, err = app.authenticateTargets(targets)
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("failed to authenticate to any target")
+		}
+	}
+	defer closeTargetConnections(targets)
+	if err = app.verifySudoPasswords(targets); err != nil {
+		return err
+	}
+	if err = app.previewPrivileges(targets); err != nil {
+		return err
+	}
+	if app.args.daemon {
+		return app.doDaemon(targets)
+	}
+	return app.collectTargets(targets)
+}
+
+// logStatus logs each change of a target's status, which is otherwise only shown on the
+// terminal
+func logStatus(event progress.Event) {
+	log.Printf("%s: %s", event.Label, event.Status)
+}
+
+// getStatusColor colors the target's status red if its collection failed, yellow if it
+// hasn't started, and green if it finished
+func getStatusColor(label string, status string) progress.Color {
+	switch {
+	case strings.HasPrefix(status, "error"):
+		return progress.ColorRed
+	case status == "?" || strings.HasPrefix(status, "waiting"):
+		return progress.ColorYellow
+	case strings.HasPrefix(status, "finished") || strings.HasPrefix(status, "detached"):
+		return progress.ColorGreen
+	}
+	return progress.ColorNone
+}
+
+// newMultiSpinner returns the spinners that show the targets' status, grouped by their
+// group in the targets file
+func (app *App) newMultiSpinner(targets []target.Target) *progress.MultiSpinner {
 	multiSpinner := progress.NewMultiSpinner()
 	for _, t := range targets {
-		multiSpinner.AddSpinner(t.GetName())
+		var group string
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			group = targetArgs.group
+		}
+		multiSpinner.AddSpinnerToGroup(t.GetName(), group)
 	}
-	multiSpinner.Start()
+	multiSpinner.SetStatusColor(getStatusColor)
+	multiSpinner.AddHook(logStatus)
+	if app.progress != nil {
+		multiSpinner.AddHook(app.progress.statusChanged)
+	}
+	if app.metrics != nil {
+		multiSpinner.AddHook(app.metrics.statusChanged)
+	}
+	return multiSpinner
+}
+
+// startMultiSpinner starts drawing the spinners, unless the statuses are written as JSON
+func (app *App) startMultiSpinner(multiSpinner *progress.MultiSpinner) {
+	if app.progress == nil {
+		multiSpinner.Start()
+	}
+}
+
+// collectTargets collects data from the targets and creates the reports
+func (app *App) collectTargets(targets []target.Target) (err error) {
+	multiSpinner := app.newMultiSpinner(targets)
+	app.startMultiSpinner(multiSpinner)
 	defer multiSpinner.Finish()
-	collections, err := app.getCollections(targets, multiSpinner.Status)
+	app.timings = loadCommandTimings()
+	collections, err := app.getCollections(targets, multiSpinner)
 	if err != nil {
 		return err
 	}
+	saveCommandTimings(app.timings)
+	if app.args.detach {
+		multiSpinner.Finish()
+		if app.progress == nil {
+			printDetachedSessions(collections)
+		}
+		return nil
+	}
+	return app.createReports(collections, multiSpinner)
+}
+
+// createReports creates the reports from the collected data, archives the output
+// directory, and lists the reports
+func (app *App) createReports(collections []*Collection, multiSpinner *progress.MultiSpinner) (err error) {
 	var reportFilePaths []string
-	reportFilePaths, err = app.getReports(collections, multiSpinner.Status)
+	reportFilePaths, err = app.getReports(collections, multiSpinner)
 	if err != nil {
 		return err
 	}
@@ -331,12 +697,16 @@ func (app *App) doWork() (err error) {
 		return err
 	}
 	if !app.args.debug {
-		err = cleanupOutputDir(app.outputDir, collections, reportFilePaths)
+		err = cleanupOutputDir(app.outputDir, collections, reportFilePaths, app.args.keepRawData)
 		if err != nil {
 			return err
 		}
 	}
 	multiSpinner.Finish()
+	if app.progress != nil {
+		app.progress.reportsCreated(reportFilePaths)
+		return nil
+	}
 	fmt.Print("Reports:\n")
 	for _, reportFilePath := range reportFilePaths {
 		relativePath, err := filepath.Rel(filepath.Join(app.outputDir, ".."), reportFilePath)
@@ -409,14 +779,34 @@ const (
 )
 
 func mainReturnWithCode() int {
-	// command line
+	return runSubcommand(os.Args[1:])
+}
+
+// runCollect collects data from the targets and creates the reports
+func runCollect(name string, arguments []string) int {
+	return runApp(arguments, (*App).doWork)
+}
+
+// runFetch retrieves the data from detached collections and creates the reports
+func runFetch(name string, arguments []string) int {
+	return runApp(arguments, (*App).doFetch)
+}
+
+// runApp parses the collect arguments, sets up the output directory, logging, and
+// embedded tools, then calls work
+func runApp(arguments []string, work func(app *App) error) int {
 	cmdLineArgs := newCmdLineArgs()
-	err := cmdLineArgs.parse(os.Args[0], os.Args[1:])
+	err := cmdLineArgs.parse(os.Args[0], arguments)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return retError
 	}
-	err = cmdLineArgs.validate()
+	return runAppWithArgs(cmdLineArgs, work)
+}
+
+// runAppWithArgs is runApp for arguments that have already been parsed
+func runAppWithArgs(cmdLineArgs *CmdLineArgs, work func(app *App) error) int {
+	err := cmdLineArgs.validate()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return retError
@@ -466,14 +856,29 @@ func mainReturnWithCode() int {
 	log.SetOutput(logFile)
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
 
-	log.Printf("Starting up %s, version %s, PID %d, PPID %d, arguments: %s",
+	log.Printf("Starting up %s, version %s, FIPS build %t, PID %d, PPID %d, arguments: %s",
 		filepath.Base(os.Args[0]),
 		gVersion,
+		gFIPSBuild,
 		os.Getpid(),
 		os.Getppid(),
 		strings.Join(os.Args, " "),
 	)
-	tempDir, err := os.MkdirTemp(cmdLineArgs.temp, fmt.Sprintf("%s.tmp.", filepath.Base(os.Args[0])))
+	// remove old output directories, if requested
+	if cmdLineArgs.output == "" {
+		removed, err := pruneDatedDirs(filepath.Dir(outputDir), getOutputDirPattern(), cmdLineArgs.keepLast)
+		for _, dir := range removed {
+			log.Printf("removed old output directory: %s", dir)
+		}
+		if err != nil {
+			log.Printf("failed to remove old output directories: %v", err)
+		}
+	}
+	tempDirPrefix := fmt.Sprintf("%s.tmp.", filepath.Base(os.Args[0]))
+	for _, dir := range removeStaleTempDirs(cmdLineArgs.temp, tempDirPrefix, time.Now()) {
+		log.Printf("removed stale temporary directory: %s", dir)
+	}
+	tempDir, err := os.MkdirTemp(cmdLineArgs.temp, tempDirPrefix)
 	if err != nil {
 		log.Printf("Error: %v", err)
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -482,7 +887,31 @@ func mainReturnWithCode() int {
 	if !cmdLineArgs.debug {
 		defer os.RemoveAll(tempDir)
 	}
+	if err = writeTempDirOwner(tempDir); err != nil {
+		log.Printf("failed to write temporary directory owner: %v", err)
+	}
 	app := newApp(cmdLineArgs, outputDir, tempDir)
+	if cmdLineArgs.auditLog != "" {
+		app.auditLog, err = newAuditLog(cmdLineArgs.auditLog)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+		defer app.auditLog.close()
+	}
+	if cmdLineArgs.progress == progressJSON {
+		app.progress, err = newJSONProgress(cmdLineArgs.progressFile)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+		defer app.progress.close()
+		if cmdLineArgs.progressFile == "" {
+			messages = os.Stderr
+		}
+	}
 
 	// write out any executable tools we have in our embedded resources to tempDir
 	err = app.writeExecutableResources()
@@ -502,8 +931,11 @@ func mainReturnWithCode() int {
 		return exitCode
 	}
 	// get to work
-	err = app.doWork()
+	err = work(app)
 	if err != nil {
+		if app.progress != nil {
+			app.progress.failed(err)
+		}
 		log.Printf("Error: %v", err)
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return retError
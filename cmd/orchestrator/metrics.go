@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+)
+
+// In daemon mode, -metrics_address serves /healthz and /metrics so that the svr-info
+// service can be monitored like any other service. /metrics is in the Prometheus text
+// exposition format.
+
+// daemonMetrics counts the daemon's runs and collections
+type daemonMetrics struct {
+	mu                   sync.Mutex
+	started              time.Time
+	runsStarted          int
+	runsSucceeded        int
+	runsFailed           int
+	collectionsSucceeded int
+	collectionsFailed    int
+	runDurationSum       float64 // seconds
+	lastRunDuration      float64 // seconds
+	lastRun              time.Time
+	lastRunOK            bool
+	nextRun              time.Time
+	queueDepth           int                     // targets in the current run that haven't finished collecting
+	targets              map[string]targetStatus // the last status of each target's collection, by target name
+}
+
+// targetStatus is the last status of a target's collection, e.g., "collecting data"
+type targetStatus struct {
+	Status string `json:"status"`
+	Since  string `json:"since"`
+}
+
+func newDaemonMetrics() *daemonMetrics {
+	return &daemonMetrics{started: time.Now(), targets: make(map[string]targetStatus)}
+}
+
+// statusChanged records the target's status, it's a hook for the run's spinners
+func (m *daemonMetrics) statusChanged(event progress.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets[event.Label] = targetStatus{Status: event.Status, Since: event.Time.Format(time.RFC3339)}
+}
+
+// scheduled records the time of the next run
+func (m *daemonMetrics) scheduled(next time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextRun = next
+}
+
+// runStarted records the start of a run that will collect from the targets
+func (m *daemonMetrics) runStarted(targets int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runsStarted++
+	m.queueDepth = targets
+}
+
+// collectionFinished records the end of a target's collection
+func (m *daemonMetrics) collectionFinished(ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ok {
+		m.collectionsSucceeded++
+	} else {
+		m.collectionsFailed++
+	}
+	if m.queueDepth > 0 {
+		m.queueDepth--
+	}
+}
+
+// runFinished records the end of a run
+func (m *daemonMetrics) runFinished(start time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastRun = start
+	m.lastRunDuration = time.Since(start).Seconds()
+	m.runDurationSum += m.lastRunDuration
+	m.lastRunOK = err == nil
+	if m.lastRunOK {
+		m.runsSucceeded++
+	} else {
+		m.runsFailed++
+	}
+	m.queueDepth = 0
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition format
+func (m *daemonMetrics) writePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	metric := func(name string, metricType string, help string, value interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, metricType, name, value)
+	}
+	timestamp := func(t time.Time) int64 {
+		if t.IsZero() {
+			return 0
+		}
+		return t.Unix()
+	}
+	fmt.Fprintf(w, "# HELP svr_info_build_info The svr-info version.\n# TYPE svr_info_build_info gauge\nsvr_info_build_info{version=%q} 1\n", gVersion)
+	metric("svr_info_start_timestamp_seconds", "gauge", "When the daemon started, in seconds since the epoch.", timestamp(m.started))
+	metric("svr_info_runs_started_total", "counter", "Scheduled runs started.", m.runsStarted)
+	metric("svr_info_runs_succeeded_total", "counter", "Scheduled runs that created reports.", m.runsSucceeded)
+	metric("svr_info_runs_failed_total", "counter", "Scheduled runs that failed.", m.runsFailed)
+	fmt.Fprintf(w, "# HELP svr_info_collections_total Target collections finished, by result.\n# TYPE svr_info_collections_total counter\n")
+	fmt.Fprintf(w, "svr_info_collections_total{result=\"succeeded\"} %d\nsvr_info_collections_total{result=\"failed\"} %d\n", m.collectionsSucceeded, m.collectionsFailed)
+	fmt.Fprintf(w, "# HELP svr_info_run_duration_seconds Duration of the finished runs.\n# TYPE svr_info_run_duration_seconds summary\n")
+	fmt.Fprintf(w, "svr_info_run_duration_seconds_sum %.3f\nsvr_info_run_duration_seconds_count %d\n", m.runDurationSum, m.runsSucceeded+m.runsFailed)
+	metric("svr_info_last_run_duration_seconds", "gauge", "Duration of the last finished run.", fmt.Sprintf("%.3f", m.lastRunDuration))
+	metric("svr_info_last_run_timestamp_seconds", "gauge", "When the last finished run started, in seconds since the epoch.", timestamp(m.lastRun))
+	lastRunSuccess := 0
+	if m.lastRunOK {
+		lastRunSuccess = 1
+	}
+	metric("svr_info_last_run_success", "gauge", "1 if the last finished run created reports, otherwise 0.", lastRunSuccess)
+	metric("svr_info_next_run_timestamp_seconds", "gauge", "When the next run is scheduled, in seconds since the epoch.", timestamp(m.nextRun))
+	metric("svr_info_queue_depth", "gauge", "Targets in the current run that haven't finished collecting.", m.queueDepth)
+}
+
+// health is the /healthz response
+type health struct {
+	Status        string                  `json:"status"`
+	Version       string                  `json:"version"`
+	Uptime        int64                   `json:"uptime_seconds"`
+	LastRun       string                  `json:"last_run,omitempty"`
+	LastRunStatus string                  `json:"last_run_status,omitempty"`
+	NextRun       string                  `json:"next_run,omitempty"`
+	QueueDepth    int                     `json:"queue_depth"`
+	Targets       map[string]targetStatus `json:"targets,omitempty"`
+}
+
+func (m *daemonMetrics) getHealth() (h health) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h = health{
+		Status:     "ok",
+		Version:    gVersion,
+		Uptime:     int64(time.Since(m.started).Seconds()),
+		QueueDepth: m.queueDepth,
+	}
+	if len(m.targets) > 0 {
+		h.Targets = make(map[string]targetStatus, len(m.targets))
+		for name, status := range m.targets {
+			h.Targets[name] = status
+		}
+	}
+	if !m.lastRun.IsZero() {
+		h.LastRun = m.lastRun.Format(time.RFC3339)
+		h.LastRunStatus = "failed"
+		if m.lastRunOK {
+			h.LastRunStatus = "succeeded"
+		}
+	}
+	if !m.nextRun.IsZero() {
+		h.NextRun = m.nextRun.Format(time.RFC3339)
+	}
+	return
+}
+
+// handler serves /healthz and /metrics, and the report history at /grafana if history
+// isn't nil
+func (m *daemonMetrics) handler(history *reportHistory) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.getHealth()); err != nil {
+			log.Printf("Error: %v", err)
+		}
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.writePrometheus(w)
+	})
+	if history != nil {
+		history.register(mux)
+	}
+	return mux
+}
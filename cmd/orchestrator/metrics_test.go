@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+)
+
+func TestDaemonMetrics(t *testing.T) {
+	m := newDaemonMetrics()
+	next := time.Date(2023, 6, 2, 2, 0, 0, 0, time.UTC)
+	m.scheduled(next)
+	m.runStarted(3)
+	m.collectionFinished(true)
+	m.statusChanged(progress.Event{Label: "host1", Status: "collecting data", Time: next})
+	m.statusChanged(progress.Event{Label: "host1", Status: "finished collecting data", Time: next.Add(time.Minute)})
+	server := httptest.NewServer(m.handler(nil))
+	defer server.Close()
+	response, err := server.Client().Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h health
+	err = json.NewDecoder(response.Body).Decode(&h)
+	response.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 || h.Status != "ok" || h.QueueDepth != 2 || h.NextRun != next.Format(time.RFC3339) || h.LastRun != "" {
+		t.Errorf("unexpected health: %d %+v", response.StatusCode, h)
+	}
+	if status := h.Targets["host1"]; len(h.Targets) != 1 || status.Status != "finished collecting data" || status.Since != next.Add(time.Minute).Format(time.RFC3339) {
+		t.Errorf("unexpected target status: %+v", h.Targets)
+	}
+	m.collectionFinished(false)
+	m.collectionFinished(true)
+	m.runFinished(time.Now().Add(-90*time.Second), fmt.Errorf("no data collected"))
+	response, err = server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"svr_info_runs_started_total 1",
+		"svr_info_runs_failed_total 1",
+		"svr_info_runs_succeeded_total 0",
+		`svr_info_collections_total{result="succeeded"} 2`,
+		`svr_info_collections_total{result="failed"} 1`,
+		"svr_info_run_duration_seconds_count 1",
+		"svr_info_last_run_success 0",
+		fmt.Sprintf("svr_info_next_run_timestamp_seconds %d", next.Unix()),
+		"svr_info_queue_depth 0",
+		"# TYPE svr_info_runs_started_total counter",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("missing metric: %s", line)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"gopkg.in/yaml.v2"
+)
+
+// When the collector fails part way through, e.g., the connection to the target is lost
+// or the collector is killed, the results of the commands that completed are kept if
+// there are at least partialCollectionMinimum of them. The commands that weren't
+// collected are listed in a result labeled notCollectedLabel, one per line with the
+// reason, so that the reports can mark the tables that depend on them as not collected.
+// When the collector is stopped at the -timeout deadline, the results are kept however
+// few commands completed.
+
+// notCollectedLabel labels the list of the commands that weren't collected, see
+// cmd/reporter/source.go
+const notCollectedLabel = "not collected"
+
+// partialCollectionMinimum is the fraction of the commands that must be collected for
+// the collection to be kept when the collector fails
+const partialCollectionMinimum = 0.8
+
+// getPartialOutputFile retrieves the collector's output after the collector failed with
+// collectorErr and writes the results of the commands that completed, with the list of
+// those that didn't, to the collection's output file. An error is returned if fewer than
+// partialCollectionMinimum of the commands completed.
+func (c *Collection) getPartialOutputFile(workingDirectory string, commandFilePath string, collectorErr error) (outputFilePath string, err error) {
+	content, err := os.ReadFile(commandFilePath)
+	if err != nil {
+		return
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(content, &cf); err != nil {
+		return
+	}
+	stdoutPath := filepath.Join(c.tempDir, c.target.GetName()+"_collector.stdout")
+	if err = c.target.PullFile(filepath.Join(workingDirectory, "collector.stdout"), stdoutPath); err != nil {
+		return
+	}
+	defer os.Remove(stdoutPath)
+	_, results, err := readRawResults(stdoutPath, c.cmdLineArgs.rawFormat)
+	if err != nil {
+		return
+	}
+	collected := make(map[string]bool)
+	for _, result := range results {
+		collected[result["label"]] = true
+	}
+	var missing []string
+	c.commandCount = 0
+	for _, cmd := range cf.Commands {
+		if !cmd.Run {
+			continue
+		}
+		c.commandCount++
+		if !collected[cmd.Label] {
+			missing = append(missing, cmd.Label)
+		}
+	}
+	if errors.Is(collectorErr, errCollectionDeadline) && len(missing) == c.commandCount {
+		err = fmt.Errorf("no commands were collected before the deadline")
+		return
+	}
+	if c.commandCount == 0 || (!errors.Is(collectorErr, errCollectionDeadline) && float64(c.commandCount-len(missing)) < partialCollectionMinimum*float64(c.commandCount)) {
+		err = fmt.Errorf("collected %d of %d commands, at least %.0f%% are required for a report", c.commandCount-len(missing), c.commandCount, partialCollectionMinimum*100)
+		return
+	}
+	c.notCollected = missing
+	reason := "collection interrupted: " + strings.ReplaceAll(collectorErr.Error(), "\n", " ")
+	var lines []string
+	for _, label := range missing {
+		lines = append(lines, label+"\t"+reason)
+	}
+	results = append(results, map[string]string{
+		"label":      notCollectedLabel,
+		"command":    "",
+		"superuser":  "false",
+		"stdout":     strings.Join(lines, "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	})
+	log.Printf("keeping partial collection for %s, %d of %d commands weren't collected: %s",
+		c.target.GetName(), len(missing), c.commandCount, strings.Join(missing, ", "))
+	outputFilePath = filepath.Join(c.outputDir, c.target.GetName()+getRawFileExtension(c.cmdLineArgs.rawFormat))
+	if err = writeRawResults(outputFilePath, c.cmdLineArgs.rawFormat, cf.Args.Name, results); err != nil {
+		return
+	}
+	err = c.addCollectionResults(outputFilePath)
+	return
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/rawdata"
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestReadRawResults(t *testing.T) {
+	dir := t.TempDir()
+	results := []map[string]string{
+		{"label": "lscpu", "stdout": "Model name: Xeon"},
+		{"label": "dmidecode", "stdout": "BIOS"},
+	}
+	for _, format := range rawFormats {
+		path := filepath.Join(dir, "host"+getRawFileExtension(format))
+		if err := writeRawResults(path, format, "host", results); err != nil {
+			t.Fatal(err)
+		}
+		hostname, read, err := readRawResults(path, format)
+		if err != nil || hostname != "host" || len(read) != 2 || read[1]["stdout"] != "BIOS" {
+			t.Errorf("%s: unexpected results: %s, %v, %v", format, hostname, read, err)
+		}
+	}
+	// the collector was interrupted while writing the second result
+	path := filepath.Join(dir, "truncated.raw.json")
+	if err := os.WriteFile(path, []byte(`{"host": [{"label": "lscpu", "stdout": "Model name: Xeon"}, {"label": "dmi`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, read, err := readRawResults(path, rawFormatJSON)
+	if err != nil || len(read) != 1 || read[0]["label"] != "lscpu" {
+		t.Errorf("unexpected truncated results: %v, %v", read, err)
+	}
+	// nothing was written
+	if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, read, err = readRawResults(path, rawFormatJSON); err != nil || len(read) != 0 {
+		t.Errorf("unexpected empty results: %v, %v", read, err)
+	}
+}
+
+func TestGetPartialOutputFile(t *testing.T) {
+	workingDir := t.TempDir()
+	commandFile := "arguments:\n  name: hostA\ncommands:\n"
+	for i := 0; i < 5; i++ {
+		commandFile += fmt.Sprintf("  - label: cmd%d\n    command: echo %d\n    run: true\n", i, i)
+	}
+	commandFile += "  - label: skipped\n    command: echo\n    run: false\n"
+	commandFilePath := filepath.Join(workingDir, "hostA_reports_collector.yaml")
+	if err := os.WriteFile(commandFilePath, []byte(commandFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeStdout := func(collected int) {
+		var results []string
+		for i := 0; i < collected; i++ {
+			results = append(results, fmt.Sprintf(`{"label": "cmd%d", "stdout": "%d"}`, i, i))
+		}
+		// truncated, as if the collector was killed
+		stdout := `{"hostA": [` + strings.Join(results, ", ") + `, {"label": "cmd`
+		if err := os.WriteFile(filepath.Join(workingDir, "collector.stdout"), []byte(stdout), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collectorErr := errors.New("signal: killed")
+	c := newCollection(target.NewLocalTarget("hostA", ""), &CmdLineArgs{rawFormat: rawFormatJSON}, t.TempDir(), t.TempDir())
+	writeStdout(4)
+	outputFilePath, err := c.getPartialOutputFile(workingDir, commandFilePath, collectorErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.commandCount != 5 || len(c.notCollected) != 1 || c.notCollected[0] != "cmd4" {
+		t.Errorf("unexpected commands: %d, %v", c.commandCount, c.notCollected)
+	}
+	content, err := os.ReadFile(outputFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string][]map[string]string
+	if err = json.Unmarshal(content, &data); err != nil {
+		t.Fatal(err)
+	}
+	outputs := make(map[string]string)
+	for _, result := range data["hostA"] {
+		outputs[result["label"]] = result["stdout"]
+	}
+	if outputs["cmd3"] != "3" || outputs[notCollectedLabel] != "cmd4\tcollection interrupted: signal: killed" {
+		t.Errorf("unexpected output: %v", outputs)
+	}
+	if _, ok := outputs[componentsLabel]; !ok {
+		t.Error("components not added")
+	}
+	if outputs[rawdata.SchemaVersionLabel] != fmt.Sprint(rawdata.SchemaVersion) {
+		t.Error("schema version not added")
+	}
+	// fewer than partialCollectionMinimum of the commands
+	writeStdout(3)
+	if _, err = c.getPartialOutputFile(workingDir, commandFilePath, collectorErr); err == nil || !strings.Contains(err.Error(), "collected 3 of 5 commands") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGetPartialOutputFileDeadline(t *testing.T) {
+	workingDir := t.TempDir()
+	commandFile := "arguments:\n  name: hostA\ncommands:\n"
+	for i := 0; i < 5; i++ {
+		commandFile += fmt.Sprintf("  - label: cmd%d\n    command: echo %d\n    run: true\n", i, i)
+	}
+	commandFilePath := filepath.Join(workingDir, "hostA_reports_collector.yaml")
+	if err := os.WriteFile(commandFilePath, []byte(commandFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout := `{"hostA": [{"label": "cmd0", "stdout": "0"}, {"label": "cmd`
+	if err := os.WriteFile(filepath.Join(workingDir, "collector.stdout"), []byte(stdout), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// the data collected before the deadline is kept, however few commands completed
+	collectorErr := fmt.Errorf("%w, -timeout 60 seconds", errCollectionDeadline)
+	c := newCollection(target.NewLocalTarget("hostA", ""), &CmdLineArgs{rawFormat: rawFormatJSON}, t.TempDir(), t.TempDir())
+	if _, err := c.getPartialOutputFile(workingDir, commandFilePath, collectorErr); err != nil || len(c.notCollected) != 4 {
+		t.Errorf("unexpected result: %v, %v", c.notCollected, err)
+	}
+	if err := os.WriteFile(filepath.Join(workingDir, "collector.stdout"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.getPartialOutputFile(workingDir, commandFilePath, collectorErr); err == nil {
+		t.Error("expected an error when no commands were collected")
+	}
+}
+
+func TestRunCollectorDeadline(t *testing.T) {
+	dir := t.TempDir()
+	collector := filepath.Join(dir, "collector")
+	if err := os.WriteFile(collector, []byte("#!/bin/bash\necho '{\"hostA\": ['\nsleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := newCollection(target.NewLocalTarget("hostA", ""), &CmdLineArgs{timeout: 1}, t.TempDir(), t.TempDir())
+	start := time.Now()
+	_, _, err := c.runCollector(collector, filepath.Join(dir, "collector.yaml"), dir)
+	if !errors.Is(err, errCollectionDeadline) || !c.deadline {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("collector wasn't stopped at the deadline: %v", elapsed)
+	}
+}
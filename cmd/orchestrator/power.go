@@ -0,0 +1,231 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+// With -power_on, the remote targets that can't be reached, e.g., the spares in a pool
+// that are kept powered off, are powered on before collection, through their BMC with
+// ipmitool or with a Wake-on-LAN magic packet, per their settings in the targets file.
+// Collection starts when SSH is available on them. With -restore_power, the targets that
+// were powered on are powered off again after collection.
+
+// powerOnPollInterval is the time between attempts to connect to a target that was
+// powered on
+var powerOnPollInterval = 10 * time.Second
+
+// wolAddress is where Wake-on-LAN magic packets are sent, the broadcast address and the
+// discard port
+var wolAddress = "255.255.255.255:9"
+
+// runIPMITool runs ipmitool with the arguments against the BMC, and returns its output,
+// the password is passed in the environment so that it isn't on the command line
+var runIPMITool = func(host string, user string, password string, args ...string) (string, error) {
+	cmd := exec.Command("ipmitool", append([]string{"-I", "lanplus", "-H", host, "-U", user, "-E"}, args...)...)
+	cmd.Env = append(os.Environ(), "IPMI_PASSWORD="+password)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("ipmitool %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(stdout), nil
+}
+
+// canConnect returns true if SSH is available on the target
+var canConnect = func(t *target.RemoteTarget) bool {
+	return t.CanConnect()
+}
+
+// hasBMC returns true if the target's BMC is set in the targets file
+func (args *CmdLineArgs) hasBMC() bool {
+	return args.bmcHost != "" && args.bmcUser != ""
+}
+
+// newMagicPacket returns the Wake-on-LAN magic packet of the MAC address, six 0xFF bytes
+// followed by the MAC address sixteen times
+func newMagicPacket(mac string) (packet []byte, err error) {
+	hwAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return
+	}
+	if len(hwAddr) != 6 {
+		err = fmt.Errorf("not a 48-bit MAC address: %s", mac)
+		return
+	}
+	packet = bytes.Repeat([]byte{0xFF}, 6)
+	for i := 0; i < 16; i++ {
+		packet = append(packet, hwAddr...)
+	}
+	return
+}
+
+// sendMagicPacket broadcasts the Wake-on-LAN magic packet of the MAC address
+func sendMagicPacket(mac string) (err error) {
+	packet, err := newMagicPacket(mac)
+	if err != nil {
+		return
+	}
+	conn, err := net.Dial("udp", wolAddress)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	_, err = conn.Write(packet)
+	return
+}
+
+// isBMCPoweredOff returns true if the BMC reports that the chassis is powered off
+func isBMCPoweredOff(args *CmdLineArgs) (off bool, err error) {
+	output, err := runIPMITool(args.bmcHost, args.bmcUser, args.bmcPassword, "chassis", "power", "status")
+	if err != nil {
+		return
+	}
+	off = strings.Contains(strings.ToLower(output), "is off")
+	return
+}
+
+// powerOn powers on the target, if it can't be reached, and waits for SSH. It returns
+// true if the target was powered on.
+func (app *App) powerOn(t *target.RemoteTarget, args *CmdLineArgs) (poweredOn bool, err error) {
+	if canConnect(t) {
+		return
+	}
+	if args.hasBMC() {
+		var off bool
+		if off, err = isBMCPoweredOff(args); err != nil {
+			return
+		}
+		if !off {
+			err = fmt.Errorf("target can't be reached but its BMC reports that it's powered on")
+			return
+		}
+		log.Printf("powering on %s through its BMC, %s", t.GetName(), args.bmcHost)
+		if _, err = runIPMITool(args.bmcHost, args.bmcUser, args.bmcPassword, "chassis", "power", "on"); err != nil {
+			return
+		}
+	} else {
+		log.Printf("waking %s with a Wake-on-LAN magic packet to %s", t.GetName(), args.wolMAC)
+		if err = sendMagicPacket(args.wolMAC); err != nil {
+			return
+		}
+	}
+	poweredOn = true
+	deadline := time.Now().Add(time.Duration(app.args.powerOnTimeout) * time.Second)
+	for !canConnect(t) {
+		if time.Now().After(deadline) {
+			err = fmt.Errorf("SSH not available after %d seconds", app.args.powerOnTimeout)
+			return
+		}
+		time.Sleep(powerOnPollInterval)
+	}
+	log.Printf("%s is up after being powered on", t.GetName())
+	return
+}
+
+// powerOnTargets powers on the remote targets that can't be reached and have a BMC or a
+// MAC address for Wake-on-LAN in the targets file, and waits for SSH. The targets that
+// can't be powered on, or don't come up, are skipped. The targets that were powered on
+// are returned separately, to restore their power state after collection.
+func (app *App) powerOnTargets(targets []target.Target) (available []target.Target, poweredOn []target.Target) {
+	results := make([]struct {
+		poweredOn bool
+		err       error
+	}, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		remoteTarget, ok := t.(*target.RemoteTarget)
+		if windowsTarget, isWindows := t.(*target.WindowsTarget); isWindows {
+			remoteTarget, ok = &windowsTarget.RemoteTarget, true
+		}
+		if !ok {
+			continue
+		}
+		args := app.args
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			args = targetArgs
+		}
+		if !args.hasBMC() && args.wolMAC == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, t *target.RemoteTarget, args *CmdLineArgs) {
+			defer wg.Done()
+			results[i].poweredOn, results[i].err = app.powerOn(t, args)
+		}(i, remoteTarget, args)
+	}
+	wg.Wait()
+	for i, t := range targets {
+		if results[i].poweredOn {
+			poweredOn = append(poweredOn, t)
+		}
+		if results[i].err != nil {
+			log.Printf("failed to power on %s: %v", t.GetName(), results[i].err)
+			fmt.Fprintf(messages, "WARNING: failed to power on %s, skipping target: %v\n", t.GetName(), results[i].err)
+			continue
+		}
+		available = append(available, t)
+	}
+	return
+}
+
+// restorePower powers off the targets that were powered on, through their BMC, or by
+// shutting them down if they were woken with Wake-on-LAN
+func (app *App) restorePower(targets []target.Target) {
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		args := app.args
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			args = targetArgs
+		}
+		wg.Add(1)
+		go func(t target.Target, args *CmdLineArgs) {
+			defer wg.Done()
+			var err error
+			if args.hasBMC() {
+				log.Printf("powering off %s through its BMC, %s", t.GetName(), args.bmcHost)
+				_, err = runIPMITool(args.bmcHost, args.bmcUser, args.bmcPassword, "chassis", "power", "soft")
+			} else {
+				log.Printf("shutting down %s", t.GetName())
+				err = shutdownTarget(t)
+			}
+			if err != nil {
+				log.Printf("failed to power off %s: %v", t.GetName(), err)
+				fmt.Fprintf(messages, "WARNING: failed to power off %s: %v\n", t.GetName(), err)
+			}
+		}(t, args)
+	}
+	wg.Wait()
+}
+
+// shutdownTarget shuts down the target, as root or with sudo
+func shutdownTarget(t target.Target) (err error) {
+	sudo := "sudo -n shutdown -h +0"
+	if t.GetSudo() != "" {
+		sudo = `sudo -kS -p "" shutdown -h +0`
+	}
+	cmd := exec.Command(`if [ "$(id -u)" = 0 ]; then shutdown -h +0; else ` + sudo + `; fi`)
+	if t.GetSudo() != "" {
+		// the password is sudo's stdin, it isn't on the command line
+		cmd.Stdin = strings.NewReader(t.GetSudo() + "\n")
+	}
+	_, stderr, _, err := t.RunCommandWithTimeout(cmd, 30)
+	if err != nil {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr))
+	}
+	return
+}
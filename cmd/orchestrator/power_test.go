@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestNewMagicPacket(t *testing.T) {
+	packet, err := newMagicPacket("00-1a-2b-3c-4d-5e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packet) != 102 {
+		t.Fatalf("unexpected packet length: %d", len(packet))
+	}
+	if !bytes.Equal(packet[:6], []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("unexpected packet header: %x", packet[:6])
+	}
+	mac := []byte{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}
+	for i := 6; i < len(packet); i += 6 {
+		if !bytes.Equal(packet[i:i+6], mac) {
+			t.Errorf("unexpected MAC address at %d: %x", i, packet[i:i+6])
+		}
+	}
+	if _, err = newMagicPacket("00:00:5e:00:53:01:02:03"); err == nil {
+		t.Error("expected error for 64-bit MAC address")
+	}
+	if _, err = newMagicPacket("not a mac"); err == nil {
+		t.Error("expected error for invalid MAC address")
+	}
+}
+
+func TestPowerOptions(t *testing.T) {
+	tf := newTargetsFile("targets")
+	targets, err := tf.parseContent([]byte("192.168.1.1::elaine::::bmc_host=10.0.0.1:bmc_user=admin:bmc_password=secret\n192.168.1.2::elaine::::wol_mac=00-1a-2b-3c-4d-5e\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.bmcHost != "10.0.0.1" || args.bmcUser != "admin" || args.bmcPassword != "secret" || !args.hasBMC() {
+		t.Errorf("unexpected BMC settings: %s %s %s", args.bmcHost, args.bmcUser, args.bmcPassword)
+	}
+	if args, err = targets[1].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if args.wolMAC != "00-1a-2b-3c-4d-5e" || args.hasBMC() {
+		t.Errorf("unexpected Wake-on-LAN settings: %s", args.wolMAC)
+	}
+	if _, err = tf.parseContent([]byte("192.168.1.2::elaine::::wol_mac=00-1a-2b\n")); err == nil {
+		t.Error("expected error for invalid wol_mac")
+	}
+	targets, err = tf.parseContent([]byte("192.168.1.1::elaine::::bmc_host=10.0.0.1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2}); err == nil {
+		t.Error("expected error for bmc_host without bmc_user")
+	}
+}
+
+func TestPowerOnTargets(t *testing.T) {
+	defer func(interval time.Duration) { powerOnPollInterval = interval }(powerOnPollInterval)
+	defer func(f func(*target.RemoteTarget) bool) { canConnect = f }(canConnect)
+	defer func(f func(string, string, string, ...string) (string, error)) { runIPMITool = f }(runIPMITool)
+	powerOnPollInterval = time.Millisecond
+	var mutex sync.Mutex
+	powered := map[string]bool{"up": true}
+	canConnect = func(t *target.RemoteTarget) bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return powered[t.GetName()]
+	}
+	var ipmiCommands []string
+	runIPMITool = func(host string, user string, password string, args ...string) (string, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		ipmiCommands = append(ipmiCommands, host+" "+strings.Join(args, " "))
+		switch {
+		case host == "bmc-broken":
+			return "", errors.New("unreachable")
+		case strings.Join(args, " ") == "chassis power status":
+			return "Chassis Power is off\n", nil
+		case strings.Join(args, " ") == "chassis power on":
+			powered["off"] = true
+		}
+		return "", nil
+	}
+	app := newApp(&CmdLineArgs{powerOnTimeout: 1}, t.TempDir(), t.TempDir())
+	var targets []target.Target
+	for _, name := range []string{"up", "off", "broken", "nobmc"} {
+		targets = append(targets, target.NewRemoteTarget(name, name, "22", "user", "", "", "", ""))
+	}
+	app.targetArgs["up"] = &CmdLineArgs{powerOnTimeout: 1, bmcHost: "bmc-up", bmcUser: "admin"}
+	app.targetArgs["off"] = &CmdLineArgs{powerOnTimeout: 1, bmcHost: "bmc-off", bmcUser: "admin"}
+	app.targetArgs["broken"] = &CmdLineArgs{powerOnTimeout: 1, bmcHost: "bmc-broken", bmcUser: "admin"}
+	available, poweredOn := app.powerOnTargets(targets)
+	var names []string
+	for _, t := range available {
+		names = append(names, t.GetName())
+	}
+	if strings.Join(names, ",") != "up,off,nobmc" {
+		t.Errorf("unexpected available targets: %v", names)
+	}
+	if len(poweredOn) != 1 || poweredOn[0].GetName() != "off" {
+		t.Errorf("unexpected powered on targets: %v", poweredOn)
+	}
+	ipmiCommands = nil
+	app.restorePower(poweredOn)
+	if len(ipmiCommands) != 1 || ipmiCommands[0] != "bmc-off chassis power soft" {
+		t.Errorf("unexpected ipmitool commands: %v", ipmiCommands)
+	}
+}
+
+// recordingTarget is a target that records the command instead of running it
+type recordingTarget struct {
+	target.Target
+	sudo  string
+	cmd   *exec.Cmd
+	stdin string
+}
+
+func (t *recordingTarget) GetSudo() string {
+	return t.sudo
+}
+
+func (t *recordingTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (string, string, int, error) {
+	t.cmd = cmd
+	if cmd.Stdin != nil {
+		stdin, _ := io.ReadAll(cmd.Stdin)
+		t.stdin = string(stdin)
+	}
+	return "", "", 0, nil
+}
+
+func TestShutdownTarget(t *testing.T) {
+	recorder := &recordingTarget{sudo: "it's $ecret"}
+	if err := shutdownTarget(recorder); err != nil {
+		t.Fatal(err)
+	}
+	// the password is sudo's stdin, not on the command line
+	command := strings.Join(recorder.cmd.Args, " ")
+	if strings.Contains(command, "ecret") || !strings.Contains(command, `sudo -kS -p "" shutdown -h +0`) {
+		t.Errorf("unexpected command: %s", command)
+	}
+	if recorder.stdin != "it's $ecret\n" {
+		t.Errorf("unexpected stdin: %q", recorder.stdin)
+	}
+	recorder = &recordingTarget{}
+	if err := shutdownTarget(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if command = strings.Join(recorder.cmd.Args, " "); !strings.Contains(command, "sudo -n shutdown -h +0") || recorder.cmd.Stdin != nil {
+		t.Errorf("unexpected command: %s", command)
+	}
+}
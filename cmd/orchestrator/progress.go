@@ -0,0 +1,192 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+	"github.com/intel/svr-info/internal/target"
+	"golang.org/x/term"
+)
+
+// While the collector runs, its progress log, collectorProgressFile, is read from the
+// target every collectorProgressInterval to show the number of commands completed and
+// an estimate of the time remaining in the target's status. The estimate is from the
+// durations of the commands in previous collections, which are kept in the user's cache
+// directory.
+
+// collectorProgressFile is the name of the collector's progress log on the target
+const collectorProgressFile = "collector.progress"
+
+// collectorProgressInterval is how often the collector's progress log is read
+const collectorProgressInterval = 5 * time.Second
+
+// collectorProgress is the state of a collection, from the collector's progress log
+type collectorProgress struct {
+	serial    []string                 // the commands that run one at a time, in order
+	parallel  []string                 // the commands that run at once, after the serial commands
+	durations map[string]time.Duration // of the commands that completed, by label
+}
+
+// parseCollectorProgress parses the collector's progress log, see cmd/collector/progress.go
+func parseCollectorProgress(content string) (p collectorProgress) {
+	p.durations = make(map[string]time.Duration)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		switch fields[0] {
+		case "run":
+			if fields[2] == "parallel" {
+				p.parallel = append(p.parallel, fields[1])
+			} else {
+				p.serial = append(p.serial, fields[1])
+			}
+		case "done":
+			seconds, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				continue
+			}
+			p.durations[fields[1]] = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return
+}
+
+// getPhase returns the collection's progress with the time remaining estimated from the
+// timings, if there are any
+func (p collectorProgress) getPhase(timings *progress.Timings) (phase progress.Phase) {
+	phase = progress.Phase{
+		Name:  "collecting data",
+		Total: len(p.serial) + len(p.parallel),
+		Unit:  "commands",
+	}
+	remaining := func(labels []string) (pending []string) {
+		for _, label := range labels {
+			if _, ok := p.durations[label]; ok {
+				phase.Done++
+			} else {
+				pending = append(pending, label)
+			}
+		}
+		return
+	}
+	serial := remaining(p.serial)
+	parallel := remaining(p.parallel)
+	if timings != nil {
+		phase.Remaining, _ = timings.Estimate(serial, parallel)
+	}
+	return
+}
+
+// getCommandTimingsPath returns the path to the durations of the commands in previous
+// collections
+func getCommandTimingsPath() (path string, err error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return
+	}
+	path = filepath.Join(dir, "svr-info", "command_timings.json")
+	return
+}
+
+// loadCommandTimings returns the durations of the commands in previous collections, or
+// none if they can't be read
+func loadCommandTimings() *progress.Timings {
+	path, err := getCommandTimingsPath()
+	if err != nil {
+		log.Printf("failed to get command timings path: %v", err)
+		return progress.NewTimings()
+	}
+	timings, err := progress.LoadTimings(path)
+	if err != nil {
+		log.Printf("failed to load command timings: %v", err)
+		return progress.NewTimings()
+	}
+	return timings
+}
+
+// saveCommandTimings saves the durations of the commands for future collections
+func saveCommandTimings(timings *progress.Timings) {
+	if timings.Len() == 0 {
+		return
+	}
+	path, err := getCommandTimingsPath()
+	if err == nil {
+		err = timings.Save(path)
+	}
+	if err != nil {
+		log.Printf("failed to save command timings: %v", err)
+	}
+}
+
+// monitorsProgress returns true if the collector's progress is shown in the collection's
+// status, i.e., the status is shown in a terminal or written as JSON, and the collector
+// runs on the target
+func (c *Collection) monitorsProgress() bool {
+	if c.statusUpdate == nil || (!term.IsTerminal(int(os.Stderr.Fd())) && c.cmdLineArgs.progress != progressJSON) {
+		return false
+	}
+	_, replay := c.target.(*target.ReplayTarget)
+	return !replay
+}
+
+// readCollectorProgress reads the collector's progress log from the target
+func (c *Collection) readCollectorProgress(path string) (p collectorProgress, err error) {
+	stdout, _, _, err := c.target.RunCommand(exec.Command("cat", path))
+	if err != nil {
+		return
+	}
+	p = parseCollectorProgress(stdout)
+	return
+}
+
+// monitorCollector shows the progress of the collector in the collection's status until
+// the returned stop function is called, which records the durations of the completed
+// commands in the collection's timings
+func (c *Collection) monitorCollector(path string) (stop func()) {
+	done := make(chan bool)
+	stopped := make(chan bool)
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(collectorProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p, err := c.readCollectorProgress(path)
+				if err != nil || len(p.serial)+len(p.parallel) == 0 {
+					continue
+				}
+				c.statusUpdate(c.target.GetName(), p.getPhase(c.timings).String())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		if c.timings == nil {
+			return
+		}
+		p, err := c.readCollectorProgress(path)
+		if err != nil {
+			log.Printf("failed to read collector progress from %s: %v", c.target.GetName(), err)
+			return
+		}
+		for label, duration := range p.durations {
+			c.timings.Record(label, duration)
+		}
+	}
+}
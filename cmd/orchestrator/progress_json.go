@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+)
+
+// With -progress json, the targets' statuses are written as they change, one JSON object
+// per line, for automation, instead of drawing the spinners in the terminal. The events
+// are written to stdout, or appended to the -progress_file.
+
+const (
+	progressSpinner = "spinner"
+	progressJSON    = "json"
+)
+
+var progressModes = []string{progressSpinner, progressJSON}
+
+// messages is where the messages to the user, e.g., warnings and prompts, are printed. It's
+// stderr when the JSON progress events are written to stdout, so that they can be parsed.
+var messages io.Writer = os.Stdout
+
+// ProgressEvent is one line of the JSON progress output
+type ProgressEvent struct {
+	Timestamp string   `json:"timestamp"`
+	Target    string   `json:"target,omitempty"` // empty for the events of the whole run
+	Phase     string   `json:"phase"`
+	Error     string   `json:"error,omitempty"`
+	Reports   []string `json:"reports,omitempty"`
+}
+
+type JSONProgress struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func newJSONProgress(path string) (jsonProgress *JSONProgress, err error) {
+	if path == "" {
+		jsonProgress = &JSONProgress{file: os.Stdout}
+		return
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to open progress file: %v", err)
+		return
+	}
+	jsonProgress = &JSONProgress{file: file}
+	return
+}
+
+func (p *JSONProgress) close() {
+	if p.file != os.Stdout {
+		p.file.Close()
+	}
+}
+
+func (p *JSONProgress) write(event ProgressEvent) {
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, err = p.file.Write(append(b, '\n')); err != nil {
+		log.Printf("failed to write progress event: %v", err)
+	}
+}
+
+// statusChanged writes the change of a target's status, it's a MultiSpinner hook
+func (p *JSONProgress) statusChanged(event progress.Event) {
+	progressEvent := ProgressEvent{Target: event.Label, Phase: event.Status}
+	if event.Err != nil {
+		progressEvent.Error = event.Err.Error()
+	}
+	p.write(progressEvent)
+}
+
+// reportsCreated writes the paths to the reports, in place of the list of reports that
+// is printed at the end of a run
+func (p *JSONProgress) reportsCreated(reportFilePaths []string) {
+	p.write(ProgressEvent{Phase: "reports created", Reports: reportFilePaths})
+}
+
+// failed writes the error that ended the run
+func (p *JSONProgress) failed(err error) {
+	p.write(ProgressEvent{Phase: "failed", Error: err.Error()})
+}
+
+// collectionStatus returns the function that updates the status of the collection's
+// target, with the collection's error as the cause if the collection failed
+func collectionStatus(collection *Collection, multiSpinner *progress.MultiSpinner) progress.MultiSpinnerUpdateFunc {
+	return func(label string, status string) error {
+		return multiSpinner.StatusWithError(label, status, collection.err)
+	}
+}
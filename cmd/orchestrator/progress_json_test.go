@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestJSONProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	jsonProgress, err := newJSONProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := newApp(&CmdLineArgs{}, t.TempDir(), t.TempDir())
+	app.progress = jsonProgress
+	targets := []target.Target{target.NewLocalTarget("hostA", ""), target.NewLocalTarget("hostB", "")}
+	multiSpinner := app.newMultiSpinner(targets)
+	collectionA := newCollection(targets[0], app.args, app.outputDir, app.tempDir)
+	collectionB := newCollection(targets[1], app.args, app.outputDir, app.tempDir)
+	collectionStatus(collectionA, multiSpinner)("hostA", "collecting data")
+	collectionB.err = errors.New("connection lost")
+	collectionStatus(collectionB, multiSpinner)("hostB", "error collecting data")
+	jsonProgress.reportsCreated([]string{"out/hostA.html"})
+	jsonProgress.failed(errors.New("interrupted"))
+	jsonProgress.close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var event ProgressEvent
+		if err = json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid line %s: %v", line, err)
+		}
+		if _, err = time.Parse(time.RFC3339Nano, event.Timestamp); err != nil {
+			t.Errorf("invalid timestamp: %s", event.Timestamp)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 4 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if events[0].Target != "hostA" || events[0].Phase != "collecting data" || events[0].Error != "" {
+		t.Errorf("unexpected event: %v", events[0])
+	}
+	if events[1].Target != "hostB" || events[1].Phase != "error collecting data" || events[1].Error != "connection lost" {
+		t.Errorf("unexpected event: %v", events[1])
+	}
+	if events[2].Target != "" || len(events[2].Reports) != 1 || events[2].Reports[0] != "out/hostA.html" {
+		t.Errorf("unexpected event: %v", events[2])
+	}
+	if events[3].Phase != "failed" || events[3].Error != "interrupted" {
+		t.Errorf("unexpected event: %v", events[3])
+	}
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+	"github.com/intel/svr-info/internal/target"
+)
+
+const testCollectorProgress = "run\tlscpu\tserial\nrun\tturbostat\tserial\nrun\tfio\tparallel\nrun\tmlc\tparallel\ndone\tlscpu\t1.500\ndone\tfio\t30.000\ndone\tbad\tx\n"
+
+func TestParseCollectorProgress(t *testing.T) {
+	p := parseCollectorProgress(testCollectorProgress)
+	if strings.Join(p.serial, ",") != "lscpu,turbostat" || strings.Join(p.parallel, ",") != "fio,mlc" {
+		t.Errorf("unexpected commands: %v, %v", p.serial, p.parallel)
+	}
+	if len(p.durations) != 2 || p.durations["lscpu"] != 1500*time.Millisecond {
+		t.Errorf("unexpected durations: %v", p.durations)
+	}
+	phase := p.getPhase(nil)
+	if phase.String() != "collecting data 2/4 commands" {
+		t.Errorf("unexpected phase: %s", phase)
+	}
+	timings := progress.NewTimings()
+	timings.Record("turbostat", 60*time.Second)
+	timings.Record("mlc", 70*time.Second)
+	if phase = p.getPhase(timings); phase.Remaining != 130*time.Second {
+		t.Errorf("unexpected remaining: %v", phase.Remaining)
+	}
+}
+
+func TestMonitorCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), collectorProgressFile)
+	if err := os.WriteFile(path, []byte(testCollectorProgress), 0644); err != nil {
+		t.Fatal(err)
+	}
+	collection := newCollection(target.NewLocalTarget("localhost", ""), &CmdLineArgs{}, t.TempDir(), t.TempDir())
+	collection.statusUpdate = func(string, string) error { return nil }
+	collection.timings = progress.NewTimings()
+	stop := collection.monitorCollector(path)
+	stop()
+	if _, ok := collection.timings.Estimate([]string{"fio"}, nil); !ok || collection.timings.Len() != 2 {
+		t.Errorf("durations not recorded")
+	}
+	collection.progressFile = path
+	if !strings.Contains(collection.getCollectorFlags("."), " -progress "+path) {
+		t.Errorf("progress flag missing: %s", collection.getCollectorFlags("."))
+	}
+}
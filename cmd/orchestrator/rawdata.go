@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/intel/svr-info/internal/rawdata"
+)
+
+const (
+	rawFormatJSON = "json"
+	rawFormatGob  = "gob"
+)
+
+var rawFormats = []string{rawFormatJSON, rawFormatGob}
+
+// getRawFileExtension returns the file name extension of the collected data in format
+func getRawFileExtension(format string) string {
+	if format == rawFormatGob {
+		return rawdata.GobExtension
+	}
+	return ".raw.json"
+}
+
+// appendRawResult appends the result to each host's collected data in the raw.json or
+// raw.gob file, as if it were the output of a command
+func appendRawResult(rawFilePath string, result map[string]string) (err error) {
+	if strings.HasSuffix(rawFilePath, rawdata.GobExtension) {
+		var hostname string
+		hostname, err = rawdata.GetGobHostname(rawFilePath)
+		if err != nil {
+			return
+		}
+		err = rawdata.AppendGobFile(rawFilePath, hostname, []rawdata.Result{result})
+		return
+	}
+	content, err := os.ReadFile(rawFilePath)
+	if err != nil {
+		return
+	}
+	var data map[string][]map[string]interface{}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return
+	}
+	for hostname, results := range data {
+		r := make(map[string]interface{}, len(result))
+		for k, v := range result {
+			r[k] = v
+		}
+		data[hostname] = append(results, r)
+	}
+	content, err = json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(rawFilePath, content, 0644)
+	return
+}
+
+// writeRawResults writes the host's results to path in format, as the collector would
+func writeRawResults(path string, format string, hostname string, results []map[string]string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if format == rawFormatGob {
+		var g *rawdata.GobWriter
+		if g, err = rawdata.NewGobWriter(f, hostname); err != nil {
+			return
+		}
+		for _, result := range results {
+			if err = g.Write(result); err != nil {
+				return
+			}
+		}
+		err = g.Close()
+		return
+	}
+	if results == nil {
+		results = []map[string]string{}
+	}
+	out, err := json.MarshalIndent(map[string][]map[string]string{hostname: results}, "", "  ")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(out)
+	return
+}
+
+// readRawResults returns the hostname and the results in the collected data at path,
+// which may be truncated, up to the first result that can't be read
+func readRawResults(path string, format string) (hostname string, results []map[string]string, err error) {
+	if format == rawFormatGob {
+		// the results read before an error are kept
+		rawdata.ReadGobFile(path, func(host string, result rawdata.Result) error {
+			hostname = host
+			results = append(results, result)
+			return nil
+		})
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for _, expected := range []string{"{", "hostname", "["} {
+		var token json.Token
+		if token, err = dec.Token(); err != nil {
+			err = nil // no results
+			return
+		}
+		if expected == "hostname" {
+			hostname, _ = token.(string)
+		} else if token != json.Delim(expected[0]) {
+			err = fmt.Errorf("invalid collector output, expected %s", expected)
+			return
+		}
+	}
+	for dec.More() {
+		var result map[string]string
+		if dec.Decode(&result) != nil {
+			break // truncated
+		}
+		results = append(results, result)
+	}
+	return
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/intel/svr-info/internal/rawdata"
+)
+
+func TestAppendRawResultGob(t *testing.T) {
+	rawFilePath := filepath.Join(t.TempDir(), "host"+getRawFileExtension(rawFormatGob))
+	f, err := os.Create(rawFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := rawdata.NewGobWriter(f, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Write(rawdata.Result{"label": "hostname", "stdout": "host\n", "exitstatus": "0"}); err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	f.Close()
+	if err = addTags(rawFilePath, "env=prod"); err != nil {
+		t.Fatal(err)
+	}
+	var results []rawdata.Result
+	err = rawdata.ReadGobFile(rawFilePath, func(hostname string, result rawdata.Result) error {
+		if hostname != "host" {
+			t.Errorf("unexpected hostname: %s", hostname)
+		}
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0]["label"] != "hostname" {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	if results[1]["label"] != tagsLabel || results[1]["stdout"] != "env=prod" {
+		t.Errorf("unexpected tags result: %v", results[1])
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/rawdata"
+	"github.com/intel/svr-info/internal/target"
+	"gopkg.in/yaml.v2"
+)
+
+// With -replay, the targets are captured raw.json or raw.gob files, e.g., a corpus of
+// collections from real-world hosts. The collection runs as usual, but the collector's
+// output is the captured results of the commands it would have run, so that changes to
+// the command files, parsers, and reports can be tested without the hosts.
+
+// getCaptureFiles returns the raw data files at path, a file or a directory of files
+func getCaptureFiles(path string) (files []string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		files = []string{path}
+		return
+	}
+	for _, format := range rawFormats {
+		var matches []string
+		if matches, err = filepath.Glob(filepath.Join(path, "*"+getRawFileExtension(format))); err != nil {
+			return
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("no raw data files found in %s", path)
+	}
+	sort.Strings(files)
+	return
+}
+
+// readCapture returns the results of each host in the raw.json or raw.gob file
+func readCapture(path string) (hosts map[string][]map[string]string, err error) {
+	if strings.HasSuffix(path, rawdata.GobExtension) {
+		hosts = make(map[string][]map[string]string)
+		err = rawdata.ReadGobFile(path, func(hostname string, result rawdata.Result) error {
+			hosts[hostname] = append(hosts[hostname], result)
+			return nil
+		})
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &hosts)
+	return
+}
+
+// getReplayTargets returns a replay target for each host in the captured raw data files
+// at path
+func getReplayTargets(path string) (targets []target.Target, err error) {
+	files, err := getCaptureFiles(path)
+	if err != nil {
+		return
+	}
+	names := make(map[string]string) // file by host name
+	for _, file := range files {
+		var hosts map[string][]map[string]string
+		if hosts, err = readCapture(file); err != nil {
+			err = fmt.Errorf("%s: %v", file, err)
+			return
+		}
+		var hostnames []string
+		for hostname := range hosts {
+			hostnames = append(hostnames, hostname)
+		}
+		sort.Strings(hostnames)
+		for _, hostname := range hostnames {
+			if other, ok := names[hostname]; ok {
+				err = fmt.Errorf("%s: host %s is also captured in %s", file, hostname, other)
+				return
+			}
+			names[hostname] = file
+			targets = append(targets, target.NewReplayTarget(hostname, hosts[hostname]))
+		}
+	}
+	return
+}
+
+// replayCollector writes the collector's output for the commands in the command file,
+// i.e., the captured results of those that would run, to outputFilePath in format. The
+// commands that weren't captured are omitted, as if they didn't run. A summary is written
+// to the collector's log in the same directory.
+func replayCollector(t *target.ReplayTarget, commandFilePath string, outputFilePath string, format string) (err error) {
+	content, err := os.ReadFile(commandFilePath)
+	if err != nil {
+		return
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(content, &cf); err != nil {
+		return
+	}
+	var results []map[string]string
+	var missing []string
+	for _, cmd := range cf.Commands {
+		if !cmd.Run {
+			continue
+		}
+		if result, ok := t.GetResult(cmd.Label); ok {
+			results = append(results, result)
+		} else {
+			missing = append(missing, cmd.Label)
+		}
+	}
+	if err = writeRawResults(outputFilePath, format, cf.Args.Name, results); err != nil {
+		return
+	}
+	summary := fmt.Sprintf("replayed %d captured command results for %s\n", len(results), cf.Args.Name)
+	if len(missing) > 0 {
+		summary += fmt.Sprintf("not captured: %s\n", strings.Join(missing, ", "))
+	}
+	log.Printf("%s: %s", t.GetName(), strings.ReplaceAll(strings.TrimSpace(summary), "\n", ", "))
+	err = os.WriteFile(filepath.Join(filepath.Dir(outputFilePath), "collector.log"), []byte(summary), 0644)
+	return
+}
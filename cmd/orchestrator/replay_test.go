@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/rawdata"
+	"github.com/intel/svr-info/internal/target"
+)
+
+func writeCaptures(t *testing.T, dir string) {
+	capture := map[string][]map[string]string{
+		"hostA": {
+			{"label": "lscpu", "command": "lscpu", "stdout": "Model name: Xeon", "stderr": "", "exitstatus": "0", "superuser": "false"},
+			{"label": "dmidecode", "command": "dmidecode", "stdout": "BIOS", "stderr": "", "exitstatus": "0", "superuser": "true"},
+		},
+	}
+	content, err := json.Marshal(capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "hostA.raw.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "hostB"+rawdata.GobExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := rawdata.NewGobWriter(f, "hostB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Write(rawdata.Result{"label": "lscpu", "command": "lscpu", "stdout": "Model name: Graviton", "stderr": "", "exitstatus": "0", "superuser": "false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetReplayTargets(t *testing.T) {
+	dir := t.TempDir()
+	writeCaptures(t, dir)
+	targets, err := getReplayTargets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].GetName() != "hostA" || targets[1].GetName() != "hostB" {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	result, ok := targets[1].(*target.ReplayTarget).GetResult("lscpu")
+	if !ok || result["stdout"] != "Model name: Graviton" {
+		t.Errorf("unexpected gob result: %v", result)
+	}
+	// a single file
+	if targets, err = getReplayTargets(filepath.Join(dir, "hostA.raw.json")); err != nil || len(targets) != 1 {
+		t.Errorf("unexpected targets from file: %v, %v", targets, err)
+	}
+	// the same host captured twice
+	content, _ := os.ReadFile(filepath.Join(dir, "hostA.raw.json"))
+	if err = os.WriteFile(filepath.Join(dir, "hostA_again.raw.json"), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getReplayTargets(dir); err == nil {
+		t.Error("expected error for host captured twice")
+	}
+	if _, err = getReplayTargets(t.TempDir()); err == nil {
+		t.Error("expected error for directory without captures")
+	}
+}
+
+func TestReplayCollector(t *testing.T) {
+	dir := t.TempDir()
+	writeCaptures(t, dir)
+	targets, err := getReplayTargets(filepath.Join(dir, "hostA.raw.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayTarget := targets[0].(*target.ReplayTarget)
+	commandFile := `arguments:
+  name: hostA
+commands:
+  - label: lscpu
+    command: lscpu
+    run: true
+  - label: dmidecode
+    command: dmidecode
+    run: false
+  - label: lspci -vmm
+    command: lspci -vmm
+    run: true
+`
+	commandFilePath := filepath.Join(dir, "hostA_reports_collector.yaml")
+	if err = os.WriteFile(commandFilePath, []byte(commandFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	// json
+	outputFilePath := filepath.Join(outputDir, "collector.stdout")
+	if err = replayCollector(replayTarget, commandFilePath, outputFilePath, rawFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(outputFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string][]map[string]string
+	if err = json.Unmarshal(content, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data["hostA"]) != 1 || data["hostA"][0]["label"] != "lscpu" {
+		t.Errorf("unexpected replayed results: %v", data)
+	}
+	logContent, err := os.ReadFile(filepath.Join(outputDir, "collector.log"))
+	if err != nil || !strings.Contains(string(logContent), "not captured: lspci -vmm") {
+		t.Errorf("unexpected collector log: %s, %v", logContent, err)
+	}
+	// gob
+	if err = replayCollector(replayTarget, commandFilePath, outputFilePath, rawFormatGob); err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	err = rawdata.ReadGobFile(outputFilePath, func(hostname string, result rawdata.Result) error {
+		if hostname != "hostA" {
+			t.Errorf("unexpected host: %s", hostname)
+		}
+		labels = append(labels, result["label"])
+		return nil
+	})
+	if err != nil || len(labels) != 1 || labels[0] != "lscpu" {
+		t.Errorf("unexpected replayed gob results: %v, %v", labels, err)
+	}
+}
+
+func TestReplayArgs(t *testing.T) {
+	dir := t.TempDir()
+	writeCaptures(t, dir)
+	if !isValid([]string{"-replay", dir}) {
+		t.Fail()
+	}
+	if isValid([]string{"-replay", filepath.Join(dir, "missing.raw.json")}) {
+		t.Fail()
+	}
+	if isValid([]string{"-replay", dir, "-ip", "192.168.1.1", "-user", "user"}) {
+		t.Fail()
+	}
+	if isValid([]string{"-replay", dir, "-megadata"}) {
+		t.Fail()
+	}
+}
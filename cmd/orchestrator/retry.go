@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/intel/svr-info/internal/progress"
+)
+
+// With -retries N, a target's collection that fails, e.g., because the SSH connection
+// was refused or dropped, is attempted up to N more times, waiting retryDelay before the
+// first retry and twice as long before each retry that follows, up to retryMaxDelay.
+// Collections that would fail again, e.g., because a prerequisite is missing on the
+// target, aren't retried.
+
+// retryDelay is the time to wait before the first retry of a failed collection
+var retryDelay = 10 * time.Second
+
+// retryMaxDelay is the longest time to wait before retrying a failed collection
+const retryMaxDelay = 5 * time.Minute
+
+// notRetryableError is the error of a collection that would fail again if it was retried
+type notRetryableError struct {
+	error
+}
+
+func (e notRetryableError) Unwrap() error {
+	return e.error
+}
+
+// notRetryable marks the error of a collection that would fail again if it was retried
+func notRetryable(err error) error {
+	return notRetryableError{err}
+}
+
+// isRetryable returns true if the collection that failed with the error could succeed
+// if it's retried
+func isRetryable(err error) bool {
+	var e notRetryableError
+	return !errors.As(err, &e)
+}
+
+// getRetryDelay returns the time to wait before the retry, starting at 1
+func getRetryDelay(retry int) time.Duration {
+	delay := retryDelay
+	for i := 1; i < retry && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	return delay
+}
+
+// collectWithRetries runs the collection, collect, and retries it with exponential
+// backoff, up to the collection's -retries times, until it succeeds
+func (c *Collection) collectWithRetries(collect func() error, statusUpdate progress.MultiSpinnerUpdateFunc) (err error) {
+	retries := c.cmdLineArgs.retries
+	for retry := 0; ; retry++ {
+		err = collect()
+		if err == nil {
+			if retry > 0 {
+				log.Printf("collection from %s succeeded after %d retries", c.target.GetName(), retry)
+			}
+			return
+		}
+		if retry == retries || !isRetryable(err) {
+			if retries > 0 {
+				log.Printf("collection from %s failed after %d of %d retries: %v", c.target.GetName(), retry, retries, err)
+			}
+			return
+		}
+		delay := getRetryDelay(retry + 1)
+		log.Printf("collection from %s failed, retry %d of %d in %s: %v", c.target.GetName(), retry+1, retries, delay, err)
+		// the error is the cause of the waiting status
+		c.err = err
+		if statusUpdate != nil {
+			statusUpdate(c.target.GetName(), fmt.Sprintf("waiting to retry (%d of %d)", retry+1, retries))
+		}
+		c.err = nil
+		time.Sleep(delay)
+		if statusUpdate != nil {
+			statusUpdate(c.target.GetName(), fmt.Sprintf("collecting data (retry %d of %d)", retry+1, retries))
+		}
+	}
+}
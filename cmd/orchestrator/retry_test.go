@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestGetRetryDelay(t *testing.T) {
+	for retry, expected := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 6: 5 * time.Minute, 100: 5 * time.Minute} {
+		if delay := getRetryDelay(retry); delay != expected {
+			t.Errorf("retry %d: unexpected delay %s", retry, delay)
+		}
+	}
+}
+
+func TestCollectWithRetries(t *testing.T) {
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+	c := newCollection(target.NewLocalTarget("hostA", ""), &CmdLineArgs{retries: 3}, t.TempDir(), t.TempDir())
+	var statuses []string
+	statusUpdate := func(label string, status string) error {
+		statuses = append(statuses, status)
+		return nil
+	}
+	// succeeds on the third attempt
+	attempts := 0
+	collect := func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("failed to connect to target: hostA")
+		}
+		return nil
+	}
+	if err := c.collectWithRetries(collect, statusUpdate); err != nil || attempts != 3 {
+		t.Errorf("unexpected attempts: %d, %v", attempts, err)
+	}
+	if len(statuses) != 4 || statuses[0] != "waiting to retry (1 of 3)" || statuses[3] != "collecting data (retry 2 of 3)" {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+	// fails every attempt
+	attempts = 0
+	if err := c.collectWithRetries(func() error { attempts++; return errors.New("connection lost") }, nil); err == nil || attempts != 4 {
+		t.Errorf("unexpected attempts: %d, %v", attempts, err)
+	}
+	// would fail again
+	attempts = 0
+	collect = func() error { attempts++; return notRetryable(errors.New("tar not found")) }
+	if err := c.collectWithRetries(collect, nil); err == nil || err.Error() != "tar not found" || attempts != 1 {
+		t.Errorf("unexpected attempts: %d, %v", attempts, err)
+	}
+}
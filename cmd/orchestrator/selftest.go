@@ -0,0 +1,375 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The selftest command verifies a build, or a new operator machine, before a fleet run.
+// It collects the configuration data of localhost in read-only mode, creates every
+// report format, and checks that each file has the structure its consumers expect.
+
+const (
+	selftestPass = "PASS"
+	selftestWarn = "WARN"
+	selftestFail = "FAIL"
+)
+
+// toolChecksumsLabel labels the collector's list of the bundled tools and their hashes
+const toolChecksumsLabel = "tool checksums"
+
+// selftestCheck is a row of the selftest's pass/fail matrix
+type selftestCheck struct {
+	name   string
+	result string
+	detail string
+}
+
+// rawDataFields are the fields of each command result in the raw.json file
+var rawDataFields = []string{"label", "command", "stdout", "stderr", "exitstatus", "superuser"}
+
+// validateRawData checks that the raw.json file is an object with the hostname as its
+// only key and an array of command results as its value
+func validateRawData(path string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var data map[string][]map[string]interface{}
+	if err = json.Unmarshal(content, &data); err != nil {
+		return
+	}
+	if len(data) != 1 {
+		err = fmt.Errorf("expected one host, found %d", len(data))
+		return
+	}
+	for _, results := range data {
+		if len(results) == 0 {
+			err = fmt.Errorf("no command results")
+			return
+		}
+		failed := 0
+		for i, result := range results {
+			for _, field := range rawDataFields {
+				if _, ok := result[field].(string); !ok {
+					err = fmt.Errorf("result %d: missing %s", i+1, field)
+					return
+				}
+			}
+			if result["exitstatus"] != "0" {
+				failed++
+			}
+		}
+		detail = fmt.Sprintf("%d commands, %d with a non-zero exit status", len(results), failed)
+	}
+	return
+}
+
+// validateJSONReport checks that the JSON report is an object of reports, each an object
+// of tables, each an array of rows of string values, and that it includes the reports
+// that other tools read
+func validateJSONReport(path string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var reports map[string]map[string][]map[string]string
+	if err = json.Unmarshal(content, &reports); err != nil {
+		return
+	}
+	for _, name := range []string{"Configuration", "Brief"} {
+		if len(reports[name]) == 0 {
+			err = fmt.Errorf("missing %s report", name)
+			return
+		}
+	}
+	tables := 0
+	for _, report := range reports {
+		tables += len(report)
+	}
+	detail = fmt.Sprintf("%d reports, %d tables", len(reports), tables)
+	return
+}
+
+// validateFactsReport checks that the facts report is an object that identifies the host
+func validateFactsReport(path string, hostname string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var facts map[string]interface{}
+	if err = json.Unmarshal(content, &facts); err != nil {
+		return
+	}
+	for _, name := range []string{"hostname", "svr_info_version"} {
+		if _, ok := facts[name].(string); !ok {
+			err = fmt.Errorf("missing %s", name)
+			return
+		}
+	}
+	if facts["hostname"] != hostname {
+		err = fmt.Errorf("hostname is %v, expected %s", facts["hostname"], hostname)
+		return
+	}
+	detail = fmt.Sprintf("%d facts", len(facts))
+	return
+}
+
+// validateHTMLReport checks that the HTML report is a complete document
+func validateHTMLReport(path string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lower := bytes.ToLower(content)
+	if !bytes.Contains(lower, []byte("<html")) || !bytes.Contains(lower, []byte("</html>")) {
+		err = fmt.Errorf("incomplete HTML document")
+		return
+	}
+	detail = fmt.Sprintf("%d bytes", len(content))
+	return
+}
+
+// validateXLSXReport checks that the Excel report is a workbook with at least one sheet
+func validateXLSXReport(path string) (detail string, err error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	haveWorkbook := false
+	sheets := 0
+	for _, f := range r.File {
+		if f.Name == "xl/workbook.xml" {
+			haveWorkbook = true
+		} else if strings.HasPrefix(f.Name, "xl/worksheets/sheet") {
+			sheets++
+		}
+	}
+	if !haveWorkbook || sheets == 0 {
+		err = fmt.Errorf("not an Excel workbook")
+		return
+	}
+	detail = fmt.Sprintf("%d sheets", sheets)
+	return
+}
+
+// validateCSVReport checks that the CSV bundle has a CSV file per table
+func validateCSVReport(path string) (detail string, err error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	tables := 0
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, ".csv") {
+			tables++
+		}
+	}
+	if tables == 0 {
+		err = fmt.Errorf("no tables")
+		return
+	}
+	detail = fmt.Sprintf("%d tables", tables)
+	return
+}
+
+// validateTxtReport checks that the txt report isn't empty
+func validateTxtReport(path string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	lines := strings.Count(string(content), "\n")
+	if lines == 0 {
+		err = fmt.Errorf("empty report")
+		return
+	}
+	detail = fmt.Sprintf("%d lines", lines)
+	return
+}
+
+// validateMarkdownReport checks that the Markdown report has a heading and tables
+func validateMarkdownReport(path string) (detail string, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if !strings.HasPrefix(string(content), "# ") {
+		err = fmt.Errorf("no heading")
+		return
+	}
+	tables := strings.Count(string(content), "\n### ")
+	if tables == 0 {
+		err = fmt.Errorf("no tables")
+		return
+	}
+	detail = fmt.Sprintf("%d tables", tables)
+	return
+}
+
+// checkToolChecksums warns about the bundled tools that weren't found on localhost,
+// according to the collector's tool checksums in the raw.json file
+func checkToolChecksums(path string) (check selftestCheck) {
+	check = selftestCheck{name: "bundled tools", result: selftestPass}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return selftestCheck{check.name, selftestFail, err.Error()}
+	}
+	var data map[string][]map[string]string
+	if err = json.Unmarshal(content, &data); err != nil {
+		return selftestCheck{check.name, selftestFail, err.Error()}
+	}
+	var tools, missing []string
+	for _, results := range data {
+		for _, result := range results {
+			if result["label"] != toolChecksumsLabel {
+				continue
+			}
+			for _, line := range strings.Split(strings.TrimSpace(result["stdout"]), "\n") {
+				fields := strings.Fields(line)
+				if len(fields) != 3 {
+					continue
+				}
+				tools = append(tools, fields[0])
+				if fields[2] == "missing" {
+					missing = append(missing, fields[0])
+				}
+			}
+		}
+	}
+	if len(tools) == 0 {
+		return selftestCheck{check.name, selftestWarn, "tool checksums not collected"}
+	}
+	if len(missing) > 0 {
+		return selftestCheck{check.name, selftestWarn, "missing: " + strings.Join(missing, ", ")}
+	}
+	check.detail = fmt.Sprintf("%d tools", len(tools))
+	return
+}
+
+// validateSelftestOutput checks the files created for the host in the output directory
+func validateSelftestOutput(outputDir string, hostname string) (checks []selftestCheck) {
+	validators := []struct {
+		name     string
+		file     string
+		validate func(path string) (string, error)
+	}{
+		{"raw data", hostname + ".raw.json", validateRawData},
+		{"json report", hostname + ".json", validateJSONReport},
+		{"facts report", hostname + ".facts.json", func(path string) (string, error) { return validateFactsReport(path, hostname) }},
+		{"html report", hostname + ".html", validateHTMLReport},
+		{"xlsx report", hostname + ".xlsx", validateXLSXReport},
+		{"txt report", hostname + ".txt", validateTxtReport},
+		{"md report", hostname + ".md", validateMarkdownReport},
+		{"csv report", hostname + ".csv.zip", validateCSVReport},
+	}
+	for _, v := range validators {
+		detail, err := v.validate(filepath.Join(outputDir, v.file))
+		if err != nil {
+			checks = append(checks, selftestCheck{v.name, selftestFail, fmt.Sprintf("%s: %v", v.file, err)})
+		} else {
+			checks = append(checks, selftestCheck{v.name, selftestPass, detail})
+		}
+	}
+	checks = append(checks, checkToolChecksums(filepath.Join(outputDir, hostname+".raw.json")))
+	return
+}
+
+// printSelftestChecks prints the pass/fail matrix, returning true if no check failed
+func printSelftestChecks(checks []selftestCheck) (passed bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Check\tResult\tDetail\n")
+	failed := 0
+	for _, check := range checks {
+		if check.result == selftestFail {
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.name, check.result, check.detail)
+	}
+	w.Flush()
+	fmt.Printf("%d of %d checks passed\n", len(checks)-failed, len(checks))
+	return failed == 0
+}
+
+func runSelftest(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var output string
+	var keep bool
+	var cmdTimeout int
+	flagSet.StringVar(&output, "output", "", "directory for the collected data and reports, kept after the test (default: a temporary directory, kept only if a check fails)")
+	flagSet.BoolVar(&keep, "keep", false, "keep the temporary directory")
+	flagSet.IntVar(&cmdTimeout, "cmd_timeout", 60, "the maximum number of seconds to wait for each data collection command")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(flagSet.Args(), " "))
+		return retError
+	}
+	var err error
+	if output == "" {
+		if output, err = os.MkdirTemp("", fmt.Sprintf("%s.selftest.", filepath.Base(os.Args[0]))); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+	} else {
+		keep = true
+		if output, err = util.AbsPath(output); err == nil {
+			err = os.MkdirAll(output, 0755)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+	}
+	// collect from localhost and create every report format
+	cmdLineArgs := newCmdLineArgs()
+	collectArgs := []string{"-output", output, "-format", "all", "-read_only", "-cmd_timeout", strconv.Itoa(cmdTimeout)}
+	if err = cmdLineArgs.parse(os.Args[0], collectArgs); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	cmdLineArgs.keepRawData = true
+	start := time.Now()
+	exitCode := runAppWithArgs(cmdLineArgs, (*App).doWork)
+	collect := selftestCheck{"collect", selftestPass, time.Since(start).Round(100 * time.Millisecond).String()}
+	if exitCode != retNoError {
+		collect.result = selftestFail
+		collect.detail = "see " + filepath.Join(output, getLogfileName())
+	}
+	checks := []selftestCheck{collect}
+	rawFiles, _ := filepath.Glob(filepath.Join(output, "*.raw.json"))
+	if len(rawFiles) == 0 && exitCode == retNoError {
+		checks = append(checks, selftestCheck{"raw data", selftestFail, "no data collected"})
+	}
+	for _, rawFile := range rawFiles {
+		checks = append(checks, validateSelftestOutput(output, strings.TrimSuffix(filepath.Base(rawFile), ".raw.json"))...)
+	}
+	fmt.Println()
+	passed := printSelftestChecks(checks)
+	if passed && !keep {
+		os.RemoveAll(output)
+	} else {
+		fmt.Printf("Output: %s\n", output)
+	}
+	if !passed {
+		return retError
+	}
+	return retNoError
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSelftestOutput(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"host.raw.json":   `{"host":[{"label":"hostname","command":"hostname","stdout":"host\n","stderr":"","exitstatus":"0","superuser":"false"},{"label":"tool checksums","command":"","stdout":"collector 1.0 abc\nfio 3.36 missing","stderr":"","exitstatus":"0","superuser":"false"}]}`,
+		"host.json":       `{"Configuration":{"CPU":[{"Sockets":"2"}]},"Brief":{"CPU":[{"Sockets":"2"}]}}`,
+		"host.facts.json": `{"hostname":"host","svr_info_version":"dev","cpu_sockets":2}`,
+		"host.html":       `<!DOCTYPE html><html><body></body></html>`,
+		"host.txt":        "Configuration\n",
+		"host.md":         "# host\n\n## Configuration\n\n### CPU\n\n| Field | Value |\n| --- | --- |\n| Sockets | 2 |\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := map[string]string{
+		"raw data":      selftestPass,
+		"json report":   selftestPass,
+		"facts report":  selftestPass,
+		"html report":   selftestPass,
+		"xlsx report":   selftestFail, // not created
+		"txt report":    selftestPass,
+		"md report":     selftestPass,
+		"csv report":    selftestFail, // not created
+		"bundled tools": selftestWarn, // fio is missing
+	}
+	checks := validateSelftestOutput(dir, "host")
+	if len(checks) != len(expected) {
+		t.Fatalf("unexpected checks: %v", checks)
+	}
+	for _, check := range checks {
+		if check.result != expected[check.name] {
+			t.Errorf("%s: expected %s, got %s (%s)", check.name, expected[check.name], check.result, check.detail)
+		}
+	}
+	// reports that don't have the expected structure fail
+	if _, err := validateJSONReport(filepath.Join(dir, "host.facts.json")); err == nil {
+		t.Error("expected error for JSON report without reports")
+	}
+	if _, err := validateFactsReport(filepath.Join(dir, "host.facts.json"), "other"); err == nil {
+		t.Error("expected error for facts of another host")
+	}
+}
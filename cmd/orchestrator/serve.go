@@ -0,0 +1,410 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// With -targets, the serve command also runs collections on demand, so that portals and
+// other tools can collect from the configured targets and download the reports, with a
+// REST API:
+//
+//	GET  /api/v1/targets                    the names of the configured targets
+//	POST /api/v1/collections                start a collection, returns its status
+//	GET  /api/v1/collections                the status of every collection
+//	GET  /api/v1/collections/ID             the status of a collection and its files
+//	GET  /api/v1/collections/ID/files/NAME  download a report, or another file
+//
+// Each collection runs 'svr-info collect' in its own output directory, DIR/ID, so its
+// reports are also served with the directory's other files. Collections are queued and
+// run -max_collections at a time. If the SVR_INFO_API_TOKEN environment variable is set,
+// API requests must include the token, as in Authorization: Bearer TOKEN. The token is
+// required to serve the API on other than a loopback address.
+
+const apiPrefix = "/api/v1/"
+
+// apiTokenEnv is the environment variable that holds the token API requests must include
+const apiTokenEnv = "SVR_INFO_API_TOKEN"
+
+// maxQueuedCollections limits the collections that are waiting to run
+const maxQueuedCollections = 64
+
+var errCollectionQueueFull = errors.New("too many queued collections, try again later")
+
+// collection statuses
+const (
+	collectionQueued    = "queued"
+	collectionRunning   = "running"
+	collectionSucceeded = "succeeded"
+	collectionFailed    = "failed"
+)
+
+// collectionRequest is the body of a POST to /api/v1/collections. The targets are a
+// subset of the configured targets, by name, all of them if empty. The other settings
+// are those of the collect command's arguments of the same name.
+type collectionRequest struct {
+	Targets   []string `json:"targets"`
+	Format    string   `json:"format"`
+	Collect   string   `json:"collect"`
+	Benchmark string   `json:"benchmark"`
+	Profile   string   `json:"profile"`
+	Analyze   string   `json:"analyze"`
+}
+
+// apiCollection is a collection started through the API
+type apiCollection struct {
+	ID       string   `json:"id"`
+	Status   string   `json:"status"`
+	Targets  []string `json:"targets,omitempty"`
+	Created  string   `json:"created"`
+	Started  string   `json:"started,omitempty"`
+	Finished string   `json:"finished,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Files    []string `json:"files,omitempty"`
+	args     []string // the collect command's arguments
+	dir      string   // the output directory
+}
+
+// collectionServer queues and runs the collections, and serves the API
+type collectionServer struct {
+	mu          sync.Mutex
+	dir         string // the served directory, the collections' output directories are in it
+	targets     string // the targets file
+	token       string
+	collections map[string]*apiCollection
+	order       []string // the collections' IDs, oldest first
+	queue       chan *apiCollection
+	count       int
+	// runCollect runs the collect command with the arguments, its output is written to
+	// outputPath, it's replaced by tests
+	runCollect func(args []string, outputPath string) error
+}
+
+func newCollectionServer(dir string, targets string, maxCollections int) (s *collectionServer) {
+	s = &collectionServer{
+		dir:         dir,
+		targets:     targets,
+		token:       os.Getenv(apiTokenEnv),
+		collections: make(map[string]*apiCollection),
+		queue:       make(chan *apiCollection, maxQueuedCollections),
+		runCollect:  runCollectCommand,
+	}
+	for i := 0; i < maxCollections; i++ {
+		go s.worker()
+	}
+	return
+}
+
+// runCollectCommand runs this program's collect command
+func runCollectCommand(args []string, outputPath string) (err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return
+	}
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return
+	}
+	defer output.Close()
+	cmd := exec.Command(self, append([]string{"collect"}, args...)...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	return cmd.Run()
+}
+
+func (s *collectionServer) worker() {
+	for c := range s.queue {
+		s.setStatus(c, collectionRunning, nil)
+		log.Printf("collection %s: collect %s", c.ID, strings.Join(c.args, " "))
+		err := os.Mkdir(c.dir, 0755)
+		if err == nil {
+			err = s.runCollect(append(c.args, "-output", c.dir), filepath.Join(c.dir, "collect.out"))
+		}
+		if err != nil {
+			log.Printf("collection %s failed: %v", c.ID, err)
+			s.setStatus(c, collectionFailed, err)
+		} else {
+			s.setStatus(c, collectionSucceeded, nil)
+		}
+	}
+}
+
+func (s *collectionServer) setStatus(c *apiCollection, status string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Status = status
+	now := time.Now().Format(time.RFC3339)
+	switch status {
+	case collectionRunning:
+		c.Started = now
+	case collectionSucceeded, collectionFailed:
+		c.Finished = now
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+}
+
+// getTargetNames returns the names of the configured targets
+func (s *collectionServer) getTargetNames() (names []string, err error) {
+	targets, err := newTargetsFile(s.targets).parse()
+	if err != nil {
+		return
+	}
+	names = []string{}
+	for _, t := range targets {
+		names = append(names, t.getName())
+	}
+	return
+}
+
+// getCollectArgs returns the collect command's arguments for the request, validated like
+// the command line
+func (s *collectionServer) getCollectArgs(request collectionRequest) (args []string, err error) {
+	args = []string{"-targets", s.targets}
+	if len(request.Targets) > 0 {
+		var targets []targetFromFile
+		if targets, err = newTargetsFile(s.targets).parse(); err != nil {
+			return
+		}
+		if _, err = selectTargets(targets, strings.Join(request.Targets, ",")); err != nil {
+			return
+		}
+		args = append(args, "-targets_select", strings.Join(request.Targets, ","))
+	}
+	for _, option := range []struct{ name, value string }{
+		{"format", request.Format},
+		{"collect", request.Collect},
+		{"benchmark", request.Benchmark},
+		{"profile", request.Profile},
+		{"analyze", request.Analyze},
+	} {
+		if option.value != "" {
+			args = append(args, "-"+option.name, option.value)
+		}
+	}
+	cmdLineArgs := newCmdLineArgs()
+	if err = cmdLineArgs.parse("collect", args); err == nil {
+		err = cmdLineArgs.validate()
+	}
+	return
+}
+
+// start queues a collection
+func (s *collectionServer) start(request collectionRequest) (c *apiCollection, err error) {
+	args, err := s.getCollectArgs(request)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	now := time.Now()
+	id := fmt.Sprintf("collection_%s_%d", now.Format("2006-01-02_15-04-05"), s.count)
+	c = &apiCollection{
+		ID:      id,
+		Status:  collectionQueued,
+		Targets: request.Targets,
+		Created: now.Format(time.RFC3339),
+		args:    args,
+		dir:     filepath.Join(s.dir, id),
+	}
+	select {
+	case s.queue <- c:
+	default:
+		err = errCollectionQueueFull
+		c = nil
+		return
+	}
+	s.collections[id] = c
+	s.order = append(s.order, id)
+	return
+}
+
+// getStatus returns a copy of the collection's status, with its files once it's finished
+func (s *collectionServer) getStatus(c *apiCollection) (status apiCollection) {
+	s.mu.Lock()
+	status = *c
+	s.mu.Unlock()
+	if status.Status == collectionSucceeded || status.Status == collectionFailed {
+		entries, err := os.ReadDir(status.dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				status.Files = append(status.Files, entry.Name())
+			}
+		}
+		sort.Strings(status.Files)
+	}
+	return
+}
+
+// authorized returns true if the API token isn't set or the request includes it
+func (s *collectionServer) authorized(r *http.Request) bool {
+	return s.token == "" || hasBearerToken(r, s.token)
+}
+
+// hasBearerToken returns true if the request includes the token, as in
+// Authorization: Bearer TOKEN
+func hasBearerToken(r *http.Request, token string) bool {
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1
+}
+
+// requireToken wraps the handler so that requests must include the token, if it's set,
+// as in Authorization: Bearer TOKEN
+func requireToken(handler http.Handler, token string) http.Handler {
+	if token == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBearerToken(r, token) {
+			http.Error(w, "a valid token is required", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isPathInDir returns true if the path is in the directory or one of its
+// subdirectories, after resolving symbolic links
+func isPathInDir(path string, dir string) bool {
+	resolve := func(p string) string {
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		return p
+	}
+	rel, err := filepath.Rel(resolve(dir), resolve(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isLoopbackAddr returns true if the listener's address only accepts connections
+// from this system, e.g., 127.0.0.1:8080, as opposed to all addresses, e.g., [::]:8080
+func isLoopbackAddr(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// writeAPIResponse writes the response as JSON with the status code
+func writeAPIResponse(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error: %v", err)
+	}
+}
+
+// writeAPIError writes the error as JSON, e.g., {"error": "no collection ..."}
+func writeAPIError(w http.ResponseWriter, code int, err error) {
+	writeAPIResponse(w, code, map[string]string{"error": err.Error()})
+}
+
+// ServeHTTP serves the API
+func (s *collectionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeAPIError(w, http.StatusUnauthorized, fmt.Errorf("a valid token is required"))
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/")
+	parts := strings.Split(path, "/")
+	method := func(allowed string) bool {
+		if r.Method != allowed {
+			w.Header().Set("Allow", allowed)
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return false
+		}
+		return true
+	}
+	switch {
+	case path == "targets":
+		if !method(http.MethodGet) {
+			return
+		}
+		names, err := s.getTargetNames()
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeAPIResponse(w, http.StatusOK, map[string][]string{"targets": names})
+	case path == "collections" && r.Method == http.MethodPost:
+		var request collectionRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+		c, err := s.start(request)
+		if err != nil {
+			code := http.StatusBadRequest
+			if errors.Is(err, errCollectionQueueFull) {
+				code = http.StatusServiceUnavailable
+			}
+			writeAPIError(w, code, err)
+			return
+		}
+		w.Header().Set("Location", apiPrefix+"collections/"+c.ID)
+		writeAPIResponse(w, http.StatusAccepted, s.getStatus(c))
+	case path == "collections":
+		if !method(http.MethodGet) {
+			return
+		}
+		s.mu.Lock()
+		ids := append([]string{}, s.order...)
+		s.mu.Unlock()
+		statuses := []apiCollection{}
+		for _, id := range ids {
+			statuses = append(statuses, s.getStatus(s.collections[id]))
+		}
+		writeAPIResponse(w, http.StatusOK, map[string][]apiCollection{"collections": statuses})
+	case parts[0] == "collections" && (len(parts) == 2 || (len(parts) == 4 && parts[2] == "files")):
+		if !method(http.MethodGet) {
+			return
+		}
+		s.mu.Lock()
+		c, ok := s.collections[parts[1]]
+		s.mu.Unlock()
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no collection %s", parts[1]))
+			return
+		}
+		status := s.getStatus(c)
+		if len(parts) == 2 {
+			writeAPIResponse(w, http.StatusOK, status)
+			return
+		}
+		name := parts[3]
+		for _, file := range status.Files {
+			if file == name {
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+				http.ServeFile(w, r, filepath.Join(c.dir, name))
+				return
+			}
+		}
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no file %s in collection %s", name, c.ID))
+	default:
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("no such endpoint: %s", r.URL.Path))
+	}
+}
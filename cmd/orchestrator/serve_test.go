@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectTargets(t *testing.T) {
+	targets := []targetFromFile{{label: "db1", ip: "10.0.0.1"}, {ip: "10.0.0.2"}, {label: "web1", ip: "10.0.0.3"}}
+	selected, err := selectTargets(targets, "web1, 10.0.0.2")
+	if err != nil || len(selected) != 2 || selected[0].ip != "10.0.0.2" || selected[1].label != "web1" {
+		t.Errorf("unexpected targets: %v, %v", selected, err)
+	}
+	if _, err = selectTargets(targets, "db1,db2"); err == nil || !strings.Contains(err.Error(), "db2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// newTestCollectionServer returns a collection server whose collections write a report
+func newTestCollectionServer(t *testing.T) (s *collectionServer, server *httptest.Server) {
+	dir := t.TempDir()
+	targets := filepath.Join(dir, "targets")
+	if err := os.WriteFile(targets, []byte("db1:10.0.0.1:22:user::password:\nweb1:10.0.0.2:22:user::password:\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s = newCollectionServer(dir, targets, 1)
+	s.runCollect = func(args []string, outputPath string) error {
+		output := args[len(args)-1]
+		return os.WriteFile(filepath.Join(output, "all_hosts.html"), []byte(strings.Join(args, " ")), 0644)
+	}
+	server = httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return
+}
+
+func TestCollectionsAPI(t *testing.T) {
+	s, server := newTestCollectionServer(t)
+	response, err := http.Get(server.URL + apiPrefix + "targets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var targets map[string][]string
+	json.NewDecoder(response.Body).Decode(&targets)
+	response.Body.Close()
+	if strings.Join(targets["targets"], ",") != "db1,web1" {
+		t.Errorf("unexpected targets: %v", targets)
+	}
+	// invalid requests
+	for _, body := range []string{`{"targets": ["db2"]}`, `{"format": "pdf"}`, `{"ip": "10.0.0.3"}`} {
+		response, err = http.Post(server.URL+apiPrefix+"collections", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", body, http.StatusBadRequest, response.StatusCode)
+		}
+	}
+	response, err = http.Post(server.URL+apiPrefix+"collections", "application/json", strings.NewReader(`{"targets": ["web1"], "format": "html"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c apiCollection
+	json.NewDecoder(response.Body).Decode(&c)
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted || c.ID == "" || response.Header.Get("Location") != apiPrefix+"collections/"+c.ID {
+		t.Fatalf("unexpected response: %d, %v", response.StatusCode, c)
+	}
+	// wait for the collection to finish
+	for i := 0; i < 100 && s.getStatus(s.collections[c.ID]).Status != collectionSucceeded; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	response, err = http.Get(server.URL + apiPrefix + "collections/" + c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(response.Body).Decode(&c)
+	response.Body.Close()
+	if c.Status != collectionSucceeded || strings.Join(c.Files, ",") != "all_hosts.html" {
+		t.Fatalf("unexpected collection: %v", c)
+	}
+	response, err = http.Get(server.URL + apiPrefix + "collections/" + c.ID + "/files/all_hosts.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if !strings.Contains(string(report), "-targets_select web1 -format html -output") {
+		t.Errorf("unexpected collect arguments: %s", report)
+	}
+	for _, path := range []string{"collections/" + c.ID + "/files/..%2Ftargets", "collections/nope", "nope"} {
+		if response, err = http.Get(server.URL + apiPrefix + path); err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: expected %d, got %d", path, http.StatusNotFound, response.StatusCode)
+		}
+	}
+}
+
+func TestCollectionsAPIToken(t *testing.T) {
+	s, server := newTestCollectionServer(t)
+	s.token = "secret"
+	response, err := http.Get(server.URL + apiPrefix + "collections")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, response.StatusCode)
+	}
+	request, _ := http.NewRequest(http.MethodGet, server.URL+apiPrefix+"collections", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	if response, err = http.DefaultClient.Do(request); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+}
+
+func TestServeRequiresTokenOnNonLoopback(t *testing.T) {
+	t.Setenv(apiTokenEnv, "")
+	dir := t.TempDir()
+	targets := filepath.Join(t.TempDir(), "targets")
+	if err := os.WriteFile(targets, []byte("db1:10.0.0.1:22:user::password:\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if exitCode := runServe("serve", []string{"-listen", "0.0.0.0:0", "-targets", targets, dir}); exitCode != retError {
+		t.Errorf("expected %d, got %d", retError, exitCode)
+	}
+	// the reports are also host data
+	if exitCode := runServe("serve", []string{"-listen", "0.0.0.0:0", dir}); exitCode != retError {
+		t.Errorf("expected %d, got %d", retError, exitCode)
+	}
+	for address, expected := range map[string]bool{"127.0.0.1:8080": true, "[::1]:8080": true, "0.0.0.0:8080": false, "[::]:8080": false, "10.0.0.1:8080": false} {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isLoopbackAddr(addr) != expected {
+			t.Errorf("%s : expected %t", address, expected)
+		}
+	}
+}
+
+func TestServeProtectsFiles(t *testing.T) {
+	dir := t.TempDir()
+	targets := filepath.Join(dir, "inventory", "targets")
+	if err := os.MkdirAll(filepath.Dir(targets), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(targets, []byte("db1:10.0.0.1:22:user::password:\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// the targets file, with its passwords, would be served
+	if exitCode := runServe("serve", []string{"-listen", "127.0.0.1:0", "-targets", targets, dir}); exitCode != retError {
+		t.Errorf("expected %d, got %d", retError, exitCode)
+	}
+	for path, expected := range map[string]bool{targets: true, dir: true, filepath.Join(dir, "..", "targets"): false, dir + "-old/targets": false} {
+		if isPathInDir(path, dir) != expected {
+			t.Errorf("%s : expected %t", path, expected)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "host1.raw.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(requireToken(http.FileServer(http.Dir(dir)), "secret"))
+	defer server.Close()
+	response, err := http.Get(server.URL + "/host1.raw.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, response.StatusCode)
+	}
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/host1.raw.json", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	if response, err = http.DefaultClient.Do(request); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+}
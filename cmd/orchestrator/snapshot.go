@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/target"
+	"github.com/intel/svr-info/internal/util"
+)
+
+// The snapshot command brackets a maintenance activity, e.g., a firmware update or a
+// kernel tuning change. 'snapshot pre' collects the configuration before the activity
+// and 'snapshot post -compare' collects it again after and reports only what changed.
+// Benchmarks, profiling, and analysis are never run so that the snapshots are quick.
+
+// snapshot stages, each is collected into a subdirectory of the snapshot directory
+const (
+	snapshotPre  = "pre"
+	snapshotPost = "post"
+)
+
+// snapshotFlags are the snapshot command's own flags, the others are passed to collect
+var snapshotFlags = []string{"dir", "compare", "format"}
+
+func runSnapshot(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var dir, format string
+	var compare bool
+	flagSet.StringVar(&dir, "dir", filepath.Base(os.Args[0])+"_snapshot", "directory containing the pre and post snapshots and the delta report")
+	flagSet.BoolVar(&compare, "compare", false, "post only, create the delta report from the pre and post snapshots")
+	flagSet.StringVar(&format, "format", "html,txt,json", "comma separated list of desired delta report format(s): html,txt,json")
+	// target flags, passed to collect
+	args := newCmdLineArgs()
+	flagSet.StringVar(&args.ipAddress, "ip", "", "IP address or hostname of the remote target")
+	flagSet.IntVar(&args.port, "port", 22, "SSH port of the remote target")
+	flagSet.StringVar(&args.user, "user", "", "user name on the remote target")
+	flagSet.StringVar(&args.key, "key", "", "path to the private SSH key for the remote target")
+	flagSet.StringVar(&args.targets, "targets", "", "path to a file containing the remote targets")
+	flagSet.BoolVar(&args.interactiveAuth, "interactive_auth", false, "prompt for passwords when the targets file and SSH keys don't provide them")
+	flagSet.StringVar(&args.transport, "transport", target.TransportSSH, "how to reach remote targets: "+strings.Join(target.Transports, ","))
+	flagSet.StringVar(&args.proxy, "proxy", "", "SOCKS5 or HTTP CONNECT proxy URL used to reach remote targets")
+	flagSet.StringVar(&args.auth, "auth", target.AuthDefault, "how to authenticate to remote targets: "+strings.Join(target.AuthMethods, ","))
+	flagSet.IntVar(&args.cmdTimeout, "cmd_timeout", 300, "the maximum number of seconds to wait for each data collection command")
+	flagSet.StringVar(&args.auditLog, "audit_log", "", "path to a file to which an NDJSON record of each command run on the targets is appended")
+	flagSet.BoolVar(&args.capabilities, "capabilities", false, "run annotated commands with only the Linux capabilities they need")
+	flagSet.BoolVar(&args.readOnly, "read_only", false, "skip the commands that have side effects on the targets")
+	flagSet.BoolVar(&args.debug, "debug", false, "keep the logs and temporary files")
+	if len(arguments) == 0 || (arguments[0] != snapshotPre && arguments[0] != snapshotPost) {
+		if len(arguments) > 0 && strings.HasPrefix(arguments[0], "-") {
+			if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+				return exitCode
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s : stage required, choose from: %s, %s\n", name, snapshotPre, snapshotPost)
+		return retError
+	}
+	stage := arguments[0]
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments[1:]); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(flagSet.Args(), " "))
+		return retError
+	}
+	if compare && stage != snapshotPost {
+		fmt.Fprintf(os.Stderr, "-compare : only valid with %s\n", snapshotPost)
+		return retError
+	}
+	reportTypes, err := getDeltaReportTypes(format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	dir, err = util.AbsPath(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	if stage == snapshotPost {
+		if _, err := getSnapshotFiles(dir, snapshotPre); err != nil {
+			fmt.Fprintf(os.Stderr, "%v, run '%s %s %s' before the maintenance activity\n", err, filepath.Base(os.Args[0]), name, snapshotPre)
+			return retError
+		}
+	}
+	stageDir := filepath.Join(dir, stage)
+	if _, err := getSnapshotFiles(dir, stage); err == nil {
+		fmt.Fprintf(os.Stderr, "%s : the %s snapshot already exists, remove it or choose another -dir\n", stageDir, stage)
+		return retError
+	}
+	if err := os.MkdirAll(stageDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	collectArgs := getSnapshotCollectArgs(flagSet, stageDir)
+	cmdLineArgs := newCmdLineArgs()
+	if err := cmdLineArgs.parse(os.Args[0], collectArgs); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	cmdLineArgs.keepRawData = true
+	if exitCode := runAppWithArgs(cmdLineArgs, (*App).doWork); exitCode != retNoError {
+		return exitCode
+	}
+	if !compare {
+		if stage == snapshotPre {
+			fmt.Printf("After the maintenance activity, run '%s %s %s -compare -dir %s' with the same target arguments.\n", filepath.Base(os.Args[0]), name, snapshotPost, dir)
+		}
+		return retNoError
+	}
+	var inputs []string
+	for _, s := range []string{snapshotPre, snapshotPost} {
+		files, err := getSnapshotFiles(dir, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return retError
+		}
+		inputs = append(inputs, files...)
+	}
+	fmt.Print("Delta:\n")
+	return runReporter([]string{"-input", strings.Join(inputs, ","), "-output", dir, "-format", strings.Join(reportTypes, ","), "-delta"})
+}
+
+// getDeltaReportTypes validates the delta report formats
+func getDeltaReportTypes(format string) (reportTypes []string, err error) {
+	for _, reportType := range strings.Split(format, ",") {
+		reportType = strings.TrimSpace(reportType)
+		if !util.StringInList(reportType, []string{"html", "txt", "json"}) {
+			err = fmt.Errorf("-format %s : unsupported delta report format, choose from: html,txt,json", reportType)
+			return
+		}
+		reportTypes = append(reportTypes, reportType)
+	}
+	return
+}
+
+// getSnapshotCollectArgs returns the collect arguments for a snapshot, i.e., the target
+// flags that were set plus the snapshot's output directory. The default collection
+// doesn't run benchmarks, profiling, or analysis.
+func getSnapshotCollectArgs(flagSet *flag.FlagSet, stageDir string) (collectArgs []string) {
+	collectArgs = []string{"-output", stageDir, "-format", "json"}
+	flagSet.Visit(func(f *flag.Flag) {
+		if !util.StringInList(f.Name, snapshotFlags) {
+			collectArgs = append(collectArgs, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return
+}
+
+// getSnapshotFiles returns the paths to the collected data in a snapshot
+func getSnapshotFiles(dir string, stage string) (files []string, err error) {
+	for _, format := range rawFormats {
+		var matches []string
+		matches, err = filepath.Glob(filepath.Join(dir, stage, "*"+getRawFileExtension(format)))
+		if err != nil {
+			return
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		err = fmt.Errorf("%s : no %s snapshot found", filepath.Join(dir, stage), stage)
+	}
+	return
+}
@@ -0,0 +1,665 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/intel/svr-info/internal/core"
+	"github.com/intel/svr-info/internal/target"
+	"github.com/intel/svr-info/internal/util"
+)
+
+// Subcommand is selected by the first command line argument, e.g., 'svr-info report'.
+// When the first argument is a flag, the collect subcommand is used so that existing
+// command lines continue to work.
+type Subcommand struct {
+	name        string
+	usage       string // arguments shown in the usage message
+	description string
+	run         func(name string, arguments []string) int
+}
+
+func getSubcommands() []Subcommand {
+	return []Subcommand{
+		{"collect", "[flags]", "collect data from local or remote systems and create reports (default)", runCollect},
+		{"report", "-input FILES [-format SELECT] [-output DIR] [-highlight RULES] [-microcode TABLE] [-instance_types TABLE] [-health_weights WEIGHTS] [-reference RESULTS] [-reference_threshold PERCENT] [-remediation] [-filter EXPRESSION]", "create reports from previously collected data, i.e., *.raw.json files or archives of output directories", runReport},
+		{"diff", "[-format SELECT] [-output DIR] FILE FILE... | OLD.tgz NEW.tgz | -baseline PATH INPUT...", "create reports that compare two or more systems side by side, or report the configuration drift, firmware updates, kernel parameter changes, and benchmark changes since a previous collection", runDiff},
+		{"query", "-input FILES [-format csv|json] [-filter EXPRESSION] PATH...", "print selected values, e.g., 'Operating System.Kernel' 'CPU.Microcode', for every host in previously collected data", runQuery},
+		{"check", "[-ip IP -user USER [-port PORT] [-key KEY] | -targets TARGETS | -targets_from PROVIDER -user USER] [-transport SELECT] [-proxy URL] [-jump_host HOST] [-os SELECT]", "verify that targets are reachable and that elevated privileges are available, no data is collected", runCheck},
+		{"serve", "[-listen ADDRESS | -address ADDRESS -port PORT] [-targets TARGETS] [-max_collections N] [DIR]", "serve the reports in an output directory over HTTP, and with -targets, a REST API that runs collections on demand", runServe},
+		{"fetch", "[collect target flags] [-format SELECT] [-output DIR]", "retrieve the data from detached collections (collect -detach) that have finished and create reports", runFetch},
+		{"snapshot", "pre|post [-compare] [-dir DIR] [-format SELECT] [collect target flags]", "collect quick configuration snapshots before and after a maintenance activity, then report only what changed", runSnapshot},
+		{"selftest", "[-output DIR] [-keep] [-cmd_timeout SECONDS]", "collect from localhost, create every report format, and check the results, to verify a build or an operator machine before a fleet run", runSelftest},
+		{"fixtures", "[-output DIR] [-profile LIST] [-format SELECT] [-list]", "generate the command outputs of unusual hardware, e.g., 8-socket systems, odd DIMM populations, and exotic NICs, create their reports, and check that the reports describe the hardware", runFixtures},
+		{"import", "[-output DIR] [-format SELECT] ARCHIVE...", "add the data in archives of previous runs, e.g., made by the package command, to the daemon's output directory, so that it's included in the report history and in reports, diffs, and queries of the directory", runImport},
+		{"package", "[-output FILE] DIR", "archive an output directory into a gzipped tarball for sharing", runPackage},
+		{"init", "", "answer a few questions to configure a run, write a targets file if collecting from remote systems, then optionally start the run", runInit},
+		{"completion", "bash|zsh|fish", "print the completion script for the shell", runCompletion},
+	}
+}
+
+func getSubcommandNames() (names []string) {
+	for _, subcommand := range getSubcommands() {
+		names = append(names, subcommand.name)
+	}
+	return
+}
+
+// runSubcommand runs the subcommand named by the first argument, or collect if the first
+// argument is a flag
+func runSubcommand(arguments []string) int {
+	if len(arguments) == 0 || strings.HasPrefix(arguments[0], "-") {
+		return runCollect("collect", arguments)
+	}
+	for _, subcommand := range getSubcommands() {
+		if subcommand.name == arguments[0] {
+			return subcommand.run(subcommand.name, arguments[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unrecognized command: %s, choose from: %s\n", arguments[0], strings.Join(getSubcommandNames(), ", "))
+	return retError
+}
+
+// gCaptureFlagSet, when set, receives the subcommand's flag set, and the subcommand
+// returns without parsing its arguments, see getSubcommandFlagSet
+var gCaptureFlagSet func(flagSet *flag.FlagSet)
+
+// getSubcommandFlagSet returns the flag set of a subcommand that uses
+// newSubcommandFlagSet and parseSubcommandArgs, or nil if it has none
+func getSubcommandFlagSet(subcommand Subcommand) (flagSet *flag.FlagSet) {
+	gCaptureFlagSet = func(f *flag.FlagSet) { flagSet = f }
+	defer func() { gCaptureFlagSet = nil }()
+	subcommand.run(subcommand.name, []string{"-h"})
+	return
+}
+
+// newSubcommandFlagSet returns a flag set whose usage message describes the subcommand
+func newSubcommandFlagSet(name string) (flagSet *flag.FlagSet) {
+	flagSet = flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.Usage = func() {
+		for _, subcommand := range getSubcommands() {
+			if subcommand.name == name {
+				fmt.Fprintf(os.Stderr, "usage: %s %s %s\n\n", filepath.Base(os.Args[0]), name, subcommand.usage)
+				fmt.Fprintf(os.Stderr, "%s\n\n", subcommand.description)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "arguments:\n")
+		flagSet.PrintDefaults()
+	}
+	if gCaptureFlagSet != nil {
+		gCaptureFlagSet(flagSet)
+	}
+	return
+}
+
+// parseSubcommandArgs parses the arguments, returning done if help was requested or the
+// arguments are invalid
+func parseSubcommandArgs(flagSet *flag.FlagSet, arguments []string) (done bool, exitCode int) {
+	if gCaptureFlagSet != nil {
+		return true, retNoError
+	}
+	err := flagSet.Parse(arguments)
+	if err == flag.ErrHelp {
+		return true, retNoError
+	}
+	if err != nil {
+		return true, retError
+	}
+	return false, retNoError
+}
+
+// runReporter runs the reporter that is embedded in the orchestrator
+func runReporter(reporterArgs []string) int {
+	tempDir, err := os.MkdirTemp("", fmt.Sprintf("%s.tmp.", filepath.Base(os.Args[0])))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	defer os.RemoveAll(tempDir)
+	app := newApp(newCmdLineArgs(), "", tempDir)
+	err = app.writeExecutableResources()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	cmd := exec.Command(filepath.Join(tempDir, "reporter"), reporterArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	return retNoError
+}
+
+// reportFlags are the flags shared by the report and diff subcommands
+type reportFlags struct {
+	format        string
+	output        string
+	highlight     string
+	microcode     string
+	instanceTypes string
+	healthWeights string
+	reference     string
+	remediation   bool
+	threshold     float64
+	refThreshold  float64
+	txtWidth      int
+	wide          bool
+	narrow        bool
+	txtSections   string
+	filter        string
+}
+
+func (r *reportFlags) define(flagSet *flag.FlagSet, defaultFormat string) {
+	flagSet.StringVar(&r.format, "format", defaultFormat, "comma separated list of desired output format(s): "+strings.Join(core.ReportTypes, ","))
+	flagSet.StringVar(&r.output, "output", ".", "output directory")
+	flagSet.StringVar(&r.highlight, "highlight", "", "path to YAML file containing rules for highlighting values in the HTML and xlsx reports")
+	flagSet.StringVar(&r.microcode, "microcode", "", "path to YAML file containing current microcode revisions")
+	flagSet.StringVar(&r.instanceTypes, "instance_types", "", "path to YAML file containing cloud instance type specifications")
+	flagSet.StringVar(&r.healthWeights, "health_weights", "", "path to YAML file containing the weights of the host health grade")
+	flagSet.StringVar(&r.reference, "reference", "", "path to JSON file containing reference benchmark results that the targets' results are compared to")
+	flagSet.BoolVar(&r.remediation, "remediation", false, "write a script per target containing the commands that implement the insights' recommendations")
+	flagSet.Float64Var(&r.threshold, "regression_threshold", 5, "percent change in a benchmark metric, between runs of the same target, that is reported as a regression")
+	flagSet.Float64Var(&r.refThreshold, "reference_threshold", 5, "percent by which a benchmark metric can be worse than the -reference result before it's listed as below the reference")
+	flagSet.IntVar(&r.txtWidth, "txt_width", 0, "width, in characters, of the txt report's tables (default: 120)")
+	flagSet.BoolVar(&r.wide, "wide", false, "format the txt report for wide terminals")
+	flagSet.BoolVar(&r.narrow, "narrow", false, "format the txt report for narrow terminals")
+	flagSet.StringVar(&r.filter, "filter", "", "include only the hosts whose tags match the expression, e.g., 'env=prod and rack!=12'")
+	flagSet.StringVar(&r.txtSections, "txt_sections", "", "comma separated list of sections to include in the txt report: brief, configuration, performance, profile, insights, commands (default: all)")
+}
+
+// reporterArgs returns the reporter arguments, converting paths to absolute paths because
+// the reporter requires them
+func (r *reportFlags) reporterArgs(inputs []string) (args []string, err error) {
+	var inputPaths []string
+	for _, input := range inputs {
+		var path string
+		if path, err = util.AbsPath(input); err != nil {
+			return
+		}
+		inputPaths = append(inputPaths, path)
+	}
+	output, err := util.AbsPath(r.output)
+	if err != nil {
+		return
+	}
+	args = []string{"-input", strings.Join(inputPaths, ","), "-output", output, "-format", r.format}
+	for _, option := range []struct{ name, path string }{{"highlight", r.highlight}, {"microcode", r.microcode}, {"instance_types", r.instanceTypes}, {"health_weights", r.healthWeights}, {"reference", r.reference}} {
+		if option.path != "" {
+			var path string
+			if path, err = util.AbsPath(option.path); err != nil {
+				return
+			}
+			args = append(args, "-"+option.name, path)
+		}
+	}
+	if r.reference != "" {
+		args = append(args, "-reference_threshold", strconv.FormatFloat(r.refThreshold, 'f', -1, 64))
+	}
+	if r.remediation {
+		args = append(args, "-remediation")
+	}
+	args = append(args, "-regression_threshold", strconv.FormatFloat(r.threshold, 'f', -1, 64))
+	if r.txtWidth != 0 {
+		args = append(args, "-txt_width", strconv.Itoa(r.txtWidth))
+	}
+	if r.wide {
+		args = append(args, "-wide")
+	}
+	if r.narrow {
+		args = append(args, "-narrow")
+	}
+	if r.txtSections != "" {
+		args = append(args, "-txt_sections", r.txtSections)
+	}
+	if r.filter != "" {
+		args = append(args, "-filter", r.filter)
+	}
+	return
+}
+
+func runReport(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var flags reportFlags
+	var input string
+	flagSet.StringVar(&input, "input", "", "required, comma separated list of input files, directories containing input (*.raw.json, *.raw.gob) files, or archives (*.tgz) of output directories")
+	flags.define(flagSet, "html,xlsx,json")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(flagSet.Args(), " "))
+		return retError
+	}
+	if input == "" {
+		fmt.Fprintf(os.Stderr, "-input : input file list or directory is required\n")
+		return retError
+	}
+	reporterArgs, err := flags.reporterArgs(strings.Split(input, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	return runReporter(reporterArgs)
+}
+
+func runDiff(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var flags reportFlags
+	var baseline string
+	flags.define(flagSet, "html")
+	flagSet.StringVar(&baseline, "baseline", "", "comma separated list of input files, directories, or archives (*.tgz) of a previous collection, report only the changes in the inputs since then, in the html, txt, or json format")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	baseline, inputs := getDiffBaseline(baseline, flagSet.Args())
+	if baseline == "" && len(inputs) < 2 {
+		fmt.Fprintf(os.Stderr, "%s : two or more input (*.raw.json) files are required\n", name)
+		return retError
+	}
+	if baseline != "" && len(inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "%s : one or more inputs to compare to the baseline are required\n", name)
+		return retError
+	}
+	if baseline != "" {
+		if _, err := getDeltaReportTypes(flags.format); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return retError
+		}
+	}
+	reporterArgs, err := flags.reporterArgs(inputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	if baseline != "" {
+		var baselinePaths []string
+		for _, path := range strings.Split(baseline, ",") {
+			if path, err = util.AbsPath(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return retError
+			}
+			baselinePaths = append(baselinePaths, path)
+		}
+		reporterArgs = append(reporterArgs, "-baseline", strings.Join(baselinePaths, ","))
+	}
+	exitCode := runReporter(reporterArgs)
+	if exitCode == retNoError {
+		if baseline != "" {
+			fmt.Printf("The delta reports list the changes since the baseline: firmware, kernel parameters, other configuration, and benchmark results.\n")
+		} else {
+			fmt.Printf("The all_hosts reports compare the systems side by side, the comparison reports highlight the fields that differ.\n")
+		}
+	}
+	return exitCode
+}
+
+// getDiffBaseline returns the baseline and the inputs to compare to it. Two archives,
+// e.g., 'diff old.tgz new.tgz', are a baseline and a new collection.
+func getDiffBaseline(baseline string, args []string) (string, []string) {
+	if baseline == "" && len(args) == 2 && isArchive(args[0]) && isArchive(args[1]) {
+		return args[0], args[1:]
+	}
+	return baseline, args
+}
+
+// isArchive returns true if the path is named like a gzipped tarball
+func isArchive(path string) bool {
+	return strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz")
+}
+
+func runQuery(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var input, format, filter string
+	flagSet.StringVar(&input, "input", "", "required, comma separated list of input files, directories containing input (*.raw.json, *.raw.gob) files, or archives (*.tgz) of output directories")
+	flagSet.StringVar(&format, "format", "csv", "output format: csv, json")
+	flagSet.StringVar(&filter, "filter", "", "include only the hosts whose tags match the expression, e.g., 'env=prod and rack!=12'")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if input == "" {
+		fmt.Fprintf(os.Stderr, "-input : input file list or directory is required\n")
+		return retError
+	}
+	if flagSet.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "%s : one or more paths are required, e.g., '.Configuration.CPU.Microcode'\n", name)
+		return retError
+	}
+	var inputPaths []string
+	for _, path := range strings.Split(input, ",") {
+		absPath, err := util.AbsPath(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+		inputPaths = append(inputPaths, absPath)
+	}
+	// the reporter's log is written to the output directory, it isn't kept
+	outputDir, err := os.MkdirTemp("", fmt.Sprintf("%s.tmp.", filepath.Base(os.Args[0])))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	defer os.RemoveAll(outputDir)
+	reporterArgs := []string{"-input", strings.Join(inputPaths, ","), "-output", outputDir, "-query", strings.Join(flagSet.Args(), ","), "-query_format", format}
+	if filter != "" {
+		reporterArgs = append(reporterArgs, "-filter", filter)
+	}
+	return runReporter(reporterArgs)
+}
+
+func runCheck(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	args := newCmdLineArgs()
+	flagSet.StringVar(&args.ipAddress, "ip", "", "IP address or hostname of the remote target")
+	flagSet.IntVar(&args.port, "port", 22, "SSH port of the remote target")
+	flagSet.StringVar(&args.user, "user", "", "user name on the remote target")
+	flagSet.StringVar(&args.key, "key", "", "path to the private SSH key for the remote target")
+	flagSet.StringVar(&args.targets, "targets", "", "path to a file containing the remote targets")
+	flagSet.StringVar(&args.targetsCmd, "targets_cmd", "", "command that writes the remote targets to stdout in JSON")
+	flagSet.StringVar(&args.targetsFrom, "targets_from", "", "cloud provider whose running instances are the remote targets: "+strings.Join(cloudProviders, ","))
+	flagSet.StringVar(&args.targetsFilter, "targets_filter", "", "comma separated list of KEY=VALUE tags that the -targets_from instances must have")
+	flagSet.StringVar(&args.targetsRegion, "targets_region", "", "region of the -targets_from instances")
+	flagSet.StringVar(&args.targetsAddress, "targets_address", cloudAddressPrivate, "address of the -targets_from instances to connect to: "+strings.Join(cloudAddresses, ","))
+	flagSet.StringVar(&args.transport, "transport", target.TransportSSH, "how to reach remote targets: "+strings.Join(target.Transports, ","))
+	flagSet.StringVar(&args.proxy, "proxy", "", "SOCKS5 or HTTP CONNECT proxy URL used to reach remote targets")
+	flagSet.StringVar(&args.jumpHost, "jump_host", "", "SSH jump host, [USER@]HOST[:PORT], used to reach remote targets")
+	flagSet.StringVar(&args.auth, "auth", target.AuthDefault, "how to authenticate to remote targets: "+strings.Join(target.AuthMethods, ","))
+	flagSet.StringVar(&args.targetOS, "os", targetOSLinux, "operating system of the remote targets: "+strings.Join(targetOSTypes, ","))
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(flagSet.Args(), " "))
+		return retError
+	}
+	if !util.StringInList(args.targetOS, targetOSTypes) {
+		fmt.Fprintf(os.Stderr, "-os %s : invalid operating system, choose from: %s\n", args.targetOS, strings.Join(targetOSTypes, ","))
+		return retError
+	}
+	if args.targetsFrom != "" {
+		if !util.StringInList(args.targetsFrom, cloudProviders) {
+			fmt.Fprintf(os.Stderr, "-targets_from %s : invalid cloud provider, choose from: %s\n", args.targetsFrom, strings.Join(cloudProviders, ","))
+			return retError
+		}
+		if args.ipAddress != "" || args.targets != "" || args.targetsCmd != "" || args.user == "" {
+			fmt.Fprintf(os.Stderr, "-targets_from requires -user and is mutually exclusive with -ip, -targets, and -targets_cmd\n")
+			return retError
+		}
+	} else if (args.ipAddress == "") != (args.user == "") {
+		fmt.Fprintf(os.Stderr, "-ip and -user are required together\n")
+		return retError
+	}
+	if args.targetsCmd != "" && (args.ipAddress != "" || args.targets != "") {
+		fmt.Fprintf(os.Stderr, "-targets_cmd is mutually exclusive with -ip and -targets\n")
+		return retError
+	}
+	tempDir, err := os.MkdirTemp("", fmt.Sprintf("%s.tmp.", filepath.Base(os.Args[0])))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	defer os.RemoveAll(tempDir)
+	log.SetOutput(io.Discard)
+	app := newApp(args, "", tempDir)
+	if err = app.writeExecutableResources(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	targets, err := app.getTargets()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	defer closeTargetConnections(targets)
+	exitCode := retNoError
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Target\tConnect\tArchitecture\tElevated Privileges\n")
+	for _, t := range targets {
+		connect, arch, elevated := checkTarget(t)
+		if connect != "OK" || elevated != "OK" {
+			exitCode = retError
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.GetName(), connect, arch, elevated)
+	}
+	w.Flush()
+	return exitCode
+}
+
+// checkTarget returns the status of the connection to the target, its architecture, and
+// whether elevated privileges are available. Data is collected without elevated
+// privileges, but some data items will be missing.
+func checkTarget(t target.Target) (connect string, arch string, elevated string) {
+	if !t.CanConnect() {
+		return "Failed", "", ""
+	}
+	connect = "OK"
+	arch, err := t.GetArchitecture()
+	if err != nil {
+		arch = "Unknown"
+	}
+	elevated = "Not Available"
+	if canElevatePrivileges(t) {
+		elevated = "OK"
+	}
+	return
+}
+
+func runServe(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var address, listen, targets string
+	var port, maxCollections int
+	flagSet.StringVar(&address, "address", "localhost", "address to listen on, e.g., 0.0.0.0 to allow connections from other systems, which requires "+apiTokenEnv)
+	flagSet.IntVar(&port, "port", 8080, "port to listen on")
+	flagSet.StringVar(&listen, "listen", "", "address and port to listen on, e.g., :8080 for all addresses, overrides -address and -port")
+	flagSet.StringVar(&targets, "targets", "", "targets file, enables the REST API, at "+apiPrefix+", that runs collections from these targets on demand")
+	flagSet.IntVar(&maxCollections, "max_collections", 1, "the number of API collections that run at once, the others wait")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if listen == "" {
+		listen = fmt.Sprintf("%s:%d", address, port)
+	}
+	if maxCollections < 1 {
+		fmt.Fprintf(os.Stderr, "-max_collections %d : must be at least 1\n", maxCollections)
+		return retError
+	}
+	if flagSet.NArg() > 1 {
+		fmt.Fprintf(os.Stderr, "%s : one directory is required\n", name)
+		return retError
+	}
+	dir := "."
+	if flagSet.NArg() == 1 {
+		dir = flagSet.Arg(0)
+	}
+	if err := argDirExists(dir, "DIR"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	// the served directory includes the hosts' data, e.g., raw.json files
+	token := os.Getenv(apiTokenEnv)
+	mux := http.NewServeMux()
+	mux.Handle("/", requireToken(http.FileServer(http.Dir(dir)), token))
+	if targets != "" {
+		path, err := argFileReadable(targets, "targets")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return retError
+		}
+		// the targets file includes the targets' passwords
+		if isPathInDir(path, dir) {
+			fmt.Fprintf(os.Stderr, "-targets %s : must not be in the served directory, %s\n", targets, dir)
+			return retError
+		}
+		if _, err = newTargetsFile(path).parse(); err != nil {
+			fmt.Fprintf(os.Stderr, "-targets %s : %v\n", path, err)
+			return retError
+		}
+		absDir, err := util.AbsPath(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return retError
+		}
+		mux.Handle(apiPrefix, newCollectionServer(absDir, path, maxCollections))
+	}
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	if token == "" {
+		// anyone who can connect could read the hosts' data and, with -targets, run
+		// collections with the targets' credentials
+		if !isLoopbackAddr(listener.Addr()) {
+			listener.Close()
+			fmt.Fprintf(os.Stderr, "%s is required to serve %s on %s, or listen on a loopback address, e.g., localhost\n", apiTokenEnv, dir, listener.Addr().String())
+			return retError
+		}
+		if targets != "" {
+			fmt.Printf("WARNING: %s isn't set, anyone who can connect to %s can run collections\n", apiTokenEnv, listener.Addr().String())
+		}
+	}
+	fmt.Printf("Serving %s at http://%s/, press Ctrl-C to stop.\n", dir, listener.Addr().String())
+	if targets != "" {
+		fmt.Printf("Collections API at http://%s%s\n", listener.Addr().String(), apiPrefix)
+	}
+	err = http.Serve(listener, mux)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	return retNoError
+}
+
+func runPackage(name string, arguments []string) int {
+	flagSet := newSubcommandFlagSet(name)
+	var output string
+	flagSet.StringVar(&output, "output", "", "path to the archive (default: DIR.tgz)")
+	if done, exitCode := parseSubcommandArgs(flagSet, arguments); done {
+		return exitCode
+	}
+	if flagSet.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "%s : one directory is required\n", name)
+		return retError
+	}
+	dir := filepath.Clean(flagSet.Arg(0))
+	if err := argDirExists(dir, "DIR"); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	if output == "" {
+		output = dir + ".tgz"
+	}
+	if err := packageDir(dir, output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	fmt.Printf("Package:\n  %s\n", output)
+	return retNoError
+}
+
+// packageDir writes the files in dir to a gzipped tarball. Paths in the tarball start
+// with the directory's name.
+func packageDir(dir string, tarFilePath string) (err error) {
+	tarFileAbsPath, err := util.AbsPath(tarFilePath)
+	if err != nil {
+		return
+	}
+	out, err := os.Create(tarFilePath)
+	if err != nil {
+		return
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		// the tarball may be written inside the directory
+		if absPath, err := util.AbsPath(path); err == nil && absPath == tarFileAbsPath {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(filepath.Base(dir), relativePath))
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	return
+}
+
+func runInit(name string, arguments []string) int {
+	if len(arguments) > 0 {
+		fmt.Fprintf(os.Stderr, "%s : unrecognized argument(s): %s\n", name, strings.Join(arguments, " "))
+		return retError
+	}
+	collectArgs, start, err := newWizard(os.Stdin, os.Stdout).run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return retError
+	}
+	if !start {
+		return retNoError
+	}
+	return runCollect("collect", collectArgs)
+}
+
+func runCompletion(name string, arguments []string) int {
+	if len(arguments) != 1 {
+		fmt.Fprintf(os.Stderr, "%s : shell required, choose from: %s\n", name, strings.Join(completionShells, ", "))
+		return retError
+	}
+	script, err := getCompletionScript(arguments[0], filepath.Base(os.Args[0]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return retError
+	}
+	fmt.Print(script)
+	return retNoError
+}
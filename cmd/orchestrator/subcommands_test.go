@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestUnrecognizedSubcommand(t *testing.T) {
+	if runSubcommand([]string{"bogus"}) != retError {
+		t.Error("expected error for unrecognized command")
+	}
+}
+
+func TestSubcommandHelp(t *testing.T) {
+	for _, name := range []string{"report", "diff", "query", "check", "serve", "package", "snapshot"} {
+		if runSubcommand([]string{name, "-h"}) != retNoError {
+			t.Errorf("expected no error for %s help", name)
+		}
+	}
+}
+
+func TestDiffRequiresTwoInputs(t *testing.T) {
+	if runSubcommand([]string{"diff", "host1.raw.json"}) != retError {
+		t.Error("expected error for one input")
+	}
+}
+
+func TestDiffBaseline(t *testing.T) {
+	baseline, inputs := getDiffBaseline("", []string{"old.tgz", "new.tar.gz"})
+	if baseline != "old.tgz" || strings.Join(inputs, ",") != "new.tar.gz" {
+		t.Errorf("unexpected baseline %s and inputs %v", baseline, inputs)
+	}
+	baseline, inputs = getDiffBaseline("", []string{"host1.raw.json", "host2.raw.json"})
+	if baseline != "" || len(inputs) != 2 {
+		t.Errorf("unexpected baseline %s and inputs %v", baseline, inputs)
+	}
+	baseline, inputs = getDiffBaseline("old", []string{"new.tgz"})
+	if baseline != "old" || strings.Join(inputs, ",") != "new.tgz" {
+		t.Errorf("unexpected baseline %s and inputs %v", baseline, inputs)
+	}
+	if runSubcommand([]string{"diff", "-baseline", "old.tgz"}) != retError {
+		t.Error("expected error for baseline without inputs")
+	}
+	if runSubcommand([]string{"diff", "-format", "xlsx", "old.tgz", "new.tgz"}) != retError {
+		t.Error("expected error for unsupported delta format")
+	}
+}
+
+func TestSnapshotRequiresStage(t *testing.T) {
+	if runSubcommand([]string{"snapshot"}) != retError {
+		t.Error("expected error for missing stage")
+	}
+	if runSubcommand([]string{"snapshot", "during"}) != retError {
+		t.Error("expected error for unrecognized stage")
+	}
+	if runSubcommand([]string{"snapshot", "pre", "-compare"}) != retError {
+		t.Error("expected error for -compare with pre")
+	}
+	if runSubcommand([]string{"snapshot", "post", "-format", "xlsx"}) != retError {
+		t.Error("expected error for unsupported delta format")
+	}
+}
+
+func TestSnapshotPostRequiresPre(t *testing.T) {
+	dir := t.TempDir()
+	if runSubcommand([]string{"snapshot", "post", "-dir", dir}) != retError {
+		t.Error("expected error for missing pre snapshot")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "post")); err == nil {
+		t.Error("expected post snapshot to not be started")
+	}
+}
+
+func TestSnapshotCollectArgs(t *testing.T) {
+	flagSet := newSubcommandFlagSet("snapshot")
+	var dir string
+	var compare, readOnly bool
+	var user string
+	flagSet.StringVar(&dir, "dir", "", "")
+	flagSet.BoolVar(&compare, "compare", false, "")
+	flagSet.BoolVar(&readOnly, "read_only", false, "")
+	flagSet.StringVar(&user, "user", "", "")
+	if err := flagSet.Parse([]string{"-compare", "-dir", "/tmp/snap", "-user", "rex", "-read_only"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "-output /tmp/snap/post -format json -read_only=true -user=rex"
+	if got := strings.Join(getSnapshotCollectArgs(flagSet, "/tmp/snap/post"), " "); got != expected {
+		t.Errorf("expected '%s', got '%s'", expected, got)
+	}
+}
+
+func TestQueryRequiresInputAndPaths(t *testing.T) {
+	if runSubcommand([]string{"query", "CPU.Microcode"}) != retError {
+		t.Error("expected error for missing input")
+	}
+	if runSubcommand([]string{"query", "-input", "host1.raw.json"}) != retError {
+		t.Error("expected error for missing paths")
+	}
+}
+
+func TestReporterArgs(t *testing.T) {
+	flags := reportFlags{format: "json", output: "/tmp/out", reference: "/tmp/reference.json", remediation: true, threshold: 2.5, refThreshold: 10}
+	args, err := flags.reporterArgs([]string{"/tmp/a.raw.json", "/tmp/b.raw.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-input /tmp/a.raw.json,/tmp/b.raw.json -output /tmp/out -format json -reference /tmp/reference.json -reference_threshold 10 -remediation -regression_threshold 2.5"
+	if strings.Join(args, " ") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(args, " "))
+	}
+}
+
+func TestReporterArgsTxt(t *testing.T) {
+	flags := reportFlags{format: "txt", output: "/tmp/out", threshold: 5, narrow: true, txtSections: "brief,insights"}
+	args, err := flags.reporterArgs([]string{"/tmp/a.raw.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "-input /tmp/a.raw.json -output /tmp/out -format txt -regression_threshold 5 -narrow -txt_sections brief,insights"
+	if strings.Join(args, " ") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(args, " "))
+	}
+}
+
+func TestPackageDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "output")
+	err := os.MkdirAll(filepath.Join(dir, "host_megadata"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"host.html", filepath.Join("host_megadata", "mpstat.txt")} {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the archive is written inside the directory, it must not include itself
+	tarFilePath := filepath.Join(dir, "output.tgz")
+	if err = packageDir(dir, tarFilePath); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(tarFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	expected := "output/host.html,output/host_megadata/mpstat.txt"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(names, ","))
+	}
+}
+
+func TestArchiveOutputDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "output")
+	for _, name := range []string{"host.html", "host.raw.json", "host_collector.log", "notes.txt", filepath.Join("host_benchmark", "mlc_bandwidth.txt"), filepath.Join("host_megadata", "mpstat.txt")} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collections := []*Collection{newCollection(target.NewLocalTarget("host", ""), &CmdLineArgs{}, dir, t.TempDir())}
+	// the reporter lists the host's benchmark directory with its reports
+	if err := archiveOutputDir(dir, collections, []string{filepath.Join(dir, "host.html"), filepath.Join(dir, "host_benchmark")}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "output.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+	expected := "output/host.html,output/host.raw.json,output/host_benchmark/mlc_bandwidth.txt,output/host_collector.log"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(names, ","))
+	}
+}
+
+func TestReporterArgsFilter(t *testing.T) {
+	flags := reportFlags{format: "html", output: "/tmp/out", threshold: 5, filter: "env=prod and rack!=12"}
+	args, err := flags.reporterArgs([]string{"/tmp/run.tgz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[len(args)-2] != "-filter" || args[len(args)-1] != "env=prod and rack!=12" {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
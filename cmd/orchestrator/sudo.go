@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/target"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+)
+
+// The sudo passwords in the targets file are verified before collection starts. When
+// one fails and svr-info is run in a terminal, the user is prompted for the target's sudo
+// password, up to sudoPasswordAttempts times, rather than collecting without elevated
+// privileges. A failed password isn't passed to the collector, so that it doesn't count
+// against the target's limit of failed sudo attempts.
+
+// sudoPasswordAttempts is the number of times the user is prompted for a target's sudo
+// password, as with sudo
+const sudoPasswordAttempts = 3
+
+// passwordReader prompts for and reads a password, it's nil if there's no terminal
+type passwordReader func(prompt string) (string, error)
+
+// readPasswordFromTerminal prompts for and reads a password from the terminal without
+// echoing it
+func readPasswordFromTerminal(prompt string) (password string, err error) {
+	fmt.Fprint(messages, prompt)
+	pwd, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintf(messages, "\n") // newline after password
+	password = string(pwd)
+	return
+}
+
+// canElevatePrivileges returns true if commands can be run with elevated privileges on
+// the target
+func canElevatePrivileges(t target.Target) bool {
+	elevator, ok := t.(interface{ CanElevatePrivileges() bool })
+	return ok && elevator.CanElevatePrivileges()
+}
+
+// checkElevatedPrivileges returns whether elevated privileges are available on each of
+// the targets, checked in parallel, no more than maxParallel at a time if it's set
+func checkElevatedPrivileges(targets []target.Target, maxParallel int) (elevated []bool) {
+	elevated = make([]bool, len(targets))
+	limit := newParallelLimit(maxParallel)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target.Target) {
+			defer wg.Done()
+			limit.run(func() { elevated[i] = canElevatePrivileges(t) }, func() {})
+		}(i, t)
+	}
+	wg.Wait()
+	return
+}
+
+// verifySudoPasswords verifies the sudo passwords of the remote targets, prompting for
+// those that fail if svr-info is run in a terminal
+func (app *App) verifySudoPasswords(targets []target.Target) (err error) {
+	var readPassword passwordReader
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		readPassword = readPasswordFromTerminal
+	}
+	var remoteTargets []*target.RemoteTarget
+	for _, t := range targets {
+		if remoteTarget, ok := t.(*target.RemoteTarget); ok && remoteTarget.GetSudo() != "" {
+			remoteTargets = append(remoteTargets, remoteTarget)
+		}
+	}
+	// check the passwords at once, then prompt for those that failed one at a time
+	var checked []target.Target
+	for _, remoteTarget := range remoteTargets {
+		checked = append(checked, remoteTarget)
+	}
+	verified := checkElevatedPrivileges(checked, app.args.maxParallel)
+	for i, remoteTarget := range remoteTargets {
+		if verified[i] {
+			continue
+		}
+		args := app.args
+		if targetArgs, ok := app.targetArgs[remoteTarget.GetName()]; ok {
+			args = targetArgs
+		}
+		if err = verifySudoPassword(remoteTarget, readPassword, args.requireRoot); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// verifySudoPassword prompts for the target's sudo password, whose password failed, until
+// one works or there have been sudoPasswordAttempts attempts. If none works, the target's
+// sudo password is cleared, and the target's collection will fail if requireRoot is set.
+func verifySudoPassword(t *target.RemoteTarget, readPassword passwordReader, requireRoot bool) (err error) {
+	log.Printf("sudo password failed for %s", t.GetName())
+	if readPassword == nil {
+		log.Print("NOT prompting for sudo password because STDIN isn't coming from a terminal.")
+	} else {
+		fmt.Fprintf(messages, "WARNING: The sudo password for %s failed.\n", t.GetName())
+		for attempt := 1; attempt <= sudoPasswordAttempts; attempt++ {
+			var password string
+			password, err = readPassword(fmt.Sprintf("Enter the sudo password for %s followed by Enter (attempt %d of %d). Otherwise, press Enter:", t.GetName(), attempt, sudoPasswordAttempts))
+			if err != nil {
+				return
+			}
+			if password == "" {
+				break
+			}
+			t.SetSudo(password)
+			if t.CanElevatePrivileges() {
+				log.Printf("sudo password for %s verified on attempt %d", t.GetName(), attempt)
+				return
+			}
+			log.Printf("sudo password for %s failed on attempt %d", t.GetName(), attempt)
+		}
+	}
+	if requireRoot {
+		fmt.Fprintf(messages, "WARNING: Not able to establish elevated privileges on %s. Elevated privileges are required, so data will not be collected.\n", t.GetName())
+	} else {
+		fmt.Fprintf(messages, "WARNING: Not able to establish elevated privileges on %s. Continuing with regular user privileges. Some data will not be collected.\n", t.GetName())
+	}
+	t.SetSudo("")
+	return
+}
+
+// checkRequiredPrivileges returns an error if elevated privileges are required for the
+// collection, i.e., require_root, or require_complete and commands that require elevated
+// privileges will run, but aren't available on the target
+func (c *Collection) checkRequiredPrivileges() (err error) {
+	required := c.cmdLineArgs.requireRoot
+	// the Windows commands don't require elevated privileges
+	if !required && c.cmdLineArgs.requireComplete && !isWindowsTarget(c.target) {
+		var privileged []string
+		if privileged, _, err = getPrivilegedCommands(c.cmdLineArgs); err != nil {
+			return
+		}
+		required = len(privileged) > 0
+	}
+	if required && !canElevatePrivileges(c.target) {
+		err = notRetryable(fmt.Errorf("elevated privileges are required but not available on target: %s", c.target.GetName()))
+	}
+	return
+}
+
+// Before collection starts, the data items, i.e., the collector commands, that won't be
+// collected from each target because they require elevated privileges that aren't
+// available are listed, so that the holes in the reports aren't a surprise.
+
+// getPrivilegedCommands returns the labels of the commands that will run and require
+// elevated privileges, and the number of commands that will run. The commands skipped in
+// read-only mode aren't included.
+func getPrivilegedCommands(cmdLineArgs *CmdLineArgs) (labels []string, count int, err error) {
+	cmdTemplate, err := resources.ReadFile("resources/collector_reports.yaml.tmpl")
+	if err != nil {
+		return
+	}
+	customized, err := customizeCommandYAML(cmdTemplate, cmdLineArgs, ".", "preview")
+	if err != nil {
+		return
+	}
+	var cf commandfile.CommandFile
+	if err = yaml.Unmarshal(customized, &cf); err != nil {
+		return
+	}
+	for _, cmd := range cf.Commands {
+		if !cmd.Run {
+			continue
+		}
+		if cmdLineArgs.readOnly && strings.TrimSpace(cmd.SideEffects) != "" {
+			continue
+		}
+		count++
+		if cmd.Superuser {
+			labels = append(labels, cmd.Label)
+		}
+	}
+	return
+}
+
+// previewPrivileges warns of the data items that won't be collected from each target
+// because elevated privileges aren't available. It's called after the sudo passwords are
+// verified, so the targets that still have one have elevated privileges and aren't
+// checked again.
+func (app *App) previewPrivileges(targets []target.Target) (err error) {
+	var unverified []target.Target
+	for _, t := range targets {
+		if t.GetSudo() == "" && !isWindowsTarget(t) {
+			unverified = append(unverified, t)
+		}
+	}
+	elevated := checkElevatedPrivileges(unverified, app.args.maxParallel)
+	for i, t := range unverified {
+		if elevated[i] {
+			continue
+		}
+		args := app.args
+		if targetArgs, ok := app.targetArgs[t.GetName()]; ok {
+			args = targetArgs
+		}
+		var labels []string
+		var count int
+		if labels, count, err = getPrivilegedCommands(args); err != nil {
+			return
+		}
+		if len(labels) == 0 {
+			continue
+		}
+		log.Printf("elevated privileges not available on %s, commands that won't be collected: %s", t.GetName(), strings.Join(labels, ", "))
+		consequence := "will not be collected"
+		if args.requireRoot || args.requireComplete {
+			consequence = "are required, so no data will be collected"
+		}
+		fmt.Fprintf(messages, "WARNING: Elevated privileges are not available on %s. %d of %d data items %s: %s\n", t.GetName(), len(labels), count, consequence, strings.Join(labels, ", "))
+	}
+	return
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/intel/svr-info/internal/target"
+	"github.com/intel/svr-info/internal/util"
+)
+
+func TestParseRequireRoot(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("ip::user:::sudopw:require_root=true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil || !args.requireRoot || targets[0].sudo != "sudopw" {
+		t.Errorf("unexpected target: %v, %v", targets[0], err)
+	}
+	if _, err = tf.parseContent([]byte("ip::user:::sudopw:require_root=maybe")); err == nil {
+		t.Error("expected an error for an invalid require_root")
+	}
+}
+
+func TestVerifySudoPassword(t *testing.T) {
+	remoteTarget := target.NewRemoteTarget("host", "host", "22", "user", "", "", "", "wrong")
+	// not in a terminal
+	if err := verifySudoPassword(remoteTarget, nil, false); err != nil || remoteTarget.GetSudo() != "" {
+		t.Errorf("sudo password not cleared: %s, %v", remoteTarget.GetSudo(), err)
+	}
+	// the user pressed Enter at the prompt
+	remoteTarget.SetSudo("wrong")
+	prompts := 0
+	readPassword := func(prompt string) (string, error) {
+		prompts++
+		return "", nil
+	}
+	if err := verifySudoPassword(remoteTarget, readPassword, true); err != nil || prompts != 1 || remoteTarget.GetSudo() != "" {
+		t.Errorf("unexpected prompts: %d, %s, %v", prompts, remoteTarget.GetSudo(), err)
+	}
+}
+
+func TestGetStdinEnv(t *testing.T) {
+	command, input := getStdinEnv([]string{"SUDO_PASSWORD=it's $(reboot)", "REDFISH_PASSWORD=a=b c"})
+	if strings.Contains(command, "reboot") || strings.Contains(command, "a=b c") {
+		t.Errorf("password on the command line: %s", command)
+	}
+	cmd := exec.Command("sh", "-c", command+`sh -c 'echo "$SUDO_PASSWORD|$REDFISH_PASSWORD"'`)
+	cmd.Stdin = strings.NewReader(input)
+	stdout, err := cmd.Output()
+	if err != nil || string(stdout) != "it's $(reboot)|a=b c\n" {
+		t.Errorf("unexpected variables: %s, %v", stdout, err)
+	}
+}
+
+func TestCheckRequiredPrivileges(t *testing.T) {
+	replayTarget := target.NewReplayTarget("host", nil)
+	for _, args := range []*CmdLineArgs{{requireRoot: true}, {requireComplete: true}} {
+		c := newCollection(replayTarget, args, t.TempDir(), t.TempDir())
+		if err := c.checkRequiredPrivileges(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGetPrivilegedCommands(t *testing.T) {
+	labels, count, err := getPrivilegedCommands(&CmdLineArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) == 0 || count <= len(labels) || !util.StringInList("dmidecode", labels) {
+		t.Errorf("unexpected privileged commands: %d of %d, %v", len(labels), count, labels)
+	}
+	readOnlyLabels, readOnlyCount, err := getPrivilegedCommands(&CmdLineArgs{readOnly: true})
+	if err != nil || readOnlyCount >= count || len(readOnlyLabels) >= len(labels) {
+		t.Errorf("unexpected read-only privileged commands: %d of %d, %v", len(readOnlyLabels), readOnlyCount, err)
+	}
+	if labels, count, err = getPrivilegedCommands(&CmdLineArgs{noConfig: true}); err != nil || len(labels) != 0 || count != 0 {
+		t.Errorf("unexpected privileged commands: %d of %d, %v", len(labels), count, err)
+	}
+}
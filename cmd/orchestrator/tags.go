@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tags are key=value labels, e.g., env=prod,rack=12, that are saved with a target's
+// collected data so that the reporter can select hosts with its -filter option without
+// collecting the data again. They are set for all targets with -tags and per target in
+// the targets file.
+
+// tagsLabel labels the tags in the collected data, the reporter reads them by this label
+const tagsLabel = "tags"
+
+var reTagKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// parseTags parses a comma separated list of key=value tags
+func parseTags(input string) (tags map[string]string, err error) {
+	tags = make(map[string]string)
+	for _, field := range strings.Split(input, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, found := strings.Cut(field, "=")
+		if !found || !reTagKey.MatchString(key) || value == "" || strings.ContainsAny(value, " \t()!=") {
+			err = fmt.Errorf("invalid tag: %s, expected key=value", field)
+			return
+		}
+		tags[key] = value
+	}
+	return
+}
+
+// mergeTags returns the tags in a with the tags in b added, b's value wins when both
+// have the same key
+func mergeTags(a string, b string) string {
+	tags, _ := parseTags(a)
+	more, _ := parseTags(b)
+	for key, value := range more {
+		tags[key] = value
+	}
+	return formatTags(tags, ",")
+}
+
+// formatTags returns the tags, sorted by key, separated by sep
+func formatTags(tags map[string]string, sep string) string {
+	var pairs []string
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, sep)
+}
+
+// addTags adds the tags, one key=value per line, to the collected data in the raw.json
+// or raw.gob file as if they were the output of a command
+func addTags(rawFilePath string, input string) (err error) {
+	tags, err := parseTags(input)
+	if err != nil || len(tags) == 0 {
+		return
+	}
+	err = appendRawResult(rawFilePath, map[string]string{
+		"label":      tagsLabel,
+		"command":    "",
+		"superuser":  "false",
+		"stdout":     formatTags(tags, "\n"),
+		"stderr":     "",
+		"exitstatus": "0",
+	})
+	return
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTagList(t *testing.T) {
+	tags, err := parseTags("env=prod, rack=12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags["env"] != "prod" || tags["rack"] != "12" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	for _, input := range []string{"env", "=prod", "env=", "env=a b", "env=a!=b", "1env=prod"} {
+		if _, err := parseTags(input); err == nil {
+			t.Errorf("expected error for %s", input)
+		}
+	}
+}
+
+func TestAddTags(t *testing.T) {
+	rawFilePath := filepath.Join(t.TempDir(), "host.raw.json")
+	err := os.WriteFile(rawFilePath, []byte(`{"host":[{"label":"hostname","command":"hostname","stdout":"host\n","stderr":"","exitstatus":"0","superuser":"false"}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = addTags(rawFilePath, "rack=12,env=prod"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(rawFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string][]map[string]string
+	if err = json.Unmarshal(content, &data); err != nil {
+		t.Fatal(err)
+	}
+	results := data["host"]
+	if len(results) != 2 || results[0]["label"] != "hostname" {
+		t.Fatalf("unexpected results: %v", results)
+	}
+	if results[1]["label"] != tagsLabel || results[1]["stdout"] != "env=prod\nrack=12" {
+		t.Errorf("unexpected tags result: %v", results[1])
+	}
+}
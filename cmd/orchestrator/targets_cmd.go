@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// With -targets_cmd, the targets are written by a command, e.g., a query of an inventory
+// system, that is run once, when svr-info starts, so a daemon collects from the same
+// targets until it's restarted. The command writes the targets to
+// stdout in JSON, with the settings of a YAML targets file, either as an object with a
+// targets list, {"targets": [{"ip": "192.168.1.1", "user": "elaine"}]}, or as the list.
+
+// targetsCmdTimeout is the time the -targets_cmd command has to write the targets
+var targetsCmdTimeout = 5 * time.Minute
+
+// targetsCmdOutput names the -targets_cmd command's output in error messages
+const targetsCmdOutput = "<targets_cmd output>"
+
+// getTargetsFromCommand runs the -targets_cmd command and returns the targets it wrote
+func getTargetsFromCommand(command string) (targets []targetFromFile, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), targetsCmdTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if stderr.Len() > 0 {
+		log.Printf("-targets_cmd stderr: %s", strings.TrimSpace(stderr.String()))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("-targets_cmd %s : timed out after %s", command, targetsCmdTimeout)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("-targets_cmd %s : %v: %s", command, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+	if targets, err = parseTargetsJSON(output); err != nil {
+		err = fmt.Errorf("-targets_cmd %s : %v", command, strings.TrimSpace(err.Error()))
+		return
+	}
+	if len(targets) == 0 {
+		err = fmt.Errorf("-targets_cmd %s : no targets found in the command's output", command)
+	}
+	return
+}
+
+// parseTargetsJSON parses targets in JSON, they're validated like those in a YAML targets
+// file
+func parseTargetsJSON(content []byte) (targets []targetFromFile, err error) {
+	content = bytes.TrimSpace(content)
+	if !json.Valid(content) {
+		err = fmt.Errorf("output is not valid JSON")
+		return
+	}
+	if bytes.HasPrefix(content, []byte("[")) {
+		content = append(append([]byte(`{"targets": `), content...), '}')
+	}
+	// JSON is YAML
+	if targets, err = newTargetsFile(targetsCmdOutput).parseYAMLContent(content); err != nil {
+		return
+	}
+	return expandTargetRanges(targets)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTargetsJSON(t *testing.T) {
+	content := `[
+  {"label": "web1", "ip": "192.168.1.1", "port": 2222, "user": "elaine", "tags": {"env": "prod"}, "megadata": true},
+  {"ip": "node[1-2]", "user": "kramer", "benchmark": ""}
+]`
+	targets, err := parseTargetsJSON([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	if targets[0].label != "web1" || targets[0].port != "2222" || targets[0].options["tags"] != "env=prod" || targets[0].options["megadata"] != "true" {
+		t.Errorf("unexpected target: %v", targets[0])
+	}
+	if targets[2].ip != "node2" || targets[2].label != "node2" || targets[2].options["benchmark"] != "" {
+		t.Errorf("unexpected target: %v", targets[2])
+	}
+	// an object with a targets list
+	if targets, err = parseTargetsJSON([]byte(`{"targets": [{"ip": "host", "user": "user"}]}`)); err != nil || len(targets) != 1 {
+		t.Errorf("unexpected targets: %v, %v", targets, err)
+	}
+	if _, err = parseTargetsJSON([]byte("host:22:user:::")); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err = parseTargetsJSON([]byte(`[{"ip": "host"}]`)); err == nil || !strings.Contains(err.Error(), "user name is required, target 1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGetTargetsFromCommand(t *testing.T) {
+	targets, err := getTargetsFromCommand(`echo '[{"ip": "host", "user": "user"}]'`)
+	if err != nil || len(targets) != 1 || targets[0].ip != "host" {
+		t.Errorf("unexpected targets: %v, %v", targets, err)
+	}
+	if _, err = getTargetsFromCommand("echo inventory unavailable >&2; exit 3"); err == nil || !strings.Contains(err.Error(), "inventory unavailable") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err = getTargetsFromCommand("echo '[]'"); err == nil || !strings.Contains(err.Error(), "no targets") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -8,24 +8,46 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/intel/svr-info/internal/target"
 	"github.com/intel/svr-info/internal/util"
+	"gopkg.in/yaml.v2"
 )
 
 type targetFromFile struct {
-	label  string
-	ip     string
-	port   string
-	user   string
-	key    string
-	pwd    string
-	sudo   string
-	lineNo int
+	label   string
+	ip      string
+	port    string
+	user    string
+	key     string
+	pwd     string
+	sudo    string
+	options map[string]string // optional per-target settings, e.g., megadata_duration=30
+	lineNo  int               // in a flat targets file
+	index   int               // in a YAML targets file, starting at 1
+	file    string            // the included YAML targets file, empty if the target is in the -targets file
 }
 
+// targetOptionNames are the settings that can be appended to a target's line in the
+// targets file to override the corresponding command line arguments
+var targetOptionNames = []string{"megadata", "megadata_profilers", "megadata_duration", "megadata_interval", "megadata_delay", "transport", "proxy", "jump_host", "tags", "schedule", "blackout", "auth", "nic_peer", "group", "benchmark", "profile", "analyze", "require_root", "require_complete", "gentle", "os", "bmc_host", "bmc_user", "bmc_password", "wol_mac"}
+
+var reTargetOption = regexp.MustCompile(`^(megadata|megadata_[a-z]+|transport|auth|tags|schedule|blackout|nic_peer|group|benchmark|profile|analyze|require_root|require_complete|gentle|os|bmc_[a-z]+|wol_mac)=(.*)$`)
+
+// the proxy option's value is a URL that contains colons, so it is extracted from the line
+// before the line is split into fields
+var reTargetProxyOption = regexp.MustCompile(`:proxy=([a-z0-9]+://[^:\s]+(?::\d+)?)`)
+
+// the jump_host option's value may end with a port, so it is extracted like the proxy option
+var reTargetJumpHostOption = regexp.MustCompile(`:jump_host=([^:\s]+(?::\d+)?)`)
+
 type TargetsFile struct {
 	path string
 }
@@ -39,7 +61,15 @@ func (tf *TargetsFile) parse() (targets []targetFromFile, err error) {
 	if err != nil {
 		return
 	}
-	return tf.parseContent(content)
+	if isYAMLTargetsFile(tf.path) {
+		targets, err = tf.parseYAMLContent(content)
+	} else {
+		targets, err = tf.parseContent(content)
+	}
+	if err != nil {
+		return
+	}
+	return expandTargetRanges(targets)
 }
 
 func (tf *TargetsFile) parseContent(content []byte) (targets []targetFromFile, err error) {
@@ -55,8 +85,35 @@ func (tf *TargetsFile) parseContent(content []byte) (targets []targetFromFile, e
 		if line == "" || line[0] == '#' {
 			continue
 		}
-		tokens := strings.Split(line, ":")
 		var t targetFromFile
+		t.options = make(map[string]string)
+		if match := reTargetProxyOption.FindStringSubmatch(line); match != nil {
+			if err := validateTargetOption("proxy", match[1]); err != nil {
+				fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : %v, line %d\n", tf.path, err, lineNo))
+			}
+			t.options["proxy"] = match[1]
+			line = strings.Replace(line, match[0], "", 1)
+		}
+		if match := reTargetJumpHostOption.FindStringSubmatch(line); match != nil {
+			if err := validateTargetOption("jump_host", match[1]); err != nil {
+				fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : %v, line %d\n", tf.path, err, lineNo))
+			}
+			t.options["jump_host"] = match[1]
+			line = strings.Replace(line, match[0], "", 1)
+		}
+		tokens := strings.Split(line, ":")
+		// options, if any, follow the sudo password
+		for len(tokens) > 0 {
+			match := reTargetOption.FindStringSubmatch(tokens[len(tokens)-1])
+			if match == nil {
+				break
+			}
+			if err := validateTargetOption(match[1], match[2]); err != nil {
+				fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : %v, line %d\n", tf.path, err, lineNo))
+			}
+			t.options[match[1]] = match[2]
+			tokens = tokens[:len(tokens)-1]
+		}
 		if len(tokens) != 6 && len(tokens) != 7 {
 			fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : format error, line %d\n", tf.path, lineNo))
 		} else {
@@ -92,12 +149,11 @@ func (tf *TargetsFile) parseContent(content []byte) (targets []targetFromFile, e
 				if err != nil {
 					fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : failed to determine if key file (%s) is a file, line %d: %v\n", tf.path, t.key, lineNo, err))
 				} else if !exists {
-					fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : key file (%s) not a file, line %d\n", tf.path, t.key, lineNo))
+					fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : key file (%s) does not exist, line %d\n", tf.path, t.key, lineNo))
 				}
 			}
 			t.pwd = tokens[i+4]
 			t.sudo = tokens[i+5]
-			t.sudo = strings.ReplaceAll(t.sudo, "$", "\\$") // escape $ in sudo password
 			targets = append(targets, t)
 		}
 	}
@@ -107,3 +163,514 @@ func (tf *TargetsFile) parseContent(content []byte) (targets []targetFromFile, e
 	}
 	return
 }
+
+// targetsYAML is the YAML targets file format, where each target's settings are named
+// rather than positional, see targets.example.yaml
+type targetsYAML struct {
+	Include     []string                   `yaml:"include"`
+	Credentials map[string]credentialsYAML `yaml:"credentials"`
+	Targets     []targetYAML               `yaml:"targets"`
+}
+
+// credentialsYAML is a named block of settings shared by the targets that refer to it.
+// Its settings are a subset of a target's, so that a block can also be merged into a
+// target with a YAML anchor and merge key, i.e., <<: *name.
+type credentialsYAML struct {
+	Port     string  `yaml:"port"`
+	User     string  `yaml:"user"`
+	Key      string  `yaml:"key"`
+	Password string  `yaml:"password"`
+	Sudo     string  `yaml:"sudo"`
+	JumpHost *string `yaml:"jump_host"`
+}
+
+// targetYAML is a target in a YAML targets file. The options are pointers so that an
+// option set to an empty value, e.g., to not run a benchmark for the target, can be told
+// apart from an option that isn't set.
+type targetYAML struct {
+	Label             string            `yaml:"label"`
+	IP                string            `yaml:"ip"`
+	Port              string            `yaml:"port"`
+	User              string            `yaml:"user"`
+	Key               string            `yaml:"key"`
+	Password          string            `yaml:"password"`
+	Sudo              string            `yaml:"sudo"`
+	Credentials       string            `yaml:"credentials"`
+	JumpHost          *string           `yaml:"jump_host"`
+	Tags              map[string]string `yaml:"tags"`
+	Group             *string           `yaml:"group"`
+	Transport         *string           `yaml:"transport"`
+	Proxy             *string           `yaml:"proxy"`
+	Auth              *string           `yaml:"auth"`
+	Benchmark         *string           `yaml:"benchmark"`
+	Profile           *string           `yaml:"profile"`
+	Analyze           *string           `yaml:"analyze"`
+	NICPeer           *string           `yaml:"nic_peer"`
+	Megadata          *string           `yaml:"megadata"`
+	MegadataProfilers *string           `yaml:"megadata_profilers"`
+	MegadataDuration  *string           `yaml:"megadata_duration"`
+	MegadataInterval  *string           `yaml:"megadata_interval"`
+	MegadataDelay     *string           `yaml:"megadata_delay"`
+	Schedule          *string           `yaml:"schedule"`
+	Blackout          *string           `yaml:"blackout"`
+	RequireRoot       *string           `yaml:"require_root"`
+	BMCHost           *string           `yaml:"bmc_host"`
+	BMCUser           *string           `yaml:"bmc_user"`
+	BMCPassword       *string           `yaml:"bmc_password"`
+	WOLMAC            *string           `yaml:"wol_mac"`
+	RequireComplete   *string           `yaml:"require_complete"`
+	Gentle            *string           `yaml:"gentle"`
+	OS                *string           `yaml:"os"`
+}
+
+// isYAMLTargetsFile returns true if the targets file is in the YAML format, by its name
+func isYAMLTargetsFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// A YAML targets file can include other YAML targets files, whose paths are relative to
+// the including file, define named credentials blocks that its targets, and the targets
+// in the files it includes, refer to by name, and refer to environment variables, as
+// ${NAME}, in its values, so that a large fleet can be described without repeating the
+// credentials and paths for every target, and without writing the secrets in the file.
+
+// reEnvVar matches a reference to an environment variable, $${NAME} is a literal ${NAME}
+var reEnvVar = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvVars replaces the references to environment variables in the value with their
+// values. It's an error to refer to an environment variable that isn't set.
+func expandEnvVars(value string) (expanded string, err error) {
+	var unset []string
+	expanded = reEnvVar.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		name := reEnvVar.FindStringSubmatch(match)[1]
+		envValue, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return envValue
+	})
+	if len(unset) > 0 {
+		err = fmt.Errorf("environment variable not set: %s", strings.Join(unset, ", "))
+	}
+	return
+}
+
+// parseYAMLContent parses a YAML targets file, the targets are validated like those in
+// a flat targets file
+func (tf *TargetsFile) parseYAMLContent(content []byte) (targets []targetFromFile, err error) {
+	return tf.parseYAML(content, nil, nil)
+}
+
+// parseYAML parses a YAML targets file and the files it includes. The credentials are
+// those defined in the files that include this one, and including is the chain of
+// files that include this one, to detect include cycles.
+func (tf *TargetsFile) parseYAML(content []byte, credentials map[string]credentialsYAML, including []string) (targets []targetFromFile, err error) {
+	var file targetsYAML
+	if err = yaml.UnmarshalStrict(content, &file); err != nil {
+		err = fmt.Errorf("-targets %s : %v", tf.path, err)
+		return
+	}
+	var fileErrors []string
+	addError := func(index int, format string, a ...interface{}) {
+		fileErrors = append(fileErrors, fmt.Sprintf("-targets %s : %s, target %d\n", tf.path, fmt.Sprintf(format, a...), index))
+	}
+	// the credentials defined in this file override those with the same name in the
+	// including files
+	fileCredentials := make(map[string]credentialsYAML)
+	for name, c := range credentials {
+		fileCredentials[name] = c
+	}
+	for name, c := range file.Credentials {
+		fileCredentials[name] = c
+	}
+	for i, y := range file.Targets {
+		index := i + 1
+		if y.Credentials != "" {
+			c, ok := fileCredentials[y.Credentials]
+			if !ok {
+				addError(index, "credentials not defined: %s", y.Credentials)
+			}
+			// the target's own settings take precedence
+			if y.Port == "" {
+				y.Port = c.Port
+			}
+			if y.User == "" {
+				y.User = c.User
+			}
+			if y.Key == "" {
+				y.Key = c.Key
+			}
+			if y.Password == "" {
+				y.Password = c.Password
+			}
+			if y.Sudo == "" {
+				y.Sudo = c.Sudo
+			}
+			if y.JumpHost == nil {
+				y.JumpHost = c.JumpHost
+			}
+		}
+		expand := func(value string) string {
+			expanded, err := expandEnvVars(value)
+			if err != nil {
+				addError(index, "%v", err)
+			}
+			return expanded
+		}
+		t := targetFromFile{
+			label:   expand(y.Label),
+			ip:      expand(y.IP),
+			port:    expand(y.Port),
+			user:    expand(y.User),
+			key:     expand(y.Key),
+			pwd:     expand(y.Password),
+			sudo:    expand(y.Sudo),
+			options: make(map[string]string),
+			index:   index,
+		}
+		if len(including) > 0 {
+			t.file = tf.path
+		}
+		// the label defaults to the IP address, as in the flat format
+		if t.label == "" {
+			t.label = t.ip
+		}
+		if t.ip == "" {
+			addError(t.index, "IP Address (or hostname) is required")
+		}
+		if t.port != "" {
+			if _, err := strconv.Atoi(t.port); err != nil {
+				addError(t.index, "invalid port %s", t.port)
+			}
+		}
+		if t.user == "" {
+			addError(t.index, "user name is required")
+		}
+		if t.key != "" {
+			exists, err := util.FileExists(t.key)
+			if err != nil {
+				addError(t.index, "failed to determine if key file (%s) is a file: %v", t.key, err)
+			} else if !exists {
+				addError(t.index, "key file (%s) does not exist", t.key)
+			}
+		}
+		if len(y.Tags) > 0 {
+			tags := make(map[string]string, len(y.Tags))
+			for key, value := range y.Tags {
+				tags[key] = expand(value)
+			}
+			t.options["tags"] = formatTags(tags, ",")
+		}
+		for name, value := range map[string]*string{
+			"group":              y.Group,
+			"transport":          y.Transport,
+			"proxy":              y.Proxy,
+			"jump_host":          y.JumpHost,
+			"auth":               y.Auth,
+			"benchmark":          y.Benchmark,
+			"profile":            y.Profile,
+			"analyze":            y.Analyze,
+			"nic_peer":           y.NICPeer,
+			"megadata":           y.Megadata,
+			"megadata_profilers": y.MegadataProfilers,
+			"megadata_duration":  y.MegadataDuration,
+			"megadata_interval":  y.MegadataInterval,
+			"megadata_delay":     y.MegadataDelay,
+			"schedule":           y.Schedule,
+			"blackout":           y.Blackout,
+			"require_root":       y.RequireRoot,
+			"require_complete":   y.RequireComplete,
+			"gentle":             y.Gentle,
+			"os":                 y.OS,
+			"bmc_host":           y.BMCHost,
+			"bmc_user":           y.BMCUser,
+			"bmc_password":       y.BMCPassword,
+			"wol_mac":            y.WOLMAC,
+		} {
+			if value != nil {
+				t.options[name] = expand(*value)
+			}
+		}
+		for _, name := range targetOptionNames {
+			if value, ok := t.options[name]; ok {
+				if err := validateTargetOption(name, value); err != nil {
+					addError(t.index, "%v", err)
+				}
+			}
+		}
+		targets = append(targets, t)
+	}
+	// the included files' targets follow this file's targets
+	including = append(including, tf.path)
+	for _, include := range file.Include {
+		var includedTargets []targetFromFile
+		if includedTargets, err = tf.parseInclude(include, fileCredentials, including); err != nil {
+			fileErrors = append(fileErrors, err.Error())
+		}
+		targets = append(targets, includedTargets...)
+	}
+	err = nil
+	if len(fileErrors) > 0 {
+		err = fmt.Errorf("%s", strings.Join(fileErrors, "\n"))
+	}
+	return
+}
+
+// parseInclude parses a YAML targets file included by this one
+func (tf *TargetsFile) parseInclude(include string, credentials map[string]credentialsYAML, including []string) (targets []targetFromFile, err error) {
+	path, err := expandEnvVars(include)
+	if err != nil {
+		err = fmt.Errorf("-targets %s : include %s: %v\n", tf.path, include, err)
+		return
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(tf.path), path)
+	}
+	if !isYAMLTargetsFile(path) {
+		err = fmt.Errorf("-targets %s : included file must be a YAML targets file: %s\n", tf.path, path)
+		return
+	}
+	for _, includingPath := range including {
+		if sameFile(includingPath, path) {
+			err = fmt.Errorf("-targets %s : include cycle: %s\n", tf.path, path)
+			return
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		err = fmt.Errorf("-targets %s : failed to read included file: %v\n", tf.path, err)
+		return
+	}
+	return newTargetsFile(path).parseYAML(content, credentials, including)
+}
+
+// sameFile returns true if the paths are to the same file
+func sameFile(path1 string, path2 string) bool {
+	info1, err1 := os.Stat(path1)
+	info2, err2 := os.Stat(path2)
+	if err1 != nil || err2 != nil {
+		return filepath.Clean(path1) == filepath.Clean(path2)
+	}
+	return os.SameFile(info1, info2)
+}
+
+func validateTargetOption(name string, value string) (err error) {
+	if !util.StringInList(name, targetOptionNames) {
+		err = fmt.Errorf("unrecognized option %s", name)
+		return
+	}
+	if name == "proxy" {
+		_, err = target.ParseProxy(value)
+		return
+	}
+	if name == "jump_host" {
+		err = target.ValidateJumpHost(value)
+		return
+	}
+	if name == "transport" {
+		if !util.StringInList(value, target.Transports) {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "os" {
+		if !util.StringInList(value, targetOSTypes) {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "auth" {
+		if !util.StringInList(value, target.AuthMethods) {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "tags" {
+		_, err = parseTags(value)
+		return
+	}
+	if name == "schedule" {
+		_, err = parseCron(value)
+		return
+	}
+	if name == "blackout" {
+		_, err = parseBlackouts(value)
+		return
+	}
+	if name == "nic_peer" {
+		err = validateNICPeer(value)
+		return
+	}
+	if name == "wol_mac" {
+		if _, err = net.ParseMAC(value); err != nil {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "group" || name == "bmc_host" || name == "bmc_user" {
+		if value == "" {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "bmc_password" {
+		return
+	}
+	if name == "megadata" || name == "require_root" || name == "require_complete" || name == "gentle" {
+		if _, err = strconv.ParseBool(value); err != nil {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "benchmark" || name == "profile" || name == "analyze" {
+		// empty to not run the category for the target
+		types := map[string][]string{"benchmark": benchmarkTypes, "profile": profileTypes, "analyze": analyzeTypes}[name]
+		if value != "" && !isValidType(types, value) {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if name == "megadata_profilers" {
+		if !isValidType(megadataProfilerTypes, value) {
+			err = fmt.Errorf("invalid %s: %s", name, value)
+		}
+		return
+	}
+	if _, err = strconv.Atoi(value); err != nil {
+		err = fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return
+}
+
+// applyOptions returns a copy of the command line arguments with the target's options applied
+func (t *targetFromFile) applyOptions(cmdLineArgs *CmdLineArgs) (targetArgs *CmdLineArgs, err error) {
+	args := *cmdLineArgs
+	targetArgs = &args
+	if len(t.options) == 0 {
+		return
+	}
+	for name, value := range t.options {
+		switch name {
+		case "megadata":
+			targetArgs.megadata, _ = strconv.ParseBool(value)
+		case "require_root":
+			targetArgs.requireRoot, _ = strconv.ParseBool(value)
+		case "require_complete":
+			targetArgs.requireComplete, _ = strconv.ParseBool(value)
+		case "gentle":
+			targetArgs.gentle, _ = strconv.ParseBool(value)
+		case "megadata_profilers":
+			targetArgs.megaProfilers = value
+		case "megadata_duration":
+			targetArgs.megaDuration, _ = strconv.Atoi(value)
+		case "megadata_interval":
+			targetArgs.megaInterval, _ = strconv.Atoi(value)
+		case "megadata_delay":
+			targetArgs.megaDelay, _ = strconv.Atoi(value)
+		case "transport":
+			targetArgs.transport = value
+		case "os":
+			targetArgs.targetOS = value
+		case "proxy":
+			targetArgs.proxy = value
+		case "jump_host":
+			targetArgs.jumpHost = value
+		case "auth":
+			targetArgs.auth = value
+		case "tags":
+			targetArgs.tags = mergeTags(targetArgs.tags, value)
+		case "schedule":
+			targetArgs.schedule = value
+		case "blackout":
+			targetArgs.blackout = value
+		case "nic_peer":
+			targetArgs.nicPeer = value
+		case "group":
+			targetArgs.group = value
+		case "bmc_host":
+			targetArgs.bmcHost = value
+		case "bmc_user":
+			targetArgs.bmcUser = value
+		case "bmc_password":
+			targetArgs.bmcPassword = value
+		case "wol_mac":
+			targetArgs.wolMAC = value
+		case "benchmark":
+			targetArgs.benchmark = value
+		case "profile":
+			targetArgs.profile = value
+		case "analyze":
+			targetArgs.analyze = value
+		}
+	}
+	err = validateMegadataOptions(targetArgs.megaProfilers, targetArgs.megaDuration, targetArgs.megaInterval, targetArgs.megaDelay)
+	if err != nil {
+		err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+		return
+	}
+	if err = validateGentleOptions(targetArgs); err != nil {
+		err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+		return
+	}
+	if (targetArgs.bmcHost == "") != (targetArgs.bmcUser == "") {
+		err = fmt.Errorf("targets file %s: bmc_host and bmc_user must be set together", t.getLocation())
+		return
+	}
+	if targetArgs.targetOS == targetOSWindows {
+		if err = validateWindowsOptions(targetArgs); err != nil {
+			err = fmt.Errorf("targets file %s: %v", t.getLocation(), err)
+		}
+	}
+	return
+}
+
+// getLocation returns where the target is in the targets file, for error messages
+func (t *targetFromFile) getLocation() string {
+	if t.file != "" {
+		return fmt.Sprintf("%s, target %d", t.file, t.index)
+	}
+	if t.index > 0 {
+		return fmt.Sprintf("target %d", t.index)
+	}
+	return fmt.Sprintf("line %d", t.lineNo)
+}
+
+// getName returns the target's name, its label or, if it has none, its address
+func (t *targetFromFile) getName() string {
+	if t.label != "" {
+		return t.label
+	}
+	return t.ip
+}
+
+// selectTargets returns the targets named in the comma separated list of names, in the
+// order of the targets. It's an error to name a target that isn't in the list.
+func selectTargets(targets []targetFromFile, names string) (selected []targetFromFile, err error) {
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted[name] = false
+		}
+	}
+	for _, t := range targets {
+		if _, ok := wanted[t.getName()]; ok {
+			wanted[t.getName()] = true
+			selected = append(selected, t)
+		}
+	}
+	var missing []string
+	for name, found := range wanted {
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		err = fmt.Errorf("-targets_select : no target named %s", strings.Join(missing, ", "))
+	}
+	return
+}
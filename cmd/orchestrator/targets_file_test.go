@@ -5,6 +5,8 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -192,14 +194,430 @@ func TestMissingIpAndUser(t *testing.T) {
 	}
 }
 
-func TestEscapeSudo(t *testing.T) {
+func TestSudoPasswordVerbatim(t *testing.T) {
 	content := "ip::user:::$foo$bar"
 	tf := newTargetsFile("testing")
 	targets, err := tf.parseContent([]byte(content))
 	if err != nil {
 		t.Fail()
 	}
-	if targets[0].sudo != "\\$foo\\$bar" {
+	if targets[0].sudo != "$foo$bar" {
 		t.Fail()
 	}
 }
+
+func TestParseOptions(t *testing.T) {
+	content := "label:ip:22:user:::sudo:megadata_profilers=mpstat,perf:megadata_duration=120"
+	tf := newTargetsFile("testing")
+	targets, err := tf.parseContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].label != "label" || targets[0].sudo != "sudo" {
+		t.Fail()
+	}
+	if targets[0].options["megadata_profilers"] != "mpstat,perf" {
+		t.Fail()
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.megaDuration != 120 || args.megaInterval != 2 || args.megaProfilers != "mpstat,perf" {
+		t.Fail()
+	}
+}
+
+func TestParseInvalidOption(t *testing.T) {
+	content := "ip::user:::sudo:megadata_duration=foo"
+	tf := newTargetsFile("testing")
+	_, err := tf.parseContent([]byte(content))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseTransport(t *testing.T) {
+	content := "i-0123456789abcdef0::user::::transport=ssm"
+	tf := newTargetsFile("testing")
+	targets, err := tf.parseContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].ip != "i-0123456789abcdef0" || targets[0].options["transport"] != "ssm" {
+		t.Fail()
+	}
+	_, err = tf.parseContent([]byte("ip::user::::transport=telnet"))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	content := "label:ip:22:user:::sudo:proxy=socks5://proxy.example.com:1080:megadata_delay=5"
+	tf := newTargetsFile("testing")
+	targets, err := tf.parseContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].options["proxy"] != "socks5://proxy.example.com:1080" || targets[0].options["megadata_delay"] != "5" {
+		t.Fail()
+	}
+	if targets[0].label != "label" || targets[0].sudo != "sudo" {
+		t.Fail()
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	content := "ip::user::::tags=env=prod,rack=12"
+	tf := newTargetsFile("testing")
+	targets, err := tf.parseContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, tags: "env=dev,team=perf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.tags != "env=prod,rack=12,team=perf" {
+		t.Errorf("unexpected tags: %s", args.tags)
+	}
+	_, err = tf.parseContent([]byte("ip::user::::tags=env"))
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseGroup(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("ip::user::::group=rack-12:tags=env=prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.group != "rack-12" {
+		t.Errorf("unexpected group: %s", args.group)
+	}
+	if _, err = tf.parseContent([]byte("ip::user::::group=")); err == nil {
+		t.Error("expected error for empty group")
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	content := "ip::user::::schedule=30 1 * * 0:blackout=* 8-17 * * 1-5"
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, schedule: "@daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.schedule != "30 1 * * 0" || args.blackout != "* 8-17 * * 1-5" {
+		t.Errorf("unexpected calendar: %s, %s", args.schedule, args.blackout)
+	}
+	_, err = tf.parseContent([]byte("ip::user::::schedule=30 1 * *"))
+	if err == nil {
+		t.Error("expected an error for an invalid schedule")
+	}
+}
+
+func TestParseAuth(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("host.example.com::user::::auth=gssapi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, auth: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.auth != "gssapi" {
+		t.Errorf("unexpected auth: %s", args.auth)
+	}
+	if _, err = tf.parseContent([]byte("host.example.com::user::::auth=kerberos")); err == nil {
+		t.Error("expected an error for an invalid auth method")
+	}
+}
+
+func TestParseNICPeer(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("192.168.1.1::user::::nic_peer=192.168.1.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.nicPeer != "192.168.1.2" {
+		t.Errorf("unexpected peer: %s", args.nicPeer)
+	}
+	if _, err = tf.parseContent([]byte("192.168.1.1::user::::nic_peer=peer;reboot")); err == nil {
+		t.Error("expected an error for an invalid peer")
+	}
+}
+
+func TestParseJumpHost(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("10.0.5.21::user::::jump_host=admin@bastion.example.com:2222:group=rack-12"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if targets[0].options["jump_host"] != "admin@bastion.example.com:2222" || targets[0].options["group"] != "rack-12" {
+		t.Errorf("unexpected target: %v", targets[0])
+	}
+	targetArgs, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2})
+	if err != nil || targetArgs.jumpHost != "admin@bastion.example.com:2222" {
+		t.Errorf("unexpected jump host: %v", err)
+	}
+	if _, err = tf.parseContent([]byte("10.0.5.21::user::::jump_host=@bastion")); err == nil {
+		t.Error("expected an error for an invalid jump host")
+	}
+}
+
+func TestParseCategories(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("ip::user::::benchmark=memory,storage:profile=:megadata=true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, profile: "all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.benchmark != "memory,storage" || args.profile != "" || !args.megadata {
+		t.Errorf("unexpected categories: %s, %s, %v", args.benchmark, args.profile, args.megadata)
+	}
+	// a gentle target can't run the megadata profilers, with the command line's -megadata
+	targets, err = tf.parseContent([]byte("ip::user::::gentle=true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, megadata: true}); err == nil {
+		t.Error("megadata accepted for a gentle target")
+	}
+	for _, option := range []string{"benchmark=disk", "analyze=python", "megadata=sometimes"} {
+		if _, err = tf.parseContent([]byte("ip::user::::" + option)); err == nil {
+			t.Errorf("expected an error for %s", option)
+		}
+	}
+}
+
+func TestParseOS(t *testing.T) {
+	tf := newTargetsFile("test")
+	targets, err := tf.parseContent([]byte("ip::user::::os=windows"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, targetOS: targetOSLinux})
+	if err != nil || args.targetOS != targetOSWindows {
+		t.Errorf("unexpected operating system: %s, %v", args.targetOS, err)
+	}
+	// the collector's options aren't supported on Windows targets
+	if _, err = targets[0].applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, benchmark: "cpu"}); err == nil {
+		t.Error("benchmark accepted for a Windows target")
+	}
+	if _, err = tf.parseContent([]byte("ip::user::::os=macos")); err == nil {
+		t.Error("invalid operating system accepted")
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	content := `
+targets:
+  - label: web1
+    ip: 192.168.1.1
+    port: 2222
+    user: user
+    key: targets.example
+    sudo: pa$$
+    jump_host: admin@bastion:22
+    tags:
+      env: prod
+      rack: "12"
+    group: rack-12
+    benchmark: memory
+    analyze: ""
+    megadata: true
+    megadata_duration: 120
+  - ip: 192.168.1.2
+    user: user
+`
+	tf := newTargetsFile("targets.yaml")
+	targets, err := tf.parseYAMLContent([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	web1 := targets[0]
+	if web1.label != "web1" || web1.port != "2222" || web1.key != "targets.example" || web1.sudo != "pa$$" || web1.options["jump_host"] != "admin@bastion:22" {
+		t.Errorf("unexpected target: %v", web1)
+	}
+	args, err := web1.applyOptions(&CmdLineArgs{megaDuration: 60, megaInterval: 2, analyze: "system", tags: "team=perf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.tags != "env=prod,rack=12,team=perf" || args.group != "rack-12" || args.benchmark != "memory" || args.analyze != "" || !args.megadata || args.megaDuration != 120 {
+		t.Errorf("unexpected options: %+v", args)
+	}
+	if targets[1].label != "192.168.1.2" || len(targets[1].options) != 0 {
+		t.Errorf("unexpected target: %v", targets[1])
+	}
+	// errors identify the target
+	_, err = tf.parseYAMLContent([]byte("targets:\n  - ip: host\n    user: user\n  - ip: host\n    benchmark: disk\n"))
+	if err == nil || !strings.Contains(err.Error(), "user name is required, target 2") || !strings.Contains(err.Error(), "invalid benchmark: disk, target 2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// unknown settings
+	if _, err = tf.parseYAMLContent([]byte("targets:\n  - ip: host\n    user: user\n    passwd: secret\n")); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+}
+
+func TestTargetsExampleYAML(t *testing.T) {
+	content, err := os.ReadFile("targets.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the example's key files don't exist
+	targets, err := newTargetsFile("targets.example.yaml").parseYAMLContent(content)
+	if len(targets) != 8 || err == nil || strings.Contains(err.Error(), "invalid") {
+		t.Errorf("unexpected example targets: %d, %v", len(targets), err)
+	}
+}
+
+func TestParseYAMLCredentials(t *testing.T) {
+	t.Setenv("SVR_INFO_TEST_SUDO", "pa$$")
+	content := `
+credentials:
+  lab: &lab
+    user: elaine
+    key: targets.example
+    sudo: ${SVR_INFO_TEST_SUDO}
+targets:
+  - ip: 192.168.1.1
+    credentials: lab
+  - ip: 192.168.1.2
+    credentials: lab
+    user: kramer
+  - ip: 192.168.1.3
+    <<: *lab
+    port: 2222
+  - ip: 192.168.1.4
+    credentials: prod
+`
+	targets, err := newTargetsFile("targets.yaml").parseYAMLContent([]byte(content))
+	if err == nil || !strings.Contains(err.Error(), "credentials not defined: prod, target 4") || strings.Contains(err.Error(), "target 1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(targets) != 4 {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	if targets[0].user != "elaine" || targets[0].key != "targets.example" || targets[0].sudo != "pa$$" {
+		t.Errorf("unexpected target: %v", targets[0])
+	}
+	if targets[1].user != "kramer" || targets[1].key != "targets.example" {
+		t.Errorf("unexpected target: %v", targets[1])
+	}
+	if targets[2].user != "elaine" || targets[2].port != "2222" || targets[2].sudo != "pa$$" {
+		t.Errorf("unexpected target: %v", targets[2])
+	}
+}
+
+func TestExpandEnvVars(t *testing.T) {
+	t.Setenv("SVR_INFO_TEST_HOST", "web1")
+	expanded, err := expandEnvVars("${SVR_INFO_TEST_HOST}.example.com:$${SVR_INFO_TEST_HOST}:$HOME")
+	if err != nil || expanded != "web1.example.com:${SVR_INFO_TEST_HOST}:$HOME" {
+		t.Errorf("unexpected expansion: %s, %v", expanded, err)
+	}
+	if _, err = expandEnvVars("${SVR_INFO_TEST_UNSET}"); err == nil || !strings.Contains(err.Error(), "SVR_INFO_TEST_UNSET") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseYAMLInclude(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SVR_INFO_TEST_RACK", "rack-12")
+	files := map[string]string{
+		"fleet.yaml": `
+include:
+  - racks/${SVR_INFO_TEST_RACK}.yaml
+credentials:
+  lab:
+    user: elaine
+targets:
+  - ip: 192.168.1.1
+    credentials: lab
+`,
+		"racks/rack-12.yaml": `
+targets:
+  - ip: 10.0.12.1
+    credentials: lab
+  - ip: 10.0.12.2
+    port: x
+    credentials: lab
+`,
+		"cycle.yaml": `
+include:
+  - cycle.yaml
+targets:
+  - ip: 192.168.1.1
+    user: elaine
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets, err := newTargetsFile(filepath.Join(dir, "fleet.yaml")).parse()
+	rackFile := filepath.Join(dir, "racks", "rack-12.yaml")
+	if err == nil || !strings.Contains(err.Error(), rackFile+" : invalid port x, target 2") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(targets) != 3 || targets[1].ip != "10.0.12.1" || targets[1].user != "elaine" {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	if targets[0].getLocation() != "target 1" || targets[2].getLocation() != rackFile+", target 2" {
+		t.Errorf("unexpected locations: %s, %s", targets[0].getLocation(), targets[2].getLocation())
+	}
+	if _, err = newTargetsFile(filepath.Join(dir, "cycle.yaml")).parse(); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseHostRange(t *testing.T) {
+	dir := t.TempDir()
+	flat := filepath.Join(dir, "targets")
+	if err := os.WriteFile(flat, []byte("node[01-03].dc1:22:user:::\nweb[1-2]:web[1,3]:22:user:::\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := newTargetsFile(flat).parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 5 || targets[0].ip != "node01.dc1" || targets[0].label != "node01.dc1" || targets[2].ip != "node03.dc1" {
+		t.Fatalf("unexpected targets: %v", targets)
+	}
+	if targets[3].label != "web1" || targets[3].ip != "web1" || targets[4].label != "web2" || targets[4].ip != "web3" {
+		t.Errorf("unexpected targets: %v", targets[3:])
+	}
+	yamlFile := filepath.Join(dir, "targets.yaml")
+	if err = os.WriteFile(yamlFile, []byte("targets:\n  - label: db\n    ip: db[1-2]\n    user: user\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newTargetsFile(yamlFile).parse(); err == nil || !strings.Contains(err.Error(), "target 1: label db expands to 1 labels") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
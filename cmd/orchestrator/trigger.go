@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// triggerTypes are the conditions that can start a detached collection
+var triggerTypes = []string{"load", "temp", "dmesg"}
+
+// triggerCondition starts a detached collection when it fires, e.g., when the 1 minute
+// load average exceeds the value
+type triggerCondition struct {
+	name  string
+	value string
+}
+
+// triggerConditions holds the -trigger key=value conditions, any one of them starts
+// the collection
+type triggerConditions []triggerCondition
+
+func (v *triggerConditions) String() string {
+	var conditions []string
+	for _, c := range *v {
+		conditions = append(conditions, c.name+"="+c.value)
+	}
+	return strings.Join(conditions, ",")
+}
+
+func (v *triggerConditions) Set(condition string) error {
+	name, value, found := strings.Cut(condition, "=")
+	if !found || value == "" {
+		return fmt.Errorf("must be in key=value format, where key is one of: %s", strings.Join(triggerTypes, ","))
+	}
+	switch name {
+	case "load", "temp":
+		if threshold, err := strconv.ParseFloat(value, 64); err != nil || threshold <= 0 {
+			return fmt.Errorf("%s threshold must be a positive number", name)
+		}
+	case "dmesg":
+		// the pattern is matched with grep -E on the target
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid dmesg pattern: %v", err)
+		}
+		if strings.ContainsAny(value, "\n\r") {
+			return fmt.Errorf("dmesg pattern must not contain newlines")
+		}
+	default:
+		return fmt.Errorf("unsupported condition: %s, choose from: %s", name, strings.Join(triggerTypes, ","))
+	}
+	*v = append(*v, triggerCondition{name: name, value: value})
+	return nil
+}
+
+// getTriggerScript returns the shell script lines that wait until one of the conditions
+// fires, checking every interval seconds. The condition that fired is written to the
+// trigger file. If none fire before the timeout, the expired file is created and the
+// script exits.
+func getTriggerScript(conditions triggerConditions, interval int, timeout int) (lines []string) {
+	lines = append(lines,
+		"# read the kernel ring buffer, with elevated privileges if required",
+		"kmsg() {",
+		"    dmesg 2>/dev/null && return",
+		"    if [ -n \"$SUDO_PASSWORD\" ]; then",
+		"        echo \"$SUDO_PASSWORD\" | sudo -S -p \"\" dmesg 2>/dev/null",
+		"    else",
+		"        sudo -n dmesg 2>/dev/null",
+		"    fi",
+		"}",
+		fmt.Sprintf("deadline=$(( $(date +%%s) + %d ))", timeout),
+	)
+	for i, c := range conditions {
+		if c.name == "dmesg" {
+			// only messages logged after the collection starts fire the trigger
+			lines = append(lines, fmt.Sprintf("dmesg_base_%d=$(kmsg | grep -E -c -- %s)", i, util.ShellQuote(c.value)))
+		}
+	}
+	lines = append(lines, "while true; do")
+	for i, c := range conditions {
+		switch c.name {
+		case "load":
+			lines = append(lines,
+				fmt.Sprintf("    if awk -v t=%s '{exit !($1 > t)}' /proc/loadavg; then", c.value),
+				fmt.Sprintf("        echo \"load average $(cut -d' ' -f1 /proc/loadavg) exceeded %s\" > %s; break", c.value, detachedTriggerFile),
+				"    fi",
+			)
+		case "temp":
+			lines = append(lines,
+				"    temp=$(cat /sys/class/thermal/thermal_zone*/temp /sys/class/hwmon/hwmon*/temp*_input 2>/dev/null | awk '$1 > max {max = $1} END {print max / 1000}')",
+				fmt.Sprintf("    if awk -v t=%s -v temp=\"$temp\" 'BEGIN {exit !(temp > t)}'; then", c.value),
+				fmt.Sprintf("        echo \"temperature ${temp}C exceeded %sC\" > %s; break", c.value, detachedTriggerFile),
+				"    fi",
+			)
+		case "dmesg":
+			lines = append(lines,
+				fmt.Sprintf("    if [ \"$(kmsg | grep -E -c -- %s)\" -gt \"$dmesg_base_%d\" ]; then", util.ShellQuote(c.value), i),
+				fmt.Sprintf("        { echo dmesg matched %s; kmsg | grep -E -- %s | tail -n 5; } > %s; break", util.ShellQuote(c.value), util.ShellQuote(c.value), detachedTriggerFile),
+				"    fi",
+			)
+		}
+	}
+	lines = append(lines,
+		"    if [ \"$(date +%s)\" -ge \"$deadline\" ]; then",
+		fmt.Sprintf("        touch %s %s; exit 0", detachedExpiredFile, detachedDoneFile),
+		"    fi",
+		fmt.Sprintf("    sleep %d", interval),
+		"done",
+	)
+	return
+}
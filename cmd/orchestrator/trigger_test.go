@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestTriggerConditions(t *testing.T) {
+	var conditions triggerConditions
+	for _, valid := range []string{"load=16", "temp=85.5", "dmesg=mce: \\[Hardware Error\\]", "dmesg=it's"} {
+		if err := conditions.Set(valid); err != nil {
+			t.Errorf("%s: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"load", "load=", "load=-1", "temp=hot", "dmesg=(", "disk=90"} {
+		if err := conditions.Set(invalid); err == nil {
+			t.Errorf("%s: expected error", invalid)
+		}
+	}
+	if len(conditions) != 4 {
+		t.Errorf("expected 4 conditions, got %d", len(conditions))
+	}
+}
+
+func TestTriggerScript(t *testing.T) {
+	var conditions triggerConditions
+	conditions.Set("load=16")
+	conditions.Set("temp=90")
+	conditions.Set("dmesg=it's $(reboot)")
+	script := strings.Join(getTriggerScript(conditions, 5, 60), "\n")
+	if !strings.Contains(script, `'it'\''s $(reboot)'`) {
+		t.Errorf("dmesg pattern not quoted: %s", script)
+	}
+	// check the syntax without running it
+	cmd := exec.Command("bash", "-n")
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("invalid script: %v, %s", err, out)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/commandfile"
+	"github.com/intel/svr-info/internal/target"
+	"gopkg.in/yaml.v2"
+)
+
+// The collector doesn't run on Windows targets. Their commands, PowerShell scripts in
+// collector_windows.yaml.tmpl, are run one at a time over SSH and their results are
+// written in the collector's output format. The scripts' output has the format of the
+// Linux commands of the same label, e.g., lscpu and dmidecode, so that the reports of
+// mixed-OS fleets are created from Linux and Windows hosts' data alike.
+
+// getWindowsCommandFile returns the Windows command file customized for the collection
+func getWindowsCommandFile(cmdLineArgs *CmdLineArgs, targetName string) (cf commandfile.CommandFile, err error) {
+	cmdTemplate, err := resources.ReadFile("resources/collector_windows.yaml.tmpl")
+	if err != nil {
+		return
+	}
+	customized, err := customizeCommandYAML(cmdTemplate, cmdLineArgs, "", targetName)
+	if err != nil {
+		return
+	}
+	err = yaml.Unmarshal(customized, &cf)
+	return
+}
+
+// collectWindows collects data from the Windows target
+func (c *Collection) collectWindows() (err error) {
+	if err = c.checkRequiredPrivileges(); err != nil {
+		log.Print(err)
+		return
+	}
+	cf, err := getWindowsCommandFile(c.cmdLineArgs, c.target.GetName())
+	if err != nil {
+		return
+	}
+	var deadline time.Time
+	if c.cmdLineArgs.timeout > 0 {
+		deadline = time.Now().Add(time.Duration(c.cmdLineArgs.timeout) * time.Second)
+	}
+	var results []map[string]string
+	var missing []string
+	var summary strings.Builder
+	c.commandCount = 0
+	for _, cmd := range cf.Commands {
+		if !cmd.Run {
+			continue
+		}
+		c.commandCount++
+		timeout := max(cf.Args.Timeout, cmd.Timeout)
+		if !deadline.IsZero() {
+			remaining := int(time.Until(deadline).Seconds())
+			if remaining <= 0 {
+				c.deadline = true
+				missing = append(missing, cmd.Label)
+				continue
+			}
+			if timeout == 0 || remaining < timeout {
+				timeout = remaining
+			}
+		}
+		start := time.Now()
+		stdout, stderr, exitCode, _ := c.target.RunCommandWithTimeout(exec.Command(cmd.Command), timeout)
+		results = append(results, map[string]string{
+			"label":      cmd.Label,
+			"command":    cmd.Command,
+			"superuser":  "false",
+			"stdout":     stdout,
+			"stderr":     stderr,
+			"exitstatus": strconv.Itoa(exitCode),
+		})
+		fmt.Fprintf(&summary, "%s: exit status %d, %.1fs\n", cmd.Label, exitCode, time.Since(start).Seconds())
+	}
+	if len(results) == 0 {
+		err = fmt.Errorf("no commands were collected from Windows target: %s", c.target.GetName())
+		log.Print(err)
+		return
+	}
+	if len(missing) > 0 {
+		c.notCollected = missing
+		reason := fmt.Sprintf("collection interrupted: %v, -timeout %d seconds", errCollectionDeadline, c.cmdLineArgs.timeout)
+		var lines []string
+		for _, label := range missing {
+			lines = append(lines, label+"\t"+reason)
+		}
+		results = append(results, map[string]string{
+			"label":      notCollectedLabel,
+			"command":    "",
+			"superuser":  "false",
+			"stdout":     strings.Join(lines, "\n"),
+			"stderr":     "",
+			"exitstatus": "0",
+		})
+		log.Printf("stopped collecting from %s at the deadline, %d of %d commands weren't collected: %s",
+			c.target.GetName(), len(missing), c.commandCount, strings.Join(missing, ", "))
+	}
+	c.outputFilePath = filepath.Join(c.outputDir, c.target.GetName()+getRawFileExtension(c.cmdLineArgs.rawFormat))
+	if err = writeRawResults(c.outputFilePath, c.cmdLineArgs.rawFormat, cf.Args.Name, results); err != nil {
+		return
+	}
+	if err = c.addCollectionResults(c.outputFilePath); err != nil {
+		return
+	}
+	c.ok = true
+	if err := os.WriteFile(filepath.Join(c.outputDir, c.target.GetName()+"_collector.log"), []byte(summary.String()), 0644); err != nil {
+		log.Printf("failed to write collector.log for %s: %v", c.target.GetName(), err)
+	}
+	return
+}
+
+// isWindowsTarget returns true if the target is a Windows host
+func isWindowsTarget(t target.Target) bool {
+	_, ok := t.(*target.WindowsTarget)
+	return ok
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/intel/svr-info/internal/target"
+)
+
+func TestWindowsCommandFile(t *testing.T) {
+	cf, err := getWindowsCommandFile(&CmdLineArgs{cmdTimeout: 300}, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.Args.Name != "host" || cf.Args.Timeout != 300 {
+		t.Errorf("unexpected arguments: %v", cf.Args)
+	}
+	run := make(map[string]bool)
+	for _, cmd := range cf.Commands {
+		run[cmd.Label] = cmd.Run
+		// the encoded script must fit on cmd.exe's command line
+		if length := len(utf16.Encode([]rune(cmd.Command))); length > target.MaxPowerShellScriptLength {
+			t.Errorf("%s: script is too long, %d characters", cmd.Label, length)
+		}
+	}
+	// the reports' host identity is created from these commands' output
+	for _, label := range []string{"date -u", "uname -a", "/etc/*-release", "lscpu", "/proc/meminfo", "dmidecode"} {
+		if !run[label] {
+			t.Errorf("%s isn't run", label)
+		}
+	}
+	// the host's identity is collected whatever the categories
+	if cf, err = getWindowsCommandFile(&CmdLineArgs{cmdTimeout: 300, collect: "storage"}, "host"); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range cf.Commands {
+		if cmd.Label == "lscpu" && !cmd.Run {
+			t.Error("lscpu isn't run with -collect storage")
+		}
+	}
+	if !isWindowsTarget(target.NewWindowsTarget("host", "host", "22", "user", "", "", "")) || isWindowsTarget(target.NewRemoteTarget("host", "host", "22", "user", "", "", "", "")) {
+		t.Error("unexpected Windows target type")
+	}
+}
@@ -0,0 +1,322 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/intel/svr-info/internal/core"
+	"golang.org/x/term"
+)
+
+// Wizard asks first-time users what they want to do and translates the answers into
+// command line arguments, writing a targets file when collecting from remote targets
+type Wizard struct {
+	in           *bufio.Reader
+	out          io.Writer
+	readPassword func() (string, error)
+}
+
+func newWizard(in io.Reader, out io.Writer) *Wizard {
+	w := &Wizard{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+	// don't echo passwords when input is from a terminal
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		w.readPassword = func() (string, error) {
+			pwd, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintf(w.out, "\n")
+			return string(pwd), err
+		}
+	} else {
+		w.readPassword = w.readLine
+	}
+	return w
+}
+
+func (w *Wizard) readLine() (line string, err error) {
+	line, err = w.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	line = strings.TrimSpace(line)
+	return
+}
+
+// ask prints the question and returns the answer, or defaultAnswer if the answer is empty
+func (w *Wizard) ask(question string, defaultAnswer string) (answer string, err error) {
+	if defaultAnswer != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, defaultAnswer)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	answer, err = w.readLine()
+	if err != nil {
+		return
+	}
+	if answer == "" {
+		answer = defaultAnswer
+	}
+	return
+}
+
+// askValid asks until the answer is accepted by valid
+func (w *Wizard) askValid(question string, defaultAnswer string, valid func(string) error) (answer string, err error) {
+	for {
+		answer, err = w.ask(question, defaultAnswer)
+		if err != nil {
+			return
+		}
+		validErr := valid(answer)
+		if validErr == nil {
+			return
+		}
+		fmt.Fprintf(w.out, "  %v\n", validErr)
+	}
+}
+
+func (w *Wizard) askYesNo(question string, defaultYes bool) (yes bool, err error) {
+	choices := "y/N"
+	if defaultYes {
+		choices = "Y/n"
+	}
+	for {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, choices)
+		var answer string
+		if answer, err = w.readLine(); err != nil {
+			return
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			yes = defaultYes
+			return
+		case "y", "yes":
+			yes = true
+			return
+		case "n", "no":
+			yes = false
+			return
+		}
+		fmt.Fprintf(w.out, "  please answer y or n\n")
+	}
+}
+
+func (w *Wizard) askPassword(question string) (pwd string, err error) {
+	for {
+		fmt.Fprintf(w.out, "%s: ", question)
+		pwd, err = w.readPassword()
+		if err != nil {
+			return
+		}
+		if validErr := validTargetsFileField(pwd); validErr != nil {
+			fmt.Fprintf(w.out, "  %v\n", validErr)
+			continue
+		}
+		return
+	}
+}
+
+func validRequired(answer string) error {
+	if answer == "" {
+		return fmt.Errorf("a value is required")
+	}
+	return validTargetsFileField(answer)
+}
+
+// validTargetsFileField rejects the characters used as separators in the targets file
+func validTargetsFileField(answer string) error {
+	if strings.ContainsAny(answer, ":#") {
+		return fmt.Errorf("':' and '#' are not supported")
+	}
+	return nil
+}
+
+func validTypes(validTypes []string) func(string) error {
+	return func(answer string) error {
+		if !isValidType(validTypes, answer) {
+			return fmt.Errorf("choose from: %s", strings.Join(validTypes, ","))
+		}
+		return nil
+	}
+}
+
+func validPositiveInt(answer string) error {
+	if i, err := strconv.Atoi(answer); err != nil || i <= 0 {
+		return fmt.Errorf("a positive number is required")
+	}
+	return nil
+}
+
+// askTarget asks for the connection details of one remote target
+func (w *Wizard) askTarget() (t targetFromFile, err error) {
+	if t.ip, err = w.askValid("IP address or hostname", "", validRequired); err != nil {
+		return
+	}
+	if t.port, err = w.askValid("SSH port", "22", validPositiveInt); err != nil {
+		return
+	}
+	if t.user, err = w.askValid("User name", "", validRequired); err != nil {
+		return
+	}
+	if t.key, err = w.askValid("Path to SSH private key (leave empty to use a password)", "", validTargetsFileField); err != nil {
+		return
+	}
+	if t.key == "" {
+		if t.pwd, err = w.askPassword("SSH password"); err != nil {
+			return
+		}
+	}
+	if t.sudo, err = w.askPassword("sudo password (leave empty if passwordless sudo is configured)"); err != nil {
+		return
+	}
+	t.label, err = w.askValid("Label for the report (optional)", "", validTargetsFileField)
+	return
+}
+
+// formatTargetLine formats the target as a line in the targets file
+func formatTargetLine(t targetFromFile) string {
+	fields := []string{t.ip, t.port, t.user, t.key, t.pwd, t.sudo}
+	if t.label != "" {
+		fields = append([]string{t.label}, fields...)
+	}
+	return strings.Join(fields, ":")
+}
+
+// writeTargetsFile writes the targets to the file. An existing file is an error, satisfying
+// errors.Is(err, fs.ErrExist), unless overwrite is true.
+func writeTargetsFile(path string, targets []targetFromFile, overwrite bool) (err error) {
+	var sb strings.Builder
+	sb.WriteString("# targets file written by 'svr-info init'\n")
+	sb.WriteString("# see targets.example for the format\n")
+	for _, t := range targets {
+		sb.WriteString(formatTargetLine(t) + "\n")
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	// may contain passwords
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return
+	}
+	_, err = f.WriteString(sb.String())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
+
+// saveTargetsFile asks for the path of the targets file and writes it, asking before
+// overwriting an existing file
+func (w *Wizard) saveTargetsFile(targets []targetFromFile) (path string, err error) {
+	for {
+		if path, err = w.askValid("Save targets file as", "targets", validRequired); err != nil {
+			return
+		}
+		err = writeTargetsFile(path, targets, false)
+		if !errors.Is(err, fs.ErrExist) {
+			return
+		}
+		var overwrite bool
+		if overwrite, err = w.askYesNo(path+" exists, overwrite it", false); err != nil {
+			return
+		}
+		if overwrite {
+			err = writeTargetsFile(path, targets, true)
+			return
+		}
+	}
+}
+
+// run asks the questions and returns the equivalent command line arguments. Start is true
+// if the user wants to start collection now.
+func (w *Wizard) run() (arguments []string, start bool, err error) {
+	fmt.Fprintf(w.out, "This wizard asks a few questions to configure a data collection run.\n")
+	fmt.Fprintf(w.out, "Press Enter to accept the [default] answer.\n\n")
+	location, err := w.askValid("Collect data from this system (local) or from remote systems (remote)", "local", validTypes([]string{"local", "remote"}))
+	if err != nil {
+		return
+	}
+	if location == "remote" {
+		var targets []targetFromFile
+		for {
+			fmt.Fprintf(w.out, "\nRemote target %d\n", len(targets)+1)
+			var t targetFromFile
+			if t, err = w.askTarget(); err != nil {
+				return
+			}
+			targets = append(targets, t)
+			var another bool
+			if another, err = w.askYesNo("Add another target", false); err != nil {
+				return
+			}
+			if !another {
+				break
+			}
+		}
+		var path string
+		if path, err = w.saveTargetsFile(targets); err != nil {
+			return
+		}
+		fmt.Fprintf(w.out, "Wrote %s. It may contain passwords, keep it private.\n", path)
+		arguments = append(arguments, "-targets", path)
+	}
+	fmt.Fprintf(w.out, "\n")
+	benchmark, err := w.askYesNo("Run benchmarks (takes several minutes and loads the system)", false)
+	if err != nil {
+		return
+	}
+	if benchmark {
+		var benchmarks string
+		if benchmarks, err = w.askValid("Benchmarks ("+strings.Join(benchmarkTypes, ",")+")", "all", validTypes(benchmarkTypes)); err != nil {
+			return
+		}
+		arguments = append(arguments, "-benchmark", benchmarks)
+	}
+	profile, err := w.askYesNo("Profile system utilization", false)
+	if err != nil {
+		return
+	}
+	if profile {
+		var profiles, duration string
+		if profiles, err = w.askValid("Profilers ("+strings.Join(profileTypes, ",")+")", "all", validTypes(profileTypes)); err != nil {
+			return
+		}
+		if duration, err = w.askValid("Profile duration in seconds", "60", validPositiveInt); err != nil {
+			return
+		}
+		arguments = append(arguments, "-profile", profiles, "-profile_duration", duration)
+	}
+	format, err := w.askValid("Report formats ("+strings.Join(core.ReportTypes, ",")+")", "html,xlsx,json", validTypes(core.ReportTypes))
+	if err != nil {
+		return
+	}
+	if format != "html,xlsx,json" {
+		arguments = append(arguments, "-format", format)
+	}
+	output, err := w.ask("Output directory (leave empty to create one in the current directory)", "")
+	if err != nil {
+		return
+	}
+	if output != "" {
+		if err = os.MkdirAll(output, 0755); err != nil {
+			return
+		}
+		arguments = append(arguments, "-output", output)
+	}
+	fmt.Fprintf(w.out, "\nTo run with these settings later:\n  ./%s %s\n\n", filepath.Base(os.Args[0]), strings.Join(arguments, " "))
+	start, err = w.askYesNo("Start now", true)
+	return
+}
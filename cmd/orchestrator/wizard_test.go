@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWizardLocal(t *testing.T) {
+	answers := strings.Join([]string{
+		"",          // local
+		"y",         // benchmarks
+		"cpu,turbo", // which benchmarks
+		"n",         // profile
+		"json",      // format
+		"",          // output directory
+		"n",         // start now
+	}, "\n") + "\n"
+	w := newWizard(strings.NewReader(answers), io.Discard)
+	arguments, start, err := w.run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start {
+		t.Error("expected start to be false")
+	}
+	expected := "-benchmark cpu,turbo -format json"
+	if strings.Join(arguments, " ") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(arguments, " "))
+	}
+}
+
+func TestWizardRemote(t *testing.T) {
+	targetsPath := filepath.Join(t.TempDir(), "targets")
+	answers := strings.Join([]string{
+		"bogus",       // invalid, asked again
+		"remote",      // remote
+		"192.168.1.1", // ip
+		"",            // port
+		"elaine",      // user
+		"",            // key
+		"pass:word",   // invalid, asked again
+		"password",    // ssh password
+		"sudopass",    // sudo password
+		"",            // label
+		"y",           // add another
+		"192.168.1.2", // ip
+		"2222",        // port
+		"jerry",       // user
+		"",            // key
+		"password",    // ssh password
+		"",            // sudo password
+		"Xeon_Gen_4",  // label
+		"",            // add another
+		targetsPath,   // targets file
+		"",            // benchmarks
+		"",            // profile
+		"",            // format
+		"",            // output directory
+		"",            // start now
+	}, "\n") + "\n"
+	w := newWizard(strings.NewReader(answers), io.Discard)
+	arguments, start, err := w.run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start {
+		t.Error("expected start to be true")
+	}
+	expected := "-targets " + targetsPath
+	if strings.Join(arguments, " ") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(arguments, " "))
+	}
+	content, err := os.ReadFile(targetsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targets, err := newTargetsFile(targetsPath).parseContent(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+	if targets[0].ip != "192.168.1.1" || targets[0].port != "22" || targets[0].user != "elaine" || targets[0].pwd != "password" || targets[0].sudo != "sudopass" {
+		t.Errorf("unexpected first target: %+v", targets[0])
+	}
+	if targets[1].label != "Xeon_Gen_4" || targets[1].port != "2222" || targets[1].sudo != "" {
+		t.Errorf("unexpected second target: %+v", targets[1])
+	}
+}
+
+func TestWizardEndOfInput(t *testing.T) {
+	w := newWizard(strings.NewReader("remote\n"), io.Discard)
+	_, _, err := w.run()
+	if err == nil {
+		t.Error("expected error at end of input")
+	}
+}
+
+func TestWizardExistingTargetsFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	if err := os.WriteFile(existing, []byte("keep\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other")
+	answers := strings.Join([]string{
+		"remote",      // remote
+		"192.168.1.1", // ip
+		"",            // port
+		"elaine",      // user
+		"",            // key
+		"password",    // ssh password
+		"",            // sudo password
+		"",            // label
+		"",            // add another
+		existing,      // targets file, exists
+		"",            // don't overwrite
+		other,         // targets file
+		"n",           // benchmarks
+		"n",           // profile
+		"",            // format
+		"",            // output directory
+		"n",           // start now
+	}, "\n") + "\n"
+	arguments, _, err := newWizard(strings.NewReader(answers), io.Discard).run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "-targets " + other; strings.Join(arguments, " ") != expected {
+		t.Errorf("expected '%s', got '%s'", expected, strings.Join(arguments, " "))
+	}
+	if content, err := os.ReadFile(existing); err != nil || string(content) != "keep\n" {
+		t.Errorf("existing file was overwritten: %q, %v", content, err)
+	}
+	// overwritten when confirmed
+	if err = writeTargetsFile(existing, nil, false); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("expected fs.ErrExist, got %v", err)
+	}
+	if err = writeTargetsFile(existing, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(existing); string(content) == "keep\n" {
+		t.Error("existing file wasn't overwritten")
+	}
+}
@@ -31,8 +31,9 @@ type EventDefinition struct {
 type GroupDefinition []EventDefinition
 
 // LoadEventGroups reads the events defined in the architecture specific event definition file, then
-// expands them to include the per-device uncore events
-func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (groups []GroupDefinition, err error) {
+// expands them to include the per-device uncore events. When a list of metrics is provided, only
+// the groups needed to compute those metrics are loaded.
+func LoadEventGroups(eventDefinitionOverridePath string, metrics []MetricDefinition, metadata Metadata) (groups []GroupDefinition, err error) {
 	var file fs.File
 	if eventDefinitionOverridePath != "" {
 		if file, err = os.Open(eventDefinitionOverridePath); err != nil {
@@ -78,6 +79,13 @@ func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (gro
 	if err = scanner.Err(); err != nil {
 		return
 	}
+	if len(metrics) > 0 {
+		selected := selectEventGroups(groups, metrics)
+		if gCmdLineArgs.verbose {
+			log.Printf("Collecting %d of %d event groups for the selected metrics", len(selected), len(groups))
+		}
+		groups = selected
+	}
 	// expand uncore groups for all uncore devices
 	groups, err = expandUncoreGroups(groups, metadata)
 	// "fixed" PMU counters are not supported on (most) IaaS VMs, so we add a separate group
@@ -100,6 +108,52 @@ func LoadEventGroups(eventDefinitionOverridePath string, metadata Metadata) (gro
 	return
 }
 
+// selectEventGroups returns the groups needed to compute the metrics. The groups are chosen
+// for each metric as its variables are assigned to groups when the metric is computed, i.e.,
+// the group with the most of the metric's remaining variables first, so that the metric's
+// events are collected together as they would be with all groups.
+func selectEventGroups(groups []GroupDefinition, metrics []MetricDefinition) (selected []GroupDefinition) {
+	groupEventNames := make([]mapset.Set[string], len(groups))
+	for groupIdx, group := range groups {
+		groupEventNames[groupIdx] = mapset.NewSet[string]()
+		for _, event := range group {
+			name := event.Name
+			if name == "" {
+				name = event.Raw
+			}
+			groupEventNames[groupIdx].Add(name)
+		}
+	}
+	needed := make([]bool, len(groups))
+	for _, metric := range metrics {
+		remainingVariableNames := mapset.NewSetFromMapKeys(metric.Variables)
+		for remainingVariableNames.Cardinality() > 0 {
+			bestGroupIdx := -1
+			bestMatches := 0
+			var matchedNames mapset.Set[string]
+			for groupIdx := range groups {
+				intersection := remainingVariableNames.Intersect(groupEventNames[groupIdx])
+				if intersection.Cardinality() > bestMatches {
+					bestGroupIdx = groupIdx
+					bestMatches = intersection.Cardinality()
+					matchedNames = intersection
+				}
+			}
+			if bestGroupIdx == -1 { // the remaining events aren't collectable on this platform
+				break
+			}
+			needed[bestGroupIdx] = true
+			remainingVariableNames = remainingVariableNames.Difference(matchedNames)
+		}
+	}
+	for groupIdx, group := range groups {
+		if needed[groupIdx] {
+			selected = append(selected, group)
+		}
+	}
+	return
+}
+
 // isUncoreSupported confirms if platform has exposed uncore devices
 func isUncoreSupported(metadata Metadata) (supported bool) {
 	supported = false
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import "testing"
+
+func TestSelectEventGroups(t *testing.T) {
+	groups := []GroupDefinition{
+		{{Name: "L1D.REPLACEMENT"}, {Name: "cpu-cycles"}, {Name: "instructions"}},
+		{{Name: "L2_LINES_IN.ALL"}, {Name: "MEM_LOAD_RETIRED.L1_HIT"}, {Name: "cpu-cycles"}, {Name: "instructions"}},
+		{{Name: "UNC_CHA_TOR_INSERTS.IA_MISS_CRD", Device: "cha"}},
+		{{Raw: "cpu-cycles:k"}, {Raw: "instructions"}},
+	}
+	tests := []struct {
+		variables []string
+		expected  []int
+	}{
+		// all variables are in the first group
+		{[]string{"cpu-cycles", "instructions"}, []int{0}},
+		// the group with the most variables is chosen
+		{[]string{"L2_LINES_IN.ALL", "MEM_LOAD_RETIRED.L1_HIT", "instructions"}, []int{1}},
+		// the variables span groups
+		{[]string{"L1D.REPLACEMENT", "UNC_CHA_TOR_INSERTS.IA_MISS_CRD", "instructions"}, []int{0, 2}},
+		// events without names are matched by their raw definition
+		{[]string{"cpu-cycles:k"}, []int{3}},
+		// uncollectable events select no group
+		{[]string{"OCR.READS_TO_CORE.DRAM"}, nil},
+	}
+	for _, test := range tests {
+		metric := MetricDefinition{Name: "test", Variables: make(map[string]int)}
+		for _, variable := range test.variables {
+			metric.Variables[variable] = -1
+		}
+		selected := selectEventGroups(groups, []MetricDefinition{metric})
+		if len(selected) != len(test.expected) {
+			t.Errorf("%v: selected %d groups, expected %d", test.variables, len(selected), len(test.expected))
+			continue
+		}
+		for i, groupIdx := range test.expected {
+			if selected[i][0] != groups[groupIdx][0] {
+				t.Errorf("%v: selected %v, expected %v", test.variables, selected[i], groups[groupIdx])
+			}
+		}
+	}
+}
@@ -33,6 +33,7 @@ type EventFrame struct {
 	Timestamp   float64
 	Socket      string
 	CPU         string
+	Core        string
 	Cgroup      string
 }
 
@@ -50,6 +51,7 @@ type Event struct {
 	Value        float64 // parsed value
 	Group        int     // event group index
 	Socket       string  // only relevant if granularity is socket
+	Core         string  // only relevant if granularity is core
 }
 
 // GetEventFrames organizes raw events received from perf into one or more frames (groups of events) that
@@ -61,7 +63,7 @@ type Event struct {
 // one process at a time.
 //
 // The frames produced will differ based on the intended metric granularity. Current options are
-// system, socket, cpu (thread/logical CPU), but only when in system scope. Process and cgroup scope
+// system, socket, cpu (thread/logical CPU), core (physical core), but only when in system scope. Process and cgroup scope
 // only support system-level granularity.
 func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition, scope Scope, granularity Granularity, metadata Metadata) (eventFrames []EventFrame, err error) {
 	// parse raw events into list of Event
@@ -88,6 +90,8 @@ func GetEventFrames(rawEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 					eventFrame.CPU = event.CPU
 				} else if gCmdLineArgs.granularity == GranularitySocket {
 					eventFrame.Socket = event.Socket
+				} else if gCmdLineArgs.granularity == GranularityCore {
+					eventFrame.Core = event.Core
 				}
 				if gCmdLineArgs.scope == ScopeCgroup {
 					eventFrame.Cgroup = event.Cgroup
@@ -198,6 +202,36 @@ func coalesceEvents(allEvents []Event, scope Scope, granularity Granularity, met
 				newEvents[cpu] = append(newEvents[cpu], event)
 			}
 			coalescedEvents = append(coalescedEvents, newEvents...)
+		} else if granularity == GranularityCore {
+			// create one list of Events per physical core, summing the values of the core's
+			// logical CPUs, i.e., its hyperthreads
+			numCores := metadata.SocketCount * metadata.CoresPerSocket
+			newEvents := make([][]Event, numCores)
+			eventIndices := make([]map[string]int, numCores) // group and event name -> index in the core's list
+			for i := 0; i < numCores; i++ {
+				newEvents[i] = make([]Event, 0, len(allEvents)/numCores)
+				eventIndices[i] = make(map[string]int)
+			}
+			for _, event := range allEvents {
+				var cpu int
+				if cpu, err = strconv.Atoi(event.CPU); err != nil {
+					return
+				}
+				core, ok := metadata.CPUCoreMap[cpu]
+				if !ok {
+					err = fmt.Errorf("unknown core for CPU: %d", cpu)
+					return
+				}
+				key := fmt.Sprintf("%d:%s", event.Group, event.Event)
+				if idx, ok := eventIndices[core][key]; ok { // the same event from a sibling CPU
+					newEvents[core][idx].Value += event.Value
+					continue
+				}
+				event.Core = fmt.Sprintf("%d", core)
+				eventIndices[core][key] = len(newEvents[core])
+				newEvents[core] = append(newEvents[core], event)
+			}
+			coalescedEvents = append(coalescedEvents, newEvents...)
 		} else {
 			err = fmt.Errorf("unsupported granularity: %d", granularity)
 			return
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCoalesceEventsCore(t *testing.T) {
+	// 2 sockets, 2 cores per socket, hyperthreading, i.e., CPUs 4-7 are the cores' siblings
+	metadata := Metadata{SocketCount: 2, CoresPerSocket: 2, ThreadsPerCore: 2}
+	metadata.CPUCoreMap = createCPUCoreMap(metadata.CoresPerSocket, metadata.SocketCount, true)
+	var allEvents []Event
+	for _, name := range []string{"cycles", "instructions"} {
+		for cpu := 0; cpu < 8; cpu++ {
+			allEvents = append(allEvents, Event{Event: name, CPU: strconv.Itoa(cpu), Value: float64(cpu + 1)})
+		}
+	}
+	coalesced, err := coalesceEvents(allEvents, ScopeSystem, GranularityCore, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(coalesced) != 4 {
+		t.Fatalf("unexpected number of cores: %d", len(coalesced))
+	}
+	for core, events := range coalesced {
+		if len(events) != 2 || events[0].Event != "cycles" || events[1].Event != "instructions" {
+			t.Fatalf("unexpected events for core %d: %v", core, events)
+		}
+		// the values of CPU core and its sibling, core + 4
+		expected := float64(core+1) + float64(core+5)
+		if events[0].Value != expected || events[1].Value != expected || events[0].Core != strconv.Itoa(core) {
+			t.Errorf("unexpected events for core %d: %v", core, events)
+		}
+	}
+}
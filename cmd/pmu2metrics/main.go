@@ -11,10 +11,12 @@ import (
 	"bufio"
 	"embed"
 	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"log/syslog"
+	"math"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -41,9 +43,10 @@ const (
 	GranularitySystem Granularity = iota
 	GranularitySocket
 	GranularityCPU
+	GranularityCore
 )
 
-var GranularityOptions = []string{"system", "socket", "cpu"}
+var GranularityOptions = []string{"system", "socket", "cpu", "core"}
 
 // Scope represents the requested scope of event collection
 type Scope int
@@ -63,9 +66,10 @@ const (
 	FormatHuman Format = iota
 	FormatCSV
 	FormatWide
+	FormatJSON
 )
 
-var FormatOptions = []string{"human", "csv", "wide"}
+var FormatOptions = []string{"human", "csv", "wide", "json"}
 
 // Summary represents the format of the post-processed summary report
 type Summary int
@@ -152,20 +156,82 @@ func resourceExists(filename string) (exists bool) {
 	return
 }
 
+// MetricFrameJSON is one line of the JSON output, i.e., one frame of metrics
+type MetricFrameJSON struct {
+	Timestamp         string              `json:"timestamp"`
+	Socket            string              `json:"socket,omitempty"`
+	CPU               string              `json:"cpu,omitempty"`
+	Core              string              `json:"core,omitempty"`
+	PID               string              `json:"pid,omitempty"`
+	Cmd               string              `json:"cmd,omitempty"`
+	Cgroup            string              `json:"cid,omitempty"`
+	CPUModel          string              `json:"cpu_model"`
+	Microarchitecture string              `json:"microarchitecture"`
+	Metrics           map[string]*float64 `json:"metrics"` // nil when the value isn't a number
+}
+
+// isMachineReadable returns true if the output format is meant to be parsed, i.e., progress
+// messages must not be printed to stdout
+func isMachineReadable(format Format) bool {
+	return format == FormatCSV || format == FormatJSON
+}
+
+// printMetricsJSON prints one frame of metrics to stdout as a single line JSON object
+func printMetricsJSON(metricFrame MetricFrame, metadata Metadata) {
+	frame := MetricFrameJSON{
+		Timestamp:         gCollectionStartTime.Add(time.Second * time.Duration(int(metricFrame.Timestamp))).UTC().Format(time.RFC3339),
+		Socket:            metricFrame.Socket,
+		CPU:               metricFrame.CPU,
+		Core:              metricFrame.Core,
+		PID:               metricFrame.PID,
+		Cmd:               metricFrame.Cmd,
+		Cgroup:            metricFrame.Cgroup,
+		CPUModel:          metadata.ModelName,
+		Microarchitecture: metadata.Microarchitecture,
+		Metrics:           make(map[string]*float64, len(metricFrame.Metrics)),
+	}
+	for _, metric := range metricFrame.Metrics {
+		if math.IsNaN(metric.Value) || math.IsInf(metric.Value, 0) {
+			frame.Metrics[metric.Name] = nil
+			continue
+		}
+		value := metric.Value
+		frame.Metrics[metric.Name] = &value
+	}
+	out, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("failed to marshal metrics to JSON: %v", err)
+		return
+	}
+	fmt.Println(string(out))
+}
+
 // printMetrics prints one frame of metrics to stdout in the format requested by the user. The
 // frameCount argument is used to control when the headers are printed, e.g., on the first frame
 // only.
-func printMetrics(metricFrame MetricFrame, frameCount int) {
-	if gCmdLineArgs.outputFormat == FormatCSV {
+func printMetrics(metricFrame MetricFrame, frameCount int, metadata Metadata) {
+	if gCmdLineArgs.outputFormat == FormatJSON {
+		printMetricsJSON(metricFrame, metadata)
+	} else if gCmdLineArgs.outputFormat == FormatCSV {
+		// at core granularity, the CPU column is named CORE and holds the core number, so
+		// that post-processing groups the rows by core
+		cpu := metricFrame.CPU
+		if gCmdLineArgs.granularity == GranularityCore {
+			cpu = metricFrame.Core
+		}
 		if frameCount == 1 {
-			fmt.Print("TS,SKT,CPU,PID,CMD,CID,")
+			if gCmdLineArgs.granularity == GranularityCore {
+				fmt.Print("TS,SKT,CORE,PID,CMD,CID,")
+			} else {
+				fmt.Print("TS,SKT,CPU,PID,CMD,CID,")
+			}
 			names := make([]string, 0, len(metricFrame.Metrics))
 			for _, metric := range metricFrame.Metrics {
 				names = append(names, metric.Name)
 			}
 			fmt.Printf("%s\n", strings.Join(names, ","))
 		}
-		fmt.Printf("%d,%s,%s,%s,%s,%s,", gCollectionStartTime.Unix()+int64(metricFrame.Timestamp), metricFrame.Socket, metricFrame.CPU, metricFrame.PID, metricFrame.Cmd, metricFrame.Cgroup)
+		fmt.Printf("%d,%s,%s,%s,%s,%s,", gCollectionStartTime.Unix()+int64(metricFrame.Timestamp), metricFrame.Socket, cpu, metricFrame.PID, metricFrame.Cmd, metricFrame.Cgroup)
 		values := make([]string, 0, len(metricFrame.Metrics))
 		for _, metric := range metricFrame.Metrics {
 			values = append(values, strconv.FormatFloat(metric.Value, 'g', 8, 64))
@@ -183,6 +249,8 @@ func printMetrics(metricFrame MetricFrame, frameCount int) {
 			}
 			if metricFrame.CPU != "" {
 				fmt.Printf("- CPU: %s\n", metricFrame.CPU)
+			} else if metricFrame.Core != "" {
+				fmt.Printf("- Core: %s\n", metricFrame.Core)
 			} else if metricFrame.Socket != "" {
 				fmt.Printf("- Socket: %s\n", metricFrame.Socket)
 			}
@@ -211,6 +279,8 @@ func printMetrics(metricFrame MetricFrame, frameCount int) {
 				}
 				if metricFrame.CPU != "" {
 					header += "CPU   " // 3 + 3
+				} else if metricFrame.Core != "" {
+					header += "CORE   " // 4 + 3
 				} else if metricFrame.Socket != "" {
 					header += "SKT   " // 3 + 3
 				}
@@ -245,6 +315,9 @@ func printMetrics(metricFrame MetricFrame, frameCount int) {
 			if metricFrame.CPU != "" {
 				CPUColWidth := 3
 				row += fmt.Sprintf("%s%*s%*s", metricFrame.CPU, CPUColWidth-len(metricFrame.CPU), "", colSpacing, "")
+			} else if metricFrame.Core != "" {
+				coreColWidth := 4
+				row += fmt.Sprintf("%s%*s%*s", metricFrame.Core, coreColWidth-len(metricFrame.Core), "", colSpacing, "")
 			} else if metricFrame.Socket != "" {
 				SKTColWidth := 3
 				row += fmt.Sprintf("%s%*s%*s", metricFrame.Socket, SKTColWidth-len(metricFrame.Socket), "", colSpacing, "")
@@ -288,7 +361,7 @@ func getPerfCommandArgs(pid string, cgroups []string, timeout int, eventGroups [
 	args = append(args, "stat", "-I", fmt.Sprintf("%d", gCmdLineArgs.perfPrintInterval), "-j")
 	if gCmdLineArgs.scope == ScopeSystem {
 		args = append(args, "-a") // system-wide collection
-		if gCmdLineArgs.granularity == GranularityCPU || gCmdLineArgs.granularity == GranularitySocket {
+		if gCmdLineArgs.granularity != GranularitySystem {
 			args = append(args, "-A") // no aggregation
 		}
 	} else if gCmdLineArgs.scope == ScopeProcess {
@@ -475,12 +548,12 @@ func runPerf(process Process, cmd *exec.Cmd, eventGroupDefinitions []GroupDefini
 }
 
 // receiveMetrics prints metrics that it receives over the provided channel
-func receiveMetrics(frameChannel chan MetricFrame) {
+func receiveMetrics(frameChannel chan MetricFrame, metadata Metadata) {
 	totalFrameCount := 0
 	// block until next frame of metrics arrives, will exit loop when channel is closed
 	for frame := range frameChannel {
 		totalFrameCount++
-		printMetrics(frame, totalFrameCount)
+		printMetrics(frame, totalFrameCount, metadata)
 	}
 }
 
@@ -493,7 +566,7 @@ func doWork(perfPath string, eventGroupDefinitions []GroupDefinition, metricDefi
 	errorChannel := make(chan error)
 	frameChannel := make(chan MetricFrame)
 	totalRuntimeSeconds := 0 // only relevant in process scope
-	go receiveMetrics(frameChannel)
+	go receiveMetrics(frameChannel, metadata)
 	for {
 		// get current time for use in setting timestamps on output
 		gCollectionStartTime = time.Now()
@@ -580,7 +653,7 @@ func doWorkDebug(perfStatFilePath string, eventGroupDefinitions []GroupDefinitio
 				}
 				for _, metricFrame := range metricFrames {
 					frameCount++
-					printMetrics(metricFrame, frameCount)
+					printMetrics(metricFrame, frameCount, metadata)
 					outputLines = [][]byte{} // empty it
 				}
 			}
@@ -597,7 +670,7 @@ func doWorkDebug(perfStatFilePath string, eventGroupDefinitions []GroupDefinitio
 		}
 		for _, metricFrame := range metricFrames {
 			frameCount += 1
-			printMetrics(metricFrame, frameCount)
+			printMetrics(metricFrame, frameCount, metadata)
 		}
 	}
 	err = scanner.Err()
@@ -648,9 +721,9 @@ Collection Options
 
 Output Options
   -g, --granularity <option>
-        Specify the level of metric granularity. Only valid when collecting at system scope. Options: %[2]s (default: system).
+        Specify the level of metric granularity. Only valid when collecting at system scope. Options: %[2]s. 'cpu' is per logical CPU, 'core' is per physical core, i.e., its logical CPUs' events are summed (default: system).
   -o, --output <option>
-        Specify the output format. Options: %[3]s. 'csv' is required for post-processing (default: human).
+        Specify the output format. Options: %[3]s. 'csv' is required for post-processing. 'json' prints one JSON object per line, per interval, for log pipelines (default: human).
   -[v]v, --[very]verbose
         Enable verbose, or very verbose (-vv) logging (Default: False).
 
@@ -666,7 +739,7 @@ Advanced Options
   -l, --list
         Show metric names available on this platform and exit (default: False).
   -m, --metrics <metric names>
-        A quoted and comma separated list of metric names to include in output. Use --list to view metric names. (default: all metrics).
+        A quoted and comma separated list of metric names to include in output. Use --list to view metric names. Names may include '*' and '?' wildcards, they're matched without regard to case, and underscores match spaces. Names prefixed with '-' are excluded. Only the events required by the selected metrics are collected (default: all metrics).
   -e, --eventfile <path>
         Path to perf event definition file (default: None).
   -M, --metricfile <path>
@@ -691,6 +764,12 @@ Advanced Options
     $ sudo %[1]s --output csv --scope process --pid 12345,67890
   Specified Metrics to screen in wide format.
     $ sudo %[1]s --output wide --metrics "CPU utilization %%, TMA_Frontend_Bound(%%)"
+  LLC metrics and IPC, except the latency metrics, to screen in CSV format.
+    $ sudo %[1]s --output csv --metrics "ipc,*llc*,-*latency*"
+  Metrics with socket-level granularity to file in JSON lines format.
+    $ sudo %[1]s --output json --granularity socket >%[1]s.json
+  Metrics with core-level granularity to screen in wide format.
+    $ sudo %[1]s --output wide --granularity core
   Metrics for the "hottest" process to screen in CSV format.
     $ sudo %[1]s --output csv --scope process --count 1
 Post-processing Examples
@@ -933,7 +1012,7 @@ func mainReturnWithCode() int {
 			gCmdLineArgs.timeout = (qi + 1) * intervalSeconds
 		}
 	}
-	if gCmdLineArgs.outputFormat != FormatCSV {
+	if !isMachineReadable(gCmdLineArgs.outputFormat) {
 		fmt.Print("Loading.")
 	}
 	var metadata Metadata
@@ -955,7 +1034,7 @@ func mainReturnWithCode() int {
 	if gCmdLineArgs.verbose {
 		log.Printf("%s", metadata)
 	}
-	if gCmdLineArgs.outputFormat != FormatCSV {
+	if !isMachineReadable(gCmdLineArgs.outputFormat) {
 		fmt.Print(".")
 	}
 	evaluatorFunctions := GetEvaluatorFunctions()
@@ -971,7 +1050,7 @@ func mainReturnWithCode() int {
 		log.Printf("failed to load metric definitions: %v", err)
 		return exitError
 	}
-	if gCmdLineArgs.outputFormat != FormatCSV {
+	if !isMachineReadable(gCmdLineArgs.outputFormat) {
 		fmt.Print(".")
 	}
 	if gCmdLineArgs.showMetricNames {
@@ -986,11 +1065,17 @@ func mainReturnWithCode() int {
 		return exitError
 	}
 	var groupDefinitions []GroupDefinition
-	if groupDefinitions, err = LoadEventGroups(gCmdLineArgs.eventFilePath, metadata); err != nil {
+	// collect only the events of the selected metrics, fewer events need less multiplexing,
+	// but recorded perf stat output has the events of all groups
+	var selectedMetricDefinitions []MetricDefinition
+	if len(selectedMetricNames) > 0 && gCmdLineArgs.perfStatFilePath == "" {
+		selectedMetricDefinitions = metricDefinitions
+	}
+	if groupDefinitions, err = LoadEventGroups(gCmdLineArgs.eventFilePath, selectedMetricDefinitions, metadata); err != nil {
 		log.Printf("failed to load event definitions: %v", err)
 		return exitError
 	}
-	if gCmdLineArgs.outputFormat != FormatCSV {
+	if !isMachineReadable(gCmdLineArgs.outputFormat) {
 		fmt.Print(".")
 	}
 	if gCmdLineArgs.perfStatFilePath != "" { // testing/debugging flow
@@ -1027,7 +1112,7 @@ func mainReturnWithCode() int {
 			}
 			defer SetNMIWatchdog(nmiWatchdog)
 		}
-		if gCmdLineArgs.outputFormat != FormatCSV {
+		if !isMachineReadable(gCmdLineArgs.outputFormat) {
 			fmt.Print(".")
 		}
 		var perfMuxIntervals map[string]int
@@ -1040,7 +1125,7 @@ func mainReturnWithCode() int {
 			return exitError
 		}
 		defer SetMuxIntervals(perfMuxIntervals)
-		if gCmdLineArgs.outputFormat != FormatCSV {
+		if !isMachineReadable(gCmdLineArgs.outputFormat) {
 			fmt.Print(".\n")
 			fmt.Printf("Reporting metrics in %d millisecond intervals...\n", gCmdLineArgs.perfPrintInterval)
 		}
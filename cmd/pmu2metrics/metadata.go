@@ -28,6 +28,7 @@ import (
 type Metadata struct {
 	CoresPerSocket      int `yaml:"CoresPerSocket"`
 	CPUSocketMap        map[int]int
+	CPUCoreMap          map[int]int
 	DeviceIDs           map[string][]int `yaml:"DeviceIDs"`
 	Microarchitecture   string           `yaml:"Microarchitecture"`
 	ModelName           string
@@ -119,8 +120,9 @@ func LoadMetadata() (metadata Metadata, err error) {
 	} else {
 		metadata.ThreadsPerCore = 1
 	}
-	// CPUSocketMap
+	// CPUSocketMap and CPUCoreMap
 	metadata.CPUSocketMap = createCPUSocketMap(metadata.CoresPerSocket, metadata.SocketCount, metadata.ThreadsPerCore == 2)
+	metadata.CPUCoreMap = createCPUCoreMap(metadata.CoresPerSocket, metadata.SocketCount, metadata.ThreadsPerCore == 2)
 	// System TSC Frequency
 	metadata.TSCFrequencyHz = GetTSCFreqMHz() * 1000000
 	// calculate TSC
@@ -156,6 +158,7 @@ func LoadMetadataFromFile(metadataFilePath string) (metadata Metadata, err error
 		return
 	}
 	metadata.CPUSocketMap = createCPUSocketMap(metadata.CoresPerSocket, metadata.SocketCount, metadata.ThreadsPerCore == 2)
+	metadata.CPUCoreMap = createCPUCoreMap(metadata.CoresPerSocket, metadata.SocketCount, metadata.ThreadsPerCore == 2)
 	return
 }
 
@@ -363,3 +366,20 @@ func createCPUSocketMap(coresPerSocket int, sockets int, hyperthreading bool) (c
 	}
 	return cpuSocketMap
 }
+
+// createCPUCoreMap creates a map from CPU number to physical core number, numbered across
+// the sockets, e.g., the first core of the second socket is core coresPerSocket
+func createCPUCoreMap(coresPerSocket int, sockets int, hyperthreading bool) (cpuCoreMap map[int]int) {
+	cpuCoreMap = make(map[int]int)
+	totalCores := coresPerSocket * sockets
+	totalCPUs := totalCores
+	if hyperthreading {
+		totalCPUs *= 2
+	}
+	for i := 0; i < totalCPUs; i++ {
+		// with non-adjacent hyperthreading, the second logical CPU of each core is in the
+		// second half, as in createCPUSocketMap
+		cpuCoreMap[i] = i % totalCores
+	}
+	return
+}
@@ -10,10 +10,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/Knetic/govaluate"
-	"github.com/intel/svr-info/internal/util"
 )
 
 type Variable struct {
@@ -31,8 +31,9 @@ type MetricDefinition struct {
 // LoadMetricDefinitions reads and parses metric definitions from an architecture-specific metric
 // definition file. When the override path argument is empty, the function will load metrics from
 // the file associated with the platform's architecture found in the provided metadata. When
-// a list of metric names is provided, only those metric definitions will be loaded.
-func LoadMetricDefinitions(metricDefinitionOverridePath string, selectedMetrics []string, metadata Metadata) (metrics []MetricDefinition, err error) {
+// a list of metric selectors is provided, only the selected metric definitions will be loaded,
+// see selectMetrics.
+func LoadMetricDefinitions(metricDefinitionOverridePath string, selectors []string, metadata Metadata) (metrics []MetricDefinition, err error) {
 	var bytes []byte
 	if metricDefinitionOverridePath != "" {
 		if bytes, err = os.ReadFile(metricDefinitionOverridePath); err != nil {
@@ -51,31 +52,88 @@ func LoadMetricDefinitions(metricDefinitionOverridePath string, selectedMetrics
 	for i := range metricsInFile {
 		metricsInFile[i].Name = strings.TrimPrefix(metricsInFile[i].Name, "metric_")
 	}
-	// if a list of metric names provided, reduce list to match
-	if len(selectedMetrics) > 0 {
-		// confirm provided metric names are valid (included in metrics defined in file)
-		for _, metricName := range selectedMetrics {
-			found := false
-			for _, metric := range metricsInFile {
-				if metricName == metric.Name {
-					found = true
-					break
-				}
-			}
-			if !found {
-				err = fmt.Errorf("provided metric name not found: %s", metricName)
-				return
-			}
+	// if a list of metric selectors provided, reduce list to match
+	if len(selectors) > 0 {
+		metrics, err = selectMetrics(metricsInFile, selectors)
+	} else {
+		metrics = metricsInFile
+	}
+	return
+}
+
+// getMetricPattern returns the regular expression that matches the metric names matched
+// by the glob, i.e., '*' matches any characters and '?' matches one character. Matching
+// ignores case, and underscores and spaces match each other, e.g., memory_bandwidth
+// matches "memory bandwidth".
+func getMetricPattern(glob string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("(?i)^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		case '_', ' ':
+			pattern.WriteString("[_ ]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(c)))
 		}
-		// build list of metrics based on provided list of metric names
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// selectMetrics returns the metrics, in their definition order, that are matched by the
+// selectors. A selector is a metric name or a glob, see getMetricPattern. Selectors that
+// start with '-' exclude the metrics they match. When all selectors are exclusions, the
+// metrics not excluded are selected. Selectors that match no metric are an error, they're
+// likely misspelled.
+func selectMetrics(metricsInFile []MetricDefinition, selectors []string) (metrics []MetricDefinition, err error) {
+	var includes, excludes []*regexp.Regexp
+	for _, selector := range selectors {
+		exclude := strings.HasPrefix(selector, "-")
+		glob := strings.TrimSpace(strings.TrimPrefix(selector, "-"))
+		if glob == "" {
+			continue
+		}
+		pattern := getMetricPattern(glob)
+		found := false
 		for _, metric := range metricsInFile {
-			if !util.StringInList(metric.Name, selectedMetrics) {
-				continue
+			if pattern.MatchString(metric.Name) {
+				found = true
+				break
 			}
-			metrics = append(metrics, metric)
 		}
-	} else {
-		metrics = metricsInFile
+		if !found {
+			err = fmt.Errorf("provided metric name or pattern not found: %s", glob)
+			return
+		}
+		if exclude {
+			excludes = append(excludes, pattern)
+		} else {
+			includes = append(includes, pattern)
+		}
+	}
+	matchesAny := func(patterns []*regexp.Regexp, name string) bool {
+		for _, pattern := range patterns {
+			if pattern.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, metric := range metricsInFile {
+		if len(includes) > 0 && !matchesAny(includes, metric.Name) {
+			continue
+		}
+		if matchesAny(excludes, metric.Name) {
+			continue
+		}
+		metrics = append(metrics, metric)
+	}
+	if len(metrics) == 0 {
+		err = fmt.Errorf("no metrics selected by: %s", strings.Join(selectors, ","))
 	}
 	return
 }
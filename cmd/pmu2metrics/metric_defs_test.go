@@ -4,7 +4,10 @@
  */
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestTransformConditional(t *testing.T) {
 	var in string
@@ -74,3 +77,43 @@ func TestTransformConditional(t *testing.T) {
 		t.Errorf("improper transform: [%s] -> [%s]", in, out)
 	}
 }
+
+func TestSelectMetrics(t *testing.T) {
+	var metricsInFile []MetricDefinition
+	for _, name := range []string{"CPI", "IPC", "LLC MPI", "Average LLC data read miss latency (in ns)", "memory bandwidth read (MB/sec)", "TMA_Frontend_Bound(%)"} {
+		metricsInFile = append(metricsInFile, MetricDefinition{Name: name})
+	}
+	names := func(metrics []MetricDefinition) (names []string) {
+		for _, metric := range metrics {
+			names = append(names, metric.Name)
+		}
+		return
+	}
+	tests := []struct {
+		selectors []string
+		expected  []string
+	}{
+		{[]string{"IPC"}, []string{"IPC"}},
+		{[]string{"ipc", "memory_bandwidth*"}, []string{"IPC", "memory bandwidth read (MB/sec)"}},
+		{[]string{"*llc*"}, []string{"LLC MPI", "Average LLC data read miss latency (in ns)"}},
+		{[]string{"*llc*", "-*latency*"}, []string{"LLC MPI"}},
+		{[]string{"-*llc*", "-TMA*", "-?PI"}, []string{"IPC", "memory bandwidth read (MB/sec)"}},
+		{[]string{"TMA_Frontend_Bound(%)"}, []string{"TMA_Frontend_Bound(%)"}},
+	}
+	for _, test := range tests {
+		metrics, err := selectMetrics(metricsInFile, test.selectors)
+		if err != nil {
+			t.Errorf("%v: %v", test.selectors, err)
+			continue
+		}
+		if strings.Join(names(metrics), "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%v: got %v, expected %v", test.selectors, names(metrics), test.expected)
+		}
+	}
+	if _, err := selectMetrics(metricsInFile, []string{"IPC", "*llcc*"}); err == nil {
+		t.Error("didn't catch pattern that matches no metric")
+	}
+	if _, err := selectMetrics(metricsInFile, []string{"IPC", "-IPC"}); err == nil {
+		t.Error("didn't catch selectors that select no metric")
+	}
+}
@@ -31,6 +31,7 @@ type MetricFrame struct {
 	FrameCount int
 	Socket     string
 	CPU        string
+	Core       string
 	Cgroup     string
 	PID        string
 	Cmd        string
@@ -51,6 +52,7 @@ func ProcessEvents(perfEvents [][]byte, eventGroupDefinitions []GroupDefinition,
 		metricFrame.Timestamp = eventFrame.Timestamp
 		metricFrame.Socket = eventFrame.Socket
 		metricFrame.CPU = eventFrame.CPU
+		metricFrame.Core = eventFrame.Core
 		metricFrame.Cgroup = eventFrame.Cgroup
 		metricFrame.PID = process.pid
 		metricFrame.Cmd = process.cmd
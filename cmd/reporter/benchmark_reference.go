@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BenchmarkReference is a set of reference benchmark results, e.g., vendor-claimed or
+// lab-measured values, that each host's benchmark summary is compared to for acceptance
+// testing. The metrics are named as in the benchmark Summary table. Their values are
+// numbers in the Summary table's units, or values with units, e.g., "250.5 GB/s" or
+// "3.5 GHz", that are converted to the Summary table's units.
+type BenchmarkReference struct {
+	Name    string                 `json:"name"` // optional, e.g., "Vendor Specification"
+	Metrics map[string]interface{} `json:"metrics"`
+	values  map[string]float64     // in the Summary table's units
+}
+
+// loadBenchmarkReference loads the reference benchmark results from path, nil if path is
+// empty
+func loadBenchmarkReference(path string) (reference *BenchmarkReference, err error) {
+	if path == "" {
+		return
+	}
+	jsonBytes, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	reference = &BenchmarkReference{}
+	if err = json.Unmarshal(jsonBytes, reference); err != nil {
+		err = fmt.Errorf("failed to parse benchmark reference file %s: %v", path, err)
+		return
+	}
+	if len(reference.Metrics) == 0 {
+		err = fmt.Errorf("benchmark reference file %s has no metrics", path)
+		return
+	}
+	if reference.Name == "" {
+		reference.Name = "Reference"
+	}
+	reference.values = make(map[string]float64)
+	for name, value := range reference.Metrics {
+		metric := getReferenceMetric(name)
+		if metric == nil {
+			var names []string
+			for _, metric := range benchmarkMetrics {
+				names = append(names, metric.name)
+			}
+			err = fmt.Errorf("invalid metric in benchmark reference file %s: %s, choose from: %s", path, name, strings.Join(names, ", "))
+			return
+		}
+		var number float64
+		var ok bool
+		switch v := value.(type) {
+		case float64:
+			number, ok = v, true
+		case string:
+			number, ok = convertReferenceValue(v, metric.unit)
+		}
+		if !ok || number <= 0 {
+			err = fmt.Errorf("invalid value of %s in benchmark reference file %s: %v, expected a positive number in %s or a value in units that convert to %s", name, path, value, metric.unit, metric.unit)
+			return
+		}
+		reference.values[name] = number
+	}
+	return
+}
+
+func getReferenceMetric(name string) *benchmarkMetric {
+	for i := range benchmarkMetrics {
+		if benchmarkMetrics[i].name == name {
+			return &benchmarkMetrics[i]
+		}
+	}
+	return nil
+}
+
+// convertReferenceValue converts a reference value, e.g., "3.5 GHz", to a number in the
+// unit, e.g., 3500 for MHz. A value without a unit is in the unit. The ok return value is
+// false when the value's unit can't be converted to the unit, e.g., "250 GB/s" to iops.
+func convertReferenceValue(value string, unit string) (number float64, ok bool) {
+	if quantity, canonical, found := normalizeQuantity("", value); found {
+		perUnit, unitCanonical, unitFound := normalizeQuantity("", "1 "+unit)
+		if unitFound && unitCanonical == canonical {
+			number, ok = quantity/perUnit, true
+		}
+		return
+	}
+	fields := strings.Fields(value)
+	if len(fields) > 2 || (len(fields) == 2 && !strings.EqualFold(fields[1], unit)) {
+		return
+	}
+	return getBenchmarkMetricValue(value)
+}
+
+// addBenchmarkReferenceValues adds each referenced metric's reference value, and the
+// delta of the host's value from it, after the metric's value in the benchmark Summary
+// table, followed by the reference's name and the metrics that are below the reference.
+// A metric is below the reference when it's worse than the reference by more than the
+// threshold percentage.
+func addBenchmarkReferenceValues(tableSummary *Table, reference *BenchmarkReference, threshold float64) {
+	for hostIndex := range tableSummary.AllHostValues {
+		hostValues := &tableSummary.AllHostValues[hostIndex]
+		if len(hostValues.Values) == 0 {
+			continue
+		}
+		var valueNames, values, below []string
+		for valueIndex, valueName := range hostValues.ValueNames {
+			value := hostValues.Values[0][valueIndex]
+			valueNames = append(valueNames, valueName)
+			values = append(values, value)
+			metric := getReferenceMetric(valueName)
+			if metric == nil {
+				continue
+			}
+			referenceValue, ok := reference.values[metric.name]
+			if !ok {
+				continue
+			}
+			var delta string
+			if number, ok := getBenchmarkMetricValue(value); ok {
+				change := (number - referenceValue) / referenceValue * 100
+				delta = fmt.Sprintf("%+.1f%%", change)
+				if (metric.higherIsBetter && change < -threshold) || (!metric.higherIsBetter && change > threshold) {
+					below = append(below, metric.name)
+				}
+			}
+			valueNames = append(valueNames, metric.name+" Reference", metric.name+" vs Reference")
+			values = append(values, strconv.FormatFloat(referenceValue, 'f', -1, 64)+" "+metric.unit, delta)
+		}
+		hostValues.ValueNames = append(valueNames, "Reference", "Below Reference")
+		hostValues.Values[0] = append(values, reference.Name, strings.Join(below, ", "))
+	}
+}
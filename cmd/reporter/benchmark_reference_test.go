@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertReferenceValue(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		unit     string
+		expected float64
+		ok       bool
+	}{
+		{"3500", "MHz", 3500, true},
+		{"3500 MHz", "MHz", 3500, true},
+		{"3.5 GHz", "MHz", 3500, true},
+		{"250 GB/s", "GB/s", 250, true},
+		{"2000 Mb/s", "GB/s", 0.25, true},
+		{"200k iops", "iops", 200000, true},
+		{"300 watts", "Watts", 300, true},
+		{"250 GB/s", "iops", 0, false},
+		{"3.5 GHz", "GB/s", 0, false},
+		{"80 ms", "ns", 0, false},
+		{"fast", "ops/s", 0, false},
+	} {
+		number, ok := convertReferenceValue(test.value, test.unit)
+		if ok != test.ok || number != test.expected {
+			t.Errorf("%s in %s : expected %g %t, got %g %t", test.value, test.unit, test.expected, test.ok, number, ok)
+		}
+	}
+}
+
+func TestBenchmarkReference(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reference.json")
+	content := `{"name": "Vendor", "metrics": {"All-core Turbo Frequency": "3.2 GHz", "Memory Minimum Latency": 100, "Disk Speed": "200k iops"}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reference, err := loadBenchmarkReference(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary := &Table{
+		Name: "Summary",
+		AllHostValues: []HostValues{
+			{Name: "host1", ValueNames: []string{"All-core Turbo Frequency", "Memory Minimum Latency", "Disk Speed"}, Values: [][]string{{"3100 MHz", "120.0 ns", ""}}},
+		},
+	}
+	addBenchmarkReferenceValues(summary, reference, 5)
+	hv := summary.AllHostValues[0]
+	for name, expected := range map[string]string{
+		"All-core Turbo Frequency Reference":    "3200 MHz",
+		"All-core Turbo Frequency vs Reference": "-3.1%",
+		"Memory Minimum Latency vs Reference":   "+20.0%",
+		"Disk Speed Reference":                  "200000 iops",
+		"Disk Speed vs Reference":               "",
+		"Reference":                             "Vendor",
+		"Below Reference":                       "Memory Minimum Latency",
+	} {
+		if value, err := summary.getValue(0, name); err != nil || value != expected {
+			t.Errorf("%s : expected %q, got %q (%v)", name, expected, value, err)
+		}
+	}
+	if len(hv.ValueNames) != 11 || hv.ValueNames[1] != "All-core Turbo Frequency Reference" {
+		t.Errorf("unexpected value names: %v", hv.ValueNames)
+	}
+	// a value in units that don't convert to the Summary table's
+	if err = os.WriteFile(path, []byte(`{"metrics": {"Disk Speed": "2 GB/s"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadBenchmarkReference(path); err == nil {
+		t.Error("expected error for mismatched units")
+	}
+}
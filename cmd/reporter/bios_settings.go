@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type BIOSSettingDef struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+}
+
+// BIOSSetting is a setting as reported by a vendor utility or Redfish
+type BIOSSetting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+var reNonAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+
+// normalizeBIOSSettingName removes case, spaces, and punctuation so that names can be
+// matched against aliases
+func normalizeBIOSSettingName(name string) string {
+	return reNonAlphanumeric.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// normalizeBIOSSettingValue maps the various ways vendors express on and off to
+// Enabled and Disabled
+func normalizeBIOSSettingValue(value string) string {
+	switch normalizeBIOSSettingName(value) {
+	case "enabled", "enable", "on":
+		return "Enabled"
+	case "disabled", "disable", "off":
+		return "Disabled"
+	}
+	return value
+}
+
+func loadBIOSSettingDefs() (defs []BIOSSettingDef, err error) {
+	yamlBytes, err := resources.ReadFile("resources/bios_settings.yaml")
+	if err != nil {
+		return
+	}
+	err = yaml.UnmarshalStrict(yamlBytes, &defs)
+	return
+}
+
+// parseINISettings parses the settings saved by Intel's syscfg utility. Values are
+// option codes that are described by the preceding ';Options:' comment, e.g.,
+//
+//	;Options: Enable=01: Disable=00
+//	Intel(R) Hyper-Threading Tech=01
+func parseINISettings(output string) (settings []BIOSSetting) {
+	options := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ";Options:") {
+			options = map[string]string{}
+			for _, option := range strings.Split(strings.TrimPrefix(line, ";Options:"), ":") {
+				if label, code, found := strings.Cut(strings.TrimSpace(option), "="); found {
+					options[strings.TrimSpace(code)] = strings.TrimSpace(label)
+				}
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if label, ok := options[value]; ok {
+			value = label
+		}
+		settings = append(settings, BIOSSetting{Name: strings.TrimSpace(name), Value: value, Source: "syscfg"})
+		options = map[string]string{}
+	}
+	return
+}
+
+// parseRacadmSettings parses the output of Dell's 'racadm get BIOS.<group>'. Read-only
+// settings are prefixed with '#'.
+func parseRacadmSettings(output string) (settings []BIOSSetting) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "#")
+		if line == "" || strings.HasPrefix(line, "[") {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		settings = append(settings, BIOSSetting{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value), Source: "racadm"})
+	}
+	return
+}
+
+// parseJSONSettings parses BIOS attributes in JSON format, i.e., the Redfish Bios
+// resource or the output of HPE's ilorest
+func parseJSONSettings(output string, source string) (settings []BIOSSetting) {
+	start := strings.Index(output, "{")
+	if start == -1 {
+		return
+	}
+	// decode only the first object, ilorest may return a list of objects
+	var resource map[string]interface{}
+	if err := json.NewDecoder(strings.NewReader(output[start:])).Decode(&resource); err != nil {
+		return
+	}
+	attributes := resource
+	if a, ok := resource["Attributes"].(map[string]interface{}); ok {
+		attributes = a
+	}
+	for name, value := range attributes {
+		switch v := value.(type) {
+		case string, float64, bool:
+			settings = append(settings, BIOSSetting{Name: name, Value: fmt.Sprintf("%v", v), Source: source})
+		}
+	}
+	return
+}
+
+// getBIOSSettings returns the settings from all sources. Vendor utilities are listed
+// before Redfish because they report the settings of the running system.
+func (s *Source) getBIOSSettings() (settings []BIOSSetting) {
+	sections := s.getCommandOutputSections("bios settings")
+	settings = append(settings, parseINISettings(sections["syscfg"])...)
+	settings = append(settings, parseRacadmSettings(sections["racadm"])...)
+	settings = append(settings, parseJSONSettings(sections["ilorest"], "ilorest")...)
+	settings = append(settings, parseJSONSettings(s.getCommandOutput("redfish bios settings"), "redfish")...)
+	return
+}
+
+// normalizeBIOSSettings returns the settings that match a definition, in the order of
+// the definitions. The first source that reports a setting is used.
+func normalizeBIOSSettings(settings []BIOSSetting, defs []BIOSSettingDef) (normalized [][]string) {
+	for _, def := range defs {
+		aliases := make(map[string]bool)
+		for _, alias := range def.Aliases {
+			aliases[normalizeBIOSSettingName(alias)] = true
+		}
+		for _, setting := range settings {
+			if aliases[normalizeBIOSSettingName(setting.Name)] {
+				normalized = append(normalized, []string{def.Name, normalizeBIOSSettingValue(setting.Value), setting.Name, setting.Value, setting.Source})
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// The -filter option selects the hosts to include in the reports by the tags saved with
+// their data, e.g., 'env=prod and rack!=12'. A comparison is key=value or key!=value,
+// where the value may be a glob pattern, e.g., rack=1*. Comparisons are combined with
+// and, or, not, and parentheses. A host without the key doesn't match key=value and does
+// match key!=value. The hostname can be compared with the host key.
+
+// tagsLabel labels the tags in the collected data, one key=value per line
+const tagsLabel = "tags"
+
+// hostTagKey is the key that compares the hostname
+const hostTagKey = "host"
+
+// getTags returns the tags saved with the host's data
+func (s *Source) getTags() (tags map[string]string) {
+	tags = make(map[string]string)
+	for _, line := range s.getCommandOutputLines(tagsLabel) {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			tags[key] = value
+		}
+	}
+	return
+}
+
+// tagFilter is a node in the parsed filter expression
+type tagFilter interface {
+	match(tags map[string]string) bool
+}
+
+type tagComparison struct {
+	key     string
+	pattern string
+	negate  bool
+}
+
+func (c *tagComparison) match(tags map[string]string) bool {
+	value, ok := tags[c.key]
+	matched := false
+	if ok {
+		matched, _ = path.Match(c.pattern, value)
+	}
+	return matched != c.negate
+}
+
+type tagAnd struct{ a, b tagFilter }
+
+func (n *tagAnd) match(tags map[string]string) bool { return n.a.match(tags) && n.b.match(tags) }
+
+type tagOr struct{ a, b tagFilter }
+
+func (n *tagOr) match(tags map[string]string) bool { return n.a.match(tags) || n.b.match(tags) }
+
+type tagNot struct{ a tagFilter }
+
+func (n *tagNot) match(tags map[string]string) bool { return !n.a.match(tags) }
+
+// tokenizeFilter splits the expression into words, parentheses, and the = and !=
+// operators
+func tokenizeFilter(expression string) (tokens []string) {
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	runes := []rune(expression)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')' || r == '=':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			flush()
+			tokens = append(tokens, "!=")
+			i++
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return
+}
+
+// filterParser is a recursive descent parser for the filter expression:
+//
+//	or         := and { "or" and }
+//	and        := unary { "and" unary }
+//	unary      := "not" unary | "(" or ")" | comparison
+//	comparison := key ( "=" | "!=" ) value
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) parseOr() (node tagFilter, err error) {
+	if node, err = p.parseAnd(); err != nil {
+		return
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		var right tagFilter
+		if right, err = p.parseAnd(); err != nil {
+			return
+		}
+		node = &tagOr{node, right}
+	}
+	return
+}
+
+func (p *filterParser) parseAnd() (node tagFilter, err error) {
+	if node, err = p.parseUnary(); err != nil {
+		return
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		var right tagFilter
+		if right, err = p.parseUnary(); err != nil {
+			return
+		}
+		node = &tagAnd{node, right}
+	}
+	return
+}
+
+func (p *filterParser) parseUnary() (node tagFilter, err error) {
+	token := p.next()
+	switch {
+	case strings.EqualFold(token, "not"):
+		var operand tagFilter
+		if operand, err = p.parseUnary(); err != nil {
+			return
+		}
+		node = &tagNot{operand}
+	case token == "(":
+		if node, err = p.parseOr(); err != nil {
+			return
+		}
+		if p.next() != ")" {
+			err = fmt.Errorf("missing )")
+		}
+	case token == "" || token == ")" || token == "=" || token == "!=":
+		err = fmt.Errorf("expected key=value, found '%s'", token)
+	default:
+		operator := p.next()
+		if operator != "=" && operator != "!=" {
+			err = fmt.Errorf("expected = or != after %s", token)
+			return
+		}
+		value := p.next()
+		if value == "" || value == "(" || value == ")" || value == "=" || value == "!=" {
+			err = fmt.Errorf("expected a value after %s%s", token, operator)
+			return
+		}
+		if _, err = path.Match(value, ""); err != nil {
+			err = fmt.Errorf("invalid pattern: %s", value)
+			return
+		}
+		node = &tagComparison{key: token, pattern: value, negate: operator == "!="}
+	}
+	return
+}
+
+// parseFilter parses the -filter expression
+func parseFilter(expression string) (filter tagFilter, err error) {
+	p := &filterParser{tokens: tokenizeFilter(expression)}
+	filter, err = p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.peek())
+	}
+	if err != nil {
+		err = fmt.Errorf("-filter %s : %v", expression, err)
+	}
+	return
+}
+
+// filterSources returns the sources whose tags match the filter
+func filterSources(sources []*Source, filter tagFilter) (filtered []*Source) {
+	for _, source := range sources {
+		tags := source.getTags()
+		tags[hostTagKey] = source.getHostname()
+		if filter.match(tags) {
+			filtered = append(filtered, source)
+		}
+	}
+	return
+}
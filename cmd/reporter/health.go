@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// HealthRule deducts points from a host's health score for each record of a table whose
+// field matches, e.g., an insight, an NVMe media error, or a benchmark regression
+type HealthRule struct {
+	Name    string  `yaml:"name"`
+	Table   string  `yaml:"table"`
+	Field   string  `yaml:"field"`
+	Match   string  `yaml:"match"`   // optional, numbers greater than zero match if empty
+	Penalty float64 `yaml:"penalty"` // per record
+	Max     float64 `yaml:"max"`     // optional, the most points deducted by the rule
+	re      *regexp.Regexp
+}
+
+// HealthGrade is the minimum score of a grade
+type HealthGrade struct {
+	Grade   string  `yaml:"grade"`
+	Minimum float64 `yaml:"minimum"`
+}
+
+type HealthWeights struct {
+	Rules  []HealthRule  `yaml:"rules"`
+	Grades []HealthGrade `yaml:"grades"`
+}
+
+// loadHealthWeights loads the health grade weights from path, or the weights bundled
+// with the reporter if path is empty
+func loadHealthWeights(path string) (weights *HealthWeights, err error) {
+	var yamlBytes []byte
+	if path == "" {
+		path = "resources/health_weights.yaml"
+		yamlBytes, err = resources.ReadFile(path)
+	} else {
+		yamlBytes, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return
+	}
+	weights = &HealthWeights{}
+	err = yaml.UnmarshalStrict(yamlBytes, weights)
+	if err != nil {
+		err = fmt.Errorf("failed to parse health weights file %s: %v", path, err)
+		return
+	}
+	for i := range weights.Rules {
+		rule := &weights.Rules[i]
+		if rule.Name == "" || rule.Table == "" || rule.Field == "" {
+			err = fmt.Errorf("health weights rule %d requires name, table, and field", i+1)
+			return
+		}
+		if rule.Penalty < 0 || rule.Max < 0 {
+			err = fmt.Errorf("health weights rule %d: penalty and max can't be negative", i+1)
+			return
+		}
+		if rule.Match != "" {
+			if rule.re, err = regexp.Compile(rule.Match); err != nil {
+				err = fmt.Errorf("invalid match in health weights rule %d: %v", i+1, err)
+				return
+			}
+		}
+	}
+	if len(weights.Grades) == 0 {
+		err = fmt.Errorf("health weights file %s has no grades", path)
+		return
+	}
+	for i, grade := range weights.Grades {
+		if grade.Grade == "" {
+			err = fmt.Errorf("health weights grade %d requires grade", i+1)
+			return
+		}
+		if i > 0 && grade.Minimum >= weights.Grades[i-1].Minimum {
+			err = fmt.Errorf("health weights grades must be listed highest minimum first, %s isn't", grade.Grade)
+			return
+		}
+	}
+	return
+}
+
+// matches returns true if the value counts toward the rule
+func (r *HealthRule) matches(value string) bool {
+	if r.re != nil {
+		return r.re.MatchString(value)
+	}
+	number, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	return err == nil && number > 0
+}
+
+// healthDeduction is the points a rule deducted from a host's score
+type healthDeduction struct {
+	name   string
+	count  int
+	points float64
+}
+
+// getHealthDeductions applies the rules to the host's records in the reports' tables. A
+// record counts toward the first of its table's rules that matches it.
+func (w *HealthWeights) getHealthDeductions(reports []*Report, sourceIdx int) (deductions []healthDeduction) {
+	counted := make(map[string]map[int]bool) // table -> record indices already counted
+	for i := range w.Rules {
+		rule := &w.Rules[i]
+		var table *Table
+		for _, report := range reports {
+			if report == nil {
+				continue
+			}
+			if table = report.findTable(rule.Table); table != nil {
+				break
+			}
+		}
+		if table == nil || sourceIdx >= len(table.AllHostValues) {
+			continue
+		}
+		hv := table.AllHostValues[sourceIdx]
+		valueIndex, err := findValueIndex(&hv, rule.Field)
+		if err != nil {
+			continue
+		}
+		if counted[rule.Table] == nil {
+			counted[rule.Table] = make(map[int]bool)
+		}
+		deduction := healthDeduction{name: rule.Name}
+		for recordIdx, record := range hv.Values {
+			if counted[rule.Table][recordIdx] || valueIndex >= len(record) || !rule.matches(record[valueIndex]) {
+				continue
+			}
+			counted[rule.Table][recordIdx] = true
+			deduction.count++
+		}
+		if deduction.count == 0 {
+			continue
+		}
+		deduction.points = float64(deduction.count) * rule.Penalty
+		if rule.Max > 0 && deduction.points > rule.Max {
+			deduction.points = rule.Max
+		}
+		deductions = append(deductions, deduction)
+	}
+	return
+}
+
+// getHealthGrade returns the grade of the score
+func (w *HealthWeights) getHealthGrade(score float64) string {
+	for _, grade := range w.Grades {
+		if score >= grade.Minimum {
+			return grade.Grade
+		}
+	}
+	return w.Grades[len(w.Grades)-1].Grade
+}
+
+// newHealthTable grades each host's health, for quick triage, from its insights, error
+// counters, e.g., NVMe media errors and hardware errors in the kernel log, and benchmark
+// regressions, weighted by the health weights. Each host starts with 100 points.
+func newHealthTable(sources []*Source, reports []*Report, weights *HealthWeights, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Health",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIdx, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Grade",
+				"Score",
+				"Deductions",
+			},
+			Values: [][]string{},
+		}
+		score := 100.0
+		var deductions []string
+		if weights != nil {
+			for _, deduction := range weights.getHealthDeductions(reports, sourceIdx) {
+				score -= deduction.points
+				deductions = append(deductions, fmt.Sprintf("%s: -%s (%d)", deduction.name, strconv.FormatFloat(deduction.points, 'f', -1, 64), deduction.count))
+			}
+		}
+		if score < 0 {
+			score = 0
+		}
+		grade := ""
+		if weights != nil {
+			grade = weights.getHealthGrade(score)
+		}
+		if len(deductions) == 0 {
+			deductions = []string{"None"}
+		}
+		hostValues.Values = append(hostValues.Values, []string{
+			grade,
+			strconv.FormatFloat(score, 'f', -1, 64),
+			strings.Join(deductions, ", "),
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func findHealthRule(t *testing.T, weights *HealthWeights, name string) *HealthRule {
+	for i := range weights.Rules {
+		if weights.Rules[i].Name == name {
+			return &weights.Rules[i]
+		}
+	}
+	t.Fatalf("rule not found: %s", name)
+	return nil
+}
+
+func TestLoadHealthWeights(t *testing.T) {
+	weights, err := loadHealthWeights("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights.Rules) == 0 || len(weights.Grades) == 0 {
+		t.Fatalf("unexpected weights: %v", weights)
+	}
+	for _, rule := range weights.Rules {
+		if rule.Match != "" && rule.re == nil {
+			t.Errorf("%s : match not compiled", rule.Name)
+		}
+	}
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"valid":        "rules:\n  - {name: Insight, table: Insight, field: Recommendation, match: ., penalty: 3}\ngrades:\n  - {grade: Pass, minimum: 50}\n  - {grade: Fail, minimum: 0}\n",
+		"no field":     "rules:\n  - {name: Insight, table: Insight, penalty: 3}\ngrades:\n  - {grade: Pass, minimum: 0}\n",
+		"negative":     "rules:\n  - {name: Insight, table: Insight, field: Recommendation, penalty: -3}\ngrades:\n  - {grade: Pass, minimum: 0}\n",
+		"bad match":    "rules:\n  - {name: Insight, table: Insight, field: Recommendation, match: '(', penalty: 3}\ngrades:\n  - {grade: Pass, minimum: 0}\n",
+		"no grades":    "rules:\n  - {name: Insight, table: Insight, field: Recommendation, penalty: 3}\n",
+		"grade order":  "rules: []\ngrades:\n  - {grade: Fail, minimum: 0}\n  - {grade: Pass, minimum: 50}\n",
+		"unknown key":  "rules: []\ngrades:\n  - {grade: Pass, minimum: 0, color: green}\n",
+		"invalid yaml": "rules: [\n",
+	} {
+		path := filepath.Join(dir, name+".yaml")
+		if err = os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = loadHealthWeights(path)
+		if name == "valid" && err != nil {
+			t.Errorf("%s : unexpected error: %v", name, err)
+		} else if name != "valid" && err == nil {
+			t.Errorf("%s : expected error", name)
+		}
+	}
+	if _, err = loadHealthWeights(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestHardwareErrorRule(t *testing.T) {
+	weights, err := loadHealthWeights("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := findHealthRule(t, weights, "Hardware Error in Kernel Log")
+	for _, entry := range []string{
+		"mce: [Hardware Error]: Machine check events logged",
+		"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_MC#0_Chan#0_DIMM#0 (channel:0 slot:0 page:0x1234 offset:0x0 grain:32 syndrome:0x0)",
+		"EDAC MC1: 1 UE memory read error on CPU_SrcID#1_MC#0_Chan#1_DIMM#0",
+		"{1}[Hardware Error]: Hardware error from APEI Generic Hardware Error Source: 0",
+		"pcieport 0000:00:1c.0: AER: Uncorrected (Non-Fatal) error received: 0000:00:1c.0",
+	} {
+		if !rule.matches(entry) {
+			t.Errorf("hardware error not matched: %s", entry)
+		}
+	}
+	for _, entry := range []string{
+		"EDAC MC0: Giving out device to module skx_edac controller Skylake Socket#0 IMC#0: DEV 0000:3a:0a.0 (INTERRUPT)",
+		"EDAC skx: ECC is disabled on imc 0",
+		"mce: CPU0: Thermal monitoring enabled (TM1)",
+		"mce: CPU supports 20 MCE banks",
+		"MCE: In-kernel MCE decoding enabled.",
+	} {
+		if rule.matches(entry) {
+			t.Errorf("boot message matched as a hardware error: %s", entry)
+		}
+	}
+}
+
+func TestGetHealthDeductions(t *testing.T) {
+	weights, err := loadHealthWeights("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insights := &Table{
+		Name: "Insight",
+		AllHostValues: []HostValues{
+			{Name: "host1", ValueNames: []string{"Recommendation", "Justification"}, Values: [][]string{
+				{"Update the microcode.", ""},
+				{"Enable the vulnerability mitigations.", ""},
+				{"Set the governor to performance.", ""},
+			}},
+			{Name: "host2", ValueNames: []string{"Recommendation", "Justification"}, Values: [][]string{}},
+		},
+	}
+	kernelLog := &Table{
+		Name: "Kernel Log",
+		AllHostValues: []HostValues{
+			{Name: "host1", ValueNames: []string{"Entries"}, Values: [][]string{
+				{"mce: CPU0: Thermal monitoring enabled (TM1)"},
+			}},
+			{Name: "host2", ValueNames: []string{"Entries"}, Values: [][]string{
+				{"mce: [Hardware Error]: Machine check events logged"},
+				{"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_MC#0_Chan#0_DIMM#0"},
+				{"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_MC#0_Chan#0_DIMM#0"},
+				{"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_MC#0_Chan#0_DIMM#0"},
+				{"EDAC MC0: 1 CE memory read error on CPU_SrcID#0_MC#0_Chan#0_DIMM#0"},
+			}},
+		},
+	}
+	nvme := &Table{
+		Name: "NVMe Health",
+		AllHostValues: []HostValues{
+			{Name: "host1", ValueNames: []string{"Media Errors"}, Values: [][]string{{"0"}, {"3"}}},
+			{Name: "host2", ValueNames: []string{"Media Errors"}, Values: [][]string{{"0"}}},
+		},
+	}
+	// the tables are found in any of the reports
+	reports := []*Report{{Tables: []*Table{insights, kernelLog}}, nil, {Tables: []*Table{nvme}}}
+	summarize := func(deductions []healthDeduction) map[string]healthDeduction {
+		byName := make(map[string]healthDeduction)
+		for _, deduction := range deductions {
+			byName[deduction.name] = deduction
+		}
+		return byName
+	}
+	// each insight counts toward the first rule that matches it
+	host1 := summarize(weights.getHealthDeductions(reports, 0))
+	expected := map[string]healthDeduction{
+		"Microcode Insight":     {name: "Microcode Insight", count: 1, points: 10},
+		"Vulnerability Insight": {name: "Vulnerability Insight", count: 1, points: 8},
+		"Insight":               {name: "Insight", count: 1, points: 3},
+		"NVMe Media Errors":     {name: "NVMe Media Errors", count: 1, points: 10},
+	}
+	if len(host1) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, host1)
+	}
+	for name, deduction := range expected {
+		if host1[name] != deduction {
+			t.Errorf("%s : expected %v, got %v", name, deduction, host1[name])
+		}
+	}
+	// the rule's maximum
+	host2 := summarize(weights.getHealthDeductions(reports, 1))
+	if deduction := host2["Hardware Error in Kernel Log"]; len(host2) != 1 || deduction.count != 5 || deduction.points != 20 {
+		t.Errorf("unexpected deductions: %v", host2)
+	}
+	// a host that isn't in the tables
+	if deductions := weights.getHealthDeductions(reports, 2); len(deductions) != 0 {
+		t.Errorf("unexpected deductions: %v", deductions)
+	}
+}
+
+func TestGetHealthGrade(t *testing.T) {
+	weights, err := loadHealthWeights("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for score, expected := range map[float64]string{100: "A", 90: "A", 89.5: "B", 80: "B", 75: "C", 60: "D", 59: "F", 0: "F", -20: "F"} {
+		if grade := weights.getHealthGrade(score); grade != expected {
+			t.Errorf("%.1f : expected %s, got %s", score, expected, grade)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+/* Highlight rules are loaded from a YAML file, e.g.:
+ *
+ * rules:
+ *   - table: DIMM
+ *     field: Speed
+ *     comparison: "<"
+ *     value: 4800 MT/s
+ *     severity: critical
+ *   - field: Microcode
+ *     comparison: "!="
+ *     value: "0x2b000461"
+ *     color: "#FFC000"
+ *
+ * Cells that match a rule are highlighted in the HTML and xlsx reports.
+ */
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+	"gopkg.in/yaml.v2"
+)
+
+type HighlightRule struct {
+	Table      string `yaml:"table"`      // optional, rule applies to all tables if not specified
+	Field      string `yaml:"field"`      // value name
+	Comparison string `yaml:"comparison"` // <, <=, >, >=, ==, !=, contains, matches
+	Value      string `yaml:"value"`      // value to compare against, units are honored, e.g., "4800 MT/s"
+	Severity   string `yaml:"severity"`   // info, warning, critical
+	Color      string `yaml:"color"`      // optional, hex color, e.g., "#FF0000", overrides severity
+	re         *regexp.Regexp
+}
+
+type HighlightRules struct {
+	Rules []HighlightRule `yaml:"rules"`
+}
+
+var severityColors = map[string]string{
+	"info":     "#B4C7E7",
+	"warning":  "#FFE699",
+	"critical": "#FF9999",
+}
+
+var reHexColor = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+var validComparisons = []string{"<", "<=", ">", ">=", "==", "!=", "contains", "matches"}
+
+func loadHighlightRules(path string) (rules *HighlightRules, err error) {
+	yamlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	rules = &HighlightRules{}
+	err = yaml.UnmarshalStrict(yamlBytes, rules)
+	if err != nil {
+		err = fmt.Errorf("failed to parse highlight rules file %s: %v", path, err)
+		return
+	}
+	for i := range rules.Rules {
+		err = rules.Rules[i].validate()
+		if err != nil {
+			err = fmt.Errorf("invalid highlight rule %d in %s: %v", i+1, path, err)
+			return
+		}
+	}
+	return
+}
+
+func (r *HighlightRule) validate() (err error) {
+	if r.Field == "" {
+		err = fmt.Errorf("field is required")
+		return
+	}
+	if !util.StringInList(r.Comparison, validComparisons) {
+		err = fmt.Errorf("comparison must be one of: %s", strings.Join(validComparisons, ", "))
+		return
+	}
+	if r.Color == "" {
+		var ok bool
+		if r.Color, ok = severityColors[r.Severity]; !ok {
+			err = fmt.Errorf("severity must be one of: info, warning, critical")
+			return
+		}
+	} else if !reHexColor.MatchString(r.Color) {
+		err = fmt.Errorf("color must be in #RRGGBB format: %s", r.Color)
+		return
+	}
+	if r.Comparison == "matches" {
+		r.re, err = regexp.Compile(r.Value)
+	}
+	return
+}
+
+// compareNumbers compares a value to the rule's value, honoring units when both are
+// quantities, e.g., "4400 MT/s" < "4.8 GT/s"
+func (r *HighlightRule) compareNumbers(value string) (result int, ok bool) {
+	var a, b float64
+	aQuantity, aUnit, aOK := normalizeQuantity(r.Field, value)
+	bQuantity, bUnit, bOK := normalizeQuantity(r.Field, r.Value)
+	if aOK && bOK && aUnit == bUnit {
+		a, b = aQuantity, bQuantity
+	} else {
+		var err error
+		if a, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return
+		}
+		if b, err = strconv.ParseFloat(strings.TrimSpace(r.Value), 64); err != nil {
+			return
+		}
+	}
+	ok = true
+	if a < b {
+		result = -1
+	} else if a > b {
+		result = 1
+	}
+	return
+}
+
+func (r *HighlightRule) matches(tableName string, valueName string, value string) bool {
+	if r.Field != valueName || (r.Table != "" && r.Table != tableName) || value == "" {
+		return false
+	}
+	switch r.Comparison {
+	case "contains":
+		return strings.Contains(value, r.Value)
+	case "matches":
+		return r.re.MatchString(value)
+	}
+	if result, ok := r.compareNumbers(value); ok {
+		switch r.Comparison {
+		case "<":
+			return result < 0
+		case "<=":
+			return result <= 0
+		case ">":
+			return result > 0
+		case ">=":
+			return result >= 0
+		case "==":
+			return result == 0
+		case "!=":
+			return result != 0
+		}
+	}
+	// non-numeric values only support equality
+	switch r.Comparison {
+	case "==":
+		return value == r.Value
+	case "!=":
+		return value != r.Value
+	}
+	return false
+}
+
+// getColor returns the highlight color of the first rule that matches the value, or
+// an empty string if no rules match
+func (h *HighlightRules) getColor(tableName string, valueName string, value string) string {
+	if h == nil {
+		return ""
+	}
+	for i := range h.Rules {
+		if h.Rules[i].matches(tableName, valueName, value) {
+			return h.Rules[i].Color
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type InstanceType struct {
+	Provider string  `yaml:"provider"`
+	Type     string  `yaml:"type"`
+	VCPUs    int     `yaml:"vcpus"`
+	Memory   float64 `yaml:"memory"`  // GiB
+	NVMe     int     `yaml:"nvme"`    // optional, local NVMe drives
+	Network  float64 `yaml:"network"` // Gbps
+	Burst    bool    `yaml:"burst"`   // optional, network bandwidth is "up to"
+}
+
+type InstanceTypes []InstanceType
+
+// loadInstanceTypes loads the table of cloud instance type specifications from path, or
+// the table bundled with the reporter if path is empty
+func loadInstanceTypes(path string) (instanceTypes InstanceTypes, err error) {
+	var yamlBytes []byte
+	if path == "" {
+		path = "resources/instance_types.yaml"
+		yamlBytes, err = resources.ReadFile(path)
+	} else {
+		yamlBytes, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return
+	}
+	err = yaml.UnmarshalStrict(yamlBytes, &instanceTypes)
+	if err != nil {
+		err = fmt.Errorf("failed to parse instance types file %s: %v", path, err)
+		return
+	}
+	for i, instanceType := range instanceTypes {
+		if _, ok := localNVMeModels[instanceType.Provider]; !ok {
+			err = fmt.Errorf("invalid provider in instance types entry %d: %s", i+1, instanceType.Provider)
+			return
+		}
+		if instanceType.Type == "" || instanceType.VCPUs <= 0 || instanceType.Memory <= 0 {
+			err = fmt.Errorf("instance types entry %d requires type, vcpus, and memory", i+1)
+			return
+		}
+	}
+	return
+}
+
+func (t InstanceTypes) find(provider, instanceType string) *InstanceType {
+	for i := range t {
+		if t[i].Provider == provider && strings.EqualFold(t[i].Type, instanceType) {
+			return &t[i]
+		}
+	}
+	return nil
+}
+
+// localNVMeModels identify the local, i.e., instance store or ephemeral, NVMe drives by
+// model. Network attached volumes, e.g., EBS, are also NVMe drives on some instances.
+var localNVMeModels = map[string]*regexp.Regexp{
+	"aws":   regexp.MustCompile(`Instance Storage`),
+	"azure": regexp.MustCompile(`NVMe Direct Disk`),
+	"gcp":   regexp.MustCompile(`nvme_card`),
+}
+
+// reNVMeNamespaceDevice matches the block devices of NVMe namespaces, e.g., nvme0n1, but
+// not their partitions
+var reNVMeNamespaceDevice = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// acceleratedNetworkDrivers are the drivers of the provider's accelerated (SR-IOV)
+// network interfaces, instances without one don't reach the published bandwidth
+var acceleratedNetworkDrivers = map[string][]string{
+	"aws":   {"ena"},
+	"azure": {"mlx4_en", "mlx4_core", "mlx5_core", "mana"},
+}
+
+// minInstanceMemoryPercent is the smallest MemTotal, as a percentage of the instance
+// type's memory, that isn't a discrepancy. The kernel and firmware reserve some memory.
+const minInstanceMemoryPercent = 90
+
+// cloudInstance is the instance as collected
+type cloudInstance struct {
+	provider     string
+	instanceType string
+	vCPUs        int
+	memory       float64 // GiB
+	nvme         int
+	drivers      []string
+}
+
+// checkInstance compares the instance to its instance type's specification and returns
+// the discrepancies. Values that weren't collected aren't compared.
+func checkInstance(instance cloudInstance, spec *InstanceType) (discrepancies []string) {
+	if instance.vCPUs != 0 && instance.vCPUs != spec.VCPUs {
+		discrepancies = append(discrepancies, fmt.Sprintf("%d vCPUs, expected %d", instance.vCPUs, spec.VCPUs))
+	}
+	if instance.memory != 0 && instance.memory < spec.Memory*minInstanceMemoryPercent/100 {
+		discrepancies = append(discrepancies, fmt.Sprintf("%.1f GiB memory, expected %s GiB", instance.memory, strconv.FormatFloat(spec.Memory, 'f', -1, 64)))
+	}
+	if instance.nvme < spec.NVMe {
+		discrepancies = append(discrepancies, fmt.Sprintf("%d local NVMe drives, expected %d", instance.nvme, spec.NVMe))
+	}
+	if drivers, ok := acceleratedNetworkDrivers[instance.provider]; ok && len(instance.drivers) > 0 {
+		accelerated := false
+		for _, driver := range instance.drivers {
+			for _, d := range drivers {
+				if driver == d {
+					accelerated = true
+				}
+			}
+		}
+		if !accelerated {
+			discrepancies = append(discrepancies, "accelerated networking not enabled")
+		}
+	}
+	return
+}
+
+// formatInstanceNetwork formats the instance type's network bandwidth, e.g., Up to 12.5 Gbps
+func formatInstanceNetwork(spec *InstanceType) string {
+	if spec.Network == 0 {
+		return ""
+	}
+	network := strconv.FormatFloat(spec.Network, 'f', -1, 64) + " Gbps"
+	if spec.Burst {
+		network = "Up to " + network
+	}
+	return network
+}
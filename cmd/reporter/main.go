@@ -5,17 +5,24 @@
 package main
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"embed"
 	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/intel/svr-info/internal/core"
 	"github.com/intel/svr-info/internal/cpu"
+	"github.com/intel/svr-info/internal/rawdata"
 	"github.com/intel/svr-info/internal/util"
 )
 
@@ -23,12 +30,29 @@ import (
 var resources embed.FS
 
 type CmdLineArgs struct {
-	help         bool
-	version      bool
-	format       string
-	input        string
-	output       string
-	internalJSON bool
+	help          bool
+	version       bool
+	format        string
+	input         string
+	output        string
+	internalJSON  bool
+	highlight     string
+	microcode     string
+	instanceTypes string
+	healthWeights string
+	reference     string
+	remediation   bool
+	threshold     float64
+	refThreshold  float64
+	txtWidth      int
+	wide          bool
+	narrow        bool
+	txtSections   string
+	delta         bool
+	baseline      string
+	filter        string
+	query         string
+	queryFormat   string
 }
 
 // globals
@@ -45,15 +69,34 @@ func showVersion() {
 	fmt.Println(gVersion)
 }
 
-func init() {
+// parseCmdLineArgs parses and validates the command line arguments, it exits on invalid
+// arguments
+func parseCmdLineArgs() {
 	// init command line flags
 	flag.Usage = func() { showUsage() } // override default usage output
 	flag.BoolVar(&gCmdLineArgs.help, "h", false, "Print this usage message.")
 	flag.BoolVar(&gCmdLineArgs.version, "v", false, "Print program version.")
 	flag.StringVar(&gCmdLineArgs.format, "format", "html", "comma separated list of desired report format(s):"+strings.Join(core.ReportTypes[:len(core.ReportTypes)-1], ", ")+", or all")
-	flag.StringVar(&gCmdLineArgs.input, "input", "", "required, comma separated list of input files or directory containing input (*.raw.json) files")
+	flag.StringVar(&gCmdLineArgs.input, "input", "", "required, comma separated list of input files, directories containing input (*.raw.json, *.raw.gob) files, or archives (*.tgz) containing input files")
 	flag.StringVar(&gCmdLineArgs.output, "output", ".", "output directory")
 	flag.BoolVar(&gCmdLineArgs.internalJSON, "internal_json", false, "Produce the internal json format introduced in the 2.0 release. This option is deprecated. Recommend transitioning to the new JSON report format ASAP.")
+	flag.StringVar(&gCmdLineArgs.highlight, "highlight", "", "path to YAML file containing rules for highlighting values in HTML and xlsx reports")
+	flag.StringVar(&gCmdLineArgs.microcode, "microcode", "", "path to YAML file containing current microcode revisions, overrides the bundled table")
+	flag.StringVar(&gCmdLineArgs.instanceTypes, "instance_types", "", "path to YAML file containing cloud instance type specifications, overrides the bundled table")
+	flag.StringVar(&gCmdLineArgs.healthWeights, "health_weights", "", "path to YAML file containing the weights of the host health grade, overrides the bundled weights")
+	flag.StringVar(&gCmdLineArgs.reference, "reference", "", "path to JSON file containing reference benchmark results, e.g., vendor-claimed or lab-measured, that the hosts' benchmark results are compared to")
+	flag.BoolVar(&gCmdLineArgs.remediation, "remediation", false, "write a script per host containing the commands that implement the recommendations, for review, the scripts are not run")
+	flag.Float64Var(&gCmdLineArgs.threshold, "regression_threshold", 5, "percent change in a benchmark metric, between runs of the same host, that is reported as a regression")
+	flag.Float64Var(&gCmdLineArgs.refThreshold, "reference_threshold", 5, "percent by which a benchmark metric can be worse than the -reference result before it fails")
+	flag.IntVar(&gCmdLineArgs.txtWidth, "txt_width", txtWidthDefault, "width, in characters, of the txt report's tables")
+	flag.BoolVar(&gCmdLineArgs.wide, "wide", false, fmt.Sprintf("format the txt report for wide terminals, same as -txt_width %d", txtWidthWide))
+	flag.BoolVar(&gCmdLineArgs.narrow, "narrow", false, fmt.Sprintf("format the txt report for narrow terminals, same as -txt_width %d", txtWidthNarrow))
+	flag.StringVar(&gCmdLineArgs.txtSections, "txt_sections", "all", "comma separated list of sections to include in the txt report: "+strings.Join(getTxtSectionNames(), ", ")+", or all")
+	flag.BoolVar(&gCmdLineArgs.delta, "delta", false, "create only the delta report, i.e., the configuration changes between runs of the same host, in the json, html, and txt formats")
+	flag.StringVar(&gCmdLineArgs.baseline, "baseline", "", "comma separated list of input files, directories, or archives (*.tgz) of a previous collection to compare the -input hosts to, creates only the delta report, see -delta")
+	flag.StringVar(&gCmdLineArgs.filter, "filter", "", "include only the hosts whose tags match the expression, e.g., 'env=prod and rack!=12', see the README")
+	flag.StringVar(&gCmdLineArgs.query, "query", "", "print the values selected by the comma separated list of paths, e.g., 'Operating System.Kernel,CPU.Microcode', for every host instead of creating reports, see the README")
+	flag.StringVar(&gCmdLineArgs.queryFormat, "query_format", "csv", "format of the -query output: "+strings.Join(queryFormats, ", "))
 	flag.Parse()
 	// validate input flag arguments
 	// -format
@@ -66,6 +109,37 @@ func init() {
 			}
 		}
 	}
+	// -regression_threshold
+	if gCmdLineArgs.threshold < 0 {
+		fmt.Fprintf(os.Stderr, "-regression_threshold %g : must not be negative\n", gCmdLineArgs.threshold)
+		os.Exit(1)
+	}
+	// -reference_threshold
+	if gCmdLineArgs.refThreshold < 0 {
+		fmt.Fprintf(os.Stderr, "-reference_threshold %g : must not be negative\n", gCmdLineArgs.refThreshold)
+		os.Exit(1)
+	}
+	// -txt_width, -wide, -narrow
+	if gCmdLineArgs.wide && gCmdLineArgs.narrow {
+		fmt.Fprintf(os.Stderr, "-wide and -narrow are mutually exclusive\n")
+		os.Exit(1)
+	}
+	if gCmdLineArgs.wide {
+		gCmdLineArgs.txtWidth = txtWidthWide
+	} else if gCmdLineArgs.narrow {
+		gCmdLineArgs.txtWidth = txtWidthNarrow
+	}
+	if gCmdLineArgs.txtWidth < 40 {
+		fmt.Fprintf(os.Stderr, "-txt_width %d : must be at least 40\n", gCmdLineArgs.txtWidth)
+		os.Exit(1)
+	}
+	// -txt_sections
+	for _, section := range strings.Split(gCmdLineArgs.txtSections, ",") {
+		if section != "all" && !util.StringInList(section, getTxtSectionNames()) {
+			fmt.Fprintf(os.Stderr, "-txt_sections %s : invalid section: %s\n", gCmdLineArgs.txtSections, section)
+			os.Exit(1)
+		}
+	}
 	// -input
 	if gCmdLineArgs.input != "" {
 		inputPaths := strings.Split(gCmdLineArgs.input, ",")
@@ -90,6 +164,86 @@ func init() {
 		showUsage()
 		os.Exit(1)
 	}
+	// -baseline
+	if gCmdLineArgs.baseline != "" {
+		for _, baselinePath := range strings.Split(gCmdLineArgs.baseline, ",") {
+			path, err := util.AbsPath(baselinePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			if _, err := os.Stat(path); err != nil {
+				fmt.Fprintf(os.Stderr, "-baseline %s : file (or directory) does not exist\n", path)
+				os.Exit(1)
+			}
+		}
+		gCmdLineArgs.delta = true
+	}
+	// -highlight
+	if gCmdLineArgs.highlight != "" {
+		path, err := util.AbsPath(gCmdLineArgs.highlight)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil || !exists {
+			fmt.Fprintf(os.Stderr, "-highlight %s : file does not exist\n", path)
+			os.Exit(1)
+		}
+	}
+	// -microcode
+	if gCmdLineArgs.microcode != "" {
+		path, err := util.AbsPath(gCmdLineArgs.microcode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil || !exists {
+			fmt.Fprintf(os.Stderr, "-microcode %s : file does not exist\n", path)
+			os.Exit(1)
+		}
+	}
+	// -health_weights
+	if gCmdLineArgs.healthWeights != "" {
+		path, err := util.AbsPath(gCmdLineArgs.healthWeights)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil || !exists {
+			fmt.Fprintf(os.Stderr, "-health_weights %s : file does not exist\n", path)
+			os.Exit(1)
+		}
+	}
+	// -reference
+	if gCmdLineArgs.reference != "" {
+		path, err := util.AbsPath(gCmdLineArgs.reference)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil || !exists {
+			fmt.Fprintf(os.Stderr, "-reference %s : file does not exist\n", path)
+			os.Exit(1)
+		}
+	}
+	// -instance_types
+	if gCmdLineArgs.instanceTypes != "" {
+		path, err := util.AbsPath(gCmdLineArgs.instanceTypes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		exists, err := util.FileExists(path)
+		if err != nil || !exists {
+			fmt.Fprintf(os.Stderr, "-instance_types %s : file does not exist\n", path)
+			os.Exit(1)
+		}
+	}
 	// -output
 	if gCmdLineArgs.output != "" {
 		path, err := util.AbsPath(gCmdLineArgs.output)
@@ -109,7 +263,11 @@ func init() {
 	}
 }
 
-func getInputFilePaths(input string) (inputFilePaths []string, err error) {
+// daemonRunDirGlob matches the names of the run directories in the orchestrator's output
+// directory in daemon mode, e.g., 2024-01-31_02-00
+const daemonRunDirGlob = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]_[0-9][0-9]-[0-9][0-9]"
+
+func getInputFilePaths(input string, tempDir string) (inputFilePaths []string, err error) {
 	paths := strings.Split(input, ",")
 	for _, filename := range paths {
 		var fileInfo fs.FileInfo
@@ -118,16 +276,77 @@ func getInputFilePaths(input string) (inputFilePaths []string, err error) {
 			err = fmt.Errorf("%w: %s", err, filename)
 			return
 		}
-		if fileInfo.Mode().IsRegular() {
-			inputFilePaths = append(inputFilePaths, filename)
-		} else if fileInfo.IsDir() {
-			var matches []string
-			matches, err = filepath.Glob(filepath.Join(filename, "*.raw.json"))
+		if fileInfo.Mode().IsRegular() && (strings.HasSuffix(filename, ".tgz") || strings.HasSuffix(filename, ".tar.gz")) {
+			var extracted []string
+			extracted, err = extractInputFiles(filename, tempDir)
 			if err != nil {
+				err = fmt.Errorf("%w: %s", err, filename)
 				return
 			}
-			inputFilePaths = append(inputFilePaths, matches...)
+			inputFilePaths = append(inputFilePaths, extracted...)
+		} else if fileInfo.Mode().IsRegular() {
+			inputFilePaths = append(inputFilePaths, filename)
+		} else if fileInfo.IsDir() {
+			// include the runs in a daemon's output directory, e.g., to report the
+			// changes in each host's configuration between runs
+			for _, dir := range []string{filename, filepath.Join(filename, daemonRunDirGlob)} {
+				for _, extension := range []string{".raw.json", rawdata.GobExtension} {
+					var matches []string
+					matches, err = filepath.Glob(filepath.Join(dir, "*"+extension))
+					if err != nil {
+						return
+					}
+					inputFilePaths = append(inputFilePaths, matches...)
+				}
+			}
+		}
+	}
+	return
+}
+
+// extractInputFiles extracts the input (*.raw.json, *.raw.gob) files from an archive, e.g., the
+// archive of an output directory, into a new directory in tempDir
+func extractInputFiles(archivePath string, tempDir string) (inputFilePaths []string, err error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return
+	}
+	defer gr.Close()
+	dir, err := os.MkdirTemp(tempDir, "archive")
+	if err != nil {
+		return
+	}
+	tr := tar.NewReader(gr)
+	for {
+		var header *tar.Header
+		header, err = tr.Next()
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err != nil {
+			return
+		}
+		if header.Typeflag != tar.TypeReg || !(strings.HasSuffix(header.Name, ".raw.json") || strings.HasSuffix(header.Name, rawdata.GobExtension)) {
+			continue
+		}
+		inputFilePath := filepath.Join(dir, fmt.Sprintf("%d_%s", len(inputFilePaths), filepath.Base(header.Name)))
+		var out *os.File
+		out, err = os.Create(inputFilePath)
+		if err != nil {
+			return
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return
 		}
+		inputFilePaths = append(inputFilePaths, inputFilePath)
 	}
 	return
 }
@@ -146,56 +365,178 @@ func getOutputDir(input string) (outputDir string, err error) {
 	return
 }
 
-func getSources(inputFilePaths []string) (sources []*Source) {
-	for _, inputFilePath := range inputFilePaths {
-		source := newSource(inputFilePath)
-		err := source.parse()
-		if err != nil {
-			log.Printf("Failed to parse %s: %v", inputFilePath, err)
+// getSources parses the input files, keeping the sources that match the filter, if any
+func getSources(inputFilePaths []string, filter tagFilter) (sources []*Source) {
+	// parse concurrently, keeping the sources in the order of the input files, limit the
+	// number of files being parsed at once so that parsing many large files doesn't
+	// exhaust memory
+	parsed := make([]*Source, len(inputFilePaths))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, runtime.NumCPU())
+	for i, inputFilePath := range inputFilePaths {
+		wg.Add(1)
+		go func(i int, inputFilePath string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			source := newSource(inputFilePath)
+			err := source.parse()
+			if err != nil {
+				log.Printf("Failed to parse %s: %v", inputFilePath, err)
+				return
+			}
+			parsed[i] = source
+		}(i, inputFilePath)
+	}
+	wg.Wait()
+	for _, source := range parsed {
+		if source != nil {
+			sources = append(sources, source)
+		}
+	}
+	if filter != nil {
+		sources = filterSources(sources, filter)
+	}
+	numberRuns(sources)
+	return
+}
+
+// numberRuns renames the sources that are runs of the same host, e.g., host_run1,
+// host_run2, in the order they were collected, so that each has its own report
+func numberRuns(sources []*Source) {
+	runs := make(map[string][]*Source)
+	for _, source := range sources {
+		runs[source.Hostname] = append(runs[source.Hostname], source)
+	}
+	for hostname, hostSources := range runs {
+		if len(hostSources) < 2 {
 			continue
 		}
-		sources = append(sources, source)
+		sort.SliceStable(hostSources, func(i, j int) bool {
+			return hostSources[i].getCollectionTime().Before(hostSources[j].getCollectionTime())
+		})
+		for i, source := range hostSources {
+			source.runOf = hostname
+			source.run = i + 1
+			source.Hostname = fmt.Sprintf("%s_run%d", hostname, source.run)
+		}
+	}
+}
+
+// addBaselineSources returns the baseline sources followed by the sources, with each
+// host's latest baseline run numbered as the run before its first run in the sources, so
+// that the delta report compares them. The baseline runs are named host_baseline. The
+// hosts that are in only one of the collections are reported and skipped.
+func addBaselineSources(baselineSources []*Source, sources []*Source) (all []*Source) {
+	hostKey := func(source *Source) string {
+		if source.runOf != "" {
+			return source.runOf
+		}
+		return source.Hostname
 	}
+	baselines := make(map[string]*Source)
+	for _, source := range baselineSources {
+		key := hostKey(source)
+		if latest, ok := baselines[key]; !ok || source.run > latest.run {
+			baselines[key] = source
+		}
+	}
+	matched := make(map[string]bool)
+	for _, source := range sources {
+		key := hostKey(source)
+		baseline, ok := baselines[key]
+		if !ok {
+			log.Printf("%s is not in the baseline", key)
+			fmt.Fprintf(os.Stderr, "WARNING: %s is not in the baseline, it won't be compared\n", key)
+			continue
+		}
+		if source.runOf == "" {
+			source.runOf = key
+			source.run = 1
+		}
+		if source.run == 1 && !matched[key] {
+			baseline.runOf = key
+			baseline.run = 0
+			baseline.Hostname = key + "_baseline"
+			matched[key] = true
+			all = append(all, baseline)
+		}
+	}
+	for _, source := range baselineSources {
+		if key := hostKey(source); baselines[key] == source && !matched[key] {
+			log.Printf("%s is only in the baseline", key)
+			fmt.Fprintf(os.Stderr, "WARNING: %s is only in the baseline, it won't be compared\n", key)
+		}
+	}
+	all = append(all, sources...)
 	return
 }
 
 func getReports(sources []*Source, reportTypes []string, outputDir string) (reportFilePaths []string, err error) {
-	cpusInfo, err := cpu.NewCPU()
+	var highlightRules *HighlightRules
+	if gCmdLineArgs.highlight != "" {
+		highlightRules, err = loadHighlightRules(gCmdLineArgs.highlight)
+		if err != nil {
+			return
+		}
+	}
+	microcodeRevisions, err := loadMicrocodeRevisions(gCmdLineArgs.microcode)
 	if err != nil {
 		return
 	}
-	configReport := NewConfigurationReport(sources, cpusInfo)
-	briefReport := NewBriefReport(sources, configReport, cpusInfo)
-	profileReport := NewProfileReport(sources)
-	analyzeReport := NewAnalyzeReport(sources)
-	benchmarkReport := NewBenchmarkReport(sources)
-	insightsReport := NewInsightsReport(sources, configReport, briefReport, profileReport, benchmarkReport, analyzeReport, cpusInfo)
-	var rpt ReportGenerator
-	for _, rt := range reportTypes {
-		switch rt {
-		case "html":
-			rpt = newReportGeneratorHTML(outputDir, cpusInfo, configReport, insightsReport, profileReport, benchmarkReport, analyzeReport)
-		case "json":
-			if gCmdLineArgs.internalJSON {
-				rpt = newReportGeneratorJSON(outputDir, configReport, insightsReport, profileReport, benchmarkReport, analyzeReport)
-			} else {
-				rpt = newReportGeneratorJSONSimplified(outputDir, configReport, briefReport, insightsReport, profileReport, benchmarkReport, analyzeReport)
-			}
-		case "xlsx":
-			rpt = newReportGeneratorXLSX(outputDir, configReport, briefReport, insightsReport, profileReport, benchmarkReport, analyzeReport) // only Excel has 'brief' report
-		case "txt":
-			rpt = newReportGeneratorTXT(sources, outputDir) // txt report is special...more of a raw data dump than a report
-		default:
-			err = fmt.Errorf("unsupported report type: %s", rt)
+	instanceTypes, err := loadInstanceTypes(gCmdLineArgs.instanceTypes)
+	if err != nil {
+		return
+	}
+	if gCmdLineArgs.delta {
+		var cpusInfo *cpu.CPU
+		cpusInfo, err = cpu.NewCPU()
+		if err != nil {
 			return
 		}
-		var reportPaths []string
-		reportPaths, err = rpt.generate()
+		configuration := NewConfigurationReport(sources, cpusInfo, microcodeRevisions, instanceTypes)
+		reportFilePaths, err = render([]ReportGenerator{newReportGeneratorDelta(sources, outputDir, reportTypes, configuration)})
+		return
+	}
+	healthWeights, err := loadHealthWeights(gCmdLineArgs.healthWeights)
+	if err != nil {
+		return
+	}
+	reference, err := loadBenchmarkReference(gCmdLineArgs.reference)
+	if err != nil {
+		return
+	}
+	model, err := newReportModel(sources, highlightRules, microcodeRevisions, instanceTypes, healthWeights, reference)
+	if err != nil {
+		return
+	}
+	var generators []ReportGenerator
+	for _, rt := range reportTypes {
+		var rpt ReportGenerator
+		rpt, err = model.getGenerator(rt, outputDir)
 		if err != nil {
 			return
 		}
-		reportFilePaths = append(reportFilePaths, reportPaths...)
+		generators = append(generators, rpt)
 	}
+	// hosts are compared side by side in the formats that support it
+	if len(sources) > 1 {
+		var comparisonTypes []string
+		for _, rt := range reportTypes {
+			if util.StringInList(rt, comparisonReportTypes) {
+				comparisonTypes = append(comparisonTypes, rt)
+			}
+		}
+		if len(comparisonTypes) > 0 {
+			generators = append(generators, newReportGeneratorComparison(sources, outputDir, comparisonTypes, model.configuration))
+		}
+	}
+	// raw benchmark output is kept regardless of the report formats
+	generators = append(generators, newReportGeneratorBenchmark(sources, outputDir))
+	if gCmdLineArgs.remediation {
+		generators = append(generators, newReportGeneratorRemediation(outputDir, model.insights))
+	}
+	reportFilePaths, err = render(generators)
 	return
 }
 
@@ -231,7 +572,36 @@ func mainReturnWithCode() int {
 		os.Getppid(),
 		strings.Join(os.Args, " "),
 	)
-	inputFilePaths, err := getInputFilePaths(gCmdLineArgs.input)
+	var filter tagFilter
+	if gCmdLineArgs.filter != "" {
+		filter, err = parseFilter(gCmdLineArgs.filter)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	var queries []queryPath
+	if gCmdLineArgs.query != "" {
+		if !util.StringInList(gCmdLineArgs.queryFormat, queryFormats) {
+			err = fmt.Errorf("-query_format %s : unsupported format, choose from: %s", gCmdLineArgs.queryFormat, strings.Join(queryFormats, ", "))
+		} else {
+			queries, err = parseQueries(gCmdLineArgs.query)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	tempDir, err := os.MkdirTemp("", "reporter.tmp.")
+	if err != nil {
+		log.Printf("Error: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(tempDir)
+	inputFilePaths, err := getInputFilePaths(gCmdLineArgs.input, tempDir)
 	if err != nil {
 		log.Printf("Error: %v", err)
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -243,13 +613,41 @@ func mainReturnWithCode() int {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
-	sources := getSources(inputFilePaths)
+	sources := getSources(inputFilePaths, filter)
+	if len(sources) == 0 && filter != nil {
+		err = fmt.Errorf("no hosts match -filter %s", gCmdLineArgs.filter)
+		log.Printf("Error: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
 	if len(sources) == 0 {
 		err = fmt.Errorf("no input files found")
 		log.Printf("Error: %v", err)
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 1
 	}
+	if gCmdLineArgs.baseline != "" {
+		var baselineFilePaths []string
+		baselineFilePaths, err = getInputFilePaths(gCmdLineArgs.baseline, tempDir)
+		if err == nil && len(baselineFilePaths) == 0 {
+			err = fmt.Errorf("no input files found in -baseline %s", gCmdLineArgs.baseline)
+		}
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		sources = addBaselineSources(getSources(baselineFilePaths, filter), sources)
+	}
+	if queries != nil {
+		err = printQueryResults(os.Stdout, sources, queries, gCmdLineArgs.queryFormat)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
 	reportFilePaths, err := getReports(sources, reportTypes, outputDir)
 	if err != nil {
 		log.Printf("Error: %v", err)
@@ -263,4 +661,7 @@ func mainReturnWithCode() int {
 	return 0
 }
 
-func main() { os.Exit(mainReturnWithCode()) }
+func main() {
+	parseCmdLineArgs()
+	os.Exit(mainReturnWithCode())
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type MicrocodeRevision struct {
+	Platform string `yaml:"platform"`
+	Family   string `yaml:"family"`
+	Model    string `yaml:"model"`
+	Stepping string `yaml:"stepping"`
+	Revision string `yaml:"revision"` // current revision
+	Minimum  string `yaml:"minimum"`  // optional, older revisions are missing important fixes
+	Release  string `yaml:"release"`
+}
+
+type MicrocodeRevisions []MicrocodeRevision
+
+// loadMicrocodeRevisions loads the table of current microcode revisions from path, or
+// the table bundled with the reporter if path is empty
+func loadMicrocodeRevisions(path string) (revisions MicrocodeRevisions, err error) {
+	var yamlBytes []byte
+	if path == "" {
+		path = "resources/microcode.yaml"
+		yamlBytes, err = resources.ReadFile(path)
+	} else {
+		yamlBytes, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return
+	}
+	err = yaml.UnmarshalStrict(yamlBytes, &revisions)
+	if err != nil {
+		err = fmt.Errorf("failed to parse microcode revisions file %s: %v", path, err)
+		return
+	}
+	for i, revision := range revisions {
+		if _, err = parseMicrocodeRevision(revision.Revision); err != nil {
+			err = fmt.Errorf("invalid revision in microcode revisions entry %d: %v", i+1, err)
+			return
+		}
+		if revision.Minimum != "" {
+			if _, err = parseMicrocodeRevision(revision.Minimum); err != nil {
+				err = fmt.Errorf("invalid minimum in microcode revisions entry %d: %v", i+1, err)
+				return
+			}
+		}
+	}
+	return
+}
+
+// parseMicrocodeRevision parses a hex revision, e.g., 0x2b0004d0
+func parseMicrocodeRevision(revision string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(revision)), "0x"), 16, 64)
+}
+
+func (m MicrocodeRevisions) find(family, model, stepping string) *MicrocodeRevision {
+	for i := range m {
+		if m[i].Family == family && m[i].Model == model && m[i].Stepping == stepping {
+			return &m[i]
+		}
+	}
+	return nil
+}
+
+// checkMicrocode compares the running microcode revision to the table entry. Severity
+// is critical when the revision is older than the minimum, otherwise warning when the
+// revision isn't current.
+func checkMicrocode(running string, entry *MicrocodeRevision) (status string, severity string) {
+	if entry == nil {
+		status = "Unknown CPU"
+		return
+	}
+	runningRevision, err := parseMicrocodeRevision(running)
+	// hypervisors typically report an invalid revision to guests
+	if err != nil || runningRevision == 0xffffffff {
+		status = "Unknown Revision"
+		return
+	}
+	currentRevision, _ := parseMicrocodeRevision(entry.Revision)
+	if runningRevision > currentRevision {
+		status = "Newer"
+		return
+	}
+	if runningRevision == currentRevision {
+		status = "Current"
+		return
+	}
+	status = "Outdated"
+	severity = "warning"
+	if entry.Minimum != "" {
+		if minimumRevision, _ := parseMicrocodeRevision(entry.Minimum); runningRevision < minimumRevision {
+			severity = "critical"
+		}
+	}
+	return
+}
@@ -0,0 +1,290 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// power intents, the BIOS and OS power settings are classified into one of these so
+// that settings that express the same intent differently can be compared
+const (
+	powerIntentPerformance = "Performance"
+	powerIntentBalanced    = "Balanced"
+	powerIntentPowerSaving = "Power Saving"
+)
+
+// rank of each intent, from the most power saving to the most performance oriented
+var powerIntentRank = map[string]int{
+	powerIntentPowerSaving: 0,
+	powerIntentBalanced:    1,
+	powerIntentPerformance: 2,
+}
+
+// patterns that classify BIOS profile and EPB values, e.g., Dell's
+// PerfPerWattOptimizedDapc, HPE's GeneralPowerEfficientCompute, and Lenovo's
+// "Efficiency - Favor Performance", matched in order against the normalized value
+var powerIntentPatterns = []struct {
+	re     *regexp.Regexp
+	intent string
+}{
+	{regexp.MustCompile(`minimalpower|powersav|favorpower|lowpower|^power$`), powerIntentPowerSaving},
+	{regexp.MustCompile(`balanc|perwatt|efficien|dapc|normal`), powerIntentBalanced},
+	{regexp.MustCompile(`perf|latency|maximum|peak|throughput|hpc`), powerIntentPerformance},
+}
+
+// classifyBIOSPowerSetting returns the intent of a BIOS profile or EPB setting, or an
+// empty string when the setting, e.g., Custom, doesn't express one
+func classifyBIOSPowerSetting(value string) string {
+	normalized := normalizeBIOSSettingName(value)
+	for _, pattern := range powerIntentPatterns {
+		if pattern.re.MatchString(normalized) {
+			return pattern.intent
+		}
+	}
+	return ""
+}
+
+// classifyGovernor returns the intent of a cpufreq governor. The intel_pstate and
+// amd-pstate powersave governors defer to the EPP, so they express no intent.
+func classifyGovernor(governor string, driver string) string {
+	switch governor {
+	case "performance":
+		return powerIntentPerformance
+	case "ondemand", "conservative", "schedutil":
+		return powerIntentBalanced
+	case "powersave":
+		if strings.HasSuffix(driver, "pstate") {
+			return ""
+		}
+		return powerIntentPowerSaving
+	}
+	return ""
+}
+
+// classifyEPP returns the intent of an energy performance preference, a name or a
+// value from 0 (performance) to 255 (power)
+func classifyEPP(epp string) string {
+	switch epp {
+	case "performance":
+		return powerIntentPerformance
+	case "balance_performance", "default", "balance_power":
+		return powerIntentBalanced
+	case "power":
+		return powerIntentPowerSaving
+	}
+	value, err := strconv.Atoi(epp)
+	if err != nil {
+		return ""
+	}
+	if value == 0 {
+		return powerIntentPerformance
+	} else if value < 192 {
+		return powerIntentBalanced
+	}
+	return powerIntentPowerSaving
+}
+
+// classifyEPB returns the intent of IA32_ENERGY_PERF_BIAS, from 0 (performance) to 15
+// (power)
+func classifyEPB(msrHex string) (intent string, value int64) {
+	value, err := strconv.ParseInt(msrHex, 16, 0)
+	if err != nil {
+		return
+	}
+	value &= 0xf
+	if value < 4 {
+		intent = powerIntentPerformance
+	} else if value < 8 {
+		intent = powerIntentBalanced
+	} else {
+		intent = powerIntentPowerSaving
+	}
+	return
+}
+
+// idleStates is the OS configuration of the CPU idle states
+type idleStates struct {
+	driver   string
+	enabled  []string // names of the enabled states, excluding POLL
+	deepest  int      // number of the deepest enabled state, e.g., 6 for C6
+	maxState string   // intel_idle.max_cstate, if set on the kernel command line
+	poll     bool     // idle=poll
+}
+
+// getIdleStates parses the cpuidle states of cpu0 and the idle boot parameters
+func (s *Source) getIdleStates() (states idleStates) {
+	reState := regexp.MustCompile(`^(\S+) disable=(\d+)$`)
+	reNumber := regexp.MustCompile(`^C(\d+)`)
+	for _, line := range s.getCommandOutputLines("cpuidle states") {
+		if driver, found := strings.CutPrefix(line, "driver: "); found {
+			states.driver = driver
+			continue
+		}
+		match := reState.FindStringSubmatch(line)
+		if match == nil || match[1] == "POLL" || match[2] != "0" {
+			continue
+		}
+		states.enabled = append(states.enabled, match[1])
+		if number := reNumber.FindStringSubmatch(match[1]); number != nil {
+			if n, err := strconv.Atoi(number[1]); err == nil && n > states.deepest {
+				states.deepest = n
+			}
+		}
+	}
+	cmdline := s.getCommandOutputLine("/proc/cmdline")
+	states.maxState = getBootParameter(cmdline, "intel_idle.max_cstate")
+	if states.maxState == "" {
+		states.maxState = getBootParameter(cmdline, "processor.max_cstate")
+	}
+	states.poll = getBootParameter(cmdline, "idle") == "poll"
+	return
+}
+
+func (states idleStates) String() string {
+	if states.poll {
+		return "idle=poll"
+	}
+	var fields []string
+	if states.driver != "" {
+		fields = append(fields, states.driver)
+	}
+	if len(states.enabled) > 0 {
+		fields = append(fields, "enabled: "+strings.Join(states.enabled, ", "))
+	}
+	if states.maxState != "" {
+		fields = append(fields, "max_cstate="+states.maxState)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// limitsCStates returns true if the OS keeps the cores out of the deep C-states, e.g.,
+// C6
+func (states idleStates) limitsCStates() bool {
+	if states.poll {
+		return true
+	}
+	if max, err := strconv.Atoi(states.maxState); err == nil && max <= 1 {
+		return true
+	}
+	return states.driver != "" && states.deepest < 2
+}
+
+// formatPowerSetting returns the setting's value followed by its intent
+func formatPowerSetting(value string, intent string) string {
+	if intent == "" || value == "" {
+		return value
+	}
+	return fmt.Sprintf("%s (%s)", value, intent)
+}
+
+// explainPowerIntentMismatch returns the explanation of a BIOS and an OS setting that
+// express different intents, or an empty string if they agree
+func explainPowerIntentMismatch(biosIntent string, osSetting string, osIntent string) string {
+	if biosIntent == "" || osIntent == "" || biosIntent == osIntent {
+		return ""
+	}
+	if powerIntentRank[biosIntent] > powerIntentRank[osIntent] {
+		return fmt.Sprintf("The BIOS is configured for %s but the OS %s requests %s. The cores run at lower frequencies, and ramp up more slowly under load, than the BIOS profile allows.",
+			strings.ToLower(biosIntent), osSetting, strings.ToLower(osIntent))
+	}
+	return fmt.Sprintf("The OS %s requests %s but the BIOS is configured for %s. Settings the BIOS controls, e.g., C-states, C1E, uncore frequency scaling, and turbo limits, still favor power savings, so the OS setting alone doesn't deliver the expected performance.",
+		osSetting, strings.ToLower(osIntent), strings.ToLower(biosIntent))
+}
+
+// getPowerProfileMismatches compares the BIOS power profile, EPB, and C-state settings
+// to the OS governor, EPP, EPB, and idle states, and returns a row for each contradiction:
+// the setting, the BIOS value, the OS value, and the explanation
+func (s *Source) getPowerProfileMismatches(defs []BIOSSettingDef) (mismatches [][]string) {
+	bios := make(map[string]string)
+	for _, setting := range normalizeBIOSSettings(s.getBIOSSettings(), defs) {
+		bios[setting[0]] = setting[1]
+	}
+	profile := bios["System Profile"]
+	profileIntent := classifyBIOSPowerSetting(profile)
+	// the BIOS manages P-states on its own, e.g., Dell's DAPC, the OS governor and EPP
+	// are ignored
+	systemManaged := strings.Contains(normalizeBIOSSettingName(profile), "dapc")
+
+	policies := s.getCPUFreqPolicies()
+	driver := s.getCommandOutputLine("cpu_freq_driver")
+	governor := getPolicySetting(policies, "governor", nil)
+	if governor == "" {
+		governor = s.getCommandOutputLine("cpu_freq_governor")
+	}
+	// the least performance oriented intent when the policies differ
+	var governorIntent, eppIntent string
+	for _, policy := range policies {
+		if intent := classifyGovernor(policy["governor"], driver); intent != "" && (governorIntent == "" || powerIntentRank[intent] < powerIntentRank[governorIntent]) {
+			governorIntent = intent
+		}
+		if intent := classifyEPP(policy["epp"]); intent != "" && (eppIntent == "" || powerIntentRank[intent] < powerIntentRank[eppIntent]) {
+			eppIntent = intent
+		}
+	}
+	if len(policies) == 0 {
+		governorIntent = classifyGovernor(governor, driver)
+	}
+	if systemManaged && governor != "" {
+		mismatches = append(mismatches, []string{
+			"Governor",
+			profile,
+			governor,
+			"The BIOS profile manages P-states in the platform firmware, the OS governor and EPP have no effect. Select the BIOS profile that gives the OS control, e.g., PerfPerWattOptimizedOs, to tune frequency from the OS.",
+		})
+	} else if explanation := explainPowerIntentMismatch(profileIntent, "governor", governorIntent); explanation != "" {
+		mismatches = append(mismatches, []string{"Governor", formatPowerSetting(profile, profileIntent), formatPowerSetting(governor, governorIntent), explanation})
+	}
+
+	if !systemManaged {
+		epp := getPolicySetting(policies, "epp", nil)
+		if explanation := explainPowerIntentMismatch(profileIntent, "energy performance preference (EPP)", eppIntent); explanation != "" {
+			mismatches = append(mismatches, []string{"EPP", formatPowerSetting(profile, profileIntent), formatPowerSetting(epp, eppIntent), explanation})
+		}
+	}
+
+	epbIntent, epb := classifyEPB(s.getCommandOutputLine("rdmsr 0x1b0"))
+	if epbIntent != "" {
+		biosEPB := bios["Energy Performance Bias"]
+		biosEPBIntent := classifyBIOSPowerSetting(biosEPB)
+		if biosEPBIntent == "" {
+			biosEPB, biosEPBIntent = profile, profileIntent
+		}
+		osEPB := fmt.Sprintf("%d", epb)
+		if biosEPBIntent == powerIntentPerformance && epb == 6 {
+			// Linux changes the EPB from performance (0) to normal (6) at boot
+			mismatches = append(mismatches, []string{
+				"EPB",
+				formatPowerSetting(biosEPB, biosEPBIntent),
+				formatPowerSetting(osEPB, epbIntent),
+				"The BIOS sets the energy performance bias (EPB) to performance, but the Linux kernel resets it to normal (6) at boot. Set it after boot, e.g., with x86_energy_perf_policy performance or /sys/devices/system/cpu/cpu*/power/energy_perf_bias.",
+			})
+		} else if explanation := explainPowerIntentMismatch(biosEPBIntent, "energy performance bias (EPB)", epbIntent); explanation != "" {
+			mismatches = append(mismatches, []string{"EPB", formatPowerSetting(biosEPB, biosEPBIntent), formatPowerSetting(osEPB, epbIntent), explanation})
+		}
+	}
+
+	cStates := bios["C-States"]
+	idle := s.getIdleStates()
+	if cStates == "Disabled" && !idle.limitsCStates() && idle.deepest >= 2 {
+		mismatches = append(mismatches, []string{
+			"C-States",
+			cStates,
+			idle.String(),
+			fmt.Sprintf("C-states are disabled in the BIOS but the OS idle driver enables C%d. intel_idle uses its own table of C-states on many CPUs, so cores may enter deep C-states, and wake up with their latency, regardless of the BIOS setting. Limit them with intel_idle.max_cstate=1 or by disabling the states in /sys/devices/system/cpu/cpu*/cpuidle.", idle.deepest),
+		})
+	} else if cStates == "Enabled" && profileIntent != powerIntentPerformance && idle.limitsCStates() {
+		mismatches = append(mismatches, []string{
+			"C-States",
+			fmt.Sprintf("%s, %s profile", cStates, profile),
+			idle.String(),
+			"C-states are enabled in the BIOS to save power but the OS keeps the cores out of the deep C-states. Idle power is higher than the BIOS profile intends, and turbo frequencies are lower because idle cores don't give their power budget to the busy ones.",
+		})
+	}
+	return
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// The -query option prints selected values for every host instead of writing reports.
+// A query is a path to values in the reports, in the style of jq, e.g.,
+// .Configuration.CPU.Microcode or 'Operating System.Kernel'. The path is the report,
+// table, and field names separated by dots. The report can be omitted, it defaults to
+// Configuration. Each name may be a glob pattern and is matched without regard to case,
+// e.g., '.Brief.OS.*'. Values from tables with more than one row are joined with "; " in
+// the CSV output and listed in the JSON output.
+
+// queryFormats are the output formats of -query
+var queryFormats = []string{"csv", "json"}
+
+// queryPath selects values by report, table, and field name patterns
+type queryPath struct {
+	report string
+	table  string
+	field  string
+}
+
+// queryColumn is a field selected by a query, values are by host index
+type queryColumn struct {
+	name   string
+	values [][]string
+}
+
+// parseQueries parses the comma separated list of query paths
+func parseQueries(input string) (paths []queryPath, err error) {
+	for _, query := range strings.Split(input, ",") {
+		query = strings.TrimPrefix(strings.TrimSpace(query), ".")
+		segments := strings.Split(query, ".")
+		if len(segments) == 2 {
+			segments = append([]string{"Configuration"}, segments...)
+		}
+		if len(segments) != 3 {
+			err = fmt.Errorf("-query %s : expected [.Report.]Table.Field", query)
+			return
+		}
+		for _, segment := range segments {
+			if _, err = path.Match(strings.ToLower(segment), ""); err != nil || segment == "" {
+				err = fmt.Errorf("-query %s : invalid name: '%s'", query, segment)
+				return
+			}
+		}
+		paths = append(paths, queryPath{report: segments[0], table: segments[1], field: segments[2]})
+	}
+	return
+}
+
+func queryMatch(pattern string, name string) bool {
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return matched
+}
+
+// runQueries returns a column for each field in the reports that matches a query, in
+// the order of the queries and then of the reports
+func runQueries(paths []queryPath, reports []*Report) (columns []queryColumn) {
+	for _, p := range paths {
+		for _, report := range reports {
+			if !queryMatch(p.report, report.InternalName) {
+				continue
+			}
+			for _, table := range report.Tables {
+				if !queryMatch(p.table, table.Name) || len(table.AllHostValues) == 0 {
+					continue
+				}
+				for fieldIndex, field := range table.AllHostValues[0].ValueNames {
+					if !queryMatch(p.field, field) {
+						continue
+					}
+					column := queryColumn{name: report.InternalName + "." + table.Name + "." + field}
+					for _, hv := range table.AllHostValues {
+						var values []string
+						for _, row := range hv.Values {
+							if fieldIndex < len(row) {
+								values = append(values, row[fieldIndex])
+							}
+						}
+						column.values = append(column.values, values)
+					}
+					columns = append(columns, column)
+				}
+			}
+		}
+	}
+	return
+}
+
+// printQueryResults builds the reports from the sources and writes the values selected
+// by the queries
+func printQueryResults(w io.Writer, sources []*Source, paths []queryPath, format string) (err error) {
+	microcodeRevisions, err := loadMicrocodeRevisions(gCmdLineArgs.microcode)
+	if err != nil {
+		return
+	}
+	instanceTypes, err := loadInstanceTypes(gCmdLineArgs.instanceTypes)
+	if err != nil {
+		return
+	}
+	healthWeights, err := loadHealthWeights(gCmdLineArgs.healthWeights)
+	if err != nil {
+		return
+	}
+	reference, err := loadBenchmarkReference(gCmdLineArgs.reference)
+	if err != nil {
+		return
+	}
+	model, err := newReportModel(sources, nil, microcodeRevisions, instanceTypes, healthWeights, reference)
+	if err != nil {
+		return
+	}
+	columns := runQueries(paths, []*Report{model.configuration, model.brief, model.benchmark, model.profile, model.analyze, model.insights})
+	if len(columns) == 0 {
+		err = fmt.Errorf("no fields match -query %s", gCmdLineArgs.query)
+		return
+	}
+	err = writeQueryResults(w, format, sources, columns)
+	return
+}
+
+// writeQueryResults writes a row or object per host
+func writeQueryResults(w io.Writer, format string, sources []*Source, columns []queryColumn) (err error) {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{"Host"}
+		for _, column := range columns {
+			header = append(header, column.name)
+		}
+		if err = writer.Write(header); err != nil {
+			return
+		}
+		for hostIndex, source := range sources {
+			record := []string{source.getHostname()}
+			for _, column := range columns {
+				record = append(record, strings.Join(column.values[hostIndex], "; "))
+			}
+			if err = writer.Write(record); err != nil {
+				return
+			}
+		}
+		writer.Flush()
+		err = writer.Error()
+	case "json":
+		var hosts []map[string]interface{}
+		for hostIndex, source := range sources {
+			host := map[string]interface{}{"Host": source.getHostname()}
+			for _, column := range columns {
+				values := column.values[hostIndex]
+				if len(values) == 1 {
+					host[column.name] = values[0]
+				} else {
+					host[column.name] = values
+				}
+			}
+			hosts = append(hosts, host)
+		}
+		var content []byte
+		if content, err = json.MarshalIndent(hosts, "", "  "); err != nil {
+			return
+		}
+		_, err = w.Write(append(content, '\n'))
+	default:
+		err = fmt.Errorf("-query_format %s : unsupported format, choose from: %s", format, strings.Join(queryFormats, ", "))
+	}
+	return
+}
@@ -7,6 +7,7 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/intel/svr-info/internal/cpu"
@@ -17,68 +18,174 @@ type Report struct {
 	InternalName string // the value set here needs to remain consistent for users who parse the json report
 	Sources      []*Source
 	Tables       []*Table
+	notCollected *notCollectedTracker // the tables that read commands that weren't collected, nil if not tracked
+}
+
+// notCollectedTracker attributes the commands that weren't collected, see notCollectedLabel,
+// to the tables that read them. The tables are tracked as they're built, so the reads
+// since the previous table are the table's.
+type notCollectedTracker struct {
+	sources []*Source
+	tables  []notCollectedTable
+}
+
+type notCollectedTable struct {
+	table     *Table
+	sourceIdx int
+	label     string // the first command the table read that wasn't collected
+	reason    string
+}
+
+func newNotCollectedTracker(sources []*Source) *notCollectedTracker {
+	return &notCollectedTracker{sources: sources}
+}
+
+// track records the commands that weren't collected that each host's table read
+func (t *notCollectedTracker) track(table *Table) *Table {
+	for sourceIdx, source := range t.sources {
+		labels := source.takeNotCollectedReads()
+		if len(labels) == 0 {
+			continue
+		}
+		t.tables = append(t.tables, notCollectedTable{
+			table:     table,
+			sourceIdx: sourceIdx,
+			label:     labels[0],
+			reason:    source.getNotCollected()[labels[0]],
+		})
+	}
+	return table
+}
+
+// addPlaceholders marks the tables that have no values for a host because the commands
+// they read weren't collected, e.g., "not collected (lshw: collection interrupted)". The
+// placeholders are added once all reports are built, so that they aren't read as values
+// by the tables that are derived from other tables.
+func (t *notCollectedTracker) addPlaceholders() {
+	for _, nc := range t.tables {
+		hv := &nc.table.AllHostValues[nc.sourceIdx]
+		if len(hv.ValueNames) == 0 || hasValues(hv) {
+			continue
+		}
+		placeholder := make([]string, len(hv.ValueNames))
+		placeholder[0] = fmt.Sprintf("not collected (%s: %s)", nc.label, nc.reason)
+		hv.Values = [][]string{placeholder}
+	}
+}
+
+// hasValues returns true if any of the host's values isn't empty
+func hasValues(hv *HostValues) bool {
+	for _, record := range hv.Values {
+		for _, value := range record {
+			if value != "" {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // NewConfigurationReport -- includes all verbose tables
-func NewConfigurationReport(sources []*Source, cpusInfo *cpu.CPU) (report *Report) {
+func NewConfigurationReport(sources []*Source, cpusInfo *cpu.CPU, microcodeRevisions MicrocodeRevisions, instanceTypes InstanceTypes) (report *Report) {
 	report = &Report{
 		InternalName: "Configuration",
 		Sources:      sources,
 		Tables:       []*Table{},
 	}
+	notCollected := newNotCollectedTracker(sources)
+	report.notCollected = notCollected
+	track := notCollected.track
+
+	tableOS := track(newOperatingSystemTable(sources, Software))
+	tableCPU := track(newCPUTable(sources, cpusInfo, CPUCategory))
 
 	report.Tables = append(report.Tables,
 		[]*Table{
-			newHostTable(sources, System),
-			newSystemTable(sources, System),
-			newBaseboardTable(sources, System),
-			newChassisTable(sources, System),
-			newPCIeSlotsTable(sources, System),
-
-			newBIOSTable(sources, Software),
-			newOperatingSystemTable(sources, Software),
-			newSoftwareTable(sources, Software),
-
-			newCPUTable(sources, cpusInfo, CPUCategory),
-			newISATable(sources, CPUCategory),
-			newAcceleratorTable(sources, CPUCategory),
-			newFeatureTable(sources, CPUCategory),
-
-			newPowerTable(sources, Power),
-			newUncoreTable(sources, Power),
+			track(newHostTable(sources, System)),
+			track(newSystemTable(sources, System)),
+			track(newBaseboardTable(sources, System)),
+			track(newChassisTable(sources, System)),
+			track(newPCIeSlotsTable(sources, System)),
+			track(newCloudInstanceTable(sources, tableCPU, instanceTypes, System)),
+
+			track(newBIOSTable(sources, Software)),
+			track(newBIOSSettingsTable(sources, Software)),
+			tableOS,
+			track(newBootloaderTable(sources, Software)),
+			track(newBootEntryTable(sources, Software)),
+			track(newMicrocodeTable(sources, tableCPU, tableOS, microcodeRevisions, Software)),
+			track(newSoftwareTable(sources, Software)),
+			track(newContainerRuntimeTable(sources, Software)),
+
+			tableCPU,
+			track(newISATable(sources, CPUCategory)),
+			track(newCPUFeatureTable(sources, CPUCategory)),
+			track(newCPUFeatureMismatchTable(sources, CPUCategory)),
+			track(newCPUIsolationTable(sources, CPUCategory)),
+			track(newRDTTable(sources, CPUCategory)),
+			track(newRDTGroupTable(sources, CPUCategory)),
+			track(newAcceleratorTable(sources, CPUCategory)),
+			track(newFeatureTable(sources, CPUCategory)),
+
+			track(newCPUEfficiencyTable(sources, CPUEfficiency)),
+
+			track(newPowerTable(sources, Power)),
+			track(newFrequencyPolicyTable(sources, Power)),
+			track(newPowerProfileMismatchTable(sources, Power)),
+			track(newSpeedSelectTable(sources, Power)),
+			track(newSpeedSelectProfileTable(sources, Power)),
+			track(newCorePriorityTable(sources, Power)),
+			track(newUncoreTable(sources, Power)),
 		}...,
 	)
 
-	tableDIMM := newDIMMTable(sources, Memory)
-	tableDIMMPopulation := newDIMMPopulationTable(sources, tableDIMM, cpusInfo, Memory)
+	tableDIMM := track(newDIMMTable(sources, Memory))
+	tableDIMMPopulation := track(newDIMMPopulationTable(sources, tableDIMM, cpusInfo, Memory))
+	tableNIC := track(newNICTable(sources, Network))
 
 	report.Tables = append(report.Tables,
 		[]*Table{
-			newMemoryTable(sources, tableDIMM, tableDIMMPopulation, Memory),
+			track(newMemoryTable(sources, tableDIMM, tableDIMMPopulation, Memory)),
 			tableDIMMPopulation,
 			tableDIMM,
+			track(newMemoryTieringTable(sources, Memory)),
+			track(newNUMANodeMemoryTable(sources, Memory)),
 
-			newNICTable(sources, Network),
-			newNetworkIRQTable(sources, Network),
+			tableNIC,
+			track(newNetworkIRQTable(sources, Network)),
+			track(newDPUTable(sources, Network)),
 
-			newDiskTable(sources, Storage),
-			newFilesystemTable(sources, Storage),
+			track(newDiskTable(sources, Storage)),
+			track(newNVMeHealthTable(sources, Storage)),
+			track(newNVMeNamespaceTable(sources, Storage)),
+			track(newZonedStorageTable(sources, Storage)),
+			track(newComputationalStorageTable(sources, Storage)),
+			track(newFilesystemTable(sources, Storage)),
 
-			newGPUTable(sources, GPU),
+			track(newGPUTable(sources, GPU)),
 
-			newCXLDeviceTable(sources, CXL),
+			track(newCXLDeviceTable(sources, CXL)),
+			track(newCXLMemoryTable(sources, CXL)),
 
-			newVulnerabilityTable(sources, Security),
+			track(newVulnerabilityTable(sources, Security)),
 
-			newProcessTable(sources, Status),
-			newSensorTable(sources, Status),
-			newChassisStatusTable(sources, Status),
-			newSystemEventLogTable(sources, Status),
-			newKernelLogTable(sources, Status),
-			newPMUTable(sources, Status),
-			newSvrinfoTable(sources, Status),
+			track(newProcessTable(sources, Status)),
+			track(newSensorTable(sources, Status)),
+			track(newChassisStatusTable(sources, Status)),
+			track(newSystemEventLogTable(sources, Status)),
+			track(newKernelLogTable(sources, Status)),
+			track(newPMUTable(sources, Status)),
+			track(newSvrinfoTable(sources, Status)),
+			track(newToolChecksumsTable(sources, Status)),
+			track(newQuantityTable(sources, Status)),
+			track(newPrivilegesTable(sources, Status)),
+			track(newReadOnlySkippedTable(sources, Status)),
+			track(newContainerSkippedTable(sources, Status)),
+			track(newNotCollectedTable(sources, Status)),
 		}...,
 	)
+	// data quality is an appendix that validates the values in the tables above
+	report.Tables = append(report.Tables, track(newDataQualityTable(sources, report.findTable("CPU"), tableDIMM, tableNIC, Status)))
 	// TODO: remove check when code is stable
 	for _, table := range report.Tables {
 		check(table, sources)
@@ -109,6 +216,7 @@ func NewBriefReport(sources []*Source, fullReport *Report, cpusInfo *cpu.CPU) (r
 			newBIOSSummaryTable(fullReport.findTable("BIOS"), Software),
 			newOperatingSystemBriefTable(fullReport.findTable("Operating System"), Software),
 			fullReport.findTable("Power"),
+			fullReport.findTable("Frequency Policy"),
 			newVulnerabilitySummaryTable(fullReport.findTable("Vulnerability"), Security),
 			newMarketingClaimTable(fullReport, tableNicSummary, tableDiskSummary, tableAcceleratorSummary, NoCategory),
 		}...,
@@ -120,17 +228,22 @@ func NewBriefReport(sources []*Source, fullReport *Report, cpusInfo *cpu.CPU) (r
 	return
 }
 
-func NewInsightsReport(sources []*Source, configReport, briefReport, profileReport, benchmarkReport *Report, analyzeReport *Report, cpusInfo *cpu.CPU) (report *Report) {
+func NewInsightsReport(sources []*Source, configReport, briefReport, profileReport, benchmarkReport *Report, analyzeReport *Report, cpusInfo *cpu.CPU, healthWeights *HealthWeights) (report *Report) {
 	report = &Report{
 		InternalName: "Recommendations",
 		Sources:      sources,
 		Tables:       []*Table{},
 	}
+	tableInsight, tableRemediation := newInsightTables(sources, configReport, briefReport, profileReport, benchmarkReport, analyzeReport, cpusInfo)
 	report.Tables = append(report.Tables,
 		[]*Table{
-			newInsightTable(sources, configReport, briefReport, profileReport, benchmarkReport, analyzeReport, cpusInfo),
+			tableInsight,
+			tableRemediation,
 		}...,
 	)
+	// the health grade is listed first, it's derived from the insights and the other reports
+	tableHealth := newHealthTable(sources, []*Report{report, configReport, benchmarkReport, profileReport, analyzeReport}, healthWeights, NoCategory)
+	report.Tables = append([]*Table{tableHealth}, report.Tables...)
 	// TODO: remove check when code is stable
 	for _, table := range report.Tables {
 		check(table, sources)
@@ -152,18 +265,39 @@ func NewProfileReport(sources []*Source) (report *Report) {
 	memStatsTable := newMemoryStatsTable(sources, NoCategory)
 	PMUMetricsTable := newPMUMetricsTable(sources, NoCategory)
 	powerStatsTable := newPowerStatsTable(sources, NoCategory)
+	runQueueLatencyTable := newRunQueueLatencyTable(sources, NoCategory)
+	schedulingLatencyTable := newSchedulingLatencyTable(sources, runQueueLatencyTable, NoCategory)
+	blockIOLatencyTable := newBlockIOLatencyTable(sources, NoCategory)
+	offCPUTimeTable := newOffCPUTimeTable(sources, NoCategory)
+	TCPRetransmitsTable := newTCPRetransmitsTable(sources, NoCategory)
+	CStateResidencyTable := newCStateResidencyTable(sources, NoCategory)
+	PStateDistributionTable := newPStateDistributionTable(sources, NoCategory)
+	EPPTable := newEnergyPerformancePreferenceTable(sources, NoCategory)
+	workloadFingerprintTable := newWorkloadFingerprintTable(sources, NoCategory)
 	summaryTable := newProfileSummaryTable(sources, NoCategory, averageCPUUtilizationTable, CPUUtilizationTable, IRQRateTable, driveStatsTable, netStatsTable, memStatsTable, PMUMetricsTable, powerStatsTable)
+	eventTimelineTable, eventCodePathsTable := newEventTimelineTables(sources, NoCategory, averageCPUUtilizationTable)
 	report.Tables = append(report.Tables,
 		[]*Table{
 			summaryTable,
+			workloadFingerprintTable,
+			eventTimelineTable,
+			eventCodePathsTable,
 			averageCPUUtilizationTable,
 			CPUUtilizationTable,
 			powerStatsTable,
+			CStateResidencyTable,
+			PStateDistributionTable,
+			EPPTable,
 			IRQRateTable,
 			driveStatsTable,
 			netStatsTable,
 			memStatsTable,
 			PMUMetricsTable,
+			schedulingLatencyTable,
+			runQueueLatencyTable,
+			blockIOLatencyTable,
+			offCPUTimeTable,
+			TCPRetransmitsTable,
 		}...,
 	)
 	// TODO: remove check when code is stable
@@ -191,19 +325,26 @@ func NewAnalyzeReport(sources []*Source) (report *Report) {
 	return
 }
 
-func NewBenchmarkReport(sources []*Source) (report *Report) {
+func NewBenchmarkReport(sources []*Source, configReport *Report, reference *BenchmarkReference) (report *Report) {
 	report = &Report{
 		InternalName: "Performance",
 		Sources:      sources,
 		Tables:       []*Table{},
 	}
 	tableMemBandwidthLatency := newMemoryBandwidthLatencyTable(sources, NoCategory)
+	tableSummary := newBenchmarkSummaryTable(sources, tableMemBandwidthLatency, NoCategory)
+	if reference != nil {
+		addBenchmarkReferenceValues(tableSummary, reference, gCmdLineArgs.refThreshold)
+	}
 	report.Tables = append(report.Tables,
 		[]*Table{
-			newBenchmarkSummaryTable(sources, tableMemBandwidthLatency, NoCategory),
+			tableSummary,
+			newBenchmarkRegressionTable(sources, tableSummary, configReport, gCmdLineArgs.threshold, NoCategory),
 			newFrequencyTable(sources, NoCategory),
 			tableMemBandwidthLatency,
 			newMemoryNUMABandwidthTable(sources, NoCategory),
+			newNICThroughputTable(sources, NoCategory),
+			newBenchmarkRawOutputTable(sources, NoCategory),
 		}...,
 	)
 	// TODO: remove check when code is stable
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// benchmarkCommand maps a benchmark's collector command to the tool that produced
+// its output and the name of the file its raw output is kept in
+type benchmarkCommand struct {
+	label    string
+	tool     string
+	fileName string
+}
+
+var benchmarkCommands = []benchmarkCommand{
+	{"Memory MLC Loaded Latency Test", "mlc --loaded_latency", "mlc_loaded_latency.txt"},
+	{"Memory MLC Bandwidth", "mlc --bandwidth_matrix", "mlc_bandwidth_matrix.txt"},
+	{"stress-ng cpu methods", "stress-ng", "stress-ng_cpu_methods.txt"},
+	{"Measure Turbo Frequencies", "calcfreq", "calcfreq.txt"},
+	{"CPU Turbo Test", "turbostat, stress-ng", "turbostat_turbo.txt"},
+	{"CPU Idle", "turbostat", "turbostat_idle.txt"},
+	{"fio", "fio", "fio.txt"},
+	{"NIC Throughput Test", "iperf3, pktgen", "nic_throughput.txt"},
+}
+
+// benchmarkRawFile is the raw output of one benchmark command, as kept in the
+// host's benchmark directory
+type benchmarkRawFile struct {
+	benchmarkCommand
+	path       string // relative to the output directory
+	exitStatus string
+	content    string
+}
+
+// getBenchmarkDir returns the name of the directory, relative to the output
+// directory, that holds the host's raw benchmark output
+func getBenchmarkDir(source *Source) string {
+	return source.getHostname() + "_benchmark"
+}
+
+// getBenchmarkRawFiles returns the raw output files for the benchmarks that ran
+// on the host. fio's JSON output is split into its own file.
+func getBenchmarkRawFiles(source *Source) (files []benchmarkRawFile) {
+	for _, cmd := range benchmarkCommands {
+		data, ok := source.ParsedData[cmd.label]
+		if !ok || strings.TrimSpace(data.Stdout) == "" {
+			continue
+		}
+		content := data.Stdout
+		var jsonContent string
+		if cmd.label == "fio" {
+			if idx := strings.Index(content, "\n{"); idx >= 0 {
+				jsonContent = content[idx+1:]
+				content = content[:idx+1]
+			}
+		}
+		if data.Stderr != "" {
+			content += "\n" + data.Stderr
+		}
+		files = append(files, benchmarkRawFile{
+			benchmarkCommand: cmd,
+			path:             filepath.Join(getBenchmarkDir(source), cmd.fileName),
+			exitStatus:       data.ExitStatus,
+			content:          content,
+		})
+		if jsonContent != "" {
+			jsonFileName := strings.TrimSuffix(cmd.fileName, filepath.Ext(cmd.fileName)) + ".json"
+			files = append(files, benchmarkRawFile{
+				benchmarkCommand: benchmarkCommand{cmd.label, cmd.tool, jsonFileName},
+				path:             filepath.Join(getBenchmarkDir(source), jsonFileName),
+				exitStatus:       data.ExitStatus,
+				content:          jsonContent,
+			})
+		}
+	}
+	return
+}
+
+// ReportGeneratorBenchmark keeps the raw output of each benchmark tool in a
+// directory per host so that results can be re-analyzed without re-running the
+// benchmarks.
+type ReportGeneratorBenchmark struct {
+	sources   []*Source
+	outputDir string
+}
+
+func newReportGeneratorBenchmark(sources []*Source, outputDir string) (rpt *ReportGeneratorBenchmark) {
+	rpt = &ReportGeneratorBenchmark{
+		sources:   sources,
+		outputDir: outputDir,
+	}
+	return
+}
+
+func (r *ReportGeneratorBenchmark) generate() (reportFilePaths []string, err error) {
+	for _, source := range r.sources {
+		files := getBenchmarkRawFiles(source)
+		if len(files) == 0 {
+			continue
+		}
+		benchmarkDir := filepath.Join(r.outputDir, getBenchmarkDir(source))
+		err = os.MkdirAll(benchmarkDir, 0755)
+		if err != nil {
+			err = fmt.Errorf("failed to create benchmark output directory: %v", err)
+			return
+		}
+		for _, file := range files {
+			reportFilePath := filepath.Join(r.outputDir, file.path)
+			err = os.WriteFile(reportFilePath, []byte(file.content), 0644)
+			if err != nil {
+				err = fmt.Errorf("failed to write benchmark output: %v", err)
+				return
+			}
+		}
+		reportFilePaths = append(reportFilePaths, benchmarkDir)
+	}
+	return
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intel/svr-info/internal/util"
+	"github.com/xuri/excelize/v2"
+)
+
+// ReportGeneratorComparison writes the configuration of all hosts side by side, by
+// category, with the fields whose values differ between the hosts, e.g., BIOS version,
+// kernel, microcode, and memory configuration, highlighted and listed first. It's written
+// in the html and xlsx formats, as comparison.html and comparison.xlsx, when there's more
+// than one host.
+type ReportGeneratorComparison struct {
+	sources       []*Source
+	outputDir     string
+	reportTypes   []string
+	configuration *Report
+}
+
+func newReportGeneratorComparison(sources []*Source, outputDir string, reportTypes []string, configuration *Report) (rpt *ReportGeneratorComparison) {
+	rpt = &ReportGeneratorComparison{
+		sources:       sources,
+		outputDir:     outputDir,
+		reportTypes:   reportTypes,
+		configuration: configuration,
+	}
+	return
+}
+
+// comparisonReportTypes are the formats the comparison report is written in
+var comparisonReportTypes = []string{"html", "xlsx"}
+
+// comparisonColor is the background color of the fields whose values differ
+const comparisonColor = "#FFE699"
+
+// ComparisonRow is a field's values, one per host
+type ComparisonRow struct {
+	Table   string
+	Field   string
+	Values  []string
+	Differs bool
+}
+
+// ComparisonTable is a configuration table's fields
+type ComparisonTable struct {
+	Name      string
+	Rows      []ComparisonRow
+	Differing int // the number of rows that differ
+}
+
+// ComparisonCategory is the tables of a category, e.g., Software
+type ComparisonCategory struct {
+	Name   string
+	Tables []ComparisonTable
+}
+
+// Comparison is the configuration of all hosts
+type Comparison struct {
+	Version     string
+	Hosts       []string
+	Differences []ComparisonRow
+	Categories  []ComparisonCategory
+}
+
+// getComparison compares the configuration tables of the hosts. The values that change
+// from run to run, e.g., free memory, are shown but aren't compared.
+func (r *ReportGeneratorComparison) getComparison() (comparison Comparison) {
+	comparison.Version = gVersion
+	for _, source := range r.sources {
+		comparison.Hosts = append(comparison.Hosts, source.getHostname())
+	}
+	for category, label := range TableCategoryLabels {
+		if TableCategory(category) == Status {
+			continue
+		}
+		comparisonCategory := ComparisonCategory{Name: label}
+		for _, table := range r.configuration.Tables {
+			if table == nil || table.Category != TableCategory(category) {
+				continue
+			}
+			comparisonTable := getComparisonTable(table)
+			if len(comparisonTable.Rows) == 0 {
+				continue
+			}
+			for _, row := range comparisonTable.Rows {
+				if row.Differs {
+					comparison.Differences = append(comparison.Differences, row)
+				}
+			}
+			comparisonCategory.Tables = append(comparisonCategory.Tables, comparisonTable)
+		}
+		if len(comparisonCategory.Tables) > 0 {
+			comparison.Categories = append(comparison.Categories, comparisonCategory)
+		}
+	}
+	return
+}
+
+// getComparisonTable returns the table's fields with the values of each host. In a table
+// with more than one record per host, e.g., DIMM, the records are matched by their first
+// value, and each field is named by its record, as in the delta report, e.g., Speed
+// [DIMM_A1]. The fields that no host has a value for are skipped.
+func getComparisonTable(table *Table) (comparisonTable ComparisonTable) {
+	comparisonTable.Name = table.Name
+	volatileFields, volatile := volatileConfiguration[table.Name]
+	var valueNames []string
+	multiRecord := false
+	for _, hv := range table.AllHostValues {
+		for _, valueName := range hv.ValueNames {
+			if !util.StringInList(valueName, valueNames) {
+				valueNames = append(valueNames, valueName)
+			}
+		}
+		multiRecord = multiRecord || len(getCompleteRecords(hv)) > 1
+	}
+	// the hosts' records by key, and the keys in the order they're first found
+	var keys []string
+	hostRecords := make([]map[string][]string, len(table.AllHostValues))
+	for hostIndex, hv := range table.AllHostValues {
+		hostRecords[hostIndex] = make(map[string][]string)
+		for _, record := range getCompleteRecords(hv) {
+			key := ""
+			if multiRecord {
+				key = record[0]
+				for i := 2; hostRecords[hostIndex][key] != nil; i++ {
+					key = fmt.Sprintf("%s (%d)", record[0], i)
+				}
+			}
+			hostRecords[hostIndex][key] = record
+			if !util.StringInList(key, keys) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, key := range keys {
+		for valueNameIndex, valueName := range valueNames {
+			if multiRecord && valueNameIndex == 0 {
+				continue // the key
+			}
+			row := ComparisonRow{Table: table.Name, Field: valueName}
+			if multiRecord {
+				row.Field = fmt.Sprintf("%s [%s]", valueName, key)
+			}
+			haveValue := false
+			for hostIndex, hv := range table.AllHostValues {
+				value := ""
+				if record, ok := hostRecords[hostIndex][key]; ok {
+					for valueIndex, name := range hv.ValueNames {
+						if name == valueName {
+							value = record[valueIndex]
+							break
+						}
+					}
+				}
+				haveValue = haveValue || strings.TrimSpace(value) != ""
+				row.Values = append(row.Values, value)
+			}
+			if !haveValue {
+				continue
+			}
+			if !volatile || (len(volatileFields) > 0 && !util.StringInList(valueName, volatileFields)) {
+				for _, value := range row.Values[1:] {
+					if value != row.Values[0] {
+						row.Differs = true
+						break
+					}
+				}
+			}
+			if row.Differs {
+				comparisonTable.Differing++
+			}
+			comparisonTable.Rows = append(comparisonTable.Rows, row)
+		}
+	}
+	return
+}
+
+func (r *ReportGeneratorComparison) generate() (reportFilePaths []string, err error) {
+	comparison := r.getComparison()
+	for _, reportType := range r.reportTypes {
+		reportFilePath := filepath.Join(r.outputDir, "comparison."+reportType)
+		switch reportType {
+		case "html":
+			err = r.writeHTML(reportFilePath, comparison)
+		case "xlsx":
+			err = r.writeXLSX(reportFilePath, comparison)
+		default:
+			err = fmt.Errorf("unsupported comparison report type: %s", reportType)
+		}
+		if err != nil {
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
+
+func (r *ReportGeneratorComparison) writeHTML(reportFilePath string, comparison Comparison) (err error) {
+	t, err := template.ParseFS(resources, "resources/comparison.html.tmpl")
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(reportFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return t.Execute(f, comparison)
+}
+
+// writeXLSX writes the differences on the first sheet, and the configuration of all hosts
+// on the second sheet, with the values that differ highlighted
+func (r *ReportGeneratorComparison) writeXLSX(reportFilePath string, comparison Comparison) (err error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	// differences
+	sheet := "Differences"
+	f.SetSheetName("Sheet1", sheet)
+	f.SetColWidth(sheet, "A", "B", 25)
+	f.SetColWidth(sheet, "C", "L", 30)
+	headers := append([]string{"Table", "Field"}, comparison.Hosts...)
+	var values [][]string
+	for _, row := range comparison.Differences {
+		values = append(values, append([]string{row.Table, row.Field}, row.Values...))
+	}
+	renderExcelTable(headers, values, f, sheet, 1, 1, false, nil)
+	// all fields, by category
+	sheet = "Comparison"
+	f.NewSheet(sheet)
+	f.SetColWidth(sheet, "A", "A", 15)
+	f.SetColWidth(sheet, "B", "L", 30)
+	row := 1
+	for _, category := range comparison.Categories {
+		f.SetCellStr(sheet, cellName(1, row), category.Name)
+		f.SetCellStyle(sheet, cellName(1, row), cellName(1, row), bold)
+		row++
+		for _, table := range category.Tables {
+			f.SetCellStr(sheet, cellName(1, row), table.Name)
+			f.SetCellStyle(sheet, cellName(1, row), cellName(1, row), bold)
+			var values, colors [][]string
+			for _, tableRow := range table.Rows {
+				values = append(values, append([]string{tableRow.Field}, tableRow.Values...))
+				rowColors := make([]string, len(tableRow.Values)+1)
+				if tableRow.Differs {
+					for i := range rowColors {
+						rowColors[i] = comparisonColor
+					}
+				}
+				colors = append(colors, rowColors)
+			}
+			row = renderExcelTable(append([]string{""}, comparison.Hosts...), values, f, sheet, row, 2, true, colors) + 1
+		}
+	}
+	return f.SaveAs(reportFilePath)
+}
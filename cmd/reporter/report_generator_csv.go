@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// ReportGeneratorCSV writes each report table as a CSV file, for spreadsheet and pandas
+// analysis. The CSV files of a host are bundled in a zip file, e.g., hostname.csv.zip,
+// named by report and table, e.g., configuration_cpu.csv. If there's more than one host,
+// all_hosts.csv.zip has the tables of all hosts, with a Host column, in one CSV file per
+// table.
+type ReportGeneratorCSV struct {
+	sources   []*Source
+	reports   []*Report
+	outputDir string
+}
+
+func newReportGeneratorCSV(sources []*Source, outputDir string, reports ...*Report) (rpt *ReportGeneratorCSV) {
+	rpt = &ReportGeneratorCSV{
+		sources:   sources,
+		reports:   reports,
+		outputDir: outputDir,
+	}
+	return
+}
+
+func (r *ReportGeneratorCSV) generate() (reportFilePaths []string, err error) {
+	for hostIndex, source := range r.sources {
+		reportFilePath := filepath.Join(r.outputDir, source.getHostname()+".csv.zip")
+		if err = r.writeBundle(reportFilePath, []int{hostIndex}); err != nil {
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	// combined, all-host CSV bundle, if more than one host
+	if len(r.sources) > 1 {
+		var hostIndices []int
+		for hostIndex := range r.sources {
+			hostIndices = append(hostIndices, hostIndex)
+		}
+		reportFilePath := filepath.Join(r.outputDir, "all_hosts.csv.zip")
+		if err = r.writeBundle(reportFilePath, hostIndices); err != nil {
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
+
+// writeBundle writes the zip file of the hosts' tables, one CSV file per table. The
+// tables have a Host column if there's more than one host. The zip file is removed if
+// it can't be written completely.
+func (r *ReportGeneratorCSV) writeBundle(reportFilePath string, hostIndices []int) (err error) {
+	f, err := os.OpenFile(reportFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create/open file for writing: %s", reportFilePath)
+		return
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(reportFilePath)
+		}
+	}()
+	zw := zip.NewWriter(f)
+	defer func() {
+		if err != nil {
+			zw.Close() // the partial zip file is removed
+		}
+	}()
+	fileNames := make(map[string]int) // file name -> count, to name tables with the same name apart
+	modified := time.Now()
+	for _, report := range r.reports {
+		if report == nil {
+			continue
+		}
+		for _, table := range report.Tables {
+			records := getCSVRecords(table, hostIndices, r.sources)
+			if len(records) < 2 { // no values, only the header
+				continue
+			}
+			fileName := csvFileName(report.InternalName, table.Name)
+			fileNames[fileName]++
+			if fileNames[fileName] > 1 {
+				fileName = fmt.Sprintf("%s_%d.csv", strings.TrimSuffix(fileName, ".csv"), fileNames[fileName])
+			}
+			var zf io.Writer
+			if zf, err = zw.CreateHeader(&zip.FileHeader{Name: fileName, Method: zip.Deflate, Modified: modified}); err != nil {
+				return
+			}
+			if err = csv.NewWriter(zf).WriteAll(records); err != nil {
+				return
+			}
+		}
+	}
+	err = zw.Close()
+	return
+}
+
+// getCSVRecords returns the header and records of the hosts' table values. If there's
+// more than one host, the first column is the host's name. Values are matched to the
+// header by name, so that hosts whose tables have different value names line up.
+func getCSVRecords(table *Table, hostIndices []int, sources []*Source) (records [][]string) {
+	var valueNames []string
+	for _, hostIndex := range hostIndices {
+		for _, valueName := range table.AllHostValues[hostIndex].ValueNames {
+			if !util.StringInList(valueName, valueNames) {
+				valueNames = append(valueNames, valueName)
+			}
+		}
+	}
+	multiHost := len(hostIndices) > 1
+	var header []string
+	if multiHost {
+		header = append(header, "Host")
+	}
+	records = append(records, append(header, valueNames...))
+	for _, hostIndex := range hostIndices {
+		hv := table.AllHostValues[hostIndex]
+		if !hasTextValues(hv) {
+			continue
+		}
+		for _, values := range getCompleteRecords(hv) {
+			var record []string
+			if multiHost {
+				record = append(record, sources[hostIndex].getHostname())
+			}
+			for _, valueName := range valueNames {
+				value := ""
+				for i, name := range hv.ValueNames {
+					if name == valueName {
+						value = values[i]
+						break
+					}
+				}
+				record = append(record, value)
+			}
+			records = append(records, record)
+		}
+	}
+	return
+}
+
+var reCSVFileNameSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// csvFileName returns the name of the table's CSV file, e.g., configuration_cpu.csv
+func csvFileName(reportName string, tableName string) string {
+	name := reCSVFileNameSeparators.ReplaceAllString(strings.ToLower(reportName+" "+tableName), "_")
+	return strings.Trim(name, "_") + ".csv"
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/intel/svr-info/internal/util"
+)
+
+// ReportGeneratorDelta writes only the changes between consecutive runs of the same host,
+// e.g., the snapshots taken before and after a maintenance activity, or a collection and
+// the baseline collection it's compared to. The configuration changes are grouped into
+// firmware updates, kernel parameter changes, and other configuration drift, followed by
+// the changes in the benchmark results. One report per format covers all hosts.
+type ReportGeneratorDelta struct {
+	sources       []*Source
+	outputDir     string
+	reportTypes   []string
+	configuration *Report
+}
+
+func newReportGeneratorDelta(sources []*Source, outputDir string, reportTypes []string, configuration *Report) (rpt *ReportGeneratorDelta) {
+	rpt = &ReportGeneratorDelta{
+		sources:       sources,
+		outputDir:     outputDir,
+		reportTypes:   reportTypes,
+		configuration: configuration,
+	}
+	return
+}
+
+// the kinds of configuration changes, in the order they're reported
+const (
+	deltaKindFirmware         = "Firmware"
+	deltaKindKernelParameters = "Kernel Parameters"
+	deltaKindConfiguration    = "Configuration"
+)
+
+var deltaKinds = []string{deltaKindFirmware, deltaKindKernelParameters, deltaKindConfiguration}
+
+func deltaKindOrder(kind string) int {
+	for i, k := range deltaKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(deltaKinds)
+}
+
+// DeltaChange is a configuration value that changed between runs
+type DeltaChange struct {
+	Kind   string
+	Table  string
+	Field  string
+	Before string
+	After  string
+}
+
+// BenchmarkDelta is a benchmark result that changed between runs
+type BenchmarkDelta struct {
+	Metric     string
+	Before     string
+	After      string
+	Change     string
+	Regression bool // the change is worse than -regression_threshold percent
+}
+
+// HostDelta is the configuration changes between two runs of a host
+type HostDelta struct {
+	Host            string
+	Before          string
+	After           string
+	BeforeCollected string
+	AfterCollected  string
+	Changes         []DeltaChange
+	Benchmarks      []BenchmarkDelta `json:",omitempty"`
+}
+
+// getHostDeltas returns the changes between each run of a host and the run before it. A
+// baseline run is run 0.
+func (r *ReportGeneratorDelta) getHostDeltas() (deltas []HostDelta) {
+	tableSummary := newBenchmarkSummaryTable(r.sources, newMemoryBandwidthLatencyTable(r.sources, NoCategory), NoCategory)
+	for afterIndex, after := range r.sources {
+		if after.runOf == "" || after.run < 1 {
+			continue
+		}
+		for beforeIndex, before := range r.sources {
+			if before.runOf != after.runOf || before.run != after.run-1 {
+				continue
+			}
+			delta := HostDelta{
+				Host:            after.runOf,
+				Before:          before.getHostname(),
+				After:           after.getHostname(),
+				BeforeCollected: formatCollectionTime(before),
+				AfterCollected:  formatCollectionTime(after),
+				Changes:         []DeltaChange{},
+			}
+			for _, change := range getConfigurationChanges(r.configuration, beforeIndex, afterIndex) {
+				if change.table == "Operating System" && change.field == "Boot Parameters" {
+					delta.Changes = append(delta.Changes, getBootParameterChanges(change.before, change.after)...)
+					continue
+				}
+				field := change.field
+				if change.row != "" {
+					field = fmt.Sprintf("%s [%s]", field, change.row)
+				}
+				delta.Changes = append(delta.Changes, DeltaChange{
+					Kind:   getDeltaKind(change.table, change.field),
+					Table:  change.table,
+					Field:  field,
+					Before: change.before,
+					After:  change.after,
+				})
+			}
+			sort.SliceStable(delta.Changes, func(i, j int) bool {
+				return deltaKindOrder(delta.Changes[i].Kind) < deltaKindOrder(delta.Changes[j].Kind)
+			})
+			delta.Benchmarks = getBenchmarkDeltas(tableSummary, beforeIndex, afterIndex)
+			deltas = append(deltas, delta)
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return deltas[i].Host < deltas[j].Host
+	})
+	return
+}
+
+// getDeltaKind returns the kind of a change to a configuration table's field
+func getDeltaKind(table string, field string) string {
+	switch {
+	case table == "BIOS" || table == "Microcode" || field == "Microcode" || strings.Contains(field, "Firmware"):
+		return deltaKindFirmware
+	case table == "Bootloader" && util.StringInList(field, []string{"Bootloader", "Secure Boot", "Shim"}):
+		return deltaKindFirmware
+	case table == "CPU Isolation" || table == "Boot Entry" || field == "Boot Parameters" || (table == "Bootloader" && strings.Contains(field, "Parameter")):
+		return deltaKindKernelParameters
+	}
+	return deltaKindConfiguration
+}
+
+// getBootParameterChanges returns the kernel boot parameters that were added, removed, or
+// changed, one change per parameter, e.g., "Boot Parameters [isolcpus]"
+func getBootParameterChanges(before string, after string) (changes []DeltaChange) {
+	parse := func(cmdline string) (names []string, values map[string]string) {
+		values = make(map[string]string)
+		for _, parameter := range strings.Fields(cmdline) {
+			name, _, _ := strings.Cut(parameter, "=")
+			if _, ok := values[name]; !ok {
+				names = append(names, name)
+			}
+			values[name] = parameter
+		}
+		return
+	}
+	beforeNames, beforeValues := parse(before)
+	afterNames, afterValues := parse(after)
+	for _, name := range append(beforeNames, afterNames...) {
+		beforeValue, afterValue := beforeValues[name], afterValues[name]
+		if beforeValue == afterValue {
+			continue
+		}
+		if beforeValue == "" {
+			beforeValue = "(not set)"
+		}
+		if afterValue == "" {
+			afterValue = "(not set)"
+		}
+		changes = append(changes, DeltaChange{
+			Kind:   deltaKindKernelParameters,
+			Table:  "Operating System",
+			Field:  fmt.Sprintf("Boot Parameters [%s]", name),
+			Before: beforeValue,
+			After:  afterValue,
+		})
+		delete(beforeValues, name) // report each parameter once
+		delete(afterValues, name)
+	}
+	return
+}
+
+// getBenchmarkDeltas returns the benchmark results that changed between two hosts in the
+// benchmark summary table
+func getBenchmarkDeltas(tableSummary *Table, beforeIndex int, afterIndex int) (benchmarks []BenchmarkDelta) {
+	for _, metric := range benchmarkMetrics {
+		beforeValue, _ := tableSummary.getValue(beforeIndex, metric.name)
+		afterValue, _ := tableSummary.getValue(afterIndex, metric.name)
+		a, okA := getBenchmarkMetricValue(beforeValue)
+		b, okB := getBenchmarkMetricValue(afterValue)
+		if !okA || !okB || a == 0 || a == b {
+			continue
+		}
+		change := (b - a) / a * 100
+		benchmarks = append(benchmarks, BenchmarkDelta{
+			Metric:     metric.name,
+			Before:     beforeValue,
+			After:      afterValue,
+			Change:     fmt.Sprintf("%+.1f%%", change),
+			Regression: (metric.higherIsBetter && change < -gCmdLineArgs.threshold) || (!metric.higherIsBetter && change > gCmdLineArgs.threshold),
+		})
+	}
+	return
+}
+
+func formatCollectionTime(source *Source) string {
+	t := source.getCollectionTime()
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (r *ReportGeneratorDelta) generate() (reportFilePaths []string, err error) {
+	deltas := r.getHostDeltas()
+	if len(deltas) == 0 {
+		err = fmt.Errorf("the delta report requires two or more runs of the same host")
+		return
+	}
+	for _, reportType := range r.reportTypes {
+		var content []byte
+		switch reportType {
+		case "json":
+			content, err = json.MarshalIndent(deltas, "", "  ")
+		case "txt":
+			content = []byte(r.getText(deltas))
+		case "html":
+			content, err = r.getHTML(deltas)
+		default:
+			err = fmt.Errorf("unsupported delta report type: %s", reportType)
+		}
+		if err != nil {
+			return
+		}
+		reportFilePath := filepath.Join(r.outputDir, "delta."+reportType)
+		if err = os.WriteFile(reportFilePath, content, 0644); err != nil {
+			err = fmt.Errorf("failed to write delta report: %v", err)
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
+
+func (r *ReportGeneratorDelta) getText(deltas []HostDelta) string {
+	var sb strings.Builder
+	sb.WriteString(txtHeading("Configuration Delta", "=") + "\n")
+	for _, delta := range deltas {
+		sb.WriteString("\n")
+		title := fmt.Sprintf("%s: %s (%s) -> %s (%s)", delta.Host, delta.Before, delta.BeforeCollected, delta.After, delta.AfterCollected)
+		if len(delta.Changes) == 0 {
+			sb.WriteString(txtHeading(title, "-") + "\n")
+			sb.WriteString("No configuration changes.\n")
+		} else {
+			hv := HostValues{ValueNames: []string{"Kind", "Table", "Field", "Before", "After"}}
+			for _, change := range delta.Changes {
+				hv.Values = append(hv.Values, []string{change.Kind, change.Table, change.Field, change.Before, change.After})
+			}
+			sb.WriteString(renderTextTable(title, hv, gCmdLineArgs.txtWidth) + "\n")
+		}
+		if len(delta.Benchmarks) > 0 {
+			hv := HostValues{ValueNames: []string{"Metric", "Before", "After", "Change", "Regression"}}
+			for _, benchmark := range delta.Benchmarks {
+				regression := ""
+				if benchmark.Regression {
+					regression = "yes"
+				}
+				hv.Values = append(hv.Values, []string{benchmark.Metric, benchmark.Before, benchmark.After, benchmark.Change, regression})
+			}
+			sb.WriteString("\n" + renderTextTable(delta.Host+": Benchmarks", hv, gCmdLineArgs.txtWidth) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func (r *ReportGeneratorDelta) getHTML(deltas []HostDelta) (content []byte, err error) {
+	t, err := template.ParseFS(resources, "resources/delta.html.tmpl")
+	if err != nil {
+		return
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, struct {
+		Version string
+		Deltas  []HostDelta
+	}{gVersion, deltas})
+	content = []byte(sb.String())
+	return
+}
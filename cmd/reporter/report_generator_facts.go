@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReportGeneratorFacts writes a minimal, flat facts file per host, similar to Ansible
+// facts, for provisioning and image validation pipelines, e.g., Terraform's jsondecode()
+// or a cloud-init check. Fact names and types are stable, unlike the report tables.
+// Facts that weren't collected are omitted.
+type ReportGeneratorFacts struct {
+	outputDir           string
+	configurationReport *Report
+}
+
+func newReportGeneratorFacts(outputDir string, configurationReport *Report) (rpt *ReportGeneratorFacts) {
+	rpt = &ReportGeneratorFacts{
+		outputDir:           outputDir,
+		configurationReport: configurationReport,
+	}
+	return
+}
+
+// fact types
+const (
+	factString = iota
+	factInt
+	factBytes // normalized to bytes, e.g., 1.5 GiB is 1610612736
+	factBool  // Enabled, Yes, or True is true
+)
+
+type fact struct {
+	name      string
+	table     string
+	valueName string
+	factType  int
+}
+
+var facts = []fact{
+	{"collection_time", "Host", "Time", factString},
+	{"container", "Host", "Container", factString},
+	{"system_manufacturer", "System", "Manufacturer", factString},
+	{"system_product_name", "System", "Product Name", factString},
+	{"system_serial", "System", "Serial #", factString},
+	{"system_uuid", "System", "UUID", factString},
+	{"bios_vendor", "BIOS", "Vendor", factString},
+	{"bios_version", "BIOS", "Version", factString},
+	{"bios_release_date", "BIOS", "Release Date", factString},
+	{"os", "Operating System", "OS", factString},
+	{"kernel", "Operating System", "Kernel", factString},
+	{"microcode", "Operating System", "Microcode", factString},
+	{"kernel_live_patches", "Operating System", "Live Patches", factString},
+	{"kernel_taint", "Operating System", "Kernel Taint", factString},
+	{"bootloader", "Bootloader", "Bootloader", factString},
+	{"secure_boot", "Bootloader", "Secure Boot", factString},
+	{"boot_default_parameters", "Bootloader", "Default Parameters", factString},
+	{"cpu_model", "CPU", "CPU Model", factString},
+	{"cpu_microarchitecture", "CPU", "Microarchitecture", factString},
+	{"cpu_architecture", "CPU", "Architecture", factString},
+	{"cpu_sockets", "CPU", "Sockets", factInt},
+	{"cpu_cores_per_socket", "CPU", "Cores per Socket", factInt},
+	{"cpu_count", "CPU", "CPUs", factInt},
+	{"cpu_hyperthreading", "CPU", "Hyperthreading", factBool},
+	{"cpu_l3_cache_bytes", "CPU", "L3 Cache", factBytes},
+	{"numa_nodes", "CPU", "NUMA Nodes", factInt},
+	{"memory_installed_bytes", "Memory", "Installed Memory", factBytes},
+	{"memory_total_bytes", "Memory", "MemTotal", factBytes},
+	{"cloud_provider", "Cloud Instance", "Provider", factString},
+	{"cloud_instance_type", "Cloud Instance", "Instance Type", factString},
+	{"cloud_region", "Cloud Instance", "Region", factString},
+}
+
+// getFactValue converts the value to the fact's type, ok is false if it can't be converted
+func getFactValue(value string, factType int) (factValue interface{}, ok bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	switch factType {
+	case factInt:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i, true
+		}
+	case factBytes:
+		if bytes, found := normalizeSize(value); found {
+			return int64(bytes), true
+		}
+	case factBool:
+		switch strings.ToLower(value) {
+		case "enabled", "yes", "true":
+			return true, true
+		case "disabled", "no", "false":
+			return false, true
+		}
+	default:
+		return value, true
+	}
+	return
+}
+
+// getHostFacts returns the facts of the host at sourceIdx
+func (r *ReportGeneratorFacts) getHostFacts(sourceIdx int) (hostFacts map[string]interface{}) {
+	hostFacts = map[string]interface{}{
+		"hostname":         r.configurationReport.Sources[sourceIdx].getHostname(),
+		"svr_info_version": gVersion,
+	}
+	for _, f := range facts {
+		table := r.configurationReport.findTable(f.table)
+		if table == nil {
+			continue
+		}
+		value, err := table.getValue(sourceIdx, f.valueName)
+		if err != nil {
+			continue
+		}
+		if factValue, ok := getFactValue(value, f.factType); ok {
+			hostFacts[f.name] = factValue
+		}
+	}
+	if table := r.configurationReport.findTable("NIC"); table != nil {
+		nics := []map[string]interface{}{}
+		for _, row := range getRowMaps(table.AllHostValues[sourceIdx]) {
+			nics = append(nics, map[string]interface{}{
+				"name":        row["Name"],
+				"model":       row["Model"],
+				"speed":       row["Speed"],
+				"driver":      row["Driver"],
+				"mac_address": row["MAC Address"],
+			})
+		}
+		hostFacts["network_interfaces"] = nics
+	}
+	if table := r.configurationReport.findTable("Disk"); table != nil {
+		disks := []map[string]interface{}{}
+		var diskNames []string
+		for _, row := range getRowMaps(table.AllHostValues[sourceIdx]) {
+			// lsblk lists each disk followed by its partitions
+			isPartition := false
+			for _, name := range diskNames {
+				if suffix, found := strings.CutPrefix(row["NAME"], name); found && rePartitionSuffix.MatchString(suffix) {
+					isPartition = true
+				}
+			}
+			if isPartition || row["NAME"] == "" {
+				continue
+			}
+			diskNames = append(diskNames, row["NAME"])
+			disk := map[string]interface{}{
+				"name":  row["NAME"],
+				"model": row["MODEL"],
+			}
+			if size, ok := getFactValue(row["SIZE"], factBytes); ok {
+				disk["size_bytes"] = size
+			}
+			disks = append(disks, disk)
+		}
+		hostFacts["disks"] = disks
+	}
+	return
+}
+
+// rePartitionSuffix matches the suffix of a partition's name, e.g., 1 in sda1 or p1 in nvme0n1p1
+var rePartitionSuffix = regexp.MustCompile(`^p?\d+$`)
+
+// getRowMaps returns the host's rows as maps of value name to value
+func getRowMaps(hv HostValues) (rows []map[string]string) {
+	for _, values := range hv.Values {
+		row := make(map[string]string)
+		for i, value := range values {
+			row[hv.ValueNames[i]] = value
+		}
+		rows = append(rows, row)
+	}
+	return
+}
+
+func (r *ReportGeneratorFacts) generate() (reportFilePaths []string, err error) {
+	for sourceIdx, source := range r.configurationReport.Sources {
+		var jsonData []byte
+		jsonData, err = json.MarshalIndent(r.getHostFacts(sourceIdx), "", "  ")
+		if err != nil {
+			return
+		}
+		reportFilePath := filepath.Join(r.outputDir, source.getHostname()+".facts.json")
+		err = os.WriteFile(reportFilePath, jsonData, 0644)
+		if err != nil {
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
@@ -15,6 +15,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -36,16 +37,18 @@ const (
 const noDataFound = "No data found."
 
 type ReportGeneratorHTML struct {
-	reports   []*Report
-	outputDir string
-	cpusInfo  *cpu.CPU
+	reports        []*Report
+	outputDir      string
+	cpusInfo       *cpu.CPU
+	highlightRules *HighlightRules
 }
 
-func newReportGeneratorHTML(outputDir string, cpusInfo *cpu.CPU, configurationData *Report, insightData *Report, profileData *Report, benchmarkData *Report, analyzeData *Report) (rpt *ReportGeneratorHTML) {
+func newReportGeneratorHTML(outputDir string, cpusInfo *cpu.CPU, highlightRules *HighlightRules, configurationData *Report, insightData *Report, profileData *Report, benchmarkData *Report, analyzeData *Report) (rpt *ReportGeneratorHTML) {
 	rpt = &ReportGeneratorHTML{
-		reports:   []*Report{configurationData, benchmarkData, profileData, analyzeData, insightData}, // order matches const indexes defined above
-		outputDir: outputDir,
-		cpusInfo:  cpusInfo,
+		reports:        []*Report{configurationData, benchmarkData, profileData, analyzeData, insightData}, // order matches const indexes defined above
+		outputDir:      outputDir,
+		cpusInfo:       cpusInfo,
+		highlightRules: highlightRules,
 	}
 	return
 }
@@ -59,24 +62,93 @@ type ReportWithMore struct {
 
 // ReportGen - struct used within the HTML template
 type ReportGen struct {
-	HostIndices []int
-	Reports     []*ReportWithMore
+	HostIndices    []int
+	Reports        []*ReportWithMore
+	ReadOnly       string // the read-only guarantee, if all hosts were collected in read-only mode
+	highlightRules *HighlightRules
+	anchor         string // the anchor of the table being rendered
 }
 
-func newReportGen(reportsData []*Report, hostIndices []int, hostsReferenceData []*HostReferenceData) (gen *ReportGen) {
+func newReportGen(reportsData []*Report, hostIndices []int, hostsReferenceData []*HostReferenceData, highlightRules *HighlightRules) (gen *ReportGen) {
 	namedReports := []*ReportWithMore{}
-	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[configurationDataIndex], Name: "Configuration", Notes: []string{""}})
+	readOnly := isReadOnly(reportsData[configurationDataIndex].findTable("Host"), hostIndices)
+	var configurationNotes []string
+	if readOnly != "" {
+		configurationNotes = append(configurationNotes, readOnly)
+	}
+	if container := getContainerNote(reportsData[configurationDataIndex].findTable("Host"), hostIndices); container != "" {
+		configurationNotes = append(configurationNotes, container)
+	}
+	if notCollected := getNotCollectedNote(reportsData[configurationDataIndex].findTable("Not Collected Commands"), hostIndices); notCollected != "" {
+		configurationNotes = append(configurationNotes, notCollected)
+	}
+	if len(configurationNotes) == 0 {
+		configurationNotes = []string{""}
+	}
+	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[configurationDataIndex], Name: "Configuration", Notes: configurationNotes})
 	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[benchmarkDataIndex], Name: "Benchmark", Notes: []string{"Use the \"-benchmark all\" option to collect all micro-benchmarking data. See \"-help\" for finer control."}, RefData: hostsReferenceData})
 	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[profileDataIndex], Name: "Profile", Notes: []string{"Use the \"-profile all\" option to collect all system profiling data. See \"-help\" for finer control."}})
 	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[analyzeDataIndex], Name: "Analyze", Notes: []string{"Use the \"-analyze all\" option to collect all analysis data. See \"-help\" for finer control.", "Note: Perl is required on the target machine to collapse the call stacks used to produce System Flame Graphs."}})
 	namedReports = append(namedReports, &ReportWithMore{Report: *reportsData[insightDataIndex], Name: "Insights", Notes: []string{"Insights are derived from data collected by Intel® System Health Inspector. They are provided for consideration but may not always be relevant."}})
 	gen = &ReportGen{
-		HostIndices: hostIndices,
-		Reports:     namedReports,
+		HostIndices:    hostIndices,
+		Reports:        namedReports,
+		ReadOnly:       readOnly,
+		highlightRules: highlightRules,
 	}
 	return
 }
 
+// isReadOnly returns the read-only guarantee if all of the hosts were collected in
+// read-only mode, otherwise an empty string
+func isReadOnly(tableHost *Table, hostIndices []int) string {
+	if tableHost == nil || len(hostIndices) == 0 {
+		return ""
+	}
+	for _, hostIndex := range hostIndices {
+		if mode, err := tableHost.getValue(hostIndex, "Collection Mode"); err != nil || mode != "read-only" {
+			return ""
+		}
+	}
+	return readOnlyGuarantee
+}
+
+// getContainerNote returns the container note, naming the hosts that were collected in
+// a container, or an empty string if none were
+func getContainerNote(tableHost *Table, hostIndices []int) string {
+	if tableHost == nil {
+		return ""
+	}
+	var hosts []string
+	for _, hostIndex := range hostIndices {
+		if container, err := tableHost.getValue(hostIndex, "Container"); err == nil && container != "none" {
+			hosts = append(hosts, fmt.Sprintf("%s (%s)", tableHost.AllHostValues[hostIndex].Name, container))
+		}
+	}
+	if len(hosts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s Hosts: %s.", containerNote, strings.Join(hosts, ", "))
+}
+
+// getNotCollectedNote returns the partial collection note, naming the hosts whose
+// collection was interrupted, or an empty string if none were
+func getNotCollectedNote(tableNotCollected *Table, hostIndices []int) string {
+	if tableNotCollected == nil {
+		return ""
+	}
+	var hosts []string
+	for _, hostIndex := range hostIndices {
+		if hv := tableNotCollected.AllHostValues[hostIndex]; len(hv.Values) > 0 {
+			hosts = append(hosts, hv.Name)
+		}
+	}
+	if len(hosts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s Hosts: %s.", notCollectedNote, strings.Join(hosts, ", "))
+}
+
 type HostReferenceData map[string]interface{}
 type ReferenceData map[string]HostReferenceData
 
@@ -131,14 +203,72 @@ func (r *ReportGen) RenderMenuItems(reportData *ReportWithMore) template.HTML {
 	category := NoCategory
 	for _, table := range reportData.Tables {
 		if table.Category != category {
-			out += fmt.Sprintf(`<a href="#%s">%s</a>`, table.Name, TableCategoryLabels[table.Category])
+			out += fmt.Sprintf(`<a href="#%s">%s</a>`, htmlAnchor(reportData.Name, table.Name), TableCategoryLabels[table.Category])
 			category = table.Category
 		}
 	}
 	return template.HTML(out)
 }
 
+var reAnchorSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+// maxAnchorPartLength limits the length of anchors named by long values, e.g., log entries
+const maxAnchorPartLength = 48
+
+// htmlAnchor returns the anchor ID of the parts, e.g., configuration-bios-host1, so that
+// sections, hosts, and rows can be linked to. The IDs are the same in every report, i.e.,
+// a link to a host's table works in the host's report and in all_hosts.html.
+func htmlAnchor(parts ...string) string {
+	var slugs []string
+	for _, part := range parts {
+		slug := strings.Trim(reAnchorSeparator.ReplaceAllString(strings.ToLower(html.UnescapeString(part)), "-"), "-")
+		if len(slug) > maxAnchorPartLength {
+			slug = strings.TrimRight(slug[:maxAnchorPartLength], "-")
+		}
+		if slug != "" {
+			slugs = append(slugs, slug)
+		}
+	}
+	return strings.Join(slugs, "-")
+}
+
+// permalink is the control that copies the link to the anchor
+func permalink(anchor string) string {
+	return `<a class="permalink" href="#` + anchor + `" title="Copy link">#</a>`
+}
+
+// hostHeading returns the heading of a host's part of the table being rendered
+func (r *ReportGen) hostHeading(hostname string) string {
+	anchor := htmlAnchor(r.anchor, hostname)
+	return `<h3 id="` + anchor + `">` + hostname + permalink(anchor) + `</h3>`
+}
+
+// rowAnchors returns the anchors of the table's rows, named by the rows' first value,
+// e.g., the DIMM's slot, which is numbered if it's repeated
+func rowAnchors(prefix string, rows [][]string) (anchors []string) {
+	used := make(map[string]bool)
+	for _, row := range rows {
+		key := ""
+		if len(row) > 0 {
+			key = row[0]
+		}
+		anchor := htmlAnchor(prefix, key)
+		for i := 2; used[anchor]; i++ {
+			anchor = htmlAnchor(prefix, key, strconv.Itoa(i))
+		}
+		used[anchor] = true
+		anchors = append(anchors, anchor)
+	}
+	return
+}
+
 func renderHTMLTable(tableHeaders []string, tableValues [][]string, class string, valuesStyle [][]string) (out string) {
+	return renderHTMLTableWithAnchors(tableHeaders, tableValues, class, valuesStyle, nil)
+}
+
+// renderHTMLTableWithAnchors renders the table with an anchor ID, and the control that
+// copies the link to it, on each row that has an anchor
+func renderHTMLTableWithAnchors(tableHeaders []string, tableValues [][]string, class string, valuesStyle [][]string, anchors []string) (out string) {
 	if len(tableValues) > 0 {
 		out += `<table class="` + class + `">`
 		if len(tableHeaders) > 0 {
@@ -152,12 +282,23 @@ func renderHTMLTable(tableHeaders []string, tableValues [][]string, class string
 		}
 		out += `<tbody>`
 		for rowIdx, rowValues := range tableValues {
-			out += `<tr>`
+			anchor := ""
+			if len(anchors) > rowIdx {
+				anchor = anchors[rowIdx]
+			}
+			if anchor != "" {
+				out += `<tr id="` + anchor + `">`
+			} else {
+				out += `<tr>`
+			}
 			for colIdx, value := range rowValues {
 				var style string
-				if len(valuesStyle) > rowIdx && len(valuesStyle[rowIdx]) > colIdx {
+				if len(valuesStyle) > rowIdx && len(valuesStyle[rowIdx]) > colIdx && valuesStyle[rowIdx][colIdx] != "" {
 					style = ` style="` + valuesStyle[rowIdx][colIdx] + `"`
 				}
+				if anchor != "" && colIdx == 0 {
+					value += permalink(anchor)
+				}
 				out += `<td` + style + `>` + value + `</td>`
 			}
 			out += `</tr>`
@@ -170,6 +311,16 @@ func renderHTMLTable(tableHeaders []string, tableValues [][]string, class string
 	return
 }
 
+// highlightStyle returns the cell style for a value that matches a highlight rule, values
+// have been HTML escaped so they are unescaped before comparison
+func (r *ReportGen) highlightStyle(tableName string, valueName string, value string) (style string) {
+	color := r.highlightRules.getColor(html.UnescapeString(tableName), html.UnescapeString(valueName), html.UnescapeString(value))
+	if color != "" {
+		style = "background-color:" + color
+	}
+	return
+}
+
 /* Single Value Table is rendered like this:
  *
  *				Hostname 1	|	Hostname 2	|	.....	|	Hostname N
@@ -213,6 +364,7 @@ func (r *ReportGen) renderSingleValueTable(table *Table, refData []*HostReferenc
 	// so use the value names from the first host
 	for valueIndex, valueName := range table.AllHostValues[r.HostIndices[0]].ValueNames {
 		var rowValues []string
+		rowStyles := []string{"font-weight:bold"}
 		// first column in row is the value name
 		rowValues = append(rowValues, valueName)
 		// include only the hosts in HostIndices
@@ -221,8 +373,10 @@ func (r *ReportGen) renderSingleValueTable(table *Table, refData []*HostReferenc
 			// if have the value
 			if len(hv.Values) > 0 && len(hv.Values[0]) > valueIndex {
 				rowValues = append(rowValues, hv.Values[0][valueIndex])
+				rowStyles = append(rowStyles, r.highlightStyle(table.Name, valueName, hv.Values[0][valueIndex]))
 			} else { // value is missing
 				rowValues = append(rowValues, "")
+				rowStyles = append(rowStyles, "")
 			}
 		}
 		// if reference data is available, add it to the table
@@ -236,7 +390,7 @@ func (r *ReportGen) renderSingleValueTable(table *Table, refData []*HostReferenc
 			}
 		}
 		tableValues = append(tableValues, rowValues)
-		tableValueStyles = append(tableValueStyles, []string{"font-weight:bold"})
+		tableValueStyles = append(tableValueStyles, rowStyles)
 	}
 	// if all host data fields are empty string, then don't render the table
 	haveData := false
@@ -256,7 +410,7 @@ func (r *ReportGen) renderSingleValueTable(table *Table, refData []*HostReferenc
 	if !haveData {
 		tableValues = [][]string{} // this will cause renderHTMLTable to indicate "No data found."
 	}
-	out += renderHTMLTable(tableHeaders, tableValues, "pure-table pure-table-striped", tableValueStyles)
+	out += renderHTMLTableWithAnchors(tableHeaders, tableValues, "pure-table pure-table-striped", tableValueStyles, rowAnchors(r.anchor, tableValues))
 	return
 }
 
@@ -281,18 +435,59 @@ func (r *ReportGen) renderMultiValueTable(table *Table, refData []*HostReference
 	for _, hostIndex := range r.HostIndices {
 		// hostname above table if more than one hostname
 		if len(r.HostIndices) > 1 {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
+		}
+		hv := table.AllHostValues[hostIndex]
+		var valueStyles [][]string
+		for _, rowValues := range hv.Values {
+			var rowStyles []string
+			for valueIndex, value := range rowValues {
+				rowStyles = append(rowStyles, r.highlightStyle(table.Name, hv.ValueNames[valueIndex], value))
+			}
+			valueStyles = append(valueStyles, rowStyles)
 		}
-		out += renderHTMLTable(
-			table.AllHostValues[hostIndex].ValueNames,
-			table.AllHostValues[hostIndex].Values,
+		out += renderHTMLTableWithAnchors(
+			hv.ValueNames,
+			hv.Values,
 			"pure-table pure-table-striped",
-			[][]string{},
+			valueStyles,
+			rowAnchors(htmlAnchor(r.anchor, hv.Name), hv.Values),
 		)
 	}
 	return
 }
 
+// renderBenchmarkRegressionTable links the changed configuration fields to their
+// tables in the Configuration tab
+func (r *ReportGen) renderBenchmarkRegressionTable(table *Table) (out string) {
+	for _, hostIndex := range r.HostIndices {
+		if len(r.HostIndices) > 1 {
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
+		}
+		hv := table.AllHostValues[hostIndex]
+		changedIndex, err := findValueIndex(&hv, "Changed Configuration")
+		if err != nil {
+			continue
+		}
+		var values [][]string
+		for _, rowValues := range hv.Values {
+			row := append([]string{}, rowValues...)
+			var links []string
+			for _, field := range strings.Split(row[changedIndex], ", ") {
+				if field == "" {
+					continue
+				}
+				tableName, _, _ := strings.Cut(field, ": ")
+				links = append(links, fmt.Sprintf(`<a href="#%s">%s</a>`, htmlAnchor("Configuration", tableName), field))
+			}
+			row[changedIndex] = strings.Join(links, ", ")
+			values = append(values, row)
+		}
+		out += renderHTMLTable(hv.ValueNames, values, "pure-table pure-table-striped", [][]string{})
+	}
+	return
+}
+
 const datasetTemplate = `
 {
 	label: '{{.Label}}',
@@ -372,7 +567,7 @@ func (r *ReportGen) renderFrequencyChart(table *Table, refData []*HostReferenceD
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -488,7 +683,7 @@ func (r *ReportGen) renderAverageCPUUtilizationChart(table *Table, refData []*Ho
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -556,7 +751,7 @@ func (r *ReportGen) renderCPUUtilizationChart(table *Table, refData []*HostRefer
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -643,7 +838,7 @@ func (r *ReportGen) renderIRQRateChart(table *Table, refData []*HostReferenceDat
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -725,7 +920,7 @@ func (r *ReportGen) renderDriveStatsChart(table *Table, refData []*HostReference
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -806,7 +1001,7 @@ func (r *ReportGen) renderNetworkStatsChart(table *Table, refData []*HostReferen
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -887,7 +1082,7 @@ func (r *ReportGen) renderMemoryStatsChart(table *Table, refData []*HostReferenc
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -955,7 +1150,7 @@ func (r *ReportGen) renderPowerStatsChart(table *Table, refData []*HostReference
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		// need at least one set of values
@@ -1014,6 +1209,105 @@ func (r *ReportGen) renderPowerStatsChart(table *Table, refData []*HostReference
 	return
 }
 
+const barChartTemplate = `<div class="chart-container" style="max-width: 900px">
+<canvas id="{{.ID}}"></canvas>
+</div>
+<script>
+new Chart(document.getElementById('{{.ID}}'), {
+    type: 'bar',
+    data: {
+        labels: [{{.Labels}}],
+        datasets: [{{.Datasets}}]
+    },
+    options: {
+        aspectRatio: {{.AspectRatio}},
+        scales: {
+            x: {
+                title: {
+                    text: "{{.XaxisText}}",
+                    display: true
+                }
+            },
+            y: {
+                beginAtZero: true,
+                title: {
+                    text: "{{.YaxisText}}",
+                    display: true
+                },
+            }
+        },
+        plugins: {
+            legend: {
+                display: false
+            }
+        }
+    }
+});
+</script>
+`
+
+type barChartTemplateStruct struct {
+	ID          string
+	Labels      string
+	Datasets    string
+	XaxisText   string
+	YaxisText   string
+	AspectRatio string
+}
+
+// renderHistogramChart renders a bar chart of the tables with bucket and count values,
+// e.g., latency histograms collected by bpftrace
+func (r *ReportGen) renderHistogramChart(table *Table, refData []*HostReferenceData) (out string) {
+	// one chart per host
+	for _, hostIndex := range r.HostIndices {
+		// add hostname only if more than one host or a single host with reference data
+		hostnameHeader := len(r.HostIndices) > 1
+		if hostnameHeader {
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
+		}
+		hv := table.AllHostValues[hostIndex]
+		if len(hv.Values) == 0 {
+			out += noDataFound
+			continue
+		}
+		var labels, counts []string
+		for _, bucket := range hv.Values {
+			labels = append(labels, fmt.Sprintf("'%s'", bucket[0]))
+			counts = append(counts, bucket[1])
+		}
+		dst := texttemplate.Must(texttemplate.New("datasetTemplate").Parse(datasetTemplate))
+		buf := new(bytes.Buffer)
+		err := dst.Execute(buf, struct {
+			Label string
+			Data  string
+			Color string
+		}{
+			Label: hv.ValueNames[1],
+			Data:  strings.Join(counts, ","),
+			Color: getColor(0),
+		})
+		if err != nil {
+			return
+		}
+		bct := texttemplate.Must(texttemplate.New("barChartTemplate").Parse(barChartTemplate))
+		chartBuf := new(bytes.Buffer)
+		err = bct.Execute(chartBuf, barChartTemplateStruct{
+			ID:          strings.ReplaceAll(strings.ToLower(table.Name), " ", "") + fmt.Sprintf("%d", hostIndex),
+			Labels:      strings.Join(labels, ","),
+			Datasets:    buf.String(),
+			XaxisText:   hv.ValueNames[0],
+			YaxisText:   hv.ValueNames[1],
+			AspectRatio: "2",
+		})
+		if err != nil {
+			return
+		}
+		out += chartBuf.String()
+		out += "\n"
+	}
+	return
+}
+
 const flameGraphTemplate = `
 <div id="chart{{.ID}}"></div>
 <script type="text/javascript">
@@ -1112,7 +1406,13 @@ func renderFlameGraph(header string, hv *HostValues, field string, hostIndex int
 	if err != nil {
 		log.Panicf("didn't find expected field (%s) in table: %v", field, err)
 	}
-	folded := hv.Values[0][fieldIdx]
+	out += renderFoldedStacks(hv.Values[0][fieldIdx], fmt.Sprintf("%d%s", hostIndex, header))
+	return
+}
+
+// renderFoldedStacks renders folded call stacks as a flame graph, the ID must be unique in
+// the report and valid in a JavaScript identifier
+func renderFoldedStacks(folded string, id string) (out string) {
 	if folded == "" {
 		out += noDataFound
 		return
@@ -1126,7 +1426,7 @@ func renderFlameGraph(header string, hv *HostValues, field string, hostIndex int
 	fg := texttemplate.Must(texttemplate.New("flameGraphTemplate").Parse(flameGraphTemplate))
 	buf := new(bytes.Buffer)
 	err = fg.Execute(buf, flameGraphTemplateStruct{
-		ID:   fmt.Sprintf("%d%s", hostIndex, header),
+		ID:   id,
 		Data: jsonStacks,
 	})
 	if err != nil {
@@ -1144,7 +1444,7 @@ func (r *ReportGen) renderCodePathFrequency(table *Table) (out string) {
 		// add hostname only if more than one host or a single host with reference data
 		hostnameHeader := len(r.HostIndices) > 1
 		if hostnameHeader {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		hv := table.AllHostValues[hostIndex]
 		if len(hv.Values) > 0 {
@@ -1157,6 +1457,117 @@ func (r *ReportGen) renderCodePathFrequency(table *Table) (out string) {
 	return
 }
 
+// renderEventTimeline charts each event as a line, from its start to its end, in seconds
+// from the start of the profiling window, above the table of events
+func (r *ReportGen) renderEventTimeline(table *Table, refData []*HostReferenceData) (out string) {
+	for _, hostIndex := range r.HostIndices {
+		hv := table.AllHostValues[hostIndex]
+		offsetIdx, err := findValueIndex(&hv, "Offset (s)")
+		if err != nil {
+			log.Panicf("didn't find expected field (Offset (s)) in table: %v", err)
+		}
+		durationIdx, err := findValueIndex(&hv, "Duration (s)")
+		if err != nil {
+			log.Panicf("didn't find expected field (Duration (s)) in table: %v", err)
+		}
+		eventIdx, err := findValueIndex(&hv, "Event")
+		if err != nil {
+			log.Panicf("didn't find expected field (Event) in table: %v", err)
+		}
+		// one row of the chart for each type of event
+		eventTypes := make(map[string]int)
+		var datasets []string
+		for _, values := range hv.Values {
+			offset, err := strconv.Atoi(values[offsetIdx])
+			if err != nil {
+				continue
+			}
+			duration, err := strconv.Atoi(values[durationIdx])
+			if err != nil {
+				continue
+			}
+			typeIdx, ok := eventTypes[values[eventIdx]]
+			if !ok {
+				typeIdx = len(eventTypes)
+				eventTypes[values[eventIdx]] = typeIdx
+			}
+			dst := texttemplate.Must(texttemplate.New("datasetTemplate").Parse(datasetTemplate))
+			buf := new(bytes.Buffer)
+			err = dst.Execute(buf, struct {
+				Label string
+				Data  string
+				Color string
+			}{
+				Label: values[eventIdx],
+				Data:  fmt.Sprintf("{x: %d, y: %d},{x: %d, y: %d}", offset, typeIdx+1, offset+duration, typeIdx+1),
+				Color: getColor(typeIdx),
+			})
+			if err != nil {
+				return
+			}
+			datasets = append(datasets, buf.String())
+		}
+		if len(datasets) > 0 {
+			if len(r.HostIndices) > 1 {
+				out += r.hostHeading(hv.Name)
+			}
+			sct := texttemplate.Must(texttemplate.New("scatterChartTemplate").Parse(scatterChartTemplate))
+			buf := new(bytes.Buffer)
+			err := sct.Execute(buf, scatterChartTemplateStruct{
+				ID:            "eventtimeline" + fmt.Sprintf("%d", hostIndex),
+				Datasets:      strings.Join(datasets, ","),
+				XaxisText:     "Seconds from Start",
+				YaxisText:     "Event",
+				TitleText:     "",
+				DisplayTitle:  "false",
+				DisplayLegend: "false",
+				AspectRatio:   "4",
+				YaxisZero:     "true",
+			})
+			if err != nil {
+				return
+			}
+			out += buf.String()
+			out += "\n"
+		}
+	}
+	out += r.renderMultiValueTable(table, refData)
+	return
+}
+
+// renderEventCodePaths renders a flame graph of the call stacks sampled during each event
+// in the Event Timeline
+func (r *ReportGen) renderEventCodePaths(table *Table) (out string) {
+	for _, hostIndex := range r.HostIndices {
+		hostnameHeader := len(r.HostIndices) > 1
+		if hostnameHeader {
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
+		}
+		hv := table.AllHostValues[hostIndex]
+		if len(hv.Values) == 0 {
+			out += noDataFound
+			continue
+		}
+		startIdx, err := findValueIndex(&hv, "Start")
+		if err != nil {
+			log.Panicf("didn't find expected field (Start) in table: %v", err)
+		}
+		eventIdx, err := findValueIndex(&hv, "Event")
+		if err != nil {
+			log.Panicf("didn't find expected field (Event) in table: %v", err)
+		}
+		stacksIdx, err := findValueIndex(&hv, "Stacks")
+		if err != nil {
+			log.Panicf("didn't find expected field (Stacks) in table: %v", err)
+		}
+		for eventIndex, values := range hv.Values {
+			out += fmt.Sprintf("<h3>%s %s</h3>\n", values[startIdx], values[eventIdx])
+			out += renderFoldedStacks(values[stacksIdx], fmt.Sprintf("%devent%d", hostIndex, eventIndex))
+		}
+	}
+	return
+}
+
 func getColor(idx int) string {
 	// color-blind safe palette from here: http://mkweb.bcgsc.ca/colorblind/palettes.mhtml#page-container
 	colors := []string{"#9F0162", "#009F81", "#FF5AAF", "#00FCCF", "#8400CD", "#008DF9", "#00C2F9", "#FFB2FD", "#A40122", "#E20134", "#FF6E3A", "#FFC33B"}
@@ -1292,7 +1703,7 @@ func (r *ReportGen) renderNumaBandwidthTable(table *Table, refData []*HostRefere
 			tableValueStyles = append(tableValueStyles, []string{"font-weight:bold"})
 		}
 		if hostnameHeader && (len(tableValues) > 0 || len(r.HostIndices) > 1) {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		out += renderHTMLTable(tableHeaders, tableValues, "pure-table pure-table-striped", tableValueStyles)
 	}
@@ -1355,7 +1766,7 @@ func (r *ReportGen) renderDIMMPopulationTable(table *Table, refData []*HostRefer
 		var slotColorIndices = make(map[string]int)
 		// header if more than one host
 		if len(r.HostIndices) > 1 {
-			out += `<h3>` + table.AllHostValues[hostIndex].Name + `</h3>`
+			out += r.hostHeading(table.AllHostValues[hostIndex].Name)
 		}
 		// socket -> channel -> slot -> dimm details
 		var dimms = map[string]map[string]map[string]string{}
@@ -1474,10 +1885,11 @@ func HTMLEscapeTable(table *Table) (safeTable Table) {
 	return
 }
 
-func (r *ReportGen) RenderDataTable(unsafeTable *Table, refData []*HostReferenceData) template.HTML {
+func (r *ReportGen) RenderDataTable(reportName string, unsafeTable *Table, refData []*HostReferenceData) template.HTML {
 	t := HTMLEscapeTable(unsafeTable)
 	table := &t
-	out := fmt.Sprintf("<h2 id=%s>%s</h2>\n", "\""+table.Name+"\"", table.Name)
+	r.anchor = htmlAnchor(reportName, table.Name)
+	out := fmt.Sprintf("<h2 id=\"%s\">%s%s</h2>\n", r.anchor, table.Name, permalink(r.anchor))
 	if table.Name == "Core Frequency" {
 		out += r.renderFrequencyChart(table, refData)
 	} else if table.Name == "Memory Bandwidth and Latency" {
@@ -1500,8 +1912,16 @@ func (r *ReportGen) RenderDataTable(unsafeTable *Table, refData []*HostReference
 		out += r.renderMemoryStatsChart(table, refData)
 	} else if table.Name == "Code Path Frequency" {
 		out += r.renderCodePathFrequency(table)
+	} else if table.Name == "Event Timeline" {
+		out += r.renderEventTimeline(table, refData)
+	} else if table.Name == "Event Code Paths" {
+		out += r.renderEventCodePaths(table)
 	} else if table.Name == "Power Stats" {
 		out += r.renderPowerStatsChart(table, refData)
+	} else if table.Name == "Run Queue Latency" || table.Name == "Block IO Latency" || table.Name == "P-state Distribution" {
+		out += r.renderHistogramChart(table, refData)
+	} else if table.Name == "Benchmark Regression" {
+		out += r.renderBenchmarkRegressionTable(table)
 	} else if isSingleValueTable(table) {
 		out += r.renderSingleValueTable(table, refData)
 	} else {
@@ -1535,7 +1955,7 @@ func (r *ReportGeneratorHTML) generate() (reportFilePaths []string, err error) {
 		if err != nil {
 			return
 		}
-		err = t.Execute(f, newReportGen(r.reports, []int{hostIndex}, hostsReferenceData))
+		err = t.Execute(f, newReportGen(r.reports, []int{hostIndex}, hostsReferenceData, r.highlightRules))
 		f.Close()
 		if err != nil {
 			return
@@ -1574,7 +1994,7 @@ func (r *ReportGeneratorHTML) generate() (reportFilePaths []string, err error) {
 		for i := 0; i < len(hostnames); i++ {
 			hostIndices = append(hostIndices, i)
 		}
-		err = t.Execute(f, newReportGen(r.reports, hostIndices, hostsReferenceData))
+		err = t.Execute(f, newReportGen(r.reports, hostIndices, hostsReferenceData, r.highlightRules))
 		f.Close()
 		if err != nil {
 			return
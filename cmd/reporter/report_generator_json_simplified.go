@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 type ReportGeneratorJSONSimplified struct {
@@ -39,7 +40,15 @@ func convertToSimple(hostNames []string, reportsData []*Report) (simpleHosts Sim
 				for _, values := range hostValues.Values {
 					simpleRow := make(SimpleRow)
 					for valueIndex, value := range values {
-						simpleRow[hostValues.ValueNames[valueIndex]] = value
+						valueName := hostValues.ValueNames[valueIndex]
+						simpleRow[valueName] = value
+						// add the value in canonical units, raw value is preserved above
+						if quantity, unit, ok := normalizeQuantity(valueName, value); ok {
+							normalizedName := normalizedValueName(valueName, unit)
+							if normalizedName != valueName {
+								simpleRow[normalizedName] = strconv.FormatFloat(quantity, 'f', -1, 64)
+							}
+						}
 					}
 					simpleTable[table.Name] = append(simpleTable[table.Name], simpleRow)
 				}
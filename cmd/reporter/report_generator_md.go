@@ -0,0 +1,221 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The Markdown report renders the report tables as GitHub flavored Markdown tables, so
+// that they can be pasted into issues, wikis, and pull request descriptions. A report
+// is created for each host and, if there's more than one host, a combined report, where
+// a table with one record per host has a column per host.
+
+type ReportGeneratorMD struct {
+	sources   []*Source
+	reports   []*Report
+	outputDir string
+}
+
+func newReportGeneratorMD(sources []*Source, outputDir string, reports ...*Report) (rpt *ReportGeneratorMD) {
+	rpt = &ReportGeneratorMD{
+		sources:   sources,
+		reports:   reports,
+		outputDir: outputDir,
+	}
+	return
+}
+
+func (r *ReportGeneratorMD) generate() (reportFilePaths []string, err error) {
+	for hostIndex, source := range r.sources {
+		reportFilePath := filepath.Join(r.outputDir, source.getHostname()+".md")
+		if err = os.WriteFile(reportFilePath, []byte(r.renderHost(hostIndex, source)), 0644); err != nil {
+			err = fmt.Errorf("failed to write Markdown report: %v", err)
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	// combined, all-host Markdown report, if more than one host
+	if len(r.sources) > 1 {
+		reportFilePath := filepath.Join(r.outputDir, "all_hosts.md")
+		if err = os.WriteFile(reportFilePath, []byte(r.renderAllHosts()), 0644); err != nil {
+			err = fmt.Errorf("failed to write Markdown report: %v", err)
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
+
+// renderHost renders the host's report
+func (r *ReportGeneratorMD) renderHost(hostIndex int, source *Source) string {
+	var sb strings.Builder
+	sb.WriteString("# " + mdEscape(source.getHostname()) + "\n")
+	for _, note := range r.getNotes(source) {
+		sb.WriteString("\n> " + mdEscape(note) + "\n")
+	}
+	for _, report := range r.reports {
+		if report == nil {
+			continue
+		}
+		var tables []*Table
+		for _, table := range report.Tables {
+			if hasTextValues(table.AllHostValues[hostIndex]) {
+				tables = append(tables, table)
+			}
+		}
+		if len(tables) == 0 {
+			continue
+		}
+		sb.WriteString("\n## " + mdEscape(report.InternalName) + "\n")
+		for _, table := range tables {
+			sb.WriteString("\n### " + mdEscape(table.Name) + "\n\n")
+			sb.WriteString(renderMarkdownTable(table.AllHostValues[hostIndex]))
+		}
+	}
+	return sb.String()
+}
+
+// renderAllHosts renders the combined report
+func (r *ReportGeneratorMD) renderAllHosts() string {
+	var sb strings.Builder
+	sb.WriteString("# All Hosts\n")
+	for _, report := range r.reports {
+		if report == nil {
+			continue
+		}
+		reportHeading := false
+		for _, table := range report.Tables {
+			var hostIndices []int
+			singleRecord := true
+			for hostIndex := range r.sources {
+				hv := table.AllHostValues[hostIndex]
+				if !hasTextValues(hv) {
+					continue
+				}
+				hostIndices = append(hostIndices, hostIndex)
+				singleRecord = singleRecord && len(getCompleteRecords(hv)) == 1
+			}
+			if len(hostIndices) == 0 {
+				continue
+			}
+			if !reportHeading {
+				sb.WriteString("\n## " + mdEscape(report.InternalName) + "\n")
+				reportHeading = true
+			}
+			sb.WriteString("\n### " + mdEscape(table.Name) + "\n\n")
+			if singleRecord {
+				sb.WriteString(renderMarkdownHostColumns(table, hostIndices, r.sources))
+				continue
+			}
+			for _, hostIndex := range hostIndices {
+				sb.WriteString("#### " + mdEscape(r.sources[hostIndex].getHostname()) + "\n\n")
+				sb.WriteString(renderMarkdownTable(table.AllHostValues[hostIndex]) + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// getNotes returns the notes about how the host's data was collected, as in the txt report
+func (r *ReportGeneratorMD) getNotes(source *Source) (notes []string) {
+	if source.isReadOnly() {
+		notes = append(notes, readOnlyGuarantee)
+	}
+	if container := source.getContainer(); container != "" {
+		notes = append(notes, fmt.Sprintf("%s Container: %s.", containerNote, container))
+	}
+	if len(source.getNotCollected()) > 0 {
+		notes = append(notes, notCollectedNote)
+	}
+	return
+}
+
+// getCompleteRecords returns the records that have a value for each value name, records
+// may be empty, see check()
+func getCompleteRecords(hv HostValues) (records [][]string) {
+	for _, values := range hv.Values {
+		if len(values) == len(hv.ValueNames) {
+			records = append(records, values)
+		}
+	}
+	return
+}
+
+// renderMarkdownTable renders a host's table values. A table with one record is rendered
+// as a list of names and values, a table with more than one record is rendered in columns.
+func renderMarkdownTable(hv HostValues) string {
+	records := getCompleteRecords(hv)
+	if len(records) == 1 {
+		rows := [][]string{{"Field", "Value"}}
+		for i, valueName := range hv.ValueNames {
+			rows = append(rows, []string{valueName, records[0][i]})
+		}
+		return mdTable(rows)
+	}
+	return mdTable(append([][]string{hv.ValueNames}, records...))
+}
+
+// renderMarkdownHostColumns renders a table with one record per host as a list of fields
+// with a column of values per host
+func renderMarkdownHostColumns(table *Table, hostIndices []int, sources []*Source) string {
+	header := []string{"Field"}
+	for _, hostIndex := range hostIndices {
+		header = append(header, sources[hostIndex].getHostname())
+	}
+	rows := [][]string{header}
+	valueNames := table.AllHostValues[hostIndices[0]].ValueNames
+	for i, valueName := range valueNames {
+		row := []string{valueName}
+		for _, hostIndex := range hostIndices {
+			hv := table.AllHostValues[hostIndex]
+			value := ""
+			if i < len(hv.ValueNames) && hv.ValueNames[i] == valueName {
+				value = getCompleteRecords(hv)[0][i]
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return mdTable(rows)
+}
+
+// mdTable renders the rows as a Markdown table, the first row is the header
+func mdTable(rows [][]string) string {
+	var sb strings.Builder
+	for i, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, mdEscape(cell))
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString(strings.Repeat("| --- ", len(row)) + "|\n")
+		}
+	}
+	return sb.String()
+}
+
+// mdEscaper escapes the characters that would break a Markdown table or be taken as
+// formatting, and keeps multi-line values in their cell
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", "<br>",
+	"\n", "<br>",
+)
+
+// mdEscape escapes the text for a Markdown heading or table cell
+func mdEscape(text string) string {
+	return mdEscaper.Replace(strings.TrimSpace(text))
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReportGeneratorRemediation writes a shell script per host containing the commands
+// that implement the insights' recommendations. The scripts are for operators to
+// review and run, they are never run by svr-info.
+type ReportGeneratorRemediation struct {
+	outputDir     string
+	reportInsight *Report
+}
+
+func newReportGeneratorRemediation(outputDir string, reportInsight *Report) (rpt *ReportGeneratorRemediation) {
+	rpt = &ReportGeneratorRemediation{
+		outputDir:     outputDir,
+		reportInsight: reportInsight,
+	}
+	return
+}
+
+func (r *ReportGeneratorRemediation) generate() (reportFilePaths []string, err error) {
+	var tableRemediation *Table
+	for _, table := range r.reportInsight.Tables {
+		if table.Name == "Remediation" {
+			tableRemediation = table
+			break
+		}
+	}
+	if tableRemediation == nil {
+		return
+	}
+	for _, hv := range tableRemediation.AllHostValues {
+		if len(hv.Values) == 0 {
+			log.Printf("No remediation steps for %s", hv.Name)
+			continue
+		}
+		reportFilePath := filepath.Join(r.outputDir, hv.Name+"_remediation.sh")
+		err = os.WriteFile(reportFilePath, []byte(r.getScript(hv)), 0644)
+		if err != nil {
+			err = fmt.Errorf("failed to write remediation script: %v", err)
+			return
+		}
+		reportFilePaths = append(reportFilePaths, reportFilePath)
+	}
+	return
+}
+
+// scriptComment returns the text with its line breaks replaced, so that target-reported
+// values in it can't end the comment and add commands to the script
+func scriptComment(text string) string {
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+}
+
+func (r *ReportGeneratorRemediation) getScript(hv HostValues) string {
+	var sb strings.Builder
+	sb.WriteString("#!/usr/bin/env bash\n")
+	sb.WriteString(fmt.Sprintf("# Remediation steps for %s\n", scriptComment(hv.Name)))
+	sb.WriteString(fmt.Sprintf("# Generated by svr-info %s on %s\n", gVersion, time.Now().Format(time.RFC1123)))
+	sb.WriteString("#\n")
+	sb.WriteString("# REVIEW BEFORE RUNNING. Each step implements a recommendation from the Insights\n")
+	sb.WriteString("# report. Remove the steps that don't apply to your workload. Unless noted, changes\n")
+	sb.WriteString("# do not persist across reboots.\n")
+	sb.WriteString("\n")
+	sb.WriteString("if [ \"$(id -u)\" -ne 0 ]; then\n")
+	sb.WriteString("    echo \"This script must be run as root.\" >&2\n")
+	sb.WriteString("    exit 1\n")
+	sb.WriteString("fi\n")
+	for i, step := range hv.Values {
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("# Step %d: %s\n", i+1, scriptComment(step[0])))
+		sb.WriteString(fmt.Sprintf("# Finding: %s\n", scriptComment(step[1])))
+		sb.WriteString(strings.TrimSpace(step[2]) + "\n")
+	}
+	return sb.String()
+}
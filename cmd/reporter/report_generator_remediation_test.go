@@ -0,0 +1,28 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemediationScriptComments(t *testing.T) {
+	hv := HostValues{
+		Name: "host1",
+		Values: [][]string{
+			{"Set the policy.", "Power & Perf Policy is 'x\nreboot\r\nhalt'.", "echo step"},
+		},
+	}
+	script := (&ReportGeneratorRemediation{}).getScript(hv)
+	for _, line := range strings.Split(script, "\n") {
+		if line == "reboot" || line == "halt" || strings.HasPrefix(line, "halt") {
+			t.Errorf("finding added a command to the script: %q", line)
+		}
+	}
+	if !strings.Contains(script, "# Finding: Power & Perf Policy is 'x reboot halt'.\necho step\n") {
+		t.Errorf("unexpected script: %s", script)
+	}
+}
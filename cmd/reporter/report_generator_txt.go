@@ -6,51 +6,293 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
 )
 
+// text report widths, in characters
+const (
+	txtWidthNarrow  = 80
+	txtWidthDefault = 120
+	txtWidthWide    = 200
+	txtMinColumn    = 8
+)
+
+// txtSections maps the text report's section names to the reports they contain, in
+// the order they appear in the text report. The commands section is the output of the
+// commands that were executed on the target.
+var txtSections = []struct {
+	name   string
+	report string
+}{
+	{"brief", "Brief"},
+	{"configuration", "Configuration"},
+	{"performance", "Performance"},
+	{"profile", "Profile"},
+	{"insights", "Recommendations"},
+	{"commands", ""},
+}
+
+func getTxtSectionNames() (names []string) {
+	for _, section := range txtSections {
+		names = append(names, section.name)
+	}
+	return
+}
+
 type ReportGeneratorTXT struct {
 	sources   []*Source
+	reports   []*Report
 	outputDir string
+	width     int
+	sections  []string
 }
 
-func newReportGeneratorTXT(sources []*Source, outputDir string) (rpt *ReportGeneratorTXT) {
+func newReportGeneratorTXT(sources []*Source, outputDir string, width int, sections []string, reports ...*Report) (rpt *ReportGeneratorTXT) {
 	rpt = &ReportGeneratorTXT{
 		sources:   sources,
+		reports:   reports,
 		outputDir: outputDir,
+		width:     width,
+		sections:  sections,
 	}
 	return
 }
 
+// txtReportSection is a report rendered in the text report
+type txtReportSection struct {
+	Name   string
+	Tables []*Table
+}
+
+// txtReportData is the data passed to the text report template for one host
+type txtReportData struct {
+	Hostname     string
+	ReadOnly     string // the read-only guarantee, if collected in read-only mode
+	Container    string // the container note, if collected in a container
+	NotCollected string // the partial collection note, if the collection was interrupted
+	Reports      []txtReportSection
+	Commands     []CommandData
+}
+
 func (r *ReportGeneratorTXT) generate() (reportFilePaths []string, err error) {
-	for _, source := range r.sources {
-		fileName := source.getHostname() + ".txt"
-		reportFilePath := filepath.Join(r.outputDir, fileName)
+	for hostIndex, source := range r.sources {
+		t, err := template.New("report.txt.tmpl").Funcs(template.FuncMap{
+			"heading": txtHeading,
+			"table": func(table *Table) string {
+				return renderTextTable(table.Name, table.AllHostValues[hostIndex], r.width)
+			},
+		}).ParseFS(resources, "resources/report.txt.tmpl")
+		if err != nil {
+			return nil, err
+		}
+		reportFilePath := filepath.Join(r.outputDir, source.getHostname()+".txt")
 		f, err := os.OpenFile(reportFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 		if err != nil {
-			log.Printf("Failed to create/open file for writing: %s", reportFilePath)
-			continue
+			return nil, fmt.Errorf("failed to create/open file for writing: %s", reportFilePath)
 		}
-		defer f.Close()
-		f.WriteString(fmt.Sprintf("Host: %s\n", source.getHostname()))
-		var keys []string
-		for key := range source.ParsedData {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			cmd := source.ParsedData[key]
-			f.WriteString("\n----------------------------------\n")
-			f.WriteString(fmt.Sprintf("label:     %s\n", key))
-			f.WriteString(fmt.Sprintf("command:   %s\n", cmd.Command))
-			f.WriteString(fmt.Sprintf("exit code: %s\n", cmd.ExitStatus))
-			f.WriteString(fmt.Sprintf("stderr:    %s\n", cmd.Stderr))
-			f.WriteString(fmt.Sprintf("stdout:    %s\n", cmd.Stdout))
+		err = t.Execute(f, r.getData(hostIndex, source))
+		f.Close()
+		if err != nil {
+			return nil, err
 		}
 		reportFilePaths = append(reportFilePaths, reportFilePath)
 	}
 	return
 }
+
+func (r *ReportGeneratorTXT) getData(hostIndex int, source *Source) (data txtReportData) {
+	data.Hostname = source.getHostname()
+	if source.isReadOnly() {
+		data.ReadOnly = readOnlyGuarantee
+	}
+	if container := source.getContainer(); container != "" {
+		data.Container = fmt.Sprintf("%s Container: %s.", containerNote, container)
+	}
+	if len(source.getNotCollected()) > 0 {
+		data.NotCollected = notCollectedNote
+	}
+	for _, section := range txtSections {
+		if !r.includesSection(section.name) {
+			continue
+		}
+		if section.name == "commands" {
+			var labels []string
+			for label := range source.ParsedData {
+				labels = append(labels, label)
+			}
+			sort.Strings(labels)
+			for _, label := range labels {
+				data.Commands = append(data.Commands, source.ParsedData[label])
+			}
+			continue
+		}
+		for _, report := range r.reports {
+			if report == nil || report.InternalName != section.report {
+				continue
+			}
+			reportSection := txtReportSection{Name: report.InternalName}
+			for _, table := range report.Tables {
+				if hasTextValues(table.AllHostValues[hostIndex]) {
+					reportSection.Tables = append(reportSection.Tables, table)
+				}
+			}
+			if len(reportSection.Tables) > 0 {
+				data.Reports = append(data.Reports, reportSection)
+			}
+		}
+	}
+	return
+}
+
+// hasTextValues returns true if any of the host's values in the table are non-empty
+func hasTextValues(hv HostValues) bool {
+	for _, values := range hv.Values {
+		for _, value := range values {
+			if value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *ReportGeneratorTXT) includesSection(name string) bool {
+	for _, section := range r.sections {
+		if section == name || section == "all" {
+			return true
+		}
+	}
+	return false
+}
+
+// txtHeading underlines the title with the character
+func txtHeading(title string, underline string) string {
+	return title + "\n" + strings.Repeat(underline, utf8.RuneCountInString(title))
+}
+
+// renderTextTable renders a host's table values to fit in width characters. A table
+// with one row is rendered as a list of names and values, a table with more than one
+// row is rendered in columns. Values that don't fit are wrapped within their column.
+func renderTextTable(name string, hv HostValues, width int) string {
+	var sb strings.Builder
+	sb.WriteString(txtHeading(name, "-") + "\n")
+	// records may be empty, see check()
+	var records [][]string
+	for _, values := range hv.Values {
+		if len(values) == len(hv.ValueNames) {
+			records = append(records, values)
+		}
+	}
+	hv.Values = records
+	if len(hv.Values) == 1 {
+		nameWidth := 0
+		for _, valueName := range hv.ValueNames {
+			nameWidth = max(nameWidth, utf8.RuneCountInString(valueName))
+		}
+		nameWidth = min(nameWidth, width/3)
+		for i, valueName := range hv.ValueNames {
+			nameLines := wrapText(valueName, nameWidth)
+			valueLines := wrapText(hv.Values[0][i], width-nameWidth-2)
+			for line := 0; line < max(len(nameLines), len(valueLines)); line++ {
+				sb.WriteString(strings.TrimRight(padText(getLine(nameLines, line), nameWidth)+"  "+getLine(valueLines, line), " ") + "\n")
+			}
+		}
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+	columnWidths := getColumnWidths(hv, width)
+	writeRow := func(values []string) {
+		var cells [][]string
+		lines := 1
+		for i, value := range values {
+			cells = append(cells, wrapText(value, columnWidths[i]))
+			lines = max(lines, len(cells[i]))
+		}
+		for line := 0; line < lines; line++ {
+			var row []string
+			for i := range cells {
+				row = append(row, padText(getLine(cells[i], line), columnWidths[i]))
+			}
+			sb.WriteString(strings.TrimRight(strings.Join(row, "  "), " ") + "\n")
+		}
+	}
+	writeRow(hv.ValueNames)
+	var rules []string
+	for _, columnWidth := range columnWidths {
+		rules = append(rules, strings.Repeat("-", columnWidth))
+	}
+	sb.WriteString(strings.Join(rules, "  ") + "\n")
+	for _, values := range hv.Values {
+		writeRow(values)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// getColumnWidths returns the widths of the columns needed to show the values without
+// wrapping, narrowing the widest columns until the table fits in width characters
+func getColumnWidths(hv HostValues, width int) (columnWidths []int) {
+	for i, valueName := range hv.ValueNames {
+		columnWidth := utf8.RuneCountInString(valueName)
+		for _, values := range hv.Values {
+			if i < len(values) {
+				columnWidth = max(columnWidth, utf8.RuneCountInString(values[i]))
+			}
+		}
+		columnWidths = append(columnWidths, max(columnWidth, 1))
+	}
+	available := width - 2*(len(columnWidths)-1)
+	for {
+		total, widest := 0, 0
+		for i, columnWidth := range columnWidths {
+			total += columnWidth
+			if columnWidth > columnWidths[widest] {
+				widest = i
+			}
+		}
+		if total <= available || columnWidths[widest] <= txtMinColumn {
+			return
+		}
+		columnWidths[widest] = max(txtMinColumn, columnWidths[widest]-(total-available))
+	}
+}
+
+// wrapText wraps the text at spaces to lines of at most width characters, breaking
+// words that are longer than width
+func wrapText(text string, width int) (lines []string) {
+	width = max(width, 1)
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			for len(runes) > width {
+				lines = append(lines, string(runes[:width]))
+				runes = runes[width:]
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return
+}
+
+func getLine(lines []string, index int) string {
+	if index < len(lines) {
+		return lines[index]
+	}
+	return ""
+}
+
+func padText(text string, width int) string {
+	return text + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(text)))
+}
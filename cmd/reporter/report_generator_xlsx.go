@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,16 +16,18 @@ import (
 )
 
 type ReportGeneratorXLSX struct {
-	reports    []*Report
-	sheetNames []string
-	outputDir  string
+	reports        []*Report
+	sheetNames     []string
+	outputDir      string
+	highlightRules *HighlightRules
 }
 
-func newReportGeneratorXLSX(outputDir string, configurationReport *Report, briefReport *Report, insightReport *Report, profileReport *Report, benchmarkReport *Report, analyzeReport *Report) (rpt *ReportGeneratorXLSX) {
+func newReportGeneratorXLSX(outputDir string, highlightRules *HighlightRules, configurationReport *Report, briefReport *Report, insightReport *Report, profileReport *Report, benchmarkReport *Report, analyzeReport *Report) (rpt *ReportGeneratorXLSX) {
 	rpt = &ReportGeneratorXLSX{
-		reports:    []*Report{configurationReport, briefReport, benchmarkReport, profileReport, analyzeReport, insightReport}, // this is the order the tabs will appear in the spreadsheet
-		sheetNames: []string{"Configuration", "Brief", "Benchmark", "Profile", "Analyze", "Insights"},
-		outputDir:  outputDir,
+		reports:        []*Report{configurationReport, briefReport, benchmarkReport, profileReport, analyzeReport, insightReport}, // this is the order the tabs will appear in the spreadsheet
+		sheetNames:     []string{"Configuration", "Brief", "Benchmark", "Profile", "Analyze", "Insights"},
+		outputDir:      outputDir,
+		highlightRules: highlightRules,
 	}
 	return
 }
@@ -41,7 +44,96 @@ func cellName(col int, row int) (name string) {
 	return
 }
 
-func renderExcelTable(tableHeaders []string, tableValues [][]string, f *excelize.File, reportSheetName string, originRow int, originCol int, boldFirstCol bool) int {
+// reExcelNumber matches the values written to the sheet as numbers
+var reExcelNumber = regexp.MustCompile(`^-?\d+(?:\.(\d+))?$`)
+
+// parseExcelNumber returns the value as a number and the number format that displays
+// it with the value's decimal places, e.g., "0.00" for "3.20". Excel displays numbers
+// with the reader's locale decimal separator, so the format uses the "." placeholder.
+func parseExcelNumber(value string) (number float64, numFmt string, ok bool) {
+	match := reExcelNumber.FindStringSubmatch(value)
+	if match == nil {
+		return
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	numFmt = "0"
+	if len(match[1]) > 0 {
+		numFmt += "." + strings.Repeat("0", len(match[1]))
+	}
+	ok = true
+	return
+}
+
+// moveUnitsToHeaders returns copies of the headers and values with the unit moved from
+// the values to the header, e.g., "Speed" and "2.1 GHz" become "Speed (GHz)" and "2.1",
+// for each column whose values share a unit, so that the values are written as numbers
+func moveUnitsToHeaders(tableHeaders []string, tableValues [][]string) (headers []string, values [][]string) {
+	headers = append([]string{}, tableHeaders...)
+	values = make([][]string, len(tableValues))
+	for i := range tableValues {
+		values[i] = append([]string{}, tableValues[i]...)
+	}
+	for col := range headers {
+		var column []string
+		for _, rowValues := range values {
+			if col < len(rowValues) {
+				column = append(column, rowValues[col])
+			}
+		}
+		if numbers, unit := splitColumnUnit(column); unit != "" {
+			headers[col] = fmt.Sprintf("%s (%s)", headers[col], unit)
+			idx := 0
+			for _, rowValues := range values {
+				if col < len(rowValues) {
+					rowValues[col] = numbers[idx]
+					idx++
+				}
+			}
+		}
+	}
+	return
+}
+
+// moveUnitsToNames returns a copy of the values with the unit moved from the values to
+// the value name in the first column, e.g., "Speed" and "2.1 GHz" become "Speed (GHz)"
+// and "2.1", for each row whose values share a unit
+func moveUnitsToNames(tableValues [][]string) (values [][]string) {
+	values = make([][]string, len(tableValues))
+	for i, rowValues := range tableValues {
+		values[i] = append([]string{}, rowValues...)
+		if len(rowValues) < 2 {
+			continue
+		}
+		if numbers, unit := splitColumnUnit(rowValues[1:]); unit != "" {
+			values[i][0] = fmt.Sprintf("%s (%s)", rowValues[0], unit)
+			copy(values[i][1:], numbers)
+		}
+	}
+	return
+}
+
+// splitColumnUnit returns the numbers and their unit if every non-empty value is a
+// number with the same unit, otherwise the unit is empty
+func splitColumnUnit(column []string) (numbers []string, unit string) {
+	for _, value := range column {
+		if value == "" {
+			numbers = append(numbers, value)
+			continue
+		}
+		number, valueUnit, ok := splitQuantity(value)
+		if !ok || (unit != "" && valueUnit != unit) {
+			return nil, ""
+		}
+		unit = valueUnit
+		numbers = append(numbers, number)
+	}
+	return
+}
+
+func renderExcelTable(tableHeaders []string, tableValues [][]string, f *excelize.File, reportSheetName string, originRow int, originCol int, boldFirstCol bool, valueColors [][]string) int {
 	row := originRow
 	col := originCol
 	bold, _ := f.NewStyle(&excelize.Style{
@@ -49,27 +141,34 @@ func renderExcelTable(tableHeaders []string, tableValues [][]string, f *excelize
 			Bold: true,
 		},
 	})
-	alignLeft, _ := f.NewStyle(&excelize.Style{
+	alignLeft := &excelize.Style{
 		Alignment: &excelize.Alignment{
 			Horizontal: "left",
 		},
-	})
-	boldAlignLeft, _ := f.NewStyle(&excelize.Style{
+	}
+	boldAlignLeft := &excelize.Style{
 		Font: &excelize.Font{
 			Bold: true,
 		},
 		Alignment: &excelize.Alignment{
 			Horizontal: "left",
 		},
-	})
+	}
+	// numberStyle returns the style with the number format
+	numberStyle := func(style *excelize.Style, numFmt string) int {
+		s := *style
+		s.CustomNumFmt = &numFmt
+		id, _ := f.NewStyle(&s)
+		return id
+	}
 	if len(tableValues) > 0 {
 		if len(tableHeaders) > 0 {
 			for _, header := range tableHeaders {
 				// if possible, convert strings to floats before inserting into the sheet
-				floatValue, err := strconv.ParseFloat(header, 64)
-				if err == nil {
-					f.SetCellFloat(reportSheetName, cellName(col, row), floatValue, 1, 64)
-					f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), boldAlignLeft)
+				floatValue, numFmt, ok := parseExcelNumber(header)
+				if ok {
+					f.SetCellFloat(reportSheetName, cellName(col, row), floatValue, -1, 64)
+					f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), numberStyle(boldAlignLeft, numFmt))
 				} else {
 
 					f.SetCellStr(reportSheetName, cellName(col, row), header)
@@ -79,21 +178,38 @@ func renderExcelTable(tableHeaders []string, tableValues [][]string, f *excelize
 			}
 			row += 1
 		}
-		for _, rowValues := range tableValues {
+		for valuesIdx, rowValues := range tableValues {
 			col = originCol
 			if len(rowValues) > 0 {
 				for rowIdx, value := range rowValues {
 					// if possible, convert strings to floats before inserting into the sheet
-					floatValue, err := strconv.ParseFloat(value, 64)
-					if err == nil {
-						f.SetCellFloat(reportSheetName, cellName(col, row), floatValue, 1, 64)
-						f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), alignLeft)
+					floatValue, numFmt, isNumber := parseExcelNumber(value)
+					if isNumber {
+						f.SetCellFloat(reportSheetName, cellName(col, row), floatValue, -1, 64)
+						f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), numberStyle(alignLeft, numFmt))
 					} else {
 						if rowIdx == 0 && boldFirstCol {
 							f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), bold)
 						}
 						f.SetCellStr(reportSheetName, cellName(col, row), value)
 					}
+					if len(valueColors) > valuesIdx && len(valueColors[valuesIdx]) > rowIdx && valueColors[valuesIdx][rowIdx] != "" {
+						style := &excelize.Style{
+							Fill: excelize.Fill{
+								Type:    "pattern",
+								Pattern: 1,
+								Color:   []string{valueColors[valuesIdx][rowIdx]},
+							},
+							Alignment: &excelize.Alignment{
+								Horizontal: "left",
+							},
+						}
+						if isNumber {
+							style.CustomNumFmt = &numFmt
+						}
+						highlight, _ := f.NewStyle(style)
+						f.SetCellStyle(reportSheetName, cellName(col, row), cellName(col, row), highlight)
+					}
 					col += 1
 				}
 			} else {
@@ -126,17 +242,22 @@ func (r *ReportGeneratorXLSX) renderSingleValueTable(table *Table, allHostValues
 			break
 		}
 	}
+	var valueColors [][]string
 	for valueIndex, valueName := range valueNames {
 		var rowValues []string
+		rowColors := []string{""}
 		rowValues = append(rowValues, valueName)
 		for _, hv := range allHostValues {
 			if len(hv.Values) > 0 && len(hv.Values[0]) > valueIndex {
 				rowValues = append(rowValues, hv.Values[0][valueIndex])
+				rowColors = append(rowColors, r.highlightRules.getColor(table.Name, valueName, hv.Values[0][valueIndex]))
 			} else {
 				rowValues = append(rowValues, "")
+				rowColors = append(rowColors, "")
 			}
 		}
 		tableValues = append(tableValues, rowValues)
+		valueColors = append(valueColors, rowColors)
 	}
 	// if all data fields are empty string, then don't render the table
 	haveData := false
@@ -154,7 +275,9 @@ func (r *ReportGeneratorXLSX) renderSingleValueTable(table *Table, allHostValues
 	if !haveData {
 		tableValues = [][]string{} // this will cause renderExcelTable to indicate "No data found."
 	}
-	return renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, col, true)
+	// write quantities as numbers, with their unit in the value name
+	tableValues = moveUnitsToNames(tableValues)
+	return renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, col, true, valueColors)
 }
 
 func (r *ReportGeneratorXLSX) renderMultiValueTable(table *Table, allHostValues []HostValues, f *excelize.File, reportSheetName string, row int, col int) int {
@@ -171,7 +294,17 @@ func (r *ReportGeneratorXLSX) renderMultiValueTable(table *Table, allHostValues
 			f.SetCellStyle(reportSheetName, cellName(2, row), cellName(2, row), headerStyle)
 			row += 1
 		}
-		row = renderExcelTable(hv.ValueNames, hv.Values, f, reportSheetName, row, col, false)
+		var valueColors [][]string
+		for _, rowValues := range hv.Values {
+			var rowColors []string
+			for valueIndex, value := range rowValues {
+				rowColors = append(rowColors, r.highlightRules.getColor(table.Name, hv.ValueNames[valueIndex], value))
+			}
+			valueColors = append(valueColors, rowColors)
+		}
+		// write quantities as numbers, with their unit in the column header
+		headers, values := moveUnitsToHeaders(hv.ValueNames, hv.Values)
+		row = renderExcelTable(headers, values, f, reportSheetName, row, col, false, valueColors)
 		if idx < len(allHostValues)-1 {
 			row += 1
 		}
@@ -203,7 +336,7 @@ func (r *ReportGeneratorXLSX) renderNumaBandwidthTable(table *Table, allHostValu
 			rowValues = append(rowValues, bandwidths...)
 			tableValues = append(tableValues, rowValues)
 		}
-		row = renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, 2, true)
+		row = renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, 2, true, nil)
 		if idx < len(allHostValues)-1 {
 			row += 1
 		}
@@ -230,7 +363,7 @@ func (r *ReportGeneratorXLSX) renderDIMMPopulationTable(table *Table, allHostVal
 		for _, dimm := range hv.Values {
 			tableValues = append(tableValues, []string{dimm[DerivedSocketIdx], dimm[DerivedChannelIdx], dimm[DerivedSlotIdx], dimmDetails(dimm)})
 		}
-		row = renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, 2, false)
+		row = renderExcelTable(tableHeaders, tableValues, f, reportSheetName, row, 2, false, nil)
 		if idx < len(allHostValues)-1 {
 			row += 1
 		}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+/* The reporter runs in three stages: the raw.json files are parsed into Sources, the
+ * Sources are built into the ReportModel, and the model is rendered into each of the
+ * requested formats. The model is read-only once built, so the formats are rendered
+ * concurrently.
+ */
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/intel/svr-info/internal/cpu"
+)
+
+// ReportModel ... all reports built from the sources, shared by the report generators
+type ReportModel struct {
+	sources        []*Source
+	cpusInfo       *cpu.CPU
+	highlightRules *HighlightRules
+	configuration  *Report
+	brief          *Report
+	profile        *Report
+	analyze        *Report
+	benchmark      *Report
+	insights       *Report
+}
+
+func newReportModel(sources []*Source, highlightRules *HighlightRules, microcodeRevisions MicrocodeRevisions, instanceTypes InstanceTypes, healthWeights *HealthWeights, reference *BenchmarkReference) (model *ReportModel, err error) {
+	cpusInfo, err := cpu.NewCPU()
+	if err != nil {
+		return
+	}
+	model = &ReportModel{
+		sources:        sources,
+		cpusInfo:       cpusInfo,
+		highlightRules: highlightRules,
+	}
+	model.configuration = NewConfigurationReport(sources, cpusInfo, microcodeRevisions, instanceTypes)
+	model.brief = NewBriefReport(sources, model.configuration, cpusInfo)
+	model.profile = NewProfileReport(sources)
+	model.analyze = NewAnalyzeReport(sources)
+	model.benchmark = NewBenchmarkReport(sources, model.configuration, reference)
+	model.insights = NewInsightsReport(sources, model.configuration, model.brief, model.profile, model.benchmark, model.analyze, cpusInfo, healthWeights)
+	// the health grade is also in the brief report, after the host, for quick triage
+	if tableHealth := model.insights.findTable("Health"); tableHealth != nil && len(model.brief.Tables) > 0 {
+		model.brief.Tables = append([]*Table{model.brief.Tables[0], tableHealth}, model.brief.Tables[1:]...)
+	}
+	model.configuration.notCollected.addPlaceholders()
+	return
+}
+
+// getGenerator returns the generator that renders the model in the report format
+func (m *ReportModel) getGenerator(reportType string, outputDir string) (rpt ReportGenerator, err error) {
+	switch reportType {
+	case "html":
+		rpt = newReportGeneratorHTML(outputDir, m.cpusInfo, m.highlightRules, m.configuration, m.insights, m.profile, m.benchmark, m.analyze)
+	case "json":
+		if gCmdLineArgs.internalJSON {
+			rpt = newReportGeneratorJSON(outputDir, m.configuration, m.insights, m.profile, m.benchmark, m.analyze)
+		} else {
+			rpt = newReportGeneratorJSONSimplified(outputDir, m.configuration, m.brief, m.insights, m.profile, m.benchmark, m.analyze)
+		}
+	case "xlsx":
+		rpt = newReportGeneratorXLSX(outputDir, m.highlightRules, m.configuration, m.brief, m.insights, m.profile, m.benchmark, m.analyze) // only Excel has 'brief' report
+	case "txt":
+		rpt = newReportGeneratorTXT(m.sources, outputDir, gCmdLineArgs.txtWidth, strings.Split(gCmdLineArgs.txtSections, ","), m.brief, m.configuration, m.benchmark, m.profile, m.insights)
+	case "facts":
+		rpt = newReportGeneratorFacts(outputDir, m.configuration)
+	case "csv":
+		rpt = newReportGeneratorCSV(m.sources, outputDir, m.brief, m.configuration, m.benchmark, m.profile, m.analyze, m.insights)
+	case "md":
+		rpt = newReportGeneratorMD(m.sources, outputDir, m.brief, m.configuration, m.benchmark, m.profile, m.analyze, m.insights)
+	default:
+		err = fmt.Errorf("unsupported report type: %s", reportType)
+	}
+	return
+}
+
+// render runs the generators concurrently. The report file paths are returned in the
+// order of the generators. If any generator fails, the first error is returned.
+func render(generators []ReportGenerator) (reportFilePaths []string, err error) {
+	results := make([][]string, len(generators))
+	errs := make([]error, len(generators))
+	var wg sync.WaitGroup
+	for i, rpt := range generators {
+		wg.Add(1)
+		go func(i int, rpt ReportGenerator) {
+			defer wg.Done()
+			results[i], errs[i] = rpt.generate()
+			if errs[i] != nil {
+				log.Printf("Failed to generate %T: %v", rpt, errs[i])
+			}
+		}(i, rpt)
+	}
+	wg.Wait()
+	for i := range generators {
+		if errs[i] != nil {
+			err = errs[i]
+			return
+		}
+		reportFilePaths = append(reportFilePaths, results[i]...)
+	}
+	return
+}
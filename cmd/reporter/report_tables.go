@@ -10,17 +10,18 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/hyperjumptech/grule-rule-engine/ast"
 	"github.com/hyperjumptech/grule-rule-engine/builder"
 	"github.com/hyperjumptech/grule-rule-engine/engine"
 	"github.com/hyperjumptech/grule-rule-engine/pkg"
 	"github.com/intel/svr-info/internal/cpu"
+	"github.com/intel/svr-info/internal/util"
 	"gopkg.in/yaml.v2"
 )
 
@@ -169,6 +170,50 @@ func newMemoryBandwidthLatencyTable(sources []*Source, category TableCategory) (
 	return
 }
 
+func newDPUTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "DPU",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"PCI Bus",
+				"Type",
+				"Device",
+				"Mode",
+				"Firmware",
+				"Driver",
+				"Host Functions",
+				"Interfaces",
+				"VFs",
+			},
+			Values: [][]string{},
+		}
+		for _, d := range parseDPUs(source.getCommandOutputSections("dpu")) {
+			var vfs string
+			if d.totalVFs > 0 {
+				vfs = fmt.Sprintf("%d of %d", d.numVFs, d.totalVFs)
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				d.bus,
+				d.dpuType,
+				d.device,
+				getDPUMode(d),
+				strings.Join(d.firmware, ", "),
+				strings.Join(d.drivers, ", "),
+				strconv.Itoa(len(d.functions)),
+				strings.Join(d.interfaces, ", "),
+				vfs,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newNetworkIRQTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "Network IRQ Mapping",
@@ -327,14 +372,24 @@ func newHostTable(sources []*Source, category TableCategory) (table *Table) {
 			ValueNames: []string{
 				"Name",
 				"Time",
+				"Collection Mode",
+				"Container",
 			},
 			Values: [][]string{
 				{
-					source.valFromRegexSubmatch("uname -a", `^Linux (\S+) \S+`),
+					source.valFromRegexSubmatch("uname -a", `^(?:Linux|Windows_NT) (\S+) \S+`),
 					source.valFromRegexSubmatch("date -u", `^(.*UTC\s*[0-9]*)$`),
+					"standard",
+					"none",
 				},
 			},
 		}
+		if source.isReadOnly() {
+			hostValues.Values[0][2] = "read-only"
+		}
+		if container := source.getContainer(); container != "" {
+			hostValues.Values[0][3] = container
+		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
@@ -354,15 +409,61 @@ func newOperatingSystemTable(sources []*Source, category TableCategory) (table *
 				"Kernel",
 				"Boot Parameters",
 				"Microcode",
+				"Live Patches",
+				"Kernel Taint",
 			},
-			Values: [][]string{
-				{
-					source.getOperatingSystem(),
-					source.valFromRegexSubmatch("uname -a", `^Linux \S+ (\S+)`),
-					source.getCommandOutputLine("/proc/cmdline"),
-					source.valFromRegexSubmatch("/proc/cpuinfo", `^microcode.*:\s*(.+?)$`),
-				},
+			Values: [][]string{},
+		}
+		patchSections := source.getCommandOutputSections("kernel patches")
+		hostValues.Values = append(hostValues.Values, []string{
+			source.getOperatingSystem(),
+			source.valFromRegexSubmatch("uname -a", `^(?:Linux|Windows_NT) \S+ (\S+)`),
+			source.getCommandOutputLine("/proc/cmdline"),
+			source.valFromRegexSubmatch("/proc/cpuinfo", `^microcode.*:\s*(.+?)$`),
+			getLivePatchSummary(patchSections),
+			getKernelTaint(patchSections),
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newMicrocodeTable(sources []*Source, tableCPU *Table, tableOS *Table, microcodeRevisions MicrocodeRevisions, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Microcode",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIdx, hv := range tableOS.AllHostValues {
+		var hostValues = HostValues{
+			Name: hv.Name,
+			ValueNames: []string{
+				"Platform",
+				"Running Revision",
+				"Current Revision",
+				"Minimum Revision",
+				"Release",
+				"Status",
+				"Severity",
 			},
+			Values: [][]string{},
+		}
+		running, _ := tableOS.getValue(sourceIdx, "Microcode")
+		if running != "" {
+			family, _ := tableCPU.getValue(sourceIdx, "Family")
+			model, _ := tableCPU.getValue(sourceIdx, "Model")
+			stepping, _ := tableCPU.getValue(sourceIdx, "Stepping")
+			entry := microcodeRevisions.find(family, model, stepping)
+			status, severity := checkMicrocode(running, entry)
+			// guests don't see the host's microcode revision
+			if util.StringInList("hypervisor", sources[sourceIdx].getCPUFlags()) {
+				status, severity = "Unknown Revision", ""
+			}
+			var platform, current, minimum, release string
+			if entry != nil {
+				platform, current, minimum, release = entry.Platform, entry.Revision, entry.Minimum, entry.Release
+			}
+			hostValues.Values = append(hostValues.Values, []string{platform, running, current, minimum, release, status, severity})
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
@@ -541,6 +642,33 @@ func newBIOSTable(sources []*Source, category TableCategory) (table *Table) {
 	return
 }
 
+func newBIOSSettingsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "BIOS Settings",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	defs, err := loadBIOSSettingDefs()
+	if err != nil {
+		log.Printf("failed to load bios_settings.yaml: %v", err)
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Setting",
+				"Value",
+				"Vendor Setting",
+				"Vendor Value",
+				"Source",
+			},
+			Values: normalizeBIOSSettings(source.getBIOSSettings(), defs),
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newBIOSSummaryTable(tableBIOS *Table, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "BIOS",
@@ -663,6 +791,343 @@ func newSoftwareTable(sources []*Source, category TableCategory) (table *Table)
 	return
 }
 
+func newBootloaderTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Bootloader",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Bootloader",
+				"Boot Mode",
+				"Secure Boot",
+				"Shim",
+				"Default Entry",
+				"Default Kernel",
+				"Default Parameters",
+				"Entries",
+				"Next Boot Parameter Changes",
+				"Non-default Entry Drift",
+			},
+			Values: [][]string{},
+		}
+		b := getBootloader(source.getCommandOutputSections("bootloader"))
+		var defaultEntry bootEntry
+		if b.defaultEntry >= 0 {
+			defaultEntry = b.entries[b.defaultEntry]
+		}
+		// the parameters that the running kernel wasn't booted with, or that will be
+		// removed, at the next boot
+		nextBoot := ""
+		if cmdline := source.getCommandOutputLine("/proc/cmdline"); b.defaultEntry >= 0 && cmdline != "" {
+			nextBoot = getBootParameterDifferences(cmdline, defaultEntry.parameters)
+			if nextBoot == "" {
+				nextBoot = "None"
+			}
+		}
+		// the kernel parameters of the other entries, which a fallback kernel boots with,
+		// that differ from the default entry's
+		var drift []string
+		if b.defaultEntry >= 0 {
+			for i, entry := range b.entries {
+				if i == b.defaultEntry || isRecoveryBootEntry(entry) {
+					continue
+				}
+				if differences := getBootParameterDifferences(defaultEntry.parameters, entry.parameters); differences != "" {
+					drift = append(drift, fmt.Sprintf("%s: %s", entry.title, differences))
+				}
+			}
+		}
+		driftValue := strings.Join(drift, "; ")
+		if b.defaultEntry >= 0 && driftValue == "" {
+			driftValue = "None"
+		}
+		name := strings.TrimSpace(b.name + " " + b.version)
+		entries := ""
+		if len(b.entries) > 0 {
+			entries = strconv.Itoa(len(b.entries))
+		}
+		hostValues.Values = append(hostValues.Values, []string{
+			name,
+			b.bootMode,
+			b.secureBoot,
+			strings.Join(b.shim, ", "),
+			defaultEntry.title,
+			defaultEntry.kernel,
+			defaultEntry.parameters,
+			entries,
+			nextBoot,
+			driftValue,
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newBootEntryTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Boot Entry",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Title",
+				"Kernel",
+				"Default",
+				"Parameters",
+			},
+			Values: [][]string{},
+		}
+		b := getBootloader(source.getCommandOutputSections("bootloader"))
+		for i, entry := range b.entries {
+			title := entry.title
+			if entry.submenu != "" {
+				title = entry.submenu + ">" + title
+			}
+			isDefault := ""
+			if i == b.defaultEntry {
+				isDefault = "Yes"
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				title,
+				entry.kernel,
+				isDefault,
+				entry.parameters,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newContainerRuntimeTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Container Runtime",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Docker",
+				"containerd",
+				"CRI-O",
+				"kubelet",
+				"runc",
+				"Cgroup Version",
+				"Cgroup Driver",
+				"Configuration Deviations",
+			},
+			Values: [][]string{},
+		}
+		runtime := getContainerRuntime(source.getCommandOutputSections("container runtime"))
+		var drivers []string
+		for _, name := range containerRuntimeNames {
+			if driver, ok := runtime.cgroupDrivers[name]; ok {
+				drivers = append(drivers, fmt.Sprintf("%s: %s", name, driver))
+			}
+		}
+		deviations := strings.Join(runtime.deviations, "; ")
+		if len(runtime.versions) > 0 && deviations == "" {
+			deviations = "None"
+		}
+		hostValues.Values = append(hostValues.Values, []string{
+			runtime.versions["docker"],
+			runtime.versions["containerd"],
+			runtime.versions["crio"],
+			runtime.versions["kubelet"],
+			runtime.versions["runc"],
+			runtime.cgroupVersion,
+			strings.Join(drivers, ", "),
+			deviations,
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newSpeedSelectTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Speed Select",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	reSupport := regexp.MustCompile(`(?m)SST-PP \(feature perf-profile\) is (.+)$`)
+	reLevelChange := regexp.MustCompile(`TDP level change control is (\w+)`)
+	withUnits := func(val string, units string) string {
+		if val == "" {
+			return val
+		}
+		return val + " " + units
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"SST-PP",
+				"Current Level",
+				"Level Change",
+				"Active CPUs",
+				"Base Frequency",
+				"TDP",
+				"SST-BF",
+				"SST-TF",
+				"SST-CP",
+				"High Priority CPUs",
+				"High Priority Base Frequency",
+				"Low Priority Base Frequency",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getIntelSpeedSelectSections()
+		if info, ok := sections["info"]; ok {
+			var support, levelChange string
+			if match := reSupport.FindStringSubmatch(info); match != nil {
+				support = match[1]
+			}
+			if match := reLevelChange.FindStringSubmatch(info); match != nil {
+				levelChange = match[1]
+			}
+			current := getISSTValue(parseIntelSpeedSelect(sections["perf-profile current level"]), "", "get-config-current_level")
+			profiles := parseIntelSpeedSelect(sections["perf-profile info"])
+			level := "perf-profile-level-" + current
+			baseFreqProperties := level + "/speed-select-base-freq-properties"
+			hostValues.Values = append(hostValues.Values, []string{
+				support,
+				current,
+				levelChange,
+				getISSTValue(profiles, level, "cpu-count"),
+				withUnits(getISSTValue(profiles, level, "base-frequency(MHz)"), "MHz"),
+				withUnits(getISSTValue(profiles, level, "thermal-design-power(W)"), "W"),
+				getISSTValue(profiles, level, "speed-select-base-freq"),
+				getISSTValue(profiles, level, "speed-select-turbo-freq"),
+				getISSTValue(parseIntelSpeedSelect(sections["core-power info"]), "core-power", "clos-enable-status"),
+				getISSTValue(profiles, baseFreqProperties, "high-priority-cpu-list"),
+				withUnits(getISSTValue(profiles, baseFreqProperties, "high-priority-base-frequency(MHz)"), "MHz"),
+				withUnits(getISSTValue(profiles, baseFreqProperties, "low-priority-base-frequency(MHz)"), "MHz"),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newSpeedSelectProfileTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Speed Select Profile",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	reLevel := regexp.MustCompile(`^perf-profile-level-(\d+)$`)
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Level",
+				"Current",
+				"CPUs",
+				"Base Frequency (MHz)",
+				"TDP (W)",
+				"SST-BF",
+				"SST-TF",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getIntelSpeedSelectSections()
+		current := getISSTValue(parseIntelSpeedSelect(sections["perf-profile current level"]), "", "get-config-current_level")
+		profiles := parseIntelSpeedSelect(sections["perf-profile info"])
+		// the levels of the first die, the other dies are configured the same
+		for _, v := range profiles {
+			match := reLevel.FindStringSubmatch(v.section)
+			if match == nil || v.key != "cpu-count" || v.pkg != profiles[0].pkg || v.die != profiles[0].die {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				match[1],
+				yesIfTrue(strconv.FormatBool(match[1] == current)),
+				v.value,
+				getISSTValue(profiles, v.section, "base-frequency(MHz)"),
+				getISSTValue(profiles, v.section, "thermal-design-power(W)"),
+				getISSTValue(profiles, v.section, "speed-select-base-freq"),
+				getISSTValue(profiles, v.section, "speed-select-turbo-freq"),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newCorePriorityTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Core Priority",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"CLOS",
+				"CPUs",
+				"Proportional Priority",
+				"Min Frequency",
+				"Max Frequency",
+				"Desired Frequency",
+				"EPP",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getIntelSpeedSelectSections()
+		cpus := make(map[string][]int)
+		for _, v := range parseIntelSpeedSelect(sections["core-power assoc"]) {
+			if cpu, err := strconv.Atoi(v.cpu); err == nil && v.key == "clos" {
+				cpus[v.value] = append(cpus[v.value], cpu)
+			}
+		}
+		// the configuration of the first die, the other dies are configured the same
+		configs := make(map[string]map[string]string)
+		var closes []string
+		var currentClos string
+		config := parseIntelSpeedSelect(sections["core-power config"])
+		for _, v := range config {
+			if v.pkg != config[0].pkg || v.die != config[0].die {
+				continue
+			}
+			if v.key == "clos" {
+				currentClos = v.value
+				if _, ok := configs[currentClos]; !ok {
+					configs[currentClos] = make(map[string]string)
+					closes = append(closes, currentClos)
+				}
+				continue
+			}
+			if currentClos != "" {
+				configs[currentClos][v.key] = v.value
+			}
+		}
+		for _, clos := range closes {
+			hostValues.Values = append(hostValues.Values, []string{
+				clos,
+				getCPUListString(cpus[clos]),
+				configs[clos]["clos-proportional-priority"],
+				configs[clos]["clos-min"],
+				configs[clos]["clos-max"],
+				configs[clos]["clos-desired"],
+				configs[clos]["epp"],
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newUncoreTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "Uncore",
@@ -872,76 +1337,420 @@ func newISATable(sources []*Source, category TableCategory) (table *Table) {
 	return
 }
 
-func newAcceleratorTable(sources []*Source, category TableCategory) (table *Table) {
+func newCPUFeatureTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Accelerator",
+		Name:          "CPU Feature",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
-	type Accelerator struct {
-		MfgID       string `yaml:"mfgid"`
-		DevID       string `yaml:"devid"`
-		Name        string `yaml:"name"`
-		FullName    string `yaml:"full_name"`
-		Description string `yaml:"description"`
-	}
-	var accelDefs []Accelerator
-	// load accelerator info from YAML
-	yamlBytes, err := resources.ReadFile("resources/accelerators.yaml")
-	if err != nil {
-		log.Printf("failed to read accelerators.yaml: %v", err)
-		return
-	}
-	err = yaml.UnmarshalStrict(yamlBytes, &accelDefs)
-	if err != nil {
-		log.Printf("failed to parse accelerators.yaml: %v", err)
-		return
-	}
 	for _, source := range sources {
 		var hostValues = HostValues{
 			Name: source.getHostname(),
 			ValueNames: []string{
-				"Name",
-				"Count",
-				"Work Queues",
-				"Full Name",
-				"Description",
+				"Category",
+				"Feature",
+				"Flag",
+				"Supported",
 			},
-			Values: [][]string{},
 		}
-		for _, accelDef := range accelDefs {
-			hostValues.Values = append(hostValues.Values, []string{accelDef.Name, source.getAcceleratorCount(accelDef.MfgID, accelDef.DevID), source.getAcceleratorQueues(accelDef.Name), accelDef.FullName, accelDef.Description})
+		flags := source.getCPUFlags()
+		if len(flags) > 0 {
+			flagSet := make(map[string]bool)
+			for _, flag := range flags {
+				flagSet[flag] = true
+			}
+			for _, feature := range cpuFeatures {
+				hostValues.Values = append(hostValues.Values, []string{feature.Category, feature.Description, feature.Flag, yesIfTrue(strconv.FormatBool(flagSet[feature.Flag]))})
+			}
+			// VT-d isn't a CPU flag, it is enabled when the kernel finds DMA remapping units
+			if _, ok := source.ParsedData["iommu"]; ok {
+				vtd := false
+				for _, iommu := range source.getIOMMUs() {
+					if strings.HasPrefix(iommu, "dmar") {
+						vtd = true
+					}
+				}
+				hostValues.Values = append(hostValues.Values, []string{"Virtualization", "Intel Virtualization Technology for Directed I/O (VT-d)", "", yesIfTrue(strconv.FormatBool(vtd))})
+			}
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newAcceleratorSummaryTable(tableAccelerator *Table, category TableCategory) (table *Table) {
+func newCPUIsolationTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Accelerator",
+		Name:          "CPU Isolation",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
-	for _, hv := range tableAccelerator.AllHostValues {
-		var summaryParts []string
-		for _, rowValues := range hv.Values {
-			accelName := rowValues[0]
-			accelCount := rowValues[1]
-			if strings.Contains(accelName, "chipset") { // skip "QAT (on chipset)" in this table
-				continue
-			} else if strings.Contains(accelName, "CPU") { // rename "QAT (on CPU)" to simply "QAT"
-				accelName = "QAT"
-			}
-			summaryParts = append(summaryParts, fmt.Sprintf("%s %s [0]", accelName, accelCount))
+	for _, source := range sources {
+		cmdline := source.getCommandOutputLine("/proc/cmdline")
+		isolated := source.valFromRegexSubmatch("cpu isolation", `^isolated:\s*([0-9,-]+)`)
+		if isolated == "" {
+			isolated = getIsolcpusCPUList(getBootParameter(cmdline, "isolcpus"))
 		}
-		var summaryHv = HostValues{
-			Name:       hv.Name,
-			ValueNames: []string{"Accelerators Available [used]"},
-			Values:     [][]string{{strings.Join(summaryParts, ", ")}},
+		nohzFull := source.valFromRegexSubmatch("cpu isolation", `^nohz_full:\s*([0-9,-]+)`)
+		if nohzFull == "" {
+			nohzFull = getBootParameter(cmdline, "nohz_full")
 		}
-		table.AllHostValues = append(table.AllHostValues, summaryHv)
-	}
+		rcuNocbs := getBootParameter(cmdline, "rcu_nocbs")
+		irqAffinity := getBootParameter(cmdline, "irqaffinity")
+		irqbalance := source.getCommandOutputLine("irqbalance") != ""
+		irqbalanceStatus := "Not running"
+		if irqbalance {
+			irqbalanceStatus = "Running"
+		}
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Isolated CPUs",
+				"nohz_full CPUs",
+				"rcu_nocbs",
+				"irqaffinity",
+				"irqbalance",
+				"Status",
+			},
+			Values: [][]string{
+				{
+					isolated,
+					nohzFull,
+					rcuNocbs,
+					irqAffinity,
+					irqbalanceStatus,
+					getCPUIsolationStatus(isolated, nohzFull, rcuNocbs, irqAffinity, irqbalance),
+				},
+			},
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newCPUFeatureMismatchTable lists, for each host, the CPU feature flags that differ from
+// the other hosts. Virtual machines can't be live migrated between hosts that don't
+// expose the same CPU features, unless the features are masked by the hypervisor.
+func newCPUFeatureMismatchTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "CPU Feature Mismatch",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	var hostnames []string
+	var hostFlags []map[string]bool
+	for _, source := range sources {
+		hostnames = append(hostnames, source.getHostname())
+		flags := make(map[string]bool)
+		for _, flag := range source.getCPUFlags() {
+			flags[flag] = true
+		}
+		hostFlags = append(hostFlags, flags)
+	}
+	for sourceIdx := range sources {
+		var hostValues = HostValues{
+			Name: hostnames[sourceIdx],
+			ValueNames: []string{
+				"Flag",
+				"Category",
+				"Status",
+				"Hosts",
+			},
+		}
+		if len(hostFlags[sourceIdx]) > 0 {
+			missing, extra := getCPUFlagMismatches(sourceIdx, hostnames, hostFlags)
+			var flags []string
+			for flag := range missing {
+				flags = append(flags, flag)
+			}
+			for flag := range extra {
+				flags = append(flags, flag)
+			}
+			sort.Strings(flags)
+			for _, flag := range flags {
+				if hosts, ok := missing[flag]; ok {
+					hostValues.Values = append(hostValues.Values, []string{flag, getCPUFeatureCategory(flag), "Missing", "present on " + strings.Join(hosts, ", ")})
+				} else {
+					hostValues.Values = append(hostValues.Values, []string{flag, getCPUFeatureCategory(flag), "Extra", "absent on " + strings.Join(extra[flag], ", ")})
+				}
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newRDTTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "RDT",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	allocationFlags := [][]string{{"cat_l3", "L3 CAT"}, {"cat_l2", "L2 CAT"}, {"cdp_l3", "L3 CDP"}, {"mba", "MBA"}}
+	monitoringFlags := [][]string{{"cqm_llc", "CMT"}, {"cqm_mbm_total", "MBM Total"}, {"cqm_mbm_local", "MBM Local"}}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Status",
+				"Allocation",
+				"Monitoring",
+				"L3 CLOS",
+				"L3 Cache Mask",
+				"MBA CLOS",
+				"MBA Granularity",
+				"RMIDs",
+				"Monitoring Events",
+			},
+			Values: [][]string{},
+		}
+		flagSet := make(map[string]bool)
+		for _, flag := range source.getCPUFlags() {
+			flagSet[flag] = true
+		}
+		var allocation, monitoring []string
+		for _, flag := range allocationFlags {
+			if flagSet[flag[0]] {
+				allocation = append(allocation, flag[1])
+			}
+		}
+		for _, flag := range monitoringFlags {
+			if flagSet[flag[0]] {
+				monitoring = append(monitoring, flag[1])
+			}
+		}
+		var status string
+		if output := source.getCommandOutput("resctrl"); output != "" {
+			if strings.Contains(output, "resctrl is not mounted") {
+				status = "Not mounted"
+				if len(allocation) == 0 && len(monitoring) == 0 {
+					status = "Not supported"
+				}
+			} else {
+				status = "Mounted"
+			}
+		}
+		info, _ := parseResctrl(source.getCommandOutputLines("resctrl"))
+		l3Closids := info["L3/num_closids"]
+		if l3Closids == "" {
+			// with CDP enabled, L3 is split into code and data resources
+			l3Closids = info["L3CODE/num_closids"]
+		}
+		l3Mask := info["L3/cbm_mask"]
+		if l3Mask == "" {
+			l3Mask = info["L3CODE/cbm_mask"]
+		}
+		mbaGranularity := info["MB/bandwidth_gran"]
+		if mbaGranularity != "" {
+			mbaGranularity += "%"
+		}
+		hostValues.Values = append(hostValues.Values, []string{
+			status,
+			strings.Join(allocation, ", "),
+			strings.Join(monitoring, ", "),
+			l3Closids,
+			l3Mask,
+			info["MB/num_closids"],
+			mbaGranularity,
+			info["L3_MON/num_rmids"],
+			info["L3_MON/mon_features"],
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newRDTGroupTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "RDT Group",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Group",
+				"Mode",
+				"CPUs",
+				"Tasks",
+				"L3 Schemata",
+				"MB Schemata",
+				"LLC Occupancy (MiB)",
+				"Memory Bandwidth (MB/s)",
+				"Local Memory Bandwidth (MB/s)",
+			},
+			Values: [][]string{},
+		}
+		info, groups := parseResctrl(source.getCommandOutputLines("resctrl"))
+		events := strings.Split(info["L3_MON/mon_features"], ",")
+		monitored := func(event string) bool {
+			for _, e := range events {
+				if e == event {
+					return true
+				}
+			}
+			return false
+		}
+		for _, group := range groups {
+			l3Schemata := group.schemata["L3"]
+			if l3Schemata == "" && group.schemata["L3CODE"] != "" {
+				// with CDP enabled, L3 is split into code and data resources
+				l3Schemata = "CODE:" + group.schemata["L3CODE"] + " DATA:" + group.schemata["L3DATA"]
+			}
+			var occupancy, bandwidth, localBandwidth string
+			if monitored("llc_occupancy") {
+				if bytes, err := strconv.ParseInt(group.values["llc_occupancy"], 10, 64); err == nil {
+					occupancy = fmt.Sprintf("%.1f", float64(bytes)/(1024*1024))
+				}
+			}
+			if monitored("mbm_total_bytes") {
+				bandwidth = getResctrlBandwidth(group, "mbm_total_bytes")
+			}
+			if monitored("mbm_local_bytes") {
+				localBandwidth = getResctrlBandwidth(group, "mbm_local_bytes")
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				group.name,
+				group.values["mode"],
+				group.values["cpus"],
+				group.values["tasks"],
+				l3Schemata,
+				group.schemata["MB"],
+				occupancy,
+				bandwidth,
+				localBandwidth,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newCPUEfficiencyTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "CPU Efficiency",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	labels := map[string]string{
+		"frontend_bound":     "Frontend Bound",
+		"fetch_latency":      "Fetch Latency",
+		"fetch_bandwidth":    "Fetch Bandwidth",
+		"bad_speculation":    "Bad Speculation",
+		"branch_mispredicts": "Branch Mispredicts",
+		"machine_clears":     "Machine Clears",
+		"backend_bound":      "Backend Bound",
+		"core_bound":         "Core Bound",
+		"memory_bound":       "Memory Bound",
+		"retiring":           "Retiring",
+		"heavy_operations":   "Heavy Operations",
+		"light_operations":   "Light Operations",
+	}
+	var names []string
+	for _, category := range topdownLevel1 {
+		names = append(names, category[0])
+	}
+	for _, category := range topdownLevel1 {
+		names = append(names, category[1:]...)
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name:       source.getHostname(),
+			ValueNames: []string{"Socket"},
+			Values:     [][]string{},
+		}
+		for _, name := range names {
+			hostValues.ValueNames = append(hostValues.ValueNames, labels[name]+" (%)")
+		}
+		hostValues.ValueNames = append(hostValues.ValueNames, "Primary Bottleneck")
+		sockets, metrics := parseTopdown(source.getCommandOutputLines("topdown"))
+		for _, socket := range sockets {
+			values := []string{strings.TrimPrefix(socket, "S")}
+			for _, name := range names {
+				value := ""
+				if percent, ok := metrics[socket][name]; ok {
+					value = fmt.Sprintf("%.1f", percent)
+				}
+				values = append(values, value)
+			}
+			values = append(values, getTopdownBottleneck(metrics[socket], labels))
+			hostValues.Values = append(hostValues.Values, values)
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newAcceleratorTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Accelerator",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	type Accelerator struct {
+		MfgID       string `yaml:"mfgid"`
+		DevID       string `yaml:"devid"`
+		Name        string `yaml:"name"`
+		FullName    string `yaml:"full_name"`
+		Description string `yaml:"description"`
+	}
+	var accelDefs []Accelerator
+	// load accelerator info from YAML
+	yamlBytes, err := resources.ReadFile("resources/accelerators.yaml")
+	if err != nil {
+		log.Printf("failed to read accelerators.yaml: %v", err)
+		return
+	}
+	err = yaml.UnmarshalStrict(yamlBytes, &accelDefs)
+	if err != nil {
+		log.Printf("failed to parse accelerators.yaml: %v", err)
+		return
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Name",
+				"Count",
+				"Work Queues",
+				"Full Name",
+				"Description",
+			},
+			Values: [][]string{},
+		}
+		for _, accelDef := range accelDefs {
+			hostValues.Values = append(hostValues.Values, []string{accelDef.Name, source.getAcceleratorCount(accelDef.MfgID, accelDef.DevID), source.getAcceleratorQueues(accelDef.Name), accelDef.FullName, accelDef.Description})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newAcceleratorSummaryTable(tableAccelerator *Table, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Accelerator",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, hv := range tableAccelerator.AllHostValues {
+		var summaryParts []string
+		for _, rowValues := range hv.Values {
+			accelName := rowValues[0]
+			accelCount := rowValues[1]
+			if strings.Contains(accelName, "chipset") { // skip "QAT (on chipset)" in this table
+				continue
+			} else if strings.Contains(accelName, "CPU") { // rename "QAT (on CPU)" to simply "QAT"
+				accelName = "QAT"
+			}
+			summaryParts = append(summaryParts, fmt.Sprintf("%s %s [0]", accelName, accelCount))
+		}
+		var summaryHv = HostValues{
+			Name:       hv.Name,
+			ValueNames: []string{"Accelerators Available [used]"},
+			Values:     [][]string{{strings.Join(summaryParts, ", ")}},
+		}
+		table.AllHostValues = append(table.AllHostValues, summaryHv)
+	}
 	return
 }
 
@@ -956,17 +1765,11 @@ func newPowerTable(sources []*Source, category TableCategory) (table *Table) {
 			Name: source.getHostname(),
 			ValueNames: []string{
 				"TDP",
-				"Power & Perf Policy",
-				"Frequency Governor",
-				"Frequency Driver",
 				"Max C-State",
 			},
 			Values: [][]string{
 				{
 					source.getTDP(),
-					source.getPowerPerfPolicy(),
-					source.getCommandOutputLine("cpu_freq_governor"),
-					source.getCommandOutputLine("cpu_freq_driver"),
 					source.getCommandOutputLine("max_cstate"),
 				},
 			},
@@ -976,6 +1779,79 @@ func newPowerTable(sources []*Source, category TableCategory) (table *Table) {
 	return
 }
 
+func newFrequencyPolicyTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Frequency Policy",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		policies := source.getCPUFreqPolicies()
+		governor := getPolicySetting(policies, "governor", nil)
+		if governor == "" {
+			// older collections only have the governor of cpu0
+			governor = source.getCommandOutputLine("cpu_freq_governor")
+		}
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Driver",
+				"Driver Mode",
+				"Governor",
+				"Min Frequency",
+				"Max Frequency",
+				"EPP",
+				"EPB",
+				"Turbo",
+				"HWP",
+			},
+			Values: [][]string{
+				{
+					source.getCommandOutputLine("cpu_freq_driver"),
+					source.valFromRegexSubmatch("cpufreq boost", `^intel_pstate status: (.+)$`),
+					governor,
+					getPolicySetting(policies, "min", kHzToMHz),
+					getPolicySetting(policies, "max", kHzToMHz),
+					getPolicySetting(policies, "epp", nil),
+					source.getPowerPerfPolicy(),
+					source.getFrequencyBoost(),
+					source.getHWP(),
+				},
+			},
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newPowerProfileMismatchTable lists the BIOS power settings that the OS power settings
+// contradict, e.g., a balanced BIOS profile with the performance governor
+func newPowerProfileMismatchTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Power Profile Mismatch",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	defs, err := loadBIOSSettingDefs()
+	if err != nil {
+		log.Printf("failed to load bios_settings.yaml: %v", err)
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Setting",
+				"BIOS",
+				"OS",
+				"Explanation",
+			},
+			Values: source.getPowerProfileMismatches(defs),
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newGPUTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "GPU",
@@ -1062,12 +1938,12 @@ func newNICTable(sources []*Source, category TableCategory) (table *Table) {
 			nics = append(nics, []string{
 				nic[idxNicName],
 				nic[idxNicModel],
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?Speed:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?Link detected:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?bus-info:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?driver:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?version:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
-				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?firmware-version:\s*(.+)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?Speed:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?Link detected:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?bus-info:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?driver:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?version:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
+				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`Settings for %s:(?:.|\n)*?firmware-version:[ \t]*(.*)(?:.|\n)*?MAC ADDRESS`, nic[0])),
 				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`MAC ADDRESS %s: (.*)\n`, nic[0])),
 				source.valFromOutputRegexSubmatch("nic info", fmt.Sprintf(`NUMA NODE %s: (.*)\n`, nic[0])),
 				enabledIfVal(source.getCommandOutputLine("irqbalance")),
@@ -1168,6 +2044,172 @@ func newMemoryTable(sources []*Source, tableDIMM *Table, tableDIMMPopulation *Ta
 	return
 }
 
+func newMemoryTieringTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Memory Tiering",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Memory Tiers",
+				"Memory-only Nodes",
+				"CXL Memory Devices",
+				"CXL Regions",
+				"DAX Devices",
+				"PMem Mode",
+				"PMem Namespaces",
+				"Demotion",
+				"NUMA Balancing",
+				"Zone Reclaim Mode",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getCommandOutputSections("memory tiers")
+		if len(sections) > 0 {
+			nodes := getMemoryNodes(sections)
+			tierNodes := make(map[string][]int)
+			var tiers []string
+			var memoryOnlyNodes []int
+			for _, node := range nodes {
+				id, _ := strconv.Atoi(node.id)
+				if node.tier != "" {
+					if _, ok := tierNodes[node.tier]; !ok {
+						tiers = append(tiers, node.tier)
+					}
+					tierNodes[node.tier] = append(tierNodes[node.tier], id)
+				}
+				if node.cpus == "" && node.memoryKB > 0 {
+					memoryOnlyNodes = append(memoryOnlyNodes, id)
+				}
+			}
+			var tierList []string
+			for _, tier := range tiers {
+				tierList = append(tierList, fmt.Sprintf("%s (nodes %s)", tier, getCPUListString(tierNodes[tier])))
+			}
+			var regions []string
+			for _, region := range getJSONObjects(sections["cxl"], "region") {
+				regions = append(regions, fmt.Sprintf("%s: %s %s", jsonString(region, "region"), jsonString(region, "size"), jsonString(region, "type")))
+			}
+			var daxDevices []string
+			for _, device := range getJSONObjects(sections["daxctl"], "chardev") {
+				dax := fmt.Sprintf("%s: %s %s", jsonString(device, "chardev"), jsonString(device, "size"), jsonString(device, "mode"))
+				if node := jsonString(device, "target_node"); node != "" {
+					dax += " (node " + node + ")"
+				}
+				daxDevices = append(daxDevices, dax)
+			}
+			var namespaces []string
+			for _, namespace := range getJSONObjects(sections["ndctl"], "dev") {
+				namespaces = append(namespaces, fmt.Sprintf("%s: %s %s", jsonString(namespace, "dev"), jsonString(namespace, "size"), jsonString(namespace, "mode")))
+			}
+			var memdevs string
+			if cxl := sections["cxl"]; cxl != "" {
+				memdevs = strconv.Itoa(len(getJSONObjects(cxl, "memdev")))
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				strings.Join(tierList, ", "),
+				getCPUListString(memoryOnlyNodes),
+				memdevs,
+				strings.Join(regions, ", "),
+				strings.Join(daxDevices, ", "),
+				getPMemMode(strings.Split(sections["ipmctl"], "\n")),
+				strings.Join(namespaces, ", "),
+				source.valFromRegexSubmatch("memory tiers", `^demotion_enabled: (.+)$`),
+				source.getMemoryNUMABalancing(),
+				source.valFromRegexSubmatch("memory tiers", `^zone_reclaim_mode: (.+)$`),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newNUMANodeMemoryTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "NUMA Node Memory",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Node",
+				"CPUs",
+				"Memory",
+				"Memory Tier",
+				"Type",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getCommandOutputSections("memory tiers")
+		// DAX devices in system-ram mode add memory to a (CPU-less) node
+		daxNodes := make(map[string][]string)
+		for _, device := range getJSONObjects(sections["daxctl"], "chardev") {
+			if jsonString(device, "mode") == "system-ram" {
+				node := jsonString(device, "target_node")
+				daxNodes[node] = append(daxNodes[node], jsonString(device, "chardev"))
+			}
+		}
+		for _, node := range getMemoryNodes(sections) {
+			nodeType := "CPU and Memory"
+			if node.cpus == "" {
+				nodeType = "Memory-only"
+				if devices, ok := daxNodes[node.id]; ok {
+					nodeType += " (" + strings.Join(devices, ", ") + ")"
+				}
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				node.id,
+				node.cpus,
+				formatQuantity(float64(node.memoryKB)*1024, UnitBytes),
+				node.tier,
+				nodeType,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newCXLMemoryTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "CXL Memory",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Memory Device",
+				"Host",
+				"Serial",
+				"RAM Size",
+				"PMem Size",
+				"NUMA Node",
+			},
+			Values: [][]string{},
+		}
+		sections := source.getCommandOutputSections("memory tiers")
+		for _, memdev := range getJSONObjects(sections["cxl"], "memdev") {
+			hostValues.Values = append(hostValues.Values, []string{
+				jsonString(memdev, "memdev"),
+				jsonString(memdev, "host"),
+				jsonString(memdev, "serial"),
+				jsonString(memdev, "ram_size"),
+				jsonString(memdev, "pmem_size"),
+				jsonString(memdev, "numa_node"),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newMemoryBriefTable(tableMemory *Table, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "Memory",
@@ -1291,6 +2333,97 @@ func newPCIeSlotsTable(sources []*Source, category TableCategory) (table *Table)
 	return
 }
 
+// newCloudInstanceTable compares cloud instances to the published specification of their
+// instance type to detect degraded or mis-provisioned instances
+func newCloudInstanceTable(sources []*Source, tableCPU *Table, instanceTypes InstanceTypes, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Cloud Instance",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIdx, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Provider",
+				"Instance Type",
+				"Region",
+				"vCPUs",
+				"Expected vCPUs",
+				"Memory",
+				"Expected Memory",
+				"Local NVMe",
+				"Expected Local NVMe",
+				"Network Drivers",
+				"Expected Network",
+				"Status",
+				"Discrepancies",
+			},
+			Values: [][]string{},
+		}
+		provider := source.valFromRegexSubmatch("cloud instance", `^provider:\s*(.+?)$`)
+		instanceType := source.valFromRegexSubmatch("cloud instance", `^instance_type:\s*(.+?)$`)
+		if provider == "" || instanceType == "" {
+			table.AllHostValues = append(table.AllHostValues, hostValues)
+			continue
+		}
+		instance := cloudInstance{provider: provider, instanceType: instanceType}
+		cpus, _ := tableCPU.getValue(sourceIdx, "CPUs")
+		instance.vCPUs, _ = strconv.Atoi(cpus)
+		if memTotal, err := strconv.ParseFloat(source.valFromRegexSubmatch("/proc/meminfo", `^MemTotal:\s*(\d+) kB$`), 64); err == nil {
+			instance.memory = memTotal / (1024 * 1024)
+		}
+		for i, line := range source.getCommandOutputLines("lsblk -r -o") {
+			fields := strings.Split(line, " ")
+			if i == 0 || len(fields) < 2 || !reNVMeNamespaceDevice.MatchString(fields[0]) {
+				continue
+			}
+			if localNVMeModels[provider] != nil && localNVMeModels[provider].MatchString(strings.ReplaceAll(fields[1], `\x20`, " ")) {
+				instance.nvme++
+			}
+		}
+		for _, driver := range source.valsFromRegexSubmatch("nic info", `^\s*driver:\s*(\S+)`) {
+			if !util.StringInList(driver, instance.drivers) {
+				instance.drivers = append(instance.drivers, driver)
+			}
+		}
+		var memory, expectedVCPUs, expectedMemory, expectedNVMe, expectedNetwork, status, discrepancies string
+		if instance.memory != 0 {
+			memory = fmt.Sprintf("%.1f GiB", instance.memory)
+		}
+		if spec := instanceTypes.find(provider, instanceType); spec != nil {
+			expectedVCPUs = strconv.Itoa(spec.VCPUs)
+			expectedMemory = strconv.FormatFloat(spec.Memory, 'f', -1, 64) + " GiB"
+			expectedNVMe = strconv.Itoa(spec.NVMe)
+			expectedNetwork = formatInstanceNetwork(spec)
+			status = "Match"
+			if found := checkInstance(instance, spec); len(found) > 0 {
+				status = "Mismatch"
+				discrepancies = strings.Join(found, "; ")
+			}
+		} else {
+			status = "Unknown Instance Type"
+		}
+		hostValues.Values = append(hostValues.Values, []string{
+			provider,
+			instanceType,
+			source.valFromRegexSubmatch("cloud instance", `^region:\s*(.+?)$`),
+			cpus,
+			expectedVCPUs,
+			memory,
+			expectedMemory,
+			strconv.Itoa(instance.nvme),
+			expectedNVMe,
+			strings.Join(instance.drivers, ", "),
+			expectedNetwork,
+			status,
+			discrepancies,
+		})
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newDIMMPopulationTable(sources []*Source, dimmTable *Table, cpusInfo *cpu.CPU, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "DIMM Population",
@@ -1399,6 +2532,151 @@ func newBenchmarkSummaryTable(sources []*Source, tableMemBandwidthLatency *Table
 	return
 }
 
+// newBenchmarkRegressionTable compares the benchmark summary of each run of a host to
+// the host's previous run and lists the metrics that regressed by more than the
+// threshold percentage, along with the configuration that changed between the runs
+func newBenchmarkRegressionTable(sources []*Source, tableSummary *Table, configReport *Report, threshold float64, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Benchmark Regression",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIndex, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Metric",
+				"Baseline",
+				"Baseline Value",
+				"Value",
+				"Change",
+				"Changed Configuration",
+			},
+			Values: [][]string{},
+		}
+		baselineIndex := -1
+		for i, s := range sources {
+			if source.runOf != "" && s.runOf == source.runOf && s.run == source.run-1 {
+				baselineIndex = i
+				break
+			}
+		}
+		if baselineIndex >= 0 {
+			var changedConfiguration string
+			for _, metric := range benchmarkMetrics {
+				baselineValue, _ := tableSummary.getValue(baselineIndex, metric.name)
+				currentValue, _ := tableSummary.getValue(sourceIndex, metric.name)
+				a, okA := getBenchmarkMetricValue(baselineValue)
+				b, okB := getBenchmarkMetricValue(currentValue)
+				if !okA || !okB || a == 0 {
+					continue
+				}
+				change := (b - a) / a * 100
+				if (metric.higherIsBetter && change >= -threshold) || (!metric.higherIsBetter && change <= threshold) {
+					continue
+				}
+				if changedConfiguration == "" {
+					changedConfiguration = strings.Join(getChangedConfiguration(configReport, baselineIndex, sourceIndex), ", ")
+				}
+				hostValues.Values = append(hostValues.Values, []string{
+					metric.name,
+					sources[baselineIndex].getHostname(),
+					baselineValue,
+					currentValue,
+					fmt.Sprintf("%+.1f%%", change),
+					changedConfiguration,
+				})
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newNICThroughputTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "NIC Throughput",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	modes := map[string]string{"iperf3": "Pair (iperf3)", "pktgen": "Loopback (pktgen TX)"}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Name",
+				"Mode",
+				"Rated Speed (Gbps)",
+				"Achieved (Gbps)",
+				"Line Rate (%)",
+				"Status",
+			},
+			Values: [][]string{},
+		}
+		for _, nic := range parseNICThroughput(source.getCommandOutputLines("NIC Throughput Test")) {
+			var rated, achieved, percent, status string
+			if nic.ratedMbps > 0 {
+				rated = fmt.Sprintf("%.1f", float64(nic.ratedMbps)/1000)
+			}
+			if nic.mbps > 0 {
+				achieved = fmt.Sprintf("%.2f", nic.mbps/1000)
+			}
+			switch {
+			case nic.err != "":
+				status = nic.err
+			case nic.mbps == 0:
+				status = "No result"
+			case nic.ratedMbps == 0:
+				status = "Rated speed unknown"
+			default:
+				ratio := nic.mbps / float64(nic.ratedMbps) * 100
+				percent = fmt.Sprintf("%.1f", ratio)
+				status = "OK"
+				if ratio < nicLineRatePercent {
+					status = "Below line rate"
+				}
+			}
+			mode := modes[nic.mode]
+			if mode == "" {
+				mode = nic.mode
+			}
+			hostValues.Values = append(hostValues.Values, []string{nic.name, mode, rated, achieved, percent, status})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newBenchmarkRawOutputTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Raw Output",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Benchmark",
+				"Tool",
+				"Exit Status",
+				"File",
+			},
+			Values: [][]string{},
+		}
+		for _, file := range getBenchmarkRawFiles(source) {
+			hostValues.Values = append(hostValues.Values, []string{
+				file.label,
+				file.tool,
+				file.exitStatus,
+				file.path,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newDiskTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "Disk",
@@ -1420,66 +2698,322 @@ func newDiskTable(sources []*Source, category TableCategory) (table *Table) {
 			},
 			Values: [][]string{},
 		}
-		for i, line := range source.getCommandOutputLines("lsblk -r -o") {
-			fields := strings.Split(line, " ")
-			if len(fields) != len(hostValues.ValueNames)-1 {
-				log.Printf("lsblk field count mismatch: %s", strings.Join(fields, ","))
-				continue
-			}
-			if i == 0 { // headers are in the first line
-				for idx, field := range fields {
-					if field != hostValues.ValueNames[idx] {
-						log.Printf("lsblk field name mismatch: %s", strings.Join(fields, ","))
-						break
-					}
-				}
-				continue
-			}
-			// clean up the model name
-			fields[1] = strings.ReplaceAll(fields[1], `\x20`, " ")
-			fields[1] = strings.TrimSpace(fields[1])
-			fields = append(fields, source.getDiskFwRev(fields[0]))
-			hostValues.Values = append(hostValues.Values, fields)
+		for i, line := range source.getCommandOutputLines("lsblk -r -o") {
+			fields := strings.Split(line, " ")
+			if len(fields) != len(hostValues.ValueNames)-1 {
+				log.Printf("lsblk field count mismatch: %s", strings.Join(fields, ","))
+				continue
+			}
+			if i == 0 { // headers are in the first line
+				for idx, field := range fields {
+					if field != hostValues.ValueNames[idx] {
+						log.Printf("lsblk field name mismatch: %s", strings.Join(fields, ","))
+						break
+					}
+				}
+				continue
+			}
+			// clean up the model name
+			fields[1] = strings.ReplaceAll(fields[1], `\x20`, " ")
+			fields[1] = strings.TrimSpace(fields[1])
+			fields = append(fields, source.getDiskFwRev(fields[0]))
+			hostValues.Values = append(hostValues.Values, fields)
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newDiskSummaryTable(tableDisk *Table, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Disk",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, hv := range tableDisk.AllHostValues {
+		modelValIdx := 1
+		sizeValIdx := 2
+		var modelSizeCount = make(map[string]int)
+		for _, disk := range hv.Values {
+			model := disk[modelValIdx]
+			if model != "" {
+				size := disk[sizeValIdx]
+				modelSize := strings.Join([]string{model, size}, ",")
+				if _, ok := modelSizeCount[modelSize]; !ok {
+					modelSizeCount[modelSize] = 0
+				}
+				modelSizeCount[modelSize] += 1
+			}
+		}
+		var summaryParts []string
+		for modelSize, count := range modelSizeCount {
+			tokens := strings.Split(modelSize, ",")
+			model := tokens[0]
+			size := tokens[1]
+			summaryParts = append(summaryParts, fmt.Sprintf("%dx %s %s", count, size, model))
+		}
+		var summaryHv = HostValues{
+			Name:       hv.Name,
+			ValueNames: []string{"Disk"},
+			Values:     [][]string{{strings.Join(summaryParts, ", ")}},
+		}
+		table.AllHostValues = append(table.AllHostValues, summaryHv)
+	}
+	return
+}
+
+func newNVMeHealthTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "NVMe Health",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Device",
+				"Model",
+				"Firmware",
+				"Temperature (C)",
+				"Critical Warning",
+				"Percent Used",
+				"Available Spare (%)",
+				"Host Written (TB)",
+				"Media Written (TB)",
+				"Write Amplification",
+				"Media Errors",
+				"Uncorrectable Read Errors",
+				"Bad NAND Blocks",
+				"Power On Hours",
+				"Unsafe Shutdowns",
+				"Warning Temperature Time (min)",
+				"Thermal Management Transitions",
+				"Thermal Management Time (s)",
+				"Thermal Throttling Events",
+				"Thermal Throttling Status",
+			},
+			Values: [][]string{},
+		}
+		controllers, _ := parseNVMe(source.getCommandOutputSections("nvme"))
+		for _, controller := range controllers {
+			value := func(key string) string {
+				if v, ok := getNVMeValue(controller.smart, key); ok {
+					return strconv.FormatFloat(v, 'f', -1, 64)
+				}
+				return ""
+			}
+			sum := func(keys ...string) string {
+				var total float64
+				for _, key := range keys {
+					v, ok := getNVMeValue(controller.smart, key)
+					if !ok {
+						return ""
+					}
+					total += v
+				}
+				return strconv.FormatFloat(total, 'f', -1, 64)
+			}
+			var temperature, criticalWarning, percentUsed, hostWritten, mediaWritten, writeAmplification string
+			if kelvin, ok := getNVMeValue(controller.smart, "temperature"); ok {
+				temperature = fmt.Sprintf("%.0f", kelvin-273.15)
+			}
+			if warning, ok := getNVMeValue(controller.smart, "critical_warning"); ok {
+				criticalWarning = "None"
+				if warning != 0 {
+					criticalWarning = fmt.Sprintf("0x%x", int(warning))
+				}
+			}
+			if used, ok := getNVMeValue(controller.smart, "percent_used"); ok {
+				percentUsed = fmt.Sprintf("%.0f%%", used)
+			}
+			// data units are thousands of 512 byte units
+			hostBytes, hostOK := getNVMeValue(controller.smart, "data_units_written")
+			hostBytes *= 512000
+			if hostOK {
+				hostWritten = fmt.Sprintf("%.2f", hostBytes/1e12)
+			}
+			var uncorrectable, badBlocks, throttlingEvents, throttlingStatus string
+			if ocp, ok := parseOCPSmartLog(controller.ocpLog); ok {
+				mediaWritten = fmt.Sprintf("%.2f", ocp.mediaBytesWritten/1e12)
+				if hostOK && hostBytes > 0 {
+					writeAmplification = fmt.Sprintf("%.2f", ocp.mediaBytesWritten/hostBytes)
+				}
+				uncorrectable = strconv.FormatUint(ocp.uncorrectableReadErrors, 10)
+				badBlocks = strconv.FormatUint(ocp.badUserNANDBlocks, 10)
+				throttlingEvents = strconv.Itoa(ocp.throttlingEvents)
+				throttlingStatus = "Unthrottled"
+				if ocp.throttlingStatus != 0 {
+					throttlingStatus = fmt.Sprintf("Throttled (0x%02x)", ocp.throttlingStatus)
+				}
+			}
+			model, _ := controller.idCtrl["mn"].(string)
+			firmware, _ := controller.idCtrl["fr"].(string)
+			hostValues.Values = append(hostValues.Values, []string{
+				controller.name,
+				strings.TrimSpace(model),
+				strings.TrimSpace(firmware),
+				temperature,
+				criticalWarning,
+				percentUsed,
+				value("avail_spare"),
+				hostWritten,
+				mediaWritten,
+				writeAmplification,
+				value("media_errors"),
+				uncorrectable,
+				badBlocks,
+				value("power_on_hours"),
+				value("unsafe_shutdowns"),
+				sum("warning_temp_time", "critical_comp_time"),
+				sum("thm_temp1_trans_count", "thm_temp2_trans_count"),
+				sum("thm_temp1_total_time", "thm_temp2_total_time"),
+				throttlingEvents,
+				throttlingStatus,
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newNVMeNamespaceTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "NVMe Namespace",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	performance := []string{"Best", "Better", "Good", "Degraded"}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Namespace",
+				"Size",
+				"LBA Format",
+				"Metadata (bytes)",
+				"Relative Performance",
+				"Supported Formats",
+				"Format Efficiency",
+			},
+			Values: [][]string{},
+		}
+		_, namespaces := parseNVMe(source.getCommandOutputSections("nvme"))
+		for _, namespace := range namespaces {
+			formats, current := getNVMeLBAFormats(namespace.idNS)
+			var size, lbaFormat, metadata, relativePerformance string
+			var supported []string
+			for i, format := range formats {
+				supported = append(supported, fmt.Sprintf("%d+%d", format.dataSize, format.metadataSize))
+				if i != current {
+					continue
+				}
+				lbaFormat = fmt.Sprintf("%d bytes", format.dataSize)
+				metadata = strconv.Itoa(format.metadataSize)
+				if format.performance < len(performance) {
+					relativePerformance = performance[format.performance]
+				}
+				if blocks, ok := getNVMeValue(namespace.idNS, "nsze"); ok {
+					size = formatQuantity(blocks*float64(format.dataSize), UnitBytes)
+				}
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				namespace.name,
+				size,
+				lbaFormat,
+				metadata,
+				relativePerformance,
+				strings.Join(supported, ", "),
+				getNVMeFormatEfficiency(formats, current),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newZonedStorageTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Zoned Storage",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	limit := func(value string) string {
+		if value == "0" {
+			return "No limit"
+		}
+		return value
+	}
+	size := func(value string, multiplier float64) string {
+		if quantity, err := strconv.ParseFloat(value, 64); err == nil && quantity > 0 {
+			return formatQuantity(quantity*multiplier, UnitBytes)
+		}
+		return ""
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Device",
+				"Zone Model",
+				"Zone Size",
+				"Zones",
+				"Max Open Zones",
+				"Max Active Zones",
+				"Max Zone Append",
+			},
+			Values: [][]string{},
+		}
+		for _, device := range parseZonedDevices(source.getCommandOutputLines("zoned block devices")) {
+			hostValues.Values = append(hostValues.Values, []string{
+				device.name,
+				device.attributes["zoned"],
+				size(device.attributes["chunk_sectors"], 512),
+				device.attributes["nr_zones"],
+				limit(device.attributes["max_open_zones"]),
+				limit(device.attributes["max_active_zones"]),
+				size(device.attributes["zone_append_max_bytes"], 1),
+			})
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newDiskSummaryTable(tableDisk *Table, category TableCategory) (table *Table) {
+func newComputationalStorageTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Disk",
+		Name:          "Computational Storage",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
-	for _, hv := range tableDisk.AllHostValues {
-		modelValIdx := 1
-		sizeValIdx := 2
-		var modelSizeCount = make(map[string]int)
-		for _, disk := range hv.Values {
-			model := disk[modelValIdx]
-			if model != "" {
-				size := disk[sizeValIdx]
-				modelSize := strings.Join([]string{model, size}, ",")
-				if _, ok := modelSizeCount[modelSize]; !ok {
-					modelSizeCount[modelSize] = 0
-				}
-				modelSizeCount[modelSize] += 1
-			}
-		}
-		var summaryParts []string
-		for modelSize, count := range modelSizeCount {
-			tokens := strings.Split(modelSize, ",")
-			model := tokens[0]
-			size := tokens[1]
-			summaryParts = append(summaryParts, fmt.Sprintf("%dx %s %s", count, size, model))
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Slot",
+				"Type",
+				"Vendor",
+				"Device",
+				"Subsystem",
+				"NUMANode",
+			},
+			Values: [][]string{},
 		}
-		var summaryHv = HostValues{
-			Name:       hv.Name,
-			ValueNames: []string{"Disk"},
-			Values:     [][]string{{strings.Join(summaryParts, ", ")}},
+		for _, device := range source.getAllPCIDevices() {
+			deviceType := getComputationalStorageType(device)
+			if deviceType == "" {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				device["Slot"],
+				deviceType,
+				device["Vendor"],
+				device["Device"],
+				strings.TrimSpace(device["SVendor"] + " " + device["SDevice"]),
+				device["NUMANode"],
+			})
 		}
-		table.AllHostValues = append(table.AllHostValues, summaryHv)
+		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
@@ -1884,16 +3418,235 @@ func newIRQRateTable(sources []*Source, category TableCategory) (table *Table) {
 			if len(match) == 0 {
 				continue
 			}
-			hostValues.Values = append(hostValues.Values, match[1:])
+			hostValues.Values = append(hostValues.Values, match[1:])
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newDriveStatsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Drive Stats",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Device",
+				"tps",
+				"kB_read/s",
+				"kB_wrtn/s",
+				"kB_dscd/s",
+			},
+			Values: [][]string{},
+		}
+		// don't capture the last three vals: "kB_read","kB_wrtn","kB_dscd" -- they aren't the same scale as the others
+		reStat := regexp.MustCompile(`^(\w+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*\d+\s*\d+\s*\d+$`)
+		for _, line := range source.getProfileLines("iostat") {
+			match := reStat.FindStringSubmatch(line)
+			if len(match) == 0 {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, match[1:])
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newNetworkStatsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Network Stats",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Time",
+				"IFACE",
+				"rxpck/s",
+				"txpck/s",
+				"rxkB/s",
+				"txkB/s",
+			},
+			Values: [][]string{},
+		}
+		// don't capture the last four vals: "rxcmp/s","txcmp/s","rxcmt/s","%ifutil" -- obscure more important vals
+		reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\w*)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+$`)
+		for _, line := range source.getProfileLines("sar-network") {
+			match := reStat.FindStringSubmatch(line)
+			if len(match) == 0 {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, match[1:])
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newMemoryStatsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Memory Stats",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Time",
+				"free",
+				"avail",
+				"used",
+				"buffers",
+				"cached",
+				"commit",
+				"active",
+				"inactive",
+				"dirty",
+			},
+			Values: [][]string{},
+		}
+		reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)$`)
+		for _, line := range source.getProfileLines("sar-memory") {
+			match := reStat.FindStringSubmatch(line)
+			if len(match) == 0 {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, match[1:])
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newPowerStatsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Power Stats",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Package",
+				"DRAM",
+			},
+			Values: [][]string{},
+		}
+		reStat := regexp.MustCompile(`^(\d+\.\d+)\s*(\d+\.\d+)$`)
+		for _, line := range source.getProfileLines("turbostat") {
+			match := reStat.FindStringSubmatch(line)
+			if len(match) == 0 {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, match[1:])
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newBpftraceHistogramTable creates a table from the hist() map in the profile section
+func newBpftraceHistogramTable(sources []*Source, category TableCategory, name string, section string) (table *Table) {
+	table = &Table{
+		Name:          name,
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Latency (us)",
+				"Count",
+			},
+			Values: [][]string{},
+		}
+		hostValues.Values = append(hostValues.Values, parseBpftraceHistogram(source.getProfileLines(section))...)
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newRunQueueLatencyTable(sources []*Source, category TableCategory) (table *Table) {
+	return newBpftraceHistogramTable(sources, category, "Run Queue Latency", "runqlat")
+}
+
+func newBlockIOLatencyTable(sources []*Source, category TableCategory) (table *Table) {
+	return newBpftraceHistogramTable(sources, category, "Block IO Latency", "biolatency")
+}
+
+func newSchedulingLatencyTable(sources []*Source, runQueueLatencyTable *Table, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Scheduling Latency",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIdx, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"P50 (us)",
+				"P90 (us)",
+				"P99 (us)",
+				"P99.9 (us)",
+				"Max (us)",
+				"Samples",
+			},
+			Values: [][]string{},
+		}
+		// percentiles are the upper bounds of the run queue latency histogram buckets
+		percentiles, total, err := getHistogramPercentiles(runQueueLatencyTable.AllHostValues[sourceIdx].Values, []float64{50, 90, 99, 99.9, 100})
+		if err == nil {
+			var values []string
+			for _, percentile := range percentiles {
+				values = append(values, strconv.FormatInt(percentile, 10))
+			}
+			hostValues.Values = append(hostValues.Values, append(values, strconv.FormatInt(total, 10)))
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newOffCPUTimeTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Off-CPU Time",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Process",
+				"Off-CPU Time (ms)",
+			},
+			Values: [][]string{},
+		}
+		for _, entry := range parseBpftraceMap(source.getProfileLines("offcputime")) {
+			usecs, err := strconv.ParseFloat(entry[1], 64)
+			if err != nil {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, []string{entry[0], fmt.Sprintf("%.1f", usecs/1000)})
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newDriveStatsTable(sources []*Source, category TableCategory) (table *Table) {
+func newTCPRetransmitsTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Drive Stats",
+		Name:          "TCP Retransmits",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
@@ -1901,64 +3654,73 @@ func newDriveStatsTable(sources []*Source, category TableCategory) (table *Table
 		var hostValues = HostValues{
 			Name: source.getHostname(),
 			ValueNames: []string{
-				"Device",
-				"tps",
-				"kB_read/s",
-				"kB_wrtn/s",
-				"kB_dscd/s",
+				"Remote Address",
+				"Remote Port",
+				"Retransmits",
 			},
 			Values: [][]string{},
 		}
-		// don't capture the last three vals: "kB_read","kB_wrtn","kB_dscd" -- they aren't the same scale as the others
-		reStat := regexp.MustCompile(`^(\w+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*\d+\s*\d+\s*\d+$`)
-		for _, line := range source.getProfileLines("iostat") {
-			match := reStat.FindStringSubmatch(line)
-			if len(match) == 0 {
+		for _, entry := range parseBpftraceMap(source.getProfileLines("tcpretrans")) {
+			// key is "address, port"
+			idx := strings.LastIndex(entry[0], ", ")
+			if idx == -1 {
 				continue
 			}
-			hostValues.Values = append(hostValues.Values, match[1:])
+			hostValues.Values = append(hostValues.Values, []string{normalizeIPAddress(entry[0][:idx]), entry[0][idx+2:], entry[1]})
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newNetworkStatsTable(sources []*Source, category TableCategory) (table *Table) {
+func newCStateResidencyTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Network Stats",
+		Name:          "C-state Residency",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
+	reState := regexp.MustCompile(`^C(\d+)$`)
 	for _, source := range sources {
 		var hostValues = HostValues{
 			Name: source.getHostname(),
 			ValueNames: []string{
-				"Time",
-				"IFACE",
-				"rxpck/s",
-				"txpck/s",
-				"rxkB/s",
-				"txkB/s",
+				"State",
+				"Enabled CPUs",
+				"Entries",
+				"Requested Residency (%)",
+				"Core Residency (%)",
+				"Status",
 			},
 			Values: [][]string{},
 		}
-		// don't capture the last four vals: "rxcmp/s","txcmp/s","rxcmt/s","%ifutil" -- obscure more important vals
-		reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\w*)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*(\d+.\d+)\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+\s*\d+.\d+$`)
-		for _, line := range source.getProfileLines("sar-network") {
-			match := reStat.FindStringSubmatch(line)
-			if len(match) == 0 {
-				continue
+		states, numCPUs, seconds := parseCPUIdleCounters(source.getProfileLines("cpuidle"))
+		if numCPUs > 0 && seconds > 0 {
+			// residency reached by the cores, e.g., CPU%c6, is measured by turbostat
+			turbostat := getTurbostatSummary(source.getProfileLines("residency"))
+			for _, state := range states {
+				requested := float64(state.timeUs) / (seconds * 1000000 * float64(numCPUs)) * 100
+				var reached string
+				if match := reState.FindStringSubmatch(state.name); match != nil {
+					reached = turbostat["CPU%c"+match[1]]
+				}
+				hostValues.Values = append(hostValues.Values, []string{
+					state.name,
+					strconv.Itoa(state.enabledCPUs),
+					strconv.FormatInt(state.entries, 10),
+					fmt.Sprintf("%.2f", requested),
+					reached,
+					getCStateStatus(state, requested, reached),
+				})
 			}
-			hostValues.Values = append(hostValues.Values, match[1:])
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newMemoryStatsTable(sources []*Source, category TableCategory) (table *Table) {
+func newPStateDistributionTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Memory Stats",
+		Name:          "P-state Distribution",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
@@ -1966,35 +3728,43 @@ func newMemoryStatsTable(sources []*Source, category TableCategory) (table *Tabl
 		var hostValues = HostValues{
 			Name: source.getHostname(),
 			ValueNames: []string{
-				"Time",
-				"free",
-				"avail",
-				"used",
-				"buffers",
-				"cached",
-				"commit",
-				"active",
-				"inactive",
-				"dirty",
+				"Frequency (MHz)",
+				"Samples",
 			},
 			Values: [][]string{},
 		}
-		reStat := regexp.MustCompile(`^(\d+:\d+:\d+)\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)\s*\d+\.\d+\s*(\d+)\s*(\d+)\s*(\d+)$`)
-		for _, line := range source.getProfileLines("sar-memory") {
-			match := reStat.FindStringSubmatch(line)
-			if len(match) == 0 {
-				continue
-			}
-			hostValues.Values = append(hostValues.Values, match[1:])
+		hostValues.Values = append(hostValues.Values, getFrequencyDistribution(source.getProfileLines("cpufreq"))...)
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+func newEnergyPerformancePreferenceTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Energy Performance Preference",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"EPP",
+				"CPUs",
+			},
+			Values: [][]string{},
 		}
+		hostValues.Values = append(hostValues.Values, getEPPCounts(source.getProfileLines("cpufreq"))...)
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
 
-func newPowerStatsTable(sources []*Source, category TableCategory) (table *Table) {
+// newWorkloadFingerprintTable summarizes what the system was doing during the profiling
+// window, not just what the system is
+func newWorkloadFingerprintTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
-		Name:          "Power Stats",
+		Name:          "Workload Fingerprint",
 		Category:      category,
 		AllHostValues: []HostValues{},
 	}
@@ -2002,23 +3772,64 @@ func newPowerStatsTable(sources []*Source, category TableCategory) (table *Table
 		var hostValues = HostValues{
 			Name: source.getHostname(),
 			ValueNames: []string{
-				"Package",
-				"DRAM",
+				"Character",
+				"CPU Busy (%)",
+				"IO Wait (%)",
+				"Runnable Threads (avg/max)",
+				"Blocked Threads (avg)",
+				"Threads (avg/max)",
+				"Context Switches (/s)",
+				"Forks (/s)",
+				"Syscalls (/s)",
+				"Top Syscalls",
+				"Major Faults (/s)",
+				"Swapped Pages (/s)",
+				"TMA Memory Bound (%)",
 			},
 			Values: [][]string{},
 		}
-		reStat := regexp.MustCompile(`^(\d+\.\d+)\s*(\d+\.\d+)$`)
-		for _, line := range source.getProfileLines("turbostat") {
-			match := reStat.FindStringSubmatch(line)
-			if len(match) == 0 {
-				continue
+		fp, ok := parseWorkload(source.getProfileLines("workload"))
+		if ok {
+			var memoryBound float64
+			var memoryBoundValue string
+			_, metrics := parseTopdown(source.getCommandOutputLines("topdown"))
+			var measured int
+			for _, socket := range metrics {
+				if value, ok := socket["memory_bound"]; ok {
+					memoryBound += value
+					measured++
+				}
 			}
-			hostValues.Values = append(hostValues.Values, match[1:])
+			if measured > 0 {
+				memoryBound /= float64(measured)
+				memoryBoundValue = fmt.Sprintf("%.1f", memoryBound)
+			}
+			syscallRate, topSyscalls := parseSyscalls(source.getProfileLines("syscallrate"), source.getProfileLines("syscalls"), fp.seconds)
+			var syscallRateValue string
+			if syscallRate > 0 {
+				syscallRateValue = fmt.Sprintf("%.0f", syscallRate)
+			}
+			hostValues.Values = append(hostValues.Values, []string{
+				getWorkloadCharacter(fp, memoryBound),
+				fmt.Sprintf("%.1f", fp.cpuBusy),
+				fmt.Sprintf("%.1f", fp.ioWait),
+				fmt.Sprintf("%.1f/%d", fp.runningAvg, fp.runningMax),
+				fmt.Sprintf("%.1f", fp.blockedAvg),
+				fmt.Sprintf("%.0f/%d", fp.threadsAvg, fp.threadsMax),
+				fmt.Sprintf("%.0f", fp.contextSwitches),
+				fmt.Sprintf("%.1f", fp.forks),
+				syscallRateValue,
+				strings.Join(topSyscalls, ", "),
+				fmt.Sprintf("%.1f", fp.majorFaults),
+				fmt.Sprintf("%.1f", fp.swapPages),
+				memoryBoundValue,
+			})
 		}
 		table.AllHostValues = append(table.AllHostValues, hostValues)
 	}
 	return
 }
+
 func newProfileSummaryTable(sources []*Source, category TableCategory, averageCPUUtilizationTable, CPUUtilizationTable, IRQRateTable, driveStatsTable, netStatsTable, memStatsTable, PMUMetricsTable, powerStatsTable *Table) (table *Table) {
 	table = &Table{
 		Name:          "Summary",
@@ -2066,6 +3877,85 @@ func newProfileSummaryTable(sources []*Source, category TableCategory, averageCP
 	return
 }
 
+// newEventTimelineTables lists the intervals, within the profiling window, where CPU utilization
+// or IO wait spiked or the CPU frequency dropped, annotated with the processes that were
+// consuming the most CPU, and the hottest code paths, during the interval. The second
+// table holds the call stacks sampled during each event.
+func newEventTimelineTables(sources []*Source, category TableCategory, averageCPUUtilizationTable *Table) (table *Table, codePathsTable *Table) {
+	table = &Table{
+		Name:          "Event Timeline",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	codePathsTable = &Table{
+		Name:          "Event Code Paths",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for idx, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Start",
+				"End",
+				"Offset (s)",
+				"Duration (s)",
+				"Event",
+				"Peak",
+				"Top Processes",
+				"Hot Code Paths",
+			},
+			Values: [][]string{},
+		}
+		var codePathsValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Start",
+				"Event",
+				"Stacks",
+			},
+			Values: [][]string{},
+		}
+		clock := source.getProfileClock()
+		processSamples := getProcessCPUSamples(source, clock)
+		stacks := source.getProfileStacks()
+		var events []timelineEvent
+		utilization := getProfileSamples(averageCPUUtilizationTable, idx, "%idle", true, clock)
+		for _, run := range findSpikes(utilization, 50) {
+			events = append(events, newTimelineEvent(run, "CPU utilization spike", "%", false, processSamples, stacks, clock))
+		}
+		iowait := getProfileSamples(averageCPUUtilizationTable, idx, "%iowait", false, clock)
+		for _, run := range findSpikes(iowait, 10) {
+			events = append(events, newTimelineEvent(run, "IO wait spike", "%", false, processSamples, stacks, clock))
+		}
+		frequency := getPMUMetricSamples(source, "CPU operating frequency (in GHz)", clock)
+		for _, run := range findDips(frequency, 0.9) {
+			events = append(events, newTimelineEvent(run, "CPU frequency drop (possible throttling)", " GHz", true, processSamples, stacks, clock))
+		}
+		sort.SliceStable(events, func(i, j int) bool { return events[i].start < events[j].start })
+		for _, event := range events {
+			start := clock.format(event.start)
+			hostValues.Values = append(hostValues.Values, []string{
+				start,
+				clock.format(event.end),
+				// a sample covers the interval that ends at its time
+				fmt.Sprintf("%d", event.start-clock.interval-clock.start),
+				fmt.Sprintf("%d", event.end-event.start+clock.interval),
+				event.event,
+				event.peak,
+				event.top,
+				getHotCodePaths(event.folded, 3),
+			})
+			if event.folded != "" {
+				codePathsValues.Values = append(codePathsValues.Values, []string{start, event.event, event.folded})
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+		codePathsTable.AllHostValues = append(codePathsTable.AllHostValues, codePathsValues)
+	}
+	return
+}
+
 func newFeatureTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "Feature",
@@ -2152,19 +4042,27 @@ func newCodePathTable(sources []*Source, category TableCategory) (table *Table)
 	return
 }
 
-func newInsightTable(sources []*Source, configReport, briefReport, profileReport, benchmarkReport *Report, analyzeReport *Report, cpusInfo *cpu.CPU) (table *Table) {
+// newInsightTables returns the Insight table and the Remediation table, i.e., the
+// commands that implement the insights' recommendations
+func newInsightTables(sources []*Source, configReport, briefReport, profileReport, benchmarkReport *Report, analyzeReport *Report, cpusInfo *cpu.CPU) (table *Table, remediationTable *Table) {
 	table = &Table{
 		Name:          "Insight",
 		Category:      NoCategory,
 		AllHostValues: []HostValues{},
 	}
+	remediationTable = &Table{
+		Name:          "Remediation",
+		Category:      NoCategory,
+		AllHostValues: []HostValues{},
+	}
 	var gruleEngine *engine.GruleEngine
 	var knowledgeBase *ast.KnowledgeBase
 	var dataContext ast.IDataContext
 	rulesEngineContext := &RulesEngineContext{
-		insightTable: table,
-		reportsData:  []*Report{configReport, briefReport, profileReport, benchmarkReport, analyzeReport},
-		sourceIdx:    0, // will be incremented while looping through sources below
+		insightTable:     table,
+		remediationTable: remediationTable,
+		reportsData:      []*Report{configReport, briefReport, profileReport, benchmarkReport, analyzeReport},
+		sourceIdx:        0, // will be incremented while looping through sources below
 	}
 	gruleEngine = &engine.GruleEngine{MaxCycle: 500}
 	rules, err := getInsightsRules()
@@ -2207,6 +4105,14 @@ func newInsightTable(sources []*Source, configReport, briefReport, profileReport
 			},
 		}
 		table.AllHostValues = append(table.AllHostValues, hv)
+		remediationTable.AllHostValues = append(remediationTable.AllHostValues, HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Recommendation",
+				"Justification",
+				"Commands",
+			},
+		})
 		if knowledgeBase != nil {
 			rulesEngineContext.sourceIdx = sourceIdx
 			err = gruleEngine.Execute(dataContext, knowledgeBase)
@@ -2242,6 +4148,238 @@ func newSvrinfoTable(sources []*Source, category TableCategory) (table *Table) {
 	return
 }
 
+// newQuantityTable lists the sizes, frequencies, and speeds saved by the collector in
+// canonical units, with their display form
+func newQuantityTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Quantities",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Name",
+				"Value",
+				"Unit",
+				"Display",
+			},
+			Values: [][]string{},
+		}
+		for _, q := range source.getQuantities() {
+			hostValues.Values = append(hostValues.Values, []string{
+				q.name,
+				strconv.FormatFloat(q.value, 'f', -1, 64),
+				q.unit,
+				formatQuantity(q.value, q.unit),
+			})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newToolChecksumsTable lists the version and SHA-256 hash of the reporter, the svr-info
+// components that collected the data, and the tools that ran on the host, so that
+// results can be tied to exact tool versions
+func newToolChecksumsTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Tool Checksums",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	reporterHash := "unknown"
+	if path, err := os.Executable(); err == nil {
+		if hash, err := util.FileSHA256(path); err == nil {
+			reporterHash = hash
+		}
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Tool",
+				"Version",
+				"SHA-256",
+				"Location",
+			},
+			Values: [][]string{{"reporter", gVersion, reporterHash, "running"}},
+		}
+		for _, location := range []struct{ label, name string }{{"svr-info components", "svr-info"}, {"tool checksums", "target"}} {
+			for _, line := range source.getCommandOutputLines(location.label) {
+				fields := strings.Fields(line)
+				if len(fields) < 3 {
+					continue
+				}
+				hostValues.Values = append(hostValues.Values, []string{
+					fields[0],
+					strings.Join(fields[1:len(fields)-1], " "),
+					fields[len(fields)-1],
+					location.name,
+				})
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newPrivilegesTable lists the commands that ran with elevated privileges and how they
+// were elevated, i.e., as root, with sudo, or with only the listed capabilities
+func newPrivilegesTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Privileges",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Command",
+				"Privileges",
+				"Capabilities",
+				"Exit Status",
+			},
+			Values: [][]string{},
+		}
+		var labels []string
+		for label := range source.ParsedData {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			data := source.ParsedData[label]
+			if data.Privileges == "" || data.Privileges == "user" {
+				continue
+			}
+			hostValues.Values = append(hostValues.Values, []string{label, data.Privileges, data.Capabilities, data.ExitStatus})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newReadOnlySkippedTable lists the commands the collector skipped in read-only mode
+// and why
+func newReadOnlySkippedTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Read-only Skipped Commands",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Command",
+				"Reason",
+			},
+			Values: [][]string{},
+		}
+		for _, line := range source.getCommandOutputLines(readOnlyLabel) {
+			command, reason, found := strings.Cut(line, ": ")
+			if found {
+				hostValues.Values = append(hostValues.Values, []string{command, reason})
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newContainerSkippedTable lists the commands the collector skipped because the host
+// hardware they read isn't accessible from the container it ran in
+func newContainerSkippedTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Container Skipped Commands",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Command",
+				"Reason",
+			},
+			Values: [][]string{},
+		}
+		lines := source.getCommandOutputLines(containerLabel)
+		if len(lines) > 0 {
+			lines = lines[1:] // the first line is the container type
+		}
+		for _, line := range lines {
+			command, reason, found := strings.Cut(line, ": ")
+			if found {
+				hostValues.Values = append(hostValues.Values, []string{command, reason})
+			}
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newNotCollectedTable lists the commands that weren't collected because the collection
+// was interrupted, and why
+func newNotCollectedTable(sources []*Source, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Not Collected Commands",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for _, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Command",
+				"Reason",
+			},
+			Values: [][]string{},
+		}
+		reasons := source.getNotCollected()
+		var labels []string
+		for label := range reasons {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			hostValues.Values = append(hostValues.Values, []string{label, reasons[label]})
+		}
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
+// newDataQualityTable sanity checks values parsed from the collected data, e.g.,
+// CPU topology and memory size, so that parser misfires are listed rather than
+// silently rendered as if they were valid.
+func newDataQualityTable(sources []*Source, tableCPU *Table, tableDIMM *Table, tableNIC *Table, category TableCategory) (table *Table) {
+	table = &Table{
+		Name:          "Data Quality",
+		Category:      category,
+		AllHostValues: []HostValues{},
+	}
+	for sourceIdx, source := range sources {
+		var hostValues = HostValues{
+			Name: source.getHostname(),
+			ValueNames: []string{
+				"Table",
+				"Field",
+				"Value",
+				"Anomaly",
+			},
+			Values: [][]string{},
+		}
+		hostValues.Values = append(hostValues.Values, checkCPUTopology(source, tableCPU, sourceIdx)...)
+		hostValues.Values = append(hostValues.Values, checkMemorySize(source, tableDIMM, sourceIdx)...)
+		hostValues.Values = append(hostValues.Values, checkNICSpeeds(tableNIC, sourceIdx)...)
+		table.AllHostValues = append(table.AllHostValues, hostValues)
+	}
+	return
+}
+
 func newPMUMetricsTable(sources []*Source, category TableCategory) (table *Table) {
 	table = &Table{
 		Name:          "PMU Metrics",
@@ -2261,10 +4399,11 @@ func newPMUMetricsTable(sources []*Source, category TableCategory) (table *Table
 		}
 		metricNames, timeStamps, metrics := source.getPMUMetrics()
 		if len(metrics) > 0 {
+			// the target's local time, as in the sysstat tables
+			clock := source.getProfileClock()
 			var series []string
 			for _, ts := range timeStamps {
-				t := time.Unix(int64(ts), 0)
-				series = append(series, t.Format("15:04:05"))
+				series = append(series, clock.format(int64(ts)))
 			}
 			hostValues.ValueNames = append(hostValues.ValueNames, series...)
 			for i, name := range metricNames {
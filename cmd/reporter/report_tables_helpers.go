@@ -5,13 +5,20 @@
 package main
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/intel/svr-info/internal/cpu"
+	"github.com/intel/svr-info/internal/util"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
@@ -588,6 +595,63 @@ func expandCPUList(cpuList string) (cpus []int) {
 	return
 }
 
+/* [1,3,4,5,8] -> "1,3-5,8" */
+func getCPUListString(cpus []int) string {
+	sort.Ints(cpus)
+	var ranges []string
+	for i := 0; i < len(cpus); i++ {
+		begin := cpus[i]
+		for i+1 < len(cpus) && cpus[i+1] == cpus[i]+1 {
+			i++
+		}
+		if cpus[i] == begin {
+			ranges = append(ranges, strconv.Itoa(begin))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", begin, cpus[i]))
+		}
+	}
+	return strings.Join(ranges, ",")
+}
+
+// getPolicySetting returns the cpufreq policy setting if it's the same for all policies,
+// or each value followed by its CPUs, e.g., "performance (0-3); powersave (4-7)"
+func getPolicySetting(policies []map[string]string, setting string, format func(string) string) (val string) {
+	var values []string
+	cpus := make(map[string][]int)
+	for _, policy := range policies {
+		value := policy[setting]
+		if value == "" {
+			continue
+		}
+		if format != nil {
+			value = format(value)
+		}
+		if _, ok := cpus[value]; !ok {
+			values = append(values, value)
+		}
+		cpus[value] = append(cpus[value], expandCPUList(policy["cpus"])...)
+	}
+	if len(values) == 1 {
+		val = values[0]
+		return
+	}
+	var settings []string
+	for _, value := range values {
+		settings = append(settings, fmt.Sprintf("%s (%s)", value, getCPUListString(cpus[value])))
+	}
+	val = strings.Join(settings, "; ")
+	return
+}
+
+// kHzToMHz formats the frequency reported by cpufreq, in kHz, as MHz
+func kHzToMHz(khz string) string {
+	val, err := strconv.Atoi(khz)
+	if err != nil {
+		return khz
+	}
+	return fmt.Sprintf("%d MHz", val/1000)
+}
+
 func getPMUMetricFromTable(PMUMetricsTable *Table, sourceIndex int, fieldName string) (metric string) {
 	hostValues := &PMUMetricsTable.AllHostValues[sourceIndex]
 	for _, row := range hostValues.Values {
@@ -684,3 +748,2319 @@ func getMicroArchitecture(cpusInfo *cpu.CPU, family, model, stepping, capid4, de
 	}
 	return
 }
+
+// checkCPUTopology confirms that the CPU count matches sockets x cores per socket x threads per core
+func checkCPUTopology(source *Source, tableCPU *Table, sourceIdx int) (anomalies [][]string) {
+	cpus, _ := tableCPU.getValue(sourceIdx, "CPUs")
+	if cpus == "" {
+		anomalies = append(anomalies, []string{tableCPU.Name, "CPUs", cpus, "CPU count not found"})
+		return
+	}
+	cpuCount, err := strconv.Atoi(cpus)
+	if err != nil {
+		anomalies = append(anomalies, []string{tableCPU.Name, "CPUs", cpus, "CPU count is not a number"})
+		return
+	}
+	sockets, _ := tableCPU.getValue(sourceIdx, "Sockets")
+	socketCount, err := strconv.Atoi(sockets)
+	if err != nil {
+		anomalies = append(anomalies, []string{tableCPU.Name, "Sockets", sockets, "socket count is not a number"})
+		return
+	}
+	coresPerSocket, _ := tableCPU.getValue(sourceIdx, "Cores per Socket")
+	coreCount, err := strconv.Atoi(coresPerSocket)
+	if err != nil {
+		anomalies = append(anomalies, []string{tableCPU.Name, "Cores per Socket", coresPerSocket, "core count is not a number"})
+		return
+	}
+	threadsPerCore := source.valFromRegexSubmatch("lscpu", `^Thread\(s\) per core.*:\s*(.+?)$`)
+	threadCount, err := strconv.Atoi(threadsPerCore)
+	if err != nil {
+		anomalies = append(anomalies, []string{tableCPU.Name, "Threads per Core", threadsPerCore, "thread count is not a number"})
+		return
+	}
+	if socketCount*coreCount*threadCount != cpuCount {
+		anomalies = append(anomalies, []string{
+			tableCPU.Name,
+			"CPUs",
+			cpus,
+			fmt.Sprintf("CPU count does not match topology: %d sockets x %d cores x %d threads", socketCount, coreCount, threadCount),
+		})
+	}
+	return
+}
+
+// checkMemorySize confirms that the sum of the DIMM sizes is consistent with the memory
+// size reported by the kernel. The kernel reserves some memory, so MemTotal is expected to
+// be somewhat less than the installed memory.
+func checkMemorySize(source *Source, tableDIMM *Table, sourceIdx int) (anomalies [][]string) {
+	dimms := tableDIMM.AllHostValues[sourceIdx].Values
+	if len(dimms) == 0 {
+		return // DIMMs aren't reported on some VMs
+	}
+	var installedBytes float64
+	for _, dimm := range dimms {
+		if strings.HasPrefix(dimm[SizeIdx], "No Module") {
+			continue
+		}
+		size, ok := normalizeSize(dimm[SizeIdx])
+		if !ok {
+			anomalies = append(anomalies, []string{tableDIMM.Name, "Size", dimm[SizeIdx], "DIMM size not recognized"})
+			return
+		}
+		installedBytes += size
+	}
+	memTotal := source.valFromRegexSubmatch("/proc/meminfo", `^MemTotal:\s*(\d+ kB)$`)
+	memTotalBytes, ok := normalizeSize(memTotal)
+	if !ok {
+		anomalies = append(anomalies, []string{"Memory", "MemTotal", memTotal, "MemTotal not found"})
+		return
+	}
+	if memTotalBytes > installedBytes {
+		anomalies = append(anomalies, []string{
+			"Memory",
+			"MemTotal",
+			formatQuantity(memTotalBytes, UnitBytes),
+			fmt.Sprintf("MemTotal exceeds sum of DIMM sizes (%s)", formatQuantity(installedBytes, UnitBytes)),
+		})
+	} else if memTotalBytes < 0.85*installedBytes {
+		anomalies = append(anomalies, []string{
+			"Memory",
+			"MemTotal",
+			formatQuantity(memTotalBytes, UnitBytes),
+			fmt.Sprintf("MemTotal is less than 85%% of the sum of DIMM sizes (%s)", formatQuantity(installedBytes, UnitBytes)),
+		})
+	}
+	return
+}
+
+// checkNICSpeeds confirms that the speed of each NIC with a link is parseable
+func checkNICSpeeds(tableNIC *Table, sourceIdx int) (anomalies [][]string) {
+	hv := tableNIC.AllHostValues[sourceIdx]
+	nameIdx, err := findValueIndex(&hv, "Name")
+	if err != nil {
+		return
+	}
+	speedIdx, _ := findValueIndex(&hv, "Speed")
+	linkIdx, _ := findValueIndex(&hv, "Link")
+	re := regexp.MustCompile(`^\d+[MG]b/s$`)
+	for _, nic := range hv.Values {
+		speed := nic[speedIdx]
+		if re.MatchString(speed) {
+			continue
+		}
+		if speed == "Unknown!" && nic[linkIdx] != "yes" {
+			continue // speed is unknown when the link is down
+		}
+		anomalies = append(anomalies, []string{tableNIC.Name, "Speed", speed, fmt.Sprintf("speed of %s not recognized", nic[nameIdx])})
+	}
+	return
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// profileClock is the start of the profiling window on the target. The sysstat tools
+// print the target's local time of day, without the date, so the clock places those
+// times in the window, including across midnight, and relates them to the epoch times
+// of the PMU metrics and the call stacks.
+type profileClock struct {
+	start     int64 // epoch seconds
+	timeOfDay int64 // seconds since midnight in the target's local time
+	interval  int64 // seconds between samples
+	zoned     bool  // the target's UTC offset is known
+}
+
+// parseTimeOfDay returns the seconds since midnight of an HH:MM:SS time
+func parseTimeOfDay(hms string) (seconds int64, err error) {
+	t, err := time.Parse("15:04:05", hms)
+	if err != nil {
+		return
+	}
+	seconds = int64(t.Hour()*3600 + t.Minute()*60 + t.Second())
+	return
+}
+
+// epoch returns the epoch seconds of a time of day in the profiling window
+func (c profileClock) epoch(hms string) (epoch int64, err error) {
+	seconds, err := parseTimeOfDay(hms)
+	if err != nil {
+		return
+	}
+	elapsed := ((seconds-c.timeOfDay)%secondsPerDay + secondsPerDay) % secondsPerDay
+	// a sample stamped a moment before the clock was read
+	if elapsed > secondsPerDay-60 {
+		elapsed -= secondsPerDay
+	}
+	epoch = c.start + elapsed
+	return
+}
+
+// location returns the target's time zone, as its UTC offset at the start of the window,
+// or UTC if the offset isn't known
+func (c profileClock) location() *time.Location {
+	if !c.zoned {
+		return time.UTC
+	}
+	offset := ((c.timeOfDay-c.start)%secondsPerDay + secondsPerDay) % secondsPerDay
+	if offset > 14*60*60 {
+		offset -= secondsPerDay
+	}
+	return time.FixedZone("", int(offset))
+}
+
+// format returns the target's local time of day of an epoch time
+func (c profileClock) format(epoch int64) string {
+	return time.Unix(epoch, 0).In(c.location()).Format("15:04:05")
+}
+
+type profileSample struct {
+	time  int64 // epoch seconds
+	value float64
+}
+
+// getProfileSamples returns the time series of the specified field from a table with a "Time" field
+func getProfileSamples(table *Table, sourceIndex int, fieldName string, inverse bool, clock profileClock) (samples []profileSample) {
+	hostValues := &table.AllHostValues[sourceIndex]
+	timeIdx, err := findValueIndex(hostValues, "Time")
+	if err != nil {
+		return
+	}
+	fieldIdx, err := findValueIndex(hostValues, fieldName)
+	if err != nil {
+		return
+	}
+	for _, entry := range hostValues.Values {
+		value, err := strconv.ParseFloat(entry[fieldIdx], 64)
+		if err != nil {
+			continue
+		}
+		epoch, err := clock.epoch(entry[timeIdx])
+		if err != nil {
+			continue
+		}
+		if inverse {
+			value = 100.0 - value
+		}
+		samples = append(samples, profileSample{time: epoch, value: value})
+	}
+	return
+}
+
+// getPMUMetricSamples returns the time series of the specified PMU metric
+func getPMUMetricSamples(source *Source, metricName string, clock profileClock) (samples []profileSample) {
+	_, timeStamps, metrics := source.getPMUMetrics()
+	metric, ok := metrics[metricName]
+	if !ok {
+		return
+	}
+	for i, value := range metric.series {
+		if i >= len(timeStamps) {
+			break
+		}
+		epoch := int64(timeStamps[i])
+		if !clock.zoned {
+			// the sysstat times were placed in the window as if they were UTC
+			var err error
+			if epoch, err = clock.epoch(clock.format(epoch)); err != nil {
+				continue
+			}
+		}
+		samples = append(samples, profileSample{time: epoch, value: value})
+	}
+	return
+}
+
+// findRuns groups consecutive samples that satisfy the condition
+func findRuns(samples []profileSample, condition func(float64) bool) (runs [][]profileSample) {
+	var run []profileSample
+	for _, sample := range samples {
+		if condition(sample.value) {
+			run = append(run, sample)
+		} else if len(run) > 0 {
+			runs = append(runs, run)
+			run = nil
+		}
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return
+}
+
+// findSpikes returns runs of samples that are at least the minimum and more than two
+// standard deviations above the mean
+func findSpikes(samples []profileSample, minimum float64) (runs [][]profileSample) {
+	if len(samples) < 3 {
+		return
+	}
+	var sum, sumSquares float64
+	for _, sample := range samples {
+		sum += sample.value
+		sumSquares += sample.value * sample.value
+	}
+	mean := sum / float64(len(samples))
+	stddev := math.Sqrt(math.Max(sumSquares/float64(len(samples))-mean*mean, 0))
+	threshold := math.Max(minimum, mean+2*stddev)
+	return findRuns(samples, func(value float64) bool { return value >= threshold })
+}
+
+// findDips returns runs of samples that are less than the specified fraction of the median
+func findDips(samples []profileSample, fraction float64) (runs [][]profileSample) {
+	if len(samples) < 3 {
+		return
+	}
+	var values []float64
+	for _, sample := range samples {
+		values = append(values, sample.value)
+	}
+	sort.Float64s(values)
+	median := values[len(values)/2]
+	return findRuns(samples, func(value float64) bool { return value < median*fraction })
+}
+
+type processSample struct {
+	time    int64 // epoch seconds
+	pid     string
+	cpu     float64
+	command string
+}
+
+// getProcessCPUSamples parses the per-process CPU utilization collected by pidstat during profiling
+func getProcessCPUSamples(source *Source, clock profileClock) (samples []processSample) {
+	// Time UID PID %usr %system %guest [%wait] %CPU CPU Command
+	reStat := regexp.MustCompile(`^(\d\d:\d\d:\d\d)\s+\d+\s+(\d+)\s+(?:\d+\.\d+\s+){3,4}(\d+\.\d+)\s+\d+\s+(.+)$`)
+	for _, line := range source.getProfileLines("pidstat") {
+		match := reStat.FindStringSubmatch(line)
+		if len(match) == 0 {
+			continue
+		}
+		cpu, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			continue
+		}
+		epoch, err := clock.epoch(match[1])
+		if err != nil {
+			continue
+		}
+		samples = append(samples, processSample{time: epoch, pid: match[2], cpu: cpu, command: match[4]})
+	}
+	return
+}
+
+// getTopProcesses returns the processes with the highest average CPU utilization during the interval
+func getTopProcesses(samples []processSample, start int64, end int64, count int) (top string) {
+	type process struct {
+		pid     string
+		command string
+		cpu     float64
+	}
+	processes := make(map[string]*process)
+	times := make(map[int64]bool)
+	for _, sample := range samples {
+		if sample.time < start || sample.time > end {
+			continue
+		}
+		times[sample.time] = true
+		if _, ok := processes[sample.pid]; !ok {
+			processes[sample.pid] = &process{pid: sample.pid, command: sample.command}
+		}
+		processes[sample.pid].cpu += sample.cpu
+	}
+	var sorted []*process
+	for _, p := range processes {
+		p.cpu /= float64(len(times))
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].cpu > sorted[j].cpu })
+	var tops []string
+	for i := 0; i < count && i < len(sorted); i++ {
+		tops = append(tops, fmt.Sprintf("%s (%s) %0.1f%%", sorted[i].command, sorted[i].pid, sorted[i].cpu))
+	}
+	top = strings.Join(tops, ", ")
+	return
+}
+
+type profileStack struct {
+	offset int64 // start of the interval, in seconds from the start of the window
+	stack  string
+	count  int
+}
+
+// foldStacks returns the call stacks sampled in the intervals that start from the first
+// offset up to, but not including, the last, in folded format, i.e., a line per stack
+// with its count
+func foldStacks(stacks []profileStack, from int64, to int64) (folded string) {
+	counts := make(map[string]int)
+	for _, stack := range stacks {
+		if stack.offset >= from && stack.offset < to {
+			counts[stack.stack] += stack.count
+		}
+	}
+	var lines []string
+	for stack, count := range counts {
+		lines = append(lines, fmt.Sprintf("%s %d", stack, count))
+	}
+	sort.Strings(lines)
+	folded = strings.Join(lines, "\n")
+	return
+}
+
+// getHotCodePaths returns the functions at the top of the most sampled folded stacks,
+// with their share of the samples
+func getHotCodePaths(folded string, count int) (hot string) {
+	counts := make(map[string]int)
+	var total int
+	for _, line := range strings.Split(folded, "\n") {
+		sep := strings.LastIndex(line, " ")
+		if sep < 0 {
+			continue
+		}
+		n, err := strconv.Atoi(line[sep+1:])
+		if err != nil {
+			continue
+		}
+		frames := strings.Split(line[:sep], ";")
+		counts[frames[len(frames)-1]] += n
+		total += n
+	}
+	var functions []string
+	for function := range counts {
+		functions = append(functions, function)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if counts[functions[i]] != counts[functions[j]] {
+			return counts[functions[i]] > counts[functions[j]]
+		}
+		return functions[i] < functions[j]
+	})
+	var hots []string
+	for i := 0; i < count && i < len(functions); i++ {
+		hots = append(hots, fmt.Sprintf("%s %0.1f%%", functions[i], 100*float64(counts[functions[i]])/float64(total)))
+	}
+	hot = strings.Join(hots, ", ")
+	return
+}
+
+// timelineEvent is a run of samples, within the profiling window, where a metric spiked or dropped
+type timelineEvent struct {
+	start  int64 // epoch seconds of the first sample
+	end    int64 // epoch seconds of the last sample
+	event  string
+	peak   string
+	top    string // the processes with the highest CPU utilization
+	folded string // the call stacks sampled during the event
+}
+
+// newTimelineEvent summarizes a run of samples, a sample covers the interval that ends at its time
+func newTimelineEvent(run []profileSample, event string, units string, lowest bool, processSamples []processSample, stacks []profileStack, clock profileClock) timelineEvent {
+	peak := run[0].value
+	for _, sample := range run {
+		if (lowest && sample.value < peak) || (!lowest && sample.value > peak) {
+			peak = sample.value
+		}
+	}
+	start, end := run[0].time, run[len(run)-1].time
+	return timelineEvent{
+		start:  start,
+		end:    end,
+		event:  event,
+		peak:   fmt.Sprintf("%0.2f%s", peak, units),
+		top:    getTopProcesses(processSamples, start, end, 3),
+		folded: foldStacks(stacks, start-clock.interval-clock.start, end-clock.start),
+	}
+}
+
+type cpuFeature struct {
+	Category    string
+	Flag        string // as reported in /proc/cpuinfo
+	Description string
+}
+
+// cpuFeatures are the CPU feature flags decoded in the CPU Feature table, grouped by
+// capability
+var cpuFeatures = []cpuFeature{
+	{"AVX", "avx", "Advanced Vector Extensions"},
+	{"AVX", "avx2", "Advanced Vector Extensions 2"},
+	{"AVX", "fma", "Fused Multiply-Add"},
+	{"AVX", "f16c", "16-bit Floating Point Conversion"},
+	{"AVX", "avx_vnni", "AVX Vector Neural Network Instructions"},
+	{"AVX-512", "avx512f", "AVX-512 Foundation"},
+	{"AVX-512", "avx512cd", "AVX-512 Conflict Detection"},
+	{"AVX-512", "avx512bw", "AVX-512 Byte and Word"},
+	{"AVX-512", "avx512dq", "AVX-512 Doubleword and Quadword"},
+	{"AVX-512", "avx512vl", "AVX-512 Vector Length Extensions"},
+	{"AVX-512", "avx512ifma", "AVX-512 Integer Fused Multiply-Add"},
+	{"AVX-512", "avx512vbmi", "AVX-512 Vector Byte Manipulation"},
+	{"AVX-512", "avx512_vbmi2", "AVX-512 Vector Byte Manipulation 2"},
+	{"AVX-512", "avx512_vnni", "AVX-512 Vector Neural Network Instructions"},
+	{"AVX-512", "avx512_bitalg", "AVX-512 Bit Algorithms"},
+	{"AVX-512", "avx512_vpopcntdq", "AVX-512 Vector Population Count"},
+	{"AVX-512", "avx512_bf16", "AVX-512 BFloat16"},
+	{"AVX-512", "avx512_fp16", "AVX-512 FP16"},
+	{"AVX-512", "avx512_vp2intersect", "AVX-512 Vector Pair Intersection"},
+	{"AMX", "amx_tile", "Advanced Matrix Extensions Tile Architecture"},
+	{"AMX", "amx_bf16", "Advanced Matrix Extensions BFloat16"},
+	{"AMX", "amx_int8", "Advanced Matrix Extensions INT8"},
+	{"AMX", "amx_fp16", "Advanced Matrix Extensions FP16"},
+	{"Crypto", "aes", "AES New Instructions"},
+	{"Crypto", "vaes", "Vector AES"},
+	{"Crypto", "pclmulqdq", "Carry-Less Multiplication"},
+	{"Crypto", "vpclmulqdq", "Vector Carry-Less Multiplication"},
+	{"Crypto", "gfni", "Galois Field New Instructions"},
+	{"Crypto", "sha_ni", "SHA Extensions"},
+	{"Crypto", "rdrand", "Hardware Random Number Generator"},
+	{"Crypto", "rdseed", "Hardware Random Seed Generator"},
+	{"Virtualization", "vmx", "Intel Virtualization Technology (VT-x)"},
+	{"Virtualization", "ept", "Extended Page Tables"},
+	{"Virtualization", "vpid", "Virtual Processor Identifiers"},
+	{"Virtualization", "ept_ad", "EPT Accessed and Dirty Flags"},
+	{"Virtualization", "svm", "AMD Secure Virtual Machine (AMD-V)"},
+	{"Virtualization", "hypervisor", "Running under a Hypervisor"},
+	{"Security", "sgx", "Software Guard Extensions"},
+	{"Security", "tme", "Total Memory Encryption"},
+	{"Security", "pku", "Memory Protection Keys"},
+	{"Security", "ibt", "Indirect Branch Tracking"},
+	{"Security", "md_clear", "Microarchitectural Data Sampling Mitigation"},
+	{"Security", "smep", "Supervisor Mode Execution Prevention"},
+	{"Security", "smap", "Supervisor Mode Access Prevention"},
+	{"TSX", "rtm", "Restricted Transactional Memory"},
+	{"TSX", "hle", "Hardware Lock Elision"},
+	{"Data Movement", "movdiri", "Move Doubleword as Direct Store"},
+	{"Data Movement", "movdir64b", "Move 64 Bytes as Direct Store"},
+	{"Data Movement", "enqcmd", "Enqueue Command"},
+	{"Data Movement", "cldemote", "Cache Line Demote"},
+	{"Data Movement", "clwb", "Cache Line Write Back"},
+	{"Data Movement", "clflushopt", "Optimized Cache Line Flush"},
+	{"Power", "waitpkg", "User Wait Instructions (UMONITOR, UMWAIT, TPAUSE)"},
+	{"Power", "hwp", "Hardware P-States"},
+	{"Power", "hwp_epp", "HWP Energy Performance Preference"},
+	{"RDT", "rdt_a", "Resource Director Technology Allocation"},
+	{"RDT", "cat_l3", "L3 Cache Allocation Technology"},
+	{"RDT", "cat_l2", "L2 Cache Allocation Technology"},
+	{"RDT", "cdp_l3", "L3 Code and Data Prioritization"},
+	{"RDT", "mba", "Memory Bandwidth Allocation"},
+	{"RDT", "cqm_llc", "LLC Occupancy Monitoring"},
+	{"RDT", "cqm_mbm_total", "Memory Bandwidth Monitoring (Total)"},
+	{"RDT", "cqm_mbm_local", "Memory Bandwidth Monitoring (Local)"},
+}
+
+// getCPUFeatureCategory returns the category of a CPU feature flag or an empty string
+// if the flag isn't one of the decoded features
+func getCPUFeatureCategory(flag string) string {
+	for _, feature := range cpuFeatures {
+		if feature.Flag == flag {
+			return feature.Category
+		}
+	}
+	return ""
+}
+
+// getCPUFlagMismatches compares a host's CPU feature flags to the flags of the other
+// hosts. Flags the host is missing, and the hosts that have them, are returned in
+// missing. Flags only the host has, and the hosts that lack them, are returned in extra.
+func getCPUFlagMismatches(hostIdx int, hostnames []string, hostFlags []map[string]bool) (missing map[string][]string, extra map[string][]string) {
+	missing = make(map[string][]string)
+	extra = make(map[string][]string)
+	for otherIdx, otherFlags := range hostFlags {
+		if otherIdx == hostIdx || len(otherFlags) == 0 {
+			continue
+		}
+		for flag := range otherFlags {
+			if !hostFlags[hostIdx][flag] {
+				missing[flag] = append(missing[flag], hostnames[otherIdx])
+			}
+		}
+		for flag := range hostFlags[hostIdx] {
+			if !otherFlags[flag] {
+				extra[flag] = append(extra[flag], hostnames[otherIdx])
+			}
+		}
+	}
+	return
+}
+
+var reBpftraceHistogramBucket = regexp.MustCompile(`^\[([^\]\)]+)[\]\)]\s+(\d+)\s+\|`)
+
+// parseBpftraceHistogram returns the buckets and counts printed by bpftrace for a hist()
+// map, e.g., "[4, 8)    461 |@@@@|" is returned as ["4-8", "461"]
+func parseBpftraceHistogram(lines []string) (buckets [][]string) {
+	for _, line := range lines {
+		match := reBpftraceHistogramBucket.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		buckets = append(buckets, []string{strings.ReplaceAll(match[1], ", ", "-"), match[2]})
+	}
+	return
+}
+
+// normalizeIPAddress returns an IPv4-mapped IPv6 address, e.g., ::ffff:10.0.0.1, in its
+// IPv4 form. The tcp tracepoints report IPv4 peers as mapped addresses in daddr_v6.
+func normalizeIPAddress(address string) string {
+	if ip := net.ParseIP(address); ip != nil {
+		if ipv4 := ip.To4(); ipv4 != nil {
+			return ipv4.String()
+		}
+	}
+	return address
+}
+
+var reBpftraceMapEntry = regexp.MustCompile(`^@\w+\[(.*)\]: (\d+)$`)
+
+// parseBpftraceMap returns the keys and values printed by bpftrace for a map, sorted by
+// value, largest first, e.g., "@offcpu_us[kworker/0:1]: 123" is returned as
+// ["kworker/0:1", "123"]
+func parseBpftraceMap(lines []string) (entries [][]string) {
+	type entry struct {
+		key   string
+		value int64
+	}
+	var parsed []entry
+	for _, line := range lines {
+		match := reBpftraceMapEntry.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		value, err := strconv.ParseInt(match[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, entry{key: match[1], value: value})
+	}
+	sort.SliceStable(parsed, func(i, j int) bool { return parsed[i].value > parsed[j].value })
+	for _, e := range parsed {
+		entries = append(entries, []string{e.key, strconv.FormatInt(e.value, 10)})
+	}
+	return
+}
+
+// getBootParameter returns the value of the kernel boot parameter, e.g., "2-5" for
+// "isolcpus=2-5", or an empty string if the parameter isn't set
+func getBootParameter(cmdline string, name string) (value string) {
+	for _, field := range strings.Fields(cmdline) {
+		if key, val, found := strings.Cut(field, "="); found && key == name {
+			value = val
+		}
+	}
+	return
+}
+
+// getIsolcpusCPUList removes the flags, e.g., "domain,managed_irq,", from the isolcpus
+// boot parameter value leaving the CPU list
+func getIsolcpusCPUList(isolcpus string) string {
+	var cpus []string
+	for _, token := range strings.Split(isolcpus, ",") {
+		if token != "" && token[0] >= '0' && token[0] <= '9' {
+			cpus = append(cpus, token)
+		}
+	}
+	return strings.Join(cpus, ",")
+}
+
+func sameCPUs(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Ints(a)
+	sort.Ints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getCPUIsolationStatus checks that the boot parameters that isolate CPUs for
+// latency-sensitive workloads are consistent. Returns "Not configured", "OK", or the
+// problems found.
+func getCPUIsolationStatus(isolated string, nohzFull string, rcuNocbs string, irqAffinity string, irqbalance bool) (status string) {
+	if isolated == "" && nohzFull == "" {
+		return "Not configured"
+	}
+	var issues []string
+	isolatedCPUs := expandCPUList(isolated)
+	nohzFullCPUs := expandCPUList(nohzFull)
+	if isolated == "" {
+		issues = append(issues, "nohz_full CPUs are not isolated from the scheduler (isolcpus)")
+	} else if nohzFull == "" {
+		issues = append(issues, "isolated CPUs are not tickless (nohz_full)")
+	} else if !sameCPUs(isolatedCPUs, nohzFullCPUs) {
+		issues = append(issues, "isolated and nohz_full CPU lists differ")
+	}
+	if rcuNocbs == "" {
+		issues = append(issues, "RCU callbacks are not offloaded (rcu_nocbs)")
+	}
+	if irqbalance && irqAffinity == "" {
+		issues = append(issues, "irqbalance may move IRQs to isolated CPUs (irqaffinity not set)")
+	}
+	if irqAffinity != "" {
+		irqCPUs := make(map[int]bool)
+		for _, cpu := range expandCPUList(irqAffinity) {
+			irqCPUs[cpu] = true
+		}
+		for _, cpu := range isolatedCPUs {
+			if irqCPUs[cpu] {
+				issues = append(issues, "irqaffinity includes isolated CPUs")
+				break
+			}
+		}
+	}
+	if len(issues) == 0 {
+		return "OK"
+	}
+	return strings.Join(issues, "; ")
+}
+
+// getHistogramBucketUpperBound returns the upper bound of a bpftrace histogram bucket,
+// e.g., 8 for "4-8" and 2048 for "1K-2K"
+func getHistogramBucketUpperBound(bucket string) (bound int64, err error) {
+	fields := strings.Split(bucket, "-")
+	last := fields[len(fields)-1]
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(last, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(last, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(last, "G"):
+		multiplier = 1 << 30
+	}
+	value, err := strconv.ParseInt(strings.TrimRight(last, "KMG"), 10, 64)
+	if err != nil {
+		return
+	}
+	bound = value * multiplier
+	return
+}
+
+// getHistogramPercentiles returns the upper bound of the bucket that contains each
+// percentile, and the total count, from the buckets and counts of a histogram
+func getHistogramPercentiles(buckets [][]string, percentiles []float64) (values []int64, total int64, err error) {
+	var bounds, counts []int64
+	for _, bucket := range buckets {
+		var bound, count int64
+		if bound, err = getHistogramBucketUpperBound(bucket[0]); err != nil {
+			return
+		}
+		if count, err = strconv.ParseInt(bucket[1], 10, 64); err != nil {
+			return
+		}
+		bounds = append(bounds, bound)
+		counts = append(counts, count)
+		total += count
+	}
+	if total == 0 {
+		err = fmt.Errorf("histogram is empty")
+		return
+	}
+	for _, percentile := range percentiles {
+		threshold := float64(total) * percentile / 100
+		var cumulative int64
+		for i, count := range counts {
+			cumulative += count
+			if float64(cumulative) >= threshold {
+				values = append(values, bounds[i])
+				break
+			}
+		}
+	}
+	return
+}
+
+// idleStateResidency is the change in the cpuidle counters of one idle state, totaled
+// across CPUs, over the profile window
+type idleStateResidency struct {
+	name        string
+	enabledCPUs int
+	entries     int64
+	timeUs      int64
+}
+
+// parseCPUIdleCounters returns the change in the counters of each idle state, ordered by
+// state index, the number of CPUs, and the length of the window in seconds
+func parseCPUIdleCounters(lines []string) (states []idleStateResidency, numCPUs int, seconds float64) {
+	reCounter := regexp.MustCompile(`^(start|end) cpu(\d+)/cpuidle/state(\d+)/(name|disable|usage|time):(.*)$`)
+	reUptime := regexp.MustCompile(`^(start|end) uptime:(\d+\.?\d*)$`)
+	// counter values keyed by "start|end cpu state field"
+	values := make(map[string]string)
+	cpus := make(map[string]bool)
+	uptimes := make(map[string]float64)
+	maxState := -1
+	for _, line := range lines {
+		if match := reUptime.FindStringSubmatch(line); match != nil {
+			uptimes[match[1]], _ = strconv.ParseFloat(match[2], 64)
+			continue
+		}
+		match := reCounter.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		values[strings.Join(match[1:5], " ")] = match[5]
+		cpus[match[2]] = true
+		if state, err := strconv.Atoi(match[3]); err == nil && state > maxState {
+			maxState = state
+		}
+	}
+	numCPUs = len(cpus)
+	seconds = uptimes["end"] - uptimes["start"]
+	delta := func(cpu string, state string, field string) (value int64) {
+		start, err := strconv.ParseInt(values["start "+cpu+" "+state+" "+field], 10, 64)
+		if err != nil {
+			return
+		}
+		end, err := strconv.ParseInt(values["end "+cpu+" "+state+" "+field], 10, 64)
+		if err != nil {
+			return
+		}
+		value = end - start
+		return
+	}
+	for stateIdx := 0; stateIdx <= maxState; stateIdx++ {
+		state := strconv.Itoa(stateIdx)
+		var residency idleStateResidency
+		for cpu := range cpus {
+			if name := values["start "+cpu+" "+state+" name"]; name != "" {
+				residency.name = name
+			}
+			if values["start "+cpu+" "+state+" disable"] == "0" {
+				residency.enabledCPUs++
+			}
+			residency.entries += delta(cpu, state, "usage")
+			residency.timeUs += delta(cpu, state, "time")
+		}
+		if residency.name != "" {
+			states = append(states, residency)
+		}
+	}
+	return
+}
+
+// getTurbostatSummary returns the values in the summary (first) row of turbostat's per-CPU
+// output, keyed by column name, e.g., CPU%c6
+func getTurbostatSummary(lines []string) (summary map[string]string) {
+	summary = make(map[string]string)
+	for idx, line := range lines {
+		header := strings.Fields(line)
+		if !strings.Contains(line, "Busy%") || idx+1 >= len(lines) {
+			continue
+		}
+		values := strings.Fields(lines[idx+1])
+		for i := 0; i < len(header) && i < len(values); i++ {
+			summary[header[i]] = values[i]
+		}
+		break
+	}
+	return
+}
+
+// getCStateStatus flags idle states that are enabled but never entered, or that the OS
+// requests but the cores rarely reach, e.g., when deeper C-states are limited in the BIOS
+func getCStateStatus(state idleStateResidency, requested float64, reached string) string {
+	if state.enabledCPUs == 0 {
+		return "Disabled"
+	}
+	// polling isn't a hardware idle state
+	if state.name == "POLL" {
+		return "OK"
+	}
+	if state.entries == 0 {
+		return "Never entered"
+	}
+	if core, err := strconv.ParseFloat(reached, 64); err == nil && requested >= 1 && core < requested/10 {
+		return "Requested but not reached"
+	}
+	return "OK"
+}
+
+// getFrequencyDistribution counts the sampled CPU frequencies in 100 MHz buckets, ordered
+// by frequency
+func getFrequencyDistribution(lines []string) (buckets [][]string) {
+	re := regexp.MustCompile(`^freq cpu\d+/cpufreq/scaling_cur_freq:(\d+)$`)
+	counts := make(map[int]int)
+	for _, line := range lines {
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		khz, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		counts[int(math.Round(float64(khz)/100000))*100]++
+	}
+	var frequencies []int
+	for mhz := range counts {
+		frequencies = append(frequencies, mhz)
+	}
+	sort.Ints(frequencies)
+	for _, mhz := range frequencies {
+		buckets = append(buckets, []string{strconv.Itoa(mhz), strconv.Itoa(counts[mhz])})
+	}
+	return
+}
+
+// getEPPCounts counts the CPUs with each energy performance preference
+func getEPPCounts(lines []string) (counts [][]string) {
+	re := regexp.MustCompile(`^epp cpu\d+/cpufreq/energy_performance_preference:(.+)$`)
+	cpus := make(map[string]int)
+	for _, line := range lines {
+		if match := re.FindStringSubmatch(line); match != nil {
+			cpus[match[1]]++
+		}
+	}
+	var preferences []string
+	for preference := range cpus {
+		preferences = append(preferences, preference)
+	}
+	sort.Strings(preferences)
+	for _, preference := range preferences {
+		counts = append(counts, []string{preference, strconv.Itoa(cpus[preference])})
+	}
+	return
+}
+
+// resctrlGroup is a resctrl allocation group, i.e., a class of service (CLOS), and its
+// monitoring data
+type resctrlGroup struct {
+	name     string
+	values   map[string]string // mode, cpus, tasks, and the monitoring counters
+	schemata map[string]string // keyed by resource, e.g., L3 or MB
+}
+
+// parseResctrl parses the resctrl command output into the resource info, keyed by
+// resource/file, e.g., L3/num_closids, and the allocation groups in resctrl order
+func parseResctrl(lines []string) (info map[string]string, groups []resctrlGroup) {
+	info = make(map[string]string)
+	reInfo := regexp.MustCompile(`^info/(\w+/\w+):(.*)$`)
+	reGroup := regexp.MustCompile(`^group (\S+) (\w+): ?(.*)$`)
+	index := make(map[string]int)
+	for _, line := range lines {
+		if match := reInfo.FindStringSubmatch(line); match != nil {
+			// mon_features lists one event per line
+			if info[match[1]] != "" {
+				info[match[1]] += ","
+			}
+			info[match[1]] += match[2]
+			continue
+		}
+		match := reGroup.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name := match[1]
+		if name == "." {
+			name = "default"
+		}
+		idx, ok := index[name]
+		if !ok {
+			idx = len(groups)
+			index[name] = idx
+			groups = append(groups, resctrlGroup{name: name, values: make(map[string]string), schemata: make(map[string]string)})
+		}
+		if match[2] == "schemata" {
+			resource, schema, _ := strings.Cut(match[3], ":")
+			groups[idx].schemata[strings.TrimSpace(resource)] = strings.TrimSpace(schema)
+		} else {
+			groups[idx].values[match[2]] = match[3]
+		}
+	}
+	return
+}
+
+// getResctrlBandwidth returns the bandwidth, in MB/s, from the byte counter read one second
+// apart
+func getResctrlBandwidth(group resctrlGroup, counter string) string {
+	start, err := strconv.ParseInt(group.values[counter], 10, 64)
+	if err != nil {
+		return ""
+	}
+	end, err := strconv.ParseInt(group.values[counter+"_1s"], 10, 64)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", float64(end-start)/1000000)
+}
+
+// isstValue is a key:value line from intel-speed-select output and the headers it is
+// nested under, e.g., package-0, die-0, cpu-0, and perf-profile-level-1
+type isstValue struct {
+	pkg     string
+	die     string
+	cpu     string
+	section string // the other headers, joined by "/"
+	key     string
+	value   string
+}
+
+// parseIntelSpeedSelect parses the indented output of intel-speed-select, where the
+// nesting of headers is given by their indentation
+func parseIntelSpeedSelect(output string) (values []isstValue) {
+	type header struct {
+		indent int
+		name   string
+	}
+	reHeader := regexp.MustCompile(`^(\s*)([\w\-\(\)]+)$`)
+	reValue := regexp.MustCompile(`^(\s*)([\w\-\(\)]+):(.*)$`)
+	var headers []header
+	nest := func(indent int) {
+		for len(headers) > 0 && headers[len(headers)-1].indent >= indent {
+			headers = headers[:len(headers)-1]
+		}
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if match := reHeader.FindStringSubmatch(line); match != nil {
+			nest(len(match[1]))
+			headers = append(headers, header{indent: len(match[1]), name: match[2]})
+			continue
+		}
+		match := reValue.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		nest(len(match[1]))
+		value := isstValue{key: match[2], value: strings.TrimSpace(match[3])}
+		var sections []string
+		for _, h := range headers {
+			if id, ok := strings.CutPrefix(h.name, "package-"); ok {
+				value.pkg = id
+			} else if id, ok := strings.CutPrefix(h.name, "die-"); ok {
+				value.die = id
+			} else if id, ok := strings.CutPrefix(h.name, "cpu-"); ok {
+				value.cpu = id
+			} else {
+				sections = append(sections, h.name)
+			}
+		}
+		value.section = strings.Join(sections, "/")
+		values = append(values, value)
+	}
+	return
+}
+
+// getISSTValue returns the first value of the key in the section, or in any section if
+// section is empty
+func getISSTValue(values []isstValue, section string, key string) string {
+	for _, v := range values {
+		if (section == "" || v.section == section) && v.key == key {
+			return v.value
+		}
+	}
+	return ""
+}
+
+// memoryNode is a NUMA node, CPU-less nodes with memory are typically CXL or PMem memory
+type memoryNode struct {
+	id       string
+	cpus     string
+	memoryKB int64
+	tier     string
+}
+
+// getMemoryNodes parses the NUMA nodes and the memory tier of each node from the memory
+// tiers command output sections
+func getMemoryNodes(sections map[string]string) (nodes []memoryNode) {
+	reNode := regexp.MustCompile(`^node(\d+) cpus=(\S*) memory_kb=(\d*)$`)
+	reTier := regexp.MustCompile(`^memory_tier(\d+) nodes=(\S+)$`)
+	tiers := make(map[int]string)
+	for _, line := range strings.Split(sections["tiers"], "\n") {
+		if match := reTier.FindStringSubmatch(line); match != nil {
+			for _, node := range expandCPUList(match[2]) {
+				tiers[node] = match[1]
+			}
+		}
+	}
+	for _, line := range strings.Split(sections["nodes"], "\n") {
+		match := reNode.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		memoryKB, _ := strconv.ParseInt(match[3], 10, 64)
+		id, _ := strconv.Atoi(match[1])
+		nodes = append(nodes, memoryNode{id: match[1], cpus: match[2], memoryKB: memoryKB, tier: tiers[id]})
+	}
+	return
+}
+
+// findJSONObjects returns the objects with the key anywhere in the decoded JSON, e.g.,
+// the memdevs in 'cxl list' output, which nests them differently depending on the options
+func findJSONObjects(data interface{}, key string) (objects []map[string]interface{}) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if _, ok := v[key]; ok {
+			objects = append(objects, v)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			objects = append(objects, findJSONObjects(v[k], key)...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			objects = append(objects, findJSONObjects(item, key)...)
+		}
+	}
+	return
+}
+
+// getJSONObjects decodes the JSON output and returns the objects with the key
+func getJSONObjects(output string, key string) (objects []map[string]interface{}) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(output), &data); err != nil {
+		return
+	}
+	return findJSONObjects(data, key)
+}
+
+// jsonString returns the value of the key as a string, formatting sizes in bytes
+func jsonString(object map[string]interface{}, key string) string {
+	switch v := object[key].(type) {
+	case string:
+		return v
+	case float64:
+		if strings.HasSuffix(key, "size") {
+			return formatQuantity(v, UnitBytes)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// getPMemMode returns the Optane PMem operating mode from 'ipmctl show -memoryresources',
+// i.e., Memory Mode when PMem is volatile memory with DRAM as cache, App Direct when PMem
+// is persistent memory, or Mixed Mode when it is both
+func getPMemMode(lines []string) (mode string) {
+	var volatile, appDirect bool
+	for _, line := range lines {
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 {
+			continue
+		}
+		capacity := strings.TrimSpace(fields[2])
+		used := capacity != "-" && capacity != "" && !strings.HasPrefix(capacity, "0.000")
+		switch strings.TrimSpace(fields[0]) {
+		case "Volatile":
+			volatile = used
+		case "AppDirect":
+			appDirect = used
+		}
+	}
+	if volatile && appDirect {
+		mode = "Mixed Mode"
+	} else if volatile {
+		mode = "Memory Mode"
+	} else if appDirect {
+		mode = "App Direct"
+	}
+	return
+}
+
+// benchmarkMetric is a benchmark summary value that is compared between runs and to the
+// reference results, unit is the unit of the value in the Summary table
+type benchmarkMetric struct {
+	name           string
+	higherIsBetter bool
+	unit           string
+}
+
+var benchmarkMetrics = []benchmarkMetric{
+	{"CPU Speed", true, "ops/s"},
+	{"Single-core Turbo Frequency", true, "MHz"},
+	{"All-core Turbo Frequency", true, "MHz"},
+	{"Idle Power", false, "Watts"},
+	{"Memory Peak Bandwidth", true, "GB/s"},
+	{"Memory Minimum Latency", false, "ns"},
+	{"Disk Speed", true, "iops"},
+}
+
+// getBenchmarkMetricValue returns the number at the start of a benchmark summary value,
+// e.g., "12.3k iops" -> 12300
+func getBenchmarkMetricValue(value string) (number float64, ok bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return
+	}
+	multiplier := 1.0
+	field := fields[0]
+	switch {
+	case strings.HasSuffix(field, "k"):
+		multiplier = 1e3
+		field = strings.TrimSuffix(field, "k")
+	case strings.HasSuffix(field, "M"):
+		multiplier = 1e6
+		field = strings.TrimSuffix(field, "M")
+	}
+	number, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return
+	}
+	number *= multiplier
+	ok = true
+	return
+}
+
+// volatileConfiguration lists the configuration tables and fields that change from run
+// to run without a change to the system's configuration. An empty list means the whole
+// table.
+var volatileConfiguration = map[string][]string{
+	"Host":       {},
+	"Memory":     {"MemFree", "MemAvailable", "Buffers", "Cached"},
+	"Filesystem": {},
+}
+
+// configurationChange is a configuration value that differs between two hosts
+type configurationChange struct {
+	table  string
+	field  string // empty if the table's number of rows changed
+	row    string // the row's first value, for tables with more than one row
+	before string
+	after  string
+}
+
+// getConfigurationChanges returns the configuration values that differ between two hosts
+// in the report, skipping the values that change from run to run
+func getConfigurationChanges(report *Report, hostIndexA int, hostIndexB int) (changes []configurationChange) {
+	for _, table := range report.Tables {
+		if table.Category == Status {
+			continue
+		}
+		volatileFields, volatile := volatileConfiguration[table.Name]
+		if volatile && len(volatileFields) == 0 {
+			continue
+		}
+		a := table.AllHostValues[hostIndexA]
+		b := table.AllHostValues[hostIndexB]
+		if len(a.Values) != len(b.Values) {
+			changes = append(changes, configurationChange{
+				table:  table.Name,
+				before: fmt.Sprintf("%d rows", len(a.Values)),
+				after:  fmt.Sprintf("%d rows", len(b.Values)),
+			})
+			continue
+		}
+		for valueIndex, valueName := range a.ValueNames {
+			if util.StringInList(valueName, volatileFields) {
+				continue
+			}
+			for row := range a.Values {
+				if valueIndex < len(a.Values[row]) && valueIndex < len(b.Values[row]) && a.Values[row][valueIndex] != b.Values[row][valueIndex] {
+					change := configurationChange{
+						table:  table.Name,
+						field:  valueName,
+						before: a.Values[row][valueIndex],
+						after:  b.Values[row][valueIndex],
+					}
+					if len(a.Values) > 1 && len(a.Values[row]) > 0 {
+						change.row = a.Values[row][0]
+					}
+					changes = append(changes, change)
+				}
+			}
+		}
+	}
+	return
+}
+
+// getChangedConfiguration returns the configuration fields, as "table: field", whose
+// values differ between two hosts in the report
+func getChangedConfiguration(report *Report, hostIndexA int, hostIndexB int) (changed []string) {
+	for _, change := range getConfigurationChanges(report, hostIndexA, hostIndexB) {
+		name := change.table
+		if change.field != "" {
+			name += ": " + change.field
+		}
+		if !util.StringInList(name, changed) {
+			changed = append(changed, name)
+		}
+	}
+	return
+}
+
+// topdownLevel1 are the TMA level 1 categories and their level 2 children
+var topdownLevel1 = [][]string{
+	{"frontend_bound", "fetch_latency", "fetch_bandwidth"},
+	{"bad_speculation", "branch_mispredicts", "machine_clears"},
+	{"backend_bound", "core_bound", "memory_bound"},
+	{"retiring", "heavy_operations", "light_operations"},
+}
+
+// parseTopdown parses the per-socket perf stat CSV output of the topdown command into
+// the TMA metric percentages by socket, e.g., S0, and metric, e.g., backend_bound
+func parseTopdown(lines []string) (sockets []string, metrics map[string]map[string]float64) {
+	metrics = make(map[string]map[string]float64)
+	for _, line := range lines {
+		fields := strings.Split(line, ";")
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "S") {
+			continue
+		}
+		socket, _, _ := strings.Cut(fields[0], "-")
+		if _, err := strconv.Atoi(socket[1:]); err != nil {
+			continue
+		}
+		// the metric's name, with its unit, is in the last field and its value in the
+		// field before it, e.g., 23.4;%  tma_backend_bound
+		name := strings.ToLower(strings.TrimSpace(strings.TrimLeft(fields[len(fields)-1], "% ")))
+		name = strings.ReplaceAll(strings.TrimPrefix(name, "tma_"), " ", "_")
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[len(fields)-2]), 64)
+		if name == "" || err != nil {
+			continue
+		}
+		if _, ok := metrics[socket]; !ok {
+			metrics[socket] = make(map[string]float64)
+			sockets = append(sockets, socket)
+		}
+		metrics[socket][name] = value
+	}
+	return
+}
+
+// getTopdownBottleneck returns the largest of the frontend bound, bad speculation, and
+// backend bound categories, with its largest level 2 child when measured
+func getTopdownBottleneck(metrics map[string]float64, labels map[string]string) (bottleneck string) {
+	var largest []string
+	for _, category := range topdownLevel1[:3] {
+		if value, ok := metrics[category[0]]; ok && (largest == nil || value > metrics[largest[0]]) {
+			largest = category
+		}
+	}
+	if largest == nil {
+		return
+	}
+	bottleneck = labels[largest[0]]
+	child := ""
+	for _, name := range largest[1:] {
+		if value, ok := metrics[name]; ok && (child == "" || value > metrics[child]) {
+			child = name
+		}
+	}
+	if child != "" {
+		bottleneck += ", " + labels[child]
+	}
+	return
+}
+
+// workloadFingerprint characterizes the workload during the profiling window
+type workloadFingerprint struct {
+	numCPUs         int
+	seconds         float64
+	cpuBusy         float64 // percent of CPU time not idle or waiting for IO
+	ioWait          float64 // percent of CPU time
+	runningAvg      float64 // runnable threads
+	runningMax      int
+	blockedAvg      float64 // threads in uninterruptible sleep, usually waiting for IO
+	threadsAvg      float64
+	threadsMax      int
+	contextSwitches float64 // per second
+	forks           float64 // per second
+	majorFaults     float64 // per second
+	swapPages       float64 // pages swapped in and out per second
+}
+
+// parseWorkload parses the workload profile output, kernel counters read at the start and
+// end of the window and thread counts sampled during it
+func parseWorkload(lines []string) (fp workloadFingerprint, ok bool) {
+	counters := make(map[string][]float64) // keyed by "start|end name"
+	var samples int
+	var runningSum, blockedSum, threadsSum int
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "cpus":
+			fp.numCPUs, _ = strconv.Atoi(fields[1])
+		case "sample":
+			if len(fields) != 4 {
+				continue
+			}
+			running, err1 := strconv.Atoi(fields[1])
+			blocked, err2 := strconv.Atoi(fields[2])
+			threads, err3 := strconv.Atoi(fields[3])
+			if err1 != nil || err2 != nil || err3 != nil {
+				continue
+			}
+			samples++
+			runningSum += running
+			blockedSum += blocked
+			threadsSum += threads
+			fp.runningMax = max(fp.runningMax, running)
+			fp.threadsMax = max(fp.threadsMax, threads)
+		case "start", "end":
+			var values []float64
+			for _, field := range fields[2:] {
+				value, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					break
+				}
+				values = append(values, value)
+			}
+			counters[fields[0]+" "+fields[1]] = values
+		}
+	}
+	delta := func(name string, index int) float64 {
+		start, end := counters["start "+name], counters["end "+name]
+		if len(start) <= index || len(end) <= index {
+			return 0
+		}
+		return end[index] - start[index]
+	}
+	fp.seconds = delta("uptime", 0)
+	if fp.seconds <= 0 {
+		return
+	}
+	ok = true
+	// cpu: user, system, idle, iowait, irq+softirq+steal
+	var total float64
+	for i := 0; i < 5; i++ {
+		total += delta("cpu", i)
+	}
+	if total > 0 {
+		fp.ioWait = delta("cpu", 3) / total * 100
+		fp.cpuBusy = 100 - delta("cpu", 2)/total*100 - fp.ioWait
+	}
+	if samples > 0 {
+		fp.runningAvg = float64(runningSum) / float64(samples)
+		fp.blockedAvg = float64(blockedSum) / float64(samples)
+		fp.threadsAvg = float64(threadsSum) / float64(samples)
+	}
+	fp.contextSwitches = delta("ctxt", 0) / fp.seconds
+	fp.forks = delta("processes", 0) / fp.seconds
+	fp.majorFaults = delta("pgmajfault", 0) / fp.seconds
+	fp.swapPages = (delta("pswpin", 0) + delta("pswpout", 0)) / fp.seconds
+	return
+}
+
+// parseSyscalls returns the syscall rate, from the perf stat output, and the most frequent
+// syscalls with their rates, from the bpftrace output
+func parseSyscalls(rateLines []string, countLines []string, seconds float64) (rate float64, top []string) {
+	if seconds <= 0 {
+		return
+	}
+	for _, line := range rateLines {
+		fields := strings.Split(line, ";")
+		if len(fields) > 2 && fields[2] == "raw_syscalls:sys_enter" {
+			if count, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				rate = count / seconds
+			}
+		}
+	}
+	// @syscalls[tracepoint:syscalls:sys_enter_read]: 1234, in ascending order
+	reSyscall := regexp.MustCompile(`^@syscalls\[tracepoint:syscalls:sys_enter_(\w+)\]: (\d+)$`)
+	for _, line := range countLines {
+		if match := reSyscall.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			count, _ := strconv.ParseFloat(match[2], 64)
+			top = append([]string{fmt.Sprintf("%s (%.0f/s)", match[1], count/seconds)}, top...)
+		}
+	}
+	return
+}
+
+// getWorkloadCharacter classifies the workload as CPU, memory, and/or IO bound, or as
+// idle or lightly loaded. The TMA memory bound percentage is used when it was measured.
+func getWorkloadCharacter(fp workloadFingerprint, memoryBound float64) string {
+	var character []string
+	if fp.cpuBusy >= 70 || (fp.numCPUs > 0 && fp.runningAvg >= float64(fp.numCPUs)) {
+		character = append(character, "CPU bound")
+	}
+	if fp.swapPages > 100 || fp.majorFaults > 100 || (memoryBound >= 30 && fp.cpuBusy >= 20) {
+		character = append(character, "Memory bound")
+	}
+	if fp.ioWait >= 10 || (fp.blockedAvg >= 1 && fp.cpuBusy < 50) {
+		character = append(character, "IO bound")
+	}
+	if len(character) > 0 {
+		return strings.Join(character, ", ")
+	}
+	if fp.cpuBusy < 10 {
+		return "Idle"
+	}
+	return "Lightly loaded"
+}
+
+// nicThroughput is the result of the network benchmark for one NIC
+type nicThroughput struct {
+	name      string
+	mode      string // iperf3 (pair) or pktgen (loopback)
+	ratedMbps int    // 0 if unknown
+	mbps      float64
+	err       string
+}
+
+// nicLineRatePercent is the percentage of the rated speed below which a NIC is flagged
+const nicLineRatePercent = 90
+
+// parseNICThroughput parses the output of the network benchmark, a section per NIC
+func parseNICThroughput(lines []string) (nics []nicThroughput) {
+	reSection := regexp.MustCompile(`^########## (\S+)$`)
+	reIperfSender := regexp.MustCompile(`^\[\s*(\w+)\].*\s(\d+\.?\d*) Mbits/sec.*sender$`)
+	rePktgen := regexp.MustCompile(`^thread \d+ (\d+)Mb/sec$`)
+	var sumMbps, streamMbps float64
+	var haveSum bool
+	finish := func() {
+		if len(nics) == 0 {
+			return
+		}
+		nic := &nics[len(nics)-1]
+		if haveSum {
+			nic.mbps = sumMbps
+		} else {
+			nic.mbps = streamMbps
+		}
+	}
+	for _, line := range lines {
+		if match := reSection.FindStringSubmatch(line); match != nil {
+			finish()
+			nics = append(nics, nicThroughput{name: match[1]})
+			sumMbps, streamMbps, haveSum = 0, 0, false
+			continue
+		}
+		if len(nics) == 0 {
+			continue
+		}
+		nic := &nics[len(nics)-1]
+		if speed, found := strings.CutPrefix(line, "speed "); found {
+			// the speed is -1 when the driver doesn't report it
+			if mbps, err := strconv.Atoi(strings.TrimSpace(speed)); err == nil && mbps > 0 {
+				nic.ratedMbps = mbps
+			}
+		} else if mode, found := strings.CutPrefix(line, "mode "); found {
+			nic.mode = mode
+		} else if match := reIperfSender.FindStringSubmatch(line); match != nil {
+			mbps, _ := strconv.ParseFloat(match[2], 64)
+			if match[1] == "SUM" {
+				sumMbps, haveSum = mbps, true
+			} else {
+				streamMbps += mbps
+			}
+		} else if match := rePktgen.FindStringSubmatch(line); match != nil {
+			mbps, _ := strconv.ParseFloat(match[1], 64)
+			streamMbps += mbps
+		} else if reason, found := strings.CutPrefix(line, "skipped: "); found {
+			nic.err = "Skipped, " + reason
+		} else if strings.Contains(line, "error") {
+			nic.err = strings.TrimSpace(strings.TrimPrefix(line, "iperf3: "))
+		}
+	}
+	finish()
+	return
+}
+
+// nvmeDevice is an NVMe controller or namespace, with the nvme-cli JSON output of its
+// identify and SMART commands
+type nvmeDevice struct {
+	name        string
+	idCtrl      map[string]interface{}
+	smart       map[string]interface{}
+	ocpLog      string // hex
+	idNS        map[string]interface{}
+	isNamespace bool
+}
+
+// parseNVMe returns the controllers and namespaces, in name order, from the nvme
+// command's sections, e.g., "nvme0 id-ctrl"
+func parseNVMe(sections map[string]string) (controllers []nvmeDevice, namespaces []nvmeDevice) {
+	devices := make(map[string]*nvmeDevice)
+	var names []string
+	for header, content := range sections {
+		name, kind, found := strings.Cut(header, " ")
+		if !found {
+			continue
+		}
+		device, ok := devices[name]
+		if !ok {
+			device = &nvmeDevice{name: name}
+			devices[name] = device
+			names = append(names, name)
+		}
+		var values map[string]interface{}
+		if kind != "ocp-smart" {
+			if err := json.Unmarshal([]byte(content), &values); err != nil {
+				log.Printf("failed to parse nvme %s output: %v", header, err)
+				continue
+			}
+		}
+		switch kind {
+		case "id-ctrl":
+			device.idCtrl = values
+		case "smart-log":
+			device.smart = values
+		case "ocp-smart":
+			device.ocpLog = strings.TrimSpace(content)
+		case "id-ns":
+			device.idNS = values
+			device.isNamespace = true
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if devices[name].isNamespace {
+			namespaces = append(namespaces, *devices[name])
+		} else {
+			controllers = append(controllers, *devices[name])
+		}
+	}
+	return
+}
+
+// getNVMeValue returns a numeric field from nvme-cli's JSON output, newer versions
+// output 128-bit counters as strings
+func getNVMeValue(values map[string]interface{}, key string) (value float64, ok bool) {
+	switch v := values[key].(type) {
+	case float64:
+		return v, true
+	case string:
+		if f, err := strconv.ParseFloat(strings.ReplaceAll(v, ",", ""), 64); err == nil {
+			return f, true
+		}
+	}
+	return
+}
+
+// ocpSmartLogGUID identifies the OCP SMART / Health Information Extended log, other
+// vendor-specific logs also use log identifier C0h
+var ocpSmartLogGUID = "c5af1028eabff2a49c4f6f7cc914d5af"
+
+// ocpSmartLog is the subset of the OCP SMART / Health Information Extended log (C0h)
+// that is reported
+type ocpSmartLog struct {
+	mediaBytesWritten       float64
+	badUserNANDBlocks       uint64
+	uncorrectableReadErrors uint64
+	throttlingEvents        int
+	throttlingStatus        int
+}
+
+// parseOCPSmartLog decodes the log from its hex representation, the fields are little
+// endian
+func parseOCPSmartLog(hexLog string) (smartLog ocpSmartLog, ok bool) {
+	data, err := hex.DecodeString(hexLog)
+	if err != nil || len(data) != 512 || hex.EncodeToString(data[496:512]) != ocpSmartLogGUID {
+		return
+	}
+	for i := 15; i >= 0; i-- {
+		smartLog.mediaBytesWritten = smartLog.mediaBytesWritten*256 + float64(data[i])
+	}
+	littleEndian := func(offset int, length int) (value uint64) {
+		for i := offset + length - 1; i >= offset; i-- {
+			value = value<<8 | uint64(data[i])
+		}
+		return
+	}
+	smartLog.badUserNANDBlocks = littleEndian(32, 6)
+	smartLog.uncorrectableReadErrors = littleEndian(56, 8)
+	smartLog.throttlingEvents = int(data[96])
+	smartLog.throttlingStatus = int(data[97])
+	ok = true
+	return
+}
+
+// nvmeLBAFormat is one of a namespace's supported LBA formats
+type nvmeLBAFormat struct {
+	dataSize     int // bytes
+	metadataSize int // bytes
+	performance  int // relative performance, 0 is best
+}
+
+// getNVMeLBAFormats returns the namespace's supported LBA formats and the index in
+// formats of the format in use, or -1 if it isn't one of them. Unused formats are
+// skipped, so the index can differ from the format's number in flbas.
+func getNVMeLBAFormats(idNS map[string]interface{}) (formats []nvmeLBAFormat, current int) {
+	// bits 3:0 of flbas are the format's number, bits 6:5 its upper bits when the
+	// namespace supports more than 16 formats
+	flbas, _ := getNVMeValue(idNS, "flbas")
+	inUse := int(flbas)&0xf | (int(flbas)>>5&0x3)<<4
+	current = -1
+	lbafs, _ := idNS["lbafs"].([]interface{})
+	for i, lbaf := range lbafs {
+		values, _ := lbaf.(map[string]interface{})
+		ds, _ := getNVMeValue(values, "ds")
+		ms, _ := getNVMeValue(values, "ms")
+		rp, _ := getNVMeValue(values, "rp")
+		if ds == 0 {
+			continue // unused format
+		}
+		if i == inUse {
+			current = len(formats)
+		}
+		formats = append(formats, nvmeLBAFormat{dataSize: 1 << int(ds), metadataSize: int(ms), performance: int(rp)})
+	}
+	return
+}
+
+// getNVMeFormatEfficiency describes whether the namespace uses its most efficient LBA
+// format, e.g., a 512 byte format (512e) when a 4 KiB format (4Kn) is supported
+func getNVMeFormatEfficiency(formats []nvmeLBAFormat, current int) string {
+	if current < 0 || current >= len(formats) {
+		return ""
+	}
+	inUse := formats[current]
+	for _, format := range formats {
+		if format.metadataSize == inUse.metadataSize && format.dataSize == 4096 && inUse.dataSize < 4096 {
+			return "512e, 4Kn format available"
+		}
+	}
+	for _, format := range formats {
+		if format.metadataSize == inUse.metadataSize && format.performance < inUse.performance {
+			return fmt.Sprintf("Better performing %d byte format available", format.dataSize)
+		}
+	}
+	if inUse.dataSize >= 4096 {
+		return "Optimal (4Kn)"
+	}
+	return "Optimal"
+}
+
+// zonedDevice is a zoned block device, e.g., a ZNS SSD or an SMR drive, with its queue
+// attributes from sysfs
+type zonedDevice struct {
+	name       string
+	attributes map[string]string
+}
+
+// parseZonedDevices returns the zoned block devices, in name order, from the zoned block
+// devices command's output, e.g., /sys/block/nvme0n2/queue/zoned:host-managed
+func parseZonedDevices(lines []string) (devices []zonedDevice) {
+	reAttribute := regexp.MustCompile(`^/sys/block/([^/]+)/queue/(\w+):(.*)$`)
+	attributes := make(map[string]map[string]string)
+	for _, line := range lines {
+		match := reAttribute.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if _, ok := attributes[match[1]]; !ok {
+			attributes[match[1]] = make(map[string]string)
+		}
+		attributes[match[1]][match[2]] = strings.TrimSpace(match[3])
+	}
+	var names []string
+	for name, values := range attributes {
+		if zoned := values["zoned"]; zoned != "" && zoned != "none" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		devices = append(devices, zonedDevice{name: name, attributes: attributes[name]})
+	}
+	return
+}
+
+// reComputationalStorage matches the vendor and device names, from lspci, of
+// computational storage drives and processors
+var reComputationalStorage = regexp.MustCompile(`(?i)(ScaleFlux|SmartSSD|Eideticom|NoLoad|NGD Systems|Newport|Pliops|Computational Storage)`)
+
+// getComputationalStorageType returns the type of a computational storage or offload
+// device, or an empty string if the PCI device isn't one. Intel's processing
+// accelerators are listed in the Accelerator table.
+func getComputationalStorageType(device map[string]string) string {
+	if reComputationalStorage.MatchString(device["Vendor"] + " " + device["Device"] + " " + device["SVendor"] + " " + device["SDevice"]) {
+		if device["Class"] == "Non-Volatile memory controller" {
+			return "Computational storage drive"
+		}
+		return "Computational storage processor"
+	}
+	if device["Class"] == "Processing accelerators" && !strings.Contains(device["Vendor"], "Intel") {
+		return "Processing accelerator"
+	}
+	return ""
+}
+
+// dpuTypes are the DPU and IPU families, matched to the description of their PCI functions
+var dpuTypes = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"NVIDIA BlueField", regexp.MustCompile(`BlueField`)},
+	{"Intel IPU", regexp.MustCompile(`Infrastructure Data Path|IPU E2`)},
+	{"AMD Pensando", regexp.MustCompile(`Pensando`)},
+	{"Marvell OCTEON", regexp.MustCompile(`OCTEON`)},
+}
+
+var reDPURevision = regexp.MustCompile(`\(rev \w+\)$`)
+
+// dpu is a DPU or IPU and its host-visible PCI functions, which share a PCI bus
+type dpu struct {
+	bus        string
+	dpuType    string
+	device     string
+	functions  []string
+	interfaces []string
+	drivers    []string
+	firmware   []string
+	numVFs     int
+	totalVFs   int
+	settings   map[string]string // mlxconfig settings, BlueField only
+}
+
+// parseDPUs returns the DPUs, in PCI bus order, from the dpu command's sections, one per
+// PCI function, e.g., 0000:3b:00.0
+func parseDPUs(sections map[string]string) (dpus []dpu) {
+	var slots []string
+	for slot := range sections {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	byBus := make(map[string]*dpu)
+	var buses []string
+	for _, slot := range slots {
+		bus := slot
+		if idx := strings.LastIndex(slot, ":"); idx > 0 {
+			bus = slot[:idx]
+		}
+		d, ok := byBus[bus]
+		if !ok {
+			d = &dpu{bus: bus, settings: make(map[string]string)}
+			byBus[bus] = d
+			buses = append(buses, bus)
+		}
+		d.functions = append(d.functions, slot)
+		for _, line := range strings.Split(sections[slot], "\n") {
+			key, value, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch key {
+			case "description":
+				if d.device == "" {
+					// e.g., Ethernet controller: Mellanox Technologies MT42822 BlueField-2 ... (rev 01)
+					if _, name, found := strings.Cut(value, ": "); found {
+						value = name
+					}
+					d.device = strings.TrimSpace(reDPURevision.ReplaceAllString(value, ""))
+					for _, t := range dpuTypes {
+						if t.re.MatchString(value) {
+							d.dpuType = t.name
+							break
+						}
+					}
+				}
+			case "interface":
+				d.interfaces = append(d.interfaces, value)
+			case "driver":
+				if !util.StringInList(value, d.drivers) {
+					d.drivers = append(d.drivers, value)
+				}
+			case "firmware-version":
+				if value != "" && !util.StringInList(value, d.firmware) {
+					d.firmware = append(d.firmware, value)
+				}
+			case "sriov_numvfs":
+				vfs, _ := strconv.Atoi(value)
+				d.numVFs += vfs
+			case "sriov_totalvfs":
+				vfs, _ := strconv.Atoi(value)
+				d.totalVFs += vfs
+			case "INTERNAL_CPU_MODEL", "INTERNAL_CPU_OFFLOAD_ENGINE":
+				d.settings[key] = value
+			}
+		}
+	}
+	for _, bus := range buses {
+		dpus = append(dpus, *byBus[bus])
+	}
+	return
+}
+
+// getDPUMode returns the operational mode of a BlueField from its mlxconfig settings: DPU
+// mode, where the Arm cores own the NIC, NIC mode, or separated host mode
+func getDPUMode(d dpu) string {
+	if d.dpuType != "NVIDIA BlueField" {
+		return ""
+	}
+	model, offload := d.settings["INTERNAL_CPU_MODEL"], d.settings["INTERNAL_CPU_OFFLOAD_ENGINE"]
+	switch {
+	case strings.HasPrefix(model, "SEPARATED_HOST"):
+		return "Separated host"
+	case strings.HasPrefix(model, "EMBEDDED_CPU") && strings.HasPrefix(offload, "DISABLED"):
+		return "NIC"
+	case strings.HasPrefix(model, "EMBEDDED_CPU"):
+		return "DPU"
+	}
+	return "Unknown, mlxconfig not found"
+}
+
+// kernelTaintFlags are the reasons the kernel is tainted, by bit, as in the kernel's
+// Documentation/admin-guide/tainted-kernels.rst
+var kernelTaintFlags = []string{
+	"P (proprietary module loaded)",
+	"F (module force loaded)",
+	"S (kernel running on an out of specification system)",
+	"R (module force unloaded)",
+	"M (machine check exception)",
+	"B (bad page referenced)",
+	"U (taint requested by userspace)",
+	"D (kernel died recently, i.e., OOPS or BUG)",
+	"A (ACPI table overridden)",
+	"W (kernel issued warning)",
+	"C (staging driver loaded)",
+	"I (workaround for platform firmware bug applied)",
+	"O (out-of-tree module loaded)",
+	"E (unsigned module loaded)",
+	"L (soft lockup occurred)",
+	"K (kernel live patched)",
+	"X (auxiliary taint)",
+	"T (kernel built with struct randomization plugin)",
+	"N (in-kernel test run)",
+}
+
+// getKernelTaint returns the kernel's taint value and its reasons from the kernel patches
+// command output sections, e.g., "4097: P (proprietary module loaded), O (out-of-tree
+// module loaded)"
+func getKernelTaint(sections map[string]string) string {
+	tainted := strings.TrimSpace(sections["tainted"])
+	if tainted == "" {
+		return ""
+	}
+	value, err := strconv.ParseUint(tainted, 10, 64)
+	if err != nil {
+		return tainted
+	}
+	if value == 0 {
+		return "Not tainted"
+	}
+	var reasons []string
+	for bit := 0; bit < 64; bit++ {
+		if value&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(kernelTaintFlags) {
+			reasons = append(reasons, kernelTaintFlags[bit])
+		} else {
+			reasons = append(reasons, fmt.Sprintf("bit %d", bit))
+		}
+	}
+	return fmt.Sprintf("%d: %s", value, strings.Join(reasons, ", "))
+}
+
+// livePatch is a kernel live patch, applied by the kernel's livepatch facility, e.g.,
+// with kpatch or Canonical Livepatch, or installed with kpatch but not loaded
+type livePatch struct {
+	name       string
+	version    string // the module's version, or the kernel it's for if it isn't loaded
+	loaded     bool
+	enabled    bool
+	transition bool // the patch is being applied to, or removed from, the running tasks
+}
+
+var reLivePatch = regexp.MustCompile(`^(\S+) enabled=(\d*) transition=(\d*) version=(.*)$`)
+var reKpatchInstalled = regexp.MustCompile(`^(\S+) \((\S+)\)$`)
+
+// getLivePatches parses the live patches from the kernel patches command output sections
+func getLivePatches(sections map[string]string) (patches []livePatch) {
+	for _, line := range strings.Split(sections["livepatch"], "\n") {
+		match := reLivePatch.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		patches = append(patches, livePatch{
+			name:       match[1],
+			version:    strings.TrimSpace(match[4]),
+			loaded:     true,
+			enabled:    match[2] == "1",
+			transition: match[3] == "1",
+		})
+	}
+	// kpatch lists the loaded patch modules, which are in the livepatch section, and the
+	// installed patch modules, with the kernel they're for
+	installed := false
+	for _, line := range strings.Split(sections["kpatch"], "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, "patch modules:") {
+			installed = strings.HasPrefix(line, "Installed")
+			continue
+		}
+		match := reKpatchInstalled.FindStringSubmatch(line)
+		if !installed || match == nil {
+			continue
+		}
+		// the module's name in sysfs has underscores rather than dashes
+		name := strings.ReplaceAll(match[1], "-", "_")
+		found := false
+		for _, patch := range patches {
+			found = found || patch.name == name
+		}
+		if !found {
+			patches = append(patches, livePatch{name: match[1], version: match[2]})
+		}
+	}
+	return
+}
+
+// getLivePatchSummary returns the live patches, e.g., "livepatch_1 (1.2), kpatch_2 (installed
+// for kernel 6.4.0, not loaded)", "None" if there are none, or empty if they weren't
+// collected
+func getLivePatchSummary(sections map[string]string) string {
+	if _, ok := sections["livepatch"]; !ok {
+		return ""
+	}
+	var summaries []string
+	for _, patch := range getLivePatches(sections) {
+		var details []string
+		if !patch.loaded {
+			details = append(details, "installed for kernel "+patch.version+", not loaded")
+		} else if patch.version != "" {
+			details = append(details, patch.version)
+		}
+		if patch.loaded && !patch.enabled {
+			details = append(details, "not enabled")
+		}
+		if patch.transition {
+			details = append(details, "in transition")
+		}
+		summary := patch.name
+		if len(details) > 0 {
+			summary += " (" + strings.Join(details, ", ") + ")"
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(summaries) == 0 {
+		return "None"
+	}
+	return strings.Join(summaries, ", ")
+}
+
+// containerRuntime is the container runtimes and Kubernetes node agent installed on a
+// host, and their configuration
+type containerRuntime struct {
+	versions      map[string]string // by runtime, e.g., containerd
+	cgroupDrivers map[string]string // by runtime, systemd or cgroupfs
+	cgroupVersion string
+	deviations    []string // from the recommended configuration
+}
+
+// containerRuntimeNames are the runtimes, in the order they're reported
+var containerRuntimeNames = []string{"docker", "containerd", "crio", "kubelet", "runc"}
+
+var reContainerRuntimeVersions = map[string]*regexp.Regexp{
+	"docker":     regexp.MustCompile(`^(\S+)$`),
+	"containerd": regexp.MustCompile(`^containerd \S+ v?(\S+)`),
+	"crio":       regexp.MustCompile(`^(?:crio version|Version:)\s+(\S+)`),
+	"kubelet":    regexp.MustCompile(`^Kubernetes v?(\S+)`),
+	"runc":       regexp.MustCompile(`^runc version (\S+)`),
+}
+
+// getContainerRuntime parses the container runtime command output sections
+func getContainerRuntime(sections map[string]string) (runtime containerRuntime) {
+	runtime.versions = make(map[string]string)
+	runtime.cgroupDrivers = make(map[string]string)
+	for name, re := range reContainerRuntimeVersions {
+		for _, line := range strings.Split(sections[name], "\n") {
+			if match := re.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+				runtime.versions[name] = match[1]
+				break
+			}
+		}
+	}
+	switch strings.TrimSpace(sections["cgroup"]) {
+	case "cgroup2fs":
+		runtime.cgroupVersion = "v2"
+	case "tmpfs":
+		runtime.cgroupVersion = "v1"
+	}
+	// the settings, e.g., storage_driver=overlay2, SystemdCgroup = true, cgroupDriver: systemd
+	settings := make(map[string]string)
+	for _, section := range []string{"docker info", "sysctl"} {
+		for _, field := range strings.Fields(sections[section]) {
+			if key, value, found := strings.Cut(field, "="); found {
+				settings[key] = value
+			}
+		}
+	}
+	for section, separator := range map[string]string{"containerd config": "=", "crio config": "=", "kubelet config": ":"} {
+		for _, line := range strings.Split(sections[section], "\n") {
+			if key, value, found := strings.Cut(line, separator); found {
+				settings[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+	}
+	// the cgroup drivers, with the runtimes' defaults if they aren't configured
+	if _, ok := runtime.versions["docker"]; ok && settings["cgroup_driver"] != "" {
+		runtime.cgroupDrivers["docker"] = settings["cgroup_driver"]
+	}
+	if _, ok := runtime.versions["containerd"]; ok {
+		runtime.cgroupDrivers["containerd"] = "cgroupfs"
+		if settings["SystemdCgroup"] == "true" {
+			runtime.cgroupDrivers["containerd"] = "systemd"
+		}
+	}
+	if _, ok := runtime.versions["crio"]; ok {
+		runtime.cgroupDrivers["crio"] = "systemd"
+		if settings["cgroup_manager"] != "" {
+			runtime.cgroupDrivers["crio"] = settings["cgroup_manager"]
+		}
+	}
+	_, kubelet := runtime.versions["kubelet"]
+	if kubelet && settings["cgroupDriver"] != "" {
+		runtime.cgroupDrivers["kubelet"] = settings["cgroupDriver"]
+	}
+	// deviations from the recommended configuration
+	for _, name := range containerRuntimeNames {
+		driver := runtime.cgroupDrivers[name]
+		if driver == "" {
+			continue
+		}
+		if name != "kubelet" && runtime.cgroupDrivers["kubelet"] != "" && driver != runtime.cgroupDrivers["kubelet"] {
+			runtime.deviations = append(runtime.deviations, fmt.Sprintf("%s cgroup driver %s doesn't match kubelet's %s", name, driver, runtime.cgroupDrivers["kubelet"]))
+		}
+		if driver == "cgroupfs" && runtime.cgroupVersion == "v2" {
+			runtime.deviations = append(runtime.deviations, fmt.Sprintf("%s uses the cgroupfs cgroup driver with cgroup v2, systemd is recommended", name))
+		}
+	}
+	if storageDriver := settings["storage_driver"]; storageDriver != "" && storageDriver != "overlay2" {
+		runtime.deviations = append(runtime.deviations, fmt.Sprintf("docker storage driver is %s, overlay2 is recommended", storageDriver))
+	}
+	if kubelet {
+		if strings.TrimSpace(sections["swap"]) != "" && settings["failSwapOn"] != "false" {
+			runtime.deviations = append(runtime.deviations, "swap is enabled, kubelet requires failSwapOn: false")
+		}
+		for _, key := range []string{"net.ipv4.ip_forward", "net.bridge.bridge-nf-call-iptables"} {
+			if settings[key] != "1" {
+				runtime.deviations = append(runtime.deviations, fmt.Sprintf("%s is not 1", key))
+			}
+		}
+	}
+	return
+}
+
+// bootEntry is a bootloader menu entry that boots a kernel
+type bootEntry struct {
+	id         string
+	title      string
+	submenu    string // the title of the GRUB submenu the entry is in, if any
+	submenuID  string
+	kernel     string
+	parameters string
+}
+
+// bootloader is the bootloader's configuration
+type bootloader struct {
+	name         string // GRUB or systemd-boot
+	version      string
+	bootMode     string // UEFI or BIOS
+	secureBoot   string
+	shim         []string // package versions
+	entries      []bootEntry
+	defaultEntry int // index in entries, -1 if not known
+}
+
+var reGrubVersion = regexp.MustCompile(`\(GRUB\)\s+(\S+)`)
+var reGrubMenuEntry = regexp.MustCompile(`^\s*(menuentry|submenu)\s+(?:'([^']*)'|"([^"]*)")`)
+var reGrubMenuEntryID = regexp.MustCompile(`\$menuentry_id_option\s+'([^']*)'`)
+
+// getBootloader parses the bootloader command output sections. The entries are in the
+// order GRUB lists them, the GRUB configuration's entries, then the boot loader
+// specification entries, e.g., /boot/loader/entries/*.conf, newest first.
+func getBootloader(sections map[string]string) (b bootloader) {
+	b.defaultEntry = -1
+	b.bootMode = strings.TrimSpace(sections["boot mode"])
+	secureBoot := strings.ToLower(sections["secure boot"])
+	switch {
+	case strings.Contains(secureBoot, "secureboot enabled"):
+		b.secureBoot = "Enabled"
+	case strings.Contains(secureBoot, "secureboot disabled"):
+		b.secureBoot = "Disabled"
+	case strings.Contains(secureBoot, "not support"):
+		b.secureBoot = "Not supported"
+	}
+	for _, line := range strings.Split(sections["shim"], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			b.shim = append(b.shim, line)
+		}
+	}
+	env := make(map[string]string)
+	for _, line := range strings.Split(sections["grubenv"], "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			env[key] = value
+		}
+	}
+	settings := make(map[string]string)
+	for _, line := range strings.Split(sections["default grub"], "\n") {
+		if key, value, found := strings.Cut(strings.TrimSpace(line), "="); found {
+			settings[key] = strings.Trim(value, `"'`)
+		}
+	}
+	// GRUB configuration, the menu entries and their linux commands, with the closing
+	// braces to track the submenus
+	var submenu, submenuID string
+	depth, submenuDepth := 0, 0
+	for _, line := range strings.Split(sections["grub.cfg"], "\n") {
+		if match := reGrubMenuEntry.FindStringSubmatch(line); match != nil {
+			title := match[2] + match[3]
+			id := ""
+			if idMatch := reGrubMenuEntryID.FindStringSubmatch(line); idMatch != nil {
+				id = idMatch[1]
+			}
+			if strings.HasSuffix(strings.TrimSpace(line), "{") {
+				depth++
+			}
+			if match[1] == "submenu" {
+				submenu, submenuID, submenuDepth = title, id, depth
+				continue
+			}
+			b.entries = append(b.entries, bootEntry{id: id, title: title, submenu: submenu, submenuID: submenuID})
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "}" {
+			if depth == submenuDepth {
+				submenu, submenuID, submenuDepth = "", "", 0
+			}
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if len(b.entries) > 0 && len(fields) > 1 && strings.HasPrefix(fields[0], "linux") {
+			b.entries[len(b.entries)-1].kernel = fields[1]
+			b.entries[len(b.entries)-1].parameters = strings.Join(fields[2:], " ")
+		}
+	}
+	// the entries that don't boot a kernel, e.g., UEFI Firmware Settings, aren't reported
+	var entries []bootEntry
+	for _, entry := range b.entries {
+		if entry.kernel != "" {
+			entries = append(entries, entry)
+		}
+	}
+	b.entries = entries
+	// boot loader specification entries
+	var blsEntries []bootEntry
+	for _, line := range strings.Split(sections["loader entries"], "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		value = strings.TrimSpace(value)
+		if key == "entry" {
+			blsEntries = append(blsEntries, bootEntry{id: value})
+			continue
+		}
+		if len(blsEntries) == 0 {
+			continue
+		}
+		entry := &blsEntries[len(blsEntries)-1]
+		switch key {
+		case "title":
+			entry.title = value
+		case "linux":
+			entry.kernel = value
+		case "options":
+			// RHEL 8 keeps the parameters of all entries in the kernelopts variable
+			entry.parameters = strings.TrimSpace(strings.ReplaceAll(value, "$kernelopts", env["kernelopts"]))
+		}
+	}
+	sort.SliceStable(blsEntries, func(i, j int) bool {
+		return blsEntries[i].id > blsEntries[j].id
+	})
+	for _, entry := range blsEntries {
+		if entry.title == "" {
+			entry.title = entry.id
+		}
+		b.entries = append(b.entries, entry)
+	}
+	if match := reGrubVersion.FindStringSubmatch(sections["grub version"]); match != nil {
+		b.name, b.version = "GRUB", match[1]
+	} else if len(env) > 0 || len(settings) > 0 || sections["grub.cfg"] != "" {
+		b.name = "GRUB"
+	} else if len(blsEntries) > 0 {
+		b.name = "systemd-boot"
+	}
+	if len(b.entries) == 0 {
+		return
+	}
+	// the default entry, GRUB_DEFAULT is an index, title, or id, or a path to an entry in
+	// a submenu, e.g., 1>2, or saved to use the entry saved in the GRUB environment
+	defaultEntry := settings["GRUB_DEFAULT"]
+	if defaultEntry == "saved" || (defaultEntry == "" && env["saved_entry"] != "") {
+		defaultEntry = env["saved_entry"]
+	}
+	if defaultEntry == "" {
+		defaultEntry = "0"
+	}
+	b.defaultEntry = findBootEntry(b.entries, defaultEntry)
+	return
+}
+
+// findBootEntry returns the index of the entry that the GRUB_DEFAULT or saved_entry
+// value refers to, or -1
+func findBootEntry(entries []bootEntry, ref string) int {
+	parts := strings.Split(ref, ">")
+	submenu := ""
+	for partIndex, part := range parts {
+		// the items at this level of the menu, the entries and, at the top level, the
+		// submenus, in order
+		type item struct {
+			id, title string
+			entry     int // -1 for a submenu
+		}
+		var items []item
+		for i, entry := range entries {
+			if entry.submenu == submenu {
+				items = append(items, item{entry.id, entry.title, i})
+			} else if submenu == "" && (len(items) == 0 || items[len(items)-1].title != entry.submenu) {
+				items = append(items, item{entry.submenuID, entry.submenu, -1})
+			}
+		}
+		found := false
+		for i, it := range items {
+			if index, err := strconv.Atoi(part); (err == nil && index == i) || part == it.title || (it.id != "" && part == it.id) {
+				if it.entry >= 0 {
+					if partIndex == len(parts)-1 {
+						return it.entry
+					}
+					return -1
+				}
+				submenu, found = it.title, true
+				break
+			}
+		}
+		if !found {
+			return -1
+		}
+	}
+	return -1
+}
+
+// isRecoveryBootEntry returns true if the entry boots a recovery or rescue environment,
+// whose parameters are expected to differ from the default entry's
+func isRecoveryBootEntry(entry bootEntry) bool {
+	title := strings.ToLower(entry.title + " " + entry.id)
+	if strings.Contains(title, "recovery") || strings.Contains(title, "rescue") {
+		return true
+	}
+	return util.StringInList("single", strings.Fields(entry.parameters))
+}
+
+// getBootParameterDifferences returns the kernel parameters that are added and removed in
+// the command line compared to the reference command line, e.g., "+mitigations=off
+// -quiet". The kernel image, initrd, and the parameters set by GRUB variables, e.g.,
+// $vt_handoff, aren't compared.
+func getBootParameterDifferences(reference string, cmdline string) string {
+	parameters := func(cmdline string) (params []string) {
+		for _, param := range strings.Fields(cmdline) {
+			name, _, _ := strings.Cut(param, "=")
+			if !strings.HasPrefix(param, "$") && !util.StringInList(name, []string{"BOOT_IMAGE", "initrd", "vt.handoff"}) {
+				params = append(params, param)
+			}
+		}
+		return
+	}
+	referenceParams, params := parameters(reference), parameters(cmdline)
+	var differences []string
+	for _, param := range params {
+		if !util.StringInList(param, referenceParams) {
+			differences = append(differences, "+"+param)
+		}
+	}
+	for _, param := range referenceParams {
+		if !util.StringInList(param, params) {
+			differences = append(differences, "-"+param)
+		}
+	}
+	return strings.Join(differences, " ")
+}
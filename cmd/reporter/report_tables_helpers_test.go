@@ -0,0 +1,154 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newProfileSource(sections map[string]string) (source *Source) {
+	source = newSource("")
+	var stdout []string
+	for name, content := range sections {
+		stdout = append(stdout, "########## "+name+" ##########", content)
+	}
+	source.ParsedData["profile"] = CommandData{Label: "profile", ExitStatus: "0", Stdout: strings.Join(stdout, "\n")}
+	return
+}
+
+func TestProfileClock(t *testing.T) {
+	// 23:59:58 on a target five hours behind UTC, i.e., 04:59:58 UTC
+	start := int64(19000*secondsPerDay + 4*3600 + 59*60 + 58)
+	source := newProfileSource(map[string]string{
+		"clock": fmt.Sprintf("%d 23:59:58 1234.56 2", start),
+		"pidstat": strings.Join([]string{
+			"00:00:00      UID       PID    %usr %system  %guest   %wait    %CPU   CPU  Command",
+			"00:00:00        0       101   90.00    0.00    0.00    0.00   90.00     1  before",
+			"00:00:02        0       102   80.00    0.00    0.00    0.00   80.00     2  after",
+			"00:00:04        0       102   60.00    0.00    0.00    0.00   60.00     2  after",
+		}, "\n"),
+		"stacks": strings.Join([]string{
+			"0 stress;main;spin 6",
+			"2 stress;main;spin 2",
+			"2 stress;main;idle 2",
+			"4 swapper;do_idle 5",
+		}, "\n"),
+	})
+	clock := source.getProfileClock()
+	if !clock.zoned || clock.start != start || clock.interval != 2 {
+		t.Fatalf("unexpected clock: %+v", clock)
+	}
+	// the times after midnight follow the start
+	for hms, expected := range map[string]int64{"23:59:58": start, "00:00:00": start + 2, "00:01:00": start + 62, "23:59:57": start - 1} {
+		if epoch, err := clock.epoch(hms); err != nil || epoch != expected {
+			t.Errorf("%s : expected %d, got %d (%v)", hms, expected, epoch, err)
+		}
+	}
+	// the target's local time, not the reporter's
+	if formatted := clock.format(start + 3); formatted != "00:00:01" {
+		t.Errorf("expected 00:00:01, got %s", formatted)
+	}
+	samples := getProcessCPUSamples(source, clock)
+	if len(samples) != 3 {
+		t.Fatalf("unexpected samples: %v", samples)
+	}
+	if top := getTopProcesses(samples, start+4, start+6, 3); top != "after (102) 70.0%" {
+		t.Errorf("unexpected top processes: %s", top)
+	}
+	stacks := source.getProfileStacks()
+	if len(stacks) != 4 {
+		t.Fatalf("unexpected stacks: %v", stacks)
+	}
+	// the samples at 00:00:02 and 00:00:04 cover the intervals from 2 to 6 seconds
+	folded := foldStacks(stacks, 2, 6)
+	if folded != "stress;main;idle 2\nstress;main;spin 2\nswapper;do_idle 5" {
+		t.Errorf("unexpected folded stacks: %q", folded)
+	}
+	if hot := getHotCodePaths(folded, 2); hot != "do_idle 55.6%, idle 22.2%" {
+		t.Errorf("unexpected hot code paths: %s", hot)
+	}
+}
+
+func TestProfileClockWithoutClock(t *testing.T) {
+	source := newProfileSource(map[string]string{
+		"mpstat": strings.Join([]string{
+			"23:59:59     CPU    %usr   %nice    %sys %iowait    %irq   %soft  %steal  %guest  %gnice   %idle",
+			"23:59:59     all    1.00    0.00    1.00    0.00    0.00    0.00    0.00    0.00    0.00   98.00",
+		}, "\n"),
+	})
+	clock := source.getProfileClock()
+	if clock.zoned {
+		t.Fatalf("unexpected clock: %+v", clock)
+	}
+	if epoch, err := clock.epoch("00:00:01"); err != nil || epoch != clock.start+2 {
+		t.Errorf("expected %d, got %d (%v)", clock.start+2, epoch, err)
+	}
+	if formatted := clock.format(clock.start + 2); formatted != "00:00:01" {
+		t.Errorf("expected 00:00:01, got %s", formatted)
+	}
+}
+
+func TestGetNVMeLBAFormats(t *testing.T) {
+	var idNS map[string]interface{}
+	// the unused format 1 is skipped, format 2 is in use
+	content := `{"flbas": 2, "lbafs": [{"ms": 0, "ds": 9, "rp": 2}, {"ms": 0, "ds": 0, "rp": 0}, {"ms": 0, "ds": 12, "rp": 0}]}`
+	if err := json.Unmarshal([]byte(content), &idNS); err != nil {
+		t.Fatal(err)
+	}
+	formats, current := getNVMeLBAFormats(idNS)
+	if len(formats) != 2 || current != 1 || formats[current].dataSize != 4096 {
+		t.Fatalf("unexpected formats: %v, current %d", formats, current)
+	}
+	if efficiency := getNVMeFormatEfficiency(formats, current); efficiency != "Optimal (4Kn)" {
+		t.Errorf("unexpected efficiency: %s", efficiency)
+	}
+	// the in-use format is unused
+	idNS["flbas"] = float64(1)
+	if formats, current = getNVMeLBAFormats(idNS); current != -1 || getNVMeFormatEfficiency(formats, current) != "" {
+		t.Errorf("unexpected current format: %d", current)
+	}
+	// the upper bits of the format's number, bits 6:5 of flbas
+	lbafs := make([]interface{}, 18)
+	for i := range lbafs {
+		lbafs[i] = map[string]interface{}{"ms": float64(i), "ds": float64(9), "rp": float64(0)}
+	}
+	idNS = map[string]interface{}{"flbas": float64(0x21), "lbafs": lbafs}
+	if formats, current = getNVMeLBAFormats(idNS); current != 17 || formats[current].metadataSize != 17 {
+		t.Errorf("unexpected current format: %d", current)
+	}
+}
+
+func TestParseBpftraceHistogram(t *testing.T) {
+	buckets := parseBpftraceHistogram([]string{
+		"@usecs:",
+		"[0]                    3 |@                                                   |",
+		"[4, 8)               461 |@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@|",
+		"[8, 16)               12 |@                                                   |",
+		"",
+	})
+	if fmt.Sprint(buckets) != "[[0 3] [4-8 461] [8-16 12]]" {
+		t.Errorf("unexpected buckets: %v", buckets)
+	}
+}
+
+func TestTCPRetransmitsTable(t *testing.T) {
+	source := newProfileSource(map[string]string{
+		"tcpretrans": strings.Join([]string{
+			"Attaching 1 probe...",
+			"@retransmits[::ffff:10.0.0.1, 443]: 2",
+			"@retransmits[fe80::1, 22]: 1",
+			"@retransmits[::ffff:192.168.1.20, 8080]: 7",
+		}, "\n"),
+	})
+	table := newTCPRetransmitsTable([]*Source{source}, NoCategory)
+	// sorted by count, the IPv4-mapped addresses in their IPv4 form
+	expected := "[[192.168.1.20 8080 7] [10.0.0.1 443 2] [fe80::1 22 1]]"
+	if values := fmt.Sprint(table.AllHostValues[0].Values); values != expected {
+		t.Errorf("expected %s, got %s", expected, values)
+	}
+}
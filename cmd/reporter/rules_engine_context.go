@@ -17,9 +17,10 @@ import (
 // can call the exported functions below and access any exported data in the
 // struct (currently none)
 type RulesEngineContext struct {
-	insightTable *Table
-	reportsData  []*Report
-	sourceIdx    int
+	insightTable     *Table
+	remediationTable *Table
+	reportsData      []*Report
+	sourceIdx        int
 }
 
 // GetValue returns a string value from a table
@@ -112,6 +113,33 @@ func (r *RulesEngineContext) GetValuesFromColumn(reportName string, tableName st
 	return
 }
 
+// GetValuesFromNamedColumn returns all values in the named column as a string (comma
+// separated list), so that rules don't depend on the order of the table's columns
+func (r *RulesEngineContext) GetValuesFromNamedColumn(reportName string, tableName string, valueName string) (values string) {
+	var reportData *Report
+	for _, rd := range r.reportsData {
+		if rd.InternalName == reportName {
+			reportData = rd
+			break
+		}
+	}
+	if reportData == nil {
+		log.Printf("report specified in rule not found: %s", reportName)
+		return
+	}
+	table := reportData.findTable(tableName)
+	if table == nil {
+		log.Printf("table specified in rule not found: %s", tableName)
+		return
+	}
+	valueIndex, err := findValueIndex(&table.AllHostValues[r.sourceIdx], valueName)
+	if err != nil {
+		log.Printf("failed to get values from table, %s:%s, %v", tableName, valueName, err)
+		return
+	}
+	return r.GetValuesFromColumn(reportName, tableName, int64(valueIndex))
+}
+
 // GetValuesFromRow returns all values in specified row as a string (comma separated list)
 func (r *RulesEngineContext) GetValuesFromRow(reportName string, tableName string, valueIndex int64) (values string) {
 	var reportData *Report
@@ -289,3 +317,16 @@ func (r *RulesEngineContext) AddInsight(justification string, recommendation str
 		[]string{recommendation, justification},
 	)
 }
+
+// AddRemediation -- appends the shell commands that implement the most recently
+// added insight's recommendation to the remediation table
+func (r *RulesEngineContext) AddRemediation(commands string) {
+	insights := r.insightTable.AllHostValues[r.sourceIdx].Values
+	if len(insights) == 0 {
+		return
+	}
+	r.remediationTable.AllHostValues[r.sourceIdx].Values = append(
+		r.remediationTable.AllHostValues[r.sourceIdx].Values,
+		[]string{insights[len(insights)-1][0], insights[len(insights)-1][1], commands},
+	)
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/intel/svr-info/internal/rawdata"
+)
+
+// Collected data is migrated to the current schema version when it's parsed, so that the
+// reports don't depend on the version of svr-info that collected it. Each migration
+// upgrades the data from the version before it, in order.
+
+// schemaMigrations upgrade collected data to the schema version at their index + 1
+var schemaMigrations = []func(s *Source){
+	migrateToSchema1,
+	migrateToSchema2,
+}
+
+// migrate upgrades the collected data to the current schema version. Data from a newer
+// version is used as is, the commands and fields that this reporter doesn't know about
+// are ignored.
+func (s *Source) migrate() {
+	version, err := rawdata.ParseSchemaVersion(s.getCommandOutput(rawdata.SchemaVersionLabel))
+	if err != nil {
+		log.Printf("%s: %v, not migrating the collected data", s.inputFilePath, err)
+		return
+	}
+	if version > rawdata.SchemaVersion {
+		log.Printf("%s: collected data schema version %d is newer than this reporter's, %d, some data may not be reported", s.inputFilePath, version, rawdata.SchemaVersion)
+		return
+	}
+	for ; version < rawdata.SchemaVersion; version++ {
+		schemaMigrations[version](s)
+	}
+}
+
+// migrateToSchema1 sets the privileges of the commands run by collectors that didn't
+// record how they ran
+func migrateToSchema1(s *Source) {
+	for label, data := range s.ParsedData {
+		if data.Privileges != "" || data.Command == "" {
+			continue
+		}
+		if data.SuperUser == "true" {
+			data.Privileges = "superuser"
+		} else {
+			data.Privileges = "user"
+		}
+		s.ParsedData[label] = data
+	}
+}
+
+// migrateToSchema2 saves the quantities, in canonical units, that can be derived from
+// the commands of collectors that didn't save them, i.e., the memory size and the CPU
+// frequencies
+func migrateToSchema2(s *Source) {
+	if _, ok := s.ParsedData["quantities"]; ok {
+		return
+	}
+	var lines []string
+	if match := regexp.MustCompile(`(?m)^MemTotal:\s*(\d+) kB$`).FindStringSubmatch(s.ParsedData["/proc/meminfo"].Stdout); match != nil {
+		if kB, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			lines = append(lines, fmt.Sprintf("memory_total %d %s", kB*1024, UnitBytes))
+		}
+	}
+	// the cpufreq files are in kHz
+	for _, frequency := range []struct{ label, name string }{{"base frequency", "cpu_base_frequency"}, {"maximum frequency", "cpu_cpuinfo_max_freq"}} {
+		if kHz, err := strconv.ParseInt(strings.TrimSpace(s.ParsedData[frequency.label].Stdout), 10, 64); err == nil {
+			lines = append(lines, fmt.Sprintf("%s %d %s", frequency.name, kHz*1000, UnitHertz))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	s.ParsedData["quantities"] = CommandData{
+		Label:      "quantities",
+		ExitStatus: "0",
+		Stdout:     strings.Join(lines, "\n"),
+		SuperUser:  "false",
+		Privileges: "user",
+	}
+}
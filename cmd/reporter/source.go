@@ -2,11 +2,12 @@
  * Copyright (C) 2023 Intel Corporation
  * SPDX-License-Identifier: MIT
  */
-/* Reads, parses, and provides access functions to json-formatted data file produced by the collector */
+/* Reads, parses, and provides access functions to json or gob formatted data file produced by the collector */
 
 package main
 
 import (
+	"bufio"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -16,6 +17,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/intel/svr-info/internal/rawdata"
 )
 
 type CommandData struct {
@@ -25,12 +30,20 @@ type CommandData struct {
 	Stderr     string `json:"stderr"`
 	Stdout     string `json:"stdout"`
 	SuperUser  string `json:"superuser"`
+	// how the command was run: user, root, sudo, or capabilities, empty if collected by
+	// an older collector
+	Privileges   string `json:"privileges"`
+	Capabilities string `json:"capabilities"`
 }
 
 type Source struct {
 	inputFilePath string
 	Hostname      string
 	ParsedData    map[string]CommandData // command label string: command data structure
+	runOf         string                 // hostname, when the source is one of several runs of the same host
+	run           int                    // run number, starting at 1, in order of collection time
+	readsMutex    sync.Mutex
+	reads         []string // labels of the commands read that weren't collected, see takeNotCollectedReads
 }
 
 func newSource(inputFilePath string) (source *Source) {
@@ -43,25 +56,83 @@ func newSource(inputFilePath string) (source *Source) {
 }
 
 func (s *Source) parse() (err error) {
-	inputBytes, err := os.ReadFile(s.inputFilePath)
+	if strings.HasSuffix(s.inputFilePath, rawdata.GobExtension) {
+		err = s.parseGob()
+	} else {
+		err = s.parseJSON()
+	}
 	if err != nil {
 		return
 	}
-	var jsonData map[string][]CommandData // hostname: array of command data (this is the format of collector output file)
-	err = json.Unmarshal(inputBytes, &jsonData)
+	s.migrate()
+	return
+}
+
+func (s *Source) parseJSON() (err error) {
+	f, err := os.Open(s.inputFilePath)
 	if err != nil {
 		return
 	}
-	// get the hostname
-	var hostname string
-	for hostname = range jsonData {
-		break
+	defer f.Close()
+	// the collector output file is an object with the hostname as its only key and an
+	// array of command data as its value, decode the command data one at a time so that
+	// the file's content and the decoded array aren't held in memory with the map
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if err = expectJSONDelim(dec, '{'); err != nil {
+		return
+	}
+	if !dec.More() {
+		return // no hosts
+	}
+	token, err := dec.Token()
+	if err != nil {
+		return
+	}
+	s.Hostname = token.(string) // object keys are strings
+	if err = expectJSONDelim(dec, '['); err != nil {
+		return
 	}
-	s.Hostname = hostname
 	// put the data in a map for faster lookup by command label
-	for _, c := range jsonData[hostname] {
+	for dec.More() {
+		var c CommandData
+		if err = dec.Decode(&c); err != nil {
+			return
+		}
 		s.ParsedData[c.Label] = c
 	}
+	err = expectJSONDelim(dec, ']')
+	return
+}
+
+// expectJSONDelim reads the next token and returns an error if it isn't the delimiter
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) (err error) {
+	token, err := dec.Token()
+	if err != nil {
+		return
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		err = fmt.Errorf("invalid collector output, expected %s at offset %d", delim, dec.InputOffset())
+	}
+	return
+}
+
+// parseGob reads the results from a raw.gob file one at a time, i.e., without holding
+// the encoded collection in memory
+func (s *Source) parseGob() (err error) {
+	err = rawdata.ReadGobFile(s.inputFilePath, func(hostname string, result rawdata.Result) error {
+		s.Hostname = hostname
+		s.ParsedData[result["label"]] = CommandData{
+			Command:      result["command"],
+			ExitStatus:   result["exitstatus"],
+			Label:        result["label"],
+			Stderr:       result["stderr"],
+			Stdout:       result["stdout"],
+			SuperUser:    result["superuser"],
+			Privileges:   result["privileges"],
+			Capabilities: result["capabilities"],
+		}
+		return nil
+	})
 	return
 }
 
@@ -69,10 +140,87 @@ func (s *Source) getHostname() (hostname string) {
 	return s.Hostname
 }
 
+// readOnlyLabel labels the collector's list of the commands it skipped in read-only mode
+const readOnlyLabel = "read-only mode"
+
+// readOnlyGuarantee is stated in the reports of hosts collected in read-only mode
+const readOnlyGuarantee = "Read-only collection: no commands that write to disk outside of svr-info's temporary directory, write MSRs, change kernel settings, or load kernel modules were run."
+
+// isReadOnly is true if the data was collected in read-only mode
+func (s *Source) isReadOnly() bool {
+	_, ok := s.ParsedData[readOnlyLabel]
+	return ok
+}
+
+// containerLabel labels the collector's identification of the container it ran in, on
+// the first line, followed by the commands it skipped because the host's hardware isn't
+// accessible
+const containerLabel = "container"
+
+// containerNote is stated in the reports of hosts collected in a container
+const containerNote = "Container collection: values are from the container's view of the host. Commands that read host hardware that isn't accessible from the container were skipped, see Container Skipped Commands."
+
+// getContainer returns the type of container the data was collected in, e.g., lxc, or
+// an empty string if it wasn't collected in a container
+func (s *Source) getContainer() string {
+	lines := s.getCommandOutputLines(containerLabel)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(lines[0])
+}
+
+// notCollectedLabel labels the orchestrator's list of the commands that weren't collected
+// because the collection was interrupted, one per line: label<TAB>reason
+const notCollectedLabel = "not collected"
+
+// notCollectedNote is stated in the reports of hosts whose collection was interrupted
+const notCollectedNote = "Partial collection: the collection was interrupted, so some commands weren't collected and the tables that depend on them are marked not collected, see Not Collected Commands."
+
+// getNotCollected returns the reason each command wasn't collected, by label, or nil if
+// the collection wasn't interrupted
+func (s *Source) getNotCollected() (reasons map[string]string) {
+	c, ok := s.ParsedData[notCollectedLabel]
+	if !ok {
+		return
+	}
+	reasons = make(map[string]string)
+	for _, line := range strings.Split(c.Stdout, "\n") {
+		if label, reason, found := strings.Cut(line, "\t"); found {
+			reasons[label] = reason
+		}
+	}
+	return
+}
+
+// takeNotCollectedReads returns the labels of the commands that weren't collected that
+// were read since it was last called, so that they can be attributed to the table that
+// was built in between
+func (s *Source) takeNotCollectedReads() (labels []string) {
+	s.readsMutex.Lock()
+	defer s.readsMutex.Unlock()
+	labels = s.reads
+	s.reads = nil
+	return
+}
+
+// getCollectionTime returns the time the data was collected, or the zero time if unknown
+func (s *Source) getCollectionTime() (t time.Time) {
+	t, err := time.Parse(time.UnixDate, strings.TrimSpace(s.getCommandOutput("date -u")))
+	if err != nil {
+		t = time.Time{}
+	}
+	return
+}
+
 // return command output or empty string if no match
 func (s *Source) getCommandOutput(cmdLabel string) (output string) {
 	if c, ok := s.ParsedData[cmdLabel]; ok {
 		output = c.Stdout
+	} else if _, ok := s.getNotCollected()[cmdLabel]; ok {
+		s.readsMutex.Lock()
+		s.reads = append(s.reads, cmdLabel)
+		s.readsMutex.Unlock()
 	}
 	return
 }
@@ -294,15 +442,23 @@ func (s *Source) valsArrayFromDmiDecodeRegexSubmatch(dmiType string, regexes ...
 
 // return all PCI Devices of specified class
 func (s *Source) getPCIDevices(class string) (devices []map[string]string) {
+	for _, device := range s.getAllPCIDevices() {
+		if device["Class"] == class {
+			devices = append(devices, device)
+		}
+	}
+	return
+}
+
+// return all PCI Devices
+func (s *Source) getAllPCIDevices() (devices []map[string]string) {
 	device := make(map[string]string)
 	cmdout := s.getCommandOutput("lspci -vmm")
 	re := regexp.MustCompile(`^(\w+):\s+(.*)$`)
 	for _, line := range strings.Split(cmdout, "\n") {
 		if line == "" { // end of device
-			if devClass, ok := device["Class"]; ok {
-				if devClass == class {
-					devices = append(devices, device)
-				}
+			if _, ok := device["Class"]; ok {
+				devices = append(devices, device)
 			}
 			device = make(map[string]string)
 			continue
@@ -333,6 +489,84 @@ func (s *Source) getProfileLines(profileRegex string) (lines []string) {
 	return
 }
 
+// getProfileClock parses the start of the profiling window recorded by the collector:
+// the epoch seconds, the local time of day, the uptime, and the sampling interval.
+// Collections from before the clock was recorded start the window at the first mpstat
+// sample, and their time zone is unknown.
+func (s *Source) getProfileClock() (clock profileClock) {
+	clock.interval = 1
+	for _, line := range s.getProfileLines("clock") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		start, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		timeOfDay, err := parseTimeOfDay(fields[1])
+		if err != nil {
+			continue
+		}
+		if interval, err := strconv.ParseInt(fields[3], 10, 64); err == nil && interval > 0 {
+			clock.interval = interval
+		}
+		clock.start, clock.timeOfDay, clock.zoned = start, timeOfDay, true
+		return
+	}
+	re := regexp.MustCompile(`^(\d\d:\d\d:\d\d)\s+\S+\s+\d`)
+	for _, line := range s.getProfileLines("mpstat") {
+		if match := re.FindStringSubmatch(line); match != nil {
+			if timeOfDay, err := parseTimeOfDay(match[1]); err == nil {
+				// the first day of the epoch, in UTC
+				clock.start, clock.timeOfDay = timeOfDay, timeOfDay
+				return
+			}
+		}
+	}
+	return
+}
+
+// getProfileStacks parses the call stacks sampled during profiling, folded per sampling
+// interval: the interval's start, in seconds from the start of the window, the stack,
+// and its count
+func (s *Source) getProfileStacks() (stacks []profileStack) {
+	for _, line := range s.getProfileLines("stacks") {
+		first := strings.Index(line, " ")
+		last := strings.LastIndex(line, " ")
+		if first < 0 || last <= first {
+			continue
+		}
+		offset, err := strconv.ParseInt(line[:first], 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.Atoi(line[last+1:])
+		if err != nil {
+			continue
+		}
+		stacks = append(stacks, profileStack{offset: offset, stack: line[first+1 : last], count: count})
+	}
+	return
+}
+
+// getQuantities parses the sizes, frequencies, and speeds that the collector saves in
+// canonical units, one "name value unit" per line
+func (s *Source) getQuantities() (quantities []quantity) {
+	for _, line := range s.getCommandOutputLines("quantities") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		quantities = append(quantities, quantity{name: fields[0], value: value, unit: fields[2]})
+	}
+	return
+}
+
 func (s *Source) getOperatingSystem() (os string) {
 	os = s.valFromRegexSubmatch("/etc/*-release", `^PRETTY_NAME=\"(.+?)\"`)
 	centos := s.valFromRegexSubmatch("/etc/*-release", `^(CentOS Linux release .*)`)
@@ -686,6 +920,24 @@ func (s *Source) getFeatures() (vals []string) {
 	return
 }
 
+// getCPUFlags returns the CPU feature flags reported by the kernel for the first processor
+func (s *Source) getCPUFlags() (flags []string) {
+	val := s.valFromRegexSubmatch("/proc/cpuinfo", `^flags\s*:\s*(.*)$`)
+	if val == "" {
+		val = s.valFromRegexSubmatch("lscpu", `^Flags.*:\s*(.*)$`)
+	}
+	flags = strings.Fields(val)
+	return
+}
+
+// getIOMMUs returns the IOMMU devices enabled by the kernel, e.g., dmar0 when VT-d is enabled
+func (s *Source) getIOMMUs() (iommus []string) {
+	for _, line := range s.getCommandOutputLines("iommu") {
+		iommus = append(iommus, strings.Fields(line)...)
+	}
+	return
+}
+
 func (s *Source) getPPINs() (val string) {
 	ppins := s.getCommandOutputLines("rdmsr 0x4f")
 	uniquePpins := []string{}
@@ -773,6 +1025,11 @@ func (s *Source) getMemoryNUMABalancing() (val string) {
 		val = "Enabled"
 	} else if out == "0" {
 		val = "Disabled"
+	} else if out == "2" {
+		// promotes hot pages from slower memory tiers
+		val = "Memory Tiering"
+	} else if out == "3" {
+		val = "Enabled, Memory Tiering"
 	}
 	return
 }
@@ -962,6 +1219,77 @@ func (s *Source) getPowerPerfPolicy() (val string) {
 	return
 }
 
+// getHWP returns the Hardware P-States status from IA32_PM_ENABLE, or whether the CPU
+// supports HWP when the MSR can't be read
+func (s *Source) getHWP() (val string) {
+	switch s.getCommandOutputLine("rdmsr 0x770") {
+	case "1":
+		val = "Enabled"
+		return
+	case "0":
+		val = "Disabled"
+		return
+	}
+	for _, flag := range strings.Fields(s.valFromRegexSubmatch("lscpu", `^Flags:\s*(.+?)$`)) {
+		if flag == "hwp" {
+			val = "Supported"
+			break
+		}
+	}
+	return
+}
+
+// getFrequencyBoost returns the turbo status reported by the frequency driver
+func (s *Source) getFrequencyBoost() (val string) {
+	noTurbo := s.valFromRegexSubmatch("cpufreq boost", `^no_turbo: (\d)$`)
+	boost := s.valFromRegexSubmatch("cpufreq boost", `^boost: (\d)$`)
+	if noTurbo == "0" || boost == "1" {
+		val = "Enabled"
+	} else if noTurbo == "1" {
+		val = "Disabled (no_turbo)"
+	} else if boost == "0" {
+		val = "Disabled"
+	}
+	return
+}
+
+// getCPUFreqPolicies returns the settings of each cpufreq policy, keyed by setting name,
+// e.g., cpus, governor, min, max, and epp
+func (s *Source) getCPUFreqPolicies() (policies []map[string]string) {
+	rePolicy := regexp.MustCompile(`^policy\d+\s`)
+	reSetting := regexp.MustCompile(`(\w+)=(\S*)`)
+	for _, line := range s.getCommandOutputLines("cpufreq policies") {
+		if !rePolicy.MatchString(line) {
+			continue
+		}
+		policy := make(map[string]string)
+		for _, match := range reSetting.FindAllStringSubmatch(line, -1) {
+			policy[match[1]] = match[2]
+		}
+		policies = append(policies, policy)
+	}
+	return
+}
+
+// getIntelSpeedSelectSections returns the sections of the intel-speed-select output,
+// keeping the indentation that gives the nesting of the values
+func (s *Source) getIntelSpeedSelectSections() (sections map[string]string) {
+	sections = make(map[string]string)
+	reHeader := regexp.MustCompile(`^##########\s+(.+)\s+##########$`)
+	var header string
+	for _, line := range strings.Split(s.getCommandOutput("intel-speed-select"), "\n") {
+		if match := reHeader.FindStringSubmatch(line); match != nil {
+			header = match[1]
+			sections[header] = ""
+			continue
+		}
+		if header != "" {
+			sections[header] += line + "\n"
+		}
+	}
+	return
+}
+
 func (s *Source) getTDP() (val string) {
 	msrHex := s.getCommandOutputLine("rdmsr 0x610")
 	msr, err := strconv.ParseInt(msrHex, 16, 0)
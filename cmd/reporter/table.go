@@ -29,10 +29,11 @@ const (
 	CXL
 	Security
 	Status
+	CPUEfficiency
 	NoCategory
 )
 
-var TableCategoryLabels = []string{"System", "Software", "CPU", "Power", "Memory", "Network", "Storage", "GPU", "CXL", "Security", "Status"}
+var TableCategoryLabels = []string{"System", "Software", "CPU", "Power", "Memory", "Network", "Storage", "GPU", "CXL", "Security", "Status", "CPU Efficiency"}
 
 // Table ... all hosts
 type Table struct {
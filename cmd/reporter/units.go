@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// canonical units used when normalizing quantities
+const (
+	UnitBytes          = "B"
+	UnitHertz          = "Hz"
+	UnitBytesPerSecond = "B/s"
+	UnitTransfers      = "T/s"
+)
+
+type unitConversion struct {
+	canonical  string
+	multiplier float64
+}
+
+// unitConversions maps the units found in the collected data to canonical units.
+// Memory, cache, and storage sizes are reported by the kernel and dmidecode in
+// binary units even when labeled "KB", "MB", etc., so they are treated as such.
+// Frequencies, transfer rates, and network speeds are decimal.
+var unitConversions = map[string]unitConversion{
+	"B":    {UnitBytes, 1},
+	"kB":   {UnitBytes, 1 << 10},
+	"KB":   {UnitBytes, 1 << 10},
+	"KiB":  {UnitBytes, 1 << 10},
+	"MB":   {UnitBytes, 1 << 20},
+	"MiB":  {UnitBytes, 1 << 20},
+	"GB":   {UnitBytes, 1 << 30},
+	"GiB":  {UnitBytes, 1 << 30},
+	"TB":   {UnitBytes, 1 << 40},
+	"TiB":  {UnitBytes, 1 << 40},
+	"Hz":   {UnitHertz, 1},
+	"KHz":  {UnitHertz, 1e3},
+	"kHz":  {UnitHertz, 1e3},
+	"MHz":  {UnitHertz, 1e6},
+	"GHz":  {UnitHertz, 1e9},
+	"MT/s": {UnitTransfers, 1e6},
+	"GT/s": {UnitTransfers, 1e9},
+	"B/s":  {UnitBytesPerSecond, 1},
+	"KB/s": {UnitBytesPerSecond, 1e3},
+	"MB/s": {UnitBytesPerSecond, 1e6},
+	"GB/s": {UnitBytesPerSecond, 1e9},
+	"Kb/s": {UnitBytesPerSecond, 1e3 / 8},
+	"Mb/s": {UnitBytesPerSecond, 1e6 / 8},
+	"Gb/s": {UnitBytesPerSecond, 1e9 / 8},
+}
+
+// sizeSuffixes are the bare suffixes, e.g., lsblk's "1.8T" and lscpu's "48K", that are
+// binary multiples of bytes in sizes. Elsewhere, e.g., "100M" packets, they don't imply
+// bytes, so they're converted only in values that are known to be sizes.
+var sizeSuffixes = map[string]float64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// reSizeName matches the names of values that are sizes
+var reSizeName = regexp.MustCompile(`(?i)size|cache|memory|capacity|memtotal`)
+
+var reQuantity = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([A-Za-z/]+)$`)
+var reUnitInName = regexp.MustCompile(`\(([A-Za-z/]+)\)$`)
+
+// normalizeQuantity converts a value, e.g., "32 GB", "2.10GHz", "1000Mb/s", to
+// its canonical unit. If the value is a bare number, the unit is taken from the
+// value name when the name ends with a parenthesized unit, e.g., "Frequency (GHz)".
+// A bare suffix, e.g., "1.8T", is converted only if the value name is a size's, e.g.,
+// "Size". The ok return value is false when the value isn't a recognized quantity.
+func normalizeQuantity(valueName string, value string) (quantity float64, unit string, ok bool) {
+	value = strings.TrimSpace(value)
+	number, suffix := value, ""
+	if match := reQuantity.FindStringSubmatch(value); match != nil {
+		number, suffix = match[1], match[2]
+	} else if match := reUnitInName.FindStringSubmatch(valueName); match != nil {
+		suffix = match[1]
+	}
+	conversion, found := unitConversions[suffix]
+	if multiplier, isSize := sizeSuffixes[suffix]; isSize && reSizeName.MatchString(valueName) {
+		conversion, found = unitConversion{UnitBytes, multiplier}, true
+	}
+	if !found {
+		return
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return
+	}
+	quantity = f * conversion.multiplier
+	unit = conversion.canonical
+	ok = true
+	return
+}
+
+// normalizeSize converts a size, e.g., "32 GB" or "1.8T", to bytes
+func normalizeSize(value string) (bytes float64, ok bool) {
+	bytes, unit, ok := normalizeQuantity("Size", value)
+	ok = ok && unit == UnitBytes
+	return
+}
+
+// quantity is a size, frequency, or speed, in canonical units, saved by the collector
+type quantity struct {
+	name  string
+	value float64
+	unit  string
+}
+
+// formatQuantity formats a quantity in canonical units for display, scaling it
+// to the largest unit that keeps the value >= 1, e.g., 34359738368 B -> "32 GiB"
+func formatQuantity(quantity float64, unit string) string {
+	var base float64
+	var prefixes []string
+	switch unit {
+	case UnitBytes:
+		base = 1024
+		prefixes = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi"}
+	case UnitHertz, UnitBytesPerSecond, UnitTransfers:
+		base = 1000
+		prefixes = []string{"", "K", "M", "G", "T", "P"}
+	default:
+		return fmt.Sprintf("%s %s", strconv.FormatFloat(quantity, 'f', -1, 64), unit)
+	}
+	idx := 0
+	for math.Abs(quantity) >= base && idx < len(prefixes)-1 {
+		quantity /= base
+		idx++
+	}
+	return fmt.Sprintf("%s %s%s", strconv.FormatFloat(math.Round(quantity*100)/100, 'f', -1, 64), prefixes[idx], unit)
+}
+
+// normalizedValueName is the name used for the canonical form of a value in the JSON report
+func normalizedValueName(valueName string, unit string) string {
+	valueName = strings.TrimSpace(reUnitInName.ReplaceAllString(valueName, ""))
+	return fmt.Sprintf("%s (%s)", valueName, unit)
+}
+
+// reNumberWithUnit matches a number followed by a unit, e.g., "2.1 GHz", "350W", "85%"
+var reNumberWithUnit = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)\s*([A-Za-z/%]+)$`)
+
+// displayUnits are the units, in addition to those in unitConversions, that are
+// recognized when splitting a value into its number and unit
+var displayUnits = map[string]bool{
+	"%": true, "C": true, "W": true, "V": true, "RPM": true,
+	"s": true, "ms": true, "us": true, "ns": true,
+	"bytes": true, "IOPS": true, "ops/s": true, "Gbps": true, "Mbps": true,
+	"K": true, "M": true, "G": true, "T": true,
+}
+
+// splitQuantity splits a value, e.g., "2.10 GHz", into its number and unit. The ok
+// return value is false when the value isn't a number followed by a recognized unit.
+func splitQuantity(value string) (number string, unit string, ok bool) {
+	match := reNumberWithUnit.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return
+	}
+	if _, found := unitConversions[match[2]]; !found && !displayUnits[match[2]] {
+		return
+	}
+	return match[1], match[2], true
+}
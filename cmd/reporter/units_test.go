@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package main
+
+import (
+	"testing"
+)
+
+func TestNormalizeQuantity(t *testing.T) {
+	for _, test := range []struct {
+		name, value string
+		quantity    float64
+		unit        string
+		ok          bool
+	}{
+		{"Size", "32 GB", 32 << 30, UnitBytes, true},
+		{"Speed", "1000Mb/s", 125e6, UnitBytesPerSecond, true},
+		{"Frequency (GHz)", "2.1", 2.1e9, UnitHertz, true},
+		// bare suffixes are bytes only in sizes
+		{"SIZE", "1.5T", 1.5 * (1 << 40), UnitBytes, true},
+		{"L1d Cache", "48K", 48 << 10, UnitBytes, true},
+		{"Rate", "100M", 0, "", false},
+		{"Operations", "2G", 0, "", false},
+	} {
+		quantity, unit, ok := normalizeQuantity(test.name, test.value)
+		if quantity != test.quantity || unit != test.unit || ok != test.ok {
+			t.Errorf("%s %s : expected %v %s %v, got %v %s %v", test.name, test.value, test.quantity, test.unit, test.ok, quantity, unit, ok)
+		}
+	}
+	if bytes, ok := normalizeSize("1.8T"); !ok || bytes != 1.8*(1<<40) {
+		t.Errorf("unexpected size: %v %v", bytes, ok)
+	}
+}
+
+func TestMigrateQuantities(t *testing.T) {
+	source := newSource("")
+	source.ParsedData["/proc/meminfo"] = CommandData{Label: "/proc/meminfo", Stdout: "MemTotal:       16384 kB\nMemFree:         1024 kB"}
+	source.ParsedData["base frequency"] = CommandData{Label: "base frequency", Stdout: "2100000\n"}
+	source.migrate()
+	quantities := source.getQuantities()
+	expected := []quantity{{"memory_total", 16 << 20, UnitBytes}, {"cpu_base_frequency", 2.1e9, UnitHertz}}
+	if len(quantities) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, quantities)
+	}
+	for i := range expected {
+		if quantities[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], quantities[i])
+		}
+	}
+	if display := formatQuantity(quantities[0].value, quantities[0].unit); display != "16 MiB" {
+		t.Errorf("unexpected display: %s", display)
+	}
+}
@@ -10,6 +10,8 @@ replace github.com/intel/svr-info/internal/msr => ./internal/msr
 
 replace github.com/intel/svr-info/internal/progress => ./internal/progress
 
+replace github.com/intel/svr-info/internal/rawdata => ./internal/rawdata
+
 replace github.com/intel/svr-info/internal/target => ./internal/target
 
 replace github.com/intel/svr-info/internal/commandfile => ./internal/commandfile
@@ -26,6 +28,7 @@ require (
 	github.com/intel/svr-info/internal/cpu v0.0.0-00010101000000-000000000000
 	github.com/intel/svr-info/internal/msr v0.0.0-00010101000000-000000000000
 	github.com/intel/svr-info/internal/progress v0.0.0-00010101000000-000000000000
+	github.com/intel/svr-info/internal/rawdata v0.0.0-00010101000000-000000000000
 	github.com/intel/svr-info/internal/target v0.0.0-00010101000000-000000000000
 	github.com/intel/svr-info/internal/util v0.0.0-00010101000000-000000000000
 	github.com/xuri/excelize/v2 v2.8.0
@@ -10,12 +10,29 @@ package commandfile
 import "github.com/creasty/defaults"
 
 type Command struct {
-	Label     string `yaml:"label"`
-	Command   string `yaml:"command"`
-	Modprobe  string `yaml:"modprobe"`
-	Superuser bool   `default:"false" yaml:"superuser"`
-	Run       bool   `default:"false" yaml:"run"`
-	Parallel  bool   `default:"false" yaml:"parallel"`
+	Label        string      `yaml:"label"`
+	Command      string      `yaml:"command"`
+	Modprobe     string      `yaml:"modprobe"`
+	Superuser    bool        `default:"false" yaml:"superuser"`
+	Capabilities string      `yaml:"capabilities,omitempty"` // comma-separated, e.g., cap_sys_rawio,cap_dac_override
+	SideEffects  string      `yaml:"side_effects,omitempty"` // comma-separated, e.g., disk, msr, settings, modules
+	Hardware     string      `yaml:"hardware,omitempty"`     // comma-separated host devices, alternatives separated by |, e.g., /dev/cpu/*/msr
+	Run          bool        `default:"false" yaml:"run"`
+	Parallel     bool        `default:"false" yaml:"parallel"`
+	Conditions   *Conditions `yaml:"conditions,omitempty"`
+	Timeout      int         `yaml:"timeout,omitempty"`   // seconds, the minimum timeout, a larger command_timeout still applies
+	Intensive    bool        `yaml:"intensive,omitempty"` // loads the CPU, disks, or network, not run in gentle mode
+}
+
+// Conditions restrict a command to the platforms where it is relevant. They are
+// evaluated on the target by the collector. All specified conditions must be met
+// for the command to run.
+type Conditions struct {
+	CPUVendor         string `yaml:"cpu_vendor,omitempty"`        // vendor_id from /proc/cpuinfo, e.g., GenuineIntel
+	Microarchitecture string `yaml:"microarchitecture,omitempty"` // regular expression, e.g., ^(SPR|EMR|GNR)
+	Architecture      string `yaml:"architecture,omitempty"`      // machine hardware name, e.g., x86_64
+	NVMe              *bool  `yaml:"nvme,omitempty"`              // NVMe devices present
+	Virtualized       *bool  `yaml:"virtualized,omitempty"`       // running in a virtual machine
 }
 
 type Arguments struct {
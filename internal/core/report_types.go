@@ -12,7 +12,7 @@ import (
 	"strings"
 )
 
-var ReportTypes = []string{"html", "json", "xlsx", "txt", "all"}
+var ReportTypes = []string{"html", "json", "xlsx", "txt", "facts", "md", "csv", "all"}
 
 func IsValidReportType(input string) (valid bool) {
 	for _, validType := range ReportTypes {
@@ -66,8 +66,8 @@ func (c *CPU) getCPU(family, model, stepping string) (cpu CPUInfo, err error) {
 					if err != nil {
 						return
 					}
-					// if stepping does NOT match
-					if reStepping.FindString(stepping) == "" {
+					// if stepping does NOT match, e.g., stepping 11 isn't a match for (0|1|2|3|4)
+					if reStepping.FindString(stepping) != stepping {
 						// no match
 						continue
 					}
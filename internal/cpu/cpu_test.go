@@ -35,6 +35,14 @@ func TestFindCPU(t *testing.T) {
 	if uarch != "CLX" {
 		t.Fatal(fmt.Errorf("Found the wrong CPU"))
 	}
+	// should succeed, stepping 11 isn't SKX's stepping 1
+	uarch, err = cpu.GetMicroArchitecture("6", "85", "11", "", "", "") //CPX
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uarch != "CPX" {
+		t.Fatal(fmt.Errorf("Found the wrong CPU: %s", uarch))
+	}
 	uarch, err = cpu.GetMicroArchitecture("6", "85", "6", "", "", "") //CLX
 	if err != nil {
 		t.Fatal(err)
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"sync"
 	"time"
 
 	"golang.org/x/term"
@@ -20,14 +21,49 @@ var spinChars []string = []string{"⣾", "⣽", "⣻", "⢿", "⡿", "⣟", "⣯
 
 type MultiSpinnerUpdateFunc func(string, string) error
 
+// Color is the color of a spinner's status in a terminal
+type Color int
+
+const (
+	ColorNone   Color = iota
+	ColorGreen        // e.g., finished
+	ColorYellow       // e.g., pending, counted in the group's header
+	ColorRed          // e.g., failed, counted in the group's header
+)
+
+var colorCodes = map[Color]string{
+	ColorGreen:  "\x1b[32m",
+	ColorYellow: "\x1b[33m",
+	ColorRed:    "\x1b[31m",
+}
+
+// StatusColorFunc returns the color of a spinner's status
+type StatusColorFunc func(label string, status string) Color
+
 type spinnerState struct {
 	status      string
 	statusIsNew bool
 	spinIndex   int
+	group       string
+}
+
+// Event is a change of a spinner's status
+type Event struct {
+	Label  string
+	Status string
+	Time   time.Time
+	Err    error // the cause of the status, e.g., of a failure, nil if there's none
 }
 
+// EventHook is called with each change of a spinner's status, e.g., to log the status or
+// report it to a service, when the spinners aren't the only consumer of the status
+type EventHook func(Event)
+
 type MultiSpinner struct {
+	mutex    sync.Mutex // status is updated by the tasks while the spinners are drawn
 	spinners map[string]*spinnerState
+	hooks    []EventHook
+	color    StatusColorFunc // nil if the statuses aren't colored
 	ticker   *time.Ticker
 	done     chan bool
 	spinning bool
@@ -41,14 +77,30 @@ func NewMultiSpinner() *MultiSpinner {
 }
 
 func (ms *MultiSpinner) AddSpinner(label string) (err error) {
+	return ms.AddSpinnerToGroup(label, "")
+}
+
+// AddSpinnerToGroup adds a spinner that is drawn with the others in its group, under a
+// header with the group's name
+func (ms *MultiSpinner) AddSpinnerToGroup(label string, group string) (err error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
 	if _, ok := ms.spinners[label]; ok {
 		err = fmt.Errorf("spinner with label %s already exists", label)
 		return
 	}
-	ms.spinners[label] = &spinnerState{"?", false, 0}
+	ms.spinners[label] = &spinnerState{"?", false, 0, group}
 	return
 }
 
+// SetStatusColor sets the function that colors the statuses, e.g., failures in red, when
+// they're drawn in a terminal. Set NO_COLOR in the environment to disable colors.
+func (ms *MultiSpinner) SetStatusColor(color StatusColorFunc) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.color = color
+}
+
 func (ms *MultiSpinner) Start() {
 	ms.ticker = time.NewTicker(250 * time.Millisecond)
 	ms.spinning = true
@@ -64,16 +116,42 @@ func (ms *MultiSpinner) Finish() {
 	}
 }
 
+// AddHook registers a function that is called with each change of a spinner's status.
+// Hooks are called in the order they were added, by the task that changed the status,
+// so they must not block.
+func (ms *MultiSpinner) AddHook(hook EventHook) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.hooks = append(ms.hooks, hook)
+}
+
 func (ms *MultiSpinner) Status(label string, status string) (err error) {
-	if spinner, ok := ms.spinners[label]; ok {
-		if status != spinner.status {
-			spinner.statusIsNew = true
-			spinner.status = status
-		}
-	} else {
+	return ms.StatusWithError(label, status, nil)
+}
+
+// StatusWithError sets the spinner's status, like Status, and passes the cause of the
+// status, e.g., the error that a task failed with, to the hooks. The cause isn't drawn.
+func (ms *MultiSpinner) StatusWithError(label string, status string, cause error) (err error) {
+	ms.mutex.Lock()
+	spinner, ok := ms.spinners[label]
+	if !ok {
+		ms.mutex.Unlock()
 		err = fmt.Errorf("did not find spinner with label %s", label)
 		return
 	}
+	if status == spinner.status {
+		ms.mutex.Unlock()
+		return
+	}
+	spinner.statusIsNew = true
+	spinner.status = status
+	hooks := ms.hooks
+	ms.mutex.Unlock()
+	// outside the lock, so that hooks can use the spinners
+	event := Event{Label: label, Status: status, Time: time.Now(), Err: cause}
+	for _, hook := range hooks {
+		hook(event)
+	}
 	return
 }
 
@@ -88,26 +166,83 @@ func (ms *MultiSpinner) onTick() {
 	}
 }
 
-func (ms *MultiSpinner) draw(goUp bool) {
-	var spinnerLabels []string
+// getLabels returns the spinners' labels sorted by group, then by label
+func (ms *MultiSpinner) getLabels() (labels []string) {
 	for k := range ms.spinners {
-		spinnerLabels = append(spinnerLabels, k)
+		labels = append(labels, k)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := ms.spinners[labels[i]], ms.spinners[labels[j]]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		return labels[i] < labels[j]
+	})
+	return
+}
+
+// getGroupHeader returns the group's name and the number of its spinners, and of those
+// that are failed or pending, i.e., red or yellow
+func (ms *MultiSpinner) getGroupHeader(group string) string {
+	var count, failed, pending int
+	for label, spinner := range ms.spinners {
+		if spinner.group != group {
+			continue
+		}
+		count++
+		if ms.color != nil {
+			switch ms.color(label, spinner.status) {
+			case ColorRed:
+				failed++
+			case ColorYellow:
+				pending++
+			}
+		}
+	}
+	header := fmt.Sprintf("%s: %d total", group, count)
+	if failed > 0 {
+		header += fmt.Sprintf(", %d failed", failed)
+	}
+	if pending > 0 {
+		header += fmt.Sprintf(", %d pending", pending)
 	}
-	sort.Strings(spinnerLabels)
-	for _, label := range spinnerLabels {
+	return header
+}
+
+func (ms *MultiSpinner) draw(goUp bool) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	isTerminal := term.IsTerminal(int(os.Stderr.Fd()))
+	useColor := isTerminal && ms.color != nil && os.Getenv("NO_COLOR") == ""
+	lines := 0
+	group := ""
+	for _, label := range ms.getLabels() {
 		spinner := ms.spinners[label]
-		if !term.IsTerminal(int(os.Stderr.Fd())) && !spinner.statusIsNew {
-			return
+		// group headers are only drawn in a terminal, where all of the spinners are drawn
+		if isTerminal && spinner.group != "" && spinner.group != group {
+			fmt.Fprintf(os.Stderr, "%-64s\n", ms.getGroupHeader(spinner.group))
+			lines++
+		}
+		group = spinner.group
+		if !isTerminal && !spinner.statusIsNew {
+			continue
+		}
+		status := fmt.Sprintf("%-40s", spinner.status)
+		if useColor {
+			if code, ok := colorCodes[ms.color(label, spinner.status)]; ok {
+				status = code + status + "\x1b[0m"
+			}
 		}
-		fmt.Fprintf(os.Stderr, "%-20s  %s  %-40s\n", label, spinChars[spinner.spinIndex], spinner.status)
+		fmt.Fprintf(os.Stderr, "%-20s  %s  %s\n", label, spinChars[spinner.spinIndex], status)
+		lines++
 		spinner.statusIsNew = false
 		spinner.spinIndex += 1
 		if spinner.spinIndex >= len(spinChars) {
 			spinner.spinIndex = 0
 		}
 	}
-	if goUp && term.IsTerminal(int(os.Stderr.Fd())) {
-		for range ms.spinners {
+	if goUp && isTerminal {
+		for i := 0; i < lines; i++ {
 			fmt.Fprintf(os.Stderr, "\x1b[1A")
 		}
 	}
@@ -5,6 +5,7 @@
 package progress
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -42,3 +43,67 @@ func TestMultiSpinner(t *testing.T) {
 	}
 	spinner.Finish()
 }
+
+func TestMultiSpinnerHooks(t *testing.T) {
+	spinner := NewMultiSpinner()
+	if spinner.AddSpinner("A") != nil {
+		t.Fatal("failed to add spinner")
+	}
+	var events []Event
+	spinner.AddHook(func(e Event) { events = append(events, e) })
+	var statuses []string
+	spinner.AddHook(func(e Event) {
+		// hooks can use the spinners
+		statuses = append(statuses, e.Status)
+		_ = spinner.AddSpinner(e.Status)
+	})
+	spinner.Status("A", "FOO")
+	spinner.Status("A", "FOO") // unchanged
+	spinner.Phase("A", Phase{Name: "BAR", Done: 1, Total: 2})
+	spinner.Status("C", "WOOPS")
+	if len(events) != 2 || events[0].Label != "A" || events[0].Status != "FOO" || events[0].Time.IsZero() || events[1].Status != "BAR 1/2" {
+		t.Errorf("unexpected events: %v", events)
+	}
+	if len(statuses) != 2 {
+		t.Errorf("unexpected statuses: %v", statuses)
+	}
+	spinner.StatusWithError("A", "FAILED", errors.New("connection lost"))
+	if len(events) != 3 || events[2].Status != "FAILED" || events[2].Err == nil || events[2].Err.Error() != "connection lost" || events[0].Err != nil {
+		t.Errorf("unexpected events: %v", events)
+	}
+}
+
+func TestMultiSpinnerGroups(t *testing.T) {
+	spinner := NewMultiSpinner()
+	spinner.AddSpinnerToGroup("b1", "rack-2")
+	spinner.AddSpinnerToGroup("a1", "rack-1")
+	spinner.AddSpinnerToGroup("a2", "rack-1")
+	spinner.AddSpinnerToGroup("a3", "rack-1")
+	spinner.AddSpinner("c1")
+	if spinner.AddSpinnerToGroup("a1", "rack-2") == nil {
+		t.Fatal("added spinner with same label")
+	}
+	labels := spinner.getLabels()
+	if len(labels) != 5 || labels[0] != "c1" || labels[1] != "a1" || labels[3] != "a3" || labels[4] != "b1" {
+		t.Errorf("unexpected order: %v", labels)
+	}
+	spinner.SetStatusColor(func(label string, status string) Color {
+		switch status {
+		case "error":
+			return ColorRed
+		case "?":
+			return ColorYellow
+		}
+		return ColorGreen
+	})
+	spinner.Status("a1", "error")
+	spinner.Status("a2", "done")
+	if header := spinner.getGroupHeader("rack-1"); header != "rack-1: 3 total, 1 failed, 1 pending" {
+		t.Errorf("unexpected header: %s", header)
+	}
+	if header := spinner.getGroupHeader("rack-2"); header != "rack-2: 1 total, 1 pending" {
+		t.Errorf("unexpected header: %s", header)
+	}
+	spinner.Start()
+	spinner.Finish()
+}
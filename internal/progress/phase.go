@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package progress
+
+import (
+	"fmt"
+	"time"
+)
+
+// Phase is the progress of a step of a spinner's task that is made of a known number of
+// items, e.g., the commands run by the collector. Its string is the spinner's status,
+// e.g., "collecting data 34/94 commands, about 2m10s left".
+type Phase struct {
+	Name      string        // the step, e.g., collecting data
+	Done      int           // the number of items completed
+	Total     int           // the number of items
+	Unit      string        // the items, e.g., commands
+	Remaining time.Duration // the estimated time remaining, zero if unknown, not shown if under a second
+}
+
+func (p Phase) String() (s string) {
+	s = fmt.Sprintf("%s %d/%d", p.Name, p.Done, p.Total)
+	if p.Unit != "" {
+		s += " " + p.Unit
+	}
+	if p.Remaining >= time.Second {
+		s += ", about " + formatRemaining(p.Remaining) + " left"
+	}
+	return
+}
+
+// formatRemaining rounds the duration to the precision that is useful for an estimate,
+// e.g., 2h5m, 2m10s, or 45s
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= 10*time.Minute:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d >= time.Minute:
+		d = d.Round(10 * time.Second)
+	default:
+		d = d.Round(time.Second)
+	}
+	return d.String()
+}
+
+// Phase sets the spinner's status to the phase's progress
+func (ms *MultiSpinner) Phase(label string, phase Phase) error {
+	return ms.Status(label, phase.String())
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhaseString(t *testing.T) {
+	tests := []struct {
+		phase    Phase
+		expected string
+	}{
+		{Phase{Name: "collecting data", Done: 34, Total: 94, Unit: "commands"}, "collecting data 34/94 commands"},
+		{Phase{Name: "collecting data", Done: 34, Total: 94, Unit: "commands", Remaining: 2*time.Minute + 8*time.Second}, "collecting data 34/94 commands, about 2m10s left"},
+		{Phase{Name: "step", Done: 1, Total: 2, Remaining: 1500 * time.Millisecond}, "step 1/2, about 2s left"},
+		{Phase{Name: "step", Done: 1, Total: 2, Remaining: 400 * time.Millisecond}, "step 1/2"},
+		{Phase{Name: "step", Done: 1, Total: 2, Remaining: 12*time.Minute + 40*time.Second}, "step 1/2, about 13m left"},
+		{Phase{Name: "step", Done: 1, Total: 2, Remaining: 2*time.Hour + 5*time.Minute}, "step 1/2, about 2h5m left"},
+	}
+	for _, test := range tests {
+		if s := test.phase.String(); s != test.expected {
+			t.Errorf("expected '%s', got '%s'", test.expected, s)
+		}
+	}
+}
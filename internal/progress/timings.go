@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Timings are the durations of named items, e.g., commands, in previous runs, from which
+// the time remaining in a phase is estimated. A duration is the average of the runs,
+// weighted toward the most recent.
+type Timings struct {
+	mutex     sync.Mutex
+	durations map[string]time.Duration
+}
+
+// timingsWeight is the weight of the most recent run in the average duration
+const timingsWeight = 0.5
+
+func NewTimings() *Timings {
+	return &Timings{durations: make(map[string]time.Duration)}
+}
+
+// LoadTimings reads the timings saved in the file, a missing file has no timings
+func LoadTimings(path string) (t *Timings, err error) {
+	t = NewTimings()
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	var seconds map[string]float64
+	if err = json.Unmarshal(content, &seconds); err != nil {
+		return
+	}
+	for name, s := range seconds {
+		t.durations[name] = time.Duration(s * float64(time.Second))
+	}
+	return
+}
+
+// Save writes the timings to the file, creating its directory if needed
+func (t *Timings) Save(path string) (err error) {
+	t.mutex.Lock()
+	seconds := make(map[string]float64, len(t.durations))
+	for name, d := range t.durations {
+		seconds[name] = d.Seconds()
+	}
+	t.mutex.Unlock()
+	content, err := json.MarshalIndent(seconds, "", "  ")
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	err = os.WriteFile(path, content, 0644)
+	return
+}
+
+// Len returns the number of items with timings
+func (t *Timings) Len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.durations)
+}
+
+// Record adds the item's duration in this run
+func (t *Timings) Record(name string, d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if previous, ok := t.durations[name]; ok {
+		d = time.Duration(timingsWeight*float64(d) + (1-timingsWeight)*float64(previous))
+	}
+	t.durations[name] = d
+}
+
+// Estimate returns the time to complete the sequential items, one at a time, and the
+// concurrent items, all at once. Items without timings are estimated at the average of
+// those with timings. The estimate isn't ok if there are no timings.
+func (t *Timings) Estimate(sequential []string, concurrent []string) (remaining time.Duration, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.durations) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, d := range t.durations {
+		total += d
+	}
+	average := total / time.Duration(len(t.durations))
+	get := func(name string) time.Duration {
+		if d, ok := t.durations[name]; ok {
+			return d
+		}
+		return average
+	}
+	for _, name := range sequential {
+		remaining += get(name)
+	}
+	var longest time.Duration
+	for _, name := range concurrent {
+		longest = max(longest, get(name))
+	}
+	remaining += longest
+	ok = true
+	return
+}
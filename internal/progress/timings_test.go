@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package progress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "timings.json")
+	timings, err := LoadTimings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := timings.Estimate([]string{"a"}, nil); ok {
+		t.Error("expected no estimate without timings")
+	}
+	timings.Record("a", 10*time.Second)
+	timings.Record("a", 20*time.Second)
+	timings.Record("b", 5*time.Second)
+	timings.Record("c", 30*time.Second)
+	if timings.Len() != 3 {
+		t.Errorf("unexpected number of timings: %d", timings.Len())
+	}
+	if err = timings.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if timings, err = LoadTimings(path); err != nil {
+		t.Fatal(err)
+	}
+	// a is 15s, b is 5s, c is 30s, and unknown commands are the average, 50s/3
+	remaining, ok := timings.Estimate([]string{"a", "b"}, []string{"c", "d"})
+	if !ok || remaining != 50*time.Second {
+		t.Errorf("unexpected estimate: %v", remaining)
+	}
+	remaining, ok = timings.Estimate([]string{"d"}, nil)
+	if !ok || remaining.Round(time.Second) != 17*time.Second {
+		t.Errorf("unexpected estimate for unknown: %v", remaining)
+	}
+}
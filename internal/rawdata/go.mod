@@ -0,0 +1,3 @@
+module github.com/intel/svr-info/internal/rawdata
+
+go 1.21
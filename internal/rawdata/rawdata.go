@@ -0,0 +1,145 @@
+/*
+Package rawdata reads and writes the compact, binary format of collected data, an
+alternative to the raw.json format for large collections, e.g., with profiling, and
+defines the version of the collected data's schema.
+*/
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package rawdata
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+)
+
+// A gob file is a sequence of gzip members. Each member is a gob stream of the hostname
+// followed by the command results. Results are written as the commands complete and
+// read one at a time, so neither the collector nor the reporter holds the entire
+// collection in memory as encoded data. Results are appended to a file, e.g., tags
+// added after collection, by writing another member.
+
+// GobExtension is the file name extension of collected data in the gob format
+const GobExtension = ".raw.gob"
+
+// Result is a command's result by field name, e.g., label, command, stdout, stderr,
+// exitstatus, and superuser
+type Result map[string]string
+
+// GobWriter writes a member of a gob file
+type GobWriter struct {
+	zw  *gzip.Writer
+	enc *gob.Encoder
+}
+
+// NewGobWriter starts a member of a gob file, results written to it are from hostname
+func NewGobWriter(w io.Writer, hostname string) (g *GobWriter, err error) {
+	zw := gzip.NewWriter(w)
+	g = &GobWriter{zw: zw, enc: gob.NewEncoder(zw)}
+	if err = g.enc.Encode(hostname); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Write writes a result
+func (g *GobWriter) Write(result Result) error {
+	return g.enc.Encode(result)
+}
+
+// Flush writes the buffered results, e.g., so that a reader sees them before the
+// member is closed
+func (g *GobWriter) Flush() error {
+	return g.zw.Flush()
+}
+
+// Close ends the member, it doesn't close the underlying writer
+func (g *GobWriter) Close() error {
+	return g.zw.Close()
+}
+
+// ReadGob reads the results in a gob file, calling fn for each
+func ReadGob(r io.Reader, fn func(hostname string, result Result) error) (err error) {
+	br := bufio.NewReader(r)
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return
+	}
+	defer zr.Close()
+	for {
+		zr.Multistream(false)
+		dec := gob.NewDecoder(zr)
+		var hostname string
+		if err = dec.Decode(&hostname); err != nil {
+			return
+		}
+		for {
+			var result Result
+			if err = dec.Decode(&result); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return
+			}
+			if err = fn(hostname, result); err != nil {
+				return
+			}
+		}
+		if err = zr.Reset(br); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil // no more members
+			}
+			return
+		}
+	}
+}
+
+// ReadGobFile reads the results in the gob file at path, calling fn for each
+func ReadGobFile(path string, fn func(hostname string, result Result) error) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return ReadGob(f, fn)
+}
+
+// AppendGobFile appends the results, from hostname, to the gob file at path
+func AppendGobFile(path string, hostname string, results []Result) (err error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	g, err := NewGobWriter(f, hostname)
+	if err != nil {
+		return
+	}
+	for _, result := range results {
+		if err = g.Write(result); err != nil {
+			return
+		}
+	}
+	return g.Close()
+}
+
+// GetGobHostname returns the hostname of the results in the gob file at path
+func GetGobHostname(path string) (hostname string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return
+	}
+	defer zr.Close()
+	err = gob.NewDecoder(zr).Decode(&hostname)
+	return
+}
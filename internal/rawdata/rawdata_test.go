@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package rawdata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGobFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host"+GobExtension)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGobWriter(f, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []string{"lscpu", "uname -a"} {
+		if err = g.Write(Result{"label": label, "stdout": label + " output", "exitstatus": "0"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err = AppendGobFile(path, "host", []Result{{"label": "tags", "stdout": "env=prod"}}); err != nil {
+		t.Fatal(err)
+	}
+	hostname, err := GetGobHostname(path)
+	if err != nil || hostname != "host" {
+		t.Fatalf("unexpected hostname: %s, %v", hostname, err)
+	}
+	var labels []string
+	err = ReadGobFile(path, func(hostname string, result Result) error {
+		if hostname != "host" {
+			t.Errorf("unexpected hostname: %s", hostname)
+		}
+		labels = append(labels, result["label"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 3 || labels[0] != "lscpu" || labels[1] != "uname -a" || labels[2] != "tags" {
+		t.Errorf("unexpected results: %v", labels)
+	}
+}
+
+func TestGobFileEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host"+GobExtension)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGobWriter(f, "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	f.Close()
+	count := 0
+	if err = ReadGobFile(path, func(string, Result) error { count++; return nil }); err != nil || count != 0 {
+		t.Errorf("expected no results, got %d, %v", count, err)
+	}
+}
+
+func TestParseSchemaVersion(t *testing.T) {
+	if version, err := ParseSchemaVersion(GetSchemaVersionResult()["stdout"]); err != nil || version != SchemaVersion {
+		t.Errorf("unexpected version: %d, %v", version, err)
+	}
+	// data collected before the version was saved
+	if version, err := ParseSchemaVersion(""); err != nil || version != 0 {
+		t.Errorf("unexpected version: %d, %v", version, err)
+	}
+	for _, output := range []string{"one", "-1", "1.5"} {
+		if _, err := ParseSchemaVersion(output); err == nil {
+			t.Errorf("expected error for '%s'", output)
+		}
+	}
+}
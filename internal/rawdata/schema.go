@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package rawdata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The schema version is saved with the collected data, in the raw.json and raw.gob
+// formats, as the output of a command labeled SchemaVersionLabel. A reporter that
+// predates the version ignores it like any other command it doesn't use, so collected
+// data remains readable by older reporters. For the same reason, a new version may add
+// commands and fields, but must not rename or remove them. Collected data without a
+// version is version 0.
+
+// SchemaVersionLabel labels the schema version in the collected data
+const SchemaVersionLabel = "raw schema version"
+
+// SchemaVersion is the version of the schema of the data collected by this release
+//
+//	1: the schema version is saved, the collector sets the privileges field of every command
+//	2: the quantities command saves sizes, frequencies, and speeds in canonical units
+const SchemaVersion = 2
+
+// GetSchemaVersionResult returns the result that saves the schema version with the
+// collected data
+func GetSchemaVersionResult() Result {
+	return Result{
+		"label":      SchemaVersionLabel,
+		"command":    "",
+		"superuser":  "false",
+		"stdout":     strconv.Itoa(SchemaVersion),
+		"stderr":     "",
+		"exitstatus": "0",
+	}
+}
+
+// ParseSchemaVersion returns the schema version in the output of the command labeled
+// SchemaVersionLabel, 0 if there's no output, i.e., the data predates the version
+func ParseSchemaVersion(output string) (version int, err error) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return
+	}
+	version, err = strconv.Atoi(output)
+	if err != nil || version < 0 {
+		err = fmt.Errorf("invalid schema version: '%s'", output)
+	}
+	return
+}
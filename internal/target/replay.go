@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package target
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// ReplayTarget is a target whose command results were captured earlier, e.g., in a
+// raw.json file, so that parsers and reports can be developed and regression tested
+// against real-world captures without the hosts. Files are pushed to and pulled from a
+// temporary directory on localhost, but commands aren't run. They succeed without
+// output. The collector's output is written from the captured results by the caller.
+type ReplayTarget struct {
+	LocalTarget
+	results map[string]map[string]string // captured results by label
+}
+
+// NewReplayTarget creates a target named name that replays the captured results, e.g.,
+// the results of the name host in a raw.json file
+func NewReplayTarget(name string, results []map[string]string) *ReplayTarget {
+	t := ReplayTarget{LocalTarget: LocalTarget{host: name}, results: make(map[string]map[string]string)}
+	for _, result := range results {
+		t.results[result["label"]] = result
+	}
+	return &t
+}
+
+// GetResult returns a copy of the captured result of the command with the label
+func (t *ReplayTarget) GetResult(label string) (result map[string]string, ok bool) {
+	captured, ok := t.results[label]
+	if !ok {
+		return
+	}
+	result = make(map[string]string, len(captured))
+	for k, v := range captured {
+		result[k] = v
+	}
+	return
+}
+
+func (t *ReplayTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (stdout string, stderr string, exitCode int, err error) {
+	log.Printf("replay, not run: %s", strings.Join(cmd.Args, " "))
+	return
+}
+
+func (t *ReplayTarget) RunCommand(cmd *exec.Cmd) (stdout string, stderr string, exitCode int, err error) {
+	return t.RunCommandWithTimeout(cmd, 0)
+}
+
+// GetArchitecture returns the captured host's machine hardware name, from the output of
+// uname -a, defaulting to x86_64
+func (t *ReplayTarget) GetArchitecture() (arch string, err error) {
+	arch = "x86_64"
+	if result, ok := t.results["uname -a"]; ok {
+		for _, field := range strings.Fields(result["stdout"]) {
+			if field == "aarch64" || field == "arm64" {
+				arch = "aarch64"
+				break
+			}
+		}
+	}
+	return
+}
+
+func (t *ReplayTarget) CanElevatePrivileges() bool {
+	return true
+}
@@ -13,10 +13,15 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
+	osuser "os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,36 +41,92 @@ type Target interface {
 	CanConnect() bool
 	GetSudo() string
 	SetSudo(string)
+	GetUser() string
+	SetCommandObserver(CommandObserver)
 }
 
+// CommandObserver is called after each command runs on a target, e.g., to keep an audit
+// trail. Passwords in the command are masked.
+type CommandObserver func(t Target, command string, start time.Time, end time.Time, exitCode int)
+
 type LocalTarget struct {
-	host string
-	sudo string
+	host         string
+	sudo         string
+	observer     CommandObserver
+	capabilities bool
 }
 
 type RemoteTarget struct {
-	name        string
-	host        string
-	port        string
-	user        string
-	key         string
-	pass        string
-	sshpassPath string
-	sudo        string
-	arch        string
-}
+	name            string
+	host            string
+	port            string
+	user            string
+	key             string
+	pass            string
+	sshpassPath     string
+	sudo            string
+	arch            string
+	interactiveAuth bool
+	transport       string
+	proxy           *url.URL
+	observer        CommandObserver
+	auth            string
+	fips            bool
+	jumpHost        string
+	controlDir      string
+	capabilities    bool
+}
+
+// transports used to reach remote targets
+const (
+	TransportSSH = "ssh" // direct SSH connection to the target
+	TransportSSM = "ssm" // SSH tunneled through AWS Systems Manager, host is the instance ID, the instance runs sshd
+)
+
+var Transports = []string{TransportSSH, TransportSSM}
+
+// methods used to authenticate to remote targets
+const (
+	AuthDefault = "default" // public key, if a key is provided, otherwise password
+	AuthGSSAPI  = "gssapi"  // Kerberos ticket, for when password and key authentication are disabled
+)
+
+var AuthMethods = []string{AuthDefault, AuthGSSAPI}
+
+// the FIPS 140 approved SSH algorithms, offered in FIPS mode
+var (
+	fipsCiphers           = "aes256-gcm@openssh.com,aes128-gcm@openssh.com,aes256-ctr,aes192-ctr,aes128-ctr"
+	fipsMACs              = "hmac-sha2-256-etm@openssh.com,hmac-sha2-512-etm@openssh.com,hmac-sha2-256,hmac-sha2-512"
+	fipsKexAlgorithms     = "ecdh-sha2-nistp256,ecdh-sha2-nistp384,ecdh-sha2-nistp521,diffie-hellman-group14-sha256,diffie-hellman-group16-sha512,diffie-hellman-group18-sha512"
+	fipsHostKeyAlgorithms = "ecdsa-sha2-nistp256,ecdsa-sha2-nistp384,ecdsa-sha2-nistp521,rsa-sha2-256,rsa-sha2-512"
+)
+
+// fipsEnabledPath is set to 1 by the kernel when the system is in FIPS mode
+var fipsEnabledPath = "/proc/sys/crypto/fips_enabled"
+
+var rePasswordVar = regexp.MustCompile(`\b(\w+_PASSWORD)=('([^']|'\\'')*'|\S+)`)
 
 func NewRemoteTarget(name string, host string, port string, user string, key string, pass string, sshpassPath string, sudo string) *RemoteTarget {
-	t := RemoteTarget{name, host, port, user, key, pass, sshpassPath, sudo, ""}
+	t := RemoteTarget{name, host, port, user, key, pass, sshpassPath, sudo, "", false, TransportSSH, nil, nil, AuthDefault, false, "", "", false}
 	return &t
 }
 
 func NewLocalTarget(host string, sudo string) *LocalTarget {
-	t := LocalTarget{host, sudo}
+	t := LocalTarget{host, sudo, nil, false}
 	return &t
 }
 
 func (t *RemoteTarget) getSSHFlags(scp bool) (flags []string) {
+	// the master connection must outlive the time it takes to authenticate all targets
+	// when the user is prompted, so subsequent commands don't prompt again
+	controlPersist := "1m"
+	if t.interactiveAuth {
+		controlPersist = "30m"
+	}
+	gssapi := "no"
+	if t.auth == AuthGSSAPI {
+		gssapi = "yes"
+	}
 	flags = []string{
 		"-2",
 		"-o",
@@ -73,21 +134,46 @@ func (t *RemoteTarget) getSSHFlags(scp bool) (flags []string) {
 		"-o",
 		"StrictHostKeyChecking=no",
 		"-o",
-		"ConnectTimeout=10",       // This one exposes a bug in Windows' SSH client. Each connection takes
-		"-o",                      // 10 seconds to establish. https://github.com/PowerShell/Win32-OpenSSH/issues/1352
-		"GSSAPIAuthentication=no", // This one is not supported, but is ignored on Windows.
+		"ConnectTimeout=10",              // This one exposes a bug in Windows' SSH client. Each connection takes
+		"-o",                             // 10 seconds to establish. https://github.com/PowerShell/Win32-OpenSSH/issues/1352
+		"GSSAPIAuthentication=" + gssapi, // This one is not supported, but is ignored on Windows.
 		"-o",
 		"ServerAliveInterval=30",
 		"-o",
 		"ServerAliveCountMax=10", // 30 * 10 = maximum 300 seconds before disconnect on no data
 		"-o",
-		"ControlPath=" + filepath.Join(os.TempDir(), "%h"), // <<<<<<<<<<<<<
+		"ControlPath=" + t.getControlPath(),
 		"-o",
 		"ControlMaster=auto",
 		"-o",
-		"ControlPersist=1m",
+		"ControlPersist=" + controlPersist,
 	}
-	if t.key != "" {
+	if t.auth == AuthGSSAPI {
+		gssapiFlags := []string{
+			"-o",
+			"PreferredAuthentications=gssapi-with-mic",
+			"-o",
+			"PasswordAuthentication=no",
+			"-o",
+			"GSSAPIDelegateCredentials=no",
+		}
+		flags = append(flags, gssapiFlags...)
+	} else if t.interactiveAuth {
+		preferred := "keyboard-interactive,password"
+		if t.key != "" {
+			preferred = "publickey," + preferred
+		}
+		interactiveFlags := []string{
+			"-o",
+			"PreferredAuthentications=" + preferred,
+			"-o",
+			"KbdInteractiveAuthentication=yes",
+		}
+		flags = append(flags, interactiveFlags...)
+		if t.key != "" {
+			flags = append(flags, "-i", t.key)
+		}
+	} else if t.key != "" {
 		keyFlags := []string{
 			"-o",
 			"PreferredAuthentications=publickey",
@@ -98,6 +184,29 @@ func (t *RemoteTarget) getSSHFlags(scp bool) (flags []string) {
 		}
 		flags = append(flags, keyFlags...)
 	}
+	if t.fips {
+		flags = append(flags, getFIPSFlags()...)
+	}
+	if t.transport == TransportSSM {
+		// the SSM agent on the target forwards the session to the local SSH daemon, so
+		// the target doesn't need to accept inbound SSH connections. ssh runs the proxy
+		// command with the shell, the AWS CLI uses TLS so it's pointed at the FIPS
+		// endpoints in FIPS mode.
+		proxyCommand := "aws ssm start-session --target %h --document-name AWS-StartSSHSession --parameters portNumber=%p"
+		if t.fips {
+			proxyCommand = "AWS_USE_FIPS_ENDPOINT=true " + proxyCommand
+		}
+		flags = append(flags, "-o", "ProxyCommand="+proxyCommand)
+	} else if t.proxy != nil {
+		// OpenBSD netcat supports both SOCKS5 and HTTP CONNECT proxies
+		proxyProtocol := "5"
+		if t.proxy.Scheme == "http" {
+			proxyProtocol = "connect"
+		}
+		flags = append(flags, "-o", fmt.Sprintf("ProxyCommand=nc -X %s -x %s %%h %%p", proxyProtocol, quoteProxyCommandArg(t.proxy.Host)))
+	} else if t.jumpHost != "" {
+		flags = append(flags, "-o", "ProxyCommand="+t.getJumpCommand(gssapi))
+	}
 	if t.port != "" {
 		if scp {
 			flags = append(flags, "-P")
@@ -109,6 +218,84 @@ func (t *RemoteTarget) getSSHFlags(scp bool) (flags []string) {
 	return
 }
 
+// maxControlPathLength is the shortest limit of the length of a Unix domain socket's
+// path, including the random suffix that ssh adds while it creates the control socket
+const maxControlPathLength = 104 - len(".0123456789abcdef")
+
+// getControlPath returns the path to the control socket of the SSH master connection
+// to the target, which the commands and file transfers share so that each doesn't set
+// up its own connection. %C is a hash of the local host, target, port, and user, so
+// targets that share a host, e.g., behind the same address on different ports, don't
+// share a connection.
+func (t *RemoteTarget) getControlPath() string {
+	// the %C token expands to 40 characters
+	if t.controlDir != "" && len(t.controlDir)+len("/")+40 <= maxControlPathLength {
+		return filepath.Join(t.controlDir, "%C")
+	}
+	return filepath.Join(os.TempDir(), "svr-info-%C")
+}
+
+// getFIPSFlags returns the ssh options that restrict the connection to FIPS 140 approved
+// algorithms
+func getFIPSFlags() []string {
+	return []string{
+		"-o",
+		"Ciphers=" + fipsCiphers,
+		"-o",
+		"MACs=" + fipsMACs,
+		"-o",
+		"KexAlgorithms=" + fipsKexAlgorithms,
+		"-o",
+		"HostKeyAlgorithms=" + fipsHostKeyAlgorithms,
+		"-o",
+		"PubkeyAcceptedKeyTypes=" + fipsHostKeyAlgorithms,
+	}
+}
+
+// getJumpCommand returns the ProxyCommand that connects to the target through the jump
+// host. Unlike ProxyJump, which uses only the user's SSH configuration, the connection to
+// the jump host uses the target's key, host key checking, and FIPS settings, so that it
+// doesn't prompt to accept the jump host's key.
+func (t *RemoteTarget) getJumpCommand(gssapi string) string {
+	user, host, port, _ := parseJumpHost(t.jumpHost)
+	args := []string{
+		"ssh",
+		"-o",
+		"UserKnownHostsFile=/dev/null",
+		"-o",
+		"StrictHostKeyChecking=no",
+		"-o",
+		"ConnectTimeout=10",
+		"-o",
+		"GSSAPIAuthentication=" + gssapi,
+	}
+	if t.key != "" {
+		args = append(args, "-i", t.key)
+	}
+	if t.fips {
+		args = append(args, getFIPSFlags()...)
+	}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	if user != "" {
+		args = append(args, "-l", user)
+	}
+	args = append(args, host)
+	var quoted []string
+	for _, arg := range args {
+		quoted = append(quoted, quoteProxyCommandArg(arg))
+	}
+	return strings.Join(append(quoted, "-W", "%h:%p"), " ")
+}
+
+// quoteProxyCommandArg quotes an argument of a ProxyCommand, which ssh runs with the
+// user's shell after expanding the % tokens
+func quoteProxyCommandArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
 func (t *RemoteTarget) getSSHCommand(command []string) []string {
 	var cmd []string
 	cmd = append(cmd, "ssh")
@@ -155,6 +342,281 @@ func (t *RemoteTarget) GetSudo() (sudo string) {
 	return
 }
 
+// SetInteractiveAuth enables keyboard-interactive authentication, e.g., password + OTP,
+// for targets that require multi-factor authentication. Call Authenticate before running
+// commands on the target so the user is prompted only once.
+func (t *RemoteTarget) SetInteractiveAuth(interactive bool) {
+	t.interactiveAuth = interactive
+}
+
+// SetTransport selects how the target is reached, one of Transports
+func (t *RemoteTarget) SetTransport(transport string) (err error) {
+	if transport == "" {
+		transport = TransportSSH
+	}
+	if !util.StringInList(transport, Transports) {
+		err = fmt.Errorf("unsupported transport: %s", transport)
+		return
+	}
+	if transport == TransportSSM {
+		if _, err = exec.LookPath("aws"); err != nil {
+			err = fmt.Errorf("the AWS CLI (aws) and its Session Manager plugin are required for the %s transport: %v", transport, err)
+			return
+		}
+	}
+	t.transport = transport
+	return
+}
+
+// SetAuth selects how to authenticate to the target, one of AuthMethods. GSSAPI
+// authentication uses the user's Kerberos ticket, see CheckKerberosTicket.
+func (t *RemoteTarget) SetAuth(method string) (err error) {
+	if method == "" {
+		method = AuthDefault
+	}
+	if !util.StringInList(method, AuthMethods) {
+		err = fmt.Errorf("unsupported authentication method: %s", method)
+		return
+	}
+	if method == AuthGSSAPI {
+		if t.transport == TransportSSM {
+			err = fmt.Errorf("%s authentication not supported with the %s transport", method, t.transport)
+			return
+		}
+		// the ticket is for the host's service principal, host/<fqdn>@REALM
+		if net.ParseIP(t.host) != nil {
+			err = fmt.Errorf("%s authentication requires the target's fully qualified host name, not an IP address (%s), to match its Kerberos service principal", method, t.host)
+			return
+		}
+	}
+	t.auth = method
+	return
+}
+
+// CheckKerberosTicket returns an error that explains what to do if there isn't a valid
+// Kerberos ticket in the credential cache, which GSSAPI authentication requires
+func CheckKerberosTicket() (err error) {
+	klist, err := exec.LookPath("klist")
+	if err != nil {
+		err = fmt.Errorf("klist not found, install the Kerberos client tools, e.g., krb5-user or krb5-workstation, and get a ticket with kinit: %v", err)
+		return
+	}
+	cache := os.Getenv("KRB5CCNAME")
+	if cache == "" {
+		cache = "the default credential cache"
+	}
+	if _, _, _, err = RunLocalCommand(exec.Command(klist, "-s")); err != nil {
+		err = fmt.Errorf("no valid Kerberos ticket in %s, get one with 'kinit USER@REALM' or set KRB5CCNAME to the credential cache that has one", cache)
+	}
+	return
+}
+
+// SetCapabilities accepts sudo that is limited to setpriv, which is root-equivalent, as
+// elevated privileges, the collector's -capabilities option runs the commands that need
+// them with sudo setpriv
+func (t *LocalTarget) SetCapabilities(capabilities bool) {
+	t.capabilities = capabilities
+}
+
+// SetCapabilities accepts sudo that is limited to setpriv, which is root-equivalent, as
+// elevated privileges, the collector's -capabilities option runs the commands that need
+// them with sudo setpriv
+func (t *RemoteTarget) SetCapabilities(capabilities bool) {
+	t.capabilities = capabilities
+}
+
+// SetFIPS restricts the SSH connection to the target to FIPS 140 approved algorithms, so
+// that ssh refuses targets that don't support them. See CheckFIPSMode.
+func (t *RemoteTarget) SetFIPS(fips bool) {
+	t.fips = fips
+}
+
+// CheckFIPSMode returns an error if the local system isn't in FIPS mode. ssh uses the
+// system's FIPS validated cryptographic module only when the system is in FIPS mode.
+func CheckFIPSMode() (err error) {
+	content, err := os.ReadFile(fipsEnabledPath)
+	if err != nil || strings.TrimSpace(string(content)) != "1" {
+		err = fmt.Errorf("the local system isn't in FIPS mode (%s), so ssh doesn't use FIPS validated cryptography, enable it, e.g., with 'fips-mode-setup --enable', and reboot", fipsEnabledPath)
+	}
+	return
+}
+
+// SetProxy routes the connection to the target through a SOCKS5 or HTTP CONNECT proxy,
+// e.g., socks5://proxy.example.com:1080 or http://proxy.example.com:3128
+func (t *RemoteTarget) SetProxy(proxy string) (err error) {
+	if proxy == "" {
+		t.proxy = nil
+		return
+	}
+	if t.transport == TransportSSM {
+		err = fmt.Errorf("proxy not supported with the %s transport, set HTTPS_PROXY for the AWS CLI instead", t.transport)
+		return
+	}
+	proxyURL, err := ParseProxy(proxy)
+	if err != nil {
+		return
+	}
+	if _, err = exec.LookPath("nc"); err != nil {
+		err = fmt.Errorf("netcat (nc) is required to connect through a proxy: %v", err)
+		return
+	}
+	t.proxy = proxyURL
+	return
+}
+
+// SetJumpHost routes the connection to the target through an SSH jump host, e.g., a
+// bastion, given as [user@]host[:port]. The jump host is authenticated with the target's
+// key, if any, or the user's SSH agent and configuration, not with a password.
+func (t *RemoteTarget) SetJumpHost(jumpHost string) (err error) {
+	if jumpHost == "" {
+		t.jumpHost = ""
+		return
+	}
+	if t.transport == TransportSSM {
+		err = fmt.Errorf("jump host not supported with the %s transport", t.transport)
+		return
+	}
+	if t.proxy != nil {
+		err = fmt.Errorf("jump host and proxy are mutually exclusive")
+		return
+	}
+	if err = ValidateJumpHost(jumpHost); err != nil {
+		return
+	}
+	t.jumpHost = jumpHost
+	return
+}
+
+// ValidateJumpHost validates a jump host, [user@]host[:port]
+func ValidateJumpHost(jumpHost string) (err error) {
+	_, _, _, err = parseJumpHost(jumpHost)
+	return
+}
+
+// parseJumpHost splits a jump host, [user@]host[:port], into its parts
+func parseJumpHost(jumpHost string) (user string, host string, port string, err error) {
+	host = jumpHost
+	if u, rest, found := strings.Cut(host, "@"); found {
+		if u == "" {
+			err = fmt.Errorf("invalid jump host %s: user is empty", jumpHost)
+			return
+		}
+		if strings.HasPrefix(u, "-") {
+			err = fmt.Errorf("invalid jump host %s: user can't start with '-'", jumpHost)
+			return
+		}
+		user, host = u, rest
+	}
+	if h, p, found := strings.Cut(host, ":"); found {
+		if n, convErr := strconv.Atoi(p); convErr != nil || n <= 0 || n > 65535 {
+			err = fmt.Errorf("invalid jump host %s: invalid port %s", jumpHost, p)
+			return
+		}
+		host, port = h, p
+	}
+	// a host that starts with '-' would be read by ssh as an option
+	if host == "" || strings.HasPrefix(host, "-") || strings.ContainsAny(host, " \t/,@'") {
+		err = fmt.Errorf("invalid jump host %s: expected [user@]host[:port]", jumpHost)
+	}
+	return
+}
+
+// reProxyHostname matches a DNS name
+var reProxyHostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// ParseProxy validates a proxy URL, the scheme must be socks5 or http, the host must be
+// an IP address or a DNS name, and the port is required
+func ParseProxy(proxy string) (proxyURL *url.URL, err error) {
+	proxyURL, err = url.Parse(proxy)
+	if err != nil {
+		err = fmt.Errorf("invalid proxy %s: %v", proxy, err)
+		return
+	}
+	if proxyURL.Scheme != "socks5" && proxyURL.Scheme != "http" {
+		err = fmt.Errorf("invalid proxy %s: scheme must be socks5 or http", proxy)
+		return
+	}
+	if proxyURL.Hostname() == "" || proxyURL.Port() == "" {
+		err = fmt.Errorf("invalid proxy %s: host and port are required", proxy)
+		return
+	}
+	// the host is passed to nc in the ssh ProxyCommand, which runs in a shell
+	if net.ParseIP(proxyURL.Hostname()) == nil && !reProxyHostname.MatchString(proxyURL.Hostname()) {
+		err = fmt.Errorf("invalid proxy %s: host must be an IP address or a DNS name", proxy)
+		return
+	}
+	return
+}
+
+// Authenticate establishes the SSH master connection to the target with the terminal
+// attached so that the user can respond to authentication prompts. Subsequent commands
+// and file transfers reuse the authenticated connection.
+func (t *RemoteTarget) Authenticate() (err error) {
+	sshCommand := t.getSSHCommand([]string{"exit", "0"})
+	cmd := exec.Command(sshCommand[0], sshCommand[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	log.Printf("run: %s", strings.Join(cmd.Args, " "))
+	err = cmd.Run()
+	return
+}
+
+// SetControlDir sets the directory of the control socket of the SSH master connection to
+// the target. It should be private to the user, e.g., a temporary directory, and short
+// enough for a socket's path, otherwise the system's temporary directory is used.
+func (t *RemoteTarget) SetControlDir(dir string) {
+	t.controlDir = dir
+}
+
+// CloseConnection stops the SSH master connection to the target, if one is running.
+func (t *RemoteTarget) CloseConnection() (err error) {
+	controlCommand := func(operation string) *exec.Cmd {
+		var cmd []string
+		cmd = append(cmd, "ssh")
+		cmd = append(cmd, t.getSSHFlags(false)...)
+		cmd = append(cmd, "-O", operation)
+		if t.user != "" {
+			cmd = append(cmd, t.user+"@"+t.host)
+		} else {
+			cmd = append(cmd, t.host)
+		}
+		return exec.Command(cmd[0], cmd[1:]...)
+	}
+	// the master connection closes on its own when it's idle, it may not be running
+	if _, _, _, err = RunLocalCommand(controlCommand("check")); err != nil {
+		err = nil
+		return
+	}
+	_, _, _, err = RunLocalCommand(controlCommand("exit"))
+	return
+}
+
+// GetUser returns the name of the user that runs commands on the target
+func (t *LocalTarget) GetUser() (user string) {
+	if u, err := osuser.Current(); err == nil {
+		user = u.Username
+	}
+	return
+}
+
+// GetUser returns the name of the user that runs commands on the target, empty if
+// the SSH configuration's default user
+func (t *RemoteTarget) GetUser() (user string) {
+	user = t.user
+	return
+}
+
+// SetCommandObserver sets the function called after each command runs on the target
+func (t *LocalTarget) SetCommandObserver(observer CommandObserver) {
+	t.observer = observer
+}
+
+// SetCommandObserver sets the function called after each command runs on the target
+func (t *RemoteTarget) SetCommandObserver(observer CommandObserver) {
+	t.observer = observer
+}
+
 func (t *LocalTarget) SetSudo(sudo string) {
 	t.sudo = sudo
 }
@@ -165,15 +627,29 @@ func (t *RemoteTarget) SetSudo(sudo string) {
 
 func (t *LocalTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (stdout string, stderr string, exitCode int, err error) {
 	log.Printf("run: %s", strings.Join(cmd.Args, " "))
-	return RunLocalCommandWithTimeout(cmd, timeout)
+	start := time.Now()
+	stdout, stderr, exitCode, err = RunLocalCommandWithTimeout(cmd, timeout)
+	if t.observer != nil {
+		t.observer(t, maskPasswords(strings.Join(cmd.Args, " ")), start, time.Now(), exitCode)
+	}
+	return
 }
 
 func (t *LocalTarget) RunCommand(cmd *exec.Cmd) (stdout string, stderr string, exitCode int, err error) {
 	return t.RunCommandWithTimeout(cmd, 0)
 }
 
+// RunCommandWithTimeout runs the command's arguments on the target. The command's Stdin,
+// if set, is the remote command's stdin, e.g., for passwords that mustn't be on its
+// command line.
 func (t *RemoteTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (stdout string, stderr string, exitCode int, err error) {
-	sshCommand := t.getSSHCommand(cmd.Args)
+	return t.runRemoteCommand(cmd.Args, cmd.Stdin, strings.Join(cmd.Args, " "), timeout)
+}
+
+// runRemoteCommand runs the command on the target, the observer is given observed as the
+// command, e.g., a script rather than its encoding
+func (t *RemoteTarget) runRemoteCommand(command []string, stdin io.Reader, observed string, timeout int) (stdout string, stderr string, exitCode int, err error) {
+	sshCommand := t.getSSHCommand(command)
 	var name string
 	var args []string
 	if t.key == "" && t.pass != "" {
@@ -186,15 +662,23 @@ func (t *RemoteTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (stdout
 		args = sshCommand[1:]
 	}
 	localCommand := exec.Command(name, args...)
+	localCommand.Stdin = stdin // sshpass passes its stdin to ssh
 	if t.key == "" && t.pass != "" {
 		localCommand.Env = append(localCommand.Env, "SSHPASS="+t.pass)
 	}
-	logOut := strings.Join(localCommand.Args, " ")
-	if t.sudo != "" {
-		logOut = strings.Replace(logOut, "SUDO_PASSWORD="+t.sudo, "SUDO_PASSWORD=*************", -1)
+	// don't log passwords passed to remote commands, e.g., SUDO_PASSWORD
+	log.Printf("run: %s", maskPasswords(strings.Join(localCommand.Args, " ")))
+	start := time.Now()
+	stdout, stderr, exitCode, err = RunLocalCommandWithTimeout(localCommand, timeout)
+	if t.observer != nil {
+		t.observer(t, maskPasswords(observed), start, time.Now(), exitCode)
 	}
-	log.Printf("run: %s", logOut)
-	return RunLocalCommandWithTimeout(localCommand, timeout)
+	return
+}
+
+// maskPasswords replaces the values of password variables in the command line
+func maskPasswords(command string) string {
+	return rePasswordVar.ReplaceAllString(command, "${1}=*************")
 }
 
 func (t *RemoteTarget) RunCommand(cmd *exec.Cmd) (stdout string, stderr string, exitCode int, err error) {
@@ -254,6 +738,16 @@ func (t *RemoteTarget) CreateTempDirectory(rootDir string) (tempDir string, err
 //	srcPath: full path to source file
 //	dstPath: destination directory or full path to destination file
 func (t *LocalTarget) PushFile(srcPath string, dstPath string) (err error) {
+	if t.observer != nil {
+		start := time.Now()
+		defer func() {
+			exitCode := 0
+			if err != nil {
+				exitCode = 1
+			}
+			t.observer(t, "cp "+srcPath+" "+dstPath, start, time.Now(), exitCode)
+		}()
+	}
 	srcFileStat, err := os.Stat(srcPath)
 	if err != nil {
 		log.Printf("failed to stat: %s", srcPath)
@@ -294,24 +788,7 @@ func (t *LocalTarget) PushFile(srcPath string, dstPath string) (err error) {
 }
 
 func (t *RemoteTarget) PushFile(srcPath string, dstDir string) (err error) {
-	scpCommand := t.getSCPCommand(srcPath, dstDir, true)
-	var name string
-	var args []string
-	if t.key == "" && t.pass != "" {
-		name = t.sshpassPath
-		args = append(args, "-e")
-		args = append(args, "--")
-		args = append(args, scpCommand...)
-	} else {
-		name = scpCommand[0]
-		args = scpCommand[1:]
-	}
-	localCommand := exec.Command(name, args...)
-	if t.key == "" && t.pass != "" {
-		localCommand.Env = append(localCommand.Env, "SSHPASS="+t.pass)
-	}
-	log.Printf("run: %s", strings.Join(localCommand.Args, " "))
-	_, _, _, err = RunLocalCommand(localCommand)
+	err = t.copyFile(srcPath, dstDir, true)
 	return
 }
 
@@ -321,7 +798,14 @@ func (t *LocalTarget) PullFile(srcPath string, dstDir string) (err error) {
 }
 
 func (t *RemoteTarget) PullFile(srcPath string, dstDir string) (err error) {
-	scpCommand := t.getSCPCommand(srcPath, dstDir, false)
+	err = t.copyFile(srcPath, dstDir, false)
+	return
+}
+
+// copyFile copies a file to, or from, the target with scp. The observer is given the
+// transfer, i.e., the scp command without its options.
+func (t *RemoteTarget) copyFile(srcPath string, dstDir string, push bool) (err error) {
+	scpCommand := t.getSCPCommand(srcPath, dstDir, push)
 	var name string
 	var args []string
 	if t.key == "" && t.pass != "" {
@@ -338,7 +822,11 @@ func (t *RemoteTarget) PullFile(srcPath string, dstDir string) (err error) {
 		localCommand.Env = append(localCommand.Env, "SSHPASS="+t.pass)
 	}
 	log.Printf("run: %s", strings.Join(localCommand.Args, " "))
-	_, _, _, err = RunLocalCommand(localCommand)
+	start := time.Now()
+	_, _, exitCode, err := RunLocalCommand(localCommand)
+	if t.observer != nil {
+		t.observer(t, strings.Join(append([]string{"scp"}, scpCommand[len(scpCommand)-2:]...), " "), start, time.Now(), exitCode)
+	}
 	return
 }
 
@@ -395,25 +883,55 @@ func (t *RemoteTarget) CanConnect() bool {
 	return err == nil
 }
 
+// CanElevatePrivileges returns true if the user is root on the target, or can run
+// commands with sudo, with the sudo password if it's set, otherwise without a password.
+// With SetCapabilities, sudo that is limited to setpriv is sufficient.
+func (t *RemoteTarget) CanElevatePrivileges() bool {
+	sudo := "sudo -kn"
+	if t.sudo != "" {
+		sudo = `sudo -kS -p ""`
+	}
+	check := fmt.Sprintf(`[ "$(id -u)" = 0 ] || %s true`, sudo)
+	if t.capabilities {
+		check += fmt.Sprintf(` || %s -l setpriv > /dev/null`, sudo)
+	}
+	cmd := exec.Command(check)
+	if t.sudo != "" {
+		// the password is sudo's stdin, once for each sudo, it isn't on the command line
+		cmd.Stdin = strings.NewReader(strings.Repeat(t.sudo+"\n", strings.Count(check, "sudo ")))
+	}
+	_, _, _, err := t.RunCommand(cmd)
+	return err == nil
+}
+
 func (t *LocalTarget) CanElevatePrivileges() bool {
 	if os.Geteuid() == 0 {
 		return true // user is root
 	}
-	if t.sudo != "" {
-		cmd := exec.Command("sudo", "-kS", "ls")
-		stdin, _ := cmd.StdinPipe()
-		go func() {
-			defer stdin.Close()
-			io.WriteString(stdin, t.sudo+"\n")
-		}()
+	commands := [][]string{{"ls"}}
+	if t.capabilities {
+		commands = append(commands, []string{"-l", "setpriv"})
+	}
+	for _, command := range commands {
+		if t.sudo != "" {
+			cmd := exec.Command("sudo", append([]string{"-kS"}, command...)...)
+			stdin, _ := cmd.StdinPipe()
+			go func() {
+				defer stdin.Close()
+				io.WriteString(stdin, t.sudo+"\n")
+			}()
+			_, _, _, err := t.RunCommand(cmd)
+			if err == nil {
+				return true // sudo password works
+			}
+		}
+		cmd := exec.Command("sudo", append([]string{"-kS"}, command...)...)
 		_, _, _, err := t.RunCommand(cmd)
 		if err == nil {
-			return true // sudo password works
+			return true // passwordless sudo works
 		}
 	}
-	cmd := exec.Command("sudo", "-kS", "ls")
-	_, _, _, err := t.RunCommand(cmd)
-	return err == nil // true - passwordless sudo works
+	return false
 }
 
 func RunLocalCommandWithInputWithTimeout(cmd *exec.Cmd, input string, timeout int) (stdout string, stderr string, exitCode int, err error) {
@@ -423,6 +941,9 @@ func RunLocalCommandWithInputWithTimeout(cmd *exec.Cmd, input string, timeout in
 		defer cancel()
 		commandWithContext := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...)
 		commandWithContext.Env = cmd.Env
+		commandWithContext.Dir = cmd.Dir
+		commandWithContext.SysProcAttr = cmd.SysProcAttr
+		commandWithContext.Stdin = cmd.Stdin
 		cmd = commandWithContext
 	}
 	if input != "" {
@@ -5,7 +5,14 @@
 package target
 
 import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf16"
 )
 
 func TestNew(t *testing.T) {
@@ -18,3 +25,358 @@ func TestNew(t *testing.T) {
 		t.Fatal("failed to create a remote target")
 	}
 }
+
+func TestInteractiveAuthFlags(t *testing.T) {
+	remoteTarget := NewRemoteTarget("label", "hostname", "22", "user", "key", "", "", "")
+	remoteTarget.SetInteractiveAuth(true)
+	flags := strings.Join(remoteTarget.getSSHFlags(false), " ")
+	if !strings.Contains(flags, "PreferredAuthentications=publickey,keyboard-interactive,password") {
+		t.Errorf("keyboard-interactive not preferred: %s", flags)
+	}
+	if strings.Contains(flags, "PasswordAuthentication=no") {
+		t.Errorf("password authentication disabled: %s", flags)
+	}
+	if !strings.Contains(flags, "-i key") {
+		t.Errorf("key not provided: %s", flags)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	remoteTarget := NewRemoteTarget("label", "i-0123456789abcdef0", "22", "user", "key", "", "", "")
+	if strings.Contains(strings.Join(remoteTarget.getSSHFlags(false), " "), "ProxyCommand") {
+		t.Error("proxy command set for default transport")
+	}
+	if err := remoteTarget.SetTransport("telnet"); err == nil {
+		t.Error("unsupported transport accepted")
+	}
+	remoteTarget.transport = TransportSSM // SetTransport requires the AWS CLI
+	flags := strings.Join(remoteTarget.getSSHFlags(false), " ")
+	if !strings.Contains(flags, "ProxyCommand=aws ssm start-session --target %h") {
+		t.Errorf("SSM proxy command not set: %s", flags)
+	}
+	remoteTarget.SetFIPS(true)
+	flags = strings.Join(remoteTarget.getSSHFlags(false), " ")
+	if !strings.Contains(flags, "ProxyCommand=AWS_USE_FIPS_ENDPOINT=true aws ssm start-session") {
+		t.Errorf("SSM proxy command not pointed at the FIPS endpoints: %s", flags)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	for _, proxy := range []string{"ftp://proxy:21", "socks5://proxy", "proxy:1080", "socks5://a$(id):1080", "http://a'b:80", "http://a;touch%20x:80", "socks5://-proxy:1080"} {
+		if _, err := ParseProxy(proxy); err == nil {
+			t.Errorf("invalid proxy accepted: %s", proxy)
+		}
+	}
+	remoteTarget := NewRemoteTarget("label", "hostname", "22", "user", "key", "", "", "")
+	remoteTarget.proxy, _ = ParseProxy("http://proxy.example.com:3128") // SetProxy requires nc
+	flags := strings.Join(remoteTarget.getSSHFlags(false), " ")
+	if !strings.Contains(flags, "ProxyCommand=nc -X connect -x 'proxy.example.com:3128' %h %p") {
+		t.Errorf("HTTP proxy command not set: %s", flags)
+	}
+	for _, proxy := range []string{"socks5://10.0.0.1:1080", "socks5://[fe80::1]:1080"} {
+		if _, err := ParseProxy(proxy); err != nil {
+			t.Errorf("valid proxy rejected: %s: %v", proxy, err)
+		}
+	}
+}
+
+func TestCommandObserver(t *testing.T) {
+	localTarget := NewLocalTarget("hostname", "")
+	var commands []string
+	var exitCodes []int
+	localTarget.SetCommandObserver(func(target Target, command string, start time.Time, end time.Time, exitCode int) {
+		if end.Before(start) {
+			t.Errorf("command ended before it started: %s", command)
+		}
+		commands = append(commands, command)
+		exitCodes = append(exitCodes, exitCode)
+	})
+	_, _, _, _ = localTarget.RunCommand(exec.Command("sh", "-c", "SUDO_PASSWORD=secret exit 3"))
+	if len(commands) != 1 {
+		t.Fatalf("observer called %d times", len(commands))
+	}
+	if strings.Contains(commands[0], "secret") {
+		t.Errorf("password not masked: %s", commands[0])
+	}
+	if exitCodes[0] != 3 {
+		t.Errorf("unexpected exit code: %d", exitCodes[0])
+	}
+}
+
+func TestCopyFileObserver(t *testing.T) {
+	// scp fails to pull, pushes succeed
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor arg; do last=$arg; done\n[ \"$last\" != /tmp ]\n"
+	if err := os.WriteFile(filepath.Join(dir, "scp"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	remoteTarget := NewRemoteTarget("label", "host", "22", "user", "key", "", "", "")
+	var commands []string
+	var exitCodes []int
+	remoteTarget.SetCommandObserver(func(target Target, command string, start time.Time, end time.Time, exitCode int) {
+		commands = append(commands, command)
+		exitCodes = append(exitCodes, exitCode)
+	})
+	if err := remoteTarget.PushFile("/tmp/collector", "/tmp/svr-info"); err != nil {
+		t.Error(err)
+	}
+	if err := remoteTarget.PullFile("/tmp/svr-info/host.raw.json", "/tmp"); err == nil {
+		t.Error("expected the pull to fail")
+	}
+	expected := []string{"scp /tmp/collector user@host:/tmp/svr-info", "scp user@host:/tmp/svr-info/host.raw.json /tmp"}
+	if len(commands) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, commands)
+	}
+	for i := range expected {
+		if commands[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], commands[i])
+		}
+	}
+	if exitCodes[0] != 0 || exitCodes[1] == 0 {
+		t.Errorf("unexpected exit codes: %v", exitCodes)
+	}
+	// local copies are observed too
+	localTarget := NewLocalTarget("hostname", "")
+	commands = nil
+	localTarget.SetCommandObserver(func(target Target, command string, start time.Time, end time.Time, exitCode int) {
+		commands = append(commands, command)
+	})
+	src := filepath.Join(dir, "scp")
+	if err := localTarget.PullFile(src, t.TempDir()); err != nil || len(commands) != 1 || !strings.HasPrefix(commands[0], "cp "+src) {
+		t.Errorf("unexpected local copy: %v, %v", commands, err)
+	}
+}
+
+func TestRunCommandStdin(t *testing.T) {
+	// the command's stdin is kept when it runs with a timeout
+	for _, timeout := range []int{0, 10} {
+		cmd := exec.Command("cat")
+		cmd.Stdin = strings.NewReader("secret\n")
+		stdout, _, _, err := RunLocalCommandWithTimeout(cmd, timeout)
+		if err != nil || stdout != "secret\n" {
+			t.Errorf("timeout %d: unexpected output: %s, %v", timeout, stdout, err)
+		}
+	}
+}
+
+func TestCanElevatePrivileges(t *testing.T) {
+	// ssh runs the remote command locally, as a user whose sudo password is "it's $(x)"
+	dir := t.TempDir()
+	for name, script := range map[string]string{
+		"ssh":  "#!/bin/sh\nwhile [ \"$1\" != -- ]; do shift; done\nshift\nexec sh -c \"$*\"\n",
+		"id":   "#!/bin/sh\necho 1000\n",
+		"sudo": "#!/bin/sh\nIFS= read -r password\n[ \"$password\" = 'it'\\''s $(x)' ]\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	remoteTarget := NewRemoteTarget("label", "host", "22", "user", "key", "", "", "it's $(x)")
+	var commands []string
+	remoteTarget.SetCommandObserver(func(target Target, command string, start time.Time, end time.Time, exitCode int) {
+		commands = append(commands, command)
+	})
+	if !remoteTarget.CanElevatePrivileges() {
+		t.Error("sudo password not accepted")
+	}
+	remoteTarget.SetSudo("wrong")
+	if remoteTarget.CanElevatePrivileges() {
+		t.Error("wrong sudo password accepted")
+	}
+	for _, command := range commands {
+		if strings.Contains(command, "it's") || strings.Contains(command, "wrong") {
+			t.Errorf("password on the command line: %s", command)
+		}
+	}
+}
+
+func TestMaskPasswords(t *testing.T) {
+	for command, expected := range map[string]string{
+		"SUDO_PASSWORD=secret collector":                              "SUDO_PASSWORD=************* collector",
+		"SUDO_PASSWORD='se cr;et' collector":                          "SUDO_PASSWORD=************* collector",
+		`SUDO_PASSWORD='it'\''s secret' REDFISH_PASSWORD=x collector`: "SUDO_PASSWORD=************* REDFISH_PASSWORD=************* collector",
+	} {
+		if masked := maskPasswords(command); masked != expected {
+			t.Errorf("expected %s, got %s", expected, masked)
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	remoteTarget := NewRemoteTarget("label", "host.example.com", "22", "user", "key", "", "", "")
+	if !strings.Contains(strings.Join(remoteTarget.getSSHFlags(false), " "), "GSSAPIAuthentication=no") {
+		t.Error("GSSAPI enabled by default")
+	}
+	if err := remoteTarget.SetAuth("telnet"); err == nil {
+		t.Error("unsupported authentication method accepted")
+	}
+	if err := remoteTarget.SetAuth(AuthGSSAPI); err != nil {
+		t.Fatal(err)
+	}
+	flags := strings.Join(remoteTarget.getSSHFlags(false), " ")
+	if !strings.Contains(flags, "GSSAPIAuthentication=yes") || !strings.Contains(flags, "PreferredAuthentications=gssapi-with-mic") {
+		t.Errorf("GSSAPI not preferred: %s", flags)
+	}
+	if strings.Contains(flags, "-i key") {
+		t.Errorf("key provided: %s", flags)
+	}
+	if err := NewRemoteTarget("label", "192.168.1.1", "22", "user", "", "", "", "").SetAuth(AuthGSSAPI); err == nil {
+		t.Error("GSSAPI accepted for an IP address")
+	}
+}
+
+func TestFIPS(t *testing.T) {
+	remoteTarget := NewRemoteTarget("label", "hostname", "22", "user", "key", "", "", "")
+	if strings.Contains(strings.Join(remoteTarget.getSSHFlags(false), " "), "Ciphers=") {
+		t.Error("ciphers restricted by default")
+	}
+	remoteTarget.SetFIPS(true)
+	flags := strings.Join(remoteTarget.getSSHFlags(true), " ")
+	for _, option := range []string{"Ciphers=aes256-gcm@openssh.com,", "MACs=hmac-sha2-256-etm@openssh.com,", "KexAlgorithms=ecdh-sha2-nistp256,", "PubkeyAcceptedKeyTypes=ecdsa-sha2-nistp256,"} {
+		if !strings.Contains(flags, option) {
+			t.Errorf("%s not set: %s", option, flags)
+		}
+	}
+	if strings.Contains(flags, "chacha20") || strings.Contains(flags, "curve25519") || strings.Contains(flags, "ed25519") {
+		t.Errorf("algorithm that isn't FIPS approved offered: %s", flags)
+	}
+	saved := fipsEnabledPath
+	defer func() { fipsEnabledPath = saved }()
+	dir := t.TempDir()
+	fipsEnabledPath = filepath.Join(dir, "fips_enabled")
+	if err := CheckFIPSMode(); err == nil {
+		t.Error("FIPS mode detected without fips_enabled")
+	}
+	if err := os.WriteFile(fipsEnabledPath, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckFIPSMode(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplayTarget(t *testing.T) {
+	results := []map[string]string{
+		{"label": "uname -a", "command": "uname -a", "stdout": "Linux host 6.1.0 #1 SMP aarch64 GNU/Linux", "stderr": "", "exitstatus": "0"},
+		{"label": "lscpu", "command": "lscpu", "stdout": "Architecture: aarch64", "stderr": "", "exitstatus": "0"},
+	}
+	replayTarget := NewReplayTarget("captured", results)
+	var _ Target = replayTarget
+	if replayTarget.GetName() != "captured" {
+		t.Errorf("unexpected name: %s", replayTarget.GetName())
+	}
+	arch, err := replayTarget.GetArchitecture()
+	if err != nil || arch != "aarch64" {
+		t.Errorf("unexpected architecture: %s, %v", arch, err)
+	}
+	result, ok := replayTarget.GetResult("lscpu")
+	if !ok || result["stdout"] != "Architecture: aarch64" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	result["stdout"] = "changed"
+	if result, _ = replayTarget.GetResult("lscpu"); result["stdout"] != "Architecture: aarch64" {
+		t.Error("captured result modified through copy")
+	}
+	if _, ok = replayTarget.GetResult("dmidecode"); ok {
+		t.Error("result returned for command that wasn't captured")
+	}
+	// commands aren't run
+	stdout, _, exitCode, err := replayTarget.RunCommand(exec.Command("false"))
+	if stdout != "" || exitCode != 0 || err != nil {
+		t.Errorf("command run on replay target: %s, %d, %v", stdout, exitCode, err)
+	}
+	// files are pushed to and pulled from a local directory
+	tempDir, err := replayTarget.CreateTempDirectory(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "file")
+	if err = os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = replayTarget.PushFile(src, tempDir); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(tempDir, "file")); err != nil || string(content) != "content" {
+		t.Errorf("file not pushed: %s, %v", content, err)
+	}
+	if arch, _ = NewReplayTarget("x", nil).GetArchitecture(); arch != "x86_64" {
+		t.Errorf("unexpected default architecture: %s", arch)
+	}
+}
+
+func TestJumpHost(t *testing.T) {
+	for _, jumpHost := range []string{"bastion", "admin@bastion.example.com", "bastion:2222", "admin@10.0.0.1:22"} {
+		if err := ValidateJumpHost(jumpHost); err != nil {
+			t.Errorf("valid jump host rejected: %s, %v", jumpHost, err)
+		}
+	}
+	for _, jumpHost := range []string{"@bastion", "bastion:ssh", "bastion:0", "admin@", "ssh://bastion", "-oProxyCommand=touch", "admin@-oProxyCommand=touch", "-admin@bastion"} {
+		if err := ValidateJumpHost(jumpHost); err == nil {
+			t.Errorf("invalid jump host accepted: %s", jumpHost)
+		}
+	}
+	remoteTarget := NewRemoteTarget("label", "hostname", "22", "user", "/keys/it's%key", "", "", "")
+	if err := remoteTarget.SetJumpHost("admin@bastion:2222"); err != nil {
+		t.Fatal(err)
+	}
+	flags := strings.Join(remoteTarget.getSSHFlags(true), " ")
+	if !strings.Contains(flags, `ProxyCommand='ssh' `) ||
+		!strings.Contains(flags, `'-i' '/keys/it'\''s%%key' '-p' '2222' '-l' 'admin' 'bastion' -W %h:%p`) {
+		t.Errorf("jump host not set: %s", flags)
+	}
+	remoteTarget.jumpHost = ""
+	remoteTarget.proxy, _ = ParseProxy("socks5://proxy.example.com:1080")
+	if err := remoteTarget.SetJumpHost("bastion"); err == nil {
+		t.Error("jump host accepted with proxy")
+	}
+}
+
+func TestControlPath(t *testing.T) {
+	remoteTarget := NewRemoteTarget("label", "hostname", "22", "user", "", "", "", "")
+	if path := remoteTarget.getControlPath(); path != filepath.Join(os.TempDir(), "svr-info-%C") {
+		t.Errorf("unexpected default control path: %s", path)
+	}
+	remoteTarget.SetControlDir("/tmp/svr-info.tmp.123")
+	if !strings.Contains(strings.Join(remoteTarget.getSSHFlags(false), " "), "ControlPath=/tmp/svr-info.tmp.123/%C") {
+		t.Errorf("control path not set: %v", remoteTarget.getSSHFlags(false))
+	}
+	// too long for a socket
+	remoteTarget.SetControlDir("/" + strings.Repeat("d", 60))
+	if path := remoteTarget.getControlPath(); path != filepath.Join(os.TempDir(), "svr-info-%C") {
+		t.Errorf("unexpected control path: %s", path)
+	}
+}
+
+func TestWindowsTarget(t *testing.T) {
+	windowsTarget := NewWindowsTarget("label", "hostname", "22", "user", "key", "", "")
+	var _ Target = windowsTarget
+	windowsTarget.SetSudo("sudo")
+	if windowsTarget.GetSudo() != "" {
+		t.Error("sudo set on Windows target")
+	}
+	script := "Get-CimInstance Win32_Processor | Select-Object -ExpandProperty Name # 'é'"
+	command := getPowerShellCommand(script)
+	if command[0] != "powershell" || command[len(command)-2] != "-EncodedCommand" {
+		t.Fatalf("unexpected command: %v", command)
+	}
+	encoded, err := base64.StdEncoding.DecodeString(command[len(command)-1])
+	if err != nil || len(encoded)%2 != 0 {
+		t.Fatalf("invalid encoding: %v", err)
+	}
+	var decoded []uint16
+	for i := 0; i < len(encoded); i += 2 {
+		decoded = append(decoded, uint16(encoded[i])|uint16(encoded[i+1])<<8)
+	}
+	if string(utf16.Decode(decoded)) != script {
+		t.Errorf("unexpected decoded script: %s", string(utf16.Decode(decoded)))
+	}
+	if _, _, _, err = windowsTarget.RunCommand(exec.Command(strings.Repeat("#", MaxPowerShellScriptLength+1))); err == nil {
+		t.Error("script longer than the maximum accepted")
+	}
+	if quoted := quotePowerShell(`C:\Users\o'brien`); quoted != `'C:\Users\o''brien'` {
+		t.Errorf("unexpected quoting: %s", quoted)
+	}
+}
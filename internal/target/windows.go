@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2023 Intel Corporation
+ * SPDX-License-Identifier: MIT
+ */
+package target
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// WindowsTarget is a remote Windows host that's reached with SSH, i.e., the OpenSSH
+// server that ships with Windows. Commands are PowerShell scripts, they're run by
+// powershell.exe whatever the user's default shell is. The collector doesn't run on
+// Windows, so the caller runs the data collection commands one at a time.
+type WindowsTarget struct {
+	RemoteTarget
+}
+
+// NewWindowsTarget creates a Windows target, the arguments are those of NewRemoteTarget,
+// but there's no sudo, the user must be an administrator to collect all data
+func NewWindowsTarget(name string, host string, port string, user string, key string, pass string, sshpassPath string) *WindowsTarget {
+	t := WindowsTarget{RemoteTarget: *NewRemoteTarget(name, host, port, user, key, pass, sshpassPath, "")}
+	return &t
+}
+
+// MaxPowerShellScriptLength is the length, in UTF-16 code units, of the longest script
+// that can be run on a Windows target. The encoded script must fit on the command line of
+// cmd.exe, the Windows OpenSSH server's default shell, which is at most 8191 characters.
+const MaxPowerShellScriptLength = 3000
+
+// getPowerShellCommand returns the command that runs the script with PowerShell. The
+// script is encoded so that it isn't altered by the remote shell's quoting rules.
+func getPowerShellCommand(script string) []string {
+	utf16Script := utf16.Encode([]rune(script))
+	scriptBytes := make([]byte, 2*len(utf16Script))
+	for i, c := range utf16Script {
+		binary.LittleEndian.PutUint16(scriptBytes[2*i:], c)
+	}
+	return []string{"powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-EncodedCommand", base64.StdEncoding.EncodeToString(scriptBytes)}
+}
+
+// quotePowerShell returns the string as a PowerShell single-quoted string
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// RunCommandWithTimeout runs the command's arguments, joined with spaces, as a PowerShell
+// script
+func (t *WindowsTarget) RunCommandWithTimeout(cmd *exec.Cmd, timeout int) (stdout string, stderr string, exitCode int, err error) {
+	script := strings.Join(cmd.Args, " ")
+	if length := len(utf16.Encode([]rune(script))); length > MaxPowerShellScriptLength {
+		err = fmt.Errorf("script is too long to run on Windows target %s, %d characters, the maximum is %d", t.GetName(), length, MaxPowerShellScriptLength)
+		return
+	}
+	stdout, stderr, exitCode, err = t.runRemoteCommand(getPowerShellCommand(script), nil, script, timeout)
+	// PowerShell's output has Windows line endings
+	stdout = strings.ReplaceAll(stdout, "\r\n", "\n")
+	stderr = strings.ReplaceAll(stderr, "\r\n", "\n")
+	return
+}
+
+func (t *WindowsTarget) RunCommand(cmd *exec.Cmd) (stdout string, stderr string, exitCode int, err error) {
+	return t.RunCommandWithTimeout(cmd, 0)
+}
+
+// GetArchitecture returns the processor architecture with the names uname uses, e.g.,
+// x86_64 rather than AMD64
+func (t *WindowsTarget) GetArchitecture() (arch string, err error) {
+	if t.arch != "" {
+		arch = t.arch
+		return
+	}
+	arch, _, _, err = t.RunCommand(exec.Command("$env:PROCESSOR_ARCHITECTURE"))
+	if err != nil {
+		return
+	}
+	switch arch = strings.TrimSpace(arch); arch {
+	case "AMD64":
+		arch = "x86_64"
+	case "ARM64":
+		arch = "aarch64"
+	}
+	t.arch = arch
+	return
+}
+
+// CreateTempDirectory creates a temporary directory on the target in the directory
+// specified by rootDir. If rootDir is an empty string, the temporary directory will be
+// created in the user's directory for temporary files, i.e., $env:TEMP.
+// The full path to the temporary directory is returned.
+func (t *WindowsTarget) CreateTempDirectory(rootDir string) (tempDir string, err error) {
+	root := "[IO.Path]::GetTempPath()"
+	if rootDir != "" {
+		root = quotePowerShell(rootDir)
+	}
+	script := fmt.Sprintf("$dir = Join-Path %s ('%s.tmp.' + [guid]::NewGuid().ToString('N').Substring(0, 10)); (New-Item -ItemType Directory -Path $dir -ErrorAction Stop).FullName", root, filepath.Base(os.Args[0]))
+	tempDir, _, _, err = t.RunCommand(exec.Command(script))
+	tempDir = strings.TrimSpace(tempDir)
+	return
+}
+
+// PushFile copies the file to the directory on the target. Windows paths are given to
+// scp with forward slashes, e.g., C:/Users/user/AppData/Local/Temp.
+func (t *WindowsTarget) PushFile(srcPath string, dstDir string) (err error) {
+	return t.RemoteTarget.PushFile(srcPath, strings.ReplaceAll(dstDir, `\`, "/"))
+}
+
+// PullFile copies the file on the target to the local directory
+func (t *WindowsTarget) PullFile(srcPath string, dstDir string) (err error) {
+	return t.RemoteTarget.PullFile(strings.ReplaceAll(srcPath, `\`, "/"), dstDir)
+}
+
+func (t *WindowsTarget) CreateDirectory(baseDir string, targetDir string) (dir string, err error) {
+	dir = strings.TrimRight(baseDir, `\/`) + `\` + targetDir
+	script := fmt.Sprintf("New-Item -ItemType Directory -Path %s -ErrorAction Stop | Out-Null", quotePowerShell(dir))
+	_, _, _, err = t.RunCommand(exec.Command(script))
+	return
+}
+
+func (t *WindowsTarget) RemoveDirectory(targetDir string) (err error) {
+	script := fmt.Sprintf("Remove-Item -LiteralPath %s -Recurse -Force -ErrorAction Stop", quotePowerShell(targetDir))
+	_, _, _, err = t.RunCommand(exec.Command(script))
+	return
+}
+
+func (t *WindowsTarget) CanConnect() bool {
+	_, _, _, err := t.RunCommandWithTimeout(exec.Command("exit 0"), 10)
+	return err == nil
+}
+
+// CanElevatePrivileges returns true if the user is an administrator on the target and
+// the session is elevated, as SSH sessions of administrators are
+func (t *WindowsTarget) CanElevatePrivileges() bool {
+	script := "if (-not ([Security.Principal.WindowsPrincipal][Security.Principal.WindowsIdentity]::GetCurrent()).IsInRole([Security.Principal.WindowsBuiltInRole]::Administrator)) { exit 1 }"
+	_, _, _, err := t.RunCommand(exec.Command(script))
+	return err == nil
+}
+
+// SetSudo is ignored, there's no sudo on Windows
+func (t *WindowsTarget) SetSudo(sudo string) {
+}
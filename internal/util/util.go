@@ -8,7 +8,10 @@ Package util includes utility/helper functions that may be useful to other modul
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"os/user"
@@ -55,6 +58,21 @@ func FileExists(path string) (exists bool, err error) {
 	return
 }
 
+// FileSHA256 returns the hex encoded SHA-256 hash of the file's content
+func FileSHA256(path string) (hash string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	return
+}
+
 // DirectoryExists returns whether the given directory (not a file) exists
 func DirectoryExists(path string) (exists bool, err error) {
 	var fileInfo fs.FileInfo
@@ -88,6 +106,11 @@ func StringIndexInList(s string, l []string) (idx int, err error) {
 	return
 }
 
+// ShellQuote quotes the string for use as a single word in a shell command
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // StringInList confirms if string is in list of strings
 func StringInList(s string, l []string) bool {
 	for _, item := range l {